@@ -257,6 +257,7 @@ type TrustedAssetsInstallObserver interface {
 	BootLoaderSupportsEfiVariables() bool
 	ObserveExistingTrustedRecoveryAssets(recoveryRootDir string) error
 	ChosenEncryptionKeys(key, saveKey keys.EncryptionKey)
+	ChosenVolumesAuth(volumesAuth *device.VolumesAuthOptions)
 	UpdateBootEntry() error
 	Observe(op gadget.ContentOperation, partRole, root, relativeTarget string, data *gadget.ContentChange) (gadget.ContentChangeAction, error)
 }
@@ -282,6 +283,7 @@ type trustedAssetsInstallObserverImpl struct {
 	useEncryption     bool
 	dataEncryptionKey keys.EncryptionKey
 	saveEncryptionKey keys.EncryptionKey
+	volumesAuth       *device.VolumesAuthOptions
 
 	seedBootloader bootloader.Bootloader
 }
@@ -374,6 +376,10 @@ func (o *trustedAssetsInstallObserverImpl) ChosenEncryptionKeys(key, saveKey key
 	o.saveEncryptionKey = saveKey
 }
 
+func (o *trustedAssetsInstallObserverImpl) ChosenVolumesAuth(volumesAuth *device.VolumesAuthOptions) {
+	o.volumesAuth = volumesAuth
+}
+
 func (o *trustedAssetsInstallObserverImpl) UpdateBootEntry() error {
 	if o.seedBootloader == nil {
 		return nil
@@ -776,6 +782,11 @@ func (o *TrustedAssetsUpdateObserver) BeforeWrite() error {
 		// boot assets was updated
 		return nil
 	}
+	// check that the keys can be resealed to the boot chains with
+	// the new assets before resealing any of them
+	if err := resealKeyToModeenvDryRun(dirs.GlobalRootDir, o.modeenv); err != nil {
+		return err
+	}
 	const expectReseal = true
 	if err := resealKeyToModeenv(dirs.GlobalRootDir, o.modeenv, expectReseal, nil); err != nil {
 		return err
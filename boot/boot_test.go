@@ -73,6 +73,13 @@ func (s *baseBootenvSuite) SetUpTest(c *C) {
 	s.cmdlineFile = filepath.Join(c.MkDir(), "cmdline")
 	restore = kcmdline.MockProcCmdline(s.cmdlineFile)
 	s.AddCleanup(restore)
+
+	// building the PCR protection profile requires EFI variables and
+	// real EFI images
+	restore = boot.MockSecbootBuildPCRProtectionProfile(func(modelParams []*secboot.SealKeyModelParams) (string, error) {
+		return "mock-profile", nil
+	})
+	s.AddCleanup(restore)
 }
 
 func (s *baseBootenvSuite) forceBootloader(bloader bootloader.Bootloader) {
@@ -1136,10 +1143,13 @@ func (s *bootenv20Suite) TestCoreParticipant20SetNextNewKernelSnapWithReseal(c *
 				// kernel this will use candidate kernel
 				secboot.NewLoadChain(runKernelBf)),
 		})
-		// actual paths are seen only here
+		// actual paths are seen only here, the boot chains were
+		// built first for the reseal dry run
 		c.Check(tab.BootChainKernelPath, DeepEquals, []string{
 			s.kern1.MountFile(),
 			s.kern2.MountFile(),
+			s.kern1.MountFile(),
+			s.kern2.MountFile(),
 		})
 		return nil
 	})
@@ -1177,6 +1187,105 @@ func (s *bootenv20Suite) TestCoreParticipant20SetNextNewKernelSnapWithReseal(c *
 	c.Check(resealCalls, Equals, 1)
 }
 
+func (s *bootenv20Suite) TestCoreParticipant20SetNextNewKernelSnapResealDryRunFails(c *C) {
+	// checked by resealKeyToModeenv
+	s.stampSealedKeys(c, dirs.GlobalRootDir)
+
+	tab := s.bootloaderWithTrustedAssets(c, map[string]string{
+		"asset": "asset",
+	})
+
+	data := []byte("foobar")
+	// SHA3-384
+	dataHash := "0fa8abfbdaf924ad307b74dd2ed183b9a4a398891a2f6bac8fd2db7041b77f068580f9c6c66f699b496c2da1cbcc7ed8"
+
+	c.Assert(os.MkdirAll(filepath.Join(boot.InitramfsUbuntuBootDir), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(boot.InitramfsUbuntuSeedDir), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(boot.InitramfsUbuntuBootDir, "asset"), data, 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(boot.InitramfsUbuntuSeedDir, "asset"), data, 0644), IsNil)
+
+	// mock the files in cache
+	mockAssetsCache(c, dirs.GlobalRootDir, "trusted", []string{
+		"asset-" + dataHash,
+	})
+
+	runKernelBf := bootloader.NewBootFile(filepath.Join(s.kern1.Filename()), "kernel.efi", bootloader.RoleRunMode)
+
+	tab.BootChainList = []bootloader.BootFile{
+		bootloader.NewBootFile("", "asset", bootloader.RoleRunMode),
+		runKernelBf,
+	}
+
+	coreDev := boottest.MockUC20Device("", nil)
+	c.Assert(coreDev.HasModeenv(), Equals, true)
+	model := coreDev.Model()
+
+	m := &boot.Modeenv{
+		Mode:           "run",
+		Base:           s.base1.Filename(),
+		CurrentKernels: []string{s.kern1.Filename()},
+		CurrentTrustedBootAssets: boot.BootAssetsMap{
+			"asset": []string{dataHash},
+		},
+		Model:          model.Model(),
+		BrandID:        model.BrandID(),
+		Grade:          string(model.Grade()),
+		ModelSignKeyID: model.SignKeyID(),
+	}
+
+	r := setupUC20Bootenv(
+		c,
+		tab.MockBootloader,
+		&bootenv20Setup{
+			modeenv:    m,
+			kern:       s.kern1,
+			kernStatus: boot.DefaultStatus,
+		},
+	)
+	defer r()
+
+	profileCalls := 0
+	restore := boot.MockSecbootBuildPCRProtectionProfile(func(modelParams []*secboot.SealKeyModelParams) (string, error) {
+		profileCalls++
+		c.Assert(modelParams, HasLen, 1)
+		c.Check(modelParams[0].Model.Model(), Equals, model.Model())
+		// the candidate kernel is part of the boot chains
+		c.Check(tab.BootChainKernelPath, DeepEquals, []string{
+			s.kern1.MountFile(),
+			s.kern2.MountFile(),
+		})
+		return "", fmt.Errorf("mocked profile error")
+	})
+	defer restore()
+
+	restore = boot.MockSecbootResealKeys(func(params *secboot.ResealKeysParams) error {
+		c.Fatalf("unexpected reseal")
+		return nil
+	})
+	defer restore()
+
+	bootKern := boot.Participant(s.kern2, snap.TypeKernel, coreDev)
+	c.Assert(bootKern.IsTrivial(), Equals, false)
+
+	_, err := bootKern.SetNextBoot(boot.NextBootContext{BootWithoutTry: false})
+	c.Assert(err, ErrorMatches, "cannot set next boot: cannot reseal the encryption keys: cannot build the PCR protection profile for the run key: mocked profile error")
+	c.Check(profileCalls, Equals, 1)
+
+	// the boot environment was not touched
+	bvars, err := tab.GetBootVars("kernel_status", "snap_kernel", "snap_try_kernel")
+	c.Assert(err, IsNil)
+	c.Assert(bvars, DeepEquals, map[string]string{
+		"kernel_status":   boot.DefaultStatus,
+		"snap_kernel":     s.kern1.Filename(),
+		"snap_try_kernel": "",
+	})
+
+	// and neither was the modeenv
+	m2, err := boot.ReadModeenv("")
+	c.Assert(err, IsNil)
+	c.Assert(m2.CurrentKernels, DeepEquals, []string{s.kern1.Filename()})
+}
+
 func (s *bootenv20Suite) TestCoreParticipant20SetNextNewUnassertedKernelSnapWithReseal(c *C) {
 	// checked by resealKeyToModeenv
 	s.stampSealedKeys(c, dirs.GlobalRootDir)
@@ -1256,10 +1365,13 @@ func (s *bootenv20Suite) TestCoreParticipant20SetNextNewUnassertedKernelSnapWith
 				// kernel this will use candidate kernel
 				secboot.NewLoadChain(runKernelBf)),
 		})
-		// actual paths are seen only here
+		// actual paths are seen only here, the boot chains were
+		// built first for the reseal dry run
 		c.Check(tab.BootChainKernelPath, DeepEquals, []string{
 			s.ukern1.MountFile(),
 			s.ukern2.MountFile(),
+			s.ukern1.MountFile(),
+			s.ukern2.MountFile(),
 		})
 		return nil
 	})
@@ -2259,7 +2371,8 @@ func (s *bootenv20Suite) TestMarkBootSuccessful20KernelUpdateWithReseal(c *C) {
 		"snap_try_kernel": boot.DefaultStatus,
 	}
 	c.Assert(tab.BootVars, DeepEquals, expected)
-	c.Check(tab.BootChainKernelPath, DeepEquals, []string{s.kern2.MountFile()})
+	// boot chains were built for the reseal dry run and the reseal
+	c.Check(tab.BootChainKernelPath, DeepEquals, []string{s.kern2.MountFile(), s.kern2.MountFile()})
 
 	// check that the new kernel is the only one in modeenv
 	m2, err := boot.ReadModeenv("")
@@ -2670,9 +2783,10 @@ func (s *bootenv20Suite) TestMarkBootSuccessful20BootAssetsStableStateHappy(c *C
 		filepath.Join(dirs.SnapBootAssetsDir, "trusted", "shim-"+shimHash),
 	})
 
-	// boot chains were built
+	// boot chains were built for the reseal dry run and the reseal
 	c.Check(tab.BootChainKernelPath, DeepEquals, []string{
 		s.kern1.MountFile(),
+		s.kern1.MountFile(),
 	})
 	// no actual reseal
 	c.Check(resealCalls, Equals, 0)
@@ -4869,9 +4983,11 @@ func (s *bootenv20Suite) TestCoreParticipant20UndoKernelSnapInstallNewWithReseal
 			secboot.NewLoadChain(assetBf,
 				secboot.NewLoadChain(runKernelBf)),
 		})
-		// actual paths are seen only here
+		// actual paths are seen only here, the boot chains were
+		// built first for the reseal dry run
 		c.Check(tab.BootChainKernelPath, DeepEquals, []string{
 			s.kern2.MountFile(),
+			s.kern2.MountFile(),
 		})
 		return nil
 	})
@@ -4983,9 +5099,11 @@ func (s *bootenv20Suite) TestCoreParticipant20UndoUnassertedKernelSnapInstallNew
 			secboot.NewLoadChain(assetBf,
 				secboot.NewLoadChain(runKernelBf)),
 		})
-		// actual paths are seen only here
+		// actual paths are seen only here, the boot chains were
+		// built first for the reseal dry run
 		c.Check(tab.BootChainKernelPath, DeepEquals, []string{
 			s.ukern2.MountFile(),
+			s.ukern2.MountFile(),
 		})
 		return nil
 	})
@@ -248,6 +248,8 @@ func predictableBootChainsEqualForReseal(pb1, pb2 predictableBootChains) bootCha
 // copied first to the disk, so booting from the new shim to the old
 // grub is not possible. This is controlled by expectNew, that tells
 // us that the previous step in the chain is from a new asset.
+// Images that follow an asset of a bootloader which does not chain
+// through shim, like systemd-boot, are marked as loaded by the firmware.
 func bootAssetsToLoadChains(assets []bootAsset, kernelBootFile bootloader.BootFile, roleToBlName map[bootloader.Role]string, expectNew bool) ([]*secboot.LoadChain, error) {
 	// kernel is added after all the assets
 	addKernelBootFile := len(assets) == 0
@@ -298,6 +300,11 @@ func bootAssetsToLoadChains(assets []bootAsset, kernelBootFile bootloader.BootFi
 		if err != nil {
 			return nil, err
 		}
+		if blName == "systemd-boot" {
+			for _, nextChain := range next {
+				nextChain.LoadedByFirmware = true
+			}
+		}
 		chains = append(chains, secboot.NewLoadChain(bf, next...))
 	}
 	return chains, nil
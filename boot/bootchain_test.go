@@ -1057,6 +1057,34 @@ func (s *bootchainSuite) TestBootAssetsToLoadChainSimpleChain(c *C) {
 	c.Check(chains, DeepEquals, expected)
 }
 
+func (s *bootchainSuite) TestBootAssetsToLoadChainSystemdBoot(c *C) {
+	kbl := bootloader.NewBootFile("pc-kernel", "kernel.efi", bootloader.RoleRunMode)
+
+	assets := []boot.BootAsset{
+		{Name: "bootx64.efi", Hashes: []string{"hash0"}, Role: bootloader.RoleRecovery},
+	}
+
+	p := filepath.Join(dirs.SnapBootAssetsDir, "systemd-boot/bootx64.efi-hash0")
+	c.Assert(os.MkdirAll(filepath.Dir(p), 0755), IsNil)
+	c.Assert(os.WriteFile(p, nil, 0644), IsNil)
+
+	blNames := map[bootloader.Role]string{
+		bootloader.RoleRecovery: "systemd-boot",
+	}
+
+	chains, err := boot.BootAssetsToLoadChains(assets, kbl, blNames, false)
+	c.Assert(err, IsNil)
+
+	// the kernel is loaded by systemd-boot through the firmware
+	kernelChain := secboot.NewLoadChain(nbf("pc-kernel", "kernel.efi", bootloader.RoleRunMode))
+	kernelChain.LoadedByFirmware = true
+	expected := []*secboot.LoadChain{
+		secboot.NewLoadChain(nbf("", cPath("systemd-boot/bootx64.efi-hash0"), bootloader.RoleRecovery),
+			kernelChain),
+	}
+	c.Check(chains, DeepEquals, expected)
+}
+
 func (s *bootchainSuite) TestBootAssetsToLoadChainWithAlternativeChains(c *C) {
 	kbl := bootloader.NewBootFile("pc-kernel", "kernel.efi", bootloader.RoleRunMode)
 
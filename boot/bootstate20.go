@@ -200,6 +200,16 @@ func (u20 *bootStateUpdate20) commit() error {
 	// 2. Add tasks to run before writing the modeenv.
 	// 3. Add tasks to run after writing the modeenv.
 
+	modeenvChanged := !u20.writeModeenv.deepEqual(u20.modeenv)
+	if modeenvChanged {
+		// refuse to proceed if the encryption keys cannot be
+		// resealed to the boot chains of the new modeenv, before
+		// anything is changed on disk
+		if err := resealKeyToModeenvDryRun(dirs.GlobalRootDir, u20.writeModeenv); err != nil {
+			return err
+		}
+	}
+
 	// first handle any pre-modeenv writing tasks
 	for _, t := range u20.preModeenvTasks {
 		if err := t(); err != nil {
@@ -209,7 +219,7 @@ func (u20 *bootStateUpdate20) commit() error {
 
 	expectReseal := false
 	// next write the modeenv if it changed
-	if !u20.writeModeenv.deepEqual(u20.modeenv) {
+	if modeenvChanged {
 		if err := u20.writeModeenv.Write(); err != nil {
 			return err
 		}
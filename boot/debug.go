@@ -1,7 +1,7 @@
 // -*- Mode: Go; indent-tabs-mode: t -*-
 
 /*
- * Copyright (C) 2019 Canonical Ltd
+ * Copyright (C) 2019-2026 Canonical Ltd
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License version 3 as
@@ -26,6 +26,7 @@ import (
 
 	"github.com/snapcore/snapd/bootloader"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/osutil"
 )
 
@@ -136,3 +137,97 @@ func DebugSetBootVars(dir string, recoveryBootloader bool, varEqVal []string) er
 	}
 	return bloader.SetBootVars(toSet)
 }
+
+// DebugDumpBootChains writes the boot chains the encryption keys are
+// currently sealed against, the candidate boot chains derived from the
+// current modeenv and whether the keys can be resealed to the latter, to
+// the given writer.
+func DebugDumpBootChains(w io.Writer) error {
+	method, err := device.SealedKeysMethod(dirs.GlobalRootDir)
+	if err == device.ErrNoSealedKeys {
+		return fmt.Errorf("cannot show boot chains: no sealed encryption keys")
+	}
+	if err != nil {
+		return err
+	}
+	if method != device.SealingMethodTPM && method != device.SealingMethodLegacyTPM {
+		return fmt.Errorf("cannot show boot chains: encryption keys sealed with %q do not use boot chains", method)
+	}
+
+	modeenv, err := ReadModeenv("")
+	if err != nil {
+		return err
+	}
+	current, resealCount, err := readBootChains(bootChainsFileUnder(dirs.GlobalRootDir))
+	if err != nil {
+		return err
+	}
+	currentFallback, fallbackResealCount, err := readBootChains(recoveryBootChainsFileUnder(dirs.GlobalRootDir))
+	if err != nil {
+		return err
+	}
+	// the candidate boot chains are the ones a reseal would use
+	// right now, they include any kernel, assets or recovery systems
+	// which are being tried
+	candidate, candidateErr := bootChainsForModeenv(modeenv)
+
+	var candidateRun, candidateFallback predictableBootChains
+	if candidate != nil {
+		candidateRun = candidate.runKey
+		candidateFallback = candidate.fallbackKey
+	}
+	dumpKeyBootChains(w, "run-key", resealCount, current, candidateRun, candidateErr)
+	dumpKeyBootChains(w, "fallback-key", fallbackResealCount, currentFallback, candidateFallback, candidateErr)
+
+	if candidateErr != nil {
+		fmt.Fprintf(w, "seal-check: error: %v\n", candidateErr)
+		return nil
+	}
+	if _, _, err := pcrProfilesForBootChains(candidate); err != nil {
+		fmt.Fprintf(w, "seal-check: error: %v\n", err)
+		return nil
+	}
+	fmt.Fprintf(w, "seal-check: ok\n")
+	return nil
+}
+
+func dumpKeyBootChains(w io.Writer, key string, resealCount int, current, candidate predictableBootChains, candidateErr error) {
+	fmt.Fprintf(w, "%s:\n", key)
+	fmt.Fprintf(w, "  reseal-count: %d\n", resealCount)
+	fmt.Fprintf(w, "  current:\n")
+	dumpBootChains(w, current)
+	if candidateErr != nil {
+		fmt.Fprintf(w, "  candidate: error: %v\n", candidateErr)
+		return
+	}
+	fmt.Fprintf(w, "  candidate:\n")
+	dumpBootChains(w, candidate)
+	needed := predictableBootChainsEqualForReseal(current, candidate) != bootChainEquivalent
+	fmt.Fprintf(w, "  reseal-needed: %v\n", needed)
+}
+
+func dumpBootChains(w io.Writer, pbc predictableBootChains) {
+	for _, bc := range pbc {
+		fmt.Fprintf(w, "  - model: %s/%s\n", bc.BrandID, bc.Model)
+		if bc.Classic {
+			fmt.Fprintf(w, "    classic: true\n")
+		}
+		fmt.Fprintf(w, "    grade: %s\n", bc.Grade)
+		fmt.Fprintf(w, "    model-sign-key-id: %s\n", bc.ModelSignKeyID)
+		fmt.Fprintf(w, "    kernel: %s\n", bc.Kernel)
+		fmt.Fprintf(w, "    kernel-revision: %s\n", bc.KernelRevision)
+		fmt.Fprintf(w, "    kernel-cmdlines:\n")
+		for _, cmdline := range bc.KernelCmdlines {
+			fmt.Fprintf(w, "    - %s\n", cmdline)
+		}
+		fmt.Fprintf(w, "    asset-chain:\n")
+		for _, asset := range bc.AssetChain {
+			fmt.Fprintf(w, "    - role: %s\n", asset.Role)
+			fmt.Fprintf(w, "      name: %s\n", asset.Name)
+			fmt.Fprintf(w, "      hashes:\n")
+			for _, hash := range asset.Hashes {
+				fmt.Fprintf(w, "      - %s\n", hash)
+			}
+		}
+	}
+}
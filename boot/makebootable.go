@@ -566,6 +566,7 @@ func makeRunnableSystem(model *asserts.Model, bootWith *BootableSet, observer Tr
 			FactoryReset:    makeOpts.AfterDataReset,
 			SeedDir:         makeOpts.SeedDir,
 			StateUnlocker:   makeOpts.StateUnlocker,
+			VolumesAuth:     observerImpl.volumesAuth,
 		}
 		if makeOpts.Standalone {
 			flags.SnapsDir = snapBlobDir
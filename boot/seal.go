@@ -48,6 +48,7 @@ var (
 	secbootSealKeys                  = secboot.SealKeys
 	secbootSealKeysWithFDESetupHook  = secboot.SealKeysWithFDESetupHook
 	secbootResealKeys                = secboot.ResealKeys
+	secbootBuildPCRProtectionProfile = secboot.BuildPCRProtectionProfile
 	secbootPCRHandleOfSealedKey      = secboot.PCRHandleOfSealedKey
 	secbootReleasePCRResourceHandles = secboot.ReleasePCRResourceHandles
 
@@ -80,6 +81,16 @@ func MockSecbootResealKeys(f func(params *secboot.ResealKeysParams) error) (rest
 	}
 }
 
+// MockSecbootBuildPCRProtectionProfile is only useful in testing.
+func MockSecbootBuildPCRProtectionProfile(f func(modelParams []*secboot.SealKeyModelParams) (string, error)) (restore func()) {
+	osutil.MustBeTestBinary("secbootBuildPCRProtectionProfile only can be mocked in tests")
+	old := secbootBuildPCRProtectionProfile
+	secbootBuildPCRProtectionProfile = f
+	return func() {
+		secbootBuildPCRProtectionProfile = old
+	}
+}
+
 // MockResealKeyToModeenv is only useful in testing.
 func MockResealKeyToModeenv(f func(rootdir string, modeenv *Modeenv, expectReseal bool, unlocker Unlocker) error) (restore func()) {
 	osutil.MustBeTestBinary("resealKeyToModeenv only can be mocked in tests")
@@ -124,6 +135,9 @@ type sealKeyToModeenvFlags struct {
 	SeedDir string
 	// Unlocker is used unlock the snapd state for long operations
 	StateUnlocker Unlocker
+	// VolumesAuth holds the authentication options chosen for the
+	// encrypted volumes, if any
+	VolumesAuth *device.VolumesAuthOptions
 }
 
 // sealKeyToModeenvImpl seals the supplied keys to the parameters specified
@@ -147,6 +161,10 @@ func sealKeyToModeenvImpl(key, saveKey keys.EncryptionKey, model *asserts.Model,
 		}
 	}
 
+	if flags.VolumesAuth != nil && flags.VolumesAuth.Mode == device.AuthModePassphrase {
+		return setupPassphraseAuth(model)
+	}
+
 	if flags.HasFDESetupHook {
 		return sealKeyToModeenvUsingFDESetupHook(key, saveKey, model, modeenv, flags)
 	}
@@ -158,6 +176,18 @@ func sealKeyToModeenvImpl(key, saveKey keys.EncryptionKey, model *asserts.Model,
 	return sealKeyToModeenvUsingSecboot(key, saveKey, model, modeenv, flags)
 }
 
+// setupPassphraseAuth prepares the system for encrypted volumes which
+// are unlocked with a user passphrase, in which case the keys are not
+// sealed at all, the initramfs is instead told to prompt the user.
+func setupPassphraseAuth(model *asserts.Model) error {
+	for _, dir := range []string{InitramfsBootEncryptionKeyDir, InitramfsSeedEncryptionKeyDir} {
+		if err := device.WritePassphraseAuthMarker(dir); err != nil {
+			return err
+		}
+	}
+	return device.StampSealedKeys(InstallHostWritableDir(model), device.SealingMethodPassphrase)
+}
+
 func runKeySealRequests(key keys.EncryptionKey) []secboot.SealKeyRequest {
 	return []secboot.SealKeyRequest{
 		{
@@ -437,6 +467,9 @@ func resealKeyToModeenvImpl(rootdir string, modeenv *Modeenv, expectReseal bool,
 	switch method {
 	case device.SealingMethodFDESetupHook:
 		return resealKeyToModeenvUsingFDESetupHook(rootdir, modeenv, expectReseal)
+	case device.SealingMethodPassphrase:
+		// keys are not sealed, nothing to do
+		return nil
 	case device.SealingMethodTPM, device.SealingMethodLegacyTPM:
 		if unlocker != nil {
 			// unlock/relock global state
@@ -448,6 +481,59 @@ func resealKeyToModeenvImpl(rootdir string, modeenv *Modeenv, expectReseal bool,
 	}
 }
 
+var resealKeyToModeenvDryRun = resealKeyToModeenvDryRunImpl
+
+// resealKeyToModeenvDryRun checks that the existing encryption keys could be
+// resealed to the parameters specified in modeenv, without resealing them.
+// It computes the boot chains and the PCR protection profiles the keys
+// would be sealed against, such that a boot configuration that cannot be
+// sealed is refused before it is committed to, instead of leaving the
+// device to fall back to the recovery key at the next boot.
+func resealKeyToModeenvDryRunImpl(rootdir string, modeenv *Modeenv) error {
+	method, err := device.SealedKeysMethod(rootdir)
+	if err == device.ErrNoSealedKeys {
+		// nothing to do
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if method != device.SealingMethodTPM && method != device.SealingMethodLegacyTPM {
+		// the boot chains are only relevant for TPM sealed keys
+		return nil
+	}
+	chains, err := bootChainsForModeenv(modeenv)
+	if err != nil {
+		return fmt.Errorf("cannot reseal the encryption keys: %v", err)
+	}
+	if _, _, err := pcrProfilesForBootChains(chains); err != nil {
+		return fmt.Errorf("cannot reseal the encryption keys: %v", err)
+	}
+	return nil
+}
+
+// pcrProfilesForBootChains builds the PCR protection profiles for the run
+// and fallback objects from the given boot chains.
+func pcrProfilesForBootChains(chains *sealingBootChains) (runProfile, fallbackProfile string, err error) {
+	modelParams, err := sealKeyModelParams(chains.runKey, chains.roleToBlName)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot prepare for key resealing: %v", err)
+	}
+	runProfile, err = secbootBuildPCRProtectionProfile(modelParams)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot build the PCR protection profile for the run key: %v", err)
+	}
+	modelParams, err = sealKeyModelParams(chains.fallbackKey, chains.roleToBlName)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot prepare for fallback key resealing: %v", err)
+	}
+	fallbackProfile, err = secbootBuildPCRProtectionProfile(modelParams)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot build the PCR protection profile for the fallback keys: %v", err)
+	}
+	return runProfile, fallbackProfile, nil
+}
+
 var resealKeyToModeenvUsingFDESetupHook = resealKeyToModeenvUsingFDESetupHookImpl
 
 func resealKeyToModeenvUsingFDESetupHookImpl(rootdir string, modeenv *Modeenv, expectReseal bool) error {
@@ -471,19 +557,34 @@ func resealKeyToModeenvUsingFDESetupHookImpl(rootdir string, modeenv *Modeenv, e
 	return nil
 }
 
+// sealingBootChains carries the boot chains that the run and fallback
+// objects are sealed against.
+type sealingBootChains struct {
+	// runKey are the predictable boot chains for the run object
+	runKey predictableBootChains
+	// fallbackKey are the predictable boot chains for the fallback
+	// object
+	fallbackKey predictableBootChains
+	// roleToBlName maps bootloader roles to the names of the
+	// bootloaders used to compose the boot chains
+	roleToBlName map[bootloader.Role]string
+}
+
+// bootChainsForModeenv composes the boot chains the encryption keys would be
+// sealed against for the given modeenv.
 // TODO:UC20: allow more than one model to accommodate the remodel scenario
-func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bool) error {
+func bootChainsForModeenv(modeenv *Modeenv) (*sealingBootChains, error) {
 	// build the recovery mode boot chain
 	rbl, err := bootloader.Find(InitramfsUbuntuSeedDir, &bootloader.Options{
 		Role: bootloader.RoleRecovery,
 	})
 	if err != nil {
-		return fmt.Errorf("cannot find the recovery bootloader: %v", err)
+		return nil, fmt.Errorf("cannot find the recovery bootloader: %v", err)
 	}
 	tbl, ok := rbl.(bootloader.TrustedAssetsBootloader)
 	if !ok {
 		// TODO:UC20: later the exact kind of bootloaders we expect here might change
-		return fmt.Errorf("internal error: sealed keys but not a trusted assets bootloader")
+		return nil, fmt.Errorf("internal error: sealed keys but not a trusted assets bootloader")
 	}
 	// derive the allowed modes for each system mentioned in the modeenv
 	modes := modesForSystems(modeenv)
@@ -496,7 +597,7 @@ func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bo
 	recoveryBootChainsForRunKey, err := recoveryBootChainsForSystems(modeenv.CurrentRecoverySystems, modes, tbl,
 		modeenv, includeTryModel, dirs.SnapSeedDir)
 	if err != nil {
-		return fmt.Errorf("cannot compose recovery boot chains for run key: %v", err)
+		return nil, fmt.Errorf("cannot compose recovery boot chains for run key: %v", err)
 	}
 
 	// the boot chains for recovery keys include only those system that were
@@ -514,7 +615,7 @@ func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bo
 	includeTryModel = false
 	recoveryBootChains, err := recoveryBootChainsForSystems(testedRecoverySystems, modes, tbl, modeenv, includeTryModel, dirs.SnapSeedDir)
 	if err != nil {
-		return fmt.Errorf("cannot compose recovery boot chains: %v", err)
+		return nil, fmt.Errorf("cannot compose recovery boot chains: %v", err)
 	}
 
 	// build the run mode boot chains
@@ -523,26 +624,38 @@ func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bo
 		NoSlashBoot: true,
 	})
 	if err != nil {
-		return fmt.Errorf("cannot find the bootloader: %v", err)
+		return nil, fmt.Errorf("cannot find the bootloader: %v", err)
 	}
 	cmdlines, err := kernelCommandLinesForResealWithFallback(modeenv)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	runModeBootChains, err := runModeBootChains(rbl, bl, modeenv, cmdlines, "")
 	if err != nil {
-		return fmt.Errorf("cannot compose run mode boot chains: %v", err)
+		return nil, fmt.Errorf("cannot compose run mode boot chains: %v", err)
 	}
 
-	roleToBlName := map[bootloader.Role]string{
-		bootloader.RoleRecovery: rbl.Name(),
-		bootloader.RoleRunMode:  bl.Name(),
+	return &sealingBootChains{
+		runKey:      toPredictableBootChains(append(runModeBootChains, recoveryBootChainsForRunKey...)),
+		fallbackKey: toPredictableBootChains(recoveryBootChains),
+		roleToBlName: map[bootloader.Role]string{
+			bootloader.RoleRecovery: rbl.Name(),
+			bootloader.RoleRunMode:  bl.Name(),
+		},
+	}, nil
+}
+
+func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bool) error {
+	chains, err := bootChainsForModeenv(modeenv)
+	if err != nil {
+		return err
 	}
+	roleToBlName := chains.roleToBlName
 	saveFDEDir := dirs.SnapFDEDirUnderSave(dirs.SnapSaveDirUnder(rootdir))
 	authKeyFile := filepath.Join(saveFDEDir, "tpm-policy-auth-key")
 
 	// reseal the run object
-	pbc := toPredictableBootChains(append(runModeBootChains, recoveryBootChainsForRunKey...))
+	pbc := chains.runKey
 
 	needed, nextCount, err := isResealNeeded(pbc, bootChainsFileUnder(rootdir), expectReseal)
 	if err != nil {
@@ -566,7 +679,7 @@ func resealKeyToModeenvSecboot(rootdir string, modeenv *Modeenv, expectReseal bo
 	}
 
 	// reseal the fallback object
-	rpbc := toPredictableBootChains(recoveryBootChains)
+	rpbc := chains.fallbackKey
 
 	var nextFallbackCount int
 	needed, nextFallbackCount, err = isResealNeeded(rpbc, recoveryBootChainsFileUnder(rootdir), expectReseal)
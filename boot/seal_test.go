@@ -20,6 +20,7 @@
 package boot_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -37,6 +38,7 @@ import (
 	"github.com/snapcore/snapd/bootloader/assets"
 	"github.com/snapcore/snapd/bootloader/bootloadertest"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/kernel/fde"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/secboot"
@@ -1475,6 +1477,178 @@ func (s *sealSuite) TestResealKeyToModeenvFallbackCmdline(c *C) {
 	})
 }
 
+func (s *sealSuite) TestDebugDumpBootChains(c *C) {
+	rootdir := c.MkDir()
+	dirs.SetRootDir(rootdir)
+	defer dirs.SetRootDir("")
+
+	model := boottest.MakeMockUC20Model()
+
+	c.Assert(os.MkdirAll(dirs.SnapFDEDir, 0755), IsNil)
+	err := os.WriteFile(filepath.Join(dirs.SnapFDEDir, "sealed-keys"), nil, 0644)
+	c.Assert(err, IsNil)
+
+	modeenv := &boot.Modeenv{
+		Mode:                   "run",
+		CurrentRecoverySystems: []string{"20200825"},
+		GoodRecoverySystems:    []string{"20200825"},
+		CurrentTrustedRecoveryBootAssets: boot.BootAssetsMap{
+			"asset": []string{"asset-hash-1"},
+		},
+		CurrentTrustedBootAssets: boot.BootAssetsMap{
+			"asset": []string{"asset-hash-1", "asset-hash-2"},
+		},
+		CurrentKernels:            []string{"pc-kernel_500.snap"},
+		CurrentKernelCommandLines: []string{"snapd_recovery_mode=run static cmdline"},
+
+		Model:          model.Model(),
+		BrandID:        model.BrandID(),
+		Grade:          string(model.Grade()),
+		ModelSignKeyID: model.SignKeyID(),
+	}
+	c.Assert(modeenv.WriteTo(""), IsNil)
+
+	// the run key was sealed without the new asset
+	err = boot.WriteBootChains(boot.PredictableBootChains{
+		boot.BootChain{
+			BrandID:        "my-brand",
+			Model:          "my-model-uc20",
+			Grade:          "dangerous",
+			ModelSignKeyID: "Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij",
+			AssetChain: []boot.BootAsset{
+				{Role: "run-mode", Name: "asset", Hashes: []string{"asset-hash-1"}},
+			},
+			Kernel:         "pc-kernel",
+			KernelRevision: "500",
+			KernelCmdlines: []string{"snapd_recovery_mode=run static cmdline"},
+		},
+	}, filepath.Join(dirs.SnapFDEDir, "boot-chains"), 3)
+	c.Assert(err, IsNil)
+	mockAssetsCache(c, rootdir, "trusted", []string{
+		"asset-asset-hash-1",
+		"asset-asset-hash-2",
+	})
+
+	bootdir := c.MkDir()
+	mtbl := bootloadertest.Mock("trusted", bootdir).WithTrustedAssets()
+	mtbl.TrustedAssetsMap = map[string]string{"asset": "asset"}
+	mtbl.StaticCommandLine = "static cmdline"
+	mtbl.BootChainList = []bootloader.BootFile{
+		bootloader.NewBootFile("", "asset", bootloader.RoleRunMode),
+		bootloader.NewBootFile("/var/lib/snapd/snap/pc-kernel_500.snap", "kernel.efi", bootloader.RoleRunMode),
+	}
+	mtbl.RecoveryBootChainList = []bootloader.BootFile{
+		bootloader.NewBootFile("", "asset", bootloader.RoleRecovery),
+		bootloader.NewBootFile("/var/lib/snapd/seed/snaps/pc-kernel_1.snap", "kernel.efi", bootloader.RoleRecovery),
+	}
+	bootloader.Force(mtbl)
+	defer bootloader.Force(nil)
+
+	restore := boot.MockSeedReadSystemEssential(func(seedDir, label string, essentialTypes []snap.Type, tm timings.Measurer) (*asserts.Model, []*seed.Snap, error) {
+		return model, []*seed.Snap{mockKernelSeedSnap(snap.R(1)), mockGadgetSeedSnap(c, nil)}, nil
+	})
+	defer restore()
+
+	restore = boot.MockSecbootResealKeys(func(params *secboot.ResealKeysParams) error {
+		c.Fatalf("unexpected reseal")
+		return nil
+	})
+	defer restore()
+
+	profileErr := error(nil)
+	profileCalls := 0
+	restore = boot.MockSecbootBuildPCRProtectionProfile(func(modelParams []*secboot.SealKeyModelParams) (string, error) {
+		profileCalls++
+		return "profile", profileErr
+	})
+	defer restore()
+
+	var buf bytes.Buffer
+	err = boot.DebugDumpBootChains(&buf)
+	c.Assert(err, IsNil)
+	c.Check(profileCalls, Equals, 2)
+	c.Check(buf.String(), Equals, `run-key:
+  reseal-count: 3
+  current:
+  - model: my-brand/my-model-uc20
+    grade: dangerous
+    model-sign-key-id: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+    kernel: pc-kernel
+    kernel-revision: 500
+    kernel-cmdlines:
+    - snapd_recovery_mode=run static cmdline
+    asset-chain:
+    - role: run-mode
+      name: asset
+      hashes:
+      - asset-hash-1
+  candidate:
+  - model: my-brand/my-model-uc20
+    grade: dangerous
+    model-sign-key-id: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+    kernel: pc-kernel
+    kernel-revision: 1
+    kernel-cmdlines:
+    - snapd_recovery_mode=factory-reset snapd_recovery_system=20200825 static cmdline
+    - snapd_recovery_mode=recover snapd_recovery_system=20200825 static cmdline
+    asset-chain:
+    - role: recovery
+      name: asset
+      hashes:
+      - asset-hash-1
+  - model: my-brand/my-model-uc20
+    grade: dangerous
+    model-sign-key-id: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+    kernel: pc-kernel
+    kernel-revision: 500
+    kernel-cmdlines:
+    - snapd_recovery_mode=run static cmdline
+    asset-chain:
+    - role: run-mode
+      name: asset
+      hashes:
+      - asset-hash-1
+      - asset-hash-2
+  reseal-needed: true
+fallback-key:
+  reseal-count: 0
+  current:
+  candidate:
+  - model: my-brand/my-model-uc20
+    grade: dangerous
+    model-sign-key-id: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+    kernel: pc-kernel
+    kernel-revision: 1
+    kernel-cmdlines:
+    - snapd_recovery_mode=factory-reset snapd_recovery_system=20200825 static cmdline
+    - snapd_recovery_mode=recover snapd_recovery_system=20200825 static cmdline
+    asset-chain:
+    - role: recovery
+      name: asset
+      hashes:
+      - asset-hash-1
+  reseal-needed: true
+seal-check: ok
+`)
+
+	// the candidate boot chains cannot be sealed
+	profileErr = fmt.Errorf("mocked error")
+	buf.Reset()
+	err = boot.DebugDumpBootChains(&buf)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), testutil.Contains, "\nseal-check: error: cannot build the PCR protection profile for the run key: mocked error\n")
+
+	// the boot chains are not relevant without TPM sealed keys
+	err = os.WriteFile(filepath.Join(dirs.SnapFDEDir, "sealed-keys"), []byte("fde-setup-hook"), 0644)
+	c.Assert(err, IsNil)
+	err = boot.DebugDumpBootChains(&buf)
+	c.Assert(err, ErrorMatches, `cannot show boot chains: encryption keys sealed with "fde-setup-hook" do not use boot chains`)
+
+	c.Assert(os.Remove(filepath.Join(dirs.SnapFDEDir, "sealed-keys")), IsNil)
+	err = boot.DebugDumpBootChains(&buf)
+	c.Assert(err, ErrorMatches, "cannot show boot chains: no sealed encryption keys")
+}
+
 func (s *sealSuite) TestRunModeBootChains(c *C) {
 	for _, tc := range []struct {
 		desc               string
@@ -2228,6 +2402,75 @@ func (s *sealSuite) TestSealToModeenvWithFdeHookSad(c *C) {
 	c.Check(marker, testutil.FileAbsent)
 }
 
+func (s *sealSuite) TestSealToModeenvWithPassphraseAuth(c *C) {
+	rootdir := c.MkDir()
+	dirs.SetRootDir(rootdir)
+	defer dirs.SetRootDir("")
+
+	restore := boot.MockSecbootProvisionTPM(func(mode secboot.TPMProvisionMode, lockoutAuthFile string) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+	restore = boot.MockSecbootSealKeys(func(keys []secboot.SealKeyRequest, params *secboot.SealKeysParams) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	modeenv := &boot.Modeenv{
+		RecoverySystem: "20200825",
+	}
+	key := keys.EncryptionKey{1, 2, 3, 4}
+	saveKey := keys.EncryptionKey{5, 6, 7, 8}
+
+	defer boot.MockModeenvLocked()()
+
+	model := boottest.MakeMockUC20Model()
+	err := boot.SealKeyToModeenv(key, saveKey, model, modeenv, boot.MockSealKeyToModeenvFlags{
+		VolumesAuth: &device.VolumesAuthOptions{
+			Mode:       device.AuthModePassphrase,
+			Passphrase: "secret",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	c.Check(filepath.Join(boot.InitramfsBootEncryptionKeyDir, "passphrase-auth"), testutil.FilePresent)
+	c.Check(filepath.Join(boot.InitramfsSeedEncryptionKeyDir, "passphrase-auth"), testutil.FilePresent)
+	c.Check(filepath.Join(boot.InitramfsBootEncryptionKeyDir, "ubuntu-data.sealed-key"), testutil.FileAbsent)
+	marker := filepath.Join(dirs.SnapFDEDirUnder(filepath.Join(dirs.GlobalRootDir, "/run/mnt/ubuntu-data/system-data")), "sealed-keys")
+	c.Check(marker, testutil.FileEquals, "passphrase")
+}
+
+func (s *sealSuite) TestResealKeyToModeenvWithPassphraseAuth(c *C) {
+	rootdir := c.MkDir()
+	dirs.SetRootDir(rootdir)
+	defer dirs.SetRootDir("")
+
+	restore := boot.MockResealKeyToModeenvUsingFDESetupHook(func(string, *boot.Modeenv, bool) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	marker := filepath.Join(dirs.SnapFDEDirUnder(rootdir), "sealed-keys")
+	c.Assert(os.MkdirAll(filepath.Dir(marker), 0755), IsNil)
+	c.Assert(os.WriteFile(marker, []byte("passphrase"), 0644), IsNil)
+
+	defer boot.MockModeenvLocked()()
+
+	model := boottest.MakeMockUC20Model()
+	modeenv := &boot.Modeenv{
+		RecoverySystem: "20200825",
+		Model:          model.Model(),
+		BrandID:        model.BrandID(),
+		Grade:          string(model.Grade()),
+		ModelSignKeyID: model.SignKeyID(),
+	}
+	err := boot.ResealKeyToModeenv(rootdir, modeenv, true, nil)
+	c.Assert(err, IsNil)
+}
+
 func (s *sealSuite) TestResealKeyToModeenvWithFdeHookCalled(c *C) {
 	rootdir := c.MkDir()
 	dirs.SetRootDir(rootdir)
@@ -340,6 +340,7 @@ var (
 		newAndroidBoot,
 		newLk,
 		newPiboot,
+		newSdboot,
 	}
 )
 
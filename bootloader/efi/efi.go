@@ -17,7 +17,7 @@
  *
  */
 
-// Package efi supports reading EFI variables.
+// Package efi supports reading and writing EFI variables.
 package efi
 
 import (
@@ -46,27 +46,31 @@ const (
 )
 
 var (
-	openEFIVar = openEFIVarImpl
+	openEFIVar   = openEFIVarImpl
+	writeEFIVar  = writeEFIVarImpl
+	removeEFIVar = removeEFIVarImpl
 )
 
 const expectedEFIvarfsDir = "/sys/firmware/efi/efivars"
 
-func openEFIVarImpl(name string) (r io.ReadCloser, attr VariableAttr, size int64, err error) {
+func checkEFIvarfs() error {
 	mounts, err := osutil.LoadMountInfo()
 	if err != nil {
-		return nil, 0, 0, err
+		return err
 	}
-	found := false
 	for _, mnt := range mounts {
 		if mnt.MountDir == expectedEFIvarfsDir {
 			if mnt.FsType == "efivarfs" {
-				found = true
-				break
+				return nil
 			}
 		}
 	}
-	if !found {
-		return nil, 0, 0, ErrNoEFISystem
+	return ErrNoEFISystem
+}
+
+func openEFIVarImpl(name string) (r io.ReadCloser, attr VariableAttr, size int64, err error) {
+	if err := checkEFIvarfs(); err != nil {
+		return nil, 0, 0, err
 	}
 	varf, err := os.Open(filepath.Join(dirs.GlobalRootDir, expectedEFIvarfsDir, name))
 	if err != nil {
@@ -92,10 +96,74 @@ func openEFIVarImpl(name string) (r io.ReadCloser, attr VariableAttr, size int64
 	return varf, attr, sz - 4, nil
 }
 
+// clearImmutable drops the immutable flag the kernel sets on most of
+// the files in efivarfs, so that the variable can be modified.
+func clearImmutable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	attr, err := osutil.GetAttr(f)
+	if err != nil {
+		// not supported by the filesystem, if the file really is
+		// immutable the subsequent operation will fail anyway
+		return nil
+	}
+	if attr&osutil.FS_IMMUTABLE_FL == 0 {
+		return nil
+	}
+	return osutil.SetAttr(f, attr&^osutil.FS_IMMUTABLE_FL)
+}
+
+func writeEFIVarImpl(name string, attr VariableAttr, data []byte) error {
+	if err := checkEFIvarfs(); err != nil {
+		return err
+	}
+	path := filepath.Join(dirs.GlobalRootDir, expectedEFIvarfsDir, name)
+	if err := clearImmutable(path); err != nil {
+		return err
+	}
+	varf, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// efivarfs expects the attributes and the data in a single write
+	buf := make([]byte, 4, 4+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(attr))
+	buf = append(buf, data...)
+	if _, err := varf.Write(buf); err != nil {
+		varf.Close()
+		return err
+	}
+	return varf.Close()
+}
+
+func removeEFIVarImpl(name string) error {
+	if err := checkEFIvarfs(); err != nil {
+		return err
+	}
+	path := filepath.Join(dirs.GlobalRootDir, expectedEFIvarfsDir, name)
+	if err := clearImmutable(path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func cannotReadError(name string, err error) error {
 	return fmt.Errorf("cannot read EFI var %q: %v", name, err)
 }
 
+func cannotWriteError(name string, err error) error {
+	return fmt.Errorf("cannot write EFI var %q: %v", name, err)
+}
+
 // ReadVarBytes will attempt to read the bytes of the value of the
 // specified EFI variable, specified by its full name composed of the
 // variable name and vendor ID. It also returns the attribute value
@@ -158,6 +226,45 @@ func ReadVarString(name string) (string, VariableAttr, error) {
 	return b.String(), attr, nil
 }
 
+// WriteVarBytes will attempt to write the given value with the given
+// attributes to the specified EFI variable, specified by its full name
+// composed of the variable name and vendor ID. It expects to use the
+// efivars filesystem at /sys/firmware/efi/efivars.
+func WriteVarBytes(name string, attr VariableAttr, value []byte) error {
+	if err := writeEFIVar(name, attr, value); err != nil {
+		if err == ErrNoEFISystem {
+			return err
+		}
+		return cannotWriteError(name, err)
+	}
+	return nil
+}
+
+// WriteVarString will attempt to write the given string value with the
+// given attributes to the specified EFI variable, specified by its full
+// name composed of the variable name and vendor ID. The value is
+// encoded as a NUL terminated UTF16 string.
+func WriteVarString(name string, attr VariableAttr, value string) error {
+	r16 := append(utf16.Encode([]rune(value)), 0)
+	b := &bytes.Buffer{}
+	// writing to a bytes.Buffer cannot fail
+	binary.Write(b, binary.LittleEndian, r16)
+	return WriteVarBytes(name, attr, b.Bytes())
+}
+
+// DeleteVar will attempt to delete the specified EFI variable,
+// specified by its full name composed of the variable name and vendor
+// ID. It is not an error if the variable does not exist.
+func DeleteVar(name string) error {
+	if err := removeEFIVar(name); err != nil {
+		if err == ErrNoEFISystem {
+			return err
+		}
+		return fmt.Errorf("cannot delete EFI var %q: %v", name, err)
+	}
+	return nil
+}
+
 // MockVars mocks EFI variables as read by ReadVar*, only to be used
 // from tests. Set vars to nil to mock a non-EFI system.
 func MockVars(vars map[string][]byte, attrs map[string]VariableAttr) (restore func()) {
@@ -181,3 +288,40 @@ func MockVars(vars map[string][]byte, attrs map[string]VariableAttr) (restore fu
 		openEFIVar = old
 	}
 }
+
+// MockWriteVars mocks writing and deleting EFI variables as done by
+// WriteVar* and DeleteVar, only to be used from tests. Written values
+// are stored in vars and deleted variables are removed from it, the
+// attributes are recorded in attrs if it is not nil. Passing the same
+// maps to MockVars allows reading back the written values. Set vars to
+// nil to mock a non-EFI system.
+func MockWriteVars(vars map[string][]byte, attrs map[string]VariableAttr) (restore func()) {
+	osutil.MustBeTestBinary("MockWriteVars only to be used from tests")
+	oldWrite := writeEFIVar
+	oldRemove := removeEFIVar
+	writeEFIVar = func(name string, attr VariableAttr, data []byte) error {
+		if vars == nil {
+			return ErrNoEFISystem
+		}
+		vars[name] = data
+		if attrs != nil {
+			attrs[name] = attr
+		}
+		return nil
+	}
+	removeEFIVar = func(name string) error {
+		if vars == nil {
+			return ErrNoEFISystem
+		}
+		delete(vars, name)
+		if attrs != nil {
+			delete(attrs, name)
+		}
+		return nil
+	}
+
+	return func() {
+		writeEFIVar = oldWrite
+		removeEFIVar = oldRemove
+	}
+}
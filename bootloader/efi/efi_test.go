@@ -121,6 +121,73 @@ func (s *efiVarsSuite) TestEmpty(c *C) {
 	c.Check(v, HasLen, 0)
 }
 
+func (s *efiVarsSuite) TestWriteVarString(c *C) {
+	err := efi.WriteVarString("my-cool-efi-var", efi.VariableNonVolatile|efi.VariableBootServiceAccess|efi.VariableRuntimeAccess, "foo.conf")
+	c.Assert(err, IsNil)
+
+	varPath := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars", "my-cool-efi-var")
+	c.Check(varPath, testutil.FileEquals, "\x07\x00\x00\x00f\x00o\x00o\x00.\x00c\x00o\x00n\x00f\x00\x00\x00")
+
+	v, attr, err := efi.ReadVarString("my-cool-efi-var")
+	c.Assert(err, IsNil)
+	c.Check(attr, Equals, efi.VariableNonVolatile|efi.VariableBootServiceAccess|efi.VariableRuntimeAccess)
+	c.Check(v, Equals, "foo.conf")
+}
+
+func (s *efiVarsSuite) TestWriteVarBytesError(c *C) {
+	err := os.RemoveAll(filepath.Join(s.rootdir, "/sys/firmware/efi/efivars"))
+	c.Assert(err, IsNil)
+
+	err = efi.WriteVarBytes("my-cool-efi-var", efi.VariableRuntimeAccess, []byte("\x01"))
+	c.Check(err, ErrorMatches, `cannot write EFI var "my-cool-efi-var": open .*/my-cool-efi-var: no such file or directory`)
+}
+
+func (s *efiVarsSuite) TestDeleteVar(c *C) {
+	varPath := filepath.Join(s.rootdir, "/sys/firmware/efi/efivars", "my-cool-efi-var")
+	err := os.WriteFile(varPath, []byte("\x06\x00\x00\x00\x01"), 0644)
+	c.Assert(err, IsNil)
+
+	c.Assert(efi.DeleteVar("my-cool-efi-var"), IsNil)
+	c.Check(varPath, testutil.FileAbsent)
+
+	// deleting a variable which does not exist is not an error
+	c.Assert(efi.DeleteVar("my-cool-efi-var"), IsNil)
+}
+
+func (s *efiVarsSuite) TestWriteNoEFISystem(c *C) {
+	// no efivarfs
+	osutil.MockMountInfo("")
+
+	err := efi.WriteVarString("my-cool-efi-var", efi.VariableRuntimeAccess, "foo")
+	c.Check(err, Equals, efi.ErrNoEFISystem)
+
+	err = efi.DeleteVar("my-cool-efi-var")
+	c.Check(err, Equals, efi.ErrNoEFISystem)
+}
+
+func (s *efiVarsSuite) TestMockWriteVars(c *C) {
+	vars := map[string][]byte{
+		"a": []byte("\x01"),
+	}
+	attrs := map[string]efi.VariableAttr{}
+	restore := efi.MockVars(vars, attrs)
+	defer restore()
+	restore = efi.MockWriteVars(vars, attrs)
+	defer restore()
+
+	err := efi.WriteVarString("b", efi.VariableNonVolatile|efi.VariableRuntimeAccess, "foo")
+	c.Assert(err, IsNil)
+	c.Check(vars["b"], DeepEquals, bootloadertest.UTF16Bytes("foo"))
+
+	v, attr, err := efi.ReadVarString("b")
+	c.Assert(err, IsNil)
+	c.Check(attr, Equals, efi.VariableNonVolatile|efi.VariableRuntimeAccess)
+	c.Check(v, Equals, "foo")
+
+	c.Assert(efi.DeleteVar("a"), IsNil)
+	c.Check(vars, HasLen, 1)
+}
+
 func (s *efiVarsSuite) TestMockVars(c *C) {
 	restore := efi.MockVars(map[string][]byte{
 		"a": []byte("\x01"),
@@ -320,6 +320,18 @@ func LayoutKernelAssetsToDir(b Bootloader, snapf snap.Container, dstDir string)
 	return p.layoutKernelAssetsToDir(snapf, dstDir)
 }
 
+func NewSdboot(rootdir string, opts *Options) Bootloader {
+	return newSdboot(rootdir, opts)
+}
+
+func MockSdbootOpenSnapFile(f func(path string) (snap.Container, error)) (restore func()) {
+	old := sdbootOpenSnapFile
+	sdbootOpenSnapFile = f
+	return func() {
+		sdbootOpenSnapFile = old
+	}
+}
+
 var (
 	EditionFromDiskConfigAsset           = editionFromDiskConfigAsset
 	EditionFromConfigAsset               = editionFromConfigAsset
@@ -366,14 +366,20 @@ func (g *grub) ManagedAssets() []string {
 }
 
 func (g *grub) commandLineForEdition(edition uint, pieces CommandLineComponents) (string, error) {
+	return composeCommandLine(g.defaultCommandLineForEdition(edition), pieces)
+}
+
+// composeCommandLine returns the kernel command line composed of the
+// snapd mode and system arguments followed by the static command line
+// with any extra arguments, or a full set of arguments overriding
+// those.
+func composeCommandLine(staticCmdline string, pieces CommandLineComponents) (string, error) {
 	if err := pieces.Validate(); err != nil {
 		return "", err
 	}
 
 	var nonSnapdCmdline string
 	if pieces.FullArgs == "" {
-		staticCmdline := g.defaultCommandLineForEdition(edition)
-
 		keepDefaultArgs := kcmdline.RemoveMatchingFilter(staticCmdline, pieces.RemoveArgs)
 
 		nonSnapdCmdline = strutil.JoinNonEmpty(append(keepDefaultArgs, pieces.ExtraArgs), " ")
@@ -0,0 +1,656 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package bootloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snapcore/snapd/arch"
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/bootloader/ubootenv"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapfile"
+)
+
+// sdboot implements the required interfaces
+var (
+	_ Bootloader                        = (*sdboot)(nil)
+	_ RecoveryAwareBootloader           = (*sdboot)(nil)
+	_ ExtractedRunKernelImageBootloader = (*sdboot)(nil)
+	_ TrustedAssetsBootloader           = (*sdboot)(nil)
+)
+
+const (
+	sdbootEnvFile     = "loader/snapd.env"
+	sdbootLoaderConf  = "loader/loader.conf"
+	sdbootEntriesDir  = "loader/entries"
+	sdbootKernelsDir  = "EFI/ubuntu"
+	sdbootRunEntry    = "snapd-run.conf"
+	sdbootTryRunEntry = "snapd-try-run.conf"
+
+	// variables of the boot loader interface implemented by
+	// systemd-boot, see https://systemd.io/BOOT_LOADER_INTERFACE/
+	sdbootLoaderEntryOneShot  = "LoaderEntryOneShot-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+	sdbootLoaderEntrySelected = "LoaderEntrySelected-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+
+	// same as the static command line of the managed grub boot config
+	sdbootStaticCmdline = "console=ttyS0 console=tty1 panic=-1"
+
+	sdbootManagedHeader = "# This file is managed by snapd, do not edit\n"
+)
+
+// This is in a variable so it can be mocked in tests
+var sdbootOpenSnapFile = snapfile.Open
+
+// sdbootRecoveryModes are the modes for which boot entries are generated
+// for each recovery system.
+var sdbootRecoveryModes = []string{"install", "recover", "factory-reset"}
+
+// sdboot is the systemd-boot bootloader. It is used with a layout where
+// ubuntu-seed is the EFI system partition carrying systemd-boot and the
+// boot entries of the recovery systems, while ubuntu-boot is an extended
+// boot loader partition (XBOOTLDR) carrying the run mode kernels and
+// boot entries. systemd-boot cannot be scripted, instead snapd writes the
+// boot entries and selects the one to boot either through the default
+// entry of the loader configuration or through the LoaderEntryOneShot
+// EFI variable for boots which must happen only once, like trying a new
+// kernel or going into recover mode.
+type sdboot struct {
+	rootdir string
+
+	basedir string
+
+	recovery              bool
+	nativePartitionLayout bool
+	prepareImageTime      bool
+}
+
+// newSdboot creates a new systemd-boot bootloader object
+func newSdboot(rootdir string, opts *Options) Bootloader {
+	s := &sdboot{rootdir: rootdir}
+	if opts != nil {
+		s.recovery = opts.Role == RoleRecovery
+		s.nativePartitionLayout = opts.NoSlashBoot || s.recovery
+		s.prepareImageTime = opts.PrepareImageTime
+	}
+	if !s.nativePartitionLayout {
+		// unlike with grub, there is no bind mount of the boot
+		// partition under /boot, so look at where it is mounted
+		s.basedir = "run/mnt/ubuntu-boot"
+	}
+	return s
+}
+
+func (s *sdboot) Name() string {
+	return "systemd-boot"
+}
+
+func (s *sdboot) dir() string {
+	if s.rootdir == "" {
+		panic("internal error: unset rootdir")
+	}
+	return filepath.Join(s.rootdir, s.basedir)
+}
+
+func (s *sdboot) envFile() string {
+	return filepath.Join(s.dir(), sdbootEnvFile)
+}
+
+func (s *sdboot) entryFile(name string) string {
+	return filepath.Join(s.dir(), sdbootEntriesDir, name)
+}
+
+// systemd-boot is enabled if the snapd env file exists
+func (s *sdboot) Present() (bool, error) {
+	return osutil.FileExists(s.envFile()), nil
+}
+
+func (s *sdboot) InstallBootConfig(gadgetDir string, opts *Options) error {
+	if err := os.MkdirAll(filepath.Join(s.dir(), sdbootEntriesDir), 0755); err != nil {
+		return err
+	}
+	env, err := ubootenv.Create(s.envFile(), 4096, ubootenv.CreateOptions{HeaderFlagByte: true})
+	if err != nil {
+		return err
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+	if s.recovery {
+		return s.writeLoaderConf(sdbootRunEntry)
+	}
+	return nil
+}
+
+func (s *sdboot) writeLoaderConf(defaultEntry string) error {
+	var buf bytes.Buffer
+	buf.WriteString(sdbootManagedHeader)
+	buf.WriteString("timeout 0\n")
+	fmt.Fprintf(&buf, "default %s\n", defaultEntry)
+	return osutil.AtomicWriteFile(filepath.Join(s.dir(), sdbootLoaderConf), buf.Bytes(), 0644, 0)
+}
+
+func (s *sdboot) writeEntry(name, title, efiPath, cmdline string) error {
+	var buf bytes.Buffer
+	buf.WriteString(sdbootManagedHeader)
+	fmt.Fprintf(&buf, "title %s\n", title)
+	fmt.Fprintf(&buf, "efi %s\n", efiPath)
+	fmt.Fprintf(&buf, "options %s\n", cmdline)
+	entry := s.entryFile(name)
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(entry, buf.Bytes(), 0644, 0)
+}
+
+// entryEFIPath returns the path of the EFI binary booted by the given
+// entry, as it appears in the entry.
+func (s *sdboot) entryEFIPath(name string) (string, error) {
+	f, err := os.Open(s.entryFile(name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if efiPath := strings.TrimPrefix(scanner.Text(), "efi "); efiPath != scanner.Text() {
+			return strings.TrimSpace(efiPath), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("cannot find EFI binary in boot entry %s", name)
+}
+
+func recoveryEntryName(mode, system string) string {
+	return fmt.Sprintf("snapd-%s-%s.conf", mode, system)
+}
+
+func sdbootSetOneShot(entry string) error {
+	attr := efi.VariableNonVolatile | efi.VariableBootServiceAccess | efi.VariableRuntimeAccess
+	if err := efi.WriteVarString(sdbootLoaderEntryOneShot, attr, entry); err != nil {
+		return fmt.Errorf("cannot request one-shot boot of %s: %v", entry, err)
+	}
+	return nil
+}
+
+func sdbootClearOneShot() error {
+	if err := efi.DeleteVar(sdbootLoaderEntryOneShot); err != nil && err != efi.ErrNoEFISystem {
+		return err
+	}
+	return nil
+}
+
+func (s *sdboot) openEnv() (*ubootenv.Env, error) {
+	return ubootenv.OpenWithFlags(s.envFile(), ubootenv.OpenBestEffort)
+}
+
+func (s *sdboot) GetBootVars(names ...string) (map[string]string, error) {
+	env, err := s.openEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		out[name] = env.Get(name)
+	}
+	if status, ok := out["kernel_status"]; ok && status == "try" && !s.recovery {
+		out["kernel_status"] = s.tryKernelStatus()
+	}
+
+	return out, nil
+}
+
+// tryKernelStatus returns the status of a kernel being tried. As
+// systemd-boot does not update the environment when booting, the status
+// is derived from the boot loader interface variables instead.
+func (s *sdboot) tryKernelStatus() string {
+	oneShot, _, err := efi.ReadVarString(sdbootLoaderEntryOneShot)
+	if err == efi.ErrNoEFISystem || (err == nil && oneShot == sdbootTryRunEntry) {
+		// not rebooted yet
+		return "try"
+	}
+	// the one-shot request was consumed, look at what was booted
+	selected, _, err := efi.ReadVarString(sdbootLoaderEntrySelected)
+	if err == nil && selected == sdbootTryRunEntry {
+		return "trying"
+	}
+	// the try kernel failed to boot, or the system was rebooted while
+	// trying it, both cases are equivalent to the try having failed
+	return ""
+}
+
+// Variables stored in ubuntu-seed:
+//
+//	snapd_recovery_system
+//	snapd_recovery_mode
+//	snapd_good_recovery_systems
+//	recovery_system_status
+//	try_recovery_system
+//
+// Variables stored in ubuntu-boot:
+//
+//	kernel_status
+//	snapd_extra_cmdline_args
+//	snapd_full_cmdline_args
+func (s *sdboot) SetBootVars(values map[string]string) error {
+	env, err := s.openEnv()
+	if err != nil {
+		return err
+	}
+
+	dirtyEnv := false
+	modeChanged := false
+	cmdlineChanged := false
+	kernelStatusChanged := false
+	for k, v := range values {
+		// already set to the right value, nothing to do
+		if env.Get(k) == v {
+			continue
+		}
+		env.Set(k, v)
+		dirtyEnv = true
+		switch k {
+		case "snapd_recovery_mode", "snapd_recovery_system":
+			modeChanged = true
+		case "snapd_extra_cmdline_args", "snapd_full_cmdline_args":
+			cmdlineChanged = true
+		case "kernel_status":
+			kernelStatusChanged = true
+		}
+	}
+
+	if dirtyEnv {
+		if err := env.Save(); err != nil {
+			return err
+		}
+	}
+
+	if s.recovery {
+		if modeChanged {
+			return s.applyRecoveryMode(env)
+		}
+		return nil
+	}
+
+	if cmdlineChanged {
+		if err := s.rewriteRunEntries(env); err != nil {
+			return err
+		}
+	}
+	if kernelStatusChanged {
+		if env.Get("kernel_status") == "try" {
+			return sdbootSetOneShot(sdbootTryRunEntry)
+		}
+		return sdbootClearOneShot()
+	}
+	return nil
+}
+
+// applyRecoveryMode selects the boot entry corresponding to the recovery
+// mode and system set in the environment.
+func (s *sdboot) applyRecoveryMode(env *ubootenv.Env) error {
+	mode := env.Get("snapd_recovery_mode")
+	system := env.Get("snapd_recovery_system")
+
+	if mode == "run" {
+		if err := s.writeLoaderConf(sdbootRunEntry); err != nil {
+			return err
+		}
+		if s.prepareImageTime {
+			return nil
+		}
+		// drop any pending request to boot a recovery system
+		return sdbootClearOneShot()
+	}
+	if system == "" {
+		// nothing to boot into yet
+		return nil
+	}
+
+	switch mode {
+	case "recover":
+		entry := recoveryEntryName(mode, system)
+		if s.prepareImageTime {
+			return s.writeLoaderConf(entry)
+		}
+		// recover mode is entered only once, the next boot goes back
+		// to the default entry
+		return sdbootSetOneShot(entry)
+	case "install", "factory-reset":
+		return s.writeLoaderConf(recoveryEntryName(mode, system))
+	default:
+		return fmt.Errorf("cannot use unsupported recovery mode %q", mode)
+	}
+}
+
+func (s *sdboot) SetRecoverySystemEnv(recoverySystemDir string, values map[string]string) error {
+	if recoverySystemDir == "" {
+		return fmt.Errorf("internal error: recoverySystemDir unset")
+	}
+	envFile := filepath.Join(s.rootdir, recoverySystemDir, "snapd.env")
+	if err := os.MkdirAll(filepath.Dir(envFile), 0755); err != nil {
+		return err
+	}
+	env, err := ubootenv.Create(envFile, 4096, ubootenv.CreateOptions{HeaderFlagByte: true})
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		env.Set(k, v)
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+
+	// systemd-boot cannot load the kernel from inside the snap, so
+	// extract it to the recovery system directory
+	kernelSnap := env.Get("snapd_recovery_kernel")
+	if kernelSnap == "" {
+		return fmt.Errorf("cannot set up recovery system %s without a kernel", recoverySystemDir)
+	}
+	snapf, err := sdbootOpenSnapFile(filepath.Join(s.rootdir, kernelSnap))
+	if err != nil {
+		return err
+	}
+	kernelDir := filepath.Join(s.rootdir, recoverySystemDir, "kernel")
+	if err := extractKernelAssetsToBootDir(kernelDir, snapf, []string{"kernel.efi"}); err != nil {
+		return err
+	}
+
+	system := filepath.Base(recoverySystemDir)
+	efiPath := filepath.Join("/", recoverySystemDir, "kernel", "kernel.efi")
+	for _, mode := range sdbootRecoveryModes {
+		cmdline, err := s.CommandLine(CommandLineComponents{
+			ModeArg:   "snapd_recovery_mode=" + mode,
+			SystemArg: "snapd_recovery_system=" + system,
+			ExtraArgs: env.Get("snapd_extra_cmdline_args"),
+			FullArgs:  env.Get("snapd_full_cmdline_args"),
+		})
+		if err != nil {
+			return err
+		}
+		title := fmt.Sprintf("Ubuntu Core %s using %s", mode, system)
+		if err := s.writeEntry(recoveryEntryName(mode, system), title, efiPath, cmdline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sdboot) GetRecoverySystemEnv(recoverySystemDir string, key string) (string, error) {
+	if recoverySystemDir == "" {
+		return "", fmt.Errorf("internal error: recoverySystemDir unset")
+	}
+	env, err := ubootenv.OpenWithFlags(filepath.Join(s.rootdir, recoverySystemDir, "snapd.env"), ubootenv.OpenBestEffort)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return env.Get(key), nil
+}
+
+func (s *sdboot) ExtractKernelAssets(sn snap.PlaceInfo, snapf snap.Container) error {
+	if s.recovery {
+		// recovery kernels are extracted when setting up the
+		// recovery system
+		return nil
+	}
+	return extractKernelAssetsToBootDir(
+		filepath.Join(s.dir(), sdbootKernelsDir, sn.Filename()),
+		snapf,
+		[]string{"kernel.efi"},
+	)
+}
+
+func (s *sdboot) RemoveKernelAssets(sn snap.PlaceInfo) error {
+	return removeKernelAssetsFromBootDir(filepath.Join(s.dir(), sdbootKernelsDir), sn)
+}
+
+// ExtractedRunKernelImageBootloader helper methods
+
+func (s *sdboot) writeRunEntry(name string, sn snap.PlaceInfo, env *ubootenv.Env) error {
+	efiPath := filepath.Join("/", sdbootKernelsDir, sn.Filename(), "kernel.efi")
+	// check that the kernel snap has been extracted already so we don't
+	// inadvertently create an entry which cannot boot
+	if !osutil.FileExists(filepath.Join(s.dir(), efiPath)) {
+		return fmt.Errorf("cannot enable %s at %s: %v", name, efiPath, os.ErrNotExist)
+	}
+	cmdline, err := s.CommandLine(CommandLineComponents{
+		ModeArg:   "snapd_recovery_mode=run",
+		ExtraArgs: env.Get("snapd_extra_cmdline_args"),
+		FullArgs:  env.Get("snapd_full_cmdline_args"),
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeEntry(name, "Ubuntu Core", efiPath, cmdline)
+}
+
+// rewriteRunEntries updates the command line of the existing run mode
+// entries.
+func (s *sdboot) rewriteRunEntries(env *ubootenv.Env) error {
+	for _, name := range []string{sdbootRunEntry, sdbootTryRunEntry} {
+		sn, err := s.readEntryKernel(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := s.writeRunEntry(name, sn, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sdboot) readEntryKernel(name string) (snap.PlaceInfo, error) {
+	efiPath, err := s.entryEFIPath(name)
+	if err != nil {
+		return nil, err
+	}
+	kernelSnapFileName := filepath.Base(filepath.Dir(efiPath))
+	sn, err := snap.ParsePlaceInfoFromSnapFileName(kernelSnapFileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse kernel snap file name from boot entry %s: %v", name, err)
+	}
+	return sn, nil
+}
+
+// actual ExtractedRunKernelImageBootloader methods
+
+// EnableKernel writes the default run mode boot entry, booting the
+// referenced kernel snap. EnableKernel() will fail if the referenced
+// kernel snap was not extracted.
+func (s *sdboot) EnableKernel(sn snap.PlaceInfo) error {
+	env, err := s.openEnv()
+	if err != nil {
+		return err
+	}
+	return s.writeRunEntry(sdbootRunEntry, sn, env)
+}
+
+// EnableTryKernel writes the run mode boot entry used when trying the
+// referenced kernel snap, the entry is booted once after setting
+// kernel_status to "try". EnableTryKernel() will fail if the referenced
+// kernel snap was not extracted.
+func (s *sdboot) EnableTryKernel(sn snap.PlaceInfo) error {
+	env, err := s.openEnv()
+	if err != nil {
+		return err
+	}
+	return s.writeRunEntry(sdbootTryRunEntry, sn, env)
+}
+
+// DisableTryKernel removes the try kernel boot entry if it exists.
+func (s *sdboot) DisableTryKernel() error {
+	err := os.Remove(s.entryFile(sdbootTryRunEntry))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Kernel returns the kernel snap booted by the default run mode entry.
+func (s *sdboot) Kernel() (snap.PlaceInfo, error) {
+	return s.readEntryKernel(sdbootRunEntry)
+}
+
+// TryKernel returns the kernel snap booted by the try kernel entry if
+// it exists, or ErrNoTryKernelRef otherwise.
+func (s *sdboot) TryKernel() (snap.PlaceInfo, error) {
+	if !osutil.FileExists(s.entryFile(sdbootTryRunEntry)) {
+		return nil, ErrNoTryKernelRef
+	}
+	return s.readEntryKernel(sdbootTryRunEntry)
+}
+
+// UpdateBootConfig does nothing for systemd-boot, as its configuration
+// is generated from the environment whenever it changes.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (s *sdboot) UpdateBootConfig() (bool, error) {
+	return false, nil
+}
+
+// ManagedAssets returns a list relative paths to boot assets inside the root
+// directory of the filesystem.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (s *sdboot) ManagedAssets() []string {
+	return []string{
+		filepath.Join(s.basedir, sdbootLoaderConf),
+	}
+}
+
+// CommandLine returns the kernel command line composed of mode and
+// system arguments, followed by either the static arguments and any extra
+// arguments or a separate set of arguments provided in the components.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (s *sdboot) CommandLine(pieces CommandLineComponents) (string, error) {
+	return composeCommandLine(sdbootStaticCmdline, pieces)
+}
+
+// CandidateCommandLine is the same as CommandLine, as there are no
+// editions of the systemd-boot configuration.
+//
+// Implements TrustedAssetsBootloader for the systemd-boot bootloader.
+func (s *sdboot) CandidateCommandLine(pieces CommandLineComponents) (string, error) {
+	return s.CommandLine(pieces)
+}
+
+// DefaultCommandLine returns the default kernel command-line used by
+// the bootloader excluding the recovery mode and system parameters.
+func (s *sdboot) DefaultCommandLine(candidate bool) (string, error) {
+	return sdbootStaticCmdline, nil
+}
+
+// sdbootBinaryForArch contains the paths to the systemd-boot binary for
+// the supported architectures.
+var sdbootBinaryForArch = map[string]taggedPath{
+	"amd64": {
+		path: filepath.Join("EFI/boot/", "bootx64.efi"),
+	},
+	"arm64": {
+		path: filepath.Join("EFI/boot/", "bootaa64.efi"),
+	},
+}
+
+func (s *sdboot) getBootBinaryForArch() (taggedPath, error) {
+	if s.prepareImageTime {
+		return taggedPath{}, fmt.Errorf("internal error: retrieving boot assets at prepare image time")
+	}
+	archi := arch.DpkgArchitecture()
+	binary, ok := sdbootBinaryForArch[archi]
+	if !ok {
+		return taggedPath{}, fmt.Errorf("cannot find systemd-boot assets for %q", archi)
+	}
+	return binary, nil
+}
+
+// TrustedAssets returns the map of relative paths to asset
+// identifers. The only trusted asset is the systemd-boot binary on the
+// seed partition, which directly loads both the recovery and the run
+// mode kernels.
+func (s *sdboot) TrustedAssets() (map[string]string, error) {
+	if !s.nativePartitionLayout {
+		return nil, fmt.Errorf("internal error: trusted assets called without native host-partition layout")
+	}
+	ret := make(map[string]string)
+	if !s.recovery {
+		return ret, nil
+	}
+	binary, err := s.getBootBinaryForArch()
+	if err != nil {
+		return nil, err
+	}
+	ret[binary.path] = binary.Id()
+	return ret, nil
+}
+
+// RecoveryBootChains returns the list of load chains for recovery modes.
+// It should be called on a RoleRecovery bootloader.
+func (s *sdboot) RecoveryBootChains(kernelPath string) ([][]BootFile, error) {
+	if !s.recovery {
+		return nil, fmt.Errorf("not a recovery bootloader")
+	}
+	binary, err := s.getBootBinaryForArch()
+	if err != nil {
+		return nil, err
+	}
+	return [][]BootFile{{
+		NewBootFile("", binary.path, RoleRecovery),
+		NewBootFile(kernelPath, "kernel.efi", RoleRecovery),
+	}}, nil
+}
+
+// BootChains returns the list of load chains for run mode.
+// It should be called on a RoleRecovery bootloader passing the
+// RoleRunMode bootloader.
+func (s *sdboot) BootChains(runBl Bootloader, kernelPath string) ([][]BootFile, error) {
+	if !s.recovery {
+		return nil, fmt.Errorf("not a recovery bootloader")
+	}
+	if runBl.Name() != s.Name() {
+		return nil, fmt.Errorf("run mode bootloader must be %s", s.Name())
+	}
+	binary, err := s.getBootBinaryForArch()
+	if err != nil {
+		return nil, err
+	}
+	// systemd-boot on the seed partition boots the run mode kernel
+	// from the boot partition directly
+	return [][]BootFile{{
+		NewBootFile("", binary.path, RoleRecovery),
+		NewBootFile(kernelPath, "kernel.efi", RoleRunMode),
+	}}, nil
+}
@@ -0,0 +1,426 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package bootloader_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/arch"
+	"github.com/snapcore/snapd/bootloader"
+	"github.com/snapcore/snapd/bootloader/bootloadertest"
+	"github.com/snapcore/snapd/bootloader/efi"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const (
+	loaderEntryOneShot  = "LoaderEntryOneShot-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+	loaderEntrySelected = "LoaderEntrySelected-4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
+)
+
+type sdbootTestSuite struct {
+	baseBootenvTestSuite
+
+	efiVars map[string][]byte
+}
+
+var _ = Suite(&sdbootTestSuite{})
+
+func (s *sdbootTestSuite) SetUpTest(c *C) {
+	s.baseBootenvTestSuite.SetUpTest(c)
+
+	s.efiVars = map[string][]byte{}
+	s.AddCleanup(efi.MockVars(s.efiVars, nil))
+	s.AddCleanup(efi.MockWriteVars(s.efiVars, nil))
+
+	oldArch := arch.DpkgArchitecture()
+	arch.SetArchitecture("amd64")
+	s.AddCleanup(func() { arch.SetArchitecture(arch.ArchitectureType(oldArch)) })
+}
+
+// unpackOnlyContainer is a snap container which only supports unpacking
+// of the given files.
+type unpackOnlyContainer struct {
+	snap.Container
+
+	files map[string]string
+}
+
+func (u *unpackOnlyContainer) Unpack(src, dstDir string) error {
+	content, ok := u.files[src]
+	if !ok {
+		return fmt.Errorf("cannot find %s", src)
+	}
+	return os.WriteFile(filepath.Join(dstDir, src), []byte(content), 0644)
+}
+
+func (s *sdbootTestSuite) newInstalled(c *C, opts *bootloader.Options) bootloader.Bootloader {
+	bl := bootloader.NewSdboot(s.rootdir, opts)
+	c.Assert(bl.InstallBootConfig(c.MkDir(), opts), IsNil)
+	return bl
+}
+
+func (s *sdbootTestSuite) TestNewSdboot(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true}
+	bl := bootloader.NewSdboot(s.rootdir, opts)
+	c.Assert(bl, NotNil)
+	c.Check(bl.Name(), Equals, "systemd-boot")
+
+	present, err := bl.Present()
+	c.Assert(err, IsNil)
+	c.Check(present, Equals, false)
+
+	c.Assert(bl.InstallBootConfig(c.MkDir(), opts), IsNil)
+	present, err = bl.Present()
+	c.Assert(err, IsNil)
+	c.Check(present, Equals, true)
+
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileEquals, `# This file is managed by snapd, do not edit
+timeout 0
+default snapd-run.conf
+`)
+
+	found, err := bootloader.Find(s.rootdir, opts)
+	c.Assert(err, IsNil)
+	c.Check(found.Name(), Equals, "systemd-boot")
+}
+
+func (s *sdbootTestSuite) TestForGadget(c *C) {
+	gadgetDir := c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(gadgetDir, "systemd-boot.conf"), nil, 0644), IsNil)
+
+	bl, err := bootloader.ForGadget(gadgetDir, s.rootdir, &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true})
+	c.Assert(err, IsNil)
+	c.Check(bl.Name(), Equals, "systemd-boot")
+
+	_, ok := bl.(bootloader.ExtractedRunKernelImageBootloader)
+	c.Check(ok, Equals, true)
+	_, ok = bl.(bootloader.TrustedAssetsBootloader)
+	c.Check(ok, Equals, true)
+	_, ok = bl.(bootloader.RecoveryAwareBootloader)
+	c.Check(ok, Equals, true)
+}
+
+func (s *sdbootTestSuite) TestSetRecoverySystemEnv(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true}
+	bl := s.newInstalled(c, opts).(bootloader.RecoveryAwareBootloader)
+
+	var openedPath string
+	restore := bootloader.MockSdbootOpenSnapFile(func(path string) (snap.Container, error) {
+		openedPath = path
+		return &unpackOnlyContainer{files: map[string]string{"kernel.efi": "kernel"}}, nil
+	})
+	defer restore()
+
+	err := bl.SetRecoverySystemEnv("/systems/20240101", map[string]string{
+		"snapd_recovery_kernel":    "/snaps/pc-kernel_1.snap",
+		"snapd_extra_cmdline_args": "foo=bar",
+	})
+	c.Assert(err, IsNil)
+	c.Check(openedPath, Equals, filepath.Join(s.rootdir, "/snaps/pc-kernel_1.snap"))
+	c.Check(filepath.Join(s.rootdir, "systems/20240101/kernel/kernel.efi"), testutil.FileEquals, "kernel")
+
+	for _, mode := range []string{"install", "recover", "factory-reset"} {
+		c.Check(filepath.Join(s.rootdir, "loader/entries", "snapd-"+mode+"-20240101.conf"), testutil.FileEquals,
+			fmt.Sprintf(`# This file is managed by snapd, do not edit
+title Ubuntu Core %[1]s using 20240101
+efi /systems/20240101/kernel/kernel.efi
+options snapd_recovery_mode=%[1]s snapd_recovery_system=20240101 console=ttyS0 console=tty1 panic=-1 foo=bar
+`, mode))
+	}
+
+	v, err := bl.GetRecoverySystemEnv("/systems/20240101", "snapd_extra_cmdline_args")
+	c.Assert(err, IsNil)
+	c.Check(v, Equals, "foo=bar")
+
+	v, err = bl.GetRecoverySystemEnv("/systems/not-there", "snapd_extra_cmdline_args")
+	c.Assert(err, IsNil)
+	c.Check(v, Equals, "")
+}
+
+func (s *sdbootTestSuite) TestSetRecoverySystemEnvNoKernel(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true}
+	bl := s.newInstalled(c, opts).(bootloader.RecoveryAwareBootloader)
+
+	err := bl.SetRecoverySystemEnv("/systems/20240101", map[string]string{
+		"snapd_extra_cmdline_args": "foo=bar",
+	})
+	c.Assert(err, ErrorMatches, "cannot set up recovery system /systems/20240101 without a kernel")
+}
+
+func (s *sdbootTestSuite) TestRecoveryModesPrepareImage(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery, PrepareImageTime: true}
+	bl := s.newInstalled(c, opts)
+
+	err := bl.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20240101",
+		"snapd_recovery_mode":   "install",
+	})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileContains, "default snapd-install-20240101.conf\n")
+
+	// no EFI variables are used when preparing the image
+	err = bl.SetBootVars(map[string]string{"snapd_recovery_mode": "recover"})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileContains, "default snapd-recover-20240101.conf\n")
+	c.Check(s.efiVars, HasLen, 0)
+
+	m, err := bl.GetBootVars("snapd_recovery_system", "snapd_recovery_mode")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{
+		"snapd_recovery_system": "20240101",
+		"snapd_recovery_mode":   "recover",
+	})
+}
+
+func (s *sdbootTestSuite) TestRecoveryModesRuntime(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery}
+	bl := s.newInstalled(c, opts)
+
+	err := bl.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20240101",
+		"snapd_recovery_mode":   "recover",
+	})
+	c.Assert(err, IsNil)
+	// recover mode is booted once
+	c.Check(s.efiVars[loaderEntryOneShot], DeepEquals, bootloadertest.UTF16Bytes("snapd-recover-20240101.conf"))
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileContains, "default snapd-run.conf\n")
+
+	// factory reset sticks until it is completed
+	err = bl.SetBootVars(map[string]string{"snapd_recovery_mode": "factory-reset"})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileContains, "default snapd-factory-reset-20240101.conf\n")
+
+	// going back to run mode drops the pending one-shot boot
+	err = bl.SetBootVars(map[string]string{
+		"snapd_recovery_system": "",
+		"snapd_recovery_mode":   "run",
+	})
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.rootdir, "loader/loader.conf"), testutil.FileContains, "default snapd-run.conf\n")
+	c.Check(s.efiVars, HasLen, 0)
+}
+
+func (s *sdbootTestSuite) TestRecoveryModeUnsupported(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery}
+	bl := s.newInstalled(c, opts)
+
+	err := bl.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20240101",
+		"snapd_recovery_mode":   "foo",
+	})
+	c.Assert(err, ErrorMatches, `cannot use unsupported recovery mode "foo"`)
+}
+
+func (s *sdbootTestSuite) TestRecoveryModeNoEFISystem(c *C) {
+	restore := efi.MockWriteVars(nil, nil)
+	defer restore()
+
+	opts := &bootloader.Options{Role: bootloader.RoleRecovery}
+	bl := s.newInstalled(c, opts)
+
+	err := bl.SetBootVars(map[string]string{
+		"snapd_recovery_system": "20240101",
+		"snapd_recovery_mode":   "recover",
+	})
+	c.Assert(err, ErrorMatches, "cannot request one-shot boot of snapd-recover-20240101.conf: not a supported EFI system")
+}
+
+func (s *sdbootTestSuite) makeExtractedKernel(c *C, sn snap.PlaceInfo) {
+	kernelDir := filepath.Join(s.rootdir, "EFI/ubuntu", sn.Filename())
+	c.Assert(os.MkdirAll(kernelDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(kernelDir, "kernel.efi"), nil, 0644), IsNil)
+}
+
+func (s *sdbootTestSuite) TestExtractKernelAssets(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true}
+	bl := s.newInstalled(c, opts)
+
+	kernel, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	snapf := &unpackOnlyContainer{files: map[string]string{"kernel.efi": "kernel"}}
+	c.Assert(bl.ExtractKernelAssets(kernel, snapf), IsNil)
+	c.Check(filepath.Join(s.rootdir, "EFI/ubuntu/pc-kernel_1.snap/kernel.efi"), testutil.FileEquals, "kernel")
+
+	c.Assert(bl.RemoveKernelAssets(kernel), IsNil)
+	c.Check(filepath.Join(s.rootdir, "EFI/ubuntu/pc-kernel_1.snap"), testutil.FileAbsent)
+}
+
+func (s *sdbootTestSuite) TestRunModeKernels(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true}
+	bl := s.newInstalled(c, opts).(bootloader.ExtractedRunKernelImageBootloader)
+
+	kernel, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	tryKernel, err := snap.ParsePlaceInfoFromSnapFileName("pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+
+	// the kernel must be extracted first
+	err = bl.EnableKernel(kernel)
+	c.Assert(err, ErrorMatches, "cannot enable snapd-run.conf at /EFI/ubuntu/pc-kernel_1.snap/kernel.efi: file does not exist")
+
+	s.makeExtractedKernel(c, kernel)
+	c.Assert(bl.EnableKernel(kernel), IsNil)
+	c.Check(filepath.Join(s.rootdir, "loader/entries/snapd-run.conf"), testutil.FileEquals, `# This file is managed by snapd, do not edit
+title Ubuntu Core
+efi /EFI/ubuntu/pc-kernel_1.snap/kernel.efi
+options snapd_recovery_mode=run console=ttyS0 console=tty1 panic=-1
+`)
+
+	current, err := bl.Kernel()
+	c.Assert(err, IsNil)
+	c.Check(current, DeepEquals, kernel)
+
+	_, err = bl.TryKernel()
+	c.Check(err, Equals, bootloader.ErrNoTryKernelRef)
+
+	s.makeExtractedKernel(c, tryKernel)
+	c.Assert(bl.EnableTryKernel(tryKernel), IsNil)
+	try, err := bl.TryKernel()
+	c.Assert(err, IsNil)
+	c.Check(try, DeepEquals, tryKernel)
+
+	// changing the command line updates all the entries
+	err = bl.SetBootVars(map[string]string{"snapd_extra_cmdline_args": "foo=bar"})
+	c.Assert(err, IsNil)
+	for _, entry := range []string{"snapd-run.conf", "snapd-try-run.conf"} {
+		c.Check(filepath.Join(s.rootdir, "loader/entries", entry), testutil.FileContains,
+			"options snapd_recovery_mode=run console=ttyS0 console=tty1 panic=-1 foo=bar\n")
+	}
+
+	c.Assert(bl.DisableTryKernel(), IsNil)
+	_, err = bl.TryKernel()
+	c.Check(err, Equals, bootloader.ErrNoTryKernelRef)
+	// disabling again is fine
+	c.Assert(bl.DisableTryKernel(), IsNil)
+}
+
+func (s *sdbootTestSuite) TestRunModeKernelStatus(c *C) {
+	opts := &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true}
+	bl := s.newInstalled(c, opts)
+
+	err := bl.SetBootVars(map[string]string{"kernel_status": "try"})
+	c.Assert(err, IsNil)
+	c.Check(s.efiVars[loaderEntryOneShot], DeepEquals, bootloadertest.UTF16Bytes("snapd-try-run.conf"))
+
+	// not rebooted yet
+	m, err := bl.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "try"})
+
+	// booted the try entry
+	delete(s.efiVars, loaderEntryOneShot)
+	s.efiVars[loaderEntrySelected] = bootloadertest.UTF16Bytes("snapd-try-run.conf")
+	m, err = bl.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": "trying"})
+
+	// booted the default entry, the try kernel failed
+	s.efiVars[loaderEntrySelected] = bootloadertest.UTF16Bytes("snapd-run.conf")
+	m, err = bl.GetBootVars("kernel_status")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{"kernel_status": ""})
+
+	s.efiVars[loaderEntryOneShot] = bootloadertest.UTF16Bytes("snapd-try-run.conf")
+	err = bl.SetBootVars(map[string]string{"kernel_status": ""})
+	c.Assert(err, IsNil)
+	c.Check(s.efiVars[loaderEntryOneShot], IsNil)
+}
+
+func (s *sdbootTestSuite) TestCommandLine(c *C) {
+	bl := bootloader.NewSdboot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	tbl, ok := bl.(bootloader.TrustedAssetsBootloader)
+	c.Assert(ok, Equals, true)
+
+	cmdline, err := tbl.CommandLine(bootloader.CommandLineComponents{
+		ModeArg:   "snapd_recovery_mode=recover",
+		SystemArg: "snapd_recovery_system=20240101",
+		ExtraArgs: "foo=bar",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cmdline, Equals, "snapd_recovery_mode=recover snapd_recovery_system=20240101 console=ttyS0 console=tty1 panic=-1 foo=bar")
+
+	cmdline, err = tbl.CandidateCommandLine(bootloader.CommandLineComponents{
+		ModeArg:  "snapd_recovery_mode=run",
+		FullArgs: "foo=bar",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cmdline, Equals, "snapd_recovery_mode=run foo=bar")
+
+	cmdline, err = tbl.DefaultCommandLine(false)
+	c.Assert(err, IsNil)
+	c.Check(cmdline, Equals, "console=ttyS0 console=tty1 panic=-1")
+}
+
+func (s *sdbootTestSuite) TestTrustedAssetsAndBootChains(c *C) {
+	rbl := bootloader.NewSdboot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRecovery})
+	runBl := bootloader.NewSdboot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRunMode, NoSlashBoot: true})
+	tbl := rbl.(bootloader.TrustedAssetsBootloader)
+	runTbl := runBl.(bootloader.TrustedAssetsBootloader)
+
+	c.Check(tbl.ManagedAssets(), DeepEquals, []string{"loader/loader.conf"})
+
+	ta, err := tbl.TrustedAssets()
+	c.Assert(err, IsNil)
+	c.Check(ta, DeepEquals, map[string]string{"EFI/boot/bootx64.efi": "bootx64.efi"})
+
+	ta, err = runTbl.TrustedAssets()
+	c.Assert(err, IsNil)
+	c.Check(ta, HasLen, 0)
+
+	chains, err := tbl.RecoveryBootChains("/snaps/pc-kernel_1.snap")
+	c.Assert(err, IsNil)
+	c.Check(chains, DeepEquals, [][]bootloader.BootFile{{
+		bootloader.NewBootFile("", "EFI/boot/bootx64.efi", bootloader.RoleRecovery),
+		bootloader.NewBootFile("/snaps/pc-kernel_1.snap", "kernel.efi", bootloader.RoleRecovery),
+	}})
+
+	chains, err = tbl.BootChains(runBl, "/snaps/pc-kernel_2.snap")
+	c.Assert(err, IsNil)
+	c.Check(chains, DeepEquals, [][]bootloader.BootFile{{
+		bootloader.NewBootFile("", "EFI/boot/bootx64.efi", bootloader.RoleRecovery),
+		bootloader.NewBootFile("/snaps/pc-kernel_2.snap", "kernel.efi", bootloader.RoleRunMode),
+	}})
+
+	_, err = tbl.BootChains(bootloader.NewGrub(s.rootdir, nil), "/snaps/pc-kernel_2.snap")
+	c.Check(err, ErrorMatches, "run mode bootloader must be systemd-boot")
+
+	_, err = runTbl.RecoveryBootChains("/snaps/pc-kernel_1.snap")
+	c.Check(err, ErrorMatches, "not a recovery bootloader")
+
+	_, err = runTbl.BootChains(runBl, "/snaps/pc-kernel_1.snap")
+	c.Check(err, ErrorMatches, "not a recovery bootloader")
+
+	slashBootBl := bootloader.NewSdboot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRunMode})
+	_, err = slashBootBl.(bootloader.TrustedAssetsBootloader).TrustedAssets()
+	c.Check(err, ErrorMatches, "internal error: trusted assets called without native host-partition layout")
+}
+
+func (s *sdbootTestSuite) TestTrustedAssetsUnsupportedArch(c *C) {
+	arch.SetArchitecture("non-existing-architecture")
+
+	tbl := bootloader.NewSdboot(s.rootdir, &bootloader.Options{Role: bootloader.RoleRecovery}).(bootloader.TrustedAssetsBootloader)
+	_, err := tbl.TrustedAssets()
+	c.Check(err, ErrorMatches, `cannot find systemd-boot assets for "non-existing-architecture"`)
+}
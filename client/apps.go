@@ -31,6 +31,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/snap"
 )
@@ -39,7 +40,8 @@ import (
 // system.
 type AppActivator struct {
 	Name string
-	// Type describes the type of the unit, either timer or socket
+	// Type describes the type of the activator, either timer, socket,
+	// path or dbus
 	Type    string
 	Active  bool
 	Enabled bool
@@ -56,6 +58,28 @@ type AppInfo struct {
 	Active      bool             `json:"active,omitempty"`
 	CommonID    string           `json:"common-id,omitempty"`
 	Activators  []AppActivator   `json:"activators,omitempty"`
+	Resources   *AppResources    `json:"resources,omitempty"`
+	// RestartStats is only set for system services, when requested.
+	RestartStats *AppRestartStats `json:"restart-stats,omitempty"`
+}
+
+// AppResources describes the resources a service declares it expects to
+// use.
+type AppResources struct {
+	ExpectedMemory quantity.Size `json:"expected-memory,omitempty"`
+	MaxMemory      quantity.Size `json:"max-memory,omitempty"`
+	CPUShare       int           `json:"cpu-share,omitempty"`
+	Threads        int           `json:"threads,omitempty"`
+}
+
+// AppRestartStats describes the exits and automatic restarts of a service
+// since it was last started.
+type AppRestartStats struct {
+	Failed     bool       `json:"failed,omitempty"`
+	Restarts   int        `json:"restarts"`
+	ExitCode   int        `json:"exit-code,omitempty"`
+	ExitSignal string     `json:"exit-signal,omitempty"`
+	ExitTime   *time.Time `json:"exit-time,omitempty"`
 }
 
 // IsService returns true if the application is a background daemon.
@@ -80,6 +104,9 @@ type AppOptions struct {
 	// of the services for the current user, or the global enable status.
 	// For root-users, global is always implied.
 	Global bool
+	// RestartStats if set, also returns the restart statistics of the
+	// system services, see AppRestartStats.
+	RestartStats bool
 }
 
 // Apps returns information about all matching apps. Each name can be
@@ -96,6 +123,9 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 	if opts.Global {
 		q.Add("global", fmt.Sprintf("%t", opts.Global))
 	}
+	if opts.RestartStats {
+		q.Add("restart-stats", fmt.Sprintf("%t", opts.RestartStats))
+	}
 
 	var appInfos []*AppInfo
 	_, err := client.doSync("GET", "/v2/apps", q, nil, nil, &appInfos)
@@ -105,8 +135,14 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 
 // LogOptions represent the options of the Logs call.
 type LogOptions struct {
-	N      int  // The maximum number of log lines to retrieve initially. If <0, no limit.
-	Follow bool // Whether to continue returning new lines as they appear
+	N         int       // The maximum number of log lines to retrieve initially. If <0, no limit.
+	Follow    bool      // Whether to continue returning new lines as they appear
+	Since     time.Time // If set, only return entries not older than this
+	Until     time.Time // If set, only return entries not newer than this
+	Priority  string    // If set, only return entries of at least this priority, by name or number
+	Grep      string    // If set, only return entries whose message matches this regular expression
+	Boot      string    // If set, only return entries of the given boot, by offset or ID
+	AllFields bool      // Whether to return all the journal fields of the entries
 }
 
 // A Log holds the information of a single syslog entry
@@ -115,6 +151,8 @@ type Log struct {
 	Message   string    `json:"message"`   // The log message itself
 	SID       string    `json:"sid"`       // The syslog identifier
 	PID       string    `json:"pid"`       // The process identifier
+	// All the journal fields of the entry, only set if requested
+	Fields map[string]*json.RawMessage `json:"fields,omitempty"`
 }
 
 // String will format the log entry with the timestamp in the local timezone
@@ -145,6 +183,24 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 	if opts.Follow {
 		query.Set("follow", strconv.FormatBool(opts.Follow))
 	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339))
+	}
+	if opts.Priority != "" {
+		query.Set("priority", opts.Priority)
+	}
+	if opts.Grep != "" {
+		query.Set("grep", opts.Grep)
+	}
+	if opts.Boot != "" {
+		query.Set("boot", opts.Boot)
+	}
+	if opts.AllFields {
+		query.Set("fields", "all")
+	}
 
 	rsp, err := client.raw(context.Background(), "GET", "/v2/logs", query, nil, nil)
 	if err != nil {
@@ -400,3 +456,21 @@ func (client *Client) Restart(names []string, scope ScopeSelector, users UserSel
 	}
 	return client.doAsync("POST", "/v2/apps", nil, nil, bytes.NewReader(buf))
 }
+
+// Linger enables, or disables, systemd lingering for the given users, so
+// that their snap user services run without them being logged in, including
+// on boot.
+func (client *Client) Linger(users UserSelector, enable bool) (changeID string, err error) {
+	action := "linger"
+	if !enable {
+		action = "unlinger"
+	}
+	buf, err := json.Marshal(appInstruction{
+		Action: action,
+		Users:  users,
+	})
+	if err != nil {
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/apps", nil, nil, bytes.NewReader(buf))
+}
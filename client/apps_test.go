@@ -22,8 +22,10 @@ package client_test
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -77,7 +79,20 @@ func testClientAppsGlobal(cs *clientSuite, c *check.C) ([]*client.AppInfo, error
 	return services, err
 }
 
-var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService, testClientAppsGlobal}
+func testClientAppsRestartStats(cs *clientSuite, c *check.C) ([]*client.AppInfo, error) {
+	services, err := cs.cli.Apps([]string{"foo", "bar"}, client.AppOptions{Service: true, RestartStats: true})
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+	c.Check(cs.req.Method, check.Equals, "GET")
+	query := cs.req.URL.Query()
+	c.Check(query, check.HasLen, 3)
+	c.Check(query.Get("names"), check.Equals, "foo,bar")
+	c.Check(query.Get("select"), check.Equals, "service")
+	c.Check(query.Get("restart-stats"), check.Equals, "true")
+
+	return services, err
+}
+
+var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService, testClientAppsGlobal, testClientAppsRestartStats}
 
 func (cs *clientSuite) TestClientServiceGetHappy(c *check.C) {
 	expected := []*client.AppInfo{mksvc("foo", "foo"), mksvc("bar", "bar1")}
@@ -116,6 +131,27 @@ func (cs *clientSuite) TestClientAppCommonID(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientAppRestartStats(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"snap": "foo", "name": "svc", "daemon": "simple",
+		"restart-stats": {"failed": true, "restarts": 3, "exit-signal": "SIGSEGV", "exit-time": "2026-10-19T10:02:00Z"}
+	}]}`
+	actual, err := testClientAppsRestartStats(cs, c)
+	c.Assert(err, check.IsNil)
+	exitTime := time.Date(2026, time.October, 19, 10, 2, 0, 0, time.UTC)
+	c.Check(actual, check.DeepEquals, []*client.AppInfo{{
+		Snap:   "foo",
+		Name:   "svc",
+		Daemon: "simple",
+		RestartStats: &client.AppRestartStats{
+			Failed:     true,
+			Restarts:   3,
+			ExitSignal: "SIGSEGV",
+			ExitTime:   &exitTime,
+		},
+	}})
+}
+
 func testClientLogs(cs *clientSuite, c *check.C) ([]client.Log, error) {
 	ch, err := cs.cli.Logs([]string{"foo", "bar"}, client.LogOptions{N: -1, Follow: false})
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/logs")
@@ -222,6 +258,43 @@ func (cs *clientSuite) TestClientLogsOpts(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientLogsFilterOpts(c *check.C) {
+	ch, err := cs.cli.Logs([]string{"foo"}, client.LogOptions{
+		N:         10,
+		Since:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:     time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Priority:  "err",
+		Grep:      "fail",
+		Boot:      "-1",
+		AllFields: true,
+	})
+	c.Assert(err, check.IsNil)
+	for range ch {
+		// drain the channel
+	}
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"names":    {"foo"},
+		"n":        {"10"},
+		"since":    {"2026-01-01T00:00:00Z"},
+		"until":    {"2026-01-02T00:00:00Z"},
+		"priority": {"err"},
+		"grep":     {"fail"},
+		"boot":     {"-1"},
+		"fields":   {"all"},
+	})
+}
+
+func (cs *clientSuite) TestClientLogsAllFields(c *check.C) {
+	cs.rsp = "\x1e" + `{"message":"hello","fields":{"MESSAGE":"hello","PRIORITY":"6"}}` + "\n"
+
+	logs, err := testClientLogs(cs, c)
+	c.Assert(err, check.IsNil)
+	c.Assert(logs, check.HasLen, 1)
+	c.Check(logs[0].Message, check.Equals, "hello")
+	c.Check(logs[0].Fields, check.HasLen, 2)
+	c.Check(string(*logs[0].Fields["PRIORITY"]), check.Equals, `"6"`)
+}
+
 func (cs *clientSuite) TestClientLogsNotFound(c *check.C) {
 	cs.rsp = `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"snap \"foo\" not found","kind":"snap-not-found","value":"foo"}}`
 	cs.status = 404
@@ -679,3 +752,30 @@ func (s *scopeSelectorSuite) TestScopeUnmarshalUser(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(us, check.DeepEquals, client.ScopeSelector{"user"})
 }
+
+func (cs *clientSuite) TestClientLinger(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "24"}`
+
+	for _, t := range []struct {
+		users  client.UserSelector
+		enable bool
+		action string
+		json   interface{}
+	}{
+		{client.UserSelector{Names: []string{"foo", "bar"}}, true, "linger", []interface{}{"foo", "bar"}},
+		{client.UserSelector{Selector: client.UserSelectionSelf}, false, "unlinger", "self"},
+	} {
+		id, err := cs.cli.Linger(t.users, t.enable)
+		c.Assert(err, check.IsNil)
+		c.Check(id, check.Equals, "24")
+		c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+		c.Check(cs.req.Method, check.Equals, "POST")
+
+		var reqOp map[string]interface{}
+		c.Assert(json.NewDecoder(cs.req.Body).Decode(&reqOp), check.IsNil)
+		c.Check(reqOp["action"], check.Equals, t.action)
+		c.Check(reqOp["names"], check.IsNil)
+		c.Check(reqOp["users"], check.DeepEquals, t.json)
+	}
+}
@@ -787,6 +787,83 @@ func (client *Client) SystemRecoveryKeys(result interface{}) error {
 	return err
 }
 
+// KeySlot describes a used key slot of an encrypted volume.
+type KeySlot struct {
+	ID int `json:"id"`
+	// Type describes what the key slot is used for, e.g.
+	// "encryption-key", "recovery-key" or "named-recovery-key".
+	Type string `json:"type"`
+	// Name and Created are only set for named recovery keys.
+	Name    string     `json:"name,omitempty"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// SystemRecoveryKeySlotsResponse holds the used key slots of the encrypted
+// volumes, indexed by the volume name.
+type SystemRecoveryKeySlotsResponse struct {
+	Volumes map[string][]KeySlot `json:"volumes"`
+}
+
+// NamedRecoveryKey holds a newly added or rotated named recovery key.
+type NamedRecoveryKey struct {
+	Name        string `json:"name"`
+	RecoveryKey string `json:"recovery-key"`
+}
+
+// SystemRecoveryKeySlots lists the used key slots of the encrypted volumes.
+func (client *Client) SystemRecoveryKeySlots() (*SystemRecoveryKeySlotsResponse, error) {
+	q := url.Values{"select": []string{"key-slots"}}
+	var slots SystemRecoveryKeySlotsResponse
+	if _, err := client.doSync("GET", "/v2/system-recovery-keys", q, nil, nil, &slots); err != nil {
+		return nil, fmt.Errorf("cannot list recovery key slots: %v", err)
+	}
+	return &slots, nil
+}
+
+func (client *Client) namedRecoveryKeyAction(action, name string, result interface{}) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(struct {
+		Action string `json:"action"`
+		Name   string `json:"name"`
+	}{
+		Action: action,
+		Name:   name,
+	}); err != nil {
+		return err
+	}
+	_, err := client.doSync("POST", "/v2/system-recovery-keys", nil, nil, &body, result)
+	return err
+}
+
+// AddRecoveryKey adds a new recovery key with the given name to the encrypted
+// volumes and returns it.
+func (client *Client) AddRecoveryKey(name string) (string, error) {
+	var key NamedRecoveryKey
+	if err := client.namedRecoveryKeyAction("add", name, &key); err != nil {
+		return "", fmt.Errorf("cannot add recovery key %q: %v", name, err)
+	}
+	return key.RecoveryKey, nil
+}
+
+// RotateRecoveryKey replaces the recovery key with the given name with a newly
+// generated one and returns it.
+func (client *Client) RotateRecoveryKey(name string) (string, error) {
+	var key NamedRecoveryKey
+	if err := client.namedRecoveryKeyAction("rotate", name, &key); err != nil {
+		return "", fmt.Errorf("cannot rotate recovery key %q: %v", name, err)
+	}
+	return key.RecoveryKey, nil
+}
+
+// RemoveRecoveryKey removes the recovery key with the given name from the
+// encrypted volumes.
+func (client *Client) RemoveRecoveryKey(name string) error {
+	if err := client.namedRecoveryKeyAction("remove", name, nil); err != nil {
+		return fmt.Errorf("cannot remove recovery key %q: %v", name, err)
+	}
+	return nil
+}
+
 func (c *Client) MigrateSnapHome(snaps []string) (changeID string, err error) {
 	body, err := json.Marshal(struct {
 		Action string   `json:"action"`
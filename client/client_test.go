@@ -696,6 +696,83 @@ func (cs *clientSuite) TestClientSystemRecoveryKeys(c *C) {
 	c.Check(key.RecoveryKey, Equals, "42")
 }
 
+func (cs *clientSuite) TestClientSystemRecoveryKeySlots(c *C) {
+	cs.rsp = `{"type":"sync", "result":{"volumes":{"ubuntu-data":[{"id":0,"type":"encryption-key"},{"id":4,"type":"named-recovery-key","name":"backup","created":"2026-01-02T03:04:05Z"}]}}}`
+
+	slots, err := cs.cli.SystemRecoveryKeySlots()
+	c.Assert(err, IsNil)
+	c.Check(cs.reqs, HasLen, 1)
+	c.Check(cs.reqs[0].Method, Equals, "GET")
+	c.Check(cs.reqs[0].URL.Path, Equals, "/v2/system-recovery-keys")
+	c.Check(cs.reqs[0].URL.Query(), DeepEquals, url.Values{"select": []string{"key-slots"}})
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Check(slots, DeepEquals, &client.SystemRecoveryKeySlotsResponse{
+		Volumes: map[string][]client.KeySlot{
+			"ubuntu-data": {
+				{ID: 0, Type: "encryption-key"},
+				{ID: 4, Type: "named-recovery-key", Name: "backup", Created: &created},
+			},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientSystemRecoveryKeySlotsError(c *C) {
+	cs.status = 500
+	cs.rsp = `{"type":"error","result":{"message":"boom"}}`
+
+	_, err := cs.cli.SystemRecoveryKeySlots()
+	c.Assert(err, ErrorMatches, "cannot list recovery key slots: boom")
+}
+
+func (cs *clientSuite) TestClientAddRecoveryKey(c *C) {
+	cs.rsp = `{"type":"sync", "result":{"name":"backup","recovery-key":"11111-22222"}}`
+
+	key, err := cs.cli.AddRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	c.Check(key, Equals, "11111-22222")
+	c.Assert(cs.reqs, HasLen, 1)
+	c.Check(cs.reqs[0].Method, Equals, "POST")
+	c.Check(cs.reqs[0].URL.Path, Equals, "/v2/system-recovery-keys")
+	var req map[string]interface{}
+	c.Assert(json.NewDecoder(cs.reqs[0].Body).Decode(&req), IsNil)
+	c.Check(req, DeepEquals, map[string]interface{}{
+		"action": "add",
+		"name":   "backup",
+	})
+}
+
+func (cs *clientSuite) TestClientRotateRecoveryKey(c *C) {
+	cs.rsp = `{"type":"sync", "result":{"name":"backup","recovery-key":"33333-44444"}}`
+
+	key, err := cs.cli.RotateRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	c.Check(key, Equals, "33333-44444")
+	var req map[string]interface{}
+	c.Assert(json.NewDecoder(cs.reqs[0].Body).Decode(&req), IsNil)
+	c.Check(req, DeepEquals, map[string]interface{}{
+		"action": "rotate",
+		"name":   "backup",
+	})
+}
+
+func (cs *clientSuite) TestClientRemoveRecoveryKey(c *C) {
+	cs.rsp = `{"type":"sync", "result":null}`
+
+	err := cs.cli.RemoveRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	var req map[string]interface{}
+	c.Assert(json.NewDecoder(cs.reqs[0].Body).Decode(&req), IsNil)
+	c.Check(req, DeepEquals, map[string]interface{}{
+		"action": "remove",
+		"name":   "backup",
+	})
+
+	cs.status = 400
+	cs.rsp = `{"type":"error","result":{"message":"recovery key \"backup\" does not exist"}}`
+	err = cs.cli.RemoveRecoveryKey("backup")
+	c.Assert(err, ErrorMatches, `cannot remove recovery key "backup": recovery key "backup" does not exist`)
+}
+
 func (cs *clientSuite) TestClientDebugEnvVar(c *C) {
 	buf, restore := logger.MockLogger()
 	defer restore()
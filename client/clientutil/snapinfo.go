@@ -96,11 +96,11 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 		return "-"
 	}
 
-	var notes = make([]string, 0, 4)
+	var notes = make([]string, 0, 5)
 	if app.DaemonScope == snap.UserDaemon {
 		notes = append(notes, "user")
 	}
-	var seenTimer, seenSocket, seenDbus bool
+	var seenTimer, seenSocket, seenDbus, seenPath bool
 	for _, act := range app.Activators {
 		switch act.Type {
 		case "timer":
@@ -109,6 +109,8 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 			seenSocket = true
 		case "dbus":
 			seenDbus = true
+		case "path":
+			seenPath = true
 		}
 	}
 	if seenTimer {
@@ -120,6 +122,9 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 	if seenDbus {
 		notes = append(notes, "dbus-activated")
 	}
+	if seenPath {
+		notes = append(notes, "path-activated")
+	}
 	if len(notes) == 0 {
 		return "-"
 	}
@@ -145,6 +150,14 @@ func ClientAppInfosFromSnapAppInfos(apps []*snap.AppInfo, decorator StatusDecora
 
 		appInfo.Daemon = app.Daemon
 		appInfo.DaemonScope = app.DaemonScope
+		if res := app.Resources; res != nil {
+			appInfo.Resources = &client.AppResources{
+				ExpectedMemory: res.ExpectedMemory,
+				MaxMemory:      res.MaxMemory,
+				CPUShare:       res.CPUShare,
+				Threads:        res.Threads,
+			}
+		}
 		if !app.IsService() || decorator == nil || !app.Snap.IsActive() {
 			out = append(out, appInfo)
 			continue
@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -196,6 +197,13 @@ func (*cmdSuite) TestClientSnapFromSnapInfoAppsInactive(c *C) {
 		"svc": {Snap: si, Name: "svc", Daemon: "simple", DaemonScope: snap.SystemDaemon},
 		"app": {Snap: si, Name: "app", CommonID: "common.id"},
 	}
+	si.Apps["svc"].Resources = &snap.ResourceHints{
+		App:            si.Apps["svc"],
+		ExpectedMemory: quantity.SizeMiB,
+		MaxMemory:      quantity.SizeGiB,
+		CPUShare:       50,
+		Threads:        8,
+	}
 	// validity
 	c.Check(si.IsActive(), Equals, false)
 	// desktop file
@@ -222,6 +230,12 @@ func (*cmdSuite) TestClientSnapFromSnapInfoAppsInactive(c *C) {
 			Name:        "svc",
 			Daemon:      "simple",
 			DaemonScope: snap.SystemDaemon,
+			Resources: &client.AppResources{
+				ExpectedMemory: quantity.SizeMiB,
+				MaxMemory:      quantity.SizeGiB,
+				CPUShare:       50,
+				Threads:        8,
+			},
 		},
 	})
 	// not called on inactive snaps
@@ -307,6 +321,14 @@ func (*cmdSuite) TestAppStatusNotes(c *C) {
 	}
 	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "dbus-activated")
 
+	ai = client.AppInfo{
+		Daemon: "simple",
+		Activators: []client.AppActivator{
+			{Type: "path"},
+		},
+	}
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "path-activated")
+
 	// check that the output is stable regardless of the order of activators
 	ai = client.AppInfo{
 		Daemon: "oneshot",
@@ -321,10 +343,11 @@ func (*cmdSuite) TestAppStatusNotes(c *C) {
 		Daemon:      "oneshot",
 		DaemonScope: snap.UserDaemon,
 		Activators: []client.AppActivator{
+			{Type: "path"},
 			{Type: "dbus"},
 			{Type: "socket"},
 			{Type: "timer"},
 		},
 	}
-	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "user,timer-activated,socket-activated,dbus-activated")
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "user,timer-activated,socket-activated,dbus-activated,path-activated")
 }
@@ -35,6 +35,8 @@ type postQuotaData struct {
 	Snaps       []string     `json:"snaps,omitempty"`
 	Services    []string     `json:"services,omitempty"`
 	Constraints *QuotaValues `json:"constraints,omitempty"`
+
+	FromSnapHints bool `json:"from-snap-hints,omitempty"`
 }
 
 type QuotaGroupResult struct {
@@ -84,6 +86,9 @@ type EnsureQuotaOptions struct {
 	// Constraints are the resource limits that should be applied to the quota group,
 	// these are added or modified, not removed.
 	Constraints *QuotaValues
+	// FromSnapHints computes the constraints which are not given from the
+	// resources the snaps of the quota group declare they expect to use.
+	FromSnapHints bool
 }
 
 // EnsureQuota creates a quota group or updates an existing group with the options
@@ -105,6 +110,8 @@ func (client *Client) EnsureQuota(groupName string, opts *EnsureQuotaOptions) (c
 		Snaps:       opts.Snaps,
 		Services:    opts.Services,
 		Constraints: opts.Constraints,
+
+		FromSnapHints: opts.FromSnapHints,
 	}
 
 	var body bytes.Buffer
@@ -109,6 +109,33 @@ func (cs *clientSuite) TestEnsureQuotaGroup(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestEnsureQuotaGroupFromSnapHints(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+
+	chgID, err := cs.cli.EnsureQuota("foo", &client.EnsureQuotaOptions{
+		Snaps:         []string{"snap-a"},
+		FromSnapHints: true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(chgID, check.Equals, "42")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = jsonutil.DecodeWithNumber(bytes.NewReader(body), &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action":          "ensure",
+		"group-name":      "foo",
+		"snaps":           []interface{}{"snap-a"},
+		"from-snap-hints": true,
+	})
+}
+
 func (cs *clientSuite) TestEnsureQuotaGroupError(c *check.C) {
 	cs.status = 500
 	cs.rsp = `{"type": "error"}`
@@ -89,6 +89,10 @@ type Snapshot struct {
 	// newer snapd just updates this flag on the fly for snapshots
 	// returned by List().
 	Auto bool `json:"auto,omitempty"`
+
+	// set if the snapshot was requested by the snap itself through
+	// snapctl; like Auto, this is decorated by List().
+	SnapInitiated bool `json:"snap-initiated,omitempty"`
 }
 
 // IsValid checks whether the snapshot is missing information that
@@ -104,6 +108,7 @@ func (sh *Snapshot) ContentHash() ([]byte, error) {
 	sh2.SetID = 0
 	sh2.Time = time.Time{}
 	sh2.Auto = false
+	sh2.SnapInitiated = false
 	sh2.Options = nil
 	h := sha256.New()
 	enc := json.NewEncoder(h)
@@ -27,6 +27,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/snap"
 )
 
@@ -241,6 +242,10 @@ type InstallSystemOptions struct {
 	// snaps and components, provide an empty OptionalInstallRequest with the
 	// All field set to false.
 	OptionalInstall *OptionalInstallRequest `json:"optional-install,omitempty"`
+	// VolumesAuth contains the authentication options, e.g. a
+	// passphrase, for the encrypted volumes. It is only relevant for
+	// the "setup-storage-encryption" step.
+	VolumesAuth *device.VolumesAuthOptions `json:"volumes-auth,omitempty"`
 }
 
 type OptionalInstallRequest struct {
@@ -276,6 +281,69 @@ func (client *Client) InstallSystem(systemLabel string, opts *InstallSystemOptio
 	return chgID, nil
 }
 
+// FactoryResetOptions holds the options for a factory reset.
+type FactoryResetOptions struct {
+	// PreserveSnaps lists the snaps whose data is carried across the
+	// factory reset.
+	PreserveSnaps []string `json:"preserve-snaps,omitempty"`
+}
+
+// FactoryReset requests a factory reset of the device into the given
+// recovery system. When snaps are to be preserved, their data is saved
+// first by a change whose ID is returned, otherwise the device restarts
+// into factory reset mode right away and no change ID is returned.
+func (client *Client) FactoryReset(systemLabel string, opts *FactoryResetOptions) (changeID string, err error) {
+	if systemLabel == "" {
+		return "", fmt.Errorf("cannot factory reset without the system")
+	}
+	if opts == nil || len(opts.PreserveSnaps) == 0 {
+		return "", client.DoSystemAction(systemLabel, &SystemAction{Mode: "factory-reset"})
+	}
+
+	req := struct {
+		Action string `json:"action"`
+		Mode   string `json:"mode"`
+		*FactoryResetOptions
+	}{
+		Action:              "do",
+		Mode:                "factory-reset",
+		FactoryResetOptions: opts,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&req); err != nil {
+		return "", err
+	}
+	chgID, err := client.doAsync("POST", "/v2/systems/"+systemLabel, nil, nil, &body)
+	if err != nil {
+		return "", xerrors.Errorf("cannot request factory reset into %q: %v", systemLabel, err)
+	}
+	return chgID, nil
+}
+
+// ChangeEncryptionPassphrase changes the passphrase used to unlock the
+// encrypted volumes of the running system.
+func (client *Client) ChangeEncryptionPassphrase(oldPassphrase, newPassphrase string) error {
+	req := struct {
+		Action        string `json:"action"`
+		OldPassphrase string `json:"old-passphrase"`
+		NewPassphrase string `json:"new-passphrase"`
+	}{
+		Action:        "change-passphrase",
+		OldPassphrase: oldPassphrase,
+		NewPassphrase: newPassphrase,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&req); err != nil {
+		return err
+	}
+	if _, err := client.doSync("POST", "/v2/system-volumes", nil, nil, &body, nil); err != nil {
+		return xerrors.Errorf("cannot change encryption passphrase: %v", err)
+	}
+	return nil
+}
+
 // CreateSystemOptions contains the options for creating a new recovery system.
 type CreateSystemOptions struct {
 	// Label is the label of the new system.
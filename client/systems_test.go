@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/snap"
 )
 
@@ -171,6 +172,70 @@ func (cs *clientSuite) TestRequestSystemActionInvalid(c *check.C) {
 	c.Assert(err, check.ErrorMatches, "cannot request an action without one")
 }
 
+func (cs *clientSuite) TestFactoryResetPreserveSnapsHappy(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+	    "type": "async",
+	    "status-code": 202,
+	    "change": "42"
+	}`
+	chgID, err := cs.cli.FactoryReset("1234", &client.FactoryResetOptions{
+		PreserveSnaps: []string{"site-config"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems/1234")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action":         "do",
+		"mode":           "factory-reset",
+		"preserve-snaps": []interface{}{"site-config"},
+	})
+}
+
+func (cs *clientSuite) TestFactoryResetNoPreservedSnaps(c *check.C) {
+	cs.rsp = `{
+	    "type": "sync",
+	    "status-code": 200,
+	    "result": {}
+	}`
+	chgID, err := cs.cli.FactoryReset("1234", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action": "do",
+		"mode":   "factory-reset",
+	})
+}
+
+func (cs *clientSuite) TestFactoryResetError(c *check.C) {
+	_, err := cs.cli.FactoryReset("", nil)
+	c.Assert(err, check.ErrorMatches, "cannot factory reset without the system")
+
+	cs.status = 400
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 400,
+	    "result": {"message": "snap is not listed in the model"}
+	}`
+	_, err = cs.cli.FactoryReset("1234", &client.FactoryResetOptions{
+		PreserveSnaps: []string{"other"},
+	})
+	c.Assert(err, check.ErrorMatches, `cannot request factory reset into "1234": snap is not listed in the model`)
+}
+
 func (cs *clientSuite) TestRequestSystemRebootHappy(c *check.C) {
 	cs.rsp = `{
 	    "type": "sync",
@@ -414,3 +479,69 @@ func (cs *clientSuite) TestRequestSystemInstallHappy(c *check.C) {
 		},
 	})
 }
+
+func (cs *clientSuite) TestRequestSystemInstallVolumesAuth(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42"
+	}`
+	opts := &client.InstallSystemOptions{
+		Step: client.InstallStepSetupStorageEncryption,
+		VolumesAuth: &device.VolumesAuthOptions{
+			Mode:       device.AuthModePassphrase,
+			Passphrase: "secret",
+		},
+	}
+	chgID, err := cs.cli.InstallSystem("1234", opts)
+	c.Assert(err, check.IsNil)
+	c.Assert(chgID, check.Equals, "42")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action": "install",
+		"step":   "setup-storage-encryption",
+		"volumes-auth": map[string]interface{}{
+			"mode":       "passphrase",
+			"passphrase": "secret",
+		},
+	})
+}
+
+func (cs *clientSuite) TestChangeEncryptionPassphrase(c *check.C) {
+	cs.rsp = `{
+	    "type": "sync",
+	    "status-code": 200,
+	    "result": {}
+	}`
+	err := cs.cli.ChangeEncryptionPassphrase("old", "new")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-volumes")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action":         "change-passphrase",
+		"old-passphrase": "old",
+		"new-passphrase": "new",
+	})
+}
+
+func (cs *clientSuite) TestChangeEncryptionPassphraseError(c *check.C) {
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 500,
+	    "result": {"message": "failed"}
+	}`
+	err := cs.cli.ChangeEncryptionPassphrase("old", "new")
+	c.Assert(err, check.ErrorMatches, `cannot change encryption passphrase: failed`)
+}
@@ -103,6 +103,34 @@ static void test_feature_hidden_snap_folder(void)
 	g_assert_true(sc_feature_enabled(SC_FEATURE_HIDDEN_SNAP_FOLDER));
 }
 
+static void test_feature_landlock(void)
+{
+	const char *d = sc_testdir();
+	sc_mock_feature_flag_dir(d);
+
+	g_assert_false(sc_feature_enabled(SC_FEATURE_LANDLOCK));
+
+	char pname[PATH_MAX];
+	sc_must_snprintf(pname, sizeof pname, "%s/landlock", d);
+	g_assert_true(g_file_set_contents(pname, "", -1, NULL));
+
+	g_assert_true(sc_feature_enabled(SC_FEATURE_LANDLOCK));
+}
+
+static void test_feature_selinux_confinement(void)
+{
+	const char *d = sc_testdir();
+	sc_mock_feature_flag_dir(d);
+
+	g_assert_false(sc_feature_enabled(SC_FEATURE_SELINUX_CONFINEMENT));
+
+	char pname[PATH_MAX];
+	sc_must_snprintf(pname, sizeof pname, "%s/selinux-confinement", d);
+	g_assert_true(g_file_set_contents(pname, "", -1, NULL));
+
+	g_assert_true(sc_feature_enabled(SC_FEATURE_SELINUX_CONFINEMENT));
+}
+
 static void __attribute__((constructor)) init(void)
 {
 	g_test_add_func("/feature/missing_dir",
@@ -115,4 +143,7 @@ static void __attribute__((constructor)) init(void)
 			test_feature_parallel_instances);
 	g_test_add_func("/feature/hidden_snap_folder",
 			test_feature_hidden_snap_folder);
+	g_test_add_func("/feature/landlock", test_feature_landlock);
+	g_test_add_func("/feature/selinux_confinement",
+			test_feature_selinux_confinement);
 }
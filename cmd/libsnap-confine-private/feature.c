@@ -46,6 +46,12 @@ bool sc_feature_enabled(sc_feature_flag flag)
 	case SC_FEATURE_HIDDEN_SNAP_FOLDER:
 		file_name = "hidden-snap-folder";
 		break;
+	case SC_FEATURE_LANDLOCK:
+		file_name = "landlock";
+		break;
+	case SC_FEATURE_SELINUX_CONFINEMENT:
+		file_name = "selinux-confinement";
+		break;
 	default:
 		die("unknown feature flag code %d", flag);
 	}
@@ -360,7 +360,7 @@ func doInstall(mst *initramfsMountsState, model *asserts.Model, sysSnaps map[sna
 	}
 
 	if useEncryption {
-		if err := install.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, trustedInstallObserver); err != nil {
+		if err := install.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, nil, trustedInstallObserver); err != nil {
 			return err
 		}
 	}
@@ -661,9 +661,10 @@ const (
 	partitionUnlocked     = "unlocked"
 	partitionErrUnlocking = "error-unlocking"
 	// keys used to unlock for UnlockKey
-	keyRun      = "run"
-	keyFallback = "fallback"
-	keyRecovery = "recovery"
+	keyRun        = "run"
+	keyFallback   = "fallback"
+	keyRecovery   = "recovery"
+	keyPassphrase = "passphrase"
 )
 
 // partitionState is the state of a partition after recover mode has completed
@@ -925,6 +926,9 @@ func (m *recoverModeStateMachine) setUnlockStateWithRunKey(partName string, unlo
 		// unlocked successfully
 		part.UnlockState = partitionUnlocked
 		part.UnlockKey = keyRun
+		if unlockRes.UnlockMethod == secboot.UnlockedWithPassphrase {
+			part.UnlockKey = keyPassphrase
+		}
 	}
 
 	return nil
@@ -1015,6 +1019,8 @@ func (m *recoverModeStateMachine) setUnlockStateWithFallbackKey(partName string,
 			part.UnlockKey = keyFallback
 		case secboot.UnlockedWithRecoveryKey:
 			part.UnlockKey = keyRecovery
+		case secboot.UnlockedWithPassphrase:
+			part.UnlockKey = keyPassphrase
 
 			// TODO: should we fail with internal error for default case here?
 		}
@@ -1165,6 +1171,7 @@ func (m *recoverModeStateMachine) unlockDataRunKey() (stateFunc, error) {
 		// recovery key after we first try the fallback object
 		AllowRecoveryKey: false,
 		WhichModel:       m.whichModel,
+		PassphraseAuth:   device.HasPassphraseAuthMarkerUnder(boot.InitramfsBootEncryptionKeyDir),
 	}
 	unlockRes, unlockErr := secbootUnlockVolumeUsingSealedKeyIfEncrypted(m.disk, "ubuntu-data", runModeKey, unlockOpts)
 	if err := m.setUnlockStateWithRunKey("ubuntu-data", unlockRes, unlockErr); err != nil {
@@ -1205,6 +1212,7 @@ func (m *recoverModeStateMachine) unlockDataFallbackKey() (stateFunc, error) {
 		// to unlock data
 		AllowRecoveryKey: true,
 		WhichModel:       m.whichModel,
+		PassphraseAuth:   device.HasPassphraseAuthMarkerUnder(boot.InitramfsSeedEncryptionKeyDir),
 	}
 	// TODO: this prompts for a recovery key
 	// TODO: we should somehow customize the prompt to mention what key we need
@@ -1339,6 +1347,7 @@ func (m *recoverModeStateMachine) unlockEncryptedSaveFallbackKey() (stateFunc, e
 		// to unlock save
 		AllowRecoveryKey: true,
 		WhichModel:       m.whichModel,
+		PassphraseAuth:   device.HasPassphraseAuthMarkerUnder(boot.InitramfsSeedEncryptionKeyDir),
 	}
 	saveFallbackKey := device.FallbackSaveSealedKeyUnder(boot.InitramfsSeedEncryptionKeyDir)
 	// TODO: this prompts again for a recover key, but really this is the
@@ -1708,6 +1717,19 @@ func getNonUEFISystemDisk(fallbacklabel string) (string, error) {
 	return candidate, nil
 }
 
+// filesystemNeedsFsck returns whether a filesystem of the given type should be
+// checked with fsck before being mounted. The fsck helpers of btrfs and xfs do
+// nothing, as those filesystems are verified and repaired by the kernel when
+// they are mounted.
+func filesystemNeedsFsck(fsType string) bool {
+	switch fsType {
+	case "btrfs", "xfs":
+		return false
+	default:
+		return true
+	}
+}
+
 // mountNonDataPartitionMatchingKernelDisk will select the partition to mount at
 // dir, using the boot package function FindPartitionUUIDForBootedKernelDisk to
 // determine what partition the booted kernel came from. If which disk the
@@ -1992,6 +2014,7 @@ func generateMountsModeRun(mst *initramfsMountsState) error {
 	opts := &secboot.UnlockVolumeUsingSealedKeyOptions{
 		AllowRecoveryKey: true,
 		WhichModel:       mst.UnverifiedBootModel,
+		PassphraseAuth:   device.HasPassphraseAuthMarkerUnder(boot.InitramfsBootEncryptionKeyDir),
 	}
 	unlockRes, err := secbootUnlockVolumeUsingSealedKeyIfEncrypted(disk, "ubuntu-data", runModeKey, opts)
 	if err != nil {
@@ -2003,6 +2026,12 @@ func generateMountsModeRun(mst *initramfsMountsState) error {
 	dataMountOpts := &systemdMountOptions{
 		NeedsFsck: true,
 	}
+	if !unlockRes.IsEncrypted {
+		// the filesystem type is only known for unencrypted partitions
+		if part, err := disk.FindMatchingPartitionWithFsLabel("ubuntu-data"); err == nil {
+			dataMountOpts.NeedsFsck = filesystemNeedsFsck(part.FilesystemType)
+		}
+	}
 	if !isClassic {
 		// fsck and mount with nosuid to prevent snaps from being able to bypass
 		// the sandbox by creating suid root files there and trying to escape the
@@ -40,6 +40,7 @@ import (
 	main "github.com/snapcore/snapd/cmd/snap-bootstrap"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	gadgetInstall "github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
@@ -1088,6 +1089,68 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRunModeUnencryptedWithSaveHapp
 	c.Assert(err, IsNil)
 }
 
+func (s *initramfsMountsSuite) TestInitramfsMountsRunModeUnencryptedBtrfsDataNoFsck(c *C) {
+	s.mockProcCmdlineContent(c, "snapd_recovery_mode=run")
+
+	btrfsDataPart := dataPart
+	btrfsDataPart.FilesystemType = "btrfs"
+	btrfsDataDisk := &disks.MockDiskMapping{
+		Structure: []disks.Partition{
+			seedPart,
+			bootPart,
+			btrfsDataPart,
+		},
+		DiskHasPartitions: true,
+		DevNum:            "btrfs-data",
+	}
+
+	restore := disks.MockMountPointDisksToPartitionMapping(
+		map[disks.Mountpoint]*disks.MockDiskMapping{
+			{Mountpoint: boot.InitramfsUbuntuBootDir}: btrfsDataDisk,
+			{Mountpoint: boot.InitramfsDataDir}:       btrfsDataDisk,
+		},
+	)
+	defer restore()
+
+	// btrfs is not checked with fsck before being mounted
+	dataMount := s.ubuntuPartUUIDMount("ubuntu-data-partuuid", "run")
+	dataMount.opts = needsNoSuidDiskMountOpts
+
+	restore = s.mockSystemdMountSequence(c, []systemdMount{
+		s.ubuntuLabelMount("ubuntu-boot", "run"),
+		s.ubuntuPartUUIDMount("ubuntu-seed-partuuid", "run"),
+		dataMount,
+		s.makeRunSnapSystemdMount(snap.TypeBase, s.core20),
+		s.makeRunSnapSystemdMount(snap.TypeGadget, s.gadget),
+		s.makeRunSnapSystemdMount(snap.TypeKernel, s.kernel),
+	}, nil)
+	defer restore()
+
+	// mock a bootloader
+	bloader := boottest.MockUC20RunBootenv(bootloadertest.Mock("mock", c.MkDir()))
+	bootloader.Force(bloader)
+	defer bootloader.Force(nil)
+
+	// set the current kernel
+	restore = bloader.SetEnabledKernel(s.kernel)
+	defer restore()
+
+	s.makeSnapFilesOnEarlyBootUbuntuData(c, s.kernel, s.core20, s.gadget)
+
+	// write modeenv
+	modeEnv := boot.Modeenv{
+		Mode:           "run",
+		Base:           s.core20.Filename(),
+		Gadget:         s.gadget.Filename(),
+		CurrentKernels: []string{s.kernel.Filename()},
+	}
+	err := modeEnv.WriteTo(filepath.Join(dirs.GlobalRootDir, "/run/mnt/data/system-data"))
+	c.Assert(err, IsNil)
+
+	_, err = main.Parser().ParseArgs([]string{"initramfs-mounts"})
+	c.Assert(err, IsNil)
+}
+
 func (s *initramfsMountsSuite) TestInitramfsMountsRunModeHappyNoGadgetMount(c *C) {
 	// M
 	s.mockProcCmdlineContent(c, "snapd_recovery_mode=run")
@@ -2298,8 +2361,21 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRunModeWithBootedKernelPartUUI
 }
 
 func (s *initramfsMountsSuite) TestInitramfsMountsRunModeEncryptedDataHappy(c *C) {
+	s.testInitramfsMountsRunModeEncryptedDataHappy(c, false)
+}
+
+func (s *initramfsMountsSuite) TestInitramfsMountsRunModeEncryptedDataPassphraseHappy(c *C) {
+	s.testInitramfsMountsRunModeEncryptedDataHappy(c, true)
+}
+
+func (s *initramfsMountsSuite) testInitramfsMountsRunModeEncryptedDataHappy(c *C, passphraseAuth bool) {
 	s.mockProcCmdlineContent(c, "snapd_recovery_mode=run")
 
+	if passphraseAuth {
+		c.Assert(os.MkdirAll(boot.InitramfsBootEncryptionKeyDir, 0755), IsNil)
+		c.Assert(device.WritePassphraseAuthMarker(boot.InitramfsBootEncryptionKeyDir), IsNil)
+	}
+
 	// ensure that we check that access to sealed keys were locked
 	sealedKeysLocked := false
 	defer main.MockSecbootLockSealedKeys(func() error {
@@ -2355,9 +2431,13 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRunModeEncryptedDataHappy(c *C
 		mod, err := opts.WhichModel()
 		c.Assert(err, IsNil)
 		c.Check(mod.Model(), Equals, "my-model")
+		c.Check(opts.PassphraseAuth, Equals, passphraseAuth)
 
 		dataActivated = true
 		// return true because we are using an encrypted device
+		if passphraseAuth {
+			return happyUnlocked("ubuntu-data", secboot.UnlockedWithPassphrase), nil
+		}
 		return happyUnlocked("ubuntu-data", secboot.UnlockedWithSealedKey), nil
 	})
 	defer restore()
@@ -4184,8 +4264,23 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRecoverModeEncryptedDegradedAb
 }
 
 func (s *initramfsMountsSuite) TestInitramfsMountsRecoverModeEncryptedDegradedAbsentBootDataUnlockRecoveryKeyHappy(c *C) {
+	s.testInitramfsMountsRecoverModeEncryptedDegradedAbsentBootDataUnlockHappy(c, false)
+}
+
+func (s *initramfsMountsSuite) TestInitramfsMountsRecoverModeEncryptedDegradedAbsentBootDataUnlockPassphraseHappy(c *C) {
+	s.testInitramfsMountsRecoverModeEncryptedDegradedAbsentBootDataUnlockHappy(c, true)
+}
+
+func (s *initramfsMountsSuite) testInitramfsMountsRecoverModeEncryptedDegradedAbsentBootDataUnlockHappy(c *C, passphraseAuth bool) {
 	s.mockProcCmdlineContent(c, "snapd_recovery_mode=recover snapd_recovery_system="+s.sysLabel)
 
+	expectedDataUnlockKey := "recovery"
+	if passphraseAuth {
+		expectedDataUnlockKey = "passphrase"
+		c.Assert(os.MkdirAll(boot.InitramfsSeedEncryptionKeyDir, 0755), IsNil)
+		c.Assert(device.WritePassphraseAuthMarker(boot.InitramfsSeedEncryptionKeyDir), IsNil)
+	}
+
 	restore := main.MockPartitionUUIDForBootedKernelDisk("")
 	defer restore()
 
@@ -4237,7 +4332,11 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRecoverModeEncryptedDegradedAb
 			c.Assert(encDevPartUUID, Equals, "ubuntu-data-enc-partuuid")
 			c.Assert(opts.AllowRecoveryKey, Equals, true)
 			c.Assert(opts.WhichModel, NotNil)
+			c.Check(opts.PassphraseAuth, Equals, passphraseAuth)
 			dataActivated = true
+			if passphraseAuth {
+				return happyUnlocked("ubuntu-data", secboot.UnlockedWithPassphrase), nil
+			}
 			// it was unlocked with a recovery key
 
 			return happyUnlocked("ubuntu-data", secboot.UnlockedWithRecoveryKey), nil
@@ -4320,7 +4419,7 @@ func (s *initramfsMountsSuite) TestInitramfsMountsRecoverModeEncryptedDegradedAb
 			"unlock-state":   "unlocked",
 			"find-state":     "found",
 			"mount-state":    "mounted",
-			"unlock-key":     "recovery",
+			"unlock-key":     expectedDataUnlockKey,
 			"mount-location": boot.InitramfsHostUbuntuDataDir,
 		},
 		"ubuntu-save": map[string]interface{}{
@@ -7975,6 +8074,7 @@ type MockObserver struct {
 	BootLoaderSupportsEfiVariablesFunc       func() bool
 	ObserveExistingTrustedRecoveryAssetsFunc func(recoveryRootDir string) error
 	ChosenEncryptionKeysFunc                 func(key, saveKey keys.EncryptionKey)
+	ChosenVolumesAuthFunc                    func(volumesAuth *device.VolumesAuthOptions)
 	UpdateBootEntryFunc                      func() error
 	ObserveFunc                              func(op gadget.ContentOperation, partRole, root, relativeTarget string, data *gadget.ContentChange) (gadget.ContentChangeAction, error)
 }
@@ -7991,6 +8091,10 @@ func (m *MockObserver) ChosenEncryptionKeys(key, saveKey keys.EncryptionKey) {
 	m.ChosenEncryptionKeysFunc(key, saveKey)
 }
 
+func (m *MockObserver) ChosenVolumesAuth(volumesAuth *device.VolumesAuthOptions) {
+	m.ChosenVolumesAuthFunc(volumesAuth)
+}
+
 func (m *MockObserver) UpdateBootEntry() error {
 	return m.UpdateBootEntryFunc()
 }
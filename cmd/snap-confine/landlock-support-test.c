@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+#include "landlock-support.c"
+
+#include <glib.h>
+#include <glib/gstdio.h>
+
+static FILE *make_landlock_profile(const char *content)
+{
+	FILE *file = fmemopen((void *)content, strlen(content), "r");
+	g_assert_nonnull(file);
+	return file;
+}
+
+static void test_landlock_parse_profile__happy(void)
+{
+	FILE *file SC_CLEANUP(sc_cleanup_file) =
+	    make_landlock_profile("# comment\n"
+				  "\n"
+				  "fs rx /usr\n"
+				  "  fs rw $SNAP_USER_DATA  \n"
+				  "net bind 8080\n" "net connect *\n");
+	sc_landlock_profile *profile
+	    SC_CLEANUP(sc_cleanup_landlock_profile) = NULL;
+	profile = sc_landlock_parse_profile(file, "test");
+
+	g_assert_false(profile->complain);
+	g_assert_false(profile->unrestricted);
+	g_assert_cmpint(profile->num_fs_rules, ==, 2);
+	g_assert_cmpstr(profile->fs_rules[0].path, ==, "/usr");
+	g_assert_cmpuint(profile->fs_rules[0].access, ==,
+			 SC_LANDLOCK_ACCESS_FS_READ_FILE |
+			 SC_LANDLOCK_ACCESS_FS_READ_DIR |
+			 SC_LANDLOCK_ACCESS_FS_EXECUTE);
+	g_assert_cmpstr(profile->fs_rules[1].path, ==, "$SNAP_USER_DATA");
+	g_assert_true(profile->fs_rules[1].access &
+		      SC_LANDLOCK_ACCESS_FS_WRITE_FILE);
+	g_assert_cmpint(profile->num_net_rules, ==, 2);
+	g_assert_cmpuint(profile->net_rules[0].access, ==,
+			 SC_LANDLOCK_ACCESS_NET_BIND_TCP);
+	g_assert_cmpint(profile->net_rules[0].port, ==, 8080);
+	g_assert_cmpuint(profile->net_rules[1].access, ==,
+			 SC_LANDLOCK_ACCESS_NET_CONNECT_TCP);
+	g_assert_cmpint(profile->net_rules[1].port, ==, -1);
+}
+
+static void test_landlock_parse_profile__flags(void)
+{
+	FILE *file SC_CLEANUP(sc_cleanup_file) =
+	    make_landlock_profile("@unrestricted\n@complain\nfs r /etc\n");
+	sc_landlock_profile *profile
+	    SC_CLEANUP(sc_cleanup_landlock_profile) = NULL;
+	profile = sc_landlock_parse_profile(file, "test");
+
+	g_assert_true(profile->complain);
+	g_assert_true(profile->unrestricted);
+	g_assert_cmpint(profile->num_fs_rules, ==, 1);
+}
+
+static void landlock_parse_profile_dies_with(const char *content,
+					     const char *err_msg)
+{
+	if (g_test_subprocess()) {
+		FILE *file SC_CLEANUP(sc_cleanup_file) =
+		    make_landlock_profile(content);
+		sc_landlock_parse_profile(file, "test");
+		// the function above is expected to call die()
+		g_assert_not_reached();
+	}
+	g_test_trap_subprocess(NULL, 0, 0);
+	g_test_trap_assert_failed();
+	g_test_trap_assert_stderr(err_msg);
+}
+
+static void test_landlock_parse_profile__errors(void)
+{
+	landlock_parse_profile_dies_with("foo r /etc\n",
+					 "cannot parse line 1 of landlock profile test: unknown directive foo\n");
+}
+
+static void test_landlock_parse_profile__bad_perms(void)
+{
+	landlock_parse_profile_dies_with("\nfs rz /etc\n",
+					 "cannot parse line 2 of landlock profile test: invalid permissions rz\n");
+}
+
+static void test_landlock_parse_profile__bad_fields(void)
+{
+	landlock_parse_profile_dies_with("fs r /etc /usr\n",
+					 "cannot parse line 1 of landlock profile test\n");
+}
+
+static void test_landlock_parse_profile__bad_port(void)
+{
+	landlock_parse_profile_dies_with("net bind 65536\n",
+					 "cannot parse line 1 of landlock profile test: invalid port 65536\n");
+}
+
+static void test_landlock_expand_path(void)
+{
+	char buf[PATH_MAX] = { 0 };
+
+	g_setenv("SNAP_USER_DATA", "/home/user/snap/foo/1", TRUE);
+	g_unsetenv("XDG_RUNTIME_DIR");
+
+	g_assert_true(sc_landlock_expand_path("/usr", buf, sizeof buf));
+	g_assert_cmpstr(buf, ==, "/usr");
+	g_assert_true(sc_landlock_expand_path
+		      ("$SNAP_USER_DATA", buf, sizeof buf));
+	g_assert_cmpstr(buf, ==, "/home/user/snap/foo/1");
+	g_assert_true(sc_landlock_expand_path
+		      ("${SNAP_USER_DATA}/sub", buf, sizeof buf));
+	g_assert_cmpstr(buf, ==, "/home/user/snap/foo/1/sub");
+
+	// unset variables make the rule be skipped
+	g_assert_false(sc_landlock_expand_path
+		       ("$XDG_RUNTIME_DIR", buf, sizeof buf));
+	// as do relative paths
+	g_setenv("SNAP_USER_DATA", "relative", TRUE);
+	g_assert_false(sc_landlock_expand_path
+		       ("$SNAP_USER_DATA", buf, sizeof buf));
+	// and paths not fitting in the buffer
+	g_assert_false(sc_landlock_expand_path("/usr/lib", buf, 5));
+}
+
+static void test_landlock_handled_access(void)
+{
+	g_assert_false(sc_landlock_handled_access_fs(1) &
+		       SC_LANDLOCK_ACCESS_FS_REFER);
+	g_assert_true(sc_landlock_handled_access_fs(2) &
+		      SC_LANDLOCK_ACCESS_FS_REFER);
+	g_assert_false(sc_landlock_handled_access_fs(2) &
+		       SC_LANDLOCK_ACCESS_FS_TRUNCATE);
+	g_assert_true(sc_landlock_handled_access_fs(3) &
+		      SC_LANDLOCK_ACCESS_FS_TRUNCATE);
+
+	FILE *file SC_CLEANUP(sc_cleanup_file) =
+	    make_landlock_profile("net bind 8080\nnet connect *\n");
+	sc_landlock_profile *profile
+	    SC_CLEANUP(sc_cleanup_landlock_profile) = NULL;
+	profile = sc_landlock_parse_profile(file, "test");
+
+	// the network is not handled before ABI version 4
+	g_assert_cmpuint(sc_landlock_handled_access_net(3, profile), ==, 0);
+	// connecting to any port is allowed, so it is not handled
+	g_assert_cmpuint(sc_landlock_handled_access_net(4, profile), ==,
+			 SC_LANDLOCK_ACCESS_NET_BIND_TCP);
+}
+
+static void __attribute__((constructor)) init(void)
+{
+	g_test_add_func("/landlock/parse_profile/happy",
+			test_landlock_parse_profile__happy);
+	g_test_add_func("/landlock/parse_profile/flags",
+			test_landlock_parse_profile__flags);
+	g_test_add_func("/landlock/parse_profile/errors",
+			test_landlock_parse_profile__errors);
+	g_test_add_func("/landlock/parse_profile/bad_perms",
+			test_landlock_parse_profile__bad_perms);
+	g_test_add_func("/landlock/parse_profile/bad_fields",
+			test_landlock_parse_profile__bad_fields);
+	g_test_add_func("/landlock/parse_profile/bad_port",
+			test_landlock_parse_profile__bad_port);
+	g_test_add_func("/landlock/expand_path", test_landlock_expand_path);
+	g_test_add_func("/landlock/handled_access",
+			test_landlock_handled_access);
+}
@@ -0,0 +1,512 @@
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+#include "config.h"
+#include "landlock-support.h"
+
+#include <ctype.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <limits.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <sys/syscall.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include "../libsnap-confine-private/cleanup-funcs.h"
+#include "../libsnap-confine-private/feature.h"
+#include "../libsnap-confine-private/string-utils.h"
+#include "../libsnap-confine-private/utils.h"
+
+// The landlock ABI is defined here so that snap-confine can be built against
+// kernel headers which predate it. Keep in sync with sandbox/landlock.
+#ifndef __NR_landlock_create_ruleset
+#define __NR_landlock_create_ruleset 444
+#endif
+#ifndef __NR_landlock_add_rule
+#define __NR_landlock_add_rule 445
+#endif
+#ifndef __NR_landlock_restrict_self
+#define __NR_landlock_restrict_self 446
+#endif
+
+#define SC_LANDLOCK_CREATE_RULESET_VERSION (1U << 0)
+
+#define SC_LANDLOCK_RULE_PATH_BENEATH 1
+#define SC_LANDLOCK_RULE_NET_PORT 2
+
+#define SC_LANDLOCK_ACCESS_FS_EXECUTE (1ULL << 0)
+#define SC_LANDLOCK_ACCESS_FS_WRITE_FILE (1ULL << 1)
+#define SC_LANDLOCK_ACCESS_FS_READ_FILE (1ULL << 2)
+#define SC_LANDLOCK_ACCESS_FS_READ_DIR (1ULL << 3)
+#define SC_LANDLOCK_ACCESS_FS_REMOVE_DIR (1ULL << 4)
+#define SC_LANDLOCK_ACCESS_FS_REMOVE_FILE (1ULL << 5)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_CHAR (1ULL << 6)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_DIR (1ULL << 7)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_REG (1ULL << 8)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_SOCK (1ULL << 9)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_FIFO (1ULL << 10)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_BLOCK (1ULL << 11)
+#define SC_LANDLOCK_ACCESS_FS_MAKE_SYM (1ULL << 12)
+// ABI version 2
+#define SC_LANDLOCK_ACCESS_FS_REFER (1ULL << 13)
+// ABI version 3
+#define SC_LANDLOCK_ACCESS_FS_TRUNCATE (1ULL << 14)
+
+// ABI version 4
+#define SC_LANDLOCK_ACCESS_NET_BIND_TCP (1ULL << 0)
+#define SC_LANDLOCK_ACCESS_NET_CONNECT_TCP (1ULL << 1)
+
+// access rights which can be granted on non-directory file system objects
+#define SC_LANDLOCK_ACCESS_FS_FILE (SC_LANDLOCK_ACCESS_FS_EXECUTE | \
+				    SC_LANDLOCK_ACCESS_FS_WRITE_FILE | \
+				    SC_LANDLOCK_ACCESS_FS_READ_FILE | \
+				    SC_LANDLOCK_ACCESS_FS_TRUNCATE)
+
+struct sc_landlock_ruleset_attr {
+	uint64_t handled_access_fs;
+	uint64_t handled_access_net;
+};
+
+struct __attribute__((__packed__)) sc_landlock_path_beneath_attr {
+	uint64_t allowed_access;
+	int32_t parent_fd;
+};
+
+struct sc_landlock_net_port_attr {
+	uint64_t allowed_access;
+	uint64_t port;
+};
+
+static const char *landlock_profile_dir = "/var/lib/snapd/seccomp/bpf";
+
+// Maximum number of rules of a single profile, profiles are generated by
+// snapd and are expected to be much smaller.
+#define SC_LANDLOCK_MAX_RULES 1024
+
+typedef struct sc_landlock_fs_rule {
+	uint64_t access;
+	char *path;
+} sc_landlock_fs_rule;
+
+typedef struct sc_landlock_net_rule {
+	uint64_t access;
+	// port is -1 when any port is allowed
+	int port;
+} sc_landlock_net_rule;
+
+typedef struct sc_landlock_profile {
+	// profiles of snaps in devmode are not enforced, landlock has no
+	// complain mode
+	bool complain;
+	// profiles of snaps using classic confinement are not enforced
+	bool unrestricted;
+	size_t num_fs_rules;
+	sc_landlock_fs_rule fs_rules[SC_LANDLOCK_MAX_RULES];
+	size_t num_net_rules;
+	sc_landlock_net_rule net_rules[SC_LANDLOCK_MAX_RULES];
+} sc_landlock_profile;
+
+static void sc_landlock_profile_free(sc_landlock_profile *profile)
+{
+	if (profile == NULL) {
+		return;
+	}
+	for (size_t i = 0; i < profile->num_fs_rules; i++) {
+		free(profile->fs_rules[i].path);
+	}
+	free(profile);
+}
+
+static void sc_cleanup_landlock_profile(sc_landlock_profile **profile)
+{
+	sc_landlock_profile_free(*profile);
+	*profile = NULL;
+}
+
+static uint64_t sc_landlock_handled_access_fs(int abi)
+{
+	uint64_t handled = SC_LANDLOCK_ACCESS_FS_EXECUTE |
+	    SC_LANDLOCK_ACCESS_FS_WRITE_FILE |
+	    SC_LANDLOCK_ACCESS_FS_READ_FILE |
+	    SC_LANDLOCK_ACCESS_FS_READ_DIR |
+	    SC_LANDLOCK_ACCESS_FS_REMOVE_DIR |
+	    SC_LANDLOCK_ACCESS_FS_REMOVE_FILE |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_CHAR |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_DIR |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_REG |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_SOCK |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_FIFO |
+	    SC_LANDLOCK_ACCESS_FS_MAKE_BLOCK | SC_LANDLOCK_ACCESS_FS_MAKE_SYM;
+	if (abi >= 2) {
+		handled |= SC_LANDLOCK_ACCESS_FS_REFER;
+	}
+	if (abi >= 3) {
+		handled |= SC_LANDLOCK_ACCESS_FS_TRUNCATE;
+	}
+	return handled;
+}
+
+// sc_landlock_access_from_perms converts the permission letters used in the
+// profiles into file system access rights, 0 is returned for invalid
+// permissions.
+static uint64_t sc_landlock_access_from_perms(const char *perms)
+{
+	uint64_t access = 0;
+	for (const char *p = perms; *p != '\0'; p++) {
+		switch (*p) {
+		case 'r':
+			access |= SC_LANDLOCK_ACCESS_FS_READ_FILE |
+			    SC_LANDLOCK_ACCESS_FS_READ_DIR;
+			break;
+		case 'w':
+			// character and block devices cannot be created
+			// by snaps anyway
+			access |= SC_LANDLOCK_ACCESS_FS_WRITE_FILE |
+			    SC_LANDLOCK_ACCESS_FS_REMOVE_DIR |
+			    SC_LANDLOCK_ACCESS_FS_REMOVE_FILE |
+			    SC_LANDLOCK_ACCESS_FS_MAKE_DIR |
+			    SC_LANDLOCK_ACCESS_FS_MAKE_REG |
+			    SC_LANDLOCK_ACCESS_FS_MAKE_SOCK |
+			    SC_LANDLOCK_ACCESS_FS_MAKE_FIFO |
+			    SC_LANDLOCK_ACCESS_FS_MAKE_SYM |
+			    SC_LANDLOCK_ACCESS_FS_REFER |
+			    SC_LANDLOCK_ACCESS_FS_TRUNCATE;
+			break;
+		case 'x':
+			access |= SC_LANDLOCK_ACCESS_FS_EXECUTE;
+			break;
+		default:
+			return 0;
+		}
+	}
+	return access;
+}
+
+static char *sc_landlock_trim(char *line)
+{
+	while (isspace((unsigned char)*line)) {
+		line++;
+	}
+	size_t len = strlen(line);
+	while (len > 0 && isspace((unsigned char)line[len - 1])) {
+		line[--len] = '\0';
+	}
+	return line;
+}
+
+// sc_landlock_parse_profile parses a landlock profile, see ParseProfile in
+// sandbox/landlock for the description of the format. The function dies on
+// invalid profiles.
+static sc_landlock_profile *sc_landlock_parse_profile(FILE *file,
+						      const char *profile_path)
+{
+	sc_landlock_profile *profile = calloc(1, sizeof *profile);
+	if (profile == NULL) {
+		die("cannot allocate memory for landlock profile");
+	}
+	char *buf SC_CLEANUP(sc_cleanup_string) = NULL;
+	size_t buf_size = 0;
+	int lineno = 0;
+	while (getline(&buf, &buf_size, file) != -1) {
+		lineno++;
+		// parse errors below are not caused by failing system calls,
+		// do not let die() report a stale error
+		errno = 0;
+		char *line = sc_landlock_trim(buf);
+		if (*line == '\0' || *line == '#') {
+			continue;
+		}
+		if (sc_streq(line, "@complain")) {
+			profile->complain = true;
+			continue;
+		}
+		if (sc_streq(line, "@unrestricted")) {
+			profile->unrestricted = true;
+			continue;
+		}
+		char *saveptr = NULL;
+		const char *directive = strtok_r(line, " \t", &saveptr);
+		const char *arg1 = strtok_r(NULL, " \t", &saveptr);
+		const char *arg2 = strtok_r(NULL, " \t", &saveptr);
+		if (arg1 == NULL || arg2 == NULL
+		    || strtok_r(NULL, " \t", &saveptr) != NULL) {
+			die("cannot parse line %d of landlock profile %s",
+			    lineno, profile_path);
+		}
+		if (sc_streq(directive, "fs")) {
+			uint64_t access = sc_landlock_access_from_perms(arg1);
+			if (access == 0) {
+				die("cannot parse line %d of landlock profile %s: invalid permissions %s", lineno, profile_path, arg1);
+			}
+			if (arg2[0] != '/' && arg2[0] != '$') {
+				die("cannot parse line %d of landlock profile %s: path %s is not absolute", lineno, profile_path, arg2);
+			}
+			if (profile->num_fs_rules == SC_LANDLOCK_MAX_RULES) {
+				die("too many rules in landlock profile %s",
+				    profile_path);
+			}
+			sc_landlock_fs_rule *rule =
+			    &profile->fs_rules[profile->num_fs_rules++];
+			rule->access = access;
+			rule->path = sc_strdup(arg2);
+		} else if (sc_streq(directive, "net")) {
+			uint64_t access;
+			if (sc_streq(arg1, "bind")) {
+				access = SC_LANDLOCK_ACCESS_NET_BIND_TCP;
+			} else if (sc_streq(arg1, "connect")) {
+				access = SC_LANDLOCK_ACCESS_NET_CONNECT_TCP;
+			} else {
+				die("cannot parse line %d of landlock profile %s: unknown network access %s", lineno, profile_path, arg1);
+			}
+			int port = -1;
+			if (!sc_streq(arg2, "*")) {
+				char *end = NULL;
+				errno = 0;
+				long n = strtol(arg2, &end, 10);
+				if (errno != 0 || *end != '\0' || end == arg2
+				    || n < 0 || n > 65535) {
+					die("cannot parse line %d of landlock profile %s: invalid port %s", lineno, profile_path, arg2);
+				}
+				port = (int)n;
+			}
+			if (profile->num_net_rules == SC_LANDLOCK_MAX_RULES) {
+				die("too many rules in landlock profile %s",
+				    profile_path);
+			}
+			sc_landlock_net_rule *rule =
+			    &profile->net_rules[profile->num_net_rules++];
+			rule->access = access;
+			rule->port = port;
+		} else {
+			die("cannot parse line %d of landlock profile %s: unknown directive %s", lineno, profile_path, directive);
+		}
+	}
+	if (ferror(file)) {
+		die("cannot read landlock profile %s", profile_path);
+	}
+	return profile;
+}
+
+// sc_landlock_expand_path expands the $VARIABLE and ${VARIABLE} references in
+// the given path into buf. False is returned if any of the variables is unset
+// or empty, or if the result is not an absolute path.
+//
+// The variables are set by snap run for the calling user, the process runs as
+// that user so they can only ever relax the sandbox of their own processes.
+static bool sc_landlock_expand_path(const char *path, char *buf,
+				    size_t buf_size)
+{
+	buf[0] = '\0';
+	for (const char *p = path; *p != '\0';) {
+		if (*p != '$') {
+			if (strlen(buf) + 1 >= buf_size) {
+				return false;
+			}
+			sc_string_append_char(buf, buf_size, *p);
+			p++;
+			continue;
+		}
+		p++;
+		bool braces = *p == '{';
+		if (braces) {
+			p++;
+		}
+		char name[64] = { 0 };
+		size_t len = 0;
+		while (isalnum((unsigned char)*p) || *p == '_') {
+			if (len == sizeof name - 1) {
+				return false;
+			}
+			name[len++] = *p++;
+		}
+		if (braces) {
+			if (*p != '}') {
+				return false;
+			}
+			p++;
+		}
+		const char *value = getenv(name);
+		if (len == 0 || value == NULL || value[0] == '\0') {
+			return false;
+		}
+		if (strlen(buf) + strlen(value) >= buf_size) {
+			return false;
+		}
+		sc_string_append(buf, buf_size, value);
+	}
+	return buf[0] == '/';
+}
+
+static int sc_landlock_abi_version(void)
+{
+	long abi = syscall(__NR_landlock_create_ruleset, NULL, 0,
+			   SC_LANDLOCK_CREATE_RULESET_VERSION);
+	if (abi < 0) {
+		// ENOSYS or EOPNOTSUPP when landlock is not supported or
+		// disabled
+		return 0;
+	}
+	return (int)abi;
+}
+
+static void sc_landlock_add_path_rules(int ruleset_fd, uint64_t handled,
+				       const sc_landlock_profile *profile)
+{
+	char path[PATH_MAX] = { 0 };
+	for (size_t i = 0; i < profile->num_fs_rules; i++) {
+		const sc_landlock_fs_rule *rule = &profile->fs_rules[i];
+		if (!sc_landlock_expand_path(rule->path, path, sizeof path)) {
+			debug("skipping landlock rule for %s", rule->path);
+			continue;
+		}
+		int fd SC_CLEANUP(sc_cleanup_close) = -1;
+		fd = open(path, O_PATH | O_CLOEXEC);
+		if (fd < 0) {
+			if (errno == ENOENT || errno == ENOTDIR
+			    || errno == EACCES) {
+				// nothing to grant access to
+				continue;
+			}
+			die("cannot open %s", path);
+		}
+		struct stat file_info;
+		if (fstat(fd, &file_info) < 0) {
+			die("cannot stat %s", path);
+		}
+		uint64_t access = rule->access & handled;
+		if (!S_ISDIR(file_info.st_mode)) {
+			access &= SC_LANDLOCK_ACCESS_FS_FILE;
+		}
+		struct sc_landlock_path_beneath_attr attr = {
+			.allowed_access = access,
+			.parent_fd = fd,
+		};
+		if (syscall(__NR_landlock_add_rule, ruleset_fd,
+			    SC_LANDLOCK_RULE_PATH_BENEATH, &attr, 0) < 0) {
+			die("cannot add landlock rule for %s", path);
+		}
+	}
+}
+
+// sc_landlock_handled_access_net returns the network access rights handled
+// by the profile, access types for which a rule allows any port are not
+// handled.
+static uint64_t sc_landlock_handled_access_net(int abi,
+					       const sc_landlock_profile
+					       *profile)
+{
+	if (abi < 4) {
+		return 0;
+	}
+	uint64_t handled = SC_LANDLOCK_ACCESS_NET_BIND_TCP |
+	    SC_LANDLOCK_ACCESS_NET_CONNECT_TCP;
+	for (size_t i = 0; i < profile->num_net_rules; i++) {
+		if (profile->net_rules[i].port < 0) {
+			handled &= ~profile->net_rules[i].access;
+		}
+	}
+	return handled;
+}
+
+static void sc_landlock_add_port_rules(int ruleset_fd, uint64_t handled,
+				       const sc_landlock_profile *profile)
+{
+	for (size_t i = 0; i < profile->num_net_rules; i++) {
+		const sc_landlock_net_rule *rule = &profile->net_rules[i];
+		uint64_t access = rule->access & handled;
+		if (rule->port < 0 || access == 0) {
+			continue;
+		}
+		struct sc_landlock_net_port_attr attr = {
+			.allowed_access = access,
+			.port = (uint64_t)rule->port,
+		};
+		if (syscall(__NR_landlock_add_rule, ruleset_fd,
+			    SC_LANDLOCK_RULE_NET_PORT, &attr, 0) < 0) {
+			die("cannot add landlock rule for port %d", rule->port);
+		}
+	}
+}
+
+static void sc_landlock_apply_profile(int abi,
+				      const sc_landlock_profile *profile)
+{
+	if (profile->unrestricted || profile->complain || abi <= 0) {
+		return;
+	}
+	struct sc_landlock_ruleset_attr attr = {
+		.handled_access_fs = sc_landlock_handled_access_fs(abi),
+		.handled_access_net =
+		    sc_landlock_handled_access_net(abi, profile),
+	};
+	size_t attr_size = sizeof attr;
+	if (abi < 4) {
+		// older kernels reject the unknown network field
+		attr_size =
+		    offsetof(struct sc_landlock_ruleset_attr,
+			     handled_access_net);
+	}
+	int ruleset_fd SC_CLEANUP(sc_cleanup_close) = -1;
+	ruleset_fd = syscall(__NR_landlock_create_ruleset, &attr, attr_size, 0);
+	if (ruleset_fd < 0) {
+		die("cannot create landlock ruleset");
+	}
+	sc_landlock_add_path_rules(ruleset_fd, attr.handled_access_fs,
+				   profile);
+	sc_landlock_add_port_rules(ruleset_fd, attr.handled_access_net,
+				   profile);
+	// snap-confine holds CAP_SYS_ADMIN at this point, so no_new_privs
+	// does not need to be set
+	if (syscall(__NR_landlock_restrict_self, ruleset_fd, 0) < 0) {
+		die("cannot enforce landlock ruleset");
+	}
+}
+
+void sc_apply_landlock_profile_for_security_tag(const char *security_tag)
+{
+	if (!sc_feature_enabled(SC_FEATURE_LANDLOCK)) {
+		return;
+	}
+	int abi = sc_landlock_abi_version();
+	if (abi == 0) {
+		debug("landlock is not supported");
+		return;
+	}
+	char profile_path[PATH_MAX] = { 0 };
+	sc_must_snprintf(profile_path, sizeof(profile_path), "%s/%s.landlock",
+			 landlock_profile_dir, security_tag);
+	FILE *file SC_CLEANUP(sc_cleanup_file) = fopen(profile_path, "r");
+	if (file == NULL) {
+		if (errno == ENOENT) {
+			debug("no landlock profile %s", profile_path);
+			return;
+		}
+		die("cannot open landlock profile %s", profile_path);
+	}
+	sc_landlock_profile *profile
+	    SC_CLEANUP(sc_cleanup_landlock_profile) = NULL;
+	profile = sc_landlock_parse_profile(file, profile_path);
+	debug("applying landlock profile %s (ABI version %d)", profile_path,
+	      abi);
+	sc_landlock_apply_profile(abi, profile);
+}
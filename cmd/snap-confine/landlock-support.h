@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+#ifndef SNAP_CONFINE_LANDLOCK_SUPPORT_H
+#define SNAP_CONFINE_LANDLOCK_SUPPORT_H
+
+/**
+ * sc_apply_landlock_profile_for_security_tag applies the landlock profile of
+ * the given security tag to the current process.
+ *
+ * Landlock profiles are only generated by snapd on systems without AppArmor,
+ * when the experimental landlock feature is enabled. The profile is loaded
+ * from "/var/lib/snapd/seccomp/bpf" using the security tag and the extension
+ * ".landlock". Nothing is done if the feature is disabled, if the profile is
+ * missing or if the kernel does not support landlock.
+ *
+ * The profile format is described in sandbox/landlock of snapd. Paths of rules
+ * may refer to the $SNAP_REAL_HOME, $SNAP_USER_COMMON, $SNAP_USER_DATA and
+ * $XDG_RUNTIME_DIR environment variables, rules referring to unset variables
+ * or to paths which do not exist are skipped. Profiles of snaps using devmode
+ * or classic confinement are not enforced.
+ *
+ * The function must be called with CAP_SYS_ADMIN or with no_new_privs set,
+ * before the seccomp profile is loaded.
+ **/
+void sc_apply_landlock_profile_for_security_tag(const char *security_tag);
+
+#endif
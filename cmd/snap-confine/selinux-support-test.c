@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+#include "selinux-support.c"
+#include "selinux-support.h"
+
+#include <glib.h>
+
+static void test_sc_selinux_domain_for_security_tag(void) {
+    /* the domains match the ones declared by the policy modules snapd
+     * generates, see interfaces/selinux */
+    const struct {
+        const char *tag;
+        const char *domain;
+    } cases[] = {
+        {"snap.foo.app", "snap_foo_app_t"},
+        {"snap.foo.hook.configure", "snap_foo_hook_configure_t"},
+        {"snap.foo_instance.app", "snap_foo_instance_app_t"},
+        {"snap.foo-bar.app-1", "snap_foo-bar_app-1_t"},
+        {"snap.foo+comp.hook.install", "snap_foo_comp_hook_install_t"},
+    };
+    for (size_t i = 0; i < sizeof cases / sizeof cases[0]; i++) {
+        char *domain SC_CLEANUP(sc_cleanup_string) = sc_selinux_domain_for_security_tag(cases[i].tag);
+        g_assert_cmpstr(domain, ==, cases[i].domain);
+    }
+}
+
+static void __attribute__((constructor)) init(void) {
+    g_test_add_func("/selinux/domain_for_security_tag", test_sc_selinux_domain_for_security_tag);
+}
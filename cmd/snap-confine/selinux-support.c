@@ -17,10 +17,14 @@
 #include "selinux-support.h"
 #include "config.h"
 
+#include <stdlib.h>
+#include <string.h>
+
 #include <selinux/context.h>
 #include <selinux/selinux.h>
 
 #include "../libsnap-confine-private/cleanup-funcs.h"
+#include "../libsnap-confine-private/feature.h"
 #include "../libsnap-confine-private/string-utils.h"
 #include "../libsnap-confine-private/utils.h"
 
@@ -38,12 +42,28 @@ static void sc_context_free(context_t *ctx) {
     }
 }
 
+char *sc_selinux_domain_for_security_tag(const char *security_tag) {
+    size_t len = strlen(security_tag);
+    char *domain = calloc(len + sizeof "_t", 1);
+    if (domain == NULL) {
+        die("cannot allocate memory for SELinux domain");
+    }
+    for (size_t i = 0; i < len; i++) {
+        char c = security_tag[i];
+        domain[i] = (c == '.' || c == '+') ? '_' : c;
+    }
+    memcpy(domain + len, "_t", sizeof "_t");
+    return domain;
+}
+
 /**
  * Set security context for the snap.
  *
- * Sets up SELinux context transition to unconfined_service_t.
+ * Sets up SELinux context transition to the domain of the application, or to
+ * unconfined_service_t for snaps using classic confinement and when the
+ * experimental selinux-confinement feature is disabled.
  **/
-int sc_selinux_set_snap_execcon(void) {
+int sc_selinux_set_snap_execcon(const char *security_tag, bool classic_confinement) {
     if (is_selinux_enabled() < 1) {
         debug("SELinux not enabled");
         return 0;
@@ -67,30 +87,47 @@ int sc_selinux_set_snap_execcon(void) {
         die("cannot obtain type from SELinux context string %s", ctx_str);
     }
 
-    if (sc_streq(ctx_type, "snappy_confine_t")) {
-        /* We are running under a targeted policy which ended up transitioning
-         * to snappy_confine_t domain, at this point we are right before
-         * executing snap-exec. However we do not have a full SELinux support
-         * for services running in snaps, only the snapd bits and helpers are
-         * covered by the policy.
-         *
-         * At this point transition to the unconfined_service_t domain (allowed
-         * by snap_confine_t policy) upon the next exec() call.
-         */
-        if (context_type_set(ctx, "unconfined_service_t") != 0) {
-            die("cannot update SELinux context %s type to unconfined_service_t", ctx_str);
-        }
+    if (!sc_streq(ctx_type, "snappy_confine_t")) {
+        /* We are not running under the targeted policy of snapd, there is no
+         * transition to set up. */
+        return 0;
+    }
 
-        /* freed by context_free(ctx) */
-        const char *new_ctx_str = context_str(ctx);
-        if (new_ctx_str == NULL) {
-            die("cannot obtain updated SELinux context string");
-        }
-        if (setexeccon(new_ctx_str) < 0) {
-            die("cannot set SELinux exec context to %s", new_ctx_str);
-        }
-        debug("SELinux context after next exec: %s", new_ctx_str);
+    /* We are running under a targeted policy which ended up transitioning to
+     * snappy_confine_t domain, at this point we are right before executing
+     * snap-exec. Transition to the domain of the application, declared by the
+     * policy module snapd generated for the snap, upon the next exec() call.
+     *
+     * Snaps using classic confinement are not confined by the module, they
+     * transition to the unconfined_service_t domain (allowed by
+     * snappy_confine_t policy) instead. So do all snaps unless the
+     * experimental confinement with SELinux was enabled, snapd only generates
+     * the domains of the applications in that case. */
+    bool confined = !classic_confinement && sc_feature_enabled(SC_FEATURE_SELINUX_CONFINEMENT);
+    char *domain SC_CLEANUP(sc_cleanup_string) = NULL;
+    if (!confined) {
+        domain = sc_strdup("unconfined_service_t");
+    } else {
+        domain = sc_selinux_domain_for_security_tag(security_tag);
+    }
+    if (context_type_set(ctx, domain) != 0) {
+        die("cannot update SELinux context %s type to %s", ctx_str, domain);
+    }
+
+    /* freed by context_free(ctx) */
+    const char *new_ctx_str = context_str(ctx);
+    if (new_ctx_str == NULL) {
+        die("cannot obtain updated SELinux context string");
+    }
+    if (confined && security_check_context(new_ctx_str) < 0) {
+        /* Do not fall back to an unconfined domain, the application would
+         * silently run without confinement. */
+        die("cannot use SELinux domain %s: the policy module of the snap is not loaded", domain);
+    }
+    if (setexeccon(new_ctx_str) < 0) {
+        die("cannot set SELinux exec context to %s", new_ctx_str);
     }
+    debug("SELinux context after next exec: %s", new_ctx_str);
 
     return 0;
 }
@@ -17,11 +17,27 @@
 #ifndef SNAP_CONFINE_SELINUX_SUPPORT_H
 #define SNAP_CONFINE_SELINUX_SUPPORT_H
 
+#include <stdbool.h>
+
+/**
+ * Compute the SELinux domain of the given security tag.
+ *
+ * The domain is declared by the policy module snapd generates for each snap.
+ * It is named after the security tag, with dots and plus signs replaced by
+ * underscores and a "_t" suffix, e.g. snap_foo_app_t for snap.foo.app.
+ *
+ * The returned string must be freed by the caller.
+ **/
+char *sc_selinux_domain_for_security_tag(const char *security_tag);
+
 /**
  * Set security context for the snap
  *
- * Sets up SELinux context transition to unconfined_service_t.
+ * Sets up SELinux context transition to the domain of the application or hook
+ * with the given security tag. Snaps using classic confinement transition to
+ * unconfined_service_t instead, as do all snaps unless the experimental
+ * selinux-confinement feature is enabled.
  **/
-int sc_selinux_set_snap_execcon(void);
+int sc_selinux_set_snap_execcon(const char *security_tag, bool classic_confinement);
 
 #endif /* SNAP_CONFINE_SELINUX_SUPPORT_H */
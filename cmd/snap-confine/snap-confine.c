@@ -48,6 +48,7 @@
 #include "../libsnap-confine-private/tool.h"
 #include "../libsnap-confine-private/utils.h"
 #include "cookie-support.h"
+#include "landlock-support.h"
 #include "mount-support.h"
 #include "ns-support.h"
 #include "seccomp-support.h"
@@ -485,7 +486,8 @@ int main(int argc, char **argv)
 	sc_maybe_aa_change_onexec(&apparmor, invocation.security_tag);
 #ifdef HAVE_SELINUX
 	// For classic and confined snaps
-	sc_selinux_set_snap_execcon();
+	sc_selinux_set_snap_execcon(invocation.security_tag,
+				    invocation.classic_confinement);
 #endif
 	if (snap_context != NULL) {
 		setenv("SNAP_COOKIE", snap_context, 1);
@@ -550,6 +552,10 @@ int main(int argc, char **argv)
 			die("capset regain failed");
 		}
 	}
+	// Enforcing a landlock ruleset also requires either SYS_ADMIN or
+	// PR_SET_NO_NEW_PRIVS, apply the landlock profile, if any, while
+	// SYS_ADMIN is still held.
+	sc_apply_landlock_profile_for_security_tag(invocation.security_tag);
 	// Now that we've dropped and regained SYS_ADMIN, we can load the
 	// seccomp profiles.
 	sc_apply_seccomp_profile_for_security_tag(invocation.security_tag);
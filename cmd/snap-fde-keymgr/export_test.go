@@ -68,3 +68,57 @@ func MockOsStdin(r io.Reader) (restore func()) {
 	osStdin = r
 	return restore
 }
+
+func MockStageLUKSDevicePassphraseChange(f func(oldPassphrase, newPassphrase, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrStageLUKSDevicePassphraseChange)
+	keymgrStageLUKSDevicePassphraseChange = f
+	return restore
+}
+
+func MockUnstageLUKSDevicePassphraseChange(f func(oldPassphrase, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrUnstageLUKSDevicePassphraseChange)
+	keymgrUnstageLUKSDevicePassphraseChange = f
+	return restore
+}
+
+func MockTransitionLUKSDevicePassphraseChange(f func(newPassphrase, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrTransitionLUKSDevicePassphraseChange)
+	keymgrTransitionLUKSDevicePassphraseChange = f
+	return restore
+}
+
+func MockEncryptionKeyFromUserKeyring(f func(dev string) (keys.EncryptionKey, error)) (restore func()) {
+	restore = testutil.Backup(&keymgrEncryptionKeyFromUserKeyring)
+	keymgrEncryptionKeyFromUserKeyring = f
+	return restore
+}
+
+func MockAddNamedRecoveryKeyToLUKSUsingKey(f func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrAddNamedRecoveryKeyToLUKSDeviceUsingKey)
+	keymgrAddNamedRecoveryKeyToLUKSDeviceUsingKey = f
+	return restore
+}
+
+func MockRemoveNamedRecoveryKeyFromLUKSUsingKey(f func(name string, key keys.EncryptionKey, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrRemoveNamedRecoveryKeyUsingKey)
+	keymgrRemoveNamedRecoveryKeyUsingKey = f
+	return restore
+}
+
+func MockStageNamedRecoveryKeyRotation(f func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) (int, error)) (restore func()) {
+	restore = testutil.Backup(&keymgrStageNamedRecoveryKeyRotation)
+	keymgrStageNamedRecoveryKeyRotation = f
+	return restore
+}
+
+func MockTransitionNamedRecoveryKeyRotation(f func(name string, newSlot int, key keys.EncryptionKey, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrTransitionNamedRecoveryKeyRotation)
+	keymgrTransitionNamedRecoveryKeyRotation = f
+	return restore
+}
+
+func MockAbortNamedRecoveryKeyRotation(f func(name string, newSlot int, key keys.EncryptionKey, dev string) error) (restore func()) {
+	restore = testutil.Backup(&keymgrAbortNamedRecoveryKeyRotation)
+	keymgrAbortNamedRecoveryKeyRotation = f
+	return restore
+}
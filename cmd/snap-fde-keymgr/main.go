@@ -56,10 +56,35 @@ type cmdChangeEncryptionKey struct {
 	Transition bool   `long:"transition" description:"replace the old key, unstage the new"`
 }
 
+type cmdChangePassphrase struct {
+	Devices []string `long:"devices" description:"encrypted devices (can be more than one)" required:"yes"`
+}
+
+type namedRecoveryKeyMixin struct {
+	commonMultiDeviceMixin
+	Name string `long:"name" description:"name of the recovery key" required:"yes"`
+}
+
+type cmdAddNamedRecoveryKey struct {
+	namedRecoveryKeyMixin
+}
+
+type cmdRotateNamedRecoveryKey struct {
+	namedRecoveryKeyMixin
+}
+
+type cmdRemoveNamedRecoveryKey struct {
+	namedRecoveryKeyMixin
+}
+
 type options struct {
-	CmdAddRecoveryKey      cmdAddRecoveryKey      `command:"add-recovery-key"`
-	CmdRemoveRecoveryKey   cmdRemoveRecoveryKey   `command:"remove-recovery-key"`
-	CmdChangeEncryptionKey cmdChangeEncryptionKey `command:"change-encryption-key"`
+	CmdAddRecoveryKey         cmdAddRecoveryKey         `command:"add-recovery-key"`
+	CmdRemoveRecoveryKey      cmdRemoveRecoveryKey      `command:"remove-recovery-key"`
+	CmdChangeEncryptionKey    cmdChangeEncryptionKey    `command:"change-encryption-key"`
+	CmdChangePassphrase       cmdChangePassphrase       `command:"change-passphrase"`
+	CmdAddNamedRecoveryKey    cmdAddNamedRecoveryKey    `command:"add-named-recovery-key"`
+	CmdRotateNamedRecoveryKey cmdRotateNamedRecoveryKey `command:"rotate-named-recovery-key"`
+	CmdRemoveNamedRecoveryKey cmdRemoveNamedRecoveryKey `command:"remove-named-recovery-key"`
 }
 
 var (
@@ -69,6 +94,15 @@ var (
 	keymgrRemoveRecoveryKeyFromLUKSDeviceUsingKey = keymgr.RemoveRecoveryKeyFromLUKSDeviceUsingKey
 	keymgrStageLUKSDeviceEncryptionKeyChange      = keymgr.StageLUKSDeviceEncryptionKeyChange
 	keymgrTransitionLUKSDeviceEncryptionKeyChange = keymgr.TransitionLUKSDeviceEncryptionKeyChange
+	keymgrStageLUKSDevicePassphraseChange         = keymgr.StageLUKSDevicePassphraseChange
+	keymgrUnstageLUKSDevicePassphraseChange       = keymgr.UnstageLUKSDevicePassphraseChange
+	keymgrTransitionLUKSDevicePassphraseChange    = keymgr.TransitionLUKSDevicePassphraseChange
+	keymgrEncryptionKeyFromUserKeyring            = keymgr.EncryptionKeyFromUserKeyring
+	keymgrAddNamedRecoveryKeyToLUKSDeviceUsingKey = keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey
+	keymgrRemoveNamedRecoveryKeyUsingKey          = keymgr.RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey
+	keymgrStageNamedRecoveryKeyRotation           = keymgr.StageNamedRecoveryKeyRotation
+	keymgrTransitionNamedRecoveryKeyRotation      = keymgr.TransitionNamedRecoveryKeyRotation
+	keymgrAbortNamedRecoveryKeyRotation           = keymgr.AbortNamedRecoveryKeyRotation
 )
 
 func validateAuthorizations(authorizations []string) error {
@@ -233,6 +267,158 @@ func (c *cmdChangeEncryptionKey) Execute(args []string) error {
 	return nil
 }
 
+type passphraseChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func (c *cmdChangePassphrase) Execute(args []string) error {
+	var change passphraseChange
+	dec := json.NewDecoder(osStdin)
+	if err := dec.Decode(&change); err != nil {
+		return fmt.Errorf("cannot obtain passphrases: %v", err)
+	}
+	// stage the new passphrase on all the devices before replacing the old
+	// one anywhere, so that all the devices can be unlocked with the same
+	// passphrase at any point of the change; the old passphrase authorizes
+	// staging
+	for i, dev := range c.Devices {
+		if err := keymgrStageLUKSDevicePassphraseChange(change.Old, change.New, dev); err != nil {
+			var unstageErrs []string
+			for _, staged := range c.Devices[:i] {
+				if err := keymgrUnstageLUKSDevicePassphraseChange(change.Old, staged); err != nil {
+					unstageErrs = append(unstageErrs, fmt.Sprintf("%s: %v", staged, err))
+				}
+			}
+			if len(unstageErrs) > 0 {
+				return fmt.Errorf("cannot stage passphrase change of LUKS device %s: %v (cannot unstage the change of %s)", dev, err, strings.Join(unstageErrs, ", "))
+			}
+			return fmt.Errorf("cannot stage passphrase change of LUKS device %s: %v", dev, err)
+		}
+	}
+	// the new passphrase now unlocks all the devices and authorizes the rest
+	// of the change, which can be completed by running the command again
+	// with the new passphrase as the old one should it fail
+	for _, dev := range c.Devices {
+		if err := keymgrTransitionLUKSDevicePassphraseChange(change.New, dev); err != nil {
+			return fmt.Errorf("cannot transition passphrase change of LUKS device %s: %v", dev, err)
+		}
+	}
+	return nil
+}
+
+// authorizationKeys returns the keys authorizing the operations on each of the
+// devices.
+func (m *namedRecoveryKeyMixin) authorizationKeys() ([]keys.EncryptionKey, error) {
+	if len(m.Authorizations) != len(m.Devices) {
+		return nil, fmt.Errorf("mismatch in the number of devices and authorizations")
+	}
+	if err := validateAuthorizations(m.Authorizations); err != nil {
+		return nil, fmt.Errorf("invalid authorizations: %v", err)
+	}
+	authzKeys := make([]keys.EncryptionKey, len(m.Devices))
+	for i, dev := range m.Devices {
+		authz := m.Authorizations[i]
+		switch {
+		case authz == "keyring":
+			key, err := keymgrEncryptionKeyFromUserKeyring(dev)
+			if err != nil {
+				return nil, err
+			}
+			authzKeys[i] = key
+		case strings.HasPrefix(authz, "file:"):
+			key, err := os.ReadFile(authz[len("file:"):])
+			if err != nil {
+				return nil, fmt.Errorf("cannot load authorization key: %v", err)
+			}
+			authzKeys[i] = key
+		}
+	}
+	return authzKeys, nil
+}
+
+func readRecoveryKey() (keys.RecoveryKey, error) {
+	var recoveryKeyData newKey
+	dec := json.NewDecoder(osStdin)
+	if err := dec.Decode(&recoveryKeyData); err != nil {
+		return keys.RecoveryKey{}, fmt.Errorf("cannot obtain recovery key: %v", err)
+	}
+	var recoveryKey keys.RecoveryKey
+	if len(recoveryKeyData.Key) != len(recoveryKey) {
+		return keys.RecoveryKey{}, fmt.Errorf("cannot use a recovery key of size %v", len(recoveryKeyData.Key))
+	}
+	copy(recoveryKey[:], recoveryKeyData.Key)
+	return recoveryKey, nil
+}
+
+func (c *cmdAddNamedRecoveryKey) Execute(args []string) error {
+	authzKeys, err := c.authorizationKeys()
+	if err != nil {
+		return fmt.Errorf("cannot add recovery key: %v", err)
+	}
+	recoveryKey, err := readRecoveryKey()
+	if err != nil {
+		return err
+	}
+	for i, dev := range c.Devices {
+		if err := keymgrAddNamedRecoveryKeyToLUKSDeviceUsingKey(c.Name, recoveryKey, authzKeys[i], dev); err != nil {
+			// the key must be usable on all devices or none
+			for j := 0; j < i; j++ {
+				if err := keymgrRemoveNamedRecoveryKeyUsingKey(c.Name, authzKeys[j], c.Devices[j]); err != nil {
+					fmt.Fprintf(os.Stderr, "cannot remove recovery key %q from %v: %v\n", c.Name, c.Devices[j], err)
+				}
+			}
+			return fmt.Errorf("cannot add recovery key to LUKS device: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *cmdRotateNamedRecoveryKey) Execute(args []string) error {
+	authzKeys, err := c.authorizationKeys()
+	if err != nil {
+		return fmt.Errorf("cannot rotate recovery key: %v", err)
+	}
+	recoveryKey, err := readRecoveryKey()
+	if err != nil {
+		return err
+	}
+	// stage and verify the new key on all devices first, so that either
+	// all devices or none switch to the new key
+	stagedSlots := make([]int, len(c.Devices))
+	for i, dev := range c.Devices {
+		slot, err := keymgrStageNamedRecoveryKeyRotation(c.Name, recoveryKey, authzKeys[i], dev)
+		if err != nil {
+			for j := 0; j < i; j++ {
+				if err := keymgrAbortNamedRecoveryKeyRotation(c.Name, stagedSlots[j], authzKeys[j], c.Devices[j]); err != nil {
+					fmt.Fprintf(os.Stderr, "cannot abort rotation of recovery key %q on %v: %v\n", c.Name, c.Devices[j], err)
+				}
+			}
+			return fmt.Errorf("cannot stage recovery key rotation on LUKS device: %v", err)
+		}
+		stagedSlots[i] = slot
+	}
+	for i, dev := range c.Devices {
+		if err := keymgrTransitionNamedRecoveryKeyRotation(c.Name, stagedSlots[i], authzKeys[i], dev); err != nil {
+			return fmt.Errorf("cannot transition recovery key rotation on LUKS device: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *cmdRemoveNamedRecoveryKey) Execute(args []string) error {
+	authzKeys, err := c.authorizationKeys()
+	if err != nil {
+		return fmt.Errorf("cannot remove recovery key: %v", err)
+	}
+	for i, dev := range c.Devices {
+		if err := keymgrRemoveNamedRecoveryKeyUsingKey(c.Name, authzKeys[i], dev); err != nil {
+			return fmt.Errorf("cannot remove recovery key from LUKS device: %v", err)
+		}
+	}
+	return nil
+}
+
 func run(osArgs1 []string) error {
 	var opts options
 	p := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
@@ -450,3 +450,303 @@ func (s *mainSuite) TestTransitionEncryptionKey(c *C) {
 	})
 	c.Assert(err, ErrorMatches, "cannot transition LUKS device encryption key change: mock transition error")
 }
+
+func (s *mainSuite) TestChangePassphrase(c *C) {
+	b := bytes.NewBufferString(`{"old":"old passphrase","new":"new passphrase"}`)
+	restore := main.MockOsStdin(b)
+	defer restore()
+	var ops []string
+	restore = main.MockStageLUKSDevicePassphraseChange(func(oldPassphrase, newPassphrase, dev string) error {
+		c.Check(oldPassphrase, Equals, "old passphrase")
+		c.Check(newPassphrase, Equals, "new passphrase")
+		ops = append(ops, "stage:"+dev)
+		return nil
+	})
+	defer restore()
+	restore = main.MockUnstageLUKSDevicePassphraseChange(func(oldPassphrase, dev string) error {
+		c.Errorf("unexpected call")
+		return nil
+	})
+	defer restore()
+	restore = main.MockTransitionLUKSDevicePassphraseChange(func(newPassphrase, dev string) error {
+		c.Check(newPassphrase, Equals, "new passphrase")
+		ops = append(ops, "transition:"+dev)
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"change-passphrase",
+		"--devices", "/dev/vda4",
+		"--devices", "/dev/vda5",
+	})
+	c.Assert(err, IsNil)
+	// the new passphrase is staged on all devices before any transition
+	c.Check(ops, DeepEquals, []string{
+		"stage:/dev/vda4", "stage:/dev/vda5",
+		"transition:/dev/vda4", "transition:/dev/vda5",
+	})
+}
+
+func (s *mainSuite) TestChangePassphraseErrors(c *C) {
+	restore := main.MockOsStdin(bytes.NewBufferString(`garbage`))
+	defer restore()
+
+	err := main.Run([]string{"change-passphrase", "--devices", "/dev/vda4"})
+	c.Assert(err, ErrorMatches, "cannot obtain passphrases: invalid character 'g' looking for beginning of value")
+}
+
+func (s *mainSuite) TestChangePassphraseStageErrorUnstages(c *C) {
+	var ops []string
+	var unstageErr error
+	restore := main.MockStageLUKSDevicePassphraseChange(func(oldPassphrase, newPassphrase, dev string) error {
+		ops = append(ops, "stage:"+dev)
+		if dev == "/dev/vda5" {
+			return fmt.Errorf("mock stage error")
+		}
+		return nil
+	})
+	defer restore()
+	restore = main.MockUnstageLUKSDevicePassphraseChange(func(oldPassphrase, dev string) error {
+		c.Check(oldPassphrase, Equals, "old")
+		ops = append(ops, "unstage:"+dev)
+		return unstageErr
+	})
+	defer restore()
+	restore = main.MockTransitionLUKSDevicePassphraseChange(func(newPassphrase, dev string) error {
+		c.Errorf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	restore = main.MockOsStdin(bytes.NewBufferString(`{"old":"old","new":"new"}`))
+	defer restore()
+	err := main.Run([]string{"change-passphrase", "--devices", "/dev/vda4", "--devices", "/dev/vda5"})
+	c.Assert(err, ErrorMatches, "cannot stage passphrase change of LUKS device /dev/vda5: mock stage error")
+	// the already staged device is rolled back
+	c.Check(ops, DeepEquals, []string{"stage:/dev/vda4", "stage:/dev/vda5", "unstage:/dev/vda4"})
+
+	ops = nil
+	unstageErr = fmt.Errorf("mock unstage error")
+	restore = main.MockOsStdin(bytes.NewBufferString(`{"old":"old","new":"new"}`))
+	defer restore()
+	err = main.Run([]string{"change-passphrase", "--devices", "/dev/vda4", "--devices", "/dev/vda5"})
+	c.Assert(err, ErrorMatches, `cannot stage passphrase change of LUKS device /dev/vda5: mock stage error \(cannot unstage the change of /dev/vda4: mock unstage error\)`)
+}
+
+func (s *mainSuite) TestChangePassphraseTransitionError(c *C) {
+	restore := main.MockStageLUKSDevicePassphraseChange(func(oldPassphrase, newPassphrase, dev string) error {
+		return nil
+	})
+	defer restore()
+	restore = main.MockTransitionLUKSDevicePassphraseChange(func(newPassphrase, dev string) error {
+		return fmt.Errorf("mock transition error")
+	})
+	defer restore()
+
+	restore = main.MockOsStdin(bytes.NewBufferString(`{"old":"old","new":"new"}`))
+	defer restore()
+	err := main.Run([]string{"change-passphrase", "--devices", "/dev/vda4"})
+	c.Assert(err, ErrorMatches, "cannot transition passphrase change of LUKS device /dev/vda4: mock transition error")
+}
+
+// JSON encoded recovery key of all 1s
+const all1sRecoveryKey = `{"key":"AQEBAQEBAQEBAQEBAQEBAQ=="}`
+
+var all1sRecoveryKeyBytes = keys.RecoveryKey{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+func (s *mainSuite) mockNamedKeysAuthorization(c *C) (authzFile string) {
+	authzFile = filepath.Join(c.MkDir(), "authz.key")
+	c.Assert(os.WriteFile(authzFile, []byte("file-key"), 0600), IsNil)
+	return authzFile
+}
+
+func (s *mainSuite) TestAddNamedRecoveryKey(c *C) {
+	authzFile := s.mockNamedKeysAuthorization(c)
+	restore := main.MockOsStdin(bytes.NewBufferString(all1sRecoveryKey))
+	defer restore()
+	restore = main.MockEncryptionKeyFromUserKeyring(func(dev string) (keys.EncryptionKey, error) {
+		c.Check(dev, Equals, "/dev/vda4")
+		return keys.EncryptionKey("keyring-key"), nil
+	})
+	defer restore()
+	var calls []string
+	restore = main.MockAddNamedRecoveryKeyToLUKSUsingKey(func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) error {
+		c.Check(name, Equals, "backup")
+		c.Check(recoveryKey, DeepEquals, all1sRecoveryKeyBytes)
+		calls = append(calls, fmt.Sprintf("%s:%s", dev, key))
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"add-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "keyring",
+		"--devices", "/dev/vda5", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, IsNil)
+	c.Check(calls, DeepEquals, []string{"/dev/vda4:keyring-key", "/dev/vda5:file-key"})
+}
+
+func (s *mainSuite) TestAddNamedRecoveryKeyUndoOnError(c *C) {
+	authzFile := s.mockNamedKeysAuthorization(c)
+	restore := main.MockOsStdin(bytes.NewBufferString(all1sRecoveryKey))
+	defer restore()
+	restore = main.MockAddNamedRecoveryKeyToLUKSUsingKey(func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) error {
+		if dev == "/dev/vda5" {
+			return fmt.Errorf("mock error")
+		}
+		return nil
+	})
+	defer restore()
+	var removed []string
+	restore = main.MockRemoveNamedRecoveryKeyFromLUKSUsingKey(func(name string, key keys.EncryptionKey, dev string) error {
+		c.Check(name, Equals, "backup")
+		removed = append(removed, dev)
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"add-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:" + authzFile,
+		"--devices", "/dev/vda5", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, ErrorMatches, "cannot add recovery key to LUKS device: mock error")
+	c.Check(removed, DeepEquals, []string{"/dev/vda4"})
+}
+
+func (s *mainSuite) TestAddNamedRecoveryKeyErrors(c *C) {
+	err := main.Run([]string{
+		"add-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "keyring",
+		"--devices", "/dev/vda5",
+	})
+	c.Assert(err, ErrorMatches, "cannot add recovery key: mismatch in the number of devices and authorizations")
+
+	err = main.Run([]string{
+		"add-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:/does/not/exist",
+	})
+	c.Assert(err, ErrorMatches, "cannot add recovery key: invalid authorizations: authorization file /does/not/exist does not exist")
+
+	restore := main.MockEncryptionKeyFromUserKeyring(func(dev string) (keys.EncryptionKey, error) {
+		return keys.EncryptionKey("keyring-key"), nil
+	})
+	defer restore()
+	restore = main.MockOsStdin(bytes.NewBufferString(`{"key":"AQE="}`))
+	defer restore()
+	err = main.Run([]string{
+		"add-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "keyring",
+	})
+	c.Assert(err, ErrorMatches, "cannot use a recovery key of size 2")
+}
+
+func (s *mainSuite) TestRotateNamedRecoveryKey(c *C) {
+	authzFile := s.mockNamedKeysAuthorization(c)
+	restore := main.MockOsStdin(bytes.NewBufferString(all1sRecoveryKey))
+	defer restore()
+	var calls []string
+	restore = main.MockStageNamedRecoveryKeyRotation(func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) (int, error) {
+		c.Check(name, Equals, "backup")
+		c.Check(recoveryKey, DeepEquals, all1sRecoveryKeyBytes)
+		calls = append(calls, "stage:"+dev)
+		if dev == "/dev/vda4" {
+			return 4, nil
+		}
+		return 5, nil
+	})
+	defer restore()
+	restore = main.MockTransitionNamedRecoveryKeyRotation(func(name string, newSlot int, key keys.EncryptionKey, dev string) error {
+		c.Check(name, Equals, "backup")
+		calls = append(calls, fmt.Sprintf("transition:%s:%d", dev, newSlot))
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"rotate-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:" + authzFile,
+		"--devices", "/dev/vda5", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, IsNil)
+	// all devices are staged before any transition
+	c.Check(calls, DeepEquals, []string{
+		"stage:/dev/vda4",
+		"stage:/dev/vda5",
+		"transition:/dev/vda4:4",
+		"transition:/dev/vda5:5",
+	})
+}
+
+func (s *mainSuite) TestRotateNamedRecoveryKeyAbortOnError(c *C) {
+	authzFile := s.mockNamedKeysAuthorization(c)
+	restore := main.MockOsStdin(bytes.NewBufferString(all1sRecoveryKey))
+	defer restore()
+	restore = main.MockStageNamedRecoveryKeyRotation(func(name string, recoveryKey keys.RecoveryKey, key keys.EncryptionKey, dev string) (int, error) {
+		if dev == "/dev/vda5" {
+			return 0, fmt.Errorf("mock error")
+		}
+		return 4, nil
+	})
+	defer restore()
+	var aborted []string
+	restore = main.MockAbortNamedRecoveryKeyRotation(func(name string, newSlot int, key keys.EncryptionKey, dev string) error {
+		aborted = append(aborted, fmt.Sprintf("%s:%d", dev, newSlot))
+		return nil
+	})
+	defer restore()
+	restore = main.MockTransitionNamedRecoveryKeyRotation(func(name string, newSlot int, key keys.EncryptionKey, dev string) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"rotate-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:" + authzFile,
+		"--devices", "/dev/vda5", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, ErrorMatches, "cannot stage recovery key rotation on LUKS device: mock error")
+	c.Check(aborted, DeepEquals, []string{"/dev/vda4:4"})
+}
+
+func (s *mainSuite) TestRemoveNamedRecoveryKey(c *C) {
+	authzFile := s.mockNamedKeysAuthorization(c)
+	var removed []string
+	restore := main.MockRemoveNamedRecoveryKeyFromLUKSUsingKey(func(name string, key keys.EncryptionKey, dev string) error {
+		c.Check(name, Equals, "backup")
+		c.Check(string(key), Equals, "file-key")
+		removed = append(removed, dev)
+		return nil
+	})
+	defer restore()
+
+	err := main.Run([]string{
+		"remove-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:" + authzFile,
+		"--devices", "/dev/vda5", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []string{"/dev/vda4", "/dev/vda5"})
+
+	restore = main.MockRemoveNamedRecoveryKeyFromLUKSUsingKey(func(name string, key keys.EncryptionKey, dev string) error {
+		return fmt.Errorf("mock error")
+	})
+	defer restore()
+	err = main.Run([]string{
+		"remove-named-recovery-key",
+		"--name", "backup",
+		"--devices", "/dev/vda4", "--authorizations", "file:" + authzFile,
+	})
+	c.Assert(err, ErrorMatches, "cannot remove recovery key from LUKS device: mock error")
+}
@@ -40,6 +40,12 @@ func MockPreseedCore20(f func(opts *preseed.CoreOptions) error) (restore func())
 	return r
 }
 
+func MockPreseedCore20Verify(f func(opts *preseed.CoreOptions) error) (restore func()) {
+	r := testutil.Backup(&preseedCore20Verify)
+	preseedCore20Verify = f
+	return r
+}
+
 func MockPreseedClassic(f func(dir string) error) (restore func()) {
 	r := testutil.Backup(&preseedClassic)
 	preseedClassic = f
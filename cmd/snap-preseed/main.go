@@ -43,7 +43,16 @@ snaps (at /var/lib/snapd/seed), and runs through the snapd first-boot process
 up to hook execution. No boot actions unrelated to snapd are performed.
 It creates systemd units for seeded snaps, makes any connections, and generates
 security profiles. The image is updated and consequently optimised to reduce
-first-boot startup time`
+first-boot startup time.
+
+For Ubuntu Core images, --reproducible creates the preseed artifact
+using SOURCE_DATE_EPOCH for timestamps and sorted content, and records its
+inputs and content in preseed.manifest in the image directory. The snapd
+state and the snap cookies are not deterministic, the manifest lists the
+digest of the state with timestamps and random values normalized and no
+digest for the cookies. --verify checks that the preseed artifact matches
+the existing preseed assertion, then runs preseeding again and checks that
+the result has the same content, without modifying the image.`
 )
 
 type options struct {
@@ -52,6 +61,8 @@ type options struct {
 	PreseedSignKey      string `long:"preseed-sign-key"`
 	AppArmorFeaturesDir string `long:"apparmor-features-dir"`
 	SysfsOverlay        string `long:"sysfs-overlay"`
+	Reproducible        bool   `long:"reproducible"`
+	Verify              bool   `long:"verify"`
 }
 
 var (
@@ -61,6 +72,7 @@ var (
 	Stderr io.Writer = os.Stderr
 
 	preseedCore20               = preseed.Core20
+	preseedCore20Verify         = preseed.Core20Verify
 	preseedClassic              = preseed.Classic
 	preseedClassicReset         = preseed.ClassicReset
 	preseedResetPreseededChroot = preseed.ResetPreseededChroot
@@ -133,9 +145,19 @@ func run(parser *flags.Parser, args []string) (err error) {
 			PreseedSignKey:            opts.PreseedSignKey,
 			AppArmorKernelFeaturesDir: opts.AppArmorFeaturesDir,
 			SysfsOverlay:              opts.SysfsOverlay,
+			Reproducible:              opts.Reproducible,
+		}
+		if opts.Verify {
+			if opts.PreseedSignKey != "" {
+				return fmt.Errorf("cannot use --preseed-sign-key with --verify")
+			}
+			return preseedCore20Verify(coreOpts)
 		}
 		return preseedCore20(coreOpts)
 	}
+	if opts.Reproducible || opts.Verify {
+		return fmt.Errorf("cannot snap-preseed --reproducible or --verify for classic images")
+	}
 	if opts.ResetChroot {
 		return preseedResetPreseededChroot(chrootDir)
 	}
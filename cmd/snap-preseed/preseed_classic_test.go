@@ -105,6 +105,24 @@ func (s *startPreseedSuite) TestRunPreseedClassicHappy(c *C) {
 	c.Check(called, Equals, true)
 }
 
+func (s *startPreseedSuite) TestRunPreseedClassicVerifyError(c *C) {
+	restore := main.MockOsGetuid(func() int {
+		return 0
+	})
+	defer restore()
+
+	restorePreseed := main.MockPreseedClassic(func(dir string) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restorePreseed()
+
+	for _, flag := range []string{"--verify", "--reproducible"} {
+		parser := testParser(c)
+		c.Check(main.Run(parser, []string{flag, "/a/dir"}), ErrorMatches, `cannot snap-preseed --reproducible or --verify for classic images`)
+	}
+}
+
 func (s *startPreseedSuite) TestResetReexeced(c *C) {
 	restore := main.MockOsGetuid(func() int {
 		return 0
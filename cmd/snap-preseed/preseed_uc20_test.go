@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -89,6 +90,68 @@ func (s *startPreseedSuite) TestRunPreseedUC20HappyNoArgs(c *C) {
 	c.Check(called, Equals, true)
 }
 
+func (s *startPreseedSuite) TestRunPreseedUC20Reproducible(c *C) {
+	tmpDir := c.MkDir()
+	dirs.SetRootDir(tmpDir)
+
+	restore := main.MockOsGetuid(func() int {
+		return 0
+	})
+	defer restore()
+
+	// for UC20 probing
+	c.Assert(os.MkdirAll(filepath.Join(tmpDir, "system-seed/systems/20220203"), 0755), IsNil)
+
+	var called bool
+	restorePreseed := main.MockPreseedCore20(func(opts *preseed.CoreOptions) error {
+		c.Check(opts.PrepareImageDir, Equals, tmpDir)
+		c.Check(opts.Reproducible, Equals, true)
+		called = true
+		return nil
+	})
+	defer restorePreseed()
+
+	parser := testParser(c)
+	c.Assert(main.Run(parser, []string{"--reproducible", tmpDir}), IsNil)
+	c.Check(called, Equals, true)
+}
+
+func (s *startPreseedSuite) TestRunPreseedUC20Verify(c *C) {
+	tmpDir := c.MkDir()
+	dirs.SetRootDir(tmpDir)
+
+	restore := main.MockOsGetuid(func() int {
+		return 0
+	})
+	defer restore()
+
+	// for UC20 probing
+	c.Assert(os.MkdirAll(filepath.Join(tmpDir, "system-seed/systems/20220203"), 0755), IsNil)
+
+	restorePreseed := main.MockPreseedCore20(func(opts *preseed.CoreOptions) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restorePreseed()
+	var called bool
+	restoreVerify := main.MockPreseedCore20Verify(func(opts *preseed.CoreOptions) error {
+		c.Check(opts.PrepareImageDir, Equals, tmpDir)
+		c.Check(opts.AppArmorKernelFeaturesDir, Equals, "/custom/aa/features")
+		called = true
+		return fmt.Errorf("mismatch")
+	})
+	defer restoreVerify()
+
+	parser := testParser(c)
+	err := main.Run(parser, []string{"--verify", "--apparmor-features-dir", "/custom/aa/features", tmpDir})
+	c.Assert(err, ErrorMatches, "mismatch")
+	c.Check(called, Equals, true)
+
+	parser = testParser(c)
+	err = main.Run(parser, []string{"--verify", "--preseed-sign-key", "key", tmpDir})
+	c.Assert(err, ErrorMatches, "cannot use --preseed-sign-key with --verify")
+}
+
 func (s *startPreseedSuite) TestResetUC20(c *C) {
 	tmpDir := c.MkDir()
 	dirs.SetRootDir(tmpDir)
@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/boot"
+)
+
+type cmdBootChains struct{}
+
+func init() {
+	addDebugCommand("boot-chains",
+		"(internal) obtain the boot chains the encryption keys are sealed against",
+		"(internal) obtain the boot chains the encryption keys are sealed against, together with the candidate boot chains derived from the current boot state",
+		func() flags.Commander {
+			return &cmdBootChains{}
+		}, nil, nil)
+}
+
+var bootDebugDumpBootChains = boot.DebugDumpBootChains
+
+func (x *cmdBootChains) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	return bootDebugDumpBootChains(Stdout)
+}
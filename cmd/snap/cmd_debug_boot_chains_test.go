@@ -0,0 +1,58 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugBootChains(c *check.C) {
+	restore := snap.MockBootDebugDumpBootChains(func(w io.Writer) error {
+		fmt.Fprintf(w, "run-key:\n  reseal-count: 1\n")
+		return nil
+	})
+	defer restore()
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "boot-chains"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, "run-key:\n  reseal-count: 1\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugBootChainsError(c *check.C) {
+	restore := snap.MockBootDebugDumpBootChains(func(w io.Writer) error {
+		return fmt.Errorf("cannot show boot chains: no sealed encryption keys")
+	})
+	defer restore()
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "boot-chains"})
+	c.Assert(err, check.ErrorMatches, "cannot show boot chains: no sealed encryption keys")
+}
+
+func (s *SnapSuite) TestDebugBootChainsExtraArgs(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "boot-chains", "extra"})
+	c.Assert(err, check.Equals, snap.ErrExtraArgs)
+}
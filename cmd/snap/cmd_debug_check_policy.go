@@ -0,0 +1,254 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/signtool"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+)
+
+type cmdDebugCheckPolicy struct {
+	SnapYaml    flags.Filename `long:"snap-yaml" required:"yes"`
+	Declaration flags.Filename `long:"declaration"`
+	Model       flags.Filename `long:"model"`
+}
+
+var longDebugCheckPolicyHelp = i18n.G(`
+The check-policy command checks the plugs and slots of the given snap.yaml
+against the builtin base-declaration and, optionally, a snap-declaration and
+a model, without contacting snapd or the store.
+
+For each plug and slot it reports whether installation is allowed. For each
+plug it also reports whether it may be connected and auto-connected to the
+implicit slot of the system, if any. Every outcome is shown along with the
+declaration rule which decided it.
+
+Assertions are not verified. Instead of a signed assertion, an unsigned
+draft can be given, using the JSON mapping of headers understood by
+'snap sign', so the command can be used before declarations are signed.
+`)
+
+func init() {
+	addDebugCommand("check-policy",
+		i18n.G("Check interface policy for a snap"),
+		longDebugCheckPolicyHelp,
+		func() flags.Commander {
+			return &cmdDebugCheckPolicy{}
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"snap-yaml": i18n.G("Path to the snap.yaml of the snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"declaration": i18n.G("Path to the snap-declaration assertion of the snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"model": i18n.G("Path to the model assertion of the device"),
+		}, nil)
+}
+
+var assertsGenerateKey = asserts.GenerateKey
+
+// signDraft signs the JSON mapping of the headers of an unsigned assertion,
+// as accepted by "snap sign", with a throwaway key.
+func signDraft(statement []byte) ([]byte, error) {
+	privKey, err := assertsGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	keypairMgr := asserts.NewMemoryKeypairManager()
+	if err := keypairMgr.Put(privKey); err != nil {
+		return nil, err
+	}
+	return signtool.Sign(&signtool.Options{
+		KeyID:     privKey.PublicKey().ID(),
+		Statement: statement,
+	}, keypairMgr)
+}
+
+func readAssertionFile(path string, assertType *asserts.AssertionType) (asserts.Assertion, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		data, err = signDraft(data)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot use draft %q: %v"), path, err)
+		}
+	}
+	a, err := asserts.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot decode %q: %v"), path, err)
+	}
+	if a.Type() != assertType {
+		return nil, fmt.Errorf(i18n.G("cannot use %q: expected a %s assertion, got %s"), path, assertType.Name, a.Type().Name)
+	}
+	return a, nil
+}
+
+// implicitSystemSnap returns the snapd snap with the implicit slots of the
+// builtin interfaces.
+func implicitSystemSnap(classic bool) *snap.Info {
+	info := &snap.Info{
+		SuggestedName: "snapd",
+		SnapType:      snap.TypeSnapd,
+		Slots:         make(map[string]*snap.SlotInfo),
+	}
+	for _, iface := range builtin.Interfaces() {
+		si := interfaces.StaticInfoOf(iface)
+		if (classic && si.ImplicitOnClassic) || (!classic && si.ImplicitOnCore) {
+			name := iface.Name()
+			info.Slots[name] = &snap.SlotInfo{
+				Name:      name,
+				Snap:      info,
+				Interface: name,
+			}
+		}
+	}
+	return info
+}
+
+// fmtPolicyOutcome formats the outcome of a policy check.
+func fmtPolicyOutcome(rule *policy.DecidingRule, err error) string {
+	switch {
+	case rule == nil && err == nil:
+		return i18n.G("allowed, no rule applies")
+	case rule == nil:
+		return fmt.Sprintf(i18n.G("error: %v"), err)
+	case err == nil:
+		return fmt.Sprintf(i18n.G("allowed by %s"), rule)
+	case strings.HasPrefix(rule.Constraint, "deny-"):
+		return fmt.Sprintf(i18n.G("denied by %s"), rule)
+	default:
+		return fmt.Sprintf(i18n.G("not allowed by %s"), rule)
+	}
+}
+
+func (x *cmdDebugCheckPolicy) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	yaml, err := os.ReadFile(string(x.SnapYaml))
+	if err != nil {
+		return err
+	}
+	info, err := snap.InfoFromSnapYaml(yaml)
+	if err != nil {
+		return err
+	}
+	// plug/slot sanitization is disabled by default for the "snap"
+	// command, but it sets attributes the rules may depend on
+	builtin.SanitizePlugsSlots(info)
+	for name, bad := range info.BadInterfaces {
+		fmt.Fprintf(Stderr, i18n.G("WARNING: ignoring %q: %s\n"), name, bad)
+	}
+
+	var snapDecl *asserts.SnapDeclaration
+	if x.Declaration != "" {
+		a, err := readAssertionFile(string(x.Declaration), asserts.SnapDeclarationType)
+		if err != nil {
+			return err
+		}
+		snapDecl = a.(*asserts.SnapDeclaration)
+		if snapDecl.SnapName() != info.SnapName() {
+			return fmt.Errorf(i18n.G("cannot use snap-declaration of snap %q with snap %q"), snapDecl.SnapName(), info.SnapName())
+		}
+		info.SnapID = snapDecl.SnapID()
+	}
+
+	classic := release.OnClassic
+	var model *asserts.Model
+	if x.Model != "" {
+		a, err := readAssertionFile(string(x.Model), asserts.ModelType)
+		if err != nil {
+			return err
+		}
+		model = a.(*asserts.Model)
+		classic = model.Classic()
+	}
+
+	baseDecl := asserts.BuiltinBaseDeclaration()
+	ic := policy.InstallCandidate{
+		Snap:            info,
+		SnapDeclaration: snapDecl,
+		BaseDeclaration: baseDecl,
+		Model:           model,
+	}
+	appSet, err := interfaces.NewSnapAppSet(info, nil)
+	if err != nil {
+		return err
+	}
+	system := implicitSystemSnap(classic)
+	systemAppSet, err := interfaces.NewSnapAppSet(system, nil)
+	if err != nil {
+		return err
+	}
+
+	plugs := make([]*snap.PlugInfo, 0, len(info.Plugs))
+	for _, plug := range info.Plugs {
+		plugs = append(plugs, plug)
+	}
+	sort.Slice(plugs, func(i, j int) bool { return plugs[i].Name < plugs[j].Name })
+	slots := make([]*snap.SlotInfo, 0, len(info.Slots))
+	for _, slot := range info.Slots {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Name < slots[j].Name })
+
+	w := Stdout
+	for _, plug := range plugs {
+		fmt.Fprintf(w, i18n.G("plug %s (interface %s):\n"), plug.Name, plug.Interface)
+		fmt.Fprintf(w, i18n.G("  installation: %s\n"), fmtPolicyOutcome(ic.ExplainPlug(plug)))
+
+		slot := system.Slots[plug.Interface]
+		if slot == nil {
+			fmt.Fprintln(w, i18n.G("  no implicit slot on the system"))
+			continue
+		}
+		connc := policy.ConnectCandidate{
+			Plug:                interfaces.NewConnectedPlug(plug, appSet, nil, nil),
+			PlugSnapDeclaration: snapDecl,
+			Slot:                interfaces.NewConnectedSlot(slot, systemAppSet, nil, nil),
+			BaseDeclaration:     baseDecl,
+			Model:               model,
+		}
+		fmt.Fprintf(w, i18n.G("  connection to %s:%s: %s\n"), system.SnapName(), slot.Name, fmtPolicyOutcome(connc.ExplainConnect()))
+		_, rule, err := connc.ExplainAutoConnect()
+		fmt.Fprintf(w, i18n.G("  auto-connection to %s:%s: %s\n"), system.SnapName(), slot.Name, fmtPolicyOutcome(rule, err))
+	}
+	for _, slot := range slots {
+		fmt.Fprintf(w, i18n.G("slot %s (interface %s):\n"), slot.Name, slot.Interface)
+		fmt.Fprintf(w, i18n.G("  installation: %s\n"), fmtPolicyOutcome(ic.ExplainSlot(slot)))
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const checkPolicySnapYaml = `name: foo
+version: 1
+plugs:
+  cam:
+    interface: camera
+  snapd-control:
+slots:
+  mpris:
+`
+
+func (s *SnapSuite) writeCheckPolicyFiles(c *C) (dir string) {
+	dir = c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(dir, "snap.yaml"), []byte(checkPolicySnapYaml), 0644), IsNil)
+
+	storeSigning := assertstest.NewStoreStack("canonical", nil)
+	decl, err := storeSigning.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"format":       "1",
+		"series":       "16",
+		"snap-id":      "foo-id",
+		"snap-name":    "foo",
+		"publisher-id": "canonical",
+		"plugs": map[string]interface{}{
+			"camera": map[string]interface{}{
+				"allow-auto-connection": "true",
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "decl"), asserts.Encode(decl), 0644), IsNil)
+
+	model, err := storeSigning.Sign(asserts.ModelType, map[string]interface{}{
+		"series":       "16",
+		"brand-id":     "canonical",
+		"model":        "pc",
+		"architecture": "amd64",
+		"gadget":       "pc",
+		"kernel":       "pc-kernel",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "model"), asserts.Encode(model), 0644), IsNil)
+	return dir
+}
+
+func (s *SnapSuite) TestDebugCheckPolicy(c *C) {
+	dir := s.writeCheckPolicyFiles(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy",
+		"--snap-yaml", filepath.Join(dir, "snap.yaml"),
+		"--declaration", filepath.Join(dir, "decl"),
+		"--model", filepath.Join(dir, "model")})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `plug cam (interface camera):
+  installation: allowed by allow-installation of plug rule of interface "camera" in snap-declaration
+  connection to snapd:camera: allowed by allow-connection of plug rule of interface "camera" in snap-declaration
+  auto-connection to snapd:camera: allowed by allow-auto-connection of plug rule of interface "camera" in snap-declaration
+plug snapd-control (interface snapd-control):
+  installation: not allowed by allow-installation of plug rule of interface "snapd-control" in base-declaration
+  connection to snapd:snapd-control: allowed by allow-connection of plug rule of interface "snapd-control" in base-declaration
+  auto-connection to snapd:snapd-control: denied by deny-auto-connection of plug rule of interface "snapd-control" in base-declaration
+slot mpris (interface mpris):
+  installation: allowed by allow-installation of slot rule of interface "mpris" in base-declaration
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugCheckPolicyUnsignedDraft(c *C) {
+	restore := snap.MockAssertsGenerateKey(func() (asserts.PrivateKey, error) {
+		privKey, _ := assertstest.GenerateKey(752)
+		return privKey, nil
+	})
+	defer restore()
+
+	dir := s.writeCheckPolicyFiles(c)
+	// the same headers as "snap sign" takes
+	draft := `{
+  "type": "snap-declaration",
+  "authority-id": "canonical",
+  "format": "1",
+  "series": "16",
+  "snap-id": "foo-id",
+  "snap-name": "foo",
+  "publisher-id": "canonical",
+  "plugs": {
+    "snapd-control": {
+      "allow-installation": "true"
+    }
+  },
+  "timestamp": "2024-01-01T00:00:00Z"
+}
+`
+	c.Assert(os.WriteFile(filepath.Join(dir, "draft"), []byte(draft), 0644), IsNil)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy",
+		"--snap-yaml", filepath.Join(dir, "snap.yaml"),
+		"--declaration", filepath.Join(dir, "draft")})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), testutil.Contains, `plug snapd-control (interface snapd-control):
+  installation: allowed by allow-installation of plug rule of interface "snapd-control" in snap-declaration
+`)
+
+	c.Assert(os.WriteFile(filepath.Join(dir, "draft"), []byte(`{"type": "snap-declaration"}`), 0644), IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy",
+		"--snap-yaml", filepath.Join(dir, "snap.yaml"),
+		"--declaration", filepath.Join(dir, "draft")})
+	c.Check(err, ErrorMatches, `cannot use draft ".*/draft": .*`)
+}
+
+func (s *SnapSuite) TestDebugCheckPolicyErrors(c *C) {
+	dir := s.writeCheckPolicyFiles(c)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy",
+		"--snap-yaml", filepath.Join(dir, "snap.yaml"),
+		"--declaration", filepath.Join(dir, "model")})
+	c.Check(err, ErrorMatches, `cannot use ".*/model": expected a snap-declaration assertion, got model`)
+
+	c.Assert(os.WriteFile(filepath.Join(dir, "snap.yaml"), []byte("name: bar\nversion: 1\n"), 0644), IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy",
+		"--snap-yaml", filepath.Join(dir, "snap.yaml"),
+		"--declaration", filepath.Join(dir, "decl")})
+	c.Check(err, ErrorMatches, `cannot use snap-declaration of snap "foo" with snap "bar"`)
+}
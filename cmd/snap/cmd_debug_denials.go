@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortDebugDenialsHelp = i18n.G("Show sandbox denials of a snap")
+var longDebugDenialsHelp = i18n.G(`
+The denials command shows the accesses of the given snap which were recently
+denied by AppArmor or seccomp, as found in the system journal. Each distinct
+access is shown once, along with the interfaces which would permit it.
+
+Suggestions are based on the rules generated by the interfaces for the snap
+and may not be complete. In particular D-Bus accesses and system calls made
+with the compatibility ABI are never matched.
+`)
+
+type cmdDebugDenials struct {
+	clientMixin
+	timeMixin
+
+	Positionals struct {
+		Snap installedSnapName `required:"yes" positional-arg-name:"<snap>"`
+	} `positional-args:"true"`
+}
+
+func init() {
+	addDebugCommand("denials", shortDebugDenialsHelp, longDebugDenialsHelp,
+		func() flags.Commander { return &cmdDebugDenials{} },
+		timeDescs, nil)
+}
+
+// sandboxDenial mirrors the denials returned by the API.
+type sandboxDenial struct {
+	Backend     string    `json:"backend"`
+	SecurityTag string    `json:"security-tag"`
+	Access      string    `json:"access"`
+	Count       int       `json:"count"`
+	FirstSeen   time.Time `json:"first-seen"`
+	LastSeen    time.Time `json:"last-seen"`
+	Suggestions []struct {
+		Interface string `json:"interface"`
+		Plug      string `json:"plug"`
+		Connected bool   `json:"connected"`
+		Rule      string `json:"rule"`
+	} `json:"suggestions"`
+}
+
+func (x *cmdDebugDenials) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	x.setClient(mkClient())
+
+	snapName := string(x.Positionals.Snap)
+	var denials []sandboxDenial
+	if err := x.client.DebugGet("denials", &denials, map[string]string{"snap": snapName}); err != nil {
+		return err
+	}
+	if len(denials) == 0 {
+		fmt.Fprintf(Stderr, i18n.G("No denials found for snap %q.\n"), snapName)
+		return nil
+	}
+
+	for i, denial := range denials {
+		if i > 0 {
+			fmt.Fprintln(Stdout)
+		}
+		who := denial.SecurityTag
+		if who == "" {
+			who = snapName
+		}
+		fmt.Fprintf(Stdout, "%s: %s: %s\n", denial.Backend, who, denial.Access)
+		fmt.Fprintf(Stdout, i18n.G("  denied %d time(s), last %s\n"), denial.Count, x.fmtTime(denial.LastSeen))
+		if len(denial.Suggestions) == 0 {
+			fmt.Fprintln(Stdout, i18n.G("  no interface is known to permit this access"))
+			continue
+		}
+		for _, sug := range denial.Suggestions {
+			switch {
+			case sug.Plug == "":
+				fmt.Fprintf(Stdout, i18n.G("  add a plug for interface %q (%s)\n"), sug.Interface, sug.Rule)
+			case sug.Connected:
+				fmt.Fprintf(Stdout, i18n.G("  plug %s:%s of interface %q is already connected (%s)\n"), snapName, sug.Plug, sug.Interface, sug.Rule)
+			default:
+				fmt.Fprintf(Stdout, i18n.G("  connect plug %s:%s of interface %q (%s)\n"), snapName, sug.Plug, sug.Interface, sug.Rule)
+			}
+		}
+	}
+	return nil
+}
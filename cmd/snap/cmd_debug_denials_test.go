@@ -0,0 +1,74 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) mockDenialsServer(c *C, result string) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/debug")
+		c.Check(r.URL.Query().Get("aspect"), Equals, "denials")
+		c.Check(r.URL.Query().Get("snap"), Equals, "foo")
+		fmt.Fprintf(w, `{"type": "sync", "result": %s}`, result)
+	})
+}
+
+func (s *SnapSuite) TestDebugDenials(c *C) {
+	s.mockDenialsServer(c, `[
+{"backend": "apparmor", "security-tag": "snap.foo.app", "access": "open /dev/video0 (r)", "count": 3,
+ "first-seen": "2024-01-01T10:00:00Z", "last-seen": "2024-01-01T11:00:00Z",
+ "suggestions": [{"interface": "camera", "plug": "camera", "rule": "/dev/video[0-9]* rw,"},
+                 {"interface": "hardware-observe", "plug": "hw", "connected": true, "rule": "/dev/video* r,"},
+                 {"interface": "raw-usb", "rule": "/dev/** rw,"}]},
+{"backend": "seccomp", "access": "syscall 12345", "count": 1,
+ "first-seen": "2024-01-01T12:00:00Z", "last-seen": "2024-01-01T12:00:00Z"}
+]`)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "denials", "--abs-time", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `apparmor: snap.foo.app: open /dev/video0 (r)
+  denied 3 time(s), last 2024-01-01T11:00:00Z
+  connect plug foo:camera of interface "camera" (/dev/video[0-9]* rw,)
+  plug foo:hw of interface "hardware-observe" is already connected (/dev/video* r,)
+  add a plug for interface "raw-usb" (/dev/** rw,)
+
+seccomp: foo: syscall 12345
+  denied 1 time(s), last 2024-01-01T12:00:00Z
+  no interface is known to permit this access
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugDenialsNone(c *C) {
+	s.mockDenialsServer(c, `[]`)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "denials", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "No denials found for snap \"foo\".\n")
+}
@@ -0,0 +1,143 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortDebugSandboxHelp = i18n.G("Inspect the sandbox of snaps")
+var longDebugSandboxHelp = i18n.G(`
+The sandbox command groups commands which inspect the security profiles
+generated by snapd for a snap.
+`)
+
+type cmdDebugSandbox struct{}
+
+func init() {
+	cmd := addDebugCommand("sandbox", shortDebugSandboxHelp, longDebugSandboxHelp,
+		func() flags.Commander { return &cmdDebugSandbox{} },
+		nil, nil)
+	cmd.extra = func(c *flags.Command) {
+		c.AddCommand("explain", i18n.G("Explain the origin of sandbox rules"), i18n.G(`
+The explain command shows the rules that interfaces add to the security
+profiles of the given snap, along with the interface, plug or slot which
+caused each of them. Only the rules of the AppArmor, seccomp, D-Bus, mount and
+udev backends are shown, and rules coming from their base templates are not.
+When an application or hook is given, only the rules applying to it and to the
+whole snap are shown.
+`), &cmdDebugSandboxExplain{})
+	}
+}
+
+func (x *cmdDebugSandbox) Execute(args []string) error {
+	return flag.ErrHelp
+}
+
+type cmdDebugSandboxExplain struct {
+	clientMixin
+
+	Rule        string `long:"rule" value-name:"<pattern>" description:"Only show rules matching the given regular expression"`
+	Positionals struct {
+		SnapApp string `required:"yes" positional-arg-name:"<snap>[.<app>]" description:"Snap, application or hook to explain"`
+	} `positional-args:"true"`
+}
+
+// explainedRule mirrors interfaces.ExplainedRule as returned by the API.
+type explainedRule struct {
+	Backend     string `json:"backend"`
+	SecurityTag string `json:"security-tag"`
+	Rule        string `json:"rule"`
+	Origin      struct {
+		Interface string `json:"interface"`
+		Plug      *struct {
+			Snap string `json:"snap"`
+			Name string `json:"plug"`
+		} `json:"plug"`
+		Slot *struct {
+			Snap string `json:"snap"`
+			Name string `json:"slot"`
+		} `json:"slot"`
+		Source string `json:"source"`
+	} `json:"origin"`
+}
+
+func (x *cmdDebugSandboxExplain) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	x.setClient(mkClient())
+
+	var rulePattern *regexp.Regexp
+	if x.Rule != "" {
+		var err error
+		rulePattern, err = regexp.Compile(x.Rule)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot use rule pattern %q: %v"), x.Rule, err)
+		}
+	}
+
+	// both applications and hooks can be given, the latter as
+	// <snap>.hook.<hook>, which maps directly to their security tag
+	snapName, appName, _ := strings.Cut(x.Positionals.SnapApp, ".")
+	var securityTag string
+	if appName != "" {
+		securityTag = fmt.Sprintf("snap.%s.%s", snapName, appName)
+	}
+
+	var rules []explainedRule
+	if err := x.client.DebugGet("sandbox-explain", &rules, map[string]string{"snap": snapName}); err != nil {
+		return err
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Backend\tTag\tInterface\tSource\tPlug\tSlot\tRule"))
+	for _, rule := range rules {
+		if securityTag != "" && rule.SecurityTag != "" && rule.SecurityTag != securityTag {
+			continue
+		}
+		if rulePattern != nil && !rulePattern.MatchString(rule.Rule) {
+			continue
+		}
+		tag := rule.SecurityTag
+		if tag == "" {
+			tag = "-"
+		}
+		plug, slot := "-", "-"
+		if rule.Origin.Plug != nil {
+			plug = rule.Origin.Plug.Snap + ":" + rule.Origin.Plug.Name
+		}
+		if rule.Origin.Slot != nil {
+			slot = rule.Origin.Slot.Snap + ":" + rule.Origin.Slot.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rule.Backend, tag,
+			rule.Origin.Interface, rule.Origin.Source, plug, slot, rule.Rule)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const sandboxExplainJSON = `{"type": "sync", "result": [
+{"backend": "apparmor", "security-tag": "snap.foo.app", "rule": "network inet,",
+ "origin": {"interface": "network", "plug": {"snap": "foo", "plug": "network"}, "slot": {"snap": "snapd", "slot": "network"}, "source": "connected-plug"}},
+{"backend": "seccomp", "security-tag": "snap.foo.hook.install", "rule": "bind",
+ "origin": {"interface": "network-bind", "plug": {"snap": "foo", "plug": "network-bind"}, "slot": {"snap": "snapd", "slot": "network-bind"}, "source": "connected-plug"}},
+{"backend": "mount", "rule": "/src /dst none bind 0 0",
+ "origin": {"interface": "content", "plug": {"snap": "foo", "plug": "data"}, "source": "permanent-plug"}}
+]}`
+
+func (s *SnapSuite) mockSandboxExplainServer(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/debug")
+		c.Check(r.URL.Query().Get("aspect"), Equals, "sandbox-explain")
+		c.Check(r.URL.Query().Get("snap"), Equals, "foo")
+		fmt.Fprintln(w, sandboxExplainJSON)
+	})
+}
+
+func (s *SnapSuite) TestDebugSandboxExplain(c *C) {
+	s.mockSandboxExplainServer(c)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "sandbox", "explain", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Backend   Tag                    Interface     Source          Plug              Slot                Rule\n"+
+		"apparmor  snap.foo.app           network       connected-plug  foo:network       snapd:network       network inet,\n"+
+		"seccomp   snap.foo.hook.install  network-bind  connected-plug  foo:network-bind  snapd:network-bind  bind\n"+
+		"mount     -                      content       permanent-plug  foo:data          -                   /src /dst none bind 0 0\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugSandboxExplainApp(c *C) {
+	s.mockSandboxExplainServer(c)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "sandbox", "explain", "foo.hook.install"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Backend  Tag                    Interface     Source          Plug              Slot                Rule\n"+
+		"seccomp  snap.foo.hook.install  network-bind  connected-plug  foo:network-bind  snapd:network-bind  bind\n"+
+		"mount    -                      content       permanent-plug  foo:data          -                   /src /dst none bind 0 0\n")
+}
+
+func (s *SnapSuite) TestDebugSandboxExplainRule(c *C) {
+	s.mockSandboxExplainServer(c)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "sandbox", "explain", "--rule=^network", "foo"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Backend   Tag           Interface  Source          Plug         Slot           Rule\n"+
+		"apparmor  snap.foo.app  network    connected-plug  foo:network  snapd:network  network inet,\n")
+}
+
+func (s *SnapSuite) TestDebugSandboxExplainBadRule(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "sandbox", "explain", "--rule=(", "foo"})
+	c.Assert(err, ErrorMatches, `cannot use rule pattern "\(": .*`)
+}
@@ -239,9 +239,10 @@ var helpCategories = []helpCategory{
 		Commands:        []string{"saved", "save", "check-snapshot", "restore", "forget"},
 		AllOnlyCommands: []string{"export-snapshot", "import-snapshot"},
 	}, {
-		Label:       i18n.G("Device"),
-		Description: i18n.G("manage device"),
-		Commands:    []string{"model", "remodel", "reboot", "recovery"},
+		Label:           i18n.G("Device"),
+		Description:     i18n.G("manage device"),
+		Commands:        []string{"model", "remodel", "reboot", "recovery"},
+		AllOnlyCommands: []string{"change-passphrase"},
 	}, {
 		Label:       i18n.G("Warnings"),
 		Other:       true,
@@ -476,6 +476,42 @@ func (iw *infoWriter) maybePrintServices() {
 	}
 }
 
+func (iw *infoWriter) maybePrintResources() {
+	var resources []string
+	for _, app := range iw.theSnap.Apps {
+		res := app.Resources
+		if !app.IsService() || res == nil {
+			continue
+		}
+
+		var hints []string
+		if res.ExpectedMemory != 0 {
+			hints = append(hints, fmt.Sprintf(i18n.G("expected memory %s"), strutil.SizeToStr(int64(res.ExpectedMemory))))
+		}
+		if res.MaxMemory != 0 {
+			hints = append(hints, fmt.Sprintf(i18n.G("max memory %s"), strutil.SizeToStr(int64(res.MaxMemory))))
+		}
+		if res.CPUShare != 0 {
+			hints = append(hints, fmt.Sprintf(i18n.G("cpu %d%%"), res.CPUShare))
+		}
+		if res.Threads != 0 {
+			hints = append(hints, fmt.Sprintf(i18n.G("threads %d"), res.Threads))
+		}
+		if len(hints) == 0 {
+			continue
+		}
+		resources = append(resources, fmt.Sprintf("  %s:\t%s", snap.JoinSnapApp(iw.theSnap.Name, app.Name), strings.Join(hints, ", ")))
+	}
+	if len(resources) == 0 {
+		return
+	}
+
+	fmt.Fprintf(iw, "resources:\n")
+	for _, res := range resources {
+		fmt.Fprintln(iw, res)
+	}
+}
+
 func (iw *infoWriter) maybePrintNotes() {
 	if !iw.verbose {
 		return
@@ -682,6 +718,7 @@ func (x *infoCmd) Execute([]string) error {
 		iw.printDescr()
 		iw.maybePrintCommands()
 		iw.maybePrintServices()
+		iw.maybePrintResources()
 		iw.maybePrintNotes()
 		// stops the notes etc trying to be aligned with channels
 		iw.Flush()
@@ -97,6 +97,42 @@ func (s *infoSuite) TestMaybePrintServicesNoServices(c *check.C) {
 	}
 }
 
+func (s *infoSuite) TestMaybePrintResources(c *check.C) {
+	var buf flushBuffer
+	iw := snap.NewInfoWriter(&buf)
+	infos := []client.AppInfo{
+		{Name: "app1"},
+		{
+			Name:   "svc1",
+			Daemon: "simple",
+			Resources: &client.AppResources{
+				ExpectedMemory: 100 * 1000 * 1000,
+				MaxMemory:      1000 * 1000 * 1000,
+				CPUShare:       50,
+				Threads:        32,
+			},
+		},
+		{
+			Name:      "svc2",
+			Daemon:    "simple",
+			Resources: &client.AppResources{Threads: 4},
+		},
+		{Name: "svc3", Daemon: "simple"},
+	}
+	snap.SetupDiskSnap(iw, "", &client.Snap{Name: "foo", Apps: infos})
+	snap.MaybePrintResources(iw)
+
+	c.Check(buf.String(), check.Equals, `resources:
+  foo.svc1:	expected memory 100MB, max memory 1GB, cpu 50%, threads 32
+  foo.svc2:	threads 4
+`)
+
+	buf.Reset()
+	snap.SetupDiskSnap(iw, "", &client.Snap{Name: "foo", Apps: mixedAppInfos})
+	snap.MaybePrintResources(iw)
+	c.Check(buf.String(), check.Equals, "")
+}
+
 func (s *infoSuite) TestMaybePrintCommands(c *check.C) {
 	var buf flushBuffer
 	iw := snap.NewInfoWriter(&buf)
@@ -0,0 +1,89 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdChangePassphrase struct {
+	clientMixin
+}
+
+var shortChangePassphraseHelp = i18n.G("Change the passphrase of encrypted volumes")
+var longChangePassphraseHelp = i18n.G(`
+The change-passphrase command changes the passphrase used to unlock the encrypted volumes of the system during boot.
+
+This is only possible on systems that were installed with passphrase authentication for the encrypted volumes.
+`)
+
+func init() {
+	addCommand("change-passphrase", shortChangePassphraseHelp, longChangePassphraseHelp, func() flags.Commander {
+		return &cmdChangePassphrase{}
+	}, nil, nil)
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(Stdout, prompt)
+	passphrase, err := ReadPassword(0)
+	fmt.Fprint(Stdout, "\n")
+	if err != nil {
+		return "", err
+	}
+	// we get \r from the pty in the tests
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
+
+func (x *cmdChangePassphrase) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	oldPassphrase, err := readPassphrase(i18n.G("Current passphrase: "))
+	if err != nil {
+		return err
+	}
+	newPassphrase, err := readPassphrase(i18n.G("New passphrase: "))
+	if err != nil {
+		return err
+	}
+	if newPassphrase == "" {
+		return errors.New(i18n.G("new passphrase cannot be empty"))
+	}
+	confirmPassphrase, err := readPassphrase(i18n.G("Confirm new passphrase: "))
+	if err != nil {
+		return err
+	}
+	if confirmPassphrase != newPassphrase {
+		return errors.New(i18n.G("passphrases do not match"))
+	}
+
+	if err := x.client.ChangeEncryptionPassphrase(oldPassphrase, newPassphrase); err != nil {
+		return err
+	}
+	fmt.Fprintln(Stdout, i18n.G("Passphrase changed."))
+	return nil
+}
@@ -0,0 +1,110 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) mockReadPassphrases(passphrases ...string) {
+	n := 0
+	snap.ReadPassword = func(fd int) ([]byte, error) {
+		if n >= len(passphrases) {
+			return nil, fmt.Errorf("unexpected read")
+		}
+		n++
+		return []byte(passphrases[n-1]), nil
+	}
+}
+
+func (s *SnapSuite) TestChangePassphraseHelp(c *C) {
+	msg := `Usage:
+  snap.test change-passphrase
+
+The change-passphrase command changes the passphrase used to unlock the
+encrypted volumes of the system during boot.
+
+This is only possible on systems that were installed with passphrase
+authentication for the encrypted volumes.
+`
+	s.testSubCommandHelp(c, "change-passphrase", msg)
+}
+
+func (s *SnapSuite) TestChangePassphrase(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/system-volumes")
+		var body map[string]interface{}
+		c.Check(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		c.Check(body, DeepEquals, map[string]interface{}{
+			"action":         "change-passphrase",
+			"old-passphrase": "old secret",
+			"new-passphrase": "new secret",
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+	})
+	s.mockReadPassphrases("old secret\r\n", "new secret", "new secret\n")
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"change-passphrase"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(n, Equals, 1)
+	c.Check(s.Stdout(), Equals, "Current passphrase: \nNew passphrase: \nConfirm new passphrase: \nPassphrase changed.\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestChangePassphraseMismatch(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	s.mockReadPassphrases("old", "new", "other")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"change-passphrase"})
+	c.Assert(err, ErrorMatches, "passphrases do not match")
+}
+
+func (s *SnapSuite) TestChangePassphraseEmpty(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	s.mockReadPassphrases("old", "")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"change-passphrase"})
+	c.Assert(err, ErrorMatches, "new passphrase cannot be empty")
+}
+
+func (s *SnapSuite) TestChangePassphraseError(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, `{"type": "error", "status-code": 500, "result": {"message": "system does not use passphrase authentication"}}`)
+	})
+	s.mockReadPassphrases("old", "new", "new")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"change-passphrase"})
+	c.Assert(err, ErrorMatches, "cannot change encryption passphrase: system does not use passphrase authentication")
+}
@@ -38,6 +38,8 @@ type cmdPrepareImage struct {
 	Classic        bool   `long:"classic"`
 	Preseed        bool   `long:"preseed"`
 	PreseedSignKey string `long:"preseed-sign-key"`
+	// reproducible preseeding, see image.Options
+	PreseedReproducible bool `long:"preseed-reproducible"`
 	// optional path to AppArmor kernel features directory
 	AppArmorKernelFeaturesDir string `long:"apparmor-features-dir"`
 	// optional sysfs overlay
@@ -59,6 +61,8 @@ type cmdPrepareImage struct {
 	ExtraSnaps         []string `long:"extra-snaps" hidden:"yes"` // DEPRECATED
 	RevisionsFile      string   `long:"revisions"`
 	WriteRevisionsFile string   `long:"write-revisions" optional:"true" optional-value:"./seed.manifest"`
+
+	ImageFormats []string `long:"image-format" value-name:"<raw|qcow2|sparse>"`
 }
 
 func init() {
@@ -71,16 +75,23 @@ creating device images.
 For core images it is not invoked directly but usually via
 ubuntu-image.
 
-For preparing classic images it supports a --classic mode`),
+For preparing classic images it supports a --classic mode.
+
+For UC20+ images the --image-format option additionally lays out each of
+the gadget volumes into a disk image in the given formats. Building the
+disk images does not require root, preseeding the image with --preseed
+does.`),
 		func() flags.Commander { return &cmdPrepareImage{} },
 		map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"classic": i18n.G("Enable classic mode to prepare a classic model image"),
 			// TRANSLATORS: This should not start with a lowercase letter.
-			"preseed": i18n.G("Preseed (UC20+ only)"),
+			"preseed": i18n.G("Preseed (UC20+ only, requires root)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"preseed-sign-key": i18n.G("Name of the key to use to sign preseed assertion, otherwise use the default key"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"preseed-reproducible": i18n.G("Preseed using SOURCE_DATE_EPOCH for timestamps and write a preseed.manifest (UC20+ only)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"sysfs-overlay": i18n.G("Optional sysfs overlay to be used when running preseeding steps"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"apparmor-features-dir": i18n.G("Optional path to apparmor kernel features directory (UC20+ only)"),
@@ -100,6 +111,8 @@ For preparing classic images it supports a --classic mode`),
 			"channel": i18n.G("The channel to use"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"customize": i18n.G("Image customizations specified as JSON file."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"image-format": i18n.G("Also build a disk image in the given format for each gadget volume (UC20+ only)"),
 		}, []argDesc{
 			{
 				// TRANSLATORS: This needs to begin with < and end with >
@@ -182,10 +195,16 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 		return fmt.Errorf("--sysfs-overlay cannot be used without --preseed")
 	}
 
+	if x.PreseedReproducible && !x.Preseed {
+		return fmt.Errorf("--preseed-reproducible cannot be used without --preseed")
+	}
+
 	opts.Preseed = x.Preseed
 	opts.PreseedSignKey = x.PreseedSignKey
+	opts.PreseedReproducible = x.PreseedReproducible
 	opts.AppArmorKernelFeaturesDir = x.AppArmorKernelFeaturesDir
 	opts.SysfsOverlay = x.SysfsOverlay
+	opts.DiskImageFormats = x.ImageFormats
 
 	return imagePrepare(opts)
 }
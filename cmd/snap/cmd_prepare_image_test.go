@@ -208,6 +208,9 @@ func (s *SnapPrepareImageSuite) TestReadSeedManifest(c *C) {
 func (s *SnapPrepareImageSuite) TestPrepareImagePreseedArgError(c *C) {
 	_, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--preseed-sign-key", "key", "model", "prepare-dir"})
 	c.Assert(err, ErrorMatches, `--preseed-sign-key cannot be used without --preseed`)
+
+	_, err = cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--preseed-reproducible", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `--preseed-reproducible cannot be used without --preseed`)
 }
 
 func (s *SnapPrepareImageSuite) TestPrepareImagePreseed(c *C) {
@@ -233,6 +236,47 @@ func (s *SnapPrepareImageSuite) TestPrepareImagePreseed(c *C) {
 	})
 }
 
+func (s *SnapPrepareImageSuite) TestPrepareImagePreseedReproducible(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := cmdsnap.MockImagePrepare(prep)
+	defer r()
+
+	rest, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--preseed", "--preseed-reproducible", "model", "prepare-dir"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:           "model",
+		PrepareDir:          "prepare-dir",
+		Preseed:             true,
+		PreseedReproducible: true,
+	})
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageDiskImageFormats(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := cmdsnap.MockImagePrepare(prep)
+	defer r()
+
+	rest, err := cmdsnap.Parser(cmdsnap.Client()).ParseArgs([]string{"prepare-image", "--image-format", "raw", "--image-format", "qcow2", "model", "prepare-dir"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:        "model",
+		PrepareDir:       "prepare-dir",
+		DiskImageFormats: []string{"raw", "qcow2"},
+	})
+}
+
 func (s *SnapPrepareImageSuite) TestPrepareImageWriteRevisions(c *C) {
 	var opts *image.Options
 	prep := func(o *image.Options) error {
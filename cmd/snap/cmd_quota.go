@@ -110,6 +110,10 @@ Adding new snaps to a quota group will result in all non-disabled services in
 that snap being restarted.
 
 An existing sub group cannot be moved from one parent to another.
+
+With --from-snap-hints, the memory, CPU and threads limits which are not given
+are computed from the resources the services of the snaps in the quota group
+declare they expect to use.
 `)
 
 func init() {
@@ -123,6 +127,7 @@ func init() {
 			"journal-size":       i18n.G("Journal size quota"),
 			"journal-rate-limit": i18n.G("Journal rate limit as <message count>/<message period>"),
 			"parent":             i18n.G("Parent quota group"),
+			"from-snap-hints":    i18n.G("Compute the limits which are not given from the resource hints of the snaps"),
 		}), nil)
 	addCommand("quota", shortQuotaHelp, longQuotaHelp, func() flags.Commander { return &cmdQuota{} }, nil, nil)
 	addCommand("quotas", shortQuotasHelp, longQuotasHelp, func() flags.Commander { return &cmdQuotas{} }, nil, nil)
@@ -139,6 +144,7 @@ type cmdSetQuota struct {
 	JournalSizeMax   string `long:"journal-size" optional:"true"`
 	JournalRateLimit string `long:"journal-rate-limit" optional:"true"`
 	Parent           string `long:"parent" optional:"true"`
+	FromSnapHints    bool   `long:"from-snap-hints"`
 	Positional       struct {
 		GroupName string        `positional-arg-name:"<group-name>" required:"true"`
 		Snaps     []serviceName `positional-arg-name:"<snap-or-service>" optional:"true"`
@@ -287,7 +293,7 @@ func (x *cmdSetQuota) splitSnapsAndServices() (snaps []string, services []string
 }
 
 func (x *cmdSetQuota) Execute(args []string) (err error) {
-	quotaProvided := x.hasQuotaSet()
+	quotaProvided := x.hasQuotaSet() || x.FromSnapHints
 	snaps, services := x.splitSnapsAndServices()
 
 	// figure out if the group exists or not to make error messages more useful
@@ -342,6 +348,8 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 			Snaps:       snaps,
 			Services:    services,
 			Constraints: quotaValues,
+
+			FromSnapHints: x.FromSnapHints,
 		})
 		if err != nil {
 			return err
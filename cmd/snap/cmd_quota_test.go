@@ -109,6 +109,7 @@ type fakeQuotaGroupPostHandlerOpts struct {
 	cpuCount      int
 	cpuPercentage int
 	cpuSet        []int
+	fromSnapHints bool
 }
 
 type quotasEnsureBodyConstraintsCPU struct {
@@ -134,6 +135,8 @@ type quotasEnsureBody struct {
 	Snaps       []string                    `json:"snaps,omitempty"`
 	Services    []string                    `json:"services,omitempty"`
 	Constraints quotasEnsureBodyConstraints `json:"constraints,omitempty"`
+
+	FromSnapHints bool `json:"from-snap-hints,omitempty"`
 }
 
 func (s *quotaSuite) makeFakeQuotaPostHandler(c *check.C, opts fakeQuotaGroupPostHandlerOpts) func(w http.ResponseWriter, r *http.Request) {
@@ -156,6 +159,8 @@ func (s *quotaSuite) makeFakeQuotaPostHandler(c *check.C, opts fakeQuotaGroupPos
 				Snaps:       opts.snaps,
 				Services:    opts.services,
 				Constraints: quotasEnsureBodyConstraints{},
+
+				FromSnapHints: opts.fromSnapHints,
 			}
 			if opts.maxMemory != 0 {
 				exp.Constraints.Memory = opts.maxMemory
@@ -550,6 +555,40 @@ func (s *quotaSuite) TestSetQuotaGroupCreateNew(c *check.C) {
 	c.Check(s.quotaPostHandlerCalls, check.Equals, 1)
 }
 
+func (s *quotaSuite) TestSetQuotaGroupCreateFromSnapHints(c *check.C) {
+	const postJSON = `{"type": "async", "status-code": 202,"change":"42", "result": []}`
+	fakeHandlerOpts := fakeQuotaGroupPostHandlerOpts{
+		action:        "ensure",
+		body:          postJSON,
+		groupName:     "foo",
+		snaps:         []string{"snap-a"},
+		maxThreads:    64,
+		fromSnapHints: true,
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"/v2/quotas": s.makeFakeQuotaPostHandler(
+			c,
+			fakeHandlerOpts,
+		),
+		// the foo quota group is not found since it doesn't exist yet
+		"/v2/quotas/foo": s.makeFakeGetQuotaGroupNotFoundHandler(c, "foo"),
+
+		"/v2/changes/42": makeChangesHandler(c),
+	}
+
+	s.RedirectClientToTestServer(dispatchFakeHandlers(c, routes))
+
+	// the limits which are given are passed along with the hints
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"set-quota", "foo", "--from-snap-hints", "--threads=64", "snap-a"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.quotaGetGroupHandlerCalls, check.Equals, 1)
+	c.Check(s.quotaPostHandlerCalls, check.Equals, 1)
+}
+
 func (s *quotaSuite) TestSetQuotaGroupUpdateExistingUnhappy(c *check.C) {
 	const exists = true
 	s.testSetQuotaGroupUpdateExistingUnhappy(c, "no options set to change quota group", exists)
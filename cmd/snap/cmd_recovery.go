@@ -20,8 +20,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/jessevdk/go-flags"
@@ -33,8 +35,14 @@ import (
 type cmdRecovery struct {
 	clientMixin
 	colorMixin
+	timeMixin
 
 	ShowKeys bool `long:"show-keys"`
+
+	Keys      bool   `long:"keys"`
+	AddKey    string `long:"add" value-name:"<key-name>"`
+	RotateKey string `long:"rotate" value-name:"<key-name>"`
+	RemoveKey string `long:"remove" value-name:"<key-name>"`
 }
 
 var shortRecoveryHelp = i18n.G("List available recovery systems")
@@ -42,16 +50,26 @@ var longRecoveryHelp = i18n.G(`
 The recovery command lists the available recovery systems.
 
 With --show-keys it displays recovery keys that can be used to unlock the encrypted partitions if the device-specific automatic unlocking does not work.
+
+With --keys it lists the key slots of the encrypted partitions. Combined with --add, --rotate or --remove it adds a new named recovery key, replaces a named recovery key with a new one or removes a named recovery key. Added and rotated keys are displayed once and are not stored on the device.
 `)
 
 func init() {
 	addCommand("recovery", shortRecoveryHelp, longRecoveryHelp, func() flags.Commander {
 		// XXX: if we want more/nicer details we can add `snap recovery <system>` later
 		return &cmdRecovery{}
-	}, colorDescs.also(
+	}, colorDescs.also(timeDescs).also(
 		map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"show-keys": i18n.G("Show recovery keys (if available) to unlock encrypted partitions."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"keys": i18n.G("List the key slots of the encrypted partitions."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"add": i18n.G("With --keys, add a named recovery key."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"rotate": i18n.G("With --keys, replace a named recovery key with a new one."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"remove": i18n.G("With --keys, remove a named recovery key."),
 		}), nil)
 }
 
@@ -86,10 +104,77 @@ func (x *cmdRecovery) showKeys(w io.Writer) error {
 	return nil
 }
 
+func (x *cmdRecovery) manageKeys(w io.Writer) error {
+	var actions []string
+	for _, action := range []string{x.AddKey, x.RotateKey, x.RemoveKey} {
+		if action != "" {
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) > 1 {
+		return errors.New(i18n.G("cannot use more than one of --add, --rotate and --remove"))
+	}
+
+	switch {
+	case x.AddKey != "":
+		rkey, err := x.client.AddRecoveryKey(x.AddKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", x.AddKey, rkey)
+		return nil
+	case x.RotateKey != "":
+		rkey, err := x.client.RotateRecoveryKey(x.RotateKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", x.RotateKey, rkey)
+		return nil
+	case x.RemoveKey != "":
+		if err := x.client.RemoveRecoveryKey(x.RemoveKey); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, i18n.G("Recovery key %q removed.\n"), x.RemoveKey)
+		return nil
+	}
+
+	slots, err := x.client.SystemRecoveryKeySlots()
+	if err != nil {
+		return err
+	}
+	volumes := make([]string, 0, len(slots.Volumes))
+	for volume := range slots.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+
+	fmt.Fprintln(w, i18n.G("Volume\tSlot\tType\tName\tCreated"))
+	for _, volume := range volumes {
+		for _, slot := range slots.Volumes[volume] {
+			name := slot.Name
+			if name == "" {
+				name = "-"
+			}
+			created := "-"
+			if slot.Created != nil {
+				created = x.fmtTime(*slot.Created)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", volume, slot.ID, slot.Type, name, created)
+		}
+	}
+	return nil
+}
+
 func (x *cmdRecovery) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	if !x.Keys && (x.AddKey != "" || x.RotateKey != "" || x.RemoveKey != "") {
+		return errors.New(i18n.G("--add, --rotate and --remove can only be used with --keys"))
+	}
+	if x.Keys && x.ShowKeys {
+		return errors.New(i18n.G("cannot use --keys and --show-keys together"))
+	}
 
 	esc := x.getEscapes()
 	w := tabWriter()
@@ -98,6 +183,9 @@ func (x *cmdRecovery) Execute(args []string) error {
 	if x.ShowKeys {
 		return x.showKeys(w)
 	}
+	if x.Keys {
+		return x.manageKeys(w)
+	}
 
 	systems, err := x.client.ListSystems()
 	if err != nil {
@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -38,13 +39,28 @@ The recovery command lists the available recovery systems.
 With --show-keys it displays recovery keys that can be used to unlock the
 encrypted partitions if the device-specific automatic unlocking does not work.
 
+With --keys it lists the key slots of the encrypted partitions. Combined with
+--add, --rotate or --remove it adds a new named recovery key, replaces a named
+recovery key with a new one or removes a named recovery key. Added and rotated
+keys are displayed once and are not stored on the device.
+
 [recovery command options]
-      --color=[auto|never|always]     Use a little bit of color to highlight
-                                      some things. (default: auto)
-      --unicode=[auto|never|always]   Use a little bit of Unicode to improve
-                                      legibility. (default: auto)
-      --show-keys                     Show recovery keys (if available) to
-                                      unlock encrypted partitions.
+      --color=[auto|never|always]        Use a little bit of color to highlight
+                                         some things. (default: auto)
+      --unicode=[auto|never|always]      Use a little bit of Unicode to improve
+                                         legibility. (default: auto)
+      --abs-time                         Display absolute times (in RFC 3339
+                                         format). Otherwise, display relative
+                                         times up to 60 days, then YYYY-MM-DD.
+      --show-keys                        Show recovery keys (if available) to
+                                         unlock encrypted partitions.
+      --keys                             List the key slots of the encrypted
+                                         partitions.
+      --add=<key-name>                   With --keys, add a named recovery key.
+      --rotate=<key-name>                With --keys, replace a named recovery
+                                         key with a new one.
+      --remove=<key-name>                With --keys, remove a named recovery
+                                         key.
 `
 	s.testSubCommandHelp(c, "recovery", msg)
 }
@@ -209,3 +225,104 @@ func (s *SnapSuite) TestRecoveryShowRecoveryKeyAloneHappy(c *C) {
 	c.Check(s.Stderr(), Equals, "")
 	c.Check(n, Equals, 1)
 }
+
+func (s *SnapSuite) TestRecoveryKeysList(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/system-recovery-keys")
+			c.Check(r.URL.RawQuery, Equals, "select=key-slots")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"volumes": {
+  "ubuntu-save": [{"id": 0, "type": "encryption-key"}, {"id": 4, "type": "named-recovery-key", "name": "backup", "created": "2026-01-02T03:04:05Z"}],
+  "ubuntu-data": [{"id": 0, "type": "encryption-key"}, {"id": 1, "type": "recovery-key"}, {"id": 4, "type": "named-recovery-key", "name": "backup", "created": "2026-01-02T03:04:05Z"}]
+}}}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery", "--keys", "--abs-time"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `
+Volume       Slot  Type                Name    Created
+ubuntu-data  0     encryption-key      -       -
+ubuntu-data  1     recovery-key        -       -
+ubuntu-data  4     named-recovery-key  backup  2026-01-02T03:04:05Z
+ubuntu-save  0     encryption-key      -       -
+ubuntu-save  4     named-recovery-key  backup  2026-01-02T03:04:05Z
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(n, Equals, 1)
+}
+
+func (s *SnapSuite) testRecoveryKeysAction(c *C, action, output string) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/system-recovery-keys")
+			var req map[string]interface{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&req), IsNil)
+			c.Check(req, DeepEquals, map[string]interface{}{
+				"action": action,
+				"name":   "backup",
+			})
+			if action == "remove" {
+				fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+			} else {
+				fmt.Fprintln(w, `{"type": "sync", "result": {"name": "backup", "recovery-key": "61665-00531-54469-09783-47273-19035-40077-28287"}}`)
+			}
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery", "--keys", "--" + action, "backup"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, output)
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(n, Equals, 1)
+}
+
+func (s *SnapSuite) TestRecoveryKeysAdd(c *C) {
+	s.testRecoveryKeysAction(c, "add", "backup:  61665-00531-54469-09783-47273-19035-40077-28287\n")
+}
+
+func (s *SnapSuite) TestRecoveryKeysRotate(c *C) {
+	s.testRecoveryKeysAction(c, "rotate", "backup:  61665-00531-54469-09783-47273-19035-40077-28287\n")
+}
+
+func (s *SnapSuite) TestRecoveryKeysRemove(c *C) {
+	s.testRecoveryKeysAction(c, "remove", "Recovery key \"backup\" removed.\n")
+}
+
+func (s *SnapSuite) TestRecoveryKeysErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"recovery", "--add", "foo"}, "--add, --rotate and --remove can only be used with --keys"},
+		{[]string{"recovery", "--keys", "--show-keys"}, "cannot use --keys and --show-keys together"},
+		{[]string{"recovery", "--keys", "--add", "foo", "--remove", "bar"}, "cannot use more than one of --add, --rotate and --remove"},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(tc.args)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.args))
+	}
+}
+
+func (s *SnapSuite) TestRecoveryKeysAddError(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "boom"}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery", "--keys", "--add", "backup"})
+	c.Assert(err, ErrorMatches, `cannot add recovery key "backup": boom`)
+}
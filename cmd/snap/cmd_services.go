@@ -20,9 +20,12 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -30,15 +33,21 @@ import (
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/osutil/user"
+	"github.com/snapcore/snapd/strutil"
 )
 
 type svcStatus struct {
-	clientMixin
+	waitMixin
+	timeMixin
 	Positional struct {
 		ServiceNames []serviceName
 	} `positional-args:"yes"`
 	Global bool `long:"global" short:"g"`
-	User   bool `long:"user" short:"u"`
+	// User is "self" when --user is given without a value
+	User     string `long:"user" short:"u" optional:"true" optional-value:"self"`
+	Linger   bool   `long:"linger"`
+	NoLinger bool   `long:"no-linger"`
+	Verbose  bool   `long:"verbose"`
 }
 
 type svcLogs struct {
@@ -46,6 +55,12 @@ type svcLogs struct {
 	timeMixin
 	N          string `short:"n" default:"10"`
 	Follow     bool   `short:"f"`
+	Since      string `long:"since"`
+	Until      string `long:"until"`
+	Priority   string `long:"priority" short:"p"`
+	Grep       string `long:"grep" short:"g"`
+	Boot       string `long:"boot" short:"b"`
+	Output     string `long:"output" short:"o" default:"short" choice:"short" choice:"json"`
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -64,11 +79,26 @@ status of the user services for the root user itself, --user can be provided.
 If executed as a non-root user, the 'Startup'|'Current' status of user services 
 will be the current status for the invoking user. To view the global enablement
 status of user services, --global can be provided.
+
+With --linger, systemd lingering is enabled for the users given with
+--user=<name>[,<name>...], or the current user if no name is given, so that
+their user services run without them being logged in, including on boot.
+--no-linger disables it again and stops their user services.
+
+With --verbose, the number of automatic restarts of the system services since
+they were last started and how they last exited are shown as well.
 `)
 	shortLogsHelp = i18n.G("Retrieve logs for services")
 	longLogsHelp  = i18n.G(`
 The logs command fetches logs of the given services and displays them in
 chronological order.
+
+The --since and --until options take either a timestamp in RFC3339 format or
+a duration relative to the current time, such as 1h30m, in which case the
+logs of the given duration ago are selected.
+
+With --output=json each entry is printed as a JSON object on its own line,
+including all of its journal fields.
 `)
 	shortStartHelp = i18n.G("Start services")
 	longStartHelp  = i18n.G(`
@@ -94,18 +124,36 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		desc: i18n.G("A service specification, which can be just a snap name (for all services in the snap), or <snap>.<app> for a single service."),
 	}}
-	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, map[string]string{
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, waitDescs.also(timeDescs).also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"global": i18n.G("Show the global enable status for user services instead of the status for the current user."),
 		// TRANSLATORS: This should not start with a lowercase letter.
-		"user": i18n.G("Show the current status of the user services instead of the global enable status."),
-	}, argdescs)
+		"user": i18n.G("Show the current status of the user services instead of the global enable status, or select the users to enable or disable lingering for."),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"linger": i18n.G("Enable lingering for the selected users, so that their user services run without them being logged in."),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"no-linger": i18n.G("Disable lingering for the selected users and stop their user services."),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"verbose": i18n.G("Also show the restarts and the last exit of system services."),
+	}), argdescs)
 	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &svcLogs{} },
 		timeDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"n": i18n.G("Show only the given number of lines, or 'all'."),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"f": i18n.G("Wait for new lines and print them as they come in."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"since": i18n.G("Show only the lines newer than the given time or duration."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"until": i18n.G("Show only the lines older than the given time or duration."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"priority": i18n.G("Show only the lines with at least the given priority, by name (e.g. 'err') or number."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"grep": i18n.G("Show only the lines whose message matches the given regular expression."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"boot": i18n.G("Show only the lines of the given boot, by offset (e.g. '--boot=-1') or ID."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"output": i18n.G("Format of the output, either 'short' or 'json'."),
 		}), argdescs)
 
 	addCommand("start", shortStartHelp, longStartHelp, func() flags.Commander { return &svcStart{} },
@@ -134,7 +182,7 @@ func svcNames(s []serviceName) []string {
 }
 
 func (s *svcStatus) showGlobalEnablement(u *user.User) bool {
-	if u.Uid == "0" && !s.User {
+	if u.Uid == "0" && s.User == "" {
 		return true
 	} else if u.Uid != "0" && s.Global {
 		return true
@@ -144,9 +192,46 @@ func (s *svcStatus) showGlobalEnablement(u *user.User) bool {
 
 func (s *svcStatus) validateArguments() error {
 	// can't use --global and --user together
-	if s.Global && s.User {
+	if s.Global && s.User != "" {
 		return errors.New(i18n.G("cannot combine --global and --user switches."))
 	}
+	if s.Linger && s.NoLinger {
+		return errors.New(i18n.G("cannot combine --linger and --no-linger switches."))
+	}
+	if s.Linger || s.NoLinger {
+		if s.Global || len(s.Positional.ServiceNames) > 0 {
+			return errors.New(i18n.G("cannot use --linger or --no-linger with --global or services."))
+		}
+	} else if s.User != "" && s.User != "self" {
+		return errors.New(i18n.G("cannot use --user=<name> without --linger or --no-linger."))
+	}
+	return nil
+}
+
+func (s *svcStatus) lingeringUsers() client.UserSelector {
+	if s.User == "" || s.User == "self" {
+		return client.UserSelector{Selector: client.UserSelectionSelf}
+	}
+	return client.UserSelector{Names: strutil.CommaSeparatedList(s.User)}
+}
+
+func (s *svcStatus) setLingering() error {
+	changeID, err := s.client.Linger(s.lingeringUsers(), s.Linger)
+	if err != nil {
+		return err
+	}
+	if _, err := s.wait(changeID); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+
+	if s.Linger {
+		fmt.Fprintln(Stdout, i18n.G("Lingering enabled."))
+	} else {
+		fmt.Fprintln(Stdout, i18n.G("Lingering disabled."))
+	}
 	return nil
 }
 
@@ -158,6 +243,9 @@ func (s *svcStatus) Execute(args []string) error {
 	if err := s.validateArguments(); err != nil {
 		return err
 	}
+	if s.Linger || s.NoLinger {
+		return s.setLingering()
+	}
 
 	u, err := userCurrent()
 	if err != nil {
@@ -166,8 +254,9 @@ func (s *svcStatus) Execute(args []string) error {
 
 	isGlobal := s.showGlobalEnablement(u)
 	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), client.AppOptions{
-		Service: true,
-		Global:  isGlobal,
+		Service:      true,
+		Global:       isGlobal,
+		RestartStats: s.Verbose,
 	})
 	if err != nil {
 		return err
@@ -181,13 +270,46 @@ func (s *svcStatus) Execute(args []string) error {
 	w := tabWriter()
 	defer w.Flush()
 
-	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+	if !s.Verbose {
+		fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+		for _, svc := range services {
+			fmt.Fprintln(w, clientutil.FmtServiceStatus(svc, isGlobal))
+		}
+		return nil
+	}
+
+	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes\tRestarts\tLast exit"))
 	for _, svc := range services {
-		fmt.Fprintln(w, clientutil.FmtServiceStatus(svc, isGlobal))
+		fmt.Fprintf(w, "%s\t%s\n", clientutil.FmtServiceStatus(svc, isGlobal), s.fmtRestartStats(svc.RestartStats))
 	}
 	return nil
 }
 
+// fmtRestartStats returns the restarts and last exit columns for the given
+// restart statistics, which are only available for system services.
+func (s *svcStatus) fmtRestartStats(stats *client.AppRestartStats) string {
+	if stats == nil {
+		return "-\t-"
+	}
+	lastExit := "-"
+	switch {
+	case stats.ExitSignal != "":
+		// TRANSLATORS: %s is a signal name, e.g. SIGSEGV
+		lastExit = fmt.Sprintf(i18n.G("signal %s"), stats.ExitSignal)
+	case stats.ExitTime != nil:
+		// TRANSLATORS: %d is the exit status of a process
+		lastExit = fmt.Sprintf(i18n.G("status %d"), stats.ExitCode)
+	}
+	if stats.ExitTime != nil {
+		lastExit = fmt.Sprintf("%s (%s)", lastExit, s.fmtTime(*stats.ExitTime))
+	}
+	if stats.Failed {
+		// TRANSLATORS: the %s is the last exit of a service, e.g. "signal SIGSEGV"
+		lastExit = fmt.Sprintf(i18n.G("%s, failed"), lastExit)
+	}
+	return fmt.Sprintf("%d\t%s", stats.Restarts, lastExit)
+}
+
 func (s *svcLogs) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
@@ -202,12 +324,35 @@ func (s *svcLogs) Execute(args []string) error {
 		sN = int(n)
 	}
 
-	logs, err := s.client.Logs(svcNames(s.Positional.ServiceNames), client.LogOptions{N: sN, Follow: s.Follow})
+	opts := client.LogOptions{
+		N:         sN,
+		Follow:    s.Follow,
+		Priority:  s.Priority,
+		Grep:      s.Grep,
+		Boot:      s.Boot,
+		AllFields: s.Output == "json",
+	}
+	var err error
+	if opts.Since, err = parseLogsTime(s.Since); err != nil {
+		return fmt.Errorf(i18n.G("invalid argument for flag ‘--since’: %v"), err)
+	}
+	if opts.Until, err = parseLogsTime(s.Until); err != nil {
+		return fmt.Errorf(i18n.G("invalid argument for flag ‘--until’: %v"), err)
+	}
+
+	logs, err := s.client.Logs(svcNames(s.Positional.ServiceNames), opts)
 	if err != nil {
 		return err
 	}
 
+	enc := json.NewEncoder(Stdout)
 	for log := range logs {
+		if s.Output == "json" {
+			if err := enc.Encode(log); err != nil {
+				return err
+			}
+			continue
+		}
 		if s.AbsTime {
 			fmt.Fprintln(Stdout, log.StringInUTC())
 		} else {
@@ -218,6 +363,21 @@ func (s *svcLogs) Execute(args []string) error {
 	return nil
 }
 
+// parseLogsTime parses either a RFC3339 timestamp or a duration ago.
+func parseLogsTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	dur, err := time.ParseDuration(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf(i18n.G("expected a RFC3339 timestamp or a duration, got %q"), s)
+	}
+	return timeNow().Add(-dur), nil
+}
+
 var userAndScopeDescs = mixinDescs{
 	// TRANSLATORS: This should not start with a lowercase letter.
 	"system": i18n.G("The operation should only affect system services."),
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -484,6 +485,87 @@ func (s *appOpSuite) TestServiceCompletion(c *check.C) {
 	c.Check(n, check.Equals, 7)
 }
 
+func (s *appOpSuite) TestAppStatusVerbose(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/apps")
+			c.Check(r.URL.Query(), check.HasLen, 3)
+			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.URL.Query().Get("global"), check.Equals, "true")
+			c.Check(r.URL.Query().Get("restart-stats"), check.Equals, "true")
+			c.Check(r.Method, check.Equals, "GET")
+			w.WriteHeader(200)
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]interface{}{
+				"type": "sync",
+				"result": []map[string]interface{}{
+					{
+						"snap":          "foo",
+						"name":          "bar",
+						"daemon":        "simple",
+						"daemon-scope":  "system",
+						"active":        true,
+						"enabled":       true,
+						"restart-stats": map[string]interface{}{"restarts": 0},
+					}, {
+						"snap":         "foo",
+						"name":         "baz",
+						"daemon":       "simple",
+						"daemon-scope": "system",
+						"active":       true,
+						"enabled":      true,
+						"restart-stats": map[string]interface{}{
+							"restarts":    3,
+							"exit-signal": "SIGSEGV",
+							"exit-time":   "2026-10-19T10:02:00Z",
+						},
+					}, {
+						"snap":         "foo",
+						"name":         "quux",
+						"daemon":       "simple",
+						"daemon-scope": "system",
+						"active":       false,
+						"enabled":      true,
+						"restart-stats": map[string]interface{}{
+							"failed":    true,
+							"restarts":  5,
+							"exit-code": 1,
+							"exit-time": "2026-10-19T10:05:00Z",
+						},
+					}, {
+						"snap":         "foo",
+						"name":         "qux",
+						"daemon":       "simple",
+						"daemon-scope": "user",
+						"active":       false,
+						"enabled":      true,
+					},
+				},
+				"status":      "OK",
+				"status-code": 200,
+			})
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services", "--global", "--verbose", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Service   Startup  Current   Notes  Restarts  Last exit
+foo.bar   enabled  active    -      0         -
+foo.baz   enabled  active    -      3         signal SIGSEGV (2026-10-19T10:02:00Z)
+foo.quux  enabled  inactive  -      5         status 1 (2026-10-19T10:05:00Z), failed
+foo.qux   enabled  -         user   -         -
+`)
+	// ensure that the fake server api was actually hit
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *appOpSuite) TestAppStatusUserFailed(c *check.C) {
 	r := snap.MockUserCurrent(func() (*user.User, error) {
 		return nil, fmt.Errorf("oh-no")
@@ -504,6 +586,64 @@ func (s *appOpSuite) TestAppStatusInvalidUserGlobalSwitches(c *check.C) {
 	c.Check(err, check.ErrorMatches, `cannot combine --global and --user switches.`)
 }
 
+func (s *appOpSuite) TestAppStatusLinger(c *check.C) {
+	for _, t := range []struct {
+		args    []string
+		action  string
+		users   interface{}
+		summary string
+	}{
+		{[]string{"services", "--user=alice,bob", "--linger"}, "linger", []interface{}{"alice", "bob"}, "Lingering enabled.\n"},
+		{[]string{"services", "--user", "--no-linger"}, "unlinger", "self", "Lingering disabled.\n"},
+		{[]string{"services", "--linger"}, "linger", "self", "Lingering enabled.\n"},
+	} {
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch n {
+			case 0:
+				c.Check(r.URL.Path, check.Equals, "/v2/apps")
+				c.Check(r.Method, check.Equals, "POST")
+				c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+					"action": t.action,
+					"names":  nil,
+					"users":  t.users,
+				})
+				w.WriteHeader(202)
+				fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+			case 1:
+				c.Check(r.Method, check.Equals, "GET")
+				c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+				fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+			default:
+				c.Fatalf("expected to get 2 requests, now on %d", n+1)
+			}
+			n++
+		})
+
+		s.stdout.Reset()
+		rest, err := snap.Parser(snap.Client()).ParseArgs(t.args)
+		c.Assert(err, check.IsNil)
+		c.Check(rest, check.HasLen, 0)
+		c.Check(s.Stdout(), check.Equals, t.summary)
+		c.Check(n, check.Equals, 2)
+	}
+}
+
+func (s *appOpSuite) TestAppStatusLingerInvalidSwitches(c *check.C) {
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"services", "--linger", "--no-linger"}, `cannot combine --linger and --no-linger switches.`},
+		{[]string{"services", "--linger", "foo"}, `cannot use --linger or --no-linger with --global or services.`},
+		{[]string{"services", "--no-linger", "--global"}, `cannot use --linger or --no-linger with --global or services.`},
+		{[]string{"services", "--user=alice"}, `cannot use --user=<name> without --linger or --no-linger.`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(t.args)
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
+
 func (s *appOpSuite) TestAppStatusNoServices(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -580,6 +720,53 @@ func (s *appOpSuite) TestLogsCommand(c *check.C) {
 	c.Check(n, check.Equals, 1)
 }
 
+func (s *appOpSuite) TestLogsCommandFilters(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/logs")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"names":    {"snap"},
+				"n":        {"10"},
+				"since":    {"2026-01-02T10:30:00Z"},
+				"until":    {"2026-01-02T11:00:00Z"},
+				"priority": {"warning"},
+				"grep":     {"fail"},
+				"boot":     {"-1"},
+				"fields":   {"all"},
+			})
+			w.WriteHeader(200)
+			_, err := w.Write([]byte{0x1E})
+			c.Assert(err, check.IsNil)
+			_, err = w.Write([]byte(`{"timestamp":"2026-01-02T10:45:00Z","message":"it failed","sid":"service1","pid":"1000","fields":{"MESSAGE":"it failed","PRIORITY":"4"}}` + "\n"))
+			c.Assert(err, check.IsNil)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"logs", "--since", "1h30m", "--until", "2026-01-02T11:00:00Z",
+		"--priority", "warning", "--grep", "fail", "--boot=-1", "--output", "json", "snap"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+
+	c.Check(s.Stdout(), check.Equals, `{"timestamp":"2026-01-02T10:45:00Z","message":"it failed","sid":"service1","pid":"1000","fields":{"MESSAGE":"it failed","PRIORITY":"4"}}`+"\n")
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *appOpSuite) TestLogsCommandBadTime(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"logs", "--since", "yesterday", "snap"})
+	c.Assert(err, check.ErrorMatches, `invalid argument for flag ‘--since’: expected a RFC3339 timestamp or a duration, got "yesterday"`)
+}
+
 func (s *appOpSuite) TestLogsCommandWithAbsTimeFlag(c *check.C) {
 	n := 0
 	timestamp := "2021-08-16T17:33:55Z"
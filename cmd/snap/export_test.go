@@ -21,11 +21,13 @@ package main
 
 import (
 	"context"
+	"io"
 	"os"
 	"time"
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/cmd/snaplock/runinhibit"
 	"github.com/snapcore/snapd/image"
@@ -137,6 +139,7 @@ var (
 	SetupDiskSnap               = (*infoWriter).setupDiskSnap
 	SetupSnap                   = (*infoWriter).setupSnap
 	MaybePrintServices          = (*infoWriter).maybePrintServices
+	MaybePrintResources         = (*infoWriter).maybePrintResources
 	MaybePrintCommands          = (*infoWriter).maybePrintCommands
 	MaybePrintType              = (*infoWriter).maybePrintType
 	PrintSummary                = (*infoWriter).printSummary
@@ -471,3 +474,15 @@ func MockSeedWriterReadManifest(f func(manifestFile string) (*seedwriter.Manifes
 	seedwriterReadManifest = f
 	return restore
 }
+
+func MockBootDebugDumpBootChains(f func(w io.Writer) error) (restore func()) {
+	restore = testutil.Backup(&bootDebugDumpBootChains)
+	bootDebugDumpBootChains = f
+	return restore
+}
+
+func MockAssertsGenerateKey(f func() (asserts.PrivateKey, error)) (restore func()) {
+	restore = testutil.Backup(&assertsGenerateKey)
+	assertsGenerateKey = f
+	return restore
+}
@@ -62,6 +62,9 @@ var errOngoingSnapRefresh = fmt.Errorf("snap is being refreshed")
 func waitWhileInhibited(ctx context.Context, cli *client.Client, snapName string, appName string) (info *snap.Info, app *snap.AppInfo, hintFlock *osutil.FileLock, err error) {
 	var flow inhibitionFlow
 	notified := false
+	// whether the app is a service handing over its sockets across
+	// refreshes, checked once inhibited for refresh
+	var handoverChecked, handover bool
 	notInhibited := func(ctx context.Context) (err error) {
 		// Get updated "current" snap info.
 		info, app, err = getInfoAndApp(snapName, appName, snap.R(0))
@@ -78,6 +81,22 @@ func waitWhileInhibited(ctx context.Context, cli *client.Client, snapName string
 		if hint == runinhibit.HintInhibitedForRemove {
 			return false, errInhibitedForRemove
 		}
+		if hint == runinhibit.HintInhibitedForRefresh {
+			if !handoverChecked {
+				_, prevApp, err := getInfoAndApp(snapName, appName, inhibitInfo.Previous)
+				if err != nil {
+					return false, err
+				}
+				handover = prevApp.IsService() && prevApp.RefreshStrategy == "rolling"
+				handoverChecked = true
+			}
+			// services with the "rolling" refresh strategy activated
+			// through their sockets during the refresh wait for the new
+			// revision, which the queued connections are handed over to
+			if handover {
+				return false, nil
+			}
+		}
 		if !features.RefreshAppAwareness.IsEnabled() {
 			return true, errOngoingSnapRefresh
 		}
@@ -122,6 +122,68 @@ func (s *RunSuite) TestWaitWhileInhibitedRunThrough(c *C) {
 	checkHintFileLocked(c, "snapname")
 }
 
+func (s *RunSuite) TestWaitWhileInhibitedRollingServiceWaitsForRefresh(c *C) {
+	// mock installed snap with a service handing over its socket
+	const rollingYaml = `name: snapname
+version: 1.0
+apps:
+ svc:
+  command: run-svc
+  daemon: simple
+  refresh-strategy: rolling
+  plugs: [network-bind]
+  sockets:
+    sock:
+      listen-stream: $SNAP_COMMON/sock
+`
+	snaptest.MockSnapCurrent(c, rollingYaml, &snap.SideInfo{Revision: snap.R(11)})
+
+	inhibitInfo := runinhibit.InhibitInfo{Previous: snap.R(11)}
+	c.Assert(runinhibit.LockWithHint("snapname", runinhibit.HintInhibitedForRefresh, inhibitInfo, nil), IsNil)
+
+	var called int
+	restore := snaprun.MockWaitWhileInhibited(func(ctx context.Context, snapName string, notInhibited func(ctx context.Context) error, inhibited func(ctx context.Context, hint runinhibit.Hint, inhibitInfo *runinhibit.InhibitInfo) (cont bool, err error), interval time.Duration) (flock *osutil.FileLock, retErr error) {
+		called++
+
+		for i := 0; i < 3; i++ {
+			cont, err := inhibited(ctx, runinhibit.HintInhibitedForRefresh, &inhibitInfo)
+			c.Assert(err, IsNil)
+			// the service waits for the new revision even without
+			// refresh app awareness, instead of running the old one
+			c.Check(cont, Equals, false)
+		}
+		err := notInhibited(ctx)
+		c.Assert(err, IsNil)
+
+		flock, err = openHintFileLock(snapName)
+		c.Assert(err, IsNil)
+		err = flock.ReadLock()
+		c.Assert(err, IsNil)
+		return flock, nil
+	})
+	defer restore()
+
+	inhibitionFlow := fakeInhibitionFlow{
+		start: func(ctx context.Context) error {
+			return fmt.Errorf("this should never be reached")
+		},
+		finish: func(ctx context.Context) error {
+			return fmt.Errorf("this should never be reached")
+		},
+	}
+	restore = snaprun.MockInhibitionFlow(&inhibitionFlow)
+	defer restore()
+
+	info, app, hintLock, err := snaprun.WaitWhileInhibited(context.TODO(), snaprun.Client(), "snapname", "svc")
+	defer hintLock.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(info.InstanceName(), Equals, "snapname")
+	c.Check(app.Name, Equals, "svc")
+
+	c.Check(called, Equals, 1)
+	checkHintFileLocked(c, "snapname")
+}
+
 func (s *RunSuite) TestWaitWhileInhibitedErrorOnStartNotification(c *C) {
 	// mock installed snap
 	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{Revision: snap.R(11)})
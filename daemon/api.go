@@ -80,6 +80,7 @@ var api = []*Command{
 	validationSetsCmd,
 	routineConsoleConfStartCmd,
 	systemRecoveryKeysCmd,
+	systemVolumesCmd,
 	quotaGroupsCmd,
 	quotaGroupInfoCmd,
 	confdbCmd,
@@ -26,9 +26,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/osutil/user"
@@ -38,6 +40,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/systemd"
 )
 
 var (
@@ -92,6 +95,11 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest(err.Error())
 	}
 
+	restartStats, err := readMaybeBoolValue(query, "restart-stats")
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
 	if rspe != nil {
 		return rspe
@@ -108,6 +116,12 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		return InternalError("%v", err)
 	}
 
+	if restartStats {
+		if err := servicestateDecorateWithRestartStats(clientAppInfos, appInfos); err != nil {
+			return InternalError("%v", err)
+		}
+	}
+
 	return SyncResponse(clientAppInfos)
 }
 
@@ -231,6 +245,19 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		follow = f
 	}
 
+	filter, err := parseLogFilter(query)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	allFields := false
+	switch fields := query.Get("fields"); fields {
+	case "":
+	case "all":
+		allFields = true
+	default:
+		return BadRequest(`invalid value for fields: %q`, fields)
+	}
+
 	// only services have logs for now
 	opts := appInfoOptions{service: true}
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
@@ -241,7 +268,7 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 		return AppNotFound("no matching services")
 	}
 
-	reader, err := servicestate.LogReader(appInfos, n, follow)
+	reader, err := servicestate.LogReader(appInfos, n, follow, filter)
 	if err != nil {
 		return InternalError("cannot get logs: %v", err)
 	}
@@ -249,10 +276,76 @@ func getLogs(c *Command, r *http.Request, user *auth.UserState) Response {
 	return &journalLineReaderSeqResponse{
 		ReadCloser: reader,
 		follow:     follow,
+		allFields:  allFields,
+	}
+}
+
+// logPriorities are the priorities understood by journalctl -p, by name and
+// by number.
+var logPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// validBootID matches the boot selectors understood by journalctl -b, that
+// is an offset or a boot ID.
+var validBootID = regexp.MustCompile(`^(-?[0-9]+|[0-9a-f]{32})$`)
+
+func parseLogFilter(query url.Values) (*systemd.LogFilter, error) {
+	filter := &systemd.LogFilter{}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		s := query.Get(p.name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q: %v", p.name, s, err)
+		}
+		*p.t = t
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("invalid time range: until is before since")
+	}
+
+	if s := query.Get("priority"); s != "" {
+		valid := strutil.ListContains(logPriorities, s)
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(logPriorities) {
+			valid = true
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid value for priority: %q", s)
+		}
+		filter.Priority = s
+	}
+
+	if s := query.Get("grep"); s != "" {
+		// journalctl uses PCRE, which is mostly a superset of the Go
+		// syntax, this catches obvious mistakes early
+		if _, err := regexp.Compile(s); err != nil {
+			return nil, fmt.Errorf("invalid value for grep: %q: %v", s, err)
+		}
+		filter.Grep = s
 	}
+
+	if s := query.Get("boot"); s != "" {
+		if !validBootID.MatchString(s) {
+			return nil, fmt.Errorf("invalid value for boot: %q", s)
+		}
+		filter.Boot = s
+	}
+
+	return filter, nil
 }
 
-var servicestateControl = servicestate.Control
+var (
+	servicestateControl                  = servicestate.Control
+	servicestateSetLingering             = servicestate.SetLingering
+	servicestateDecorateWithRestartStats = servicestate.DecorateWithRestartStats
+)
 
 func decodeServiceInstruction(body io.ReadCloser, u *user.User) (*servicestate.Instruction, error) {
 	var inst servicestate.Instruction
@@ -291,6 +384,10 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode request body into service operation: %v", err)
 	}
 	// XXX: decoder.More()
+	switch inst.Action {
+	case "linger", "unlinger":
+		return postAppsLinger(c, inst, u)
+	}
 	if len(inst.Names) == 0 {
 		// on POST, don't allow empty to mean all
 		return BadRequest("cannot perform operation on services without a list of services to operate on")
@@ -333,6 +430,37 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 	return AsyncResponse(nil, chg.ID())
 }
 
+// postAppsLinger enables, or disables, lingering for the selected users, so
+// that their snap user services run without them being logged in.
+func postAppsLinger(c *Command, inst *servicestate.Instruction, u *user.User) Response {
+	if len(inst.Names) != 0 {
+		return BadRequest("cannot change lingering of users for specific services")
+	}
+	users, err := inst.Users.UserList(u)
+	if err != nil {
+		return BadRequest("cannot change lingering of users: %v", err)
+	}
+	if len(users) == 0 {
+		return BadRequest("cannot change lingering without a list of users")
+	}
+
+	enable := inst.Action == "linger"
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	ts, err := servicestateSetLingering(st, users, enable)
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+	summary := fmt.Sprintf("Enable lingering for users %s", strutil.Quoted(users))
+	if !enable {
+		summary = fmt.Sprintf("Disable lingering for users %s", strutil.Quoted(users))
+	}
+	chg := newChange(st, "set-lingering", summary, []*state.TaskSet{ts}, nil)
+	st.EnsureBefore(0)
+	return AsyncResponse(nil, chg.ID())
+}
+
 func namesToSnapNames(inst *servicestate.Instruction) []string {
 	seen := make(map[string]struct{}, len(inst.Names))
 	for _, snapOrSnapDotApp := range inst.Names {
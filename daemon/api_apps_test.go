@@ -29,9 +29,11 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -64,6 +66,7 @@ type appsSuite struct {
 	jctlNs             []int
 	jctlFollows        []bool
 	jctlNamespaces     []bool
+	jctlFilters        []*systemd.LogFilter
 	jctlRCs            []io.ReadCloser
 	jctlErrs           []error
 	decoratorResults   map[string]appsSuiteDecoratorResult
@@ -74,11 +77,12 @@ type appsSuite struct {
 	infoA, infoB, infoC, infoD, infoE *snap.Info
 }
 
-func (s *appsSuite) journalctl(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+func (s *appsSuite) journalctl(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 	s.jctlSvcses = append(s.jctlSvcses, svcs)
 	s.jctlNs = append(s.jctlNs, n)
 	s.jctlFollows = append(s.jctlFollows, follow)
 	s.jctlNamespaces = append(s.jctlNamespaces, namespaces)
+	s.jctlFilters = append(s.jctlFilters, filter)
 
 	if len(s.jctlErrs) > 0 {
 		err, s.jctlErrs = s.jctlErrs[0], s.jctlErrs[1:]
@@ -153,6 +157,7 @@ func (s *appsSuite) SetUpTest(c *check.C) {
 	s.jctlNs = nil
 	s.jctlFollows = nil
 	s.jctlNamespaces = nil
+	s.jctlFilters = nil
 	s.jctlRCs = nil
 	s.jctlErrs = nil
 
@@ -508,6 +513,97 @@ func (s *appsSuite) TestGetAppsInfoBadSelect(c *check.C) {
 	c.Assert(rspe.Status, check.Equals, 400)
 }
 
+func (s *appsSuite) mockSnapAStatusDecorator() (restore func()) {
+	s.decoratorResults = map[string]appsSuiteDecoratorResult{
+		"snap-a.svc1": {daemonType: "simple", active: true, enabled: true},
+		"snap-a.svc2": {daemonType: "simple", active: true, enabled: true},
+	}
+	return daemon.MockNewStatusDecorator(func(ctx context.Context, isGlobal bool, uid string) clientutil.StatusDecorator {
+		return s
+	})
+}
+
+func (s *appsSuite) TestGetAppsInfoRestartStats(c *check.C) {
+	defer s.mockSnapAStatusDecorator()()
+
+	var calls int
+	var decorated []string
+	r := daemon.MockServicestateDecorateWithRestartStats(func(appInfos []client.AppInfo, snapApps []*snap.AppInfo) error {
+		calls++
+		c.Assert(appInfos, check.HasLen, len(snapApps))
+		for i, snapApp := range snapApps {
+			appInfo := &appInfos[i]
+			c.Check(appInfo.Snap, check.Equals, snapApp.Snap.InstanceName())
+			c.Check(appInfo.Name, check.Equals, snapApp.Name)
+			decorated = append(decorated, appInfo.Snap+"."+appInfo.Name)
+			if snapApp.Name == "svc1" {
+				appInfo.RestartStats = &client.AppRestartStats{Failed: true, Restarts: 7}
+			}
+		}
+		return nil
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/apps?names=snap-a&restart-stats=true", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Assert(rsp.Result, check.FitsTypeOf, []client.AppInfo{})
+	apps := rsp.Result.([]client.AppInfo)
+	// all the apps are decorated at once
+	c.Check(calls, check.Equals, 1)
+	c.Check(decorated, check.HasLen, len(apps))
+	for _, app := range apps {
+		if app.Name == "svc1" {
+			c.Check(app.RestartStats, check.DeepEquals, &client.AppRestartStats{Failed: true, Restarts: 7})
+		} else {
+			c.Check(app.RestartStats, check.IsNil)
+		}
+	}
+}
+
+func (s *appsSuite) TestGetAppsInfoRestartStatsNotRequested(c *check.C) {
+	defer s.mockSnapAStatusDecorator()()
+
+	r := daemon.MockServicestateDecorateWithRestartStats(func(appInfos []client.AppInfo, snapApps []*snap.AppInfo) error {
+		c.Errorf("unexpected call")
+		return nil
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/apps?names=snap-a&restart-stats=false", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+}
+
+func (s *appsSuite) TestGetAppsInfoRestartStatsError(c *check.C) {
+	defer s.mockSnapAStatusDecorator()()
+
+	r := daemon.MockServicestateDecorateWithRestartStats(func(appInfos []client.AppInfo, snapApps []*snap.AppInfo) error {
+		return fmt.Errorf("boom")
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/apps?names=snap-a&restart-stats=true", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, "boom")
+}
+
+func (s *appsSuite) TestGetAppsInfoBadRestartStats(c *check.C) {
+	req, err := http.NewRequest("GET", "/v2/apps?restart-stats=potato", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid restart-stats parameter: "potato"`)
+}
+
 func (s *appsSuite) TestGetAppsInfoBadName(c *check.C) {
 	req, err := http.NewRequest("GET", "/v2/apps?names=potato", nil)
 	c.Assert(err, check.IsNil)
@@ -914,6 +1010,102 @@ func (s *appsSuite) TestPostAppsUserNotSpecifiedForUser(c *check.C) {
 	s.testPostAppsUser(c, inst, nil, "cannot perform operation on services: non-root users must specify users when targeting user services")
 }
 
+func (s *appsSuite) TestPostAppsLinger(c *check.C) {
+	for _, t := range []struct {
+		action  string
+		enable  bool
+		summary string
+	}{
+		{"linger", true, `Enable lingering for users "my-user", "other-user"`},
+		{"unlinger", false, `Disable lingering for users "my-user", "other-user"`},
+	} {
+		restore := daemon.MockServicestateSetLingering(func(st *state.State, users []string, enable bool) (*state.TaskSet, error) {
+			c.Check(users, check.DeepEquals, []string{"my-user", "other-user"})
+			c.Check(enable, check.Equals, t.enable)
+			return state.NewTaskSet(st.NewTask("set-lingering", "...")), nil
+		})
+
+		inst := servicestate.Instruction{
+			Action: t.action,
+			Users:  client.UserSelector{Names: []string{"my-user", "other-user"}},
+		}
+		postBody, err := json.Marshal(inst)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBuffer(postBody))
+		c.Assert(err, check.IsNil)
+
+		rsp := s.asyncReq(c, req, s.authUser)
+		c.Assert(rsp.Status, check.Equals, 202)
+
+		st := s.d.Overlord().State()
+		st.Lock()
+		chg := st.Change(rsp.Change)
+		c.Assert(chg, check.NotNil)
+		c.Check(chg.Kind(), check.Equals, "set-lingering")
+		c.Check(chg.Summary(), check.Equals, t.summary)
+		c.Check(chg.Tasks(), check.HasLen, 1)
+		st.Unlock()
+		restore()
+	}
+	c.Check(s.serviceControlCalls, check.HasLen, 0)
+}
+
+func (s *appsSuite) TestPostAppsLingerSelf(c *check.C) {
+	restore := daemon.MockServicestateSetLingering(func(st *state.State, users []string, enable bool) (*state.TaskSet, error) {
+		c.Check(users, check.DeepEquals, []string{"username"})
+		return state.NewTaskSet(st.NewTask("set-lingering", "...")), nil
+	})
+	defer restore()
+
+	inst := servicestate.Instruction{
+		Action: "linger",
+		Users:  client.UserSelector{Selector: client.UserSelectionSelf},
+	}
+	postBody, err := json.Marshal(inst)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBuffer(postBody))
+	c.Assert(err, check.IsNil)
+	s.asUserAuth(c, req)
+
+	rsp := s.asyncReq(c, req, s.authUser)
+	c.Check(rsp.Status, check.Equals, 202)
+}
+
+func (s *appsSuite) TestPostAppsLingerErrors(c *check.C) {
+	restore := daemon.MockServicestateSetLingering(func(st *state.State, users []string, enable bool) (*state.TaskSet, error) {
+		return nil, fmt.Errorf("cannot change lingering: unknown user %s", users[0])
+	})
+	defer restore()
+
+	for _, t := range []struct {
+		inst servicestate.Instruction
+		err  string
+	}{
+		{
+			servicestate.Instruction{Action: "linger", Names: []string{"snap-e"}, Users: client.UserSelector{Names: []string{"my-user"}}},
+			`cannot change lingering of users for specific services`,
+		}, {
+			servicestate.Instruction{Action: "linger", Users: client.UserSelector{Selector: client.UserSelectionAll}},
+			`cannot change lingering without a list of users`,
+		}, {
+			servicestate.Instruction{Action: "unlinger", Users: client.UserSelector{Selector: client.UserSelectionSelf}},
+			`cannot change lingering of users: cannot use "self" for root user`,
+		}, {
+			servicestate.Instruction{Action: "linger", Users: client.UserSelector{Names: []string{"mallory"}}},
+			`cannot change lingering: unknown user mallory`,
+		},
+	} {
+		postBody, err := json.Marshal(t.inst)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBuffer(postBody))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, s.authUser)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+}
+
 func (s *appsSuite) TestPostAppsBadJSON(c *check.C) {
 	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(`'junk`))
 	c.Assert(err, check.IsNil)
@@ -1001,6 +1193,80 @@ func (s *appsSuite) TestLogs(c *check.C) {
 `[1:])
 }
 
+func (s *appsSuite) TestLogsFilter(c *check.C) {
+	s.expectLogsAccess()
+
+	s.jctlRCs = []io.ReadCloser{io.NopCloser(strings.NewReader(""))}
+
+	q := url.Values{}
+	q.Set("names", "snap-a.svc2")
+	q.Set("since", "2026-01-01T00:00:00Z")
+	q.Set("until", "2026-01-02T00:00:00+01:00")
+	q.Set("priority", "warning")
+	q.Set("grep", "fail(ed|ure)")
+	q.Set("boot", "-1")
+	req, err := http.NewRequest("GET", "/v2/logs?"+q.Encode(), nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+
+	c.Check(s.jctlSvcses, check.DeepEquals, [][]string{{"snap.snap-a.svc2.service"}})
+	c.Assert(s.jctlFilters, check.HasLen, 1)
+	f := s.jctlFilters[0]
+	c.Check(f.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Check(f.Until.Equal(time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Check(f.Priority, check.Equals, "warning")
+	c.Check(f.Grep, check.Equals, "fail(ed|ure)")
+	c.Check(f.Boot, check.Equals, "-1")
+}
+
+func (s *appsSuite) TestLogsBadFilter(c *check.C) {
+	s.expectLogsAccess()
+
+	for _, t := range []struct {
+		query string
+		err   string
+	}{
+		{"since=yesterday", `invalid value for since: "yesterday": .*`},
+		{"until=1h", `invalid value for until: "1h": .*`},
+		{"since=2026-01-02T00:00:00Z&until=2026-01-01T00:00:00Z", `invalid time range: until is before since`},
+		{"priority=loud", `invalid value for priority: "loud"`},
+		{"priority=8", `invalid value for priority: "8"`},
+		{"grep=%28", `invalid value for grep: "\(": .*`},
+		{"boot=last", `invalid value for boot: "last"`},
+		{"fields=some", `invalid value for fields: "some"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/logs?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.query))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.query))
+	}
+	c.Check(s.jctlSvcses, check.HasLen, 0)
+}
+
+func (s *appsSuite) TestLogsAllFields(c *check.C) {
+	s.expectLogsAccess()
+
+	s.jctlRCs = []io.ReadCloser{io.NopCloser(strings.NewReader(`
+{"MESSAGE": "hello1", "SYSLOG_IDENTIFIER": "xyzzy", "_PID": "42", "__REALTIME_TIMESTAMP": "42", "PRIORITY": "6", "_BOOT_ID": "abcd"}
+	`))}
+
+	req, err := http.NewRequest("GET", "/v2/logs?names=snap-a.svc2&fields=all", nil)
+	c.Assert(err, check.IsNil)
+
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Equals, `
+{"timestamp":"1970-01-01T00:00:00.000042Z","message":"hello1","sid":"xyzzy","pid":"42","fields":{"MESSAGE":"hello1","PRIORITY":"6","SYSLOG_IDENTIFIER":"xyzzy","_BOOT_ID":"abcd","_PID":"42","__REALTIME_TIMESTAMP":"42"}}
+`[1:])
+}
+
 func (s *appsSuite) TestLogsNoNamespaceOption(c *check.C) {
 	restore := systemd.MockSystemdVersion(237, nil)
 	defer restore()
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/timings"
@@ -324,6 +325,17 @@ func getDisks(st *state.State) Response {
 	return SyncResponse(vols)
 }
 
+func getSandboxExplain(ifaceMgr *ifacestate.InterfaceManager, instanceName string) Response {
+	if instanceName == "" {
+		return BadRequest("cannot explain sandbox without a snap name")
+	}
+	rules, err := ifaceMgr.ExplainSandbox(instanceName)
+	if err != nil {
+		return errToResponse(err, []string{instanceName}, InternalError, "cannot explain sandbox of snap %q: %v", instanceName)
+	}
+	return SyncResponse(rules)
+}
+
 func createRecovery(st *state.State, label string) Response {
 	if label == "" {
 		return BadRequest("cannot create a recovery system with no label")
@@ -370,6 +382,10 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return getGadgetDiskMapping(st)
 	case "disks":
 		return getDisks(st)
+	case "sandbox-explain":
+		return getSandboxExplain(c.d.overlord.InterfaceManager(), query.Get("snap"))
+	case "denials":
+		return getDenials(st, c.d.overlord.InterfaceManager(), query.Get("snap"))
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -0,0 +1,237 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	seccomp_sandbox "github.com/snapcore/snapd/sandbox/seccomp"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var systemdAuditLogReader = systemd.AuditLogReader
+
+// denialsLogEntries is the number of most recent kernel and audit journal
+// entries which are searched for denials.
+const denialsLogEntries = 10000
+
+// interfaceSuggestion is an interface which would permit a denied access.
+type interfaceSuggestion struct {
+	Interface string `json:"interface"`
+	// Plug is the plug of the snap using the interface, if it declares one.
+	Plug      string `json:"plug,omitempty"`
+	Connected bool   `json:"connected,omitempty"`
+	// Rule is the rule of the interface which permits the access.
+	Rule string `json:"rule"`
+}
+
+// sandboxDenial is an access of a snap which was denied by its sandbox. The
+// same access denied multiple times is reported once.
+type sandboxDenial struct {
+	Backend     interfaces.SecuritySystem `json:"backend"`
+	SecurityTag string                    `json:"security-tag,omitempty"`
+	Access      string                    `json:"access"`
+	Count       int                       `json:"count"`
+	FirstSeen   time.Time                 `json:"first-seen"`
+	LastSeen    time.Time                 `json:"last-seen"`
+	Suggestions []interfaceSuggestion     `json:"suggestions,omitempty"`
+
+	apparmor *apparmor_sandbox.Denial
+	seccomp  *seccomp_sandbox.Denial
+}
+
+// instanceNameOfTag returns the name of the snap instance the security tag
+// belongs to, or an empty string if the tag is not valid.
+func instanceNameOfTag(tag string) string {
+	parsed, err := naming.ParseSecurityTag(tag)
+	if err != nil {
+		return ""
+	}
+	return parsed.InstanceName()
+}
+
+// instanceNameOfExe returns the name of the snap instance the executable
+// belongs to, or an empty string if it is not part of a snap.
+func instanceNameOfExe(exe string) string {
+	rel, err := filepath.Rel(dirs.SnapMountDir, exe)
+	if err != nil || strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	name, _, _ := strings.Cut(rel, "/")
+	return name
+}
+
+// parseDenial returns the denial described by the message if it was caused by
+// the given snap.
+func parseDenial(msg, instanceName string) (*sandboxDenial, bool) {
+	if d, ok := apparmor_sandbox.ParseDenial(msg); ok {
+		tag := d.SecurityTag()
+		if instanceNameOfTag(tag) != instanceName {
+			return nil, false
+		}
+		return &sandboxDenial{
+			Backend:     interfaces.SecurityAppArmor,
+			SecurityTag: tag,
+			Access:      d.String(),
+			apparmor:    d,
+		}, true
+	}
+	if d, ok := seccomp_sandbox.ParseDenial(msg); ok {
+		tag := d.SecurityTag()
+		owner := instanceNameOfTag(tag)
+		if tag == "" {
+			// without AppArmor the label does not identify the snap
+			owner = instanceNameOfExe(d.Exe)
+		}
+		if owner != instanceName {
+			return nil, false
+		}
+		return &sandboxDenial{
+			Backend:     interfaces.SecuritySecComp,
+			SecurityTag: tag,
+			Access:      d.String(),
+			seccomp:     d,
+		}, true
+	}
+	return nil, false
+}
+
+// readDenials reads the denials of the given snap from the journal, in the
+// order they were first seen.
+func readDenials(instanceName string) ([]*sandboxDenial, error) {
+	reader, err := systemdAuditLogReader(denialsLogEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var denials []*sandboxDenial
+	seen := make(map[string]*sandboxDenial)
+	decoder := json.NewDecoder(reader)
+	for {
+		var log systemd.Log
+		if err := decoder.Decode(&log); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		denial, ok := parseDenial(log.Message(), instanceName)
+		if !ok {
+			continue
+		}
+		// entries without a valid timestamp are still counted
+		t, _ := log.Time()
+		key := string(denial.Backend) + "\x00" + denial.SecurityTag + "\x00" + denial.Access
+		if prev := seen[key]; prev != nil {
+			prev.Count++
+			prev.LastSeen = t
+			continue
+		}
+		denial.Count = 1
+		denial.FirstSeen = t
+		denial.LastSeen = t
+		seen[key] = denial
+		denials = append(denials, denial)
+	}
+	return denials, nil
+}
+
+// ruleAllows returns whether the rule permits the denied access.
+func (d *sandboxDenial) ruleAllows(rule interfaces.ExplainedRule, info *snap.Info) bool {
+	if rule.Backend != d.Backend {
+		return false
+	}
+	if rule.SecurityTag != "" && d.SecurityTag != "" && rule.SecurityTag != d.SecurityTag {
+		return false
+	}
+	switch {
+	case d.apparmor != nil && d.apparmor.Capability != "":
+		return apparmor.CapabilityRuleAllows(rule.Rule, d.apparmor.Capability)
+	case d.apparmor != nil && d.apparmor.Name != "" && d.apparmor.RequestedMask != "":
+		return apparmor.FileRuleAllows(rule.Rule, info, d.apparmor.Name, d.apparmor.RequestedMask)
+	case d.seccomp != nil && d.seccomp.SyscallName() != "":
+		return seccomp.SyscallRuleAllows(rule.Rule, d.seccomp.SyscallName())
+	}
+	return false
+}
+
+// suggestInterfaces fills in the interfaces which would permit each of the
+// denied accesses.
+func suggestInterfaces(denials []*sandboxDenial, candidates []ifacestate.InterfaceCandidate, info *snap.Info) {
+	for _, denial := range denials {
+		for _, candidate := range candidates {
+			for _, rule := range candidate.Rules {
+				if !denial.ruleAllows(rule, info) {
+					continue
+				}
+				denial.Suggestions = append(denial.Suggestions, interfaceSuggestion{
+					Interface: candidate.Interface,
+					Plug:      candidate.Plug,
+					Connected: candidate.Connected,
+					Rule:      rule.Rule,
+				})
+				break
+			}
+		}
+	}
+}
+
+func getDenials(st *state.State, ifaceMgr *ifacestate.InterfaceManager, instanceName string) Response {
+	if instanceName == "" {
+		return BadRequest("cannot list denials without a snap name")
+	}
+	info, err := snapstate.CurrentInfo(st, instanceName)
+	if err != nil {
+		return errToResponse(err, []string{instanceName}, InternalError, "cannot list denials of snap %q: %v", instanceName)
+	}
+	candidates, err := ifaceMgr.InterfaceCandidates(instanceName)
+	if err != nil {
+		return errToResponse(err, []string{instanceName}, InternalError, "cannot list denials of snap %q: %v", instanceName)
+	}
+
+	// do not hold the state lock while reading the journal
+	st.Unlock()
+	denials, err := readDenials(instanceName)
+	st.Lock()
+	if err != nil {
+		return InternalError("cannot read denials of snap %q: %v", instanceName, err)
+	}
+
+	suggestInterfaces(denials, candidates, info)
+	if denials == nil {
+		denials = []*sandboxDenial{}
+	}
+	return SyncResponse(denials)
+}
@@ -23,13 +23,22 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
+	"golang.org/x/sys/unix"
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
@@ -111,6 +120,154 @@ func (s *postDebugSuite) TestGetDebugBaseDeclaration(c *check.C) {
 		testutil.Contains, "type: base-declaration")
 }
 
+func (s *postDebugSuite) TestGetDebugSandboxExplain(c *check.C) {
+	d := s.daemon(c)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	c.Assert(repo.AddBackend(&mount.Backend{}), check.IsNil)
+	c.Assert(repo.AddInterface(&ifacetest.TestInterface{
+		InterfaceName: "test",
+		MountPermanentPlugCallback: func(spec *mount.Specification, plug *snap.PlugInfo) error {
+			return spec.AddMountEntry(osutil.MountEntry{Name: "/src", Dir: "/dst", Type: "none", Options: []string{"bind"}})
+		},
+	}), check.IsNil)
+	s.mockSnap(c, `name: consumer
+version: 1
+plugs:
+ plug:
+  interface: test
+`)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=sandbox-explain&snap=consumer", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []interfaces.ExplainedRule{{
+		Backend: interfaces.SecurityMount,
+		Rule:    "/src /dst none bind 0 0",
+		Origin: interfaces.RuleOrigin{
+			Interface: "test",
+			Plug:      &interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+			Source:    interfaces.SourcePermanentPlug,
+		},
+	}})
+}
+
+func (s *postDebugSuite) TestGetDebugSandboxExplainErrors(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=sandbox-explain", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot explain sandbox without a snap name")
+
+	req, err = http.NewRequest("GET", "/v2/debug?aspect=sandbox-explain&snap=unknown", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+	c.Check(rspe.Message, check.Equals, `snap "unknown" is not installed`)
+}
+
+func (s *postDebugSuite) TestGetDebugDenials(c *check.C) {
+	d := s.daemon(c)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	c.Assert(repo.AddInterface(&ifacetest.TestInterface{
+		InterfaceName: "test",
+		AppArmorConnectedPlugCallback: func(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddSnippet("/dev/foo rw,")
+			return nil
+		},
+		SecCompConnectedPlugCallback: func(spec *seccomp.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddSnippet("ptrace")
+			return nil
+		},
+	}), check.IsNil)
+	s.mockSnap(c, `name: core
+version: 1
+type: os
+slots:
+ test:
+`)
+	s.mockSnap(c, `name: consumer
+version: 1
+apps:
+ app:
+`)
+
+	var n int
+	restore := daemon.MockSystemdAuditLogReader(func(entries int) (io.ReadCloser, error) {
+		n = entries
+		return io.NopCloser(strings.NewReader(fmt.Sprintf(`
+{"MESSAGE": "audit: type=1400 apparmor=\"DENIED\" operation=\"open\" profile=\"snap.consumer.app\" name=\"/dev/foo\" pid=1 comm=\"app\" requested_mask=\"r\" denied_mask=\"r\" fsuid=0 ouid=0", "__REALTIME_TIMESTAMP": "1000000"}
+{"MESSAGE": "audit: type=1400 apparmor=\"DENIED\" operation=\"open\" profile=\"snap.other.app\" name=\"/dev/foo\" pid=1 comm=\"app\" requested_mask=\"r\" denied_mask=\"r\" fsuid=0 ouid=0", "__REALTIME_TIMESTAMP": "1500000"}
+{"MESSAGE": "audit: type=1400 apparmor=\"DENIED\" operation=\"open\" profile=\"snap.consumer.app\" name=\"/dev/foo\" pid=1 comm=\"app\" requested_mask=\"r\" denied_mask=\"r\" fsuid=0 ouid=0", "__REALTIME_TIMESTAMP": "2000000"}
+{"MESSAGE": "audit: type=1326 auid=0 uid=0 gid=0 ses=1 subj=snap.consumer.app pid=1 comm=\"app\" exe=\"/snap/consumer/1/bin/app\" sig=31 arch=c000003e syscall=%d compat=0 ip=0x0 code=0x0", "__REALTIME_TIMESTAMP": "3000000"}
+{"MESSAGE": "some unrelated message", "__REALTIME_TIMESTAMP": "4000000"}
+`, unix.SYS_PTRACE))), nil
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=denials&snap=consumer", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(n, check.Equals, 10000)
+
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, check.IsNil)
+	var denials []map[string]interface{}
+	c.Assert(json.Unmarshal(data, &denials), check.IsNil)
+	c.Check(denials, check.DeepEquals, []map[string]interface{}{{
+		"backend":      "apparmor",
+		"security-tag": "snap.consumer.app",
+		"access":       "open /dev/foo (r)",
+		"count":        2.0,
+		"first-seen":   "1970-01-01T00:00:01Z",
+		"last-seen":    "1970-01-01T00:00:02Z",
+		"suggestions": []interface{}{
+			map[string]interface{}{"interface": "test", "rule": "/dev/foo rw,"},
+		},
+	}, {
+		"backend":      "seccomp",
+		"security-tag": "snap.consumer.app",
+		"access":       "syscall ptrace",
+		"count":        1.0,
+		"first-seen":   "1970-01-01T00:00:03Z",
+		"last-seen":    "1970-01-01T00:00:03Z",
+		"suggestions": []interface{}{
+			map[string]interface{}{"interface": "test", "rule": "ptrace"},
+		},
+	}})
+}
+
+func (s *postDebugSuite) TestGetDebugDenialsErrors(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=denials", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot list denials without a snap name")
+
+	req, err = http.NewRequest("GET", "/v2/debug?aspect=denials&snap=unknown", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapNotInstalled)
+
+	s.mockSnap(c, "name: consumer\nversion: 1\n")
+	restore := daemon.MockSystemdAuditLogReader(func(int) (io.ReadCloser, error) {
+		return nil, errors.New("boom")
+	})
+	defer restore()
+	req, err = http.NewRequest("GET", "/v2/debug?aspect=denials&snap=consumer", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, `cannot read denials of snap "consumer": boom`)
+}
+
 func mockDurationThreshold() func() {
 	oldDurationThreshold := timings.DurationThreshold
 	restore := func() {
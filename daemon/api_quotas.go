@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -55,12 +56,17 @@ type postQuotaGroupData struct {
 	Snaps       []string           `json:"snaps,omitempty"`
 	Services    []string           `json:"services,omitempty"`
 	Constraints client.QuotaValues `json:"constraints,omitempty"`
+	// FromSnapHints is whether the constraints which are not given are
+	// computed from the resource hints of the snaps of the group
+	FromSnapHints bool `json:"from-snap-hints,omitempty"`
 }
 
 var (
 	servicestateCreateQuota = servicestate.CreateQuota
 	servicestateUpdateQuota = servicestate.UpdateQuota
 	servicestateRemoveQuota = servicestate.RemoveQuota
+
+	servicestateResourcesFromSnapHints = servicestate.ResourcesFromSnapHints
 )
 
 var getQuotaUsage = func(grp *quota.Group) (*client.QuotaValues, error) {
@@ -249,11 +255,28 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 
 		// check if the quota group exists first, if it does then we need to
 		// update it instead of create it
-		_, err := servicestate.GetQuota(st, data.GroupName)
+		grp, err := servicestate.GetQuota(st, data.GroupName)
 		if err != nil && err != servicestate.ErrQuotaNotFound {
 			return InternalError(err.Error())
 		}
-		if err == servicestate.ErrQuotaNotFound {
+		if data.FromSnapHints {
+			// the hints of all the snaps which end up in the group are
+			// taken into account
+			var snaps []string
+			if grp != nil {
+				snaps = append(snaps, grp.Snaps...)
+			}
+			for _, sn := range data.Snaps {
+				if !strutil.ListContains(snaps, sn) {
+					snaps = append(snaps, sn)
+				}
+			}
+			resourceLimits, err = servicestateResourcesFromSnapHints(st, snaps, resourceLimits)
+			if err != nil {
+				return errToResponse(err, snaps, BadRequest, "%v")
+			}
+		}
+		if grp == nil {
 			// then we need to create the quota
 			ts, err = servicestateCreateQuota(st, data.GroupName, servicestate.CreateQuotaOptions{
 				ParentName:     data.Parent,
@@ -265,7 +288,7 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 				return errToResponse(err, nil, BadRequest, "cannot create quota group: %v")
 			}
 			chgSummary = "Create quota group"
-		} else if err == nil {
+		} else {
 			// the quota group already exists, update it
 			updateOpts := servicestate.UpdateQuotaOptions{
 				AddSnaps:          data.Snaps,
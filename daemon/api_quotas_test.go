@@ -199,6 +199,112 @@ func (s *apiQuotaSuite) TestPostEnsureQuotaCreateHappy(c *check.C) {
 	c.Assert(s.ensureSoonCalled, check.Equals, 1)
 }
 
+func (s *apiQuotaSuite) TestPostEnsureQuotaCreateFromSnapHints(c *check.C) {
+	r := daemon.MockServicestateResourcesFromSnapHints(func(st *state.State, snaps []string, limits quota.Resources) (quota.Resources, error) {
+		c.Check(snaps, check.DeepEquals, []string{"some-snap"})
+		// the given constraints are passed along
+		c.Check(limits, check.DeepEquals, quota.NewResourcesBuilder().WithThreadLimit(64).Build())
+		return quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithThreadLimit(64).Build(), nil
+	})
+	defer r()
+
+	var createCalled int
+	r = daemon.MockServicestateCreateQuota(func(st *state.State, name string, createOpts servicestate.CreateQuotaOptions) (*state.TaskSet, error) {
+		createCalled++
+		c.Check(name, check.Equals, "booze")
+		c.Check(createOpts.Snaps, check.DeepEquals, []string{"some-snap"})
+		c.Check(createOpts.ResourceLimits, check.DeepEquals, quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithThreadLimit(64).Build())
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:        "ensure",
+		GroupName:     "booze",
+		Snaps:         []string{"some-snap"},
+		Constraints:   client.QuotaValues{Threads: 64},
+		FromSnapHints: true,
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Assert(createCalled, check.Equals, 1)
+}
+
+func (s *apiQuotaSuite) TestPostEnsureQuotaUpdateFromSnapHints(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	err := servicestatetest.MockQuotaInState(st, "ginger-ale", "", []string{"test-snap"}, nil,
+		quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeMiB).Build())
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	r := daemon.MockServicestateResourcesFromSnapHints(func(st *state.State, snaps []string, limits quota.Resources) (quota.Resources, error) {
+		// the snaps already in the group are taken into account
+		c.Check(snaps, check.DeepEquals, []string{"test-snap", "some-snap"})
+		return quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(), nil
+	})
+	defer r()
+
+	updateCalled := 0
+	r = daemon.MockServicestateUpdateQuota(func(st *state.State, name string, opts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		updateCalled++
+		c.Check(name, check.Equals, "ginger-ale")
+		c.Check(opts, check.DeepEquals, servicestate.UpdateQuotaOptions{
+			AddSnaps:          []string{"test-snap", "some-snap"},
+			NewResourceLimits: quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+		})
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:        "ensure",
+		GroupName:     "ginger-ale",
+		Snaps:         []string{"test-snap", "some-snap"},
+		FromSnapHints: true,
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Assert(updateCalled, check.Equals, 1)
+}
+
+func (s *apiQuotaSuite) TestPostEnsureQuotaFromSnapHintsError(c *check.C) {
+	r := daemon.MockServicestateResourcesFromSnapHints(func(st *state.State, snaps []string, limits quota.Resources) (quota.Resources, error) {
+		return quota.Resources{}, fmt.Errorf(`cannot use resource hints: snaps "some-snap" do not declare any resources`)
+	})
+	defer r()
+	r = daemon.MockServicestateCreateQuota(func(st *state.State, name string, createOpts servicestate.CreateQuotaOptions) (*state.TaskSet, error) {
+		c.Errorf("should not have called create quota")
+		return nil, fmt.Errorf("broken test")
+	})
+	defer r()
+
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:        "ensure",
+		GroupName:     "booze",
+		Snaps:         []string{"some-snap"},
+		FromSnapHints: true,
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot use resource hints: snaps "some-snap" do not declare any resources`)
+	c.Assert(s.ensureSoonCalled, check.Equals, 0)
+}
+
 func (s *apiQuotaSuite) TestPostEnsureQuotaCreateQuotaConflicts(c *check.C) {
 	var createCalled int
 	r := daemon.MockServicestateCreateQuota(func(st *state.State, name string, createOpts servicestate.CreateQuotaOptions) (*state.TaskSet, error) {
@@ -23,8 +23,10 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/secboot"
 )
 
 var systemRecoveryKeysCmd = &Command{
@@ -40,6 +42,19 @@ func getSystemRecoveryKeys(c *Command, r *http.Request, user *auth.UserState) Re
 	st.Lock()
 	defer st.Unlock()
 
+	switch sel := r.URL.Query().Get("select"); sel {
+	case "":
+		// the default, recovery keys
+	case "key-slots":
+		slots, err := deviceManagerRecoveryKeySlots(c.d.overlord.DeviceManager())
+		if err != nil {
+			return InternalError(err.Error())
+		}
+		return SyncResponse(slots)
+	default:
+		return BadRequest("invalid select parameter: %q", sel)
+	}
+
 	keys, err := c.d.overlord.DeviceManager().EnsureRecoveryKeys()
 	if err != nil {
 		return InternalError(err.Error())
@@ -48,10 +63,19 @@ func getSystemRecoveryKeys(c *Command, r *http.Request, user *auth.UserState) Re
 	return SyncResponse(keys)
 }
 
-var deviceManagerRemoveRecoveryKeys = (*devicestate.DeviceManager).RemoveRecoveryKeys
+var (
+	deviceManagerRemoveRecoveryKeys     = (*devicestate.DeviceManager).RemoveRecoveryKeys
+	deviceManagerRecoveryKeySlots       = (*devicestate.DeviceManager).RecoveryKeySlots
+	deviceManagerAddNamedRecoveryKey    = (*devicestate.DeviceManager).AddNamedRecoveryKey
+	deviceManagerRotateNamedRecoveryKey = (*devicestate.DeviceManager).RotateNamedRecoveryKey
+	deviceManagerRemoveNamedRecoveryKey = (*devicestate.DeviceManager).RemoveNamedRecoveryKey
+)
 
 type postSystemRecoveryKeysData struct {
 	Action string `json:"action"`
+	// Name of the recovery key, only used by the named recovery keys
+	// actions
+	Name string `json:"name,omitempty"`
 }
 
 func postSystemRecoveryKeys(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -70,14 +94,47 @@ func postSystemRecoveryKeys(c *Command, r *http.Request, user *auth.UserState) R
 		return BadRequest("missing recovery keys action")
 	default:
 		return BadRequest("unsupported recovery keys action %q", postData.Action)
+	case "add", "rotate":
+		if postData.Name == "" {
+			return BadRequest("recovery key name is required for action %q", postData.Action)
+		}
 	case "remove":
-		// only currently supported action
+		// without a name all recovery keys are removed
+	}
+	if postData.Name != "" {
+		if err := secboot.ValidateRecoveryKeyName(postData.Name); err != nil {
+			return BadRequest("%v", err)
+		}
 	}
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
 
-	err := deviceManagerRemoveRecoveryKeys(c.d.overlord.DeviceManager())
+	mgr := c.d.overlord.DeviceManager()
+	switch postData.Action {
+	case "add", "rotate":
+		var rkey string
+		var err error
+		if postData.Action == "add" {
+			rkey, err = deviceManagerAddNamedRecoveryKey(mgr, postData.Name)
+		} else {
+			rkey, err = deviceManagerRotateNamedRecoveryKey(mgr, postData.Name)
+		}
+		if err != nil {
+			return InternalError(err.Error())
+		}
+		return SyncResponse(&client.NamedRecoveryKey{
+			Name:        postData.Name,
+			RecoveryKey: rkey,
+		})
+	}
+
+	var err error
+	if postData.Name != "" {
+		err = deviceManagerRemoveNamedRecoveryKey(mgr, postData.Name)
+	} else {
+		err = deviceManagerRemoveRecoveryKeys(mgr)
+	}
 	if err != nil {
 		return InternalError(err.Error())
 	}
@@ -27,6 +27,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -165,3 +166,144 @@ func (s *recoveryKeysSuite) TestPostSystemRecoveryKeysActionRemoveError(c *C) {
 	c.Check(rspe, DeepEquals, daemon.InternalError("boom"))
 	c.Check(called, Equals, 1)
 }
+
+func (s *recoveryKeysSuite) TestGetSystemRecoveryKeySlots(c *C) {
+	s.daemon(c)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	slots := &client.SystemRecoveryKeySlotsResponse{
+		Volumes: map[string][]client.KeySlot{
+			"ubuntu-data": {
+				{ID: 0, Type: "encryption-key"},
+				{ID: 4, Type: "named-recovery-key", Name: "backup", Created: &created},
+			},
+		},
+	}
+	defer daemon.MockDeviceManagerRecoveryKeySlots(func() (*client.SystemRecoveryKeySlotsResponse, error) {
+		return slots, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/system-recovery-keys?select=key-slots", nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, Equals, 200)
+	c.Check(rsp.Result, DeepEquals, slots)
+}
+
+func (s *recoveryKeysSuite) TestGetSystemRecoveryKeySlotsErrors(c *C) {
+	s.daemon(c)
+
+	defer daemon.MockDeviceManagerRecoveryKeySlots(func() (*client.SystemRecoveryKeySlotsResponse, error) {
+		return nil, errors.New("boom")
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/system-recovery-keys?select=key-slots", nil)
+	c.Assert(err, IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe, DeepEquals, daemon.InternalError("boom"))
+
+	req, err = http.NewRequest("GET", "/v2/system-recovery-keys?select=foo", nil)
+	c.Assert(err, IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe, DeepEquals, daemon.BadRequest(`invalid select parameter: "foo"`))
+}
+
+func (s *recoveryKeysSuite) TestPostSystemRecoveryKeysActionAdd(c *C) {
+	s.daemon(c)
+
+	called := 0
+	defer daemon.MockDeviceManagerAddNamedRecoveryKey(func(name string) (string, error) {
+		called++
+		c.Check(name, Equals, "backup")
+		return "11111-22222", nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"add","name":"backup"}`)
+	req, err := http.NewRequest("POST", "/v2/system-recovery-keys", buf)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(rsp.Result, DeepEquals, &client.NamedRecoveryKey{
+		Name:        "backup",
+		RecoveryKey: "11111-22222",
+	})
+	c.Check(called, Equals, 1)
+}
+
+func (s *recoveryKeysSuite) TestPostSystemRecoveryKeysActionRotate(c *C) {
+	s.daemon(c)
+
+	called := 0
+	defer daemon.MockDeviceManagerRotateNamedRecoveryKey(func(name string) (string, error) {
+		called++
+		c.Check(name, Equals, "backup")
+		return "33333-44444", nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"rotate","name":"backup"}`)
+	req, err := http.NewRequest("POST", "/v2/system-recovery-keys", buf)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(rsp.Result, DeepEquals, &client.NamedRecoveryKey{
+		Name:        "backup",
+		RecoveryKey: "33333-44444",
+	})
+	c.Check(called, Equals, 1)
+
+	defer daemon.MockDeviceManagerRotateNamedRecoveryKey(func(name string) (string, error) {
+		return "", errors.New("boom")
+	})()
+	buf = bytes.NewBufferString(`{"action":"rotate","name":"backup"}`)
+	req, err = http.NewRequest("POST", "/v2/system-recovery-keys", buf)
+	c.Assert(err, IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe, DeepEquals, daemon.InternalError("boom"))
+}
+
+func (s *recoveryKeysSuite) TestPostSystemRecoveryKeysActionRemoveNamed(c *C) {
+	s.daemon(c)
+
+	called := 0
+	defer daemon.MockDeviceManagerRemoveNamedRecoveryKey(func(name string) error {
+		called++
+		c.Check(name, Equals, "backup")
+		return nil
+	})()
+	defer daemon.MockDeviceManagerRemoveRecoveryKeys(func() error {
+		c.Fatalf("unexpected call")
+		return nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"remove","name":"backup"}`)
+	req, err := http.NewRequest("POST", "/v2/system-recovery-keys", buf)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(called, Equals, 1)
+}
+
+func (s *recoveryKeysSuite) TestPostSystemRecoveryKeysNamedBadRequest(c *C) {
+	s.daemon(c)
+
+	defer daemon.MockDeviceManagerAddNamedRecoveryKey(func(name string) (string, error) {
+		c.Fatalf("unexpected call")
+		return "", nil
+	})()
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action":"add"}`, `recovery key name is required for action "add"`},
+		{`{"action":"rotate"}`, `recovery key name is required for action "rotate"`},
+		{`{"action":"add","name":"Bad_Name"}`, `invalid recovery key name "Bad_Name"`},
+		{`{"action":"remove","name":"-"}`, `invalid recovery key name "-"`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-recovery-keys", bytes.NewBufferString(tc.body))
+		c.Assert(err, IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe, DeepEquals, daemon.BadRequest(tc.err), Commentf(tc.body))
+	}
+}
@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+)
+
+var systemVolumesCmd = &Command{
+	Path:        "/v2/system-volumes",
+	POST:        postSystemVolumes,
+	WriteAccess: rootAccess{},
+}
+
+var deviceManagerChangeEncryptionPassphrase = (*devicestate.DeviceManager).ChangeEncryptionPassphrase
+
+type postSystemVolumesData struct {
+	Action        string `json:"action"`
+	OldPassphrase string `json:"old-passphrase"`
+	NewPassphrase string `json:"new-passphrase"`
+}
+
+func postSystemVolumes(c *Command, r *http.Request, user *auth.UserState) Response {
+	var postData postSystemVolumesData
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&postData); err != nil {
+		return BadRequest("cannot decode system volumes action data from request body: %v", err)
+	}
+	if decoder.More() {
+		return BadRequest("spurious content after system volumes action")
+	}
+	switch postData.Action {
+	case "":
+		return BadRequest("missing system volumes action")
+	case "change-passphrase":
+		return postSystemVolumesChangePassphrase(c, &postData)
+	default:
+		return BadRequest("unsupported system volumes action %q", postData.Action)
+	}
+}
+
+func postSystemVolumesChangePassphrase(c *Command, postData *postSystemVolumesData) Response {
+	if postData.NewPassphrase == "" {
+		return BadRequest("new passphrase cannot be empty")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	err := deviceManagerChangeEncryptionPassphrase(c.d.overlord.DeviceManager(), postData.OldPassphrase, postData.NewPassphrase)
+	if err != nil {
+		return InternalError(err.Error())
+	}
+	return SyncResponse(nil)
+}
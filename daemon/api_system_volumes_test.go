@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+)
+
+var _ = Suite(&systemVolumesSuite{})
+
+type systemVolumesSuite struct {
+	apiBaseSuite
+}
+
+func (s *systemVolumesSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectRootAccess()
+}
+
+func (s *systemVolumesSuite) TestPostSystemVolumesChangePassphrase(c *C) {
+	s.daemon(c)
+
+	called := 0
+	defer daemon.MockDeviceManagerChangeEncryptionPassphrase(func(oldPassphrase, newPassphrase string) error {
+		called++
+		c.Check(oldPassphrase, Equals, "old")
+		c.Check(newPassphrase, Equals, "new")
+		return nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"change-passphrase","old-passphrase":"old","new-passphrase":"new"}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", buf)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 200)
+	c.Check(called, Equals, 1)
+}
+
+func (s *systemVolumesSuite) TestPostSystemVolumesChangePassphraseError(c *C) {
+	s.daemon(c)
+
+	defer daemon.MockDeviceManagerChangeEncryptionPassphrase(func(oldPassphrase, newPassphrase string) error {
+		return errors.New("boom")
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"change-passphrase","old-passphrase":"old","new-passphrase":"new"}`)
+	req, err := http.NewRequest("POST", "/v2/system-volumes", buf)
+	c.Assert(err, IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe, DeepEquals, daemon.InternalError("boom"))
+}
+
+func (s *systemVolumesSuite) TestPostSystemVolumesBadRequest(c *C) {
+	s.daemon(c)
+
+	called := 0
+	defer daemon.MockDeviceManagerChangeEncryptionPassphrase(func(oldPassphrase, newPassphrase string) error {
+		called++
+		return nil
+	})()
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{}`, `missing system volumes action`},
+		{`{"action":"unknown"}`, `unsupported system volumes action "unknown"`},
+		{`{"action":"change-pin","old-pin":"1234","new-pin":"4321"}`, `unsupported system volumes action "change-pin"`},
+		{`{"action":"change-passphrase","old-passphrase":"old"}`, `new passphrase cannot be empty`},
+		{`{"action":"change-passphrase"}{}`, `spurious content after system volumes action`},
+		{`{`, `cannot decode system volumes action data from request body: unexpected EOF`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-volumes", bytes.NewBufferString(tc.body))
+		c.Assert(err, IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe, DeepEquals, daemon.BadRequest(tc.err), Commentf(tc.body))
+	}
+	c.Check(called, Equals, 0)
+}
+
+func (s *systemVolumesSuite) TestPostSystemVolumesAsUserErrors(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("POST", "/v2/system-volumes", nil)
+	c.Assert(err, IsNil)
+
+	// being properly authorized as user is not enough, needs root
+	s.asUserAuth(c, req)
+	rec := httptest.NewRecorder()
+	s.serveHTTP(c, rec, req)
+	c.Assert(rec.Code, Equals, 403)
+}
@@ -149,6 +149,7 @@ var (
 	devicestateInstallSetupStorageEncryption = devicestate.InstallSetupStorageEncryption
 	devicestateCreateRecoverySystem          = devicestate.CreateRecoverySystem
 	devicestateRemoveRecoverySystem          = devicestate.RemoveRecoverySystem
+	devicestateFactoryReset                  = devicestate.FactoryReset
 )
 
 func getSystemDetails(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -195,6 +196,7 @@ type systemActionRequest struct {
 	client.SystemAction
 	client.InstallSystemOptions
 	client.CreateSystemOptions
+	client.FactoryResetOptions
 }
 
 func postSystemsAction(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -304,6 +306,12 @@ func postSystemActionDo(c *Command, systemLabel string, req *systemActionRequest
 	if req.Mode == "" {
 		return BadRequest("system action requires the mode to be provided")
 	}
+	if len(req.PreserveSnaps) > 0 {
+		if req.Mode != "factory-reset" {
+			return BadRequest("cannot preserve snaps data in %q mode", req.Mode)
+		}
+		return postSystemActionFactoryReset(c, systemLabel, req)
+	}
 
 	sa := devicestate.SystemAction{
 		Title: req.Title,
@@ -315,6 +323,21 @@ func postSystemActionDo(c *Command, systemLabel string, req *systemActionRequest
 	return SyncResponse(nil)
 }
 
+func postSystemActionFactoryReset(c *Command, systemLabel string, req *systemActionRequest) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	chg, err := devicestateFactoryReset(st, systemLabel, devicestate.FactoryResetOptions{
+		PreserveSnaps: req.PreserveSnaps,
+	})
+	if err != nil {
+		return errToResponse(err, req.PreserveSnaps, BadRequest, "cannot factory reset into %q: %v", systemLabel)
+	}
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
+
 func postSystemActionInstall(c *Command, systemLabel string, req *systemActionRequest) Response {
 	st := c.d.overlord.State()
 	st.Lock()
@@ -322,7 +345,7 @@ func postSystemActionInstall(c *Command, systemLabel string, req *systemActionRe
 
 	switch req.Step {
 	case client.InstallStepSetupStorageEncryption:
-		chg, err := devicestateInstallSetupStorageEncryption(st, systemLabel, req.OnVolumes)
+		chg, err := devicestateInstallSetupStorageEncryption(st, systemLabel, req.OnVolumes, req.VolumesAuth)
 		if err != nil {
 			return BadRequest("cannot setup storage encryption for install from %q: %v", systemLabel, err)
 		}
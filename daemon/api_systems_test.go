@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -1092,6 +1093,86 @@ func (s *systemsSuite) TestSystemsGetSpecificLabelIntegration(c *check.C) {
 	c.Assert(sys, check.DeepEquals, sd)
 }
 
+func (s *systemsSuite) TestSystemActionFactoryResetPreserveSnaps(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	nCalls := 0
+	r := daemon.MockDevicestateFactoryReset(func(st *state.State, label string, opts devicestate.FactoryResetOptions) (*state.Change, error) {
+		nCalls++
+		c.Check(label, check.Equals, "20191119")
+		c.Check(opts, check.DeepEquals, devicestate.FactoryResetOptions{
+			PreserveSnaps: []string{"site-config"},
+		})
+		return st.NewChange("factory-reset", "..."), nil
+	})
+	defer r()
+
+	body := map[string]interface{}{
+		"action":         "do",
+		"mode":           "factory-reset",
+		"preserve-snaps": []string{"site-config"},
+	}
+	b, err := json.Marshal(body)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/systems/20191119", bytes.NewBuffer(b))
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Check(chg, check.NotNil)
+	c.Check(nCalls, check.Equals, 1)
+	c.Check(soon, check.Equals, 1)
+}
+
+func (s *systemsSuite) TestSystemActionFactoryResetPreserveSnapsErrors(c *check.C) {
+	s.daemon(c)
+
+	r := daemon.MockDevicestateFactoryReset(func(st *state.State, label string, opts devicestate.FactoryResetOptions) (*state.Change, error) {
+		if opts.PreserveSnaps[0] == "conflict" {
+			return nil, &snapstate.ChangeConflictError{ChangeKind: "remodel", Message: "remodeling in progress"}
+		}
+		return nil, fmt.Errorf(`cannot preserve data of snap "other": snap is not listed in the model`)
+	})
+	defer r()
+
+	for _, tc := range []struct {
+		mode   string
+		snap   string
+		status int
+		kind   client.ErrorKind
+		error  string
+	}{
+		{"install", "site-config", 400, "", `cannot preserve snaps data in "install" mode`},
+		{"factory-reset", "other", 400, "", `cannot factory reset into "20191119": cannot preserve data of snap "other": snap is not listed in the model`},
+		{"factory-reset", "conflict", 409, client.ErrorKindSnapChangeConflict, `remodeling in progress`},
+	} {
+		body := map[string]interface{}{
+			"action":         "do",
+			"mode":           tc.mode,
+			"preserve-snaps": []string{tc.snap},
+		}
+		b, err := json.Marshal(body)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/systems/20191119", bytes.NewBuffer(b))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, tc.status)
+		c.Check(rspe.Kind, check.Equals, tc.kind)
+		c.Check(rspe.Message, check.Equals, tc.error)
+	}
+}
+
 func (s *systemsSuite) TestSystemInstallActionFinishCallsDevicestate(c *check.C) {
 	s.testSystemInstallActionFinishCallsDevicestate(c, client.OptionalInstallRequest{
 		AvailableForInstall: client.AvailableForInstall{
@@ -1221,9 +1302,11 @@ func (s *systemsSuite) TestSystemInstallActionSetupStorageEncryptionCallsDevices
 	nCalls := 0
 	var gotOnVolumes map[string]*gadget.Volume
 	var gotLabel string
-	r := daemon.MockDevicestateInstallSetupStorageEncryption(func(st *state.State, label string, onVolumes map[string]*gadget.Volume) (*state.Change, error) {
+	var gotVolumesAuth *device.VolumesAuthOptions
+	r := daemon.MockDevicestateInstallSetupStorageEncryption(func(st *state.State, label string, onVolumes map[string]*gadget.Volume, volumesAuth *device.VolumesAuthOptions) (*state.Change, error) {
 		gotLabel = label
 		gotOnVolumes = onVolumes
+		gotVolumesAuth = volumesAuth
 		nCalls++
 		return st.NewChange("foo", "..."), nil
 	})
@@ -1237,6 +1320,10 @@ func (s *systemsSuite) TestSystemInstallActionSetupStorageEncryptionCallsDevices
 				"bootloader": "grub",
 			},
 		},
+		"volumes-auth": map[string]interface{}{
+			"mode":       "passphrase",
+			"passphrase": "secret",
+		},
 	}
 	b, err := json.Marshal(body)
 	c.Assert(err, check.IsNil)
@@ -1258,6 +1345,10 @@ func (s *systemsSuite) TestSystemInstallActionSetupStorageEncryptionCallsDevices
 			Bootloader: "grub",
 		},
 	})
+	c.Check(gotVolumesAuth, check.DeepEquals, &device.VolumesAuthOptions{
+		Mode:       device.AuthModePassphrase,
+		Passphrase: "secret",
+	})
 
 	c.Check(soon, check.Equals, 1)
 }
@@ -20,6 +20,7 @@
 package daemon
 
 import (
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -35,6 +36,22 @@ func MockServicestateControl(f func(st *state.State, appInfos []*snap.AppInfo, i
 	}
 }
 
+func MockServicestateSetLingering(f func(st *state.State, users []string, enable bool) (*state.TaskSet, error)) (restore func()) {
+	old := servicestateSetLingering
+	servicestateSetLingering = f
+	return func() {
+		servicestateSetLingering = old
+	}
+}
+
+func MockServicestateDecorateWithRestartStats(f func(appInfos []client.AppInfo, snapApps []*snap.AppInfo) error) (restore func()) {
+	old := servicestateDecorateWithRestartStats
+	servicestateDecorateWithRestartStats = f
+	return func() {
+		servicestateDecorateWithRestartStats = old
+	}
+}
+
 type (
 	AppInfoOptions = appInfoOptions
 )
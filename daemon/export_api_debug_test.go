@@ -19,6 +19,12 @@
 
 package daemon
 
+import (
+	"io"
+
+	"github.com/snapcore/snapd/testutil"
+)
+
 type (
 	ConnectivityStatus = connectivityStatus
 )
@@ -26,3 +32,7 @@ type (
 var (
 	MinLane = minLane
 )
+
+func MockSystemdAuditLogReader(f func(n int) (io.ReadCloser, error)) (restore func()) {
+	return testutil.Mock(&systemdAuditLogReader, f)
+}
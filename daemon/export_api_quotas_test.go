@@ -46,6 +46,14 @@ func MockServicestateUpdateQuota(f func(st *state.State, name string, opts servi
 	}
 }
 
+func MockServicestateResourcesFromSnapHints(f func(st *state.State, snaps []string, limits quota.Resources) (quota.Resources, error)) func() {
+	old := servicestateResourcesFromSnapHints
+	servicestateResourcesFromSnapHints = f
+	return func() {
+		servicestateResourcesFromSnapHints = old
+	}
+}
+
 func MockServicestateRemoveQuota(f func(st *state.State, name string) (*state.TaskSet, error)) func() {
 	old := servicestateRemoveQuota
 	servicestateRemoveQuota = f
@@ -20,6 +20,7 @@
 package daemon
 
 import (
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -31,3 +32,35 @@ func MockDeviceManagerRemoveRecoveryKeys(f func() error) (restore func()) {
 	}
 	return restore
 }
+
+func MockDeviceManagerRecoveryKeySlots(f func() (*client.SystemRecoveryKeySlotsResponse, error)) (restore func()) {
+	restore = testutil.Backup(&deviceManagerRecoveryKeySlots)
+	deviceManagerRecoveryKeySlots = func(*devicestate.DeviceManager) (*client.SystemRecoveryKeySlotsResponse, error) {
+		return f()
+	}
+	return restore
+}
+
+func MockDeviceManagerAddNamedRecoveryKey(f func(name string) (string, error)) (restore func()) {
+	restore = testutil.Backup(&deviceManagerAddNamedRecoveryKey)
+	deviceManagerAddNamedRecoveryKey = func(_ *devicestate.DeviceManager, name string) (string, error) {
+		return f(name)
+	}
+	return restore
+}
+
+func MockDeviceManagerRotateNamedRecoveryKey(f func(name string) (string, error)) (restore func()) {
+	restore = testutil.Backup(&deviceManagerRotateNamedRecoveryKey)
+	deviceManagerRotateNamedRecoveryKey = func(_ *devicestate.DeviceManager, name string) (string, error) {
+		return f(name)
+	}
+	return restore
+}
+
+func MockDeviceManagerRemoveNamedRecoveryKey(f func(name string) error) (restore func()) {
+	restore = testutil.Backup(&deviceManagerRemoveNamedRecoveryKey)
+	deviceManagerRemoveNamedRecoveryKey = func(_ *devicestate.DeviceManager, name string) error {
+		return f(name)
+	}
+	return restore
+}
@@ -279,10 +279,14 @@ func (f fileResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // The reader is always closed when done (this is important for
 // osutil.WatingStdoutPipe).
 //
+// If allFields is set, all the fields of the journal entry are passed on as
+// well.
+//
 // Tip: “jq” knows how to read this; “jq --seq” both reads and writes this.
 type journalLineReaderSeqResponse struct {
 	io.ReadCloser
-	follow bool
+	follow    bool
+	allFields bool
 }
 
 func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -304,12 +308,16 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 
 		// ignore the error...
 		t, _ := log.Time()
-		if err = enc.Encode(client.Log{
+		entry := client.Log{
 			Timestamp: t,
 			Message:   log.Message(),
 			SID:       log.SID(),
 			PID:       log.PID(),
-		}); err != nil {
+		}
+		if rr.allFields {
+			entry.Fields = log
+		}
+		if err = enc.Encode(entry); err != nil {
 			break
 		}
 
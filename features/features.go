@@ -75,6 +75,10 @@ const (
 	Confdbs
 	// AppArmorPrompting enables AppArmor to prompt the user for permission when apps perform certain operations.
 	AppArmorPrompting
+	// Landlock enables confinement of snaps with landlock on systems without AppArmor.
+	Landlock
+	// SELinuxConfinement enables confinement of strictly confined snaps with SELinux domains.
+	SELinuxConfinement
 
 	// lastFeature is the final known feature, it is only used for testing.
 	lastFeature
@@ -123,6 +127,10 @@ var featureNames = map[SnapdFeature]string{
 	Confdbs:               "confdbs",
 
 	AppArmorPrompting: "apparmor-prompting",
+
+	Landlock: "landlock",
+
+	SELinuxConfinement: "selinux-confinement",
 }
 
 // featuresEnabledWhenUnset contains a set of features that are enabled when not explicitly configured.
@@ -148,6 +156,8 @@ var featuresExported = map[SnapdFeature]bool{
 	RefreshAppAwarenessUX: true,
 	Confdbs:               true,
 	AppArmorPrompting:     true,
+	Landlock:              true,
+	SELinuxConfinement:    true,
 }
 
 var (
@@ -64,6 +64,8 @@ func (*featureSuite) TestName(c *C) {
 	check(features.RefreshAppAwarenessUX, "refresh-app-awareness-ux")
 	check(features.Confdbs, "confdbs")
 	check(features.AppArmorPrompting, "apparmor-prompting")
+	check(features.Landlock, "landlock")
+	check(features.SELinuxConfinement, "selinux-confinement")
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 	c.Check(func() { _ = features.SnapdFeature(1000).String() }, PanicMatches, "unknown feature flag code 1000")
@@ -104,6 +106,8 @@ func (*featureSuite) TestIsExported(c *C) {
 	check(features.RefreshAppAwarenessUX, true)
 	check(features.Confdbs, true)
 	check(features.AppArmorPrompting, true)
+	check(features.Landlock, true)
+	check(features.SELinuxConfinement, true)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -229,6 +233,8 @@ func (*featureSuite) TestIsEnabledWhenUnset(c *C) {
 	check(features.RefreshAppAwarenessUX, false)
 	check(features.Confdbs, false)
 	check(features.AppArmorPrompting, false)
+	check(features.Landlock, false)
+	check(features.SELinuxConfinement, false)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -243,6 +249,8 @@ func (*featureSuite) TestControlFile(c *C) {
 	c.Check(features.RefreshAppAwarenessUX.ControlFile(), Equals, "/var/lib/snapd/features/refresh-app-awareness-ux")
 	c.Check(features.Confdbs.ControlFile(), Equals, "/var/lib/snapd/features/confdbs")
 	c.Check(features.AppArmorPrompting.ControlFile(), Equals, "/var/lib/snapd/features/apparmor-prompting")
+	c.Check(features.Landlock.ControlFile(), Equals, "/var/lib/snapd/features/landlock")
+	c.Check(features.SELinuxConfinement.ControlFile(), Equals, "/var/lib/snapd/features/selinux-confinement")
 	// Features that are not exported don't have a control file.
 	c.Check(features.Layouts.ControlFile, PanicMatches, `cannot compute the control file of feature "layouts" because that feature is not exported`)
 }
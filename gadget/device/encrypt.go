@@ -65,6 +65,25 @@ func WriteEncryptionMarkers(dataFDEDir, saveFDEDir string, markerSecret []byte)
 	return osutil.AtomicWriteFile(encryptionMarkerUnder(saveFDEDir), markerSecret, 0600, 0)
 }
 
+// passphraseAuthMarkerUnder returns the path of the marker indicating that
+// the encrypted volumes are unlocked with a passphrase under a given directory.
+func passphraseAuthMarkerUnder(deviceFDEDir string) string {
+	return filepath.Join(deviceFDEDir, "passphrase-auth")
+}
+
+// HasPassphraseAuthMarkerUnder returns true when there is a marker in a given
+// directory indicating that the encrypted volumes are unlocked with a
+// passphrase instead of a sealed key.
+func HasPassphraseAuthMarkerUnder(deviceFDEDir string) bool {
+	return osutil.FileExists(passphraseAuthMarkerUnder(deviceFDEDir))
+}
+
+// WritePassphraseAuthMarker writes the marker indicating that the encrypted
+// volumes are unlocked with a passphrase in the given directory.
+func WritePassphraseAuthMarker(deviceFDEDir string) error {
+	return osutil.AtomicWriteFile(passphraseAuthMarkerUnder(deviceFDEDir), nil, 0644, 0)
+}
+
 // DataSealedKeyUnder returns the path of the sealed key for ubuntu-data.
 func DataSealedKeyUnder(deviceFDEDir string) string {
 	return filepath.Join(deviceFDEDir, "ubuntu-data.sealed-key")
@@ -111,6 +130,9 @@ const (
 	SealingMethodLegacyTPM    = SealingMethod("")
 	SealingMethodTPM          = SealingMethod("tpm")
 	SealingMethodFDESetupHook = SealingMethod("fde-setup-hook")
+	// SealingMethodPassphrase indicates that no key is sealed, the
+	// encrypted volumes are unlocked with a passphrase.
+	SealingMethodPassphrase = SealingMethod("passphrase")
 )
 
 // StampSealedKeys writes what sealing method was used for key sealing
@@ -137,3 +159,38 @@ func SealedKeysMethod(rootdir string) (sm SealingMethod, err error) {
 	}
 	return SealingMethod(content), err
 }
+
+// AuthMode is the user supplied factor needed to unlock the encrypted
+// volumes. Unlocking with a key sealed to the TPM and protected by a PIN is
+// not supported, as secboot cannot seal PIN protected keys.
+type AuthMode string
+
+const (
+	// AuthModePassphrase unlocks the encrypted volumes with a passphrase
+	// only, no key is sealed to the TPM.
+	AuthModePassphrase AuthMode = "passphrase"
+)
+
+// VolumesAuthOptions carries the user supplied factor to protect the encrypted
+// volumes with. A nil VolumesAuthOptions means that keys are sealed to the
+// TPM without any user supplied factor.
+type VolumesAuthOptions struct {
+	Mode       AuthMode `json:"mode,omitempty"`
+	Passphrase string   `json:"passphrase,omitempty"`
+}
+
+// Validate checks that the options are consistent.
+func (o *VolumesAuthOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	switch o.Mode {
+	case AuthModePassphrase:
+		if o.Passphrase == "" {
+			return errors.New("passphrase cannot be empty")
+		}
+	default:
+		return fmt.Errorf("invalid authentication mode %q, only %q mode is supported", o.Mode, AuthModePassphrase)
+	}
+	return nil
+}
@@ -115,6 +115,7 @@ func (s *deviceSuite) TestStampSealedKeysRunthrough(c *C) {
 		{device.SealingMethodLegacyTPM, ""},
 		{device.SealingMethodTPM, "tpm"},
 		{device.SealingMethodFDESetupHook, "fde-setup-hook"},
+		{device.SealingMethodPassphrase, "passphrase"},
 	} {
 		err := device.StampSealedKeys(root, tc.mth)
 		c.Assert(err, IsNil)
@@ -150,3 +151,33 @@ func (s *deviceSuite) TestSealedKeysMethodWithWrongContentHappy(c *C) {
 	c.Check(err, IsNil)
 	c.Check(string(mth), Equals, "invalid-sealing-method")
 }
+
+func (s *deviceSuite) TestPassphraseAuthMarker(c *C) {
+	d := c.MkDir()
+	c.Check(device.HasPassphraseAuthMarkerUnder(d), Equals, false)
+
+	c.Assert(device.WritePassphraseAuthMarker(d), IsNil)
+	c.Check(filepath.Join(d, "passphrase-auth"), testutil.FilePresent)
+	c.Check(device.HasPassphraseAuthMarkerUnder(d), Equals, true)
+}
+
+func (s *deviceSuite) TestVolumesAuthOptionsValidate(c *C) {
+	for _, tc := range []struct {
+		opts *device.VolumesAuthOptions
+		err  string
+	}{
+		{nil, ""},
+		{&device.VolumesAuthOptions{Mode: device.AuthModePassphrase, Passphrase: "1234"}, ""},
+		{&device.VolumesAuthOptions{Mode: device.AuthModePassphrase}, "passphrase cannot be empty"},
+		{&device.VolumesAuthOptions{Mode: "pin"}, `invalid authentication mode "pin", only "passphrase" mode is supported`},
+		{&device.VolumesAuthOptions{Passphrase: "1234"}, `invalid authentication mode "", only "passphrase" mode is supported`},
+		{&device.VolumesAuthOptions{Mode: "fingerprint"}, `invalid authentication mode "fingerprint", only "passphrase" mode is supported`},
+	} {
+		err := tc.opts.Validate()
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%+v", tc.opts))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%+v", tc.opts))
+		}
+	}
+}
@@ -74,8 +74,22 @@ var (
 	SearchVolumeWithTraitsAndMatchParts = searchVolumeWithTraitsAndMatchParts
 	OrderStructuresByOffset             = orderStructuresByOffset
 	LayoutVolumePartially               = layoutVolumePartially
+
+	CanGrowStructure    = canGrowStructure
+	CanAppendStructures = canAppendStructures
+	PlanLayoutChanges   = planLayoutChanges
+	ApplyLayoutPlans    = applyLayoutPlans
+	RestoreLayoutPlans  = restoreLayoutPlans
 )
 
+func MockMkfsMake(f func(typ, img, label string, deviceSize, sectorSize quantity.Size) error) (restore func()) {
+	old := mkfsMake
+	mkfsMake = f
+	return func() {
+		mkfsMake = old
+	}
+}
+
 func MockEvalSymlinks(mock func(path string) (string, error)) (restore func()) {
 	oldEvalSymlinks := evalSymlinks
 	evalSymlinks = mock
@@ -1244,8 +1244,13 @@ func InfoFromGadgetYaml(gadgetYaml []byte, model Model) (*Info, error) {
 				return nil, errors.New("piboot bootloader valid only for UC20 onwards")
 			}
 			bootloadersFound += 1
+		case "systemd-boot":
+			if !compatWithPibootOrIndeterminate(model) {
+				return nil, errors.New("systemd-boot bootloader valid only for UC20 onwards")
+			}
+			bootloadersFound += 1
 		default:
-			return nil, errors.New("bootloader must be one of grub, u-boot, android-boot, piboot or lk")
+			return nil, errors.New("bootloader must be one of grub, u-boot, android-boot, piboot, systemd-boot or lk")
 		}
 	}
 	switch {
@@ -1670,9 +1675,13 @@ func validateVolumeStructure(vs *VolumeStructure, vol *Volume) error {
 		}
 		return fmt.Errorf("invalid %s: %v", what, err)
 	}
-	if vs.Filesystem != "" && !strutil.ListContains([]string{"ext4", "vfat", "vfat-16", "vfat-32", "none"}, vs.Filesystem) {
+	if vs.Filesystem != "" && !strutil.ListContains([]string{"ext4", "vfat", "vfat-16", "vfat-32", "btrfs", "xfs", "f2fs", "none"}, vs.Filesystem) {
 		return fmt.Errorf("invalid filesystem %q", vs.Filesystem)
 	}
+	if vs.Filesystem == "xfs" && len(vs.Content) > 0 {
+		// mkfs.xfs cannot populate the filesystem with the content
+		return fmt.Errorf("invalid filesystem %q: content is not supported", vs.Filesystem)
+	}
 
 	contentChecker := contentCheckerCreate(vs, vol)
 	for i, c := range vs.Content {
@@ -953,7 +953,25 @@ volumes:
 	c.Assert(err, IsNil)
 
 	_, err = gadget.ReadInfo(s.dir, nil)
-	c.Assert(err, ErrorMatches, "bootloader must be one of grub, u-boot, android-boot, piboot or lk")
+	c.Assert(err, ErrorMatches, "bootloader must be one of grub, u-boot, android-boot, piboot, systemd-boot or lk")
+}
+
+func (s *gadgetYamlTestSuite) TestReadGadgetYamlSystemdBootloader(c *C) {
+	mockGadgetYaml := []byte(`
+volumes:
+ name:
+  bootloader: systemd-boot
+`)
+
+	err := os.WriteFile(s.gadgetYamlPath, mockGadgetYaml, 0644)
+	c.Assert(err, IsNil)
+
+	ginfo, err := gadget.ReadInfo(s.dir, uc20Mod)
+	c.Assert(err, IsNil)
+	c.Check(ginfo.Volumes["name"].Bootloader, Equals, "systemd-boot")
+
+	_, err = gadget.ReadInfo(s.dir, coreMod)
+	c.Assert(err, ErrorMatches, "systemd-boot bootloader valid only for UC20 onwards")
 }
 
 func (s *gadgetYamlTestSuite) TestReadGadgetYamlEmptyBootloader(c *C) {
@@ -1372,7 +1390,10 @@ func (s *gadgetYamlTestSuite) TestValidateFilesystem(c *C) {
 		{"vfat-32", ""},
 		{"ext4", ""},
 		{"none", ""},
-		{"btrfs", `invalid filesystem "btrfs"`},
+		{"btrfs", ""},
+		{"xfs", ""},
+		{"f2fs", ""},
+		{"zfs", `invalid filesystem "zfs"`},
 	} {
 		c.Logf("tc: %v %+v", i, tc.s)
 
@@ -1385,6 +1406,39 @@ func (s *gadgetYamlTestSuite) TestValidateFilesystem(c *C) {
 	}
 }
 
+func (s *gadgetYamlTestSuite) TestValidateFilesystemContent(c *C) {
+	vol := &gadget.Volume{Schema: "gpt"}
+	for i, tc := range []struct {
+		s   string
+		err string
+	}{
+		{"ext4", ""},
+		{"btrfs", ""},
+		{"f2fs", ""},
+		{"xfs", `invalid filesystem "xfs": content is not supported`},
+	} {
+		c.Logf("tc: %v %+v", i, tc.s)
+
+		vs := &gadget.VolumeStructure{
+			Filesystem:      tc.s,
+			Type:            "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+			Size:            123,
+			EnclosingVolume: vol,
+			Content:         []gadget.VolumeContent{{UnresolvedSource: "foo", Target: "/"}},
+		}
+		err := gadget.ValidateVolumeStructure(vs, vol)
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+
+		// without content all of them are fine
+		vs.Content = nil
+		c.Check(gadget.ValidateVolumeStructure(vs, vol), IsNil)
+	}
+}
+
 func (s *gadgetYamlTestSuite) TestValidateVolumeSchema(c *C) {
 	for i, tc := range []struct {
 		s   string
@@ -578,6 +578,31 @@ func (s *contentTestSuite) TestMakeFilesystemRealMkfs(c *C) {
 	})
 }
 
+func (s *contentTestSuite) TestMakeFilesystemRealMkfsF2fs(c *C) {
+	mockUdevadm := testutil.MockCommand(c, "udevadm", "")
+	defer mockUdevadm.Restore()
+
+	mockMkfsF2fs := testutil.MockCommand(c, "mkfs.f2fs", "")
+	defer mockMkfsF2fs.Restore()
+
+	err := install.MakeFilesystem(install.MkfsParams{
+		Type:       "f2fs",
+		Device:     mockOnDiskStructureWritable.Node,
+		Label:      mockOnDiskStructureWritable.PartitionFSLabel,
+		Size:       mockOnDiskStructureWritable.Size,
+		SectorSize: quantity.Size(512),
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(mockUdevadm.Calls(), DeepEquals, [][]string{
+		{"udevadm", "trigger", "--settle", "/dev/node3"},
+	})
+
+	c.Assert(mockMkfsF2fs.Calls(), DeepEquals, [][]string{
+		{"mkfs.f2fs", "-f", "-l", "ubuntu-data", "/dev/node3"},
+	})
+}
+
 func (s *contentTestSuite) TestMountFilesystem(c *C) {
 	dirs.SetRootDir(c.MkDir())
 	defer dirs.SetRootDir("")
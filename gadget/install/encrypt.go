@@ -32,6 +32,7 @@ import (
 
 var (
 	secbootFormatEncryptedDevice = secboot.FormatEncryptedDevice
+	secbootAddPassphrase         = secboot.AddPassphrase
 )
 
 // encryptedDeviceCryptsetup represents a encrypted block device.
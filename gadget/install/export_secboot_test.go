@@ -40,6 +40,12 @@ func MockSecbootFormatEncryptedDevice(f func(key keys.EncryptionKey, encType sec
 
 }
 
+func MockSecbootAddPassphrase(f func(key keys.EncryptionKey, passphrase, node string) error) (restore func()) {
+	r := testutil.Backup(&secbootAddPassphrase)
+	secbootAddPassphrase = f
+	return r
+}
+
 func MockBootRunFDESetupHook(f func(req *fde.SetupRequest) ([]byte, error)) (restore func()) {
 	r := testutil.Backup(&boot.RunFDESetupHook)
 	boot.RunFDESetupHook = f
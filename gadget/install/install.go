@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/kernel"
 	"github.com/snapcore/snapd/logger"
@@ -717,6 +718,26 @@ func EncryptPartitions(onVolumes map[string]*gadget.Volume, encryptionType secbo
 	return setupData, nil
 }
 
+// AddVolumesAuth sets up the given authentication options on the
+// partitions previously encrypted by EncryptPartitions.
+func AddVolumesAuth(setupData *EncryptionSetupData, volumesAuth *device.VolumesAuthOptions) error {
+	if volumesAuth == nil {
+		return nil
+	}
+	switch volumesAuth.Mode {
+	case device.AuthModePassphrase:
+		for _, p := range setupData.parts {
+			if err := secbootAddPassphrase(p.encryptionKey, volumesAuth.Passphrase, p.device); err != nil {
+				return fmt.Errorf("cannot add passphrase to %q: %v", p.device, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported authentication mode %q", volumesAuth.Mode)
+	}
+	setupData.volumesAuth = volumesAuth
+	return nil
+}
+
 func KeysForRole(setupData *EncryptionSetupData) map[string]keys.EncryptionKey {
 	keyForRole := make(map[string]keys.EncryptionKey)
 	for _, p := range setupData.parts {
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -35,6 +36,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/gadgettest"
 	"github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/gadget/quantity"
@@ -1470,6 +1472,7 @@ func (s *installSuite) TestInstallWriteContentDeviceNotFound(c *C) {
 
 type encryptPartitionsOpts struct {
 	encryptType secboot.EncryptionType
+	volumesAuth *device.VolumesAuthOptions
 }
 
 func expectedCipher() string {
@@ -1535,6 +1538,26 @@ func (s *installSuite) testEncryptPartitions(c *C, opts encryptPartitionsOpts) {
 		{"cryptsetup", "config", "--priority", "prefer", "--key-slot", "0", "/dev/vda5"},
 		{"cryptsetup", "open", "--key-file", "-", "/dev/vda5", "ubuntu-data"},
 	})
+
+	if opts.volumesAuth == nil {
+		c.Check(encryptSetup.VolumesAuth(), IsNil)
+		return
+	}
+
+	var passphraseDevices []string
+	restore = install.MockSecbootAddPassphrase(func(key keys.EncryptionKey, passphrase, node string) error {
+		c.Check(key, HasLen, 32)
+		c.Check(passphrase, Equals, opts.volumesAuth.Passphrase)
+		passphraseDevices = append(passphraseDevices, node)
+		return nil
+	})
+	defer restore()
+
+	err = install.AddVolumesAuth(encryptSetup, opts.volumesAuth)
+	c.Assert(err, IsNil)
+	sort.Strings(passphraseDevices)
+	c.Check(passphraseDevices, DeepEquals, []string{"/dev/vda4", "/dev/vda5"})
+	c.Check(encryptSetup.VolumesAuth(), Equals, opts.volumesAuth)
 }
 
 func (s *installSuite) TestInstallEncryptPartitionsLUKSHappy(c *C) {
@@ -1543,6 +1566,25 @@ func (s *installSuite) TestInstallEncryptPartitionsLUKSHappy(c *C) {
 	})
 }
 
+func (s *installSuite) TestInstallEncryptPartitionsPassphraseHappy(c *C) {
+	s.testEncryptPartitions(c, encryptPartitionsOpts{
+		encryptType: secboot.EncryptionTypeLUKS,
+		volumesAuth: &device.VolumesAuthOptions{
+			Mode:       device.AuthModePassphrase,
+			Passphrase: "secret",
+		},
+	})
+}
+
+func (s *installSuite) TestInstallAddVolumesAuthUnsupported(c *C) {
+	encryptSetup := install.MockEncryptionSetupData(map[string]*install.MockEncryptedDeviceAndRole{
+		"ubuntu-data": {Role: "system-data", EncryptedDevice: "/dev/mapper/ubuntu-data"},
+	})
+	err := install.AddVolumesAuth(encryptSetup, &device.VolumesAuthOptions{Mode: "pin"})
+	c.Assert(err, ErrorMatches, `unsupported authentication mode "pin"`)
+	c.Check(encryptSetup.VolumesAuth(), IsNil)
+}
+
 func (s *installSuite) TestInstallEncryptPartitionsNoDeviceSet(c *C) {
 	vdaSysPath := "/sys/devices/pci0000:00/0000:00:03.0/virtio1/block/vda"
 	restore := gadget.MockSysfsPathForBlockDevice(func(device string) (string, error) {
@@ -21,6 +21,7 @@ package install
 
 import (
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/secboot"
 	"github.com/snapcore/snapd/secboot/keys"
@@ -62,6 +63,8 @@ type partEncryptionData struct {
 type EncryptionSetupData struct {
 	// maps from partition label to data
 	parts map[string]partEncryptionData
+	// authentication options set up for the encrypted partitions
+	volumesAuth *device.VolumesAuthOptions
 }
 
 // EncryptedDevices returns a map partition role -> LUKS mapper device.
@@ -73,6 +76,12 @@ func (esd *EncryptionSetupData) EncryptedDevices() map[string]string {
 	return m
 }
 
+// VolumesAuth returns the authentication options set up for the
+// encrypted partitions, if any.
+func (esd *EncryptionSetupData) VolumesAuth() *device.VolumesAuthOptions {
+	return esd.volumesAuth
+}
+
 // MockEncryptedDeviceAndRole is meant to be used for unit tests from other
 // packages.
 type MockEncryptedDeviceAndRole struct {
@@ -46,8 +46,8 @@ type OnDiskStructure struct {
 	// structure is treated as if it is of role 'mbr'.
 	Type string
 	// PartitionFSType used for the partition filesystem: 'vfat', 'ext4',
-	// 'none' for structures of type 'bare', or 'crypto_LUKS' for encrypted
-	// partitions.
+	// 'btrfs', 'xfs', 'f2fs', 'none' for structures of type 'bare', or
+	// 'crypto_LUKS' for encrypted partitions.
 	PartitionFSType string
 	// StartOffset defines the start offset of the structure within the
 	// enclosing volume
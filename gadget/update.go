@@ -1249,21 +1249,96 @@ func validateVolumesMatch(old, new map[string]*Volume) error {
 // d. After step (c) is completed the kernel refresh will now also work (no more
 // violation of rule 1)
 func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePolicy UpdatePolicyFunc, observer ContentUpdateObserver) error {
+	structureLocations, layoutPlans, allUpdates, err := prepareUpdate(model, old, new, updatePolicy, true)
+	if err != nil {
+		if err == errSkipUpdateProceedRefresh {
+			return nil
+		}
+		return err
+	}
+
+	// the partition layout is changed first, so that the new content fits in
+	// the grown structures
+	if len(layoutPlans) != 0 {
+		if layoutObserver, ok := observer.(LayoutChangeObserver); ok {
+			if err := layoutObserver.BeforeLayoutChange(layoutPlans); err != nil {
+				return err
+			}
+		}
+		if err := applyLayoutPlans(layoutPlans, rollbackDirPath); err != nil {
+			return err
+		}
+		if len(allUpdates) == 0 {
+			return nil
+		}
+	}
+
+	// apply all updates at once
+	if err := applyUpdates(structureLocations, new, allUpdates, rollbackDirPath, observer); err != nil {
+		if err == ErrNoUpdate && len(layoutPlans) != 0 {
+			// the layout was changed
+			return nil
+		}
+		if len(layoutPlans) != 0 {
+			if kept := restoreLayoutPlans(layoutPlans, rollbackDirPath); len(kept) != 0 {
+				return fmt.Errorf("%v (the partition layout changes of volumes %s cannot be reverted)", err, strutil.Quoted(kept))
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RollbackUpdate reverts a completed gadget update which changed the partition
+// layout, using the backups kept by Update in the rollback directory. The
+// content of the updated structures is restored first, then the partition
+// tables saved before the layout was changed. The arguments must be the same
+// as those passed to Update.
+func RollbackUpdate(model Model, old, new GadgetData, rollbackDirPath string, updatePolicy UpdatePolicyFunc, observer ContentUpdateObserver) error {
+	layoutPlans, err := loadLayoutPlans(rollbackDirPath)
+	if err != nil {
+		return fmt.Errorf("cannot load partition layout changes: %v", err)
+	}
+
+	structureLocations, _, allUpdates, err := prepareUpdate(model, old, new, updatePolicy, false)
+	switch err {
+	case nil:
+		if err := rollbackUpdates(structureLocations, new, allUpdates, rollbackDirPath, observer); err != nil {
+			return err
+		}
+	case ErrNoUpdate, errSkipUpdateProceedRefresh:
+		// no content was updated
+	default:
+		return err
+	}
+
+	if kept := restoreLayoutPlans(layoutPlans, rollbackDirPath); len(kept) != 0 {
+		return fmt.Errorf("cannot revert the partition layout changes of volumes %s", strutil.Quoted(kept))
+	}
+	return nil
+}
+
+// prepareUpdate works out the changes to the partition layout and the
+// updates of the structures needed to go from the old to the new gadget. The
+// layout changes are only planned when planLayout is set, otherwise the disks
+// are expected to already have the layout of the new gadget.
+func prepareUpdate(model Model, old, new GadgetData, updatePolicy UpdatePolicyFunc, planLayout bool) (structureLocations map[string]map[int]StructureLocation, layoutPlans []*LayoutPlan, allUpdates []updatePair, err error) {
 	// The gadget can only match if they have identical volumes assigned for the
 	// (currently) matching device
 	oldVolumes, _, err := VolumesForCurrentDevice(old.Info)
 	if err != nil {
-		return fmt.Errorf("cannot update gadget assets: %v", err)
+		return nil, nil, nil, fmt.Errorf("cannot update gadget assets: %v", err)
 	}
 	newVolumes, _, err := VolumesForCurrentDevice(new.Info)
 	if err != nil {
-		return fmt.Errorf("cannot update gadget assets: %v", err)
+		return nil, nil, nil, fmt.Errorf("cannot update gadget assets: %v", err)
 	}
 
 	// if the volumes from the old and the new gadgets do not match, then fail -
 	// we don't support adding or removing volumes from the gadget.yaml
 	if err := validateVolumesMatch(oldVolumes, newVolumes); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	if updatePolicy == nil {
@@ -1287,7 +1362,7 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 	// ensure all required kernel assets are found in the gadget
 	kernelInfo, err := kernel.ReadInfo(new.KernelRootDir)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	allKernelAssets := []string{}
@@ -1306,11 +1381,10 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		if err == errSkipUpdateProceedRefresh {
 			// we couldn't successfully build a map for the structure locations,
 			// but for various reasons this isn't considered a fatal error for
-			// the gadget refresh, so just return nil instead, a message should
-			// already have been logged
-			return nil
+			// the gadget refresh, a message should already have been logged
+			return nil, nil, nil, errSkipUpdateProceedRefresh
 		}
-		return err
+		return nil, nil, nil, err
 	}
 
 	// Layout new volume, delay resolving of filesystem content
@@ -1320,7 +1394,7 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		KernelRootDir:      new.KernelRootDir,
 	}
 
-	allUpdates := []updatePair{}
+	allUpdates = []updatePair{}
 	laidOutVols := map[string]*LaidOutVolume{}
 	for volName, oldVol := range oldVolumes {
 		newVol := newVolumes[volName]
@@ -1329,18 +1403,50 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		// content
 		pOld, err := layoutVolumePartially(oldVol, volToPartsMap[volName])
 		if err != nil {
-			return fmt.Errorf("cannot lay out the old volume %s: %v", volName, err)
+			return nil, nil, nil, fmt.Errorf("cannot lay out the old volume %s: %v", volName, err)
 		}
 
-		pNew, err := LayoutVolume(newVol, volToPartsMap[volName], opts)
+		if len(oldVol.Structure) > len(newVol.Structure) {
+			return nil, nil, nil, fmt.Errorf("cannot apply update to volume %s: cannot change the number of structures within volume from %v to %v", volName, len(oldVol.Structure), len(newVol.Structure))
+		}
+		if err := canAppendStructures(oldVol, newVol); err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot apply update to volume %s: %v", volName, err)
+		}
+
+		// work out whether the partition layout must change for the new
+		// volume to fit on the disk
+		var plan *LayoutPlan
+		if planLayout {
+			plan, err = planLayoutChanges(oldVol, newVol, volToPartsMap[volName], structureLocations[volName])
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("cannot apply update to volume %s: %v", volName, err)
+			}
+		}
+		if plan != nil {
+			for _, change := range plan.Changes {
+				if change.Kind == LayoutChangeCreate {
+					continue
+				}
+				idx, err := newVol.yamlIdxToStructureIdx(change.structure.YamlIndex)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if err := canUpdateStructureWithLayoutChange(oldVol, idx, newVol, idx); err != nil {
+					return nil, nil, nil, fmt.Errorf("cannot update volume structure %q for volume %s: %v", change.Name, volName, err)
+				}
+			}
+			layoutPlans = append(layoutPlans, plan)
+		}
+
+		pNew, err := LayoutVolume(newVol, diskStructuresAfterLayoutChanges(plan, volToPartsMap[volName]), opts)
 		if err != nil {
-			return fmt.Errorf("cannot lay out the new volume %s: %v", volName, err)
+			return nil, nil, nil, fmt.Errorf("cannot lay out the new volume %s: %v", volName, err)
 		}
 
 		laidOutVols[volName] = pNew
 
 		if err := canUpdateVolume(pOld, pNew); err != nil {
-			return fmt.Errorf("cannot apply update to volume %s: %v", volName, err)
+			return nil, nil, nil, fmt.Errorf("cannot apply update to volume %s: %v", volName, err)
 		}
 
 		// if we haven't consumed any kernel assets yet check if this volume
@@ -1349,7 +1455,7 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		if !atLeastOneKernelAssetConsumed {
 			consumed, err := gadgetVolumeKernelUpdateAssetsConsumed(pNew.Volume, kernelInfo)
 			if err != nil {
-				return err
+				return nil, nil, nil, err
 			}
 			atLeastOneKernelAssetConsumed = consumed
 		}
@@ -1357,21 +1463,21 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		// now we know which structure is which, find which ones need an update
 		updates, err := resolveUpdate(pOld, pNew, updatePolicy, new.RootDir, new.KernelRootDir, kernelInfo)
 		if err != nil {
-			return err
+			return nil, nil, nil, err
 		}
 
 		// can update old layout to new layout
 		for _, update := range updates {
 			fromIdx, err := oldVol.yamlIdxToStructureIdx(update.from.VolumeStructure.YamlIndex)
 			if err != nil {
-				return err
+				return nil, nil, nil, err
 			}
-			toIdx, err := oldVol.yamlIdxToStructureIdx(update.from.VolumeStructure.YamlIndex)
+			toIdx, err := newVol.yamlIdxToStructureIdx(update.to.VolumeStructure.YamlIndex)
 			if err != nil {
-				return err
+				return nil, nil, nil, err
 			}
-			if err := canUpdateStructure(oldVol, fromIdx, newVol, toIdx); err != nil {
-				return fmt.Errorf("cannot update volume structure %v for volume %s: %v", update.to, volName, err)
+			if err := canUpdateStructureWithLayoutChange(oldVol, fromIdx, newVol, toIdx); err != nil {
+				return nil, nil, nil, fmt.Errorf("cannot update volume structure %v for volume %s: %v", update.to, volName, err)
 			}
 		}
 
@@ -1384,12 +1490,12 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 	// any of the volumes
 	if len(allKernelAssets) != 0 && !atLeastOneKernelAssetConsumed {
 		sort.Strings(allKernelAssets)
-		return fmt.Errorf("gadget does not consume any of the kernel assets needing synced update %s", strutil.Quoted(allKernelAssets))
+		return nil, nil, nil, fmt.Errorf("gadget does not consume any of the kernel assets needing synced update %s", strutil.Quoted(allKernelAssets))
 	}
 
-	if len(allUpdates) == 0 {
+	if len(allUpdates) == 0 && len(layoutPlans) == 0 {
 		// nothing to update
-		return ErrNoUpdate
+		return nil, nil, nil, ErrNoUpdate
 	}
 
 	if len(newVolumes) != 1 {
@@ -1418,12 +1524,7 @@ func Update(model Model, old, new GadgetData, rollbackDirPath string, updatePoli
 		}
 	}
 
-	// apply all updates at once
-	if err := applyUpdates(structureLocations, new, allUpdates, rollbackDirPath, observer); err != nil {
-		return err
-	}
-
-	return nil
+	return structureLocations, layoutPlans, allUpdates, nil
 }
 
 func resolveVolume(old *Info, new *Info) (oldVol, newVol *Volume, err error) {
@@ -1499,6 +1600,17 @@ func arePartitionTypesCompatible(from, to *VolumeStructure) bool {
 // disk later, in EnsureVolumeCompatibility. TODO Some checks should maybe
 // happen only there even for non-partial gadgets.
 func canUpdateStructure(fromV *Volume, fromIdx int, toV *Volume, toIdx int) error {
+	return canUpdateStructureImpl(fromV, fromIdx, toV, toIdx, false)
+}
+
+// canUpdateStructureWithLayoutChange is like canUpdateStructure, but also
+// accepts the size changes that can be carried out by changing the partition
+// layout of the disk, as described in planLayoutChanges.
+func canUpdateStructureWithLayoutChange(fromV *Volume, fromIdx int, toV *Volume, toIdx int) error {
+	return canUpdateStructureImpl(fromV, fromIdx, toV, toIdx, true)
+}
+
+func canUpdateStructureImpl(fromV *Volume, fromIdx int, toV *Volume, toIdx int, allowLayoutChange bool) error {
 	from := &fromV.Structure[fromIdx]
 	to := &toV.Structure[toIdx]
 	if !toV.HasPartial(PartialSchema) && toV.Schema == schemaGPT && from.Name != to.Name {
@@ -1507,8 +1619,21 @@ func canUpdateStructure(fromV *Volume, fromIdx int, toV *Volume, toIdx int) erro
 			from.Name, to.Name)
 	}
 	if !arePossibleSizesCompatible(from, to) {
-		return fmt.Errorf("new valid structure size range [%v, %v] is not compatible with current ([%v, %v])",
+		err := fmt.Errorf("new valid structure size range [%v, %v] is not compatible with current ([%v, %v])",
 			to.MinSize, effectivePartSize(to), from.MinSize, effectivePartSize(from))
+		// the system-data structure is expanded to fill the disk at
+		// install, any change of its size is checked against the disk
+		// when planning the layout change
+		isData := from.Role == SystemData && to.Role == SystemData
+		grows := to.MinSize > effectivePartSize(from)
+		if !allowLayoutChange || !(isData || grows) {
+			return err
+		}
+		if !isData {
+			if errGrow := canGrowStructure(to); errGrow != nil {
+				return fmt.Errorf("%v: %v", err, errGrow)
+			}
+		}
 	}
 	if !arePossibleOffsetsCompatible(fromV.Structure, fromIdx, toV.Structure, toIdx) {
 		return fmt.Errorf("new valid structure offset range [%v, %v] is not compatible with current ([%v, %v])",
@@ -1556,10 +1681,11 @@ func canUpdateVolume(from *PartiallyLaidOutVolume, to *LaidOutVolume) error {
 	if err := checkCompatibleSchema(from.Volume, to.Volume); err != nil {
 		return err
 	}
-	if len(from.LaidOutStructure) != len(to.LaidOutStructure) {
+	// structures can be added, but not removed
+	if len(from.LaidOutStructure) > len(to.LaidOutStructure) {
 		return fmt.Errorf("cannot change the number of structures within volume from %v to %v", len(from.LaidOutStructure), len(to.LaidOutStructure))
 	}
-	return nil
+	return canAppendStructures(from.Volume, to.Volume)
 }
 
 type updatePair struct {
@@ -1605,8 +1731,10 @@ func KernelUpdatePolicy(from, to *LaidOutStructure) (bool, ResolvedContentFilter
 }
 
 func resolveUpdate(oldVol *PartiallyLaidOutVolume, newVol *LaidOutVolume, policy UpdatePolicyFunc, newGadgetRootDir, newKernelRootDir string, kernelInfo *kernel.Info) (updates []updatePair, err error) {
-	if len(oldVol.LaidOutStructure) != len(newVol.LaidOutStructure) {
-		return nil, errors.New("internal error: the number of structures in new and old volume definitions is different")
+	// structures added by the new volume come after the existing ones, they
+	// are created empty when the partition layout is changed
+	if len(oldVol.LaidOutStructure) > len(newVol.LaidOutStructure) {
+		return nil, errors.New("internal error: the new volume definition has fewer structures than the old one")
 	}
 	// We must order updates from the latest binary in the boot
 	// chain to the newest. So any seed partitions should come
@@ -1770,6 +1898,41 @@ func applyUpdates(structureLocations map[string]map[int]StructureLocation, new G
 	return updateErr
 }
 
+// rollbackUpdates restores the content of the structures from the backups
+// made by applyUpdates in the rollback directory.
+func rollbackUpdates(structureLocations map[string]map[int]StructureLocation, new GadgetData, updates []updatePair, rollbackDir string, observer ContentUpdateObserver) error {
+	updaters := make([]Updater, len(updates))
+	for i, one := range updates {
+		loc, err := updateLocationForStructure(structureLocations, one.to)
+		if err != nil {
+			return fmt.Errorf("cannot prepare rollback for volume structure %v on volume %s: %v", one.to, one.volume.Name, err)
+		}
+		up, err := updaterForStructure(loc, one.from, one.to, new.RootDir, rollbackDir, observer)
+		if err != nil {
+			return fmt.Errorf("cannot prepare rollback for volume structure %v on volume %s: %v", one.to, one.volume.Name, err)
+		}
+		updaters[i] = up
+	}
+
+	var rollbackErr error
+	for i, one := range updaters {
+		if err := one.Rollback(); err != nil {
+			logger.Noticef("cannot rollback volume structure %v update on volume %s: %v", updates[i].to, updates[i].volume.Name, err)
+			if rollbackErr == nil {
+				rollbackErr = fmt.Errorf("cannot rollback volume structure %v on volume %s: %v", updates[i].to, updates[i].volume.Name, err)
+			}
+		}
+	}
+
+	if observer != nil {
+		if err := observer.Canceled(); err != nil {
+			logger.Noticef("cannot observe canceled update: %v", err)
+		}
+	}
+
+	return rollbackErr
+}
+
 var updaterForStructure = updaterForStructureImpl
 
 func updaterForStructureImpl(loc StructureLocation, fromPs *LaidOutStructure, ps *LaidOutStructure, newRootDir, rollbackDir string, observer ContentUpdateObserver) (Updater, error) {
@@ -0,0 +1,707 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package gadget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/disks"
+	"github.com/snapcore/snapd/osutil/mkfs"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	mkfsMake = mkfs.Make
+
+	onDiskVolumeForStructure = onDiskVolumeForStructureImpl
+)
+
+// onlineGrowableFilesystems are the filesystems that can be grown while they
+// are mounted.
+var onlineGrowableFilesystems = []string{"ext4", "btrfs", "xfs"}
+
+// onlineShrinkableFilesystems are the filesystems that can be shrunk while
+// they are mounted.
+var onlineShrinkableFilesystems = []string{"btrfs"}
+
+// LayoutChangeKind is the kind of a change to the partition layout of a
+// volume.
+type LayoutChangeKind string
+
+const (
+	// LayoutChangeGrow grows a partition and its filesystem into the free
+	// space following it.
+	LayoutChangeGrow LayoutChangeKind = "grow"
+	// LayoutChangeShrink shrinks the filesystem of the system-data partition
+	// and then the partition itself, to make room for new structures.
+	LayoutChangeShrink LayoutChangeKind = "shrink"
+	// LayoutChangeCreate creates the partition and filesystem of a structure
+	// appended to the volume.
+	LayoutChangeCreate LayoutChangeKind = "create"
+)
+
+// LayoutChange describes a single change to the partition layout of a volume
+// carried out during a gadget update.
+type LayoutChange struct {
+	Kind LayoutChangeKind `json:"kind"`
+	// Name is the name of the structure in the gadget.
+	Name string `json:"name"`
+	// DiskIndex is the 1-based index of the partition on the disk.
+	DiskIndex int `json:"disk-index"`
+	// StartOffset is the start of the partition on the disk.
+	StartOffset quantity.Offset `json:"start-offset"`
+	// OldSize is the size of the partition before the change, it is zero
+	// for partitions that are created.
+	OldSize quantity.Size `json:"old-size,omitempty"`
+	// NewSize is the size of the partition after the change.
+	NewSize quantity.Size `json:"new-size"`
+	// Filesystem is the filesystem of the partition, if any.
+	Filesystem string `json:"filesystem,omitempty"`
+
+	node       string
+	mountPoint string
+	structure  *VolumeStructure
+}
+
+func (lc *LayoutChange) String() string {
+	if lc.Kind == LayoutChangeCreate {
+		return fmt.Sprintf("create partition #%d (%q) at offset %d with size %s",
+			lc.DiskIndex, lc.Name, lc.StartOffset, lc.NewSize.IECString())
+	}
+	return fmt.Sprintf("%s partition #%d (%q) from %s to %s", lc.Kind,
+		lc.DiskIndex, lc.Name, lc.OldSize.IECString(), lc.NewSize.IECString())
+}
+
+// LayoutChangeObserver can be implemented by the observer passed to Update to
+// be told about the changes to the partition layout of the disks before they
+// are carried out.
+type LayoutChangeObserver interface {
+	// BeforeLayoutChange is called with the planned changes before any of
+	// them is carried out, returning an error cancels the update.
+	BeforeLayoutChange(plans []*LayoutPlan) error
+}
+
+// LayoutPlan is the list of changes to the partition layout of the disk
+// backing a volume which is carried out before the content of the structures
+// of the volume is updated.
+type LayoutPlan struct {
+	Volume  string         `json:"volume"`
+	Device  string         `json:"device"`
+	Changes []LayoutChange `json:"changes"`
+
+	schema     string
+	sectorSize quantity.Size
+	// added are the disk indexes of the partitions created by the plan
+	// which were added to the kernel
+	added []int
+}
+
+func onDiskVolumeForStructureImpl(node string) (*OnDiskVolume, error) {
+	disk, err := disks.DiskFromPartitionDeviceNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return OnDiskVolumeFromDisk(disk)
+}
+
+// MockOnDiskVolumeForStructure replaces the lookup of the disk volume a
+// structure is on with a mocked one, for use in tests only.
+func MockOnDiskVolumeForStructure(f func(node string) (*OnDiskVolume, error)) (restore func()) {
+	old := onDiskVolumeForStructure
+	onDiskVolumeForStructure = f
+	return func() {
+		onDiskVolumeForStructure = old
+	}
+}
+
+// canGrowStructure checks whether a structure can be grown in place.
+func canGrowStructure(vs *VolumeStructure) error {
+	if !vs.IsPartition() {
+		return errors.New("only partitions can be grown")
+	}
+	if vs.Filesystem != "" && vs.Filesystem != "none" && !strutil.ListContains(onlineGrowableFilesystems, vs.Filesystem) {
+		return fmt.Errorf("cannot grow %s filesystem while in use", vs.Filesystem)
+	}
+	return nil
+}
+
+// canAppendStructures checks whether the structures that the new volume has
+// in addition to the structures of the current one can be added to an
+// existing disk. Only non-system partitions placed after all the existing
+// structures can be added.
+func canAppendStructures(from, to *Volume) error {
+	for i := range to.Structure {
+		vs := &to.Structure[i]
+		if vs.YamlIndex < len(from.Structure) {
+			if i >= len(from.Structure) {
+				return fmt.Errorf("cannot add new structures before existing structure %q", vs.Name)
+			}
+			continue
+		}
+		if !vs.IsPartition() {
+			return fmt.Errorf("cannot add structure %q: only partitions can be added", vs.Name)
+		}
+		if vs.Role != "" {
+			return fmt.Errorf("cannot add structure %q with role %q", vs.Name, vs.Role)
+		}
+		if effectivePartSize(vs) == UnboundedStructureSize {
+			return fmt.Errorf("cannot add structure %q without a size", vs.Name)
+		}
+		if minStructureOffset(to.Structure, i) != maxStructureOffset(to.Structure, i) {
+			return fmt.Errorf("cannot add structure %q without a known offset", vs.Name)
+		}
+	}
+	return nil
+}
+
+// partitionTypeForSchema returns the partition type of the structure suitable
+// for the given disk schema.
+func partitionTypeForSchema(vs *VolumeStructure, schema string) string {
+	types := strings.Split(vs.Type, ",")
+	if len(types) == 2 && schema == schemaGPT {
+		return types[1]
+	}
+	return types[0]
+}
+
+// partitionDeviceNode returns the device node of the partition with the given
+// 1-based index on the disk.
+func partitionDeviceNode(device string, index int) string {
+	if len(device) > 0 {
+		last := device[len(device)-1]
+		if last >= '0' && last <= '9' {
+			return fmt.Sprintf("%sp%d", device, index)
+		}
+	}
+	return fmt.Sprintf("%s%d", device, index)
+}
+
+// planLayoutChanges works out the changes to the partition table of the disk
+// backing a volume needed for the structures of the new gadget volume to fit
+// on it. Structures are grown into the free space that follows them, new
+// structures are created at their offsets, shrinking system-data when it is in
+// the way and its filesystem allows it. Structures are never moved. A nil plan
+// is returned when no change is needed.
+func planLayoutChanges(oldVol, newVol *Volume, gadgetToDisk map[int]*OnDiskStructure, locations map[int]StructureLocation) (*LayoutPlan, error) {
+	if len(gadgetToDisk) == 0 {
+		// the volume is not mapped to a disk
+		return nil, nil
+	}
+
+	var grown, added []*VolumeStructure
+	var dataStruct *VolumeStructure
+	for i := range newVol.Structure {
+		vs := &newVol.Structure[i]
+		if vs.YamlIndex >= len(oldVol.Structure) {
+			added = append(added, vs)
+			continue
+		}
+		if vs.Role == SystemData {
+			dataStruct = vs
+			continue
+		}
+		// only structures which must be bigger than the current gadget
+		// allows need to be grown, a zero size is a partial one
+		if i >= len(oldVol.Structure) {
+			continue
+		}
+		if from := &oldVol.Structure[i]; from.Size == 0 || vs.MinSize <= from.Size {
+			continue
+		}
+		ds := gadgetToDisk[vs.YamlIndex]
+		if ds == nil || ds.Size >= vs.MinSize {
+			continue
+		}
+		grown = append(grown, vs)
+	}
+	if len(grown) == 0 && len(added) == 0 {
+		return nil, nil
+	}
+
+	var anyNode string
+	for _, ds := range gadgetToDisk {
+		if ds.Node != "" {
+			anyNode = ds.Node
+			break
+		}
+	}
+	if anyNode == "" {
+		return nil, fmt.Errorf("cannot find the disk of volume %s", newVol.Name)
+	}
+	diskVol, err := onDiskVolumeForStructure(anyNode)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the disk of volume %s: %v", newVol.Name, err)
+	}
+
+	plan := &LayoutPlan{
+		Volume:     newVol.Name,
+		Device:     diskVol.Device,
+		schema:     diskVol.Schema,
+		sectorSize: diskVol.SectorSize,
+	}
+	usableEnd := quantity.Offset(diskVol.UsableSectorsEnd * uint64(diskVol.SectorSize))
+	// spaceAfter returns the free space following a partition
+	spaceAfter := func(ds *OnDiskStructure) quantity.Size {
+		limit := usableEnd
+		for _, other := range diskVol.Structure {
+			if other.StartOffset > ds.StartOffset && other.StartOffset < limit {
+				limit = other.StartOffset
+			}
+		}
+		return quantity.Size(limit-ds.StartOffset) - ds.Size
+	}
+
+	for _, vs := range grown {
+		ds := gadgetToDisk[vs.YamlIndex]
+		newSize := effectivePartSize(vs)
+		if newSize == UnboundedStructureSize {
+			newSize = vs.MinSize
+		}
+		if available := spaceAfter(ds); newSize-ds.Size > available {
+			return nil, fmt.Errorf("cannot grow structure %q to %s: only %s of free space follows it",
+				vs.Name, newSize.IECString(), available.IECString())
+		}
+		mountPoint := locations[vs.YamlIndex].RootMountPoint
+		switch ds.PartitionFSType {
+		case "", "ext4":
+			// raw content, or a filesystem that can be grown through its
+			// device node
+		case "btrfs", "xfs":
+			if mountPoint == "" {
+				return nil, fmt.Errorf("cannot grow %s filesystem of structure %q: not mounted", ds.PartitionFSType, vs.Name)
+			}
+		default:
+			return nil, fmt.Errorf("cannot grow %s filesystem of structure %q while in use", ds.PartitionFSType, vs.Name)
+		}
+		plan.Changes = append(plan.Changes, LayoutChange{
+			Kind:        LayoutChangeGrow,
+			Name:        vs.Name,
+			DiskIndex:   ds.DiskIndex,
+			StartOffset: ds.StartOffset,
+			OldSize:     ds.Size,
+			NewSize:     newSize,
+			Filesystem:  ds.PartitionFSType,
+			node:        ds.Node,
+			mountPoint:  mountPoint,
+			structure:   vs,
+		})
+	}
+
+	var dataDs *OnDiskStructure
+	if dataStruct != nil {
+		dataDs = gadgetToDisk[dataStruct.YamlIndex]
+	}
+	dataSize := quantity.Size(0)
+	if dataDs != nil {
+		dataSize = dataDs.Size
+	}
+	nextIndex := len(diskVol.Structure) + 1
+	for _, vs := range added {
+		if diskVol.Schema == "dos" && nextIndex > 4 {
+			return nil, fmt.Errorf("cannot add structure %q: no primary partitions left on the disk", vs.Name)
+		}
+		var start quantity.Offset
+		for i := range newVol.Structure {
+			if &newVol.Structure[i] == vs {
+				start = minStructureOffset(newVol.Structure, i)
+				break
+			}
+		}
+		end := start + quantity.Offset(vs.Size)
+		if end > usableEnd {
+			return nil, fmt.Errorf("cannot add structure %q: it does not fit on the disk", vs.Name)
+		}
+		for _, ds := range diskVol.Structure {
+			if ds.StartOffset >= end || start >= ds.StartOffset+quantity.Offset(ds.Size) {
+				continue
+			}
+			if dataDs != nil && ds.DiskIndex == dataDs.DiskIndex && ds.StartOffset < start {
+				// system-data is in the way but can be shrunk
+				if shrunk := quantity.Size(start - ds.StartOffset); shrunk < dataSize {
+					dataSize = shrunk
+				}
+				continue
+			}
+			return nil, fmt.Errorf("cannot add structure %q: it overlaps with partition #%d (%q)", vs.Name, ds.DiskIndex, ds.Name)
+		}
+		plan.Changes = append(plan.Changes, LayoutChange{
+			Kind:        LayoutChangeCreate,
+			Name:        vs.Name,
+			DiskIndex:   nextIndex,
+			StartOffset: start,
+			NewSize:     vs.Size,
+			Filesystem:  vs.LinuxFilesystem(),
+			node:        partitionDeviceNode(diskVol.Device, nextIndex),
+			structure:   vs,
+		})
+		nextIndex++
+	}
+
+	if dataDs != nil && dataSize < dataDs.Size {
+		if dataSize < dataStruct.MinSize {
+			return nil, fmt.Errorf("cannot shrink structure %q to %s: smaller than its minimum size %s",
+				dataStruct.Name, dataSize.IECString(), dataStruct.MinSize.IECString())
+		}
+		mountPoint := locations[dataStruct.YamlIndex].RootMountPoint
+		if !strutil.ListContains(onlineShrinkableFilesystems, dataDs.PartitionFSType) {
+			return nil, fmt.Errorf("cannot shrink %s filesystem of structure %q while in use", dataDs.PartitionFSType, dataStruct.Name)
+		}
+		if mountPoint == "" {
+			return nil, fmt.Errorf("cannot shrink %s filesystem of structure %q: not mounted", dataDs.PartitionFSType, dataStruct.Name)
+		}
+		// shrinking goes first to make room for the new partitions
+		shrink := LayoutChange{
+			Kind:        LayoutChangeShrink,
+			Name:        dataStruct.Name,
+			DiskIndex:   dataDs.DiskIndex,
+			StartOffset: dataDs.StartOffset,
+			OldSize:     dataDs.Size,
+			NewSize:     dataSize,
+			Filesystem:  dataDs.PartitionFSType,
+			node:        dataDs.Node,
+			mountPoint:  mountPoint,
+			structure:   dataStruct,
+		}
+		plan.Changes = append([]LayoutChange{shrink}, plan.Changes...)
+	}
+
+	return plan, nil
+}
+
+// diskStructuresAfterLayoutChanges returns the mapping of gadget structures to
+// disk structures as it will be once the plan is carried out.
+func diskStructuresAfterLayoutChanges(plan *LayoutPlan, gadgetToDisk map[int]*OnDiskStructure) map[int]*OnDiskStructure {
+	if plan == nil {
+		return gadgetToDisk
+	}
+	res := make(map[int]*OnDiskStructure, len(gadgetToDisk)+len(plan.Changes))
+	for idx, ds := range gadgetToDisk {
+		res[idx] = ds
+	}
+	for _, change := range plan.Changes {
+		vs := change.structure
+		if change.Kind == LayoutChangeCreate {
+			res[vs.YamlIndex] = &OnDiskStructure{
+				Name:             vs.Name,
+				PartitionFSLabel: vs.Label,
+				Type:             vs.Type,
+				PartitionFSType:  change.Filesystem,
+				StartOffset:      change.StartOffset,
+				Node:             change.node,
+				DiskIndex:        change.DiskIndex,
+				Size:             change.NewSize,
+			}
+			continue
+		}
+		ds := *res[vs.YamlIndex]
+		ds.Size = change.NewSize
+		res[vs.YamlIndex] = &ds
+	}
+	return res
+}
+
+func runLayoutCommand(stdin string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return osutil.OutputErr(out, err)
+	}
+	return nil
+}
+
+// partitionScript returns the sfdisk script defining the partition changed or
+// created by the layout change.
+func (plan *LayoutPlan) partitionScript(change *LayoutChange) string {
+	startInSectors := uint64(change.StartOffset) / uint64(plan.sectorSize)
+	sizeInSectors := uint64(change.NewSize) / uint64(plan.sectorSize)
+	if change.Kind != LayoutChangeCreate {
+		return fmt.Sprintf("start=%d, size=%d\n", startInSectors, sizeInSectors)
+	}
+	script := fmt.Sprintf("start=%d, size=%d, type=%s", startInSectors, sizeInSectors,
+		partitionTypeForSchema(change.structure, plan.schema))
+	if plan.schema == schemaGPT {
+		script += fmt.Sprintf(", name=%q", change.Name)
+	}
+	return script + "\n"
+}
+
+// changePartition resizes or creates the partition of the layout change and
+// tells the kernel about it. By default sfdisk would re-read the whole
+// partition table, which fails as partitions on the disk are in use.
+func (plan *LayoutPlan) changePartition(change *LayoutChange) error {
+	args := []string{"--no-reread", "--no-tell-kernel"}
+	partxOp := "-u"
+	if change.Kind == LayoutChangeCreate {
+		args = append(args, "--append")
+		partxOp = "-a"
+	} else {
+		args = append(args, "-N", strconv.Itoa(change.DiskIndex))
+	}
+	args = append(args, plan.Device)
+	if err := runLayoutCommand(plan.partitionScript(change), "sfdisk", args...); err != nil {
+		return fmt.Errorf("cannot %s partition #%d: %v", change.Kind, change.DiskIndex, err)
+	}
+	if err := runLayoutCommand("", "partx", partxOp, "--nr", strconv.Itoa(change.DiskIndex), plan.Device); err != nil {
+		return fmt.Errorf("cannot update partition #%d in the kernel: %v", change.DiskIndex, err)
+	}
+	if change.Kind == LayoutChangeCreate {
+		plan.added = append(plan.added, change.DiskIndex)
+	}
+	return nil
+}
+
+func growFilesystem(change *LayoutChange) error {
+	var err error
+	switch change.Filesystem {
+	case "":
+		// no filesystem
+	case "ext4":
+		err = runLayoutCommand("", "resize2fs", change.node)
+	case "btrfs":
+		err = runLayoutCommand("", "btrfs", "filesystem", "resize", "max", change.mountPoint)
+	case "xfs":
+		err = runLayoutCommand("", "xfs_growfs", change.mountPoint)
+	default:
+		err = fmt.Errorf("unsupported filesystem %q", change.Filesystem)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot grow filesystem of partition #%d: %v", change.DiskIndex, err)
+	}
+	return nil
+}
+
+func shrinkFilesystem(change *LayoutChange) error {
+	if change.Filesystem != "btrfs" {
+		return fmt.Errorf("cannot shrink filesystem of partition #%d: unsupported filesystem %q", change.DiskIndex, change.Filesystem)
+	}
+	if err := runLayoutCommand("", "btrfs", "filesystem", "resize", strconv.FormatUint(uint64(change.NewSize), 10), change.mountPoint); err != nil {
+		return fmt.Errorf("cannot shrink filesystem of partition #%d: %v", change.DiskIndex, err)
+	}
+	return nil
+}
+
+func (plan *LayoutPlan) backupPath(rollbackDir string) string {
+	return filepath.Join(rollbackDir, plan.Volume+".sfdisk")
+}
+
+// backup saves the current partition table of the disk and the plan itself
+// in the rollback directory.
+func (plan *LayoutPlan) backup(rollbackDir string) error {
+	if err := os.MkdirAll(rollbackDir, 0750); err != nil {
+		return err
+	}
+	out, err := exec.Command("sfdisk", "--dump", plan.Device).Output()
+	if err != nil {
+		return fmt.Errorf("cannot dump partition table of %s: %v", plan.Device, osutil.OutputErr(out, err))
+	}
+	if err := osutil.AtomicWriteFile(plan.backupPath(rollbackDir), out, 0600, 0); err != nil {
+		return err
+	}
+	planData, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(layoutPlanPath(rollbackDir, plan.Volume), planData, 0600, 0)
+}
+
+func layoutPlanPath(rollbackDir, volume string) string {
+	return filepath.Join(rollbackDir, volume+"-layout.json")
+}
+
+// loadLayoutPlans loads the plans saved by backup in the rollback directory,
+// the partitions created by the plans are expected to have been added to the
+// kernel.
+func loadLayoutPlans(rollbackDir string) ([]*LayoutPlan, error) {
+	paths, err := filepath.Glob(layoutPlanPath(rollbackDir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	plans := make([]*LayoutPlan, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var plan LayoutPlan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %v", filepath.Base(path), err)
+		}
+		for _, change := range plan.Changes {
+			if change.Kind == LayoutChangeCreate {
+				plan.added = append(plan.added, change.DiskIndex)
+			}
+		}
+		plans = append(plans, &plan)
+	}
+	return plans, nil
+}
+
+// restore writes back the partition table saved by backup. The partitions
+// created by the plan are removed from the kernel, which is told about the
+// original size of the other partitions.
+func (plan *LayoutPlan) restore(rollbackDir string) error {
+	dump, err := os.ReadFile(plan.backupPath(rollbackDir))
+	if err != nil {
+		return err
+	}
+	if err := runLayoutCommand(string(dump), "sfdisk", "--no-reread", "--no-tell-kernel", plan.Device); err != nil {
+		return err
+	}
+	for len(plan.added) > 0 {
+		diskIndex := plan.added[len(plan.added)-1]
+		if err := runLayoutCommand("", "partx", "-d", "--nr", strconv.Itoa(diskIndex), plan.Device); err != nil {
+			return fmt.Errorf("cannot remove partition #%d from the kernel: %v", diskIndex, err)
+		}
+		plan.added = plan.added[:len(plan.added)-1]
+	}
+	return runLayoutCommand("", "partx", "-u", plan.Device)
+}
+
+// apply carries out the layout changes. The partition table is backed up in
+// the rollback directory first and restored if changing the partitions fails.
+// Filesystems are grown last, once all partitions are in place, as restoring
+// the partition table is only safe while no filesystem has been grown.
+func (plan *LayoutPlan) apply(rollbackDir string) (err error) {
+	if err := plan.backup(rollbackDir); err != nil {
+		return fmt.Errorf("cannot back up partition table: %v", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if errRestore := plan.restore(rollbackDir); errRestore != nil {
+			logger.Noticef("cannot restore partition table of %s: %v", plan.Device, errRestore)
+		}
+	}()
+
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		switch change.Kind {
+		case LayoutChangeShrink:
+			if err := shrinkFilesystem(change); err != nil {
+				return err
+			}
+			if err := plan.changePartition(change); err != nil {
+				return err
+			}
+		case LayoutChangeGrow:
+			if err := plan.changePartition(change); err != nil {
+				return err
+			}
+		case LayoutChangeCreate:
+			if err := plan.changePartition(change); err != nil {
+				return err
+			}
+			if out, err := exec.Command("udevadm", "settle", "--timeout=180").CombinedOutput(); err != nil {
+				return fmt.Errorf("cannot wait for udev to settle: %v", osutil.OutputErr(out, err))
+			}
+			if change.structure.HasFilesystem() && change.Filesystem != "" {
+				if err := mkfsMake(change.structure.Filesystem, change.node, change.structure.Label, change.NewSize, plan.sectorSize); err != nil {
+					return fmt.Errorf("cannot create filesystem on partition #%d: %v", change.DiskIndex, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// growsFilesystems returns whether carrying out the plan grows filesystems,
+// after which the saved partition table can no longer be restored.
+func (plan *LayoutPlan) growsFilesystems() bool {
+	for _, change := range plan.Changes {
+		if change.Kind == LayoutChangeGrow && change.Filesystem != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// growFilesystems grows the filesystems of grown partitions to fill them.
+func (plan *LayoutPlan) growFilesystems() error {
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		if change.Kind != LayoutChangeGrow {
+			continue
+		}
+		if err := growFilesystem(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyLayoutPlans carries out the layout plans of all volumes. Once the
+// partition tables have been changed, the filesystems of the grown partitions
+// are grown. A failure to grow a filesystem leaves it smaller than its
+// partition, which is safe.
+func applyLayoutPlans(plans []*LayoutPlan, rollbackDir string) error {
+	for _, plan := range plans {
+		for _, change := range plan.Changes {
+			logger.Noticef("gadget update will %s on volume %s (%s)", change.String(), plan.Volume, plan.Device)
+		}
+	}
+	for i, plan := range plans {
+		if err := plan.apply(rollbackDir); err != nil {
+			// restore the partition tables changed so far
+			for _, applied := range plans[:i] {
+				if errRestore := applied.restore(rollbackDir); errRestore != nil {
+					logger.Noticef("cannot restore partition table of %s: %v", applied.Device, errRestore)
+				}
+			}
+			return fmt.Errorf("cannot change partition layout of volume %s: %v", plan.Volume, err)
+		}
+	}
+	for _, plan := range plans {
+		if err := plan.growFilesystems(); err != nil {
+			return fmt.Errorf("cannot change partition layout of volume %s: %v", plan.Volume, err)
+		}
+	}
+	return nil
+}
+
+// restoreLayoutPlans restores the partition tables changed by the given plans
+// after a later step of the gadget update failed. The plans which grew
+// filesystems cannot be reverted, their volumes are returned so that the
+// failure can report them.
+func restoreLayoutPlans(plans []*LayoutPlan, rollbackDir string) (kept []string) {
+	for _, plan := range plans {
+		if plan.growsFilesystems() {
+			kept = append(kept, plan.Volume)
+			continue
+		}
+		if err := plan.restore(rollbackDir); err != nil {
+			logger.Noticef("cannot restore partition table of %s: %v", plan.Device, err)
+			kept = append(kept, plan.Volume)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,466 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package gadget_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type updateLayoutTestSuite struct {
+	testutil.BaseTest
+
+	diskVol *gadget.OnDiskVolume
+}
+
+var _ = Suite(&updateLayoutTestSuite{})
+
+func (s *updateLayoutTestSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.diskVol = &gadget.OnDiskVolume{
+		Device:     "/dev/vda",
+		Schema:     "gpt",
+		SectorSize: 512,
+		// 2 GiB disk, minus the backup GPT header
+		Size:             2 * quantity.SizeGiB,
+		UsableSectorsEnd: uint64(2*quantity.SizeGiB/512) - 33,
+		Structure: []gadget.OnDiskStructure{
+			{
+				Name:            "ubuntu-seed",
+				PartitionFSType: "vfat",
+				Type:            "C12A7328-F81F-11D2-BA4B-00A0C93EC93B",
+				StartOffset:     quantity.OffsetMiB,
+				Size:            100 * quantity.SizeMiB,
+				DiskIndex:       1,
+				Node:            "/dev/vda1",
+			},
+			{
+				Name:            "ubuntu-boot",
+				PartitionFSType: "ext4",
+				Type:            "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+				StartOffset:     101 * quantity.OffsetMiB,
+				Size:            50 * quantity.SizeMiB,
+				DiskIndex:       2,
+				Node:            "/dev/vda2",
+			},
+			{
+				Name:            "ubuntu-data",
+				PartitionFSType: "btrfs",
+				Type:            "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+				StartOffset:     301 * quantity.OffsetMiB,
+				Size:            1024 * quantity.SizeMiB,
+				DiskIndex:       3,
+				Node:            "/dev/vda3",
+			},
+		},
+	}
+	s.AddCleanup(gadget.MockOnDiskVolumeForStructure(func(node string) (*gadget.OnDiskVolume, error) {
+		c.Check(node, Matches, "/dev/vda[0-9]")
+		return s.diskVol, nil
+	}))
+}
+
+func offsetPtr(o quantity.Offset) *quantity.Offset {
+	return &o
+}
+
+// layoutVolume returns a volume with ubuntu-seed, ubuntu-boot and ubuntu-data
+// laid out as on the mocked disk, with the given ubuntu-boot size.
+func layoutVolume(bootSize quantity.Size, extra ...gadget.VolumeStructure) *gadget.Volume {
+	vol := &gadget.Volume{
+		Name:   "pc",
+		Schema: "gpt",
+		Structure: []gadget.VolumeStructure{
+			{
+				VolumeName: "pc",
+				Name:       "ubuntu-seed",
+				Role:       gadget.SystemSeed,
+				Filesystem: "vfat",
+				Type:       "C12A7328-F81F-11D2-BA4B-00A0C93EC93B",
+				Offset:     offsetPtr(quantity.OffsetMiB),
+				MinSize:    100 * quantity.SizeMiB,
+				Size:       100 * quantity.SizeMiB,
+				YamlIndex:  0,
+			},
+			{
+				VolumeName: "pc",
+				Name:       "ubuntu-boot",
+				Role:       gadget.SystemBoot,
+				Filesystem: "ext4",
+				Type:       "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+				Offset:     offsetPtr(101 * quantity.OffsetMiB),
+				MinSize:    bootSize,
+				Size:       bootSize,
+				YamlIndex:  1,
+			},
+			{
+				VolumeName: "pc",
+				Name:       "ubuntu-data",
+				Role:       gadget.SystemData,
+				Filesystem: "btrfs",
+				Type:       "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+				Offset:     offsetPtr(301 * quantity.OffsetMiB),
+				MinSize:    500 * quantity.SizeMiB,
+				Size:       500 * quantity.SizeMiB,
+				YamlIndex:  2,
+			},
+		},
+	}
+	vol.Structure = append(vol.Structure, extra...)
+	for i := range vol.Structure {
+		vol.Structure[i].EnclosingVolume = vol
+	}
+	return vol
+}
+
+func (s *updateLayoutTestSuite) gadgetToDisk() map[int]*gadget.OnDiskStructure {
+	m := make(map[int]*gadget.OnDiskStructure)
+	for i := range s.diskVol.Structure {
+		m[i] = &s.diskVol.Structure[i]
+	}
+	return m
+}
+
+var layoutLocations = map[int]gadget.StructureLocation{
+	0: {RootMountPoint: "/run/mnt/ubuntu-seed"},
+	1: {RootMountPoint: "/run/mnt/ubuntu-boot"},
+	2: {RootMountPoint: "/run/mnt/data"},
+}
+
+var extraStructure = gadget.VolumeStructure{
+	VolumeName: "pc",
+	Name:       "extra",
+	Filesystem: "ext4",
+	Type:       "0FC63DAF-8483-4772-8E79-3D69D8477DE4",
+	Offset:     offsetPtr(1025 * quantity.OffsetMiB),
+	MinSize:    200 * quantity.SizeMiB,
+	Size:       200 * quantity.SizeMiB,
+	YamlIndex:  3,
+}
+
+func (s *updateLayoutTestSuite) TestCanGrowStructure(c *C) {
+	for _, tc := range []struct {
+		vs  gadget.VolumeStructure
+		err string
+	}{
+		{gadget.VolumeStructure{Type: "83", Filesystem: "ext4"}, ""},
+		{gadget.VolumeStructure{Type: "83", Filesystem: "btrfs"}, ""},
+		{gadget.VolumeStructure{Type: "83", Filesystem: "xfs"}, ""},
+		{gadget.VolumeStructure{Type: "83"}, ""},
+		{gadget.VolumeStructure{Type: "83", Filesystem: "vfat"}, "cannot grow vfat filesystem while in use"},
+		{gadget.VolumeStructure{Type: "bare"}, "only partitions can be grown"},
+		{gadget.VolumeStructure{Type: "mbr", Role: "mbr"}, "only partitions can be grown"},
+	} {
+		err := gadget.CanGrowStructure(&tc.vs)
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%+v", tc.vs))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%+v", tc.vs))
+		}
+	}
+}
+
+func (s *updateLayoutTestSuite) TestCanAppendStructures(c *C) {
+	from := layoutVolume(50 * quantity.SizeMiB)
+	c.Check(gadget.CanAppendStructures(from, layoutVolume(50*quantity.SizeMiB)), IsNil)
+	c.Check(gadget.CanAppendStructures(from, layoutVolume(50*quantity.SizeMiB, extraStructure)), IsNil)
+
+	bare := extraStructure
+	bare.Type = "bare"
+	bare.Filesystem = ""
+	c.Check(gadget.CanAppendStructures(from, layoutVolume(50*quantity.SizeMiB, bare)), ErrorMatches,
+		`cannot add structure "extra": only partitions can be added`)
+
+	withRole := extraStructure
+	withRole.Role = gadget.SystemSave
+	c.Check(gadget.CanAppendStructures(from, layoutVolume(50*quantity.SizeMiB, withRole)), ErrorMatches,
+		`cannot add structure "extra" with role "system-save"`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesNothingToDo(c *C) {
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+	c.Check(plan, IsNil)
+
+	// not mapped to a disk
+	plan, err = gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(150*quantity.SizeMiB), nil, layoutLocations)
+	c.Assert(err, IsNil)
+	c.Check(plan, IsNil)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesGrow(c *C) {
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(150*quantity.SizeMiB), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+	c.Assert(plan, NotNil)
+	c.Check(plan.Volume, Equals, "pc")
+	c.Check(plan.Device, Equals, "/dev/vda")
+	c.Assert(plan.Changes, HasLen, 1)
+	c.Check(plan.Changes[0].Kind, Equals, gadget.LayoutChangeGrow)
+	c.Check(plan.Changes[0].Name, Equals, "ubuntu-boot")
+	c.Check(plan.Changes[0].DiskIndex, Equals, 2)
+	c.Check(plan.Changes[0].StartOffset, Equals, 101*quantity.OffsetMiB)
+	c.Check(plan.Changes[0].OldSize, Equals, 50*quantity.SizeMiB)
+	c.Check(plan.Changes[0].NewSize, Equals, 150*quantity.SizeMiB)
+	c.Check(plan.Changes[0].Filesystem, Equals, "ext4")
+	c.Check(plan.Changes[0].String(), Equals, `grow partition #2 ("ubuntu-boot") from 50 MiB to 150 MiB`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesGrowNoSpace(c *C) {
+	_, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(250*quantity.SizeMiB), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, ErrorMatches, `cannot grow structure "ubuntu-boot" to 250 MiB: only 150 MiB of free space follows it`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesAppendShrinksData(c *C) {
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extraStructure), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+	c.Assert(plan, NotNil)
+	c.Assert(plan.Changes, HasLen, 2)
+	// system-data is shrunk first
+	c.Check(plan.Changes[0].Kind, Equals, gadget.LayoutChangeShrink)
+	c.Check(plan.Changes[0].Name, Equals, "ubuntu-data")
+	c.Check(plan.Changes[0].OldSize, Equals, 1024*quantity.SizeMiB)
+	c.Check(plan.Changes[0].NewSize, Equals, 724*quantity.SizeMiB)
+	c.Check(plan.Changes[1].Kind, Equals, gadget.LayoutChangeCreate)
+	c.Check(plan.Changes[1].Name, Equals, "extra")
+	c.Check(plan.Changes[1].DiskIndex, Equals, 4)
+	c.Check(plan.Changes[1].StartOffset, Equals, 1025*quantity.OffsetMiB)
+	c.Check(plan.Changes[1].NewSize, Equals, 200*quantity.SizeMiB)
+	c.Check(plan.Changes[1].String(), Equals, `create partition #4 ("extra") at offset 1074790400 with size 200 MiB`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesAppendDataNotShrinkable(c *C) {
+	s.diskVol.Structure[2].PartitionFSType = "ext4"
+	_, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extraStructure), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, ErrorMatches, `cannot shrink ext4 filesystem of structure "ubuntu-data" while in use`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesAppendDataTooSmall(c *C) {
+	extra := extraStructure
+	extra.Offset = offsetPtr(501 * quantity.OffsetMiB)
+	_, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extra), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, ErrorMatches, `cannot shrink structure "ubuntu-data" to 200 MiB: smaller than its minimum size 500 MiB`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesAppendDoesNotFit(c *C) {
+	extra := extraStructure
+	extra.Offset = offsetPtr(1900 * quantity.OffsetMiB)
+	_, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extra), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, ErrorMatches, `cannot add structure "extra": it does not fit on the disk`)
+}
+
+func (s *updateLayoutTestSuite) TestPlanLayoutChangesAppendOverlap(c *C) {
+	extra := extraStructure
+	extra.Offset = offsetPtr(121 * quantity.OffsetMiB)
+	_, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extra), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, ErrorMatches, `cannot add structure "extra": it overlaps with partition #2 \("ubuntu-boot"\)`)
+}
+
+func (s *updateLayoutTestSuite) mockLayoutCommands(c *C, sfdiskFailsOn string) (sfdisk, partx, resize2fs, btrfs, udevadm *testutil.MockCmd, stdinLog string) {
+	stdinLog = filepath.Join(c.MkDir(), "sfdisk-stdin")
+	sfdisk = testutil.MockCommand(c, "sfdisk", `
+if [ "$1" = "--dump" ]; then
+    echo "label: gpt"
+    echo "device: /dev/vda"
+    exit 0
+fi
+cat >> `+stdinLog+`
+if [ -n "`+sfdiskFailsOn+`" ] && [ "$3" = "`+sfdiskFailsOn+`" ]; then
+    echo "sfdisk failed" >&2
+    exit 1
+fi
+`)
+	s.AddCleanup(sfdisk.Restore)
+	partx = testutil.MockCommand(c, "partx", "")
+	s.AddCleanup(partx.Restore)
+	resize2fs = testutil.MockCommand(c, "resize2fs", "")
+	s.AddCleanup(resize2fs.Restore)
+	btrfs = testutil.MockCommand(c, "btrfs", "")
+	s.AddCleanup(btrfs.Restore)
+	udevadm = testutil.MockCommand(c, "udevadm", "")
+	s.AddCleanup(udevadm.Restore)
+	return sfdisk, partx, resize2fs, btrfs, udevadm, stdinLog
+}
+
+func (s *updateLayoutTestSuite) TestApplyLayoutPlansHappy(c *C) {
+	sfdisk, partx, resize2fs, btrfs, udevadm, stdinLog := s.mockLayoutCommands(c, "")
+	var mkfsCalls []string
+	s.AddCleanup(gadget.MockMkfsMake(func(typ, img, label string, deviceSize, sectorSize quantity.Size) error {
+		c.Check(deviceSize, Equals, 200*quantity.SizeMiB)
+		c.Check(sectorSize, Equals, quantity.Size(512))
+		mkfsCalls = append(mkfsCalls, typ+":"+img+":"+label)
+		return nil
+	}))
+
+	newVol := layoutVolume(150*quantity.SizeMiB, extraStructure)
+	newVol.Structure[3].Label = "extra"
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), newVol, s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+	c.Assert(plan.Changes, HasLen, 3)
+
+	rollbackDir := c.MkDir()
+	err = gadget.ApplyLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Assert(err, IsNil)
+
+	c.Check(sfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--dump", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "-N", "3", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "-N", "2", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "--append", "/dev/vda"},
+	})
+	c.Check(stdinLog, testutil.FileEquals, ""+
+		"start=616448, size=1482752\n"+
+		"start=206848, size=307200\n"+
+		"start=2099200, size=409600, type=0FC63DAF-8483-4772-8E79-3D69D8477DE4, name=\"extra\"\n")
+	c.Check(partx.Calls(), DeepEquals, [][]string{
+		{"partx", "-u", "--nr", "3", "/dev/vda"},
+		{"partx", "-u", "--nr", "2", "/dev/vda"},
+		{"partx", "-a", "--nr", "4", "/dev/vda"},
+	})
+	c.Check(btrfs.Calls(), DeepEquals, [][]string{
+		{"btrfs", "filesystem", "resize", "759169024", "/run/mnt/data"},
+	})
+	c.Check(udevadm.Calls(), DeepEquals, [][]string{
+		{"udevadm", "settle", "--timeout=180"},
+	})
+	c.Check(mkfsCalls, DeepEquals, []string{"ext4:/dev/vda4:extra"})
+	// filesystems are grown once the partitions are in place
+	c.Check(resize2fs.Calls(), DeepEquals, [][]string{
+		{"resize2fs", "/dev/vda2"},
+	})
+
+	// the partition table and the plan are kept for rollback
+	c.Check(filepath.Join(rollbackDir, "pc.sfdisk"), testutil.FileEquals, "label: gpt\ndevice: /dev/vda\n")
+	data, err := os.ReadFile(filepath.Join(rollbackDir, "pc-layout.json"))
+	c.Assert(err, IsNil)
+	var saved gadget.LayoutPlan
+	c.Assert(json.Unmarshal(data, &saved), IsNil)
+	c.Check(saved.Volume, Equals, "pc")
+	c.Check(saved.Device, Equals, "/dev/vda")
+	c.Assert(saved.Changes, HasLen, 3)
+	c.Check(saved.Changes[2].Kind, Equals, gadget.LayoutChangeCreate)
+}
+
+func (s *updateLayoutTestSuite) TestApplyLayoutPlansRestoresOnError(c *C) {
+	sfdisk, partx, resize2fs, _, _, _ := s.mockLayoutCommands(c, "--append")
+	s.AddCleanup(gadget.MockMkfsMake(func(typ, img, label string, deviceSize, sectorSize quantity.Size) error {
+		return errors.New("unexpected call")
+	}))
+
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(150*quantity.SizeMiB, extraStructure), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+
+	rollbackDir := c.MkDir()
+	err = gadget.ApplyLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Assert(err, ErrorMatches, `cannot change partition layout of volume pc: cannot create partition #4: sfdisk failed`)
+
+	c.Check(sfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--dump", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "-N", "3", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "-N", "2", "/dev/vda"},
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "--append", "/dev/vda"},
+		// the saved partition table is restored
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "/dev/vda"},
+	})
+	c.Check(partx.Calls(), DeepEquals, [][]string{
+		{"partx", "-u", "--nr", "3", "/dev/vda"},
+		{"partx", "-u", "--nr", "2", "/dev/vda"},
+		{"partx", "-u", "/dev/vda"},
+	})
+	// no filesystem was grown
+	c.Check(resize2fs.Calls(), HasLen, 0)
+}
+
+func (s *updateLayoutTestSuite) TestRestoreLayoutPlans(c *C) {
+	sfdisk, partx, _, _, _, _ := s.mockLayoutCommands(c, "")
+	s.AddCleanup(gadget.MockMkfsMake(func(typ, img, label string, deviceSize, sectorSize quantity.Size) error {
+		return nil
+	}))
+
+	// shrinking ubuntu-data and adding a partition can be reverted
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extraStructure), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+
+	rollbackDir := c.MkDir()
+	err = gadget.ApplyLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Assert(err, IsNil)
+	sfdisk.ForgetCalls()
+	partx.ForgetCalls()
+
+	kept := gadget.RestoreLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Check(kept, HasLen, 0)
+	c.Check(sfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "/dev/vda"},
+	})
+	// the created partition is removed from the kernel
+	c.Check(partx.Calls(), DeepEquals, [][]string{
+		{"partx", "-d", "--nr", "4", "/dev/vda"},
+		{"partx", "-u", "/dev/vda"},
+	})
+}
+
+func (s *updateLayoutTestSuite) TestRestoreLayoutPlansRemovePartitionError(c *C) {
+	_, partx, _, _, _, _ := s.mockLayoutCommands(c, "")
+	s.AddCleanup(gadget.MockMkfsMake(func(typ, img, label string, deviceSize, sectorSize quantity.Size) error {
+		return nil
+	}))
+
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(50*quantity.SizeMiB, extraStructure), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+
+	rollbackDir := c.MkDir()
+	err = gadget.ApplyLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Assert(err, IsNil)
+
+	partx = testutil.MockCommand(c, "partx", `if [ "$1" = "-d" ]; then exit 1; fi`)
+	defer partx.Restore()
+	kept := gadget.RestoreLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Check(kept, DeepEquals, []string{"pc"})
+	c.Check(partx.Calls(), DeepEquals, [][]string{
+		{"partx", "-d", "--nr", "4", "/dev/vda"},
+	})
+}
+
+func (s *updateLayoutTestSuite) TestRestoreLayoutPlansGrownFilesystemKept(c *C) {
+	sfdisk, _, resize2fs, _, _, _ := s.mockLayoutCommands(c, "")
+
+	plan, err := gadget.PlanLayoutChanges(layoutVolume(50*quantity.SizeMiB), layoutVolume(150*quantity.SizeMiB), s.gadgetToDisk(), layoutLocations)
+	c.Assert(err, IsNil)
+
+	rollbackDir := c.MkDir()
+	err = gadget.ApplyLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Assert(err, IsNil)
+	c.Check(resize2fs.Calls(), HasLen, 1)
+	sfdisk.ForgetCalls()
+
+	// the ext4 filesystem of ubuntu-boot was grown, restoring the old
+	// partition table would cut it off
+	kept := gadget.RestoreLayoutPlans([]*gadget.LayoutPlan{plan}, rollbackDir)
+	c.Check(kept, DeepEquals, []string{"pc"})
+	c.Check(sfdisk.Calls(), HasLen, 0)
+}
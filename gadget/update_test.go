@@ -790,6 +790,116 @@ func (u *updateTestSuite) TestUpdateApplyHappy(c *C) {
 	c.Assert(muo.canceledCalled, Equals, 0)
 }
 
+func (u *updateTestSuite) TestRollbackUpdateHappy(c *C) {
+	oldData, newData, rollbackDir := u.updateDataSet(c)
+	newData.Info.Volumes["foo"].Structure[0].Update.Edition = 1
+	newData.Info.Volumes["foo"].Structure[1].Update.Edition = 1
+
+	r := gadget.MockVolumeStructureToLocationMap(func(_ gadget.Model, oldVolumes, _ map[string]*gadget.Volume) (map[string]map[int]gadget.StructureLocation, map[string]map[int]*gadget.OnDiskStructure, error) {
+		return map[string]map[int]gadget.StructureLocation{
+				"foo": {
+					0: {
+						Device: "/dev/foo",
+					},
+					1: {
+						RootMountPoint: "/foo",
+					},
+					2: {
+						RootMountPoint: "/foo",
+					},
+				},
+			}, map[string]map[int]*gadget.OnDiskStructure{
+				"foo": gadget.OnDiskStructsFromGadget(oldVolumes["foo"]),
+			},
+			nil
+	})
+	defer r()
+
+	// the partition table and the layout plan saved by the update
+	c.Assert(os.WriteFile(filepath.Join(rollbackDir, "foo.sfdisk"), []byte("label: gpt\n"), 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(rollbackDir, "foo-layout.json"),
+		[]byte(`{"volume":"foo","device":"/dev/foo","changes":[{"kind":"create","name":"extra","disk-index":4,"start-offset":22020096,"new-size":1048576}]}`), 0600), IsNil)
+	sfdisk := testutil.MockCommand(c, "sfdisk", "")
+	defer sfdisk.Restore()
+	partx := testutil.MockCommand(c, "partx", "")
+	defer partx.Restore()
+
+	muo := &mockUpdateProcessObserver{}
+	rollbackCalls := make(map[string]bool)
+	restore := gadget.MockUpdaterForStructure(func(loc gadget.StructureLocation, fromPs, ps *gadget.LaidOutStructure, psRootDir, psRollbackDir string, observer gadget.ContentUpdateObserver) (gadget.Updater, error) {
+		c.Assert(psRootDir, Equals, newData.RootDir)
+		c.Assert(psRollbackDir, Equals, rollbackDir)
+		c.Assert(observer, Equals, muo)
+		return &mockUpdater{
+			rollbackCb: func() error {
+				// the content is restored before the partition table
+				c.Check(sfdisk.Calls(), HasLen, 0)
+				rollbackCalls[ps.Name()] = true
+				return nil
+			},
+		}, nil
+	})
+	defer restore()
+
+	err := gadget.RollbackUpdate(uc16Model, oldData, newData, rollbackDir, nil, muo)
+	c.Assert(err, IsNil)
+	c.Check(rollbackCalls, DeepEquals, map[string]bool{
+		"first":  true,
+		"second": true,
+	})
+	c.Check(muo.beforeWriteCalled, Equals, 0)
+	c.Check(muo.canceledCalled, Equals, 1)
+	c.Check(sfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--no-reread", "--no-tell-kernel", "/dev/foo"},
+	})
+	c.Check(partx.Calls(), DeepEquals, [][]string{
+		{"partx", "-d", "--nr", "4", "/dev/foo"},
+		{"partx", "-u", "/dev/foo"},
+	})
+}
+
+func (u *updateTestSuite) TestRollbackUpdateGrownFilesystemKept(c *C) {
+	oldData, newData, rollbackDir := u.updateDataSet(c)
+
+	r := gadget.MockVolumeStructureToLocationMap(func(_ gadget.Model, oldVolumes, _ map[string]*gadget.Volume) (map[string]map[int]gadget.StructureLocation, map[string]map[int]*gadget.OnDiskStructure, error) {
+		return map[string]map[int]gadget.StructureLocation{
+				"foo": {
+					0: {
+						Device: "/dev/foo",
+					},
+					1: {
+						RootMountPoint: "/foo",
+					},
+					2: {
+						RootMountPoint: "/foo",
+					},
+				},
+			}, map[string]map[int]*gadget.OnDiskStructure{
+				"foo": gadget.OnDiskStructsFromGadget(oldVolumes["foo"]),
+			},
+			nil
+	})
+	defer r()
+
+	c.Assert(os.WriteFile(filepath.Join(rollbackDir, "foo.sfdisk"), []byte("label: gpt\n"), 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(rollbackDir, "foo-layout.json"),
+		[]byte(`{"volume":"foo","device":"/dev/foo","changes":[{"kind":"grow","name":"second","disk-index":2,"start-offset":6291456,"old-size":10485760,"new-size":20971520,"filesystem":"ext4"}]}`), 0600), IsNil)
+	sfdisk := testutil.MockCommand(c, "sfdisk", "")
+	defer sfdisk.Restore()
+
+	restore := gadget.MockUpdaterForStructure(func(loc gadget.StructureLocation, fromPs, ps *gadget.LaidOutStructure, psRootDir, psRollbackDir string, observer gadget.ContentUpdateObserver) (gadget.Updater, error) {
+		c.Fatalf("unexpected call")
+		return nil, nil
+	})
+	defer restore()
+
+	// there were no content updates, the grown filesystem cannot be shrunk
+	// back
+	err := gadget.RollbackUpdate(uc16Model, oldData, newData, rollbackDir, nil, nil)
+	c.Assert(err, ErrorMatches, `cannot revert the partition layout changes of volumes "foo"`)
+	c.Check(sfdisk.Calls(), HasLen, 0)
+}
+
 func (u *updateTestSuite) TestUpdateApplyUC16FullLogic(c *C) {
 	u.restoreVolumeStructureToLocationMap()
 	oldData := gadget.GadgetData{
@@ -3065,6 +3175,8 @@ func (u *updateTestSuite) TestUpdateApplyErrorIllegalVolumeUpdate(c *C) {
 	bareStructUpdate.Name = "foo update"
 	bareStructUpdate.Update.Edition = 1
 	bareStructUpdate.Offset = asOffsetPtr(5 * quantity.OffsetMiB)
+	bareStructUpdate.YamlIndex = 1
+	bareStructUpdate.Type = "bare"
 
 	oldInfo := &gadget.Info{
 		Volumes: map[string]*gadget.Volume{
@@ -3110,7 +3222,7 @@ func (u *updateTestSuite) TestUpdateApplyErrorIllegalVolumeUpdate(c *C) {
 	makeSizedFile(c, filepath.Join(newRootDir, "first.img"), 900*quantity.SizeKiB, nil)
 
 	err := gadget.Update(uc16Model, oldData, newData, rollbackDir, nil, nil)
-	c.Assert(err, ErrorMatches, `cannot apply update to volume foo: cannot change the number of structures within volume from 1 to 2`)
+	c.Assert(err, ErrorMatches, `cannot apply update to volume foo: cannot add structure "foo update": only partitions can be added`)
 }
 
 func (u *updateTestSuite) TestUpdateApplyErrorIllegalStructureUpdate(c *C) {
@@ -0,0 +1,321 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/mkfs"
+)
+
+const (
+	// disk images are always built with 512 bytes sectors
+	diskImageSectorSize = quantity.Size(512)
+	// the backup GPT header and partition entries at the end of the disk
+	gptBackupSize = 33 * diskImageSectorSize
+)
+
+// diskImageFormats maps the supported disk image formats to the file name
+// suffix of the images.
+var diskImageFormats = map[string]string{
+	"raw":    ".img",
+	"qcow2":  ".qcow2",
+	"sparse": ".sparse.img",
+}
+
+func validateDiskImageFormats(formats []string) error {
+	for _, format := range formats {
+		if _, ok := diskImageFormats[format]; !ok {
+			return fmt.Errorf("cannot build disk image in unsupported format %q", format)
+		}
+	}
+	return nil
+}
+
+// var so that it can be mocked for tests
+var buildDiskImages = buildDiskImagesImpl
+
+// buildDiskImagesImpl lays out each of the volumes of the gadget unpacked in
+// the prepare directory into a raw disk image named after the volume, and
+// converts it to the requested formats. The image is expected to have been
+// fully prepared, and possibly preseeded, at this point.
+func buildDiskImagesImpl(model *asserts.Model, opts *Options) error {
+	gadgetUnpackDir := filepath.Join(opts.PrepareDir, "gadget")
+	kernelUnpackDir := filepath.Join(opts.PrepareDir, "kernel")
+
+	info, err := gadget.ReadInfoAndValidate(gadgetUnpackDir, model, nil)
+	if err != nil {
+		return err
+	}
+
+	volNames := make([]string, 0, len(info.Volumes))
+	for volName := range info.Volumes {
+		volNames = append(volNames, volName)
+	}
+	sort.Strings(volNames)
+
+	hasModes := model.Grade() != asserts.ModelGradeUnset
+	for _, volName := range volNames {
+		img := filepath.Join(opts.PrepareDir, volName+diskImageFormats["raw"])
+		vi := &volumeImage{
+			name:       volName,
+			vol:        info.Volumes[volName],
+			hasModes:   hasModes,
+			prepareDir: opts.PrepareDir,
+			gadgetDir:  gadgetUnpackDir,
+			kernelDir:  kernelUnpackDir,
+		}
+		if err := vi.write(img); err != nil {
+			return fmt.Errorf("cannot build disk image for volume %q: %v", volName, err)
+		}
+
+		keepRaw := false
+		for _, format := range opts.DiskImageFormats {
+			if format == "raw" {
+				keepRaw = true
+				continue
+			}
+			out := filepath.Join(opts.PrepareDir, volName+diskImageFormats[format])
+			if err := convertDiskImage(img, out, format); err != nil {
+				return fmt.Errorf("cannot convert disk image for volume %q to %s: %v", volName, format, err)
+			}
+		}
+		if !keepRaw {
+			if err := os.Remove(img); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// volumeImage describes how a gadget volume is written into a disk image.
+type volumeImage struct {
+	name       string
+	vol        *gadget.Volume
+	hasModes   bool
+	prepareDir string
+	gadgetDir  string
+	kernelDir  string
+}
+
+// included returns whether the structure is part of the disk image, on UC20+
+// the ubuntu-{boot,save,data} partitions are created when installing and are
+// left out.
+func (vi *volumeImage) included(ps *gadget.LaidOutStructure) bool {
+	return !(vi.hasModes && gadget.IsCreatableAtInstall(ps.VolumeStructure))
+}
+
+func (vi *volumeImage) write(img string) error {
+	opts := &gadget.LayoutOptions{
+		GadgetRootDir: vi.gadgetDir,
+		KernelRootDir: vi.kernelDir,
+	}
+	lv, err := gadget.LayoutVolume(vi.vol, gadget.OnDiskStructsFromGadget(vi.vol), opts)
+	if err != nil {
+		return err
+	}
+
+	structures := make([]gadget.LaidOutStructure, 0, len(lv.LaidOutStructure))
+	size := quantity.Size(0)
+	for i := range lv.LaidOutStructure {
+		ps := &lv.LaidOutStructure[i]
+		if !vi.included(ps) {
+			continue
+		}
+		structures = append(structures, *ps)
+		if end := quantity.Size(ps.StartOffset) + ps.Size; end > size {
+			size = end
+		}
+	}
+	if vi.vol.Schema != "mbr" {
+		size += gptBackupSize
+	}
+
+	f, err := os.OpenFile(img, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(int64(size)); err != nil {
+		return err
+	}
+
+	if err := writePartitionTable(img, vi.vol, structures); err != nil {
+		return err
+	}
+
+	for i := range lv.LaidOutStructure {
+		ps := &lv.LaidOutStructure[i]
+		if !vi.included(ps) {
+			continue
+		}
+		if ps.HasFilesystem() {
+			err = vi.writeFilesystemStructure(f, img, i, ps)
+		} else {
+			err = vi.writeRawStructure(f, ps)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot write structure %v: %v", ps, err)
+		}
+	}
+	return f.Sync()
+}
+
+func (vi *volumeImage) writeRawStructure(f *os.File, ps *gadget.LaidOutStructure) error {
+	rw, err := gadget.NewRawStructureWriter(vi.gadgetDir, ps)
+	if err != nil {
+		return err
+	}
+	return rw.Write(f)
+}
+
+// contentDir returns the directory to populate the filesystem of the
+// structure with the given index in the laid out volume, as prepared by
+// writeResolvedContent.
+func (vi *volumeImage) contentDir(idx int, ps *gadget.LaidOutStructure) (string, error) {
+	if !vi.hasModes && ps.Role() == gadget.SystemData {
+		// Core 16/18, the writable partition
+		return filepath.Join(vi.prepareDir, "image"), nil
+	}
+	// ubuntu-image uses the "part{}" nomenclature
+	dir := filepath.Join(vi.prepareDir, "resolved-content", vi.name, fmt.Sprintf("part%d", idx))
+	if !osutil.IsDirectory(dir) {
+		return "", nil
+	}
+	// the system-seed content is a symlink to <PrepareDir>/system-seed
+	return filepath.EvalSymlinks(dir)
+}
+
+func (vi *volumeImage) writeFilesystemStructure(f *os.File, img string, idx int, ps *gadget.LaidOutStructure) error {
+	contentDir, err := vi.contentDir(idx, ps)
+	if err != nil {
+		return err
+	}
+
+	fsImg := fmt.Sprintf("%s.part%d", img, idx)
+	defer os.Remove(fsImg)
+	if err := osutil.AtomicWriteFile(fsImg, nil, 0644, 0); err != nil {
+		return err
+	}
+	if err := os.Truncate(fsImg, int64(ps.Size)); err != nil {
+		return err
+	}
+	if err := mkfs.MakeWithContent(ps.Filesystem(), fsImg, ps.Label(), contentDir, ps.Size, diskImageSectorSize); err != nil {
+		return err
+	}
+
+	in, err := os.Open(fsImg)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err := f.Seek(int64(ps.StartOffset), io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.CopyN(f, in, int64(ps.Size))
+	return err
+}
+
+// partitionType returns the type of the partition for the given schema, out
+// of a possibly hybrid <mbr>,<guid> type.
+func partitionType(schema, ptype string) string {
+	t := strings.Split(ptype, ",")
+	if len(t) == 2 && schema != "mbr" {
+		return t[1]
+	}
+	return t[0]
+}
+
+// writePartitionTable writes the partition table of the volume into the disk
+// image using sfdisk, which does not require any privileges for regular
+// files.
+func writePartitionTable(img string, vol *gadget.Volume, structures []gadget.LaidOutStructure) error {
+	buf := &bytes.Buffer{}
+	label := "gpt"
+	if vol.Schema == "mbr" {
+		label = "dos"
+	}
+	fmt.Fprintf(buf, "label: %s\n", label)
+	if vol.ID != "" {
+		id := vol.ID
+		if label == "dos" {
+			id = "0x" + id
+		}
+		fmt.Fprintf(buf, "label-id: %s\n", id)
+	}
+	fmt.Fprintf(buf, "unit: sectors\n\n")
+
+	partitions := 0
+	for _, ps := range structures {
+		if !ps.IsPartition() {
+			continue
+		}
+		partitions++
+		fmt.Fprintf(buf, "start=%d, size=%d, type=%s", uint64(ps.StartOffset)/uint64(diskImageSectorSize),
+			uint64(ps.Size)/uint64(diskImageSectorSize), partitionType(vol.Schema, ps.Type()))
+		if label == "gpt" {
+			fmt.Fprintf(buf, ", name=%q", ps.Name())
+			if ps.VolumeStructure.ID != "" {
+				fmt.Fprintf(buf, ", uuid=%s", ps.VolumeStructure.ID)
+			}
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+	if partitions == 0 {
+		return nil
+	}
+
+	cmd := exec.Command("sfdisk", "--no-reread", "--no-tell-kernel", img)
+	cmd.Stdin = buf
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cannot write partition table: %v", osutil.OutputErr(output, err))
+	}
+	return nil
+}
+
+// convertDiskImage converts the raw disk image into the given format.
+func convertDiskImage(raw, out, format string) error {
+	var cmd *exec.Cmd
+	switch format {
+	case "qcow2":
+		cmd = exec.Command("qemu-img", "convert", "-f", "raw", "-O", "qcow2", raw, out)
+	case "sparse":
+		// Android sparse image
+		cmd = exec.Command("img2simg", raw, out)
+	default:
+		return fmt.Errorf("internal error: unsupported disk image format %q", format)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return osutil.OutputErr(output, err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package image_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/image/preseed"
+	"github.com/snapcore/snapd/store/tooling"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const pcUC20DiskImageGadgetYaml = `
+volumes:
+  pc:
+    bootloader: grub
+    structure:
+      - name: mbr
+        type: mbr
+        size: 440
+        content:
+          - image: pc-boot.img
+      - name: BIOS Boot
+        type: DA,21686148-6449-6E6F-744E-656564454649
+        size: 1M
+        offset: 1M
+        content:
+          - image: pc-core.img
+      - name: ubuntu-seed
+        role: system-seed
+        filesystem: vfat
+        type: EF,C12A7328-F81F-11D2-BA4B-00A0C93EC93B
+        size: 10M
+      - name: ubuntu-boot
+        role: system-boot
+        filesystem: ext4
+        type: 83,0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 10M
+      - name: ubuntu-data
+        role: system-data
+        filesystem: ext4
+        type: 83,0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 10M
+`
+
+func (s *imageSuite) TestPrepareWithDiskImages(c *C) {
+	var calls []string
+	restore := image.MockSetupSeed(func(tsto *tooling.ToolingStore, model *asserts.Model, opts *image.Options) error {
+		calls = append(calls, "setup-seed")
+		return nil
+	})
+	defer restore()
+	restore = image.MockPreseedCore20(func(opts *preseed.CoreOptions) error {
+		calls = append(calls, "preseed")
+		return nil
+	})
+	defer restore()
+	restore = image.MockBuildDiskImages(func(model *asserts.Model, opts *image.Options) error {
+		calls = append(calls, "build-disk-images")
+		c.Check(opts.DiskImageFormats, DeepEquals, []string{"raw", "qcow2"})
+		return nil
+	})
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+	fn := filepath.Join(c.MkDir(), "model.assertion")
+	c.Assert(os.WriteFile(fn, asserts.Encode(model), 0644), IsNil)
+
+	err := image.Prepare(&image.Options{
+		ModelFile:        fn,
+		Preseed:          true,
+		PrepareDir:       "/a/dir",
+		DiskImageFormats: []string{"raw", "qcow2"},
+	})
+	c.Assert(err, IsNil)
+	// disk images are built out of the preseeded image
+	c.Check(calls, DeepEquals, []string{"setup-seed", "preseed", "build-disk-images"})
+}
+
+func (s *imageSuite) TestPrepareWithDiskImagesErrors(c *C) {
+	restore := image.MockSetupSeed(func(tsto *tooling.ToolingStore, model *asserts.Model, opts *image.Options) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	err := image.Prepare(&image.Options{
+		Classic:          true,
+		PrepareDir:       "/a/dir",
+		DiskImageFormats: []string{"raw"},
+	})
+	c.Check(err, ErrorMatches, `cannot build disk images for a classic model`)
+
+	model := s.makeUC20Model(nil)
+	fn := filepath.Join(c.MkDir(), "model.assertion")
+	c.Assert(os.WriteFile(fn, asserts.Encode(model), 0644), IsNil)
+	err = image.Prepare(&image.Options{
+		ModelFile:        fn,
+		PrepareDir:       "/a/dir",
+		DiskImageFormats: []string{"raw", "vmdk"},
+	})
+	c.Check(err, ErrorMatches, `cannot build disk image in unsupported format "vmdk"`)
+
+	restore = image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+	uc18Model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"architecture": "amd64",
+		"gadget":       "pc18",
+		"kernel":       "pc-kernel",
+		"base":         "core18",
+	})
+	fn = filepath.Join(c.MkDir(), "model.assertion")
+	c.Assert(os.WriteFile(fn, asserts.Encode(uc18Model), 0644), IsNil)
+	err = image.Prepare(&image.Options{
+		ModelFile:        fn,
+		PrepareDir:       "/a/dir",
+		DiskImageFormats: []string{"raw"},
+	})
+	c.Check(err, ErrorMatches, `cannot build disk images for older base than core20`)
+}
+
+func (s *imageSuite) TestPrepareWithDiskImagesPreseedNeedsRoot(c *C) {
+	restore := image.MockOsGetuid(func() int { return 1000 })
+	defer restore()
+	restore = image.MockSetupSeed(func(tsto *tooling.ToolingStore, model *asserts.Model, opts *image.Options) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+	var built bool
+	restore = image.MockBuildDiskImages(func(model *asserts.Model, opts *image.Options) error {
+		built = true
+		return nil
+	})
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+	fn := filepath.Join(c.MkDir(), "model.assertion")
+	c.Assert(os.WriteFile(fn, asserts.Encode(model), 0644), IsNil)
+
+	err := image.Prepare(&image.Options{
+		ModelFile:        fn,
+		Preseed:          true,
+		PrepareDir:       "/a/dir",
+		DiskImageFormats: []string{"raw"},
+	})
+	c.Assert(err, ErrorMatches, `cannot preseed the image without root`)
+	c.Check(built, Equals, false)
+}
+
+func (s *imageSuite) makePreparedUC20Dir(c *C) string {
+	prepareDir := c.MkDir()
+	gadgetDir := filepath.Join(prepareDir, "gadget")
+	for _, f := range [][]string{
+		{"meta/gadget.yaml", pcUC20DiskImageGadgetYaml},
+		{"pc-boot.img", "pc-boot"},
+		{"pc-core.img", "pc-core"},
+		{"grub.conf", ""},
+	} {
+		p := filepath.Join(gadgetDir, f[0])
+		c.Assert(os.MkdirAll(filepath.Dir(p), 0755), IsNil)
+		c.Assert(os.WriteFile(p, []byte(f[1]), 0644), IsNil)
+	}
+	c.Assert(os.MkdirAll(filepath.Join(prepareDir, "kernel"), 0755), IsNil)
+
+	// as done by writeResolvedContent
+	seedDir := filepath.Join(prepareDir, "system-seed")
+	c.Assert(os.MkdirAll(filepath.Join(seedDir, "systems", "20260101"), 0755), IsNil)
+	resolvedDir := filepath.Join(prepareDir, "resolved-content", "pc")
+	c.Assert(os.MkdirAll(resolvedDir, 0755), IsNil)
+	c.Assert(os.Symlink(seedDir, filepath.Join(resolvedDir, "part2")), IsNil)
+	return prepareDir
+}
+
+func (s *imageSuite) TestBuildDiskImagesUC20(c *C) {
+	prepareDir := s.makePreparedUC20Dir(c)
+
+	sfdiskInput := filepath.Join(c.MkDir(), "sfdisk-input")
+	sfdisk := testutil.MockCommand(c, "sfdisk", fmt.Sprintf(`cat > %s`, sfdiskInput))
+	defer sfdisk.Restore()
+	// mark the filesystem so that it can be found in the disk image
+	mkfsVfat := testutil.MockCommand(c, "mkfs.vfat", `printf FATFS | dd of="${@: -1}" conv=notrunc status=none`)
+	defer mkfsVfat.Restore()
+	mcopy := testutil.MockCommand(c, "mcopy", "")
+	defer mcopy.Restore()
+	qemuImg := testutil.MockCommand(c, "qemu-img", "")
+	defer qemuImg.Restore()
+	img2simg := testutil.MockCommand(c, "img2simg", "")
+	defer img2simg.Restore()
+
+	model := s.makeUC20Model(nil)
+	err := image.BuildDiskImages(model, &image.Options{
+		PrepareDir:       prepareDir,
+		DiskImageFormats: []string{"raw", "qcow2", "sparse"},
+	})
+	c.Assert(err, IsNil)
+
+	img := filepath.Join(prepareDir, "pc.img")
+	c.Check(sfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--no-reread", "--no-tell-kernel", img},
+	})
+	// ubuntu-boot and ubuntu-data are created at install time
+	c.Check(sfdiskInput, testutil.FileEquals, `label: gpt
+unit: sectors
+
+start=2048, size=2048, type=21686148-6449-6E6F-744E-656564454649, name="BIOS Boot"
+start=4096, size=20480, type=C12A7328-F81F-11D2-BA4B-00A0C93EC93B, name="ubuntu-seed"
+`)
+	c.Check(mkfsVfat.Calls(), DeepEquals, [][]string{
+		{"mkfs.vfat", "-S", "512", "-s", "1", "-F", "32", "-n", "ubuntu-seed", img + ".part2"},
+	})
+	c.Check(mcopy.Calls(), DeepEquals, [][]string{
+		{"mcopy", "-s", "-i", img + ".part2", filepath.Join(prepareDir, "system-seed", "systems"), "::"},
+	})
+	c.Check(qemuImg.Calls(), DeepEquals, [][]string{
+		{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", img, filepath.Join(prepareDir, "pc.qcow2")},
+	})
+	c.Check(img2simg.Calls(), DeepEquals, [][]string{
+		{"img2simg", img, filepath.Join(prepareDir, "pc.sparse.img")},
+	})
+
+	data, err := os.ReadFile(img)
+	c.Assert(err, IsNil)
+	// the image ends with the backup GPT after ubuntu-seed
+	c.Check(len(data), Equals, 12*1024*1024+33*512)
+	c.Check(bytes.HasPrefix(data, []byte("pc-boot")), Equals, true)
+	c.Check(bytes.HasPrefix(data[1024*1024:], []byte("pc-core")), Equals, true)
+	c.Check(bytes.HasPrefix(data[2*1024*1024:], []byte("FATFS")), Equals, true)
+	// temporary filesystem images are gone
+	c.Check(img+".part2", testutil.FileAbsent)
+}
+
+func (s *imageSuite) TestBuildDiskImagesOnlyConverted(c *C) {
+	prepareDir := s.makePreparedUC20Dir(c)
+
+	for _, cmd := range []string{"sfdisk", "mkfs.vfat", "mcopy"} {
+		mock := testutil.MockCommand(c, cmd, "")
+		defer mock.Restore()
+	}
+	qemuImg := testutil.MockCommand(c, "qemu-img", "")
+	defer qemuImg.Restore()
+
+	model := s.makeUC20Model(nil)
+	err := image.BuildDiskImages(model, &image.Options{
+		PrepareDir:       prepareDir,
+		DiskImageFormats: []string{"qcow2"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(qemuImg.Calls(), HasLen, 1)
+	// the raw image was only needed for the conversion
+	c.Check(filepath.Join(prepareDir, "pc.img"), testutil.FileAbsent)
+}
+
+func (s *imageSuite) TestBuildDiskImagesConversionError(c *C) {
+	prepareDir := s.makePreparedUC20Dir(c)
+
+	for _, cmd := range []string{"sfdisk", "mkfs.vfat", "mcopy"} {
+		mock := testutil.MockCommand(c, cmd, "")
+		defer mock.Restore()
+	}
+	img2simg := testutil.MockCommand(c, "img2simg", `echo "cannot do it"; exit 1`)
+	defer img2simg.Restore()
+
+	model := s.makeUC20Model(nil)
+	err := image.BuildDiskImages(model, &image.Options{
+		PrepareDir:       prepareDir,
+		DiskImageFormats: []string{"sparse"},
+	})
+	c.Assert(err, ErrorMatches, `cannot convert disk image for volume "pc" to sparse: cannot do it`)
+}
@@ -60,8 +60,22 @@ func MockPreseedCore20(f func(opts *preseed.CoreOptions) error) (restore func())
 	return r
 }
 
+func MockOsGetuid(f func() int) (restore func()) {
+	r := testutil.Backup(&osGetuid)
+	osGetuid = f
+	return r
+}
+
 func MockSetupSeed(f func(tsto *tooling.ToolingStore, model *asserts.Model, opts *Options) error) (restore func()) {
 	r := testutil.Backup(&setupSeed)
 	setupSeed = f
 	return r
 }
+
+func MockBuildDiskImages(f func(model *asserts.Model, opts *Options) error) (restore func()) {
+	r := testutil.Backup(&buildDiskImages)
+	buildDiskImages = f
+	return r
+}
+
+var BuildDiskImages = buildDiskImagesImpl
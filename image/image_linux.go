@@ -58,6 +58,8 @@ var (
 	Stderr io.Writer = os.Stderr
 
 	preseedCore20 = preseed.Core20
+
+	osGetuid = os.Getuid
 )
 
 func (custo *Customizations) validate(model *asserts.Model) error {
@@ -153,6 +155,24 @@ func Prepare(opts *Options) error {
 		return err
 	}
 
+	if len(opts.DiskImageFormats) != 0 {
+		if model.Classic() {
+			return fmt.Errorf("cannot build disk images for a classic model")
+		}
+		if model.Grade() == asserts.ModelGradeUnset {
+			return fmt.Errorf("cannot build disk images for older base than core20")
+		}
+		if err := validateDiskImageFormats(opts.DiskImageFormats); err != nil {
+			return err
+		}
+	}
+
+	// unlike building disk images, preseeding runs snapd in a chroot of the
+	// image and needs root
+	if opts.Preseed && osGetuid() != 0 {
+		return fmt.Errorf("cannot preseed the image without root")
+	}
+
 	if err := setupSeed(tsto, model, opts); err != nil {
 		return err
 	}
@@ -175,8 +195,15 @@ func Prepare(opts *Options) error {
 			PreseedSignKey:            opts.PreseedSignKey,
 			AppArmorKernelFeaturesDir: opts.AppArmorKernelFeaturesDir,
 			SysfsOverlay:              opts.SysfsOverlay,
+			Reproducible:              opts.PreseedReproducible,
 		}
-		return preseedCore20(coreOpts)
+		if err := preseedCore20(coreOpts); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.DiskImageFormats) != 0 {
+		return buildDiskImages(model, opts)
 	}
 
 	return nil
@@ -113,6 +113,7 @@ func (s *imageSuite) SetUpTest(c *C) {
 	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
 
 	s.AddCleanup(osutil.MockMountInfo(""))
+	s.AddCleanup(image.MockOsGetuid(func() int { return 0 }))
 
 	s.stdout = &bytes.Buffer{}
 	image.Stdout = s.stdout
@@ -3655,6 +3656,7 @@ func (s *imageSuite) TestPrepareWithUC20Preseed(c *C) {
 		c.Assert(opts.PreseedSignKey, Equals, "foo")
 		c.Assert(opts.AppArmorKernelFeaturesDir, Equals, "/custom/aa/features")
 		c.Assert(opts.SysfsOverlay, Equals, "/sysfs-overlay")
+		c.Assert(opts.Reproducible, Equals, true)
 		return nil
 	})
 	defer restorePreseedCore20()
@@ -3670,6 +3672,7 @@ func (s *imageSuite) TestPrepareWithUC20Preseed(c *C) {
 		PreseedSignKey: "foo",
 		SysfsOverlay:   "/sysfs-overlay",
 
+		PreseedReproducible:       true,
 		AppArmorKernelFeaturesDir: "/custom/aa/features",
 	})
 	c.Assert(err, IsNil)
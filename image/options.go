@@ -30,6 +30,9 @@ type Options struct {
 	// PreseedSignKey is the name of the key to use for signing preseed
 	// assertion (empty means the default key).
 	PreseedSignKey string
+	// PreseedReproducible requests preseeding to be deterministic and
+	// to write a preseed.manifest (see preseed.CoreOptions).
+	PreseedReproducible bool
 
 	// AppArmor kernel features directory to bind-mount when preseeding.
 	// If empty then the features from /sys/kernel/security/apparmor will be used.
@@ -65,6 +68,12 @@ type Options struct {
 
 	PrepareDir string
 
+	// DiskImageFormats lists the formats, out of "raw", "qcow2" and
+	// "sparse" (Android sparse image), of the disk images to build into
+	// PrepareDir for each of the gadget volumes once the image has been
+	// prepared and possibly preseeded (core models only).
+	DiskImageFormats []string
+
 	// Architecture to use if none is specified by the model,
 	// useful only for classic mode. If set must match the model otherwise.
 	Architecture string
@@ -32,10 +32,22 @@ var (
 	ChooseTargetSnapdVersion = chooseTargetSnapdVersion
 	CreatePreseedArtifact    = createPreseedArtifact
 	RunUC20PreseedMode       = runUC20PreseedMode
+	RunUC20Snapd             = runUC20Snapd
+	VerifyUC20PreseedMode    = verifyUC20PreseedMode
+	ReproducibleTimestamp    = reproducibleTimestamp
+	NormalizeState           = normalizeState
 )
 
 type PreseedCoreOptions = preseedCoreOptions
 
+type PreseedManifest = preseedManifest
+
+type PreseedManifestFile = preseedManifestFile
+
+func WritePreseedManifest(artifactPath string, m *PreseedManifest, manifestPath string) error {
+	return writePreseedManifest(artifactPath, m, manifestPath)
+}
+
 func MockSeedOpen(f func(rootDir, label string) (seed.Seed, error)) (restore func()) {
 	oldSeedOpen := seedOpen
 	seedOpen = f
@@ -0,0 +1,265 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package preseed
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// preseedManifest records the inputs and the generated files of a
+// reproducible preseeding run, so that the resulting artifact can be
+// attested and compared with the one of a later run.
+type preseedManifest struct {
+	SystemLabel      string                 `json:"system-label"`
+	Timestamp        time.Time              `json:"timestamp"`
+	Snaps            []interface{}          `json:"snaps"`
+	ArtifactSHA3_384 string                 `json:"artifact-sha3-384"`
+	Files            []*preseedManifestFile `json:"files"`
+}
+
+// preseedManifestFile describes a single entry of the preseed artifact.
+type preseedManifestFile struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	// SHA3_384 is set for regular files, for the state it is the digest of
+	// its normalized content (see normalizeState)
+	SHA3_384 string `json:"sha3-384,omitempty"`
+	// Volatile is set for regular files whose content is random by design,
+	// their digest is not recorded
+	Volatile bool `json:"volatile,omitempty"`
+	// Link is set for symlinks
+	Link string `json:"link,omitempty"`
+}
+
+const (
+	preseedStatePath = "var/lib/snapd/state.json"
+	// snap cookies are random tokens identifying the snaps to snapd
+	preseedCookieDir = "var/lib/snapd/cookie/"
+
+	normalizedTimestamp = "normalized-timestamp"
+	normalizedRandom    = "normalized-random"
+)
+
+// normalizeState returns the content of the state written while preseeding
+// with the values which differ from one preseeding run to the next one
+// replaced by placeholders: the timestamps of the changes, tasks, notices,
+// warnings and so on, the random refresh privacy key and the random snap
+// cookies. The snapd state is not deterministic, this allows comparing the
+// state of two runs nevertheless.
+func normalizeState(r io.Reader) ([]byte, error) {
+	dec := json.NewDecoder(r)
+	// keep the numbers as written
+	dec.UseNumber()
+	var st map[string]interface{}
+	if err := dec.Decode(&st); err != nil {
+		return nil, fmt.Errorf("cannot decode state: %v", err)
+	}
+
+	if data, ok := st["data"].(map[string]interface{}); ok {
+		if _, ok := data["refresh-privacy-key"]; ok {
+			data["refresh-privacy-key"] = normalizedRandom
+		}
+		// cookie -> snap instance name
+		if cookies, ok := data["snap-cookies"].(map[string]interface{}); ok {
+			normalized := make(map[string]interface{}, len(cookies))
+			for _, snapName := range cookies {
+				if name, ok := snapName.(string); ok {
+					normalized[name] = normalizedRandom
+				}
+			}
+			data["snap-cookies"] = normalized
+		}
+	}
+
+	// encoding/json sorts the keys of maps
+	return json.Marshal(normalizeTimestamps(st))
+}
+
+func normalizeTimestamps(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeTimestamps(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeTimestamps(e)
+		}
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return normalizedTimestamp
+		}
+	}
+	return v
+}
+
+func preseedManifestPath(prepareImageDir string) string {
+	return filepath.Join(prepareImageDir, "preseed.manifest")
+}
+
+// reproducibleTimestamp returns the timestamp to use for reproducible
+// preseeding, following the SOURCE_DATE_EPOCH convention.
+func reproducibleTimestamp() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, fmt.Errorf("cannot preseed reproducibly without SOURCE_DATE_EPOCH set")
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse SOURCE_DATE_EPOCH: %v", err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// artifactFiles lists the entries of the preseed artifact sorted by path. The
+// entries of two artifacts are the same when they were created by preseeding
+// the same image, unlike the artifacts themselves.
+func artifactFiles(artifactPath string) ([]*preseedManifestFile, error) {
+	f, err := os.Open(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var files []*preseedManifestFile
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		mf := &preseedManifestFile{
+			Path: hdr.Name,
+			Mode: fmt.Sprintf("%#o", hdr.Mode),
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			if strings.HasPrefix(hdr.Name, preseedCookieDir) {
+				mf.Volatile = true
+				break
+			}
+			h := crypto.SHA3_384.New()
+			if hdr.Name == preseedStatePath {
+				normalized, err := normalizeState(tr)
+				if err != nil {
+					return nil, err
+				}
+				h.Write(normalized)
+			} else if _, err := io.Copy(h, tr); err != nil {
+				return nil, err
+			}
+			mf.SHA3_384, err = asserts.EncodeDigest(crypto.SHA3_384, h.Sum(nil))
+			if err != nil {
+				return nil, err
+			}
+		case tar.TypeSymlink:
+			mf.Link = hdr.Linkname
+		case tar.TypeDir:
+			// directories carry no content
+		}
+		files = append(files, mf)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func writePreseedManifest(artifactPath string, m *preseedManifest, manifestPath string) error {
+	files, err := artifactFiles(artifactPath)
+	if err != nil {
+		return fmt.Errorf("cannot list preseed artifact content: %v", err)
+	}
+	m.Files = files
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(manifestPath, append(data, '\n'), 0644, 0)
+}
+
+// differingFiles returns the paths of the files which were added, removed or
+// changed between the expected and the actual preseed artifact content.
+func differingFiles(expected, actual []*preseedManifestFile) []string {
+	byPath := make(map[string]*preseedManifestFile, len(expected))
+	for _, f := range expected {
+		byPath[f.Path] = f
+	}
+	var differing []string
+	for _, f := range actual {
+		exp, ok := byPath[f.Path]
+		delete(byPath, f.Path)
+		if !ok || *exp != *f {
+			differing = append(differing, f.Path)
+		}
+	}
+	for path := range byPath {
+		differing = append(differing, path)
+	}
+	sort.Strings(differing)
+	return differing
+}
+
+// readPreseedAssertion reads the preseed assertion out of the assertions
+// stream written alongside the preseed artifact.
+func readPreseedAssertion(path string) (*asserts.Preseed, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read preseed assertion: %v", err)
+	}
+	defer f.Close()
+
+	dec := asserts.NewDecoder(f)
+	for {
+		a, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode preseed assertion: %v", err)
+		}
+		if preseedAs, ok := a.(*asserts.Preseed); ok {
+			return preseedAs, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find preseed assertion in %s", path)
+}
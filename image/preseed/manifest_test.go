@@ -0,0 +1,289 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package preseed_test
+
+import (
+	"crypto"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/image/preseed"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *preseedSuite) TestReproducibleTimestamp(c *C) {
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	os.Unsetenv("SOURCE_DATE_EPOCH")
+	_, err := preseed.ReproducibleTimestamp()
+	c.Check(err, ErrorMatches, `cannot preseed reproducibly without SOURCE_DATE_EPOCH set`)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = preseed.ReproducibleTimestamp()
+	c.Check(err, ErrorMatches, `cannot parse SOURCE_DATE_EPOCH: .*`)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1767225600")
+	ts, err := preseed.ReproducibleTimestamp()
+	c.Assert(err, IsNil)
+	c.Check(ts, Equals, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func (s *preseedSuite) TestCreatePreseedArtifactReproducible(c *C) {
+	tmpDir := c.MkDir()
+	prepareDir := filepath.Join(tmpDir, "prepare-dir")
+	c.Assert(os.MkdirAll(filepath.Join(prepareDir, "system-seed/systems/20220203"), 0755), IsNil)
+	writableDir := filepath.Join(tmpDir, "writable")
+	c.Assert(os.MkdirAll(filepath.Join(writableDir, "system-data/etc/bar"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(writableDir, "system-data/etc/bar/a"), nil, 0644), IsNil)
+
+	mockTar := testutil.MockCommand(c, "tar", "")
+	defer mockTar.Restore()
+
+	c.Assert(os.MkdirAll(filepath.Join(tmpDir, "/usr/lib/snapd"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(tmpDir, "/usr/lib/snapd/preseed.json"), []byte(`{"include": ["/etc/bar/a"]}`), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(prepareDir, "system-seed/systems/20220203/preseed.tgz"), nil, 0644), IsNil)
+
+	popts := &preseed.PreseedCoreOptions{
+		CoreOptions: preseed.CoreOptions{
+			PrepareImageDir: prepareDir,
+			Reproducible:    true,
+		},
+		PreseedChrootDir: tmpDir,
+		SystemLabel:      "20220203",
+		WritableDir:      writableDir,
+		Timestamp:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	_, err := preseed.CreatePreseedArtifact(popts)
+	c.Assert(err, IsNil)
+	c.Check(mockTar.Calls(), DeepEquals, [][]string{
+		{"tar", "-cf", filepath.Join(prepareDir, "system-seed/systems/20220203/preseed.tgz"),
+			"--use-compress-program", "gzip -n", "-p", "-C", filepath.Join(writableDir, "system-data"),
+			"--sort=name", "--mtime=@1767225600", "--numeric-owner", "etc/bar/a"},
+	})
+}
+
+type verifyPreseedFixture struct {
+	popts        *preseed.PreseedCoreOptions
+	systemData   string
+	manifestPath string
+}
+
+// setupVerifyPreseed performs the original preseeding, with snapdScript as the
+// chroot mock running snapd.
+func (s *preseedSuite) setupVerifyPreseed(c *C, snapdScript string) *verifyPreseedFixture {
+	tmpDir := c.MkDir()
+	prepareDir := filepath.Join(tmpDir, "prepare-dir")
+	c.Assert(os.MkdirAll(filepath.Join(prepareDir, "system-seed/systems/20220203"), 0755), IsNil)
+	writableDir := filepath.Join(tmpDir, "writable")
+	systemData := filepath.Join(writableDir, "system-data")
+	c.Assert(os.MkdirAll(filepath.Join(systemData, "var/lib/snapd"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(systemData, "var/lib/snapd/state.json"), []byte(`{}`), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(systemData, "var/lib/snapd/other"), []byte(`other`), 0644), IsNil)
+	c.Assert(os.Symlink("other", filepath.Join(systemData, "var/lib/snapd/link")), IsNil)
+
+	chrootDir := filepath.Join(tmpDir, "chroot")
+	c.Assert(os.MkdirAll(filepath.Join(chrootDir, "/usr/lib/snapd"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(chrootDir, "/usr/lib/snapd/preseed.json"), []byte(`{"include": ["/var/lib/snapd"]}`), 0644), IsNil)
+
+	// the real tar produces the artifact
+	mockChroot := testutil.MockCommand(c, "chroot", snapdScript)
+	s.AddCleanup(mockChroot.Restore)
+	mockTar := testutil.MockCommand(c, "tar", `exec /usr/bin/tar "$@"`)
+	s.AddCleanup(mockTar.Restore)
+
+	timestamp := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	popts := &preseed.PreseedCoreOptions{
+		CoreOptions: preseed.CoreOptions{
+			PrepareImageDir: prepareDir,
+			Reproducible:    true,
+		},
+		PreseedChrootDir: chrootDir,
+		SystemLabel:      "20220203",
+		WritableDir:      writableDir,
+		Timestamp:        timestamp,
+	}
+
+	// the original preseeding run
+	c.Assert(preseed.RunUC20Snapd(popts), IsNil)
+	digest, err := preseed.CreatePreseedArtifact(popts)
+	c.Assert(err, IsNil)
+	base64Digest, err := asserts.EncodeDigest(crypto.SHA3_384, digest)
+	c.Assert(err, IsNil)
+	manifestPath := filepath.Join(prepareDir, "preseed.manifest")
+	c.Assert(preseed.WritePreseedManifest(filepath.Join(prepareDir, "system-seed/systems/20220203/preseed.tgz"), &preseed.PreseedManifest{
+		SystemLabel:      "20220203",
+		Timestamp:        timestamp,
+		ArtifactSHA3_384: base64Digest,
+	}, manifestPath), IsNil)
+
+	signing := assertstest.NewStoreStack("my-brand", nil)
+	preseedAs, err := signing.RootSigning.Sign(asserts.PreseedType, map[string]interface{}{
+		"type":              "preseed",
+		"authority-id":      "my-brand",
+		"series":            "16",
+		"brand-id":          "my-brand",
+		"model":             "my-model",
+		"system-label":      "20220203",
+		"artifact-sha3-384": base64Digest,
+		"timestamp":         timestamp.Format(time.RFC3339),
+		"snaps": []interface{}{
+			map[string]interface{}{"name": "snapd"},
+		},
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(filepath.Join(prepareDir, "system-seed/systems/20220203/preseed"), asserts.Encode(preseedAs), 0644), IsNil)
+
+	// the verification starts out of a fresh artifact
+	popts.Reproducible = false
+	popts.Timestamp = time.Time{}
+
+	return &verifyPreseedFixture{
+		popts:        popts,
+		systemData:   systemData,
+		manifestPath: manifestPath,
+	}
+}
+
+func (s *preseedSuite) TestVerifyUC20PreseedModeHappy(c *C) {
+	f := s.setupVerifyPreseed(c, "")
+
+	// file times do not matter
+	later := time.Now().Add(time.Hour)
+	c.Assert(os.Chtimes(filepath.Join(f.systemData, "var/lib/snapd/state.json"), later, later), IsNil)
+
+	c.Assert(preseed.VerifyUC20PreseedMode(f.popts), IsNil)
+
+	var m preseed.PreseedManifest
+	data, err := os.ReadFile(f.manifestPath)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &m), IsNil)
+	c.Assert(m.Files, HasLen, 4)
+	c.Check(m.Files[0].Path, Equals, "var/lib/snapd/")
+	c.Check(m.Files[1].Path, Equals, "var/lib/snapd/link")
+	c.Check(m.Files[1].Link, Equals, "other")
+	c.Check(m.Files[2].Path, Equals, "var/lib/snapd/other")
+	c.Check(m.Files[2].SHA3_384, Not(Equals), "")
+	c.Check(m.Files[3].Path, Equals, "var/lib/snapd/state.json")
+}
+
+func (s *preseedSuite) TestVerifyUC20PreseedModeMismatch(c *C) {
+	f := s.setupVerifyPreseed(c, "")
+
+	c.Assert(os.WriteFile(filepath.Join(f.systemData, "var/lib/snapd/state.json"), []byte(`{"changed": true}`), 0644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(f.systemData, "var/lib/snapd/new"), nil, 0644), IsNil)
+
+	err := preseed.VerifyUC20PreseedMode(f.popts)
+	c.Assert(err, ErrorMatches, `cannot reproduce preseed artifact of system "20220203", differing files:
+var/lib/snapd/new
+var/lib/snapd/state.json`)
+}
+
+func (s *preseedSuite) TestVerifyUC20PreseedModeArtifactNotAttested(c *C) {
+	f := s.setupVerifyPreseed(c, "")
+
+	c.Assert(os.WriteFile(filepath.Join(f.popts.PrepareImageDir, "system-seed/systems/20220203/preseed.tgz"), []byte("tampered"), 0644), IsNil)
+
+	err := preseed.VerifyUC20PreseedMode(f.popts)
+	c.Assert(err, ErrorMatches, `preseed artifact of system "20220203" does not match the preseed assertion: expected sha3-384 .*, got .*`)
+}
+
+func (s *preseedSuite) TestVerifyUC20PreseedModeTwiceNondeterministicSnapd(c *C) {
+	// snapd writes timestamps and random values to the state, and random
+	// snap cookies, on each run; the chroot is next to the writable dir
+	f := s.setupVerifyPreseed(c, `
+data="$(dirname "$1")/writable/system-data/var/lib/snapd"
+now="$(date -u +%Y-%m-%dT%H:%M:%S.%NZ)"
+random="$(head -c 24 /dev/urandom | od -An -tx1 | tr -d ' \n')"
+cat > "$data/state.json" <<EOF
+{"data":{"refresh-privacy-key":"$random","snap-cookies":{"$random":"foo"},"seed-time":"$now","seeded":true},"changes":{"1":{"id":"1","kind":"seed","spawn-time":"$now","ready-time":"$now"}},"last-change-id":1}
+EOF
+mkdir -p "$data/cookie"
+printf '%s' "$random" > "$data/cookie/snap.foo"
+`)
+
+	attested := filepath.Join(f.popts.PrepareImageDir, "system-seed/systems/20220203/preseed.tgz")
+	attestedState, err := os.ReadFile(filepath.Join(f.systemData, "var/lib/snapd/state.json"))
+	c.Assert(err, IsNil)
+
+	// preseed again and compare
+	c.Assert(preseed.VerifyUC20PreseedMode(f.popts), IsNil)
+
+	reproducedState, err := os.ReadFile(filepath.Join(f.systemData, "var/lib/snapd/state.json"))
+	c.Assert(err, IsNil)
+	c.Check(string(reproducedState), Not(Equals), string(attestedState))
+	// the artifacts differ, their normalized content does not
+	attestedDigest, _, err := osutil.FileDigest(attested, crypto.SHA3_384)
+	c.Assert(err, IsNil)
+	reproducedDigest, _, err := osutil.FileDigest(f.popts.ArtifactPath, crypto.SHA3_384)
+	c.Assert(err, IsNil)
+	c.Check(reproducedDigest, Not(DeepEquals), attestedDigest)
+
+	var m preseed.PreseedManifest
+	data, err := os.ReadFile(f.manifestPath)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &m), IsNil)
+	var cookie *preseed.PreseedManifestFile
+	for _, mf := range m.Files {
+		if mf.Path == "var/lib/snapd/cookie/snap.foo" {
+			cookie = mf
+		}
+	}
+	c.Assert(cookie, NotNil)
+	c.Check(cookie.Volatile, Equals, true)
+	c.Check(cookie.SHA3_384, Equals, "")
+}
+
+func (s *preseedSuite) TestNormalizeState(c *C) {
+	normalized, err := preseed.NormalizeState(strings.NewReader(`{
+"data": {
+  "refresh-privacy-key": "abcdef",
+  "snap-cookies": {"cookie1": "foo", "cookie2": "bar"},
+  "seed-time": "2026-10-19T12:00:00.123456789Z",
+  "not-a-time": "2026",
+  "count": 12345678901234567890
+},
+"changes": {"1": {"id": "1", "spawn-time": "2026-10-19T12:00:00+02:00", "log": ["2026-10-19T12:00:00Z INFO done"]}},
+"last-change-id": 1
+}`))
+	c.Assert(err, IsNil)
+	c.Check(string(normalized), Equals, `{"changes":{"1":{"id":"1","log":["2026-10-19T12:00:00Z INFO done"],"spawn-time":"normalized-timestamp"}},`+
+		`"data":{"count":12345678901234567890,"not-a-time":"2026","refresh-privacy-key":"normalized-random","seed-time":"normalized-timestamp",`+
+		`"snap-cookies":{"bar":"normalized-random","foo":"normalized-random"}},"last-change-id":1}`)
+
+	_, err = preseed.NormalizeState(strings.NewReader(`garbage`))
+	c.Check(err, ErrorMatches, `cannot decode state: .*`)
+}
+
+func (s *preseedSuite) TestVerifyUC20PreseedModeNoAssertion(c *C) {
+	f := s.setupVerifyPreseed(c, "")
+	c.Assert(os.Remove(filepath.Join(f.popts.PrepareImageDir, "system-seed/systems/20220203/preseed")), IsNil)
+
+	err := preseed.VerifyUC20PreseedMode(f.popts)
+	c.Assert(err, ErrorMatches, `cannot read preseed assertion: .*`)
+}
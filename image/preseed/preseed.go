@@ -59,6 +59,12 @@ type CoreOptions struct {
 	AppArmorKernelFeaturesDir string
 	// optional sysfs overlay
 	SysfsOverlay string
+	// Reproducible requests the preseed artifact to be created with
+	// timestamps fixed by SOURCE_DATE_EPOCH and sorted content, and a
+	// preseed.manifest listing the inputs and the generated files to be
+	// written into the prepare image directory. The state written by snapd
+	// is normalized in the manifest, as it is not deterministic.
+	Reproducible bool
 }
 
 // preseedCoreOptions holds internal preseeding options for the core case
@@ -75,6 +81,17 @@ type preseedCoreOptions struct {
 	SnapdSnapPath string
 	// base snap mount point
 	BaseSnapPath string
+	// ArtifactPath overrides where the preseed artifact is created
+	ArtifactPath string
+	// Timestamp is the fixed time used for reproducible preseeding
+	Timestamp time.Time
+}
+
+func (opts *preseedCoreOptions) artifactPath() string {
+	if opts.ArtifactPath != "" {
+		return opts.ArtifactPath
+	}
+	return filepath.Join(opts.PrepareImageDir, "system-seed", "systems", opts.SystemLabel, "preseed.tgz")
 }
 
 type targetSnapdInfo struct {
@@ -187,6 +204,10 @@ func writePreseedAssertion(artifactDigest []byte, opts *preseedCoreOptions) erro
 	if err != nil {
 		return err
 	}
+	timestamp := time.Now().UTC()
+	if !opts.Timestamp.IsZero() {
+		timestamp = opts.Timestamp
+	}
 	headers := map[string]interface{}{
 		"type":              "preseed",
 		"authority-id":      model.AuthorityID(),
@@ -195,7 +216,7 @@ func writePreseedAssertion(artifactDigest []byte, opts *preseedCoreOptions) erro
 		"model":             model.Model(),
 		"system-label":      opts.SystemLabel,
 		"artifact-sha3-384": base64Digest,
-		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+		"timestamp":         timestamp.Format(time.RFC3339),
 		"snaps":             snaps,
 	}
 
@@ -238,5 +259,17 @@ func writePreseedAssertion(artifactDigest []byte, opts *preseedCoreOptions) erro
 		}
 	}
 
+	if opts.Reproducible {
+		m := &preseedManifest{
+			SystemLabel:      opts.SystemLabel,
+			Timestamp:        timestamp,
+			Snaps:            snaps,
+			ArtifactSHA3_384: base64Digest,
+		}
+		if err := writePreseedManifest(opts.artifactPath(), m, preseedManifestPath(opts.PrepareImageDir)); err != nil {
+			return fmt.Errorf("cannot write preseed manifest: %v", err)
+		}
+	}
+
 	return nil
 }
@@ -30,7 +30,9 @@ import (
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/squashfs"
@@ -629,7 +631,7 @@ type preseedFilePatterns struct {
 }
 
 func createPreseedArtifact(opts *preseedCoreOptions) (digest []byte, err error) {
-	artifactPath := filepath.Join(opts.PrepareImageDir, "system-seed", "systems", opts.SystemLabel, "preseed.tgz")
+	artifactPath := opts.artifactPath()
 	systemData := filepath.Join(opts.WritableDir, "system-data")
 
 	patternsFile := filepath.Join(opts.PreseedChrootDir, "usr/lib/snapd/preseed.json")
@@ -646,6 +648,11 @@ func createPreseedArtifact(opts *preseedCoreOptions) (digest []byte, err error)
 	}
 
 	args := []string{"-czf", artifactPath, "-p", "-C", systemData}
+	if opts.Reproducible {
+		// gzip -n omits the name and timestamp from the gzip header
+		args = []string{"-cf", artifactPath, "--use-compress-program", "gzip -n", "-p", "-C", systemData,
+			"--sort=name", fmt.Sprintf("--mtime=@%d", opts.Timestamp.Unix()), "--numeric-owner"}
+	}
 	for _, excl := range patterns.Exclude {
 		args = append(args, "--exclude", excl)
 	}
@@ -707,7 +714,7 @@ func reexecReset(preseedChroot string, targetSnapd *targetSnapdInfo) error {
 	return nil
 }
 
-func runUC20PreseedMode(opts *preseedCoreOptions) error {
+func runUC20Snapd(opts *preseedCoreOptions) error {
 	cmd := exec.Command("chroot", opts.PreseedChrootDir, "/usr/lib/snapd/snapd")
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, "SNAPD_PRESEED=1")
@@ -723,6 +730,13 @@ func runUC20PreseedMode(opts *preseedCoreOptions) error {
 
 		return fmt.Errorf("error running snapd in preseed mode: %v\n", err)
 	}
+	return nil
+}
+
+func runUC20PreseedMode(opts *preseedCoreOptions) error {
+	if err := runUC20Snapd(opts); err != nil {
+		return err
+	}
 
 	digest, err := createPreseedArtifact(opts)
 	if err != nil {
@@ -745,15 +759,100 @@ func Core20(opts *CoreOptions) error {
 		return err
 	}
 
+	var timestamp time.Time
+	if opts.Reproducible {
+		timestamp, err = reproducibleTimestamp()
+		if err != nil {
+			return err
+		}
+	}
+
 	popts, cleanup, err := prepareCore20Chroot(opts)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
+	popts.Timestamp = timestamp
 
 	return runUC20PreseedMode(popts)
 }
 
+// Core20Verify runs preseeding of the UC20 system prepared by prepare-image
+// in prepareImageDir again, reproducibly, and checks that the result matches
+// the artifact attested by the existing preseed assertion in
+// system-seed/systems/<systemlabel>/preseed. The content of the artifacts is
+// compared, with the state normalized and the random snap cookies ignored,
+// as snapd does not preseed deterministically. Nothing in prepareImageDir is
+// modified.
+func Core20Verify(opts *CoreOptions) error {
+	var err error
+	opts.PrepareImageDir, err = filepath.Abs(opts.PrepareImageDir)
+	if err != nil {
+		return err
+	}
+
+	popts, cleanup, err := prepareCore20Chroot(opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return verifyUC20PreseedMode(popts)
+}
+
+func verifyUC20PreseedMode(popts *preseedCoreOptions) error {
+	systemDir := filepath.Join(popts.PrepareImageDir, "system-seed", "systems", popts.SystemLabel)
+	preseedAs, err := readPreseedAssertion(filepath.Join(systemDir, "preseed"))
+	if err != nil {
+		return err
+	}
+	if preseedAs.SystemLabel() != popts.SystemLabel {
+		return fmt.Errorf("preseed assertion is for system %q, not %q", preseedAs.SystemLabel(), popts.SystemLabel)
+	}
+
+	// the artifact of the image must be the attested one
+	attestedPath := filepath.Join(systemDir, "preseed.tgz")
+	digest, _, err := osutil.FileDigest(attestedPath, crypto.SHA3_384)
+	if err != nil {
+		return fmt.Errorf("cannot read preseed artifact: %v", err)
+	}
+	base64Digest, err := asserts.EncodeDigest(crypto.SHA3_384, digest)
+	if err != nil {
+		return err
+	}
+	if base64Digest != preseedAs.ArtifactSHA3_384() {
+		return fmt.Errorf("preseed artifact of system %q does not match the preseed assertion: expected sha3-384 %s, got %s",
+			popts.SystemLabel, preseedAs.ArtifactSHA3_384(), base64Digest)
+	}
+	attested, err := artifactFiles(attestedPath)
+	if err != nil {
+		return fmt.Errorf("cannot list preseed artifact content: %v", err)
+	}
+
+	popts.Reproducible = true
+	popts.Timestamp = preseedAs.Timestamp().UTC()
+	// outside of the tarred system-data, removed with the writable dir
+	popts.ArtifactPath = filepath.Join(popts.WritableDir, "preseed.tgz")
+
+	if err := runUC20Snapd(popts); err != nil {
+		return err
+	}
+	if _, err := createPreseedArtifact(popts); err != nil {
+		return fmt.Errorf("cannot create preseed.tgz: %v", err)
+	}
+	reproduced, err := artifactFiles(popts.ArtifactPath)
+	if err != nil {
+		return fmt.Errorf("cannot list preseed artifact content: %v", err)
+	}
+	if differing := differingFiles(attested, reproduced); len(differing) > 0 {
+		return fmt.Errorf("cannot reproduce preseed artifact of system %q, differing files:\n%s",
+			popts.SystemLabel, strings.Join(differing, "\n"))
+	}
+
+	fmt.Fprintf(Stdout, "preseed artifact of system %q verified\n", popts.SystemLabel)
+	return nil
+}
+
 // Classic runs preseeding of a classic ubuntu system pointed by chrootDir.
 func Classic(chrootDir string) error {
 	var err error
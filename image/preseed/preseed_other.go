@@ -33,3 +33,7 @@ func Classic(chrootDir string) error {
 func Core20(opts *CorePreseedOptions) error {
 	return preseedNotAvailableError
 }
+
+func Core20Verify(opts *CoreOptions) error {
+	return preseedNotAvailableError
+}
@@ -0,0 +1,192 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// globalVariables are the values of the variables defined by the tunables of
+// AppArmor, as used by the snippets of interfaces.
+var globalVariables = map[string]string{
+	"PROC":     "/proc/",
+	"HOME":     "{/home/*,/root}",
+	"HOMEDIRS": "/home/",
+	"pid":      "{[1-9],[1-9][0-9]*}",
+	"pids":     "{[1-9],[1-9][0-9]*}",
+	"tid":      "{[1-9],[1-9][0-9]*}",
+	"run":      "/run/",
+	"sys":      "/sys/",
+}
+
+var variableRegexp = regexp.MustCompile(`@\{([A-Za-z0-9_]+)\}`)
+
+// expandVariables expands the AppArmor variables used in a path pattern, using
+// the values of the variables defined for the profiles of the given snap.
+// Unknown variables match any single path component.
+func expandVariables(pattern string, info *snap.Info) string {
+	return variableRegexp.ReplaceAllStringFunc(pattern, func(v string) string {
+		name := v[2 : len(v)-1]
+		switch name {
+		case "SNAP_NAME":
+			return info.SnapName()
+		case "SNAP_INSTANCE_NAME":
+			return info.InstanceName()
+		case "SNAP_INSTANCE_DESKTOP":
+			return info.DesktopPrefix()
+		case "SNAP_REVISION":
+			return info.Revision.String()
+		case "INSTALL_DIR":
+			return "/{,var/lib/snapd/}snap"
+		}
+		if value, ok := globalVariables[name]; ok {
+			return value
+		}
+		return "*"
+	})
+}
+
+// globToRegexp converts an AppArmor path pattern into a regular expression.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	alternations := 0
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch {
+		case ch == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			buf.WriteString(".*")
+			i++
+		case ch == '*':
+			buf.WriteString("[^/]*")
+		case ch == '?':
+			buf.WriteString("[^/]")
+		case ch == '{':
+			alternations++
+			buf.WriteString("(?:")
+		case ch == '}' && alternations > 0:
+			alternations--
+			buf.WriteString(")")
+		case ch == ',' && alternations > 0:
+			buf.WriteString("|")
+		case ch == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				buf.WriteString(regexp.QuoteMeta(glob[i:]))
+				i = len(glob)
+				continue
+			}
+			buf.WriteString(glob[i : i+end+1])
+			i += end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+// permsAllow returns true if the permissions of a file rule grant all the
+// requested permissions, as reported by AppArmor denials.
+func permsAllow(perms, requested string) bool {
+	for _, p := range requested {
+		switch p {
+		case 'c', 'd':
+			// creating and deleting files requires write access
+			p = 'w'
+		case 'a':
+			if strings.ContainsRune(perms, 'w') {
+				continue
+			}
+		}
+		if !strings.ContainsRune(perms, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// FileRuleAllows returns true if the given line of an AppArmor snippet is a
+// file rule granting the requested permissions, as in "r" or "wc", to the
+// given path, when used in a profile of the given snap.
+func FileRuleAllows(rule string, info *snap.Info, path, requested string) bool {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(rule), ","))
+	// skip rule qualifiers
+	for len(fields) > 0 {
+		switch fields[0] {
+		case "audit", "owner", "file", "allow":
+			fields = fields[1:]
+			continue
+		case "deny":
+			return false
+		}
+		break
+	}
+	if len(fields) < 2 {
+		return false
+	}
+	pattern, perms := fields[0], fields[1]
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "@{") {
+		// permissions can also be given before the path
+		pattern, perms = perms, pattern
+	}
+	pattern = strings.Trim(pattern, `"`)
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "@{") {
+		return false
+	}
+	if !permsAllow(perms, requested) {
+		return false
+	}
+	expanded := expandVariables(pattern, info)
+	// variables such as @{PROC} end with a slash
+	for strings.Contains(expanded, "//") {
+		expanded = strings.Replace(expanded, "//", "/", -1)
+	}
+	re, err := globToRegexp(expanded)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// CapabilityRuleAllows returns true if the given line of an AppArmor snippet
+// grants the given capability.
+func CapabilityRuleAllows(rule, capability string) bool {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(rule), ","))
+	if len(fields) > 0 && fields[0] == "audit" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || fields[0] != "capability" {
+		return false
+	}
+	if len(fields) == 1 {
+		// all capabilities
+		return true
+	}
+	for _, c := range fields[1:] {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
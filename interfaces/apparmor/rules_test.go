@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmor_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type rulesSuite struct {
+	info *snap.Info
+}
+
+var _ = Suite(&rulesSuite{})
+
+func (s *rulesSuite) SetUpTest(c *C) {
+	s.info = snaptest.MockInfo(c, "name: foo\nversion: 1\n", &snap.SideInfo{Revision: snap.R(42)})
+}
+
+func (s *rulesSuite) TestFileRuleAllows(c *C) {
+	for _, t := range []struct {
+		rule, path, requested string
+		allowed               bool
+	}{
+		{"/etc/shadow r,", "/etc/shadow", "r", true},
+		{"/etc/shadow r,", "/etc/shadow", "w", false},
+		{"/etc/shadow r,", "/etc/passwd", "r", false},
+		{"/dev/ttyUSB[0-9]* rw,", "/dev/ttyUSB12", "rw", true},
+		{"/media/** rwk,", "/media/user/disk/file", "wc", true},
+		{"/media/* rw,", "/media/user/disk", "r", false},
+		{"owner @{HOME}/** rwlk,", "/home/user/Documents/a.txt", "r", true},
+		{"owner @{HOME}/** rwlk,", "/root/a.txt", "a", true},
+		{"@{PROC}/@{pid}/mounts r,", "/proc/123/mounts", "r", true},
+		{"@{PROC}/@{pid}/mounts r,", "/proc/self/mounts", "r", false},
+		{"/var/snap/@{SNAP_INSTANCE_NAME}/@{SNAP_REVISION}/** rw,", "/var/snap/foo/42/data", "r", true},
+		{"@{INSTALL_DIR}/@{SNAP_NAME}/** mrix,", "/var/lib/snapd/snap/foo/42/bin/foo", "x", true},
+		{"/sys/class/{net,leds}/ r,", "/sys/class/leds/", "r", true},
+		{"r /etc/hostname,", "/etc/hostname", "r", true},
+		{"deny /etc/shadow r,", "/etc/shadow", "r", false},
+		{"capability sys_admin,", "/etc/shadow", "r", false},
+		{"#include <abstractions/base>", "/etc/shadow", "r", false},
+	} {
+		c.Check(apparmor.FileRuleAllows(t.rule, s.info, t.path, t.requested), Equals, t.allowed, Commentf("%q %q", t.rule, t.path))
+	}
+}
+
+func (s *rulesSuite) TestCapabilityRuleAllows(c *C) {
+	c.Check(apparmor.CapabilityRuleAllows("capability sys_admin,", "sys_admin"), Equals, true)
+	c.Check(apparmor.CapabilityRuleAllows("capability net_admin sys_admin,", "sys_admin"), Equals, true)
+	c.Check(apparmor.CapabilityRuleAllows("audit capability,", "sys_admin"), Equals, true)
+	c.Check(apparmor.CapabilityRuleAllows("capability net_admin,", "sys_admin"), Equals, false)
+	c.Check(apparmor.CapabilityRuleAllows("/etc/capability r,", "sys_admin"), Equals, false)
+}
@@ -169,6 +169,9 @@ type Specification struct {
 	// Unconfined profile mode allows a profile to be applied without any
 	// real confinement
 	unconfined UnconfinedMode
+
+	// provenance keeps track of the interfaces which contributed snippets
+	provenance interfaces.Provenance
 }
 
 func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
@@ -202,6 +205,7 @@ func (spec *Specification) AddSnippet(snippet string) {
 		spec.snippets[tag] = append(spec.snippets[tag], snippet)
 		sort.Strings(spec.snippets[tag])
 	}
+	spec.provenance.Record(spec.securityTags, snippet)
 }
 
 // AddPrioritizedSnippet adds a new apparmor snippet to all applications and hooks using the interface,
@@ -232,10 +236,17 @@ func (spec *Specification) AddPrioritizedSnippet(snippet string, key SnippetKey,
 			snippets.list = append(snippets.list, snippet)
 		} else if snippets.priority < priority {
 			// if the priority is bigger, replace the snippets with the new one
+			for _, replaced := range snippets.list {
+				spec.provenance.Forget(tag, replaced)
+			}
 			snippets.list = append([]string(nil), snippet)
 			snippets.priority = priority
-		} // smaller priority, discard
+		} else {
+			// smaller priority, discard
+			continue
+		}
 		spec.prioritizedSnippets[tag][key] = snippets
+		spec.provenance.Record([]string{tag}, snippet)
 	}
 }
 
@@ -273,6 +284,7 @@ func (spec *Specification) AddDeduplicatedSnippet(snippet string) {
 		}
 		bag.Put(snippet)
 	}
+	spec.provenance.Record(spec.securityTags, snippet)
 }
 
 // AddParametricSnippet adds a new apparmor snippet both de-duplicated and optimized for the parser.
@@ -353,11 +365,15 @@ func (spec *Specification) AddParametricSnippet(templateFragment []string, value
 		// spec.parametricSnippets[<tag>][<template>]'s OrderedSet.
 		values.Put(value)
 	}
+	spec.provenance.Record(spec.securityTags, strings.Replace(template, "###PARAM###", value, -1))
 }
 
 // AddUpdateNS adds a new apparmor snippet for the snap-update-ns program.
 func (spec *Specification) AddUpdateNS(snippet string) {
 	spec.updateNS.Put(snippet)
+	if spec.appSet != nil {
+		spec.provenance.Record([]string{"snap-update-ns." + spec.appSet.InstanceName()}, snippet)
+	}
 }
 
 // AddUpdateNSf formats and adds a new apparmor snippet for the snap-update-ns program.
@@ -763,6 +779,12 @@ func (spec *Specification) AddEnsureDirMounts(ifaceName string, ensureDirSpecs [
 	}
 }
 
+// ExplainedRules returns the rules contributed by interfaces along with their
+// origin.
+func (spec *Specification) ExplainedRules() []interfaces.ExplainedRule {
+	return spec.provenance.Rules(interfaces.SecurityAppArmor)
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records apparmor-specific side-effects of having a connected plug.
@@ -778,6 +800,7 @@ func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *in
 
 		restore := spec.setScope(tags)
 		defer restore()
+		defer spec.provenance.Track(interfaces.ConnectedPlugOrigin(plug, slot))()
 		return iface.AppArmorConnectedPlug(spec, plug, slot)
 	}
 	return nil
@@ -796,6 +819,7 @@ func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *in
 
 		restore := spec.setScope(tags)
 		defer restore()
+		defer spec.provenance.Track(interfaces.ConnectedSlotOrigin(plug, slot))()
 		return iface.AppArmorConnectedSlot(spec, plug, slot)
 	}
 	return nil
@@ -818,6 +842,7 @@ func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *sn
 
 		restore := spec.setScope(tags)
 		defer restore()
+		defer spec.provenance.Track(interfaces.PermanentPlugOrigin(plug))()
 		return iface.AppArmorPermanentPlug(spec, plug)
 	}
 	return nil
@@ -840,6 +865,7 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 
 		restore := spec.setScope(tags)
 		defer restore()
+		defer spec.provenance.Track(interfaces.PermanentSlotOrigin(slot))()
 		return iface.AppArmorPermanentSlot(spec, slot)
 	}
 	return nil
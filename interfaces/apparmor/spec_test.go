@@ -117,6 +117,46 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 	})
 }
 
+func (s *specSuite) TestExplainedRules(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plugInfo.Snap, nil)
+	c.Assert(err, IsNil)
+	spec := apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(spec.AddPermanentPlug(s.iface, s.plugInfo), IsNil)
+	// snippets added outside of an interface have no origin
+	spec.AddSnippet("ignored")
+	plugRef := &interfaces.PlugRef{Snap: "snap1", Name: "name"}
+	slotRef := &interfaces.SlotRef{Snap: "snap2", Name: "name"}
+	c.Check(spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "connected-plug",
+		Origin:      interfaces.RuleOrigin{Interface: "name", Plug: plugRef, Slot: slotRef, Source: interfaces.SourceConnectedPlug},
+	}, {
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "permanent-plug",
+		Origin:      interfaces.RuleOrigin{Interface: "name", Plug: plugRef, Source: interfaces.SourcePermanentPlug},
+	}})
+
+	appSet, err = interfaces.NewSnapAppSet(s.slotInfo.Snap, nil)
+	c.Assert(err, IsNil)
+	spec = apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedSlot(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(spec.AddPermanentSlot(s.iface, s.slotInfo), IsNil)
+	c.Check(spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap2.app2",
+		Rule:        "connected-slot",
+		Origin:      interfaces.RuleOrigin{Interface: "test", Plug: plugRef, Slot: slotRef, Source: interfaces.SourceConnectedSlot},
+	}, {
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap2.app2",
+		Rule:        "permanent-slot",
+		Origin:      interfaces.RuleOrigin{Interface: "test", Slot: slotRef, Source: interfaces.SourcePermanentSlot},
+	}})
+}
+
 // AddSnippet adds a snippet for the given security tag.
 func (s *specSuite) TestAddSnippet(c *C) {
 	restore := apparmor.SetSpecScope(s.spec, []string{"snap.demo.command", "snap.demo.service"})
@@ -653,6 +693,40 @@ func (s *specSuite) TestPrioritySnippets(c *C) {
 	c.Assert(tags, testutil.Contains, "snap.demo.scope2")
 }
 
+func (s *specSuite) TestPrioritySnippetsExplainedRules(c *C) {
+	iface := &ifacetest.TestInterface{
+		InterfaceName: "test",
+		AppArmorConnectedPlugCallback: func(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddPrioritizedSnippet("replaced", key1, 1)
+			spec.AddPrioritizedSnippet("kept 1", key1, 2)
+			spec.AddPrioritizedSnippet("kept 2", key1, 2)
+			spec.AddPrioritizedSnippet("discarded", key1, 0)
+			return nil
+		},
+	}
+	c.Assert(s.spec.AddConnectedPlug(iface, s.plug, s.slot), IsNil)
+	c.Assert(s.spec.SnippetForTag("snap.snap1.app1"), Equals, "kept 1\nkept 2")
+
+	origin := interfaces.RuleOrigin{
+		Interface: "name",
+		Plug:      &interfaces.PlugRef{Snap: "snap1", Name: "name"},
+		Slot:      &interfaces.SlotRef{Snap: "snap2", Name: "name"},
+		Source:    interfaces.SourceConnectedPlug,
+	}
+	// only the rules of the snippets which were kept are explained
+	c.Check(s.spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "kept 1",
+		Origin:      origin,
+	}, {
+		Backend:     interfaces.SecurityAppArmor,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "kept 2",
+		Origin:      origin,
+	}})
+}
+
 func (s *specSuite) TestPrioritySnippetsNoRegisteredKey(c *C) {
 	var key1 apparmor.SnippetKey = apparmor.SnippetKey{}
 	c.Assert(func() { s.spec.AddPrioritizedSnippet("Prioritized snippet 1", key1, 0) }, PanicMatches, "priority key  is not registered")
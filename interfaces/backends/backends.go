@@ -20,17 +20,22 @@
 package backends
 
 import (
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/interfaces/polkit"
 	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/interfaces/selinux"
 	"github.com/snapcore/snapd/interfaces/systemd"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/logger"
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
+	selinux_sandbox "github.com/snapcore/snapd/sandbox/selinux"
 )
 
 // All returns a set of all available security backends.
@@ -67,6 +72,25 @@ func All() []interfaces.SecurityBackend {
 	switch apparmor_sandbox.ProbedLevel() {
 	case apparmor_sandbox.Partial, apparmor_sandbox.Full:
 		all = append(all, &apparmor.Backend{})
+	default:
+		// Without AppArmor, fall back to landlock to restrict access to
+		// the file system and the network if the kernel supports it. This
+		// is experimental until interfaces provide landlock rules, changes
+		// of the feature take effect after snapd is restarted.
+		if features.Landlock.IsEnabled() && landlock_sandbox.IsSupported() {
+			logger.Noticef("Landlock ABI version: %d", landlock_sandbox.ABIVersion())
+			all = append(all, &landlock.Backend{})
+		}
+	}
+
+	// On systems using SELinux, such as Fedora and the RHEL family, confine
+	// snaps with a policy module generated for each of them. In permissive
+	// mode denials are only logged, like in AppArmor complain mode. This is
+	// experimental until the domains cover what applications commonly use,
+	// changes of the feature take effect after snapd is restarted.
+	if features.SELinuxConfinement.IsEnabled() && selinux_sandbox.ProbedLevel() != selinux_sandbox.Unsupported {
+		logger.Noticef("SELinux status: %s", selinux_sandbox.Summary())
+		all = append(all, &selinux.Backend{})
 	}
 	return all
 }
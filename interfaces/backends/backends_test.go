@@ -18,12 +18,17 @@
 package backends_test
 
 import (
+	"os"
 	"testing"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces/backends"
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
+	selinux_sandbox "github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -31,10 +36,21 @@ func Test(t *testing.T) {
 	TestingT(t)
 }
 
-type backendsSuite struct{}
+type backendsSuite struct {
+	testutil.BaseTest
+}
 
 var _ = Suite(&backendsSuite{})
 
+func (s *backendsSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.AddCleanup(selinux_sandbox.MockIsEnabled(func() (bool, error) { return false, nil }))
+}
+
+func (s *backendsSuite) TearDownTest(c *C) {
+	s.BaseTest.TearDownTest(c)
+}
+
 func (s *backendsSuite) TestIsAppArmorEnabled(c *C) {
 	for _, level := range []apparmor_sandbox.LevelType{apparmor_sandbox.Unsupported, apparmor_sandbox.Unusable, apparmor_sandbox.Partial, apparmor_sandbox.Full} {
 		restore := apparmor_sandbox.MockLevel(level)
@@ -55,6 +71,67 @@ func (s *backendsSuite) TestIsAppArmorEnabled(c *C) {
 	}
 }
 
+func (s *backendsSuite) TestIsLandlockEnabled(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+
+	for _, enabled := range []bool{false, true} {
+		if enabled {
+			c.Assert(os.WriteFile(features.Landlock.ControlFile(), nil, 0644), IsNil)
+		}
+		for _, abi := range []int{0, 1, 4} {
+			restore := landlock_sandbox.MockABIVersion(abi)
+			defer restore()
+			for _, level := range []apparmor_sandbox.LevelType{apparmor_sandbox.Unsupported, apparmor_sandbox.Unusable, apparmor_sandbox.Partial, apparmor_sandbox.Full} {
+				restore := apparmor_sandbox.MockLevel(level)
+				defer restore()
+
+				all := backends.All()
+				names := make([]string, len(all))
+				for i, backend := range all {
+					names[i] = string(backend.Name())
+				}
+				switch {
+				case enabled && abi > 0 && (level == apparmor_sandbox.Unsupported || level == apparmor_sandbox.Unusable):
+					c.Check(names, testutil.Contains, "landlock")
+				default:
+					c.Check(names, Not(testutil.Contains), "landlock")
+				}
+			}
+		}
+	}
+}
+
+func (s *backendsSuite) TestIsSELinuxEnabled(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+
+	for _, featureEnabled := range []bool{false, true} {
+		if featureEnabled {
+			c.Assert(os.WriteFile(features.SELinuxConfinement.ControlFile(), nil, 0644), IsNil)
+		}
+		for _, enabled := range []bool{false, true} {
+			restore := selinux_sandbox.MockIsEnabled(func() (bool, error) { return enabled, nil })
+			defer restore()
+			restore = selinux_sandbox.MockIsEnforcing(func() (bool, error) { return true, nil })
+			defer restore()
+
+			all := backends.All()
+			names := make([]string, len(all))
+			for i, backend := range all {
+				names[i] = string(backend.Name())
+			}
+			if featureEnabled && enabled {
+				c.Check(names, testutil.Contains, "selinux")
+			} else {
+				c.Check(names, Not(testutil.Contains), "selinux")
+			}
+		}
+	}
+}
+
 func (s *backendsSuite) TestEssentialOrdering(c *C) {
 	restore := apparmor_sandbox.MockLevel(apparmor_sandbox.Full)
 	defer restore()
@@ -32,9 +32,11 @@ import (
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/interfaces/polkit"
 	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/interfaces/selinux"
 	"github.com/snapcore/snapd/interfaces/systemd"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
@@ -90,6 +92,32 @@ type kmodDefiner4 interface {
 	KModPermanentSlot(spec *kmod.Specification, slot *snap.SlotInfo) error
 }
 
+type landlockDefiner1 interface {
+	LandlockConnectedPlug(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type landlockDefiner2 interface {
+	LandlockConnectedSlot(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type landlockDefiner3 interface {
+	LandlockPermanentPlug(spec *landlock.Specification, plug *snap.PlugInfo) error
+}
+type landlockDefiner4 interface {
+	LandlockPermanentSlot(spec *landlock.Specification, slot *snap.SlotInfo) error
+}
+
+type selinuxDefiner1 interface {
+	SELinuxConnectedPlug(spec *selinux.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type selinuxDefiner2 interface {
+	SELinuxConnectedSlot(spec *selinux.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type selinuxDefiner3 interface {
+	SELinuxPermanentPlug(spec *selinux.Specification, plug *snap.PlugInfo) error
+}
+type selinuxDefiner4 interface {
+	SELinuxPermanentSlot(spec *selinux.Specification, slot *snap.SlotInfo) error
+}
+
 type mountDefiner1 interface {
 	MountConnectedPlug(spec *mount.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
 }
@@ -172,6 +200,16 @@ var allGoodDefiners = []reflect.Type{
 	reflect.TypeOf((*kmodDefiner2)(nil)).Elem(),
 	reflect.TypeOf((*kmodDefiner3)(nil)).Elem(),
 	reflect.TypeOf((*kmodDefiner4)(nil)).Elem(),
+	// landlock
+	reflect.TypeOf((*landlockDefiner1)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner2)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner3)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner4)(nil)).Elem(),
+	// selinux
+	reflect.TypeOf((*selinuxDefiner1)(nil)).Elem(),
+	reflect.TypeOf((*selinuxDefiner2)(nil)).Elem(),
+	reflect.TypeOf((*selinuxDefiner3)(nil)).Elem(),
+	reflect.TypeOf((*selinuxDefiner4)(nil)).Elem(),
 	// mount
 	reflect.TypeOf((*mountDefiner1)(nil)).Elem(),
 	reflect.TypeOf((*mountDefiner2)(nil)).Elem(),
@@ -390,7 +428,7 @@ func (s *AllSuite) TestUnexpectedSpecSignatures(c *C) {
 	var sigs []funcSig
 
 	// All the valid signatures from all the specification definers from all the backends.
-	for _, backend := range []string{"AppArmor", "SecComp", "UDev", "DBus", "Systemd", "KMod", "Polkit"} {
+	for _, backend := range []string{"AppArmor", "SecComp", "UDev", "DBus", "Systemd", "KMod", "Polkit", "Landlock", "SELinux"} {
 		backendLower := strings.ToLower(backend)
 		sigs = append(sigs, []funcSig{{
 			name: fmt.Sprintf("%sPermanentPlug", backend),
@@ -52,6 +52,16 @@ const cameraConnectedPlugAppArmor = `
 /sys/devices/platform/**/usb*/**/video4linux/** r,
 `
 
+const cameraConnectedPlugSELinux = `
+; Description: Can access cameras.
+(allow ###DOMAIN### device_t (dir (getattr open read search)))
+(allow ###DOMAIN### v4l_device_t (chr_file (getattr ioctl map open read write)))
+(allow ###DOMAIN### sysfs_t (dir (getattr open read search)))
+(allow ###DOMAIN### sysfs_t (file (getattr open read)))
+(allow ###DOMAIN### udev_var_run_t (dir (getattr open read search)))
+(allow ###DOMAIN### udev_var_run_t (file (getattr open read)))
+`
+
 var cameraConnectedPlugUDev = []string{
 	`KERNEL=="video[0-9]*"`,
 	`KERNEL=="vchiq"`,
@@ -66,5 +76,6 @@ func init() {
 		baseDeclarationSlots:  cameraBaseDeclarationSlots,
 		connectedPlugAppArmor: cameraConnectedPlugAppArmor,
 		connectedPlugUDev:     cameraConnectedPlugUDev,
+		connectedPlugSELinux:  cameraConnectedPlugSELinux,
 	})
 }
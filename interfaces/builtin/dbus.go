@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/interfaces/selinux"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
 )
@@ -156,6 +157,26 @@ accept
 accept4
 `
 
+// The SELinux policy only grants access to the system bus itself, which
+// names may be owned and talked to is mediated by the bus policy above.
+const dbusSystemBusSELinux = `
+(allow ###DOMAIN### system_dbusd_var_run_t (dir (search)))
+(allow ###DOMAIN### system_dbusd_var_run_t (sock_file (write)))
+(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))
+`
+
+const dbusPermanentSlotSELinux = `
+; Description: Allow owning a name on the DBus system bus
+(allow ###DOMAIN### system_dbusd_t (dbus (acquire_svc send_msg)))
+(allow ###DOMAIN### domain (dbus (send_msg)))
+`
+
+const dbusConnectedPlugSELinux = `
+; Description: Allow talking to a name on the DBus system bus
+(allow ###DOMAIN### system_dbusd_t (dbus (send_msg)))
+(allow ###DOMAIN### domain (dbus (send_msg)))
+`
+
 const dbusConnectedSlotAppArmor = `
 # allow snaps to introspect us. This allows clients to introspect all
 # DBus interfaces of this service (but not use them).
@@ -399,6 +420,43 @@ func (iface *dbusInterface) SecCompPermanentSlot(spec *seccomp.Specification, sl
 	return nil
 }
 
+func (iface *dbusInterface) SELinuxPermanentSlot(spec *selinux.Specification, slot *snap.SlotInfo) error {
+	bus, _, err := iface.getAttribs(slot)
+	if err != nil {
+		return err
+	}
+
+	// the session bus is left to the base policy
+	if bus != "system" {
+		return nil
+	}
+	spec.AddSnippet(dbusSystemBusSELinux + dbusPermanentSlotSELinux)
+	return nil
+}
+
+func (iface *dbusInterface) SELinuxConnectedPlug(spec *selinux.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	bus, name, err := iface.getAttribs(plug)
+	if err != nil {
+		return err
+	}
+
+	busSlot, nameSlot, err := iface.getAttribs(slot)
+	if err != nil {
+		return err
+	}
+
+	// ensure that we only connect to slot with matching attributes
+	if bus != busSlot || name != nameSlot {
+		return nil
+	}
+
+	if bus != "system" {
+		return nil
+	}
+	spec.AddSnippet(dbusSystemBusSELinux + dbusConnectedPlugSELinux)
+	return nil
+}
+
 func (iface *dbusInterface) AppArmorConnectedSlot(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	bus, name, err := iface.getAttribs(slot)
 	if err != nil {
@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/interfaces/selinux"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
@@ -488,6 +489,40 @@ func (s *DbusInterfaceSuite) TestConnectedPlugAppArmorSystem(c *C) {
 	c.Check(string(snippet), testutil.Contains, "interface=\"org.test-system-connected{,.*}\"\n")
 }
 
+func (s *DbusInterfaceSuite) TestPermanentSlotSELinuxSystem(c *C) {
+	selinuxSpec := selinux.NewSpecification(s.systemSlot.AppSet())
+	err := selinuxSpec.AddPermanentSlot(s.iface, s.systemSlotInfo)
+	c.Assert(err, IsNil)
+	c.Assert(selinuxSpec.SecurityTags(), DeepEquals, []string{"snap.test-dbus.test-system-provider"})
+	snippet := selinuxSpec.SnippetForTag("snap.test-dbus.test-system-provider")
+	c.Check(snippet, testutil.Contains, "(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))\n")
+	c.Check(snippet, testutil.Contains, "(allow ###DOMAIN### system_dbusd_t (dbus (acquire_svc send_msg)))\n")
+}
+
+func (s *DbusInterfaceSuite) TestPermanentSlotSELinuxSession(c *C) {
+	selinuxSpec := selinux.NewSpecification(s.sessionSlot.AppSet())
+	err := selinuxSpec.AddPermanentSlot(s.iface, s.sessionSlotInfo)
+	c.Assert(err, IsNil)
+	c.Assert(selinuxSpec.SecurityTags(), HasLen, 0)
+}
+
+func (s *DbusInterfaceSuite) TestConnectedPlugSELinuxSystem(c *C) {
+	selinuxSpec := selinux.NewSpecification(s.connectedSystemPlug.AppSet())
+	err := selinuxSpec.AddConnectedPlug(s.iface, s.connectedSystemPlug, s.connectedSystemSlot)
+	c.Assert(err, IsNil)
+	c.Assert(selinuxSpec.SecurityTags(), Not(HasLen), 0)
+	snippet := selinuxSpec.SnippetForTag(selinuxSpec.SecurityTags()[0])
+	c.Check(snippet, testutil.Contains, "(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))\n")
+	c.Check(snippet, Not(testutil.Contains), "acquire_svc")
+}
+
+func (s *DbusInterfaceSuite) TestConnectedPlugSELinuxSession(c *C) {
+	selinuxSpec := selinux.NewSpecification(s.connectedSessionPlug.AppSet())
+	err := selinuxSpec.AddConnectedPlug(s.iface, s.connectedSessionPlug, s.connectedSessionSlot)
+	c.Assert(err, IsNil)
+	c.Assert(selinuxSpec.SecurityTags(), HasLen, 0)
+}
+
 func (s *DbusInterfaceSuite) TestConnectionFirst(c *C) {
 	const plugYaml = `name: plugger
 version: 1.0
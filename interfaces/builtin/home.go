@@ -24,6 +24,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/snap"
 )
 
@@ -101,6 +102,18 @@ capability dac_read_search,
 @{HOME}/{s,sn,sna}{,/} r,
 `
 
+// Landlock can neither match on file ownership nor exclude hidden files, those
+// are left to DAC.
+const homeConnectedPlugLandlock = `
+# Description: Can access files in the user's $HOME.
+fs rw $SNAP_REAL_HOME
+`
+
+const homeConnectedPlugLandlockWithAllRead = `
+# Allow read access to all of /home
+fs r /home
+`
+
 type homeInterface struct {
 	commonInterface
 }
@@ -129,6 +142,16 @@ func (iface *homeInterface) AppArmorConnectedPlug(spec *apparmor.Specification,
 	return nil
 }
 
+func (iface *homeInterface) LandlockConnectedPlug(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	var read string
+	_ = plug.Attr("read", &read)
+	spec.AddSnippet(homeConnectedPlugLandlock)
+	if read == "all" {
+		spec.AddSnippet(homeConnectedPlugLandlockWithAllRead)
+	}
+	return nil
+}
+
 func init() {
 	registerIface(&homeInterface{commonInterface{
 		name:                 "home",
@@ -90,6 +90,19 @@ socket AF_NETLINK - NETLINK_ROUTE
 socket AF_CONN
 `
 
+const networkConnectedPlugLandlock = `
+# Description: Can connect to any TCP port.
+net connect *
+`
+
+const networkConnectedPlugSELinux = `
+; Description: Can access the network as a client.
+(allow ###DOMAIN### self (tcp_socket (create connect getattr getopt setopt read write shutdown)))
+(allow ###DOMAIN### self (udp_socket (create connect getattr getopt setopt read write)))
+(allow ###DOMAIN### port_type (tcp_socket (name_connect)))
+(allow ###DOMAIN### net_conf_t (file (getattr open read)))
+`
+
 func init() {
 	registerIface(&commonInterface{
 		name:                  "network",
@@ -99,5 +112,7 @@ func init() {
 		baseDeclarationSlots:  networkBaseDeclarationSlots,
 		connectedPlugAppArmor: networkConnectedPlugAppArmor,
 		connectedPlugSecComp:  networkConnectedPlugSecComp,
+		connectedPlugLandlock: networkConnectedPlugLandlock,
+		connectedPlugSELinux:  networkConnectedPlugSELinux,
 	})
 }
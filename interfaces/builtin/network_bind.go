@@ -95,6 +95,21 @@ listen
 socket AF_NETLINK - NETLINK_ROUTE
 `
 
+const networkBindConnectedPlugLandlock = `
+# Description: Can bind to any TCP port.
+net bind *
+`
+
+const networkBindConnectedPlugSELinux = `
+; Description: Can act as a network server.
+(allow ###DOMAIN### self (tcp_socket (create bind listen accept getattr getopt setopt read write shutdown)))
+(allow ###DOMAIN### self (udp_socket (create bind getattr getopt setopt read write)))
+(allow ###DOMAIN### port_type (tcp_socket (name_bind)))
+(allow ###DOMAIN### port_type (udp_socket (name_bind)))
+(allow ###DOMAIN### node_t (tcp_socket (node_bind)))
+(allow ###DOMAIN### node_t (udp_socket (node_bind)))
+`
+
 func init() {
 	registerIface(&commonInterface{
 		name:                  "network-bind",
@@ -104,5 +119,7 @@ func init() {
 		baseDeclarationSlots:  networkBindBaseDeclarationSlots,
 		connectedPlugAppArmor: networkBindConnectedPlugAppArmor,
 		connectedPlugSecComp:  networkBindConnectedPlugSecComp,
+		connectedPlugLandlock: networkBindConnectedPlugLandlock,
+		connectedPlugSELinux:  networkBindConnectedPlugSELinux,
 	})
 }
@@ -49,6 +49,13 @@ const removableMediaConnectedPlugAppArmor = `
 /mnt/** mrwklix,
 `
 
+const removableMediaConnectedPlugLandlock = `
+# Description: Can access removable storage filesystems
+fs rw /media
+fs rw /run/media
+fs rw /mnt
+`
+
 func init() {
 	registerIface(&commonInterface{
 		name:                  "removable-media",
@@ -57,5 +64,6 @@ func init() {
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  removableMediaBaseDeclarationSlots,
 		connectedPlugAppArmor: removableMediaConnectedPlugAppArmor,
+		connectedPlugLandlock: removableMediaConnectedPlugLandlock,
 	})
 }
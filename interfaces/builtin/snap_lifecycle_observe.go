@@ -0,0 +1,50 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+const snapLifecycleObserveSummary = `allows read access to track the installation, refresh and removal of snaps through snapctl`
+
+const snapLifecycleObserveBaseDeclarationPlugs = `
+  snap-lifecycle-observe:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const snapLifecycleObserveBaseDeclarationSlots = `
+  snap-lifecycle-observe:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// The interface does not grant any additional permissions, instead the
+// "snapctl notices" command checks that it is connected before giving access
+// to the notices about the snap changes.
+func init() {
+	registerIface(&commonInterface{
+		name:                 "snap-lifecycle-observe",
+		summary:              snapLifecycleObserveSummary,
+		implicitOnCore:       true,
+		implicitOnClassic:    true,
+		baseDeclarationPlugs: snapLifecycleObserveBaseDeclarationPlugs,
+		baseDeclarationSlots: snapLifecycleObserveBaseDeclarationSlots,
+	})
+}
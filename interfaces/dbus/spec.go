@@ -33,6 +33,8 @@ type Specification struct {
 	appSet       *interfaces.SnapAppSet
 	snippets     map[string][]string
 	securityTags []string
+	// provenance keeps track of the interfaces which contributed snippets
+	provenance interfaces.Provenance
 }
 
 func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
@@ -54,6 +56,7 @@ func (spec *Specification) AddSnippet(snippet string) {
 	for _, tag := range spec.securityTags {
 		spec.snippets[tag] = append(spec.snippets[tag], snippet)
 	}
+	spec.provenance.Record(spec.securityTags, snippet)
 }
 
 // Snippets returns a deep copy of all the added snippets.
@@ -86,6 +89,12 @@ func (spec *Specification) SecurityTags() []string {
 	return tags
 }
 
+// ExplainedRules returns the rules contributed by interfaces along with their
+// origin.
+func (spec *Specification) ExplainedRules() []interfaces.ExplainedRule {
+	return spec.provenance.Rules(interfaces.SecurityDBus)
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records dbus-specific side-effects of having a connected plug.
@@ -101,6 +110,7 @@ func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *in
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.ConnectedPlugOrigin(plug, slot))()
 		return iface.DBusConnectedPlug(spec, plug, slot)
 	}
 	return nil
@@ -119,6 +129,7 @@ func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *in
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.ConnectedSlotOrigin(plug, slot))()
 		return iface.DBusConnectedSlot(spec, plug, slot)
 	}
 	return nil
@@ -137,6 +148,7 @@ func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *sn
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.PermanentPlugOrigin(plug))()
 		return iface.DBusPermanentPlug(spec, plug)
 	}
 	return nil
@@ -155,6 +167,7 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.PermanentSlotOrigin(slot))()
 		return iface.DBusPermanentSlot(spec, slot)
 	}
 	return nil
@@ -106,3 +106,43 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 
 	c.Assert(spec.SnippetForTag("non-existing"), Equals, "")
 }
+
+func (s *specSuite) TestExplainedRules(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := dbus.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(spec.AddPermanentPlug(s.iface, s.plugInfo), IsNil)
+	// snippets added outside of an interface have no origin
+	spec.AddSnippet("ignored")
+	plugRef := &interfaces.PlugRef{Snap: "snap1", Name: "name"}
+	slotRef := &interfaces.SlotRef{Snap: "snap2", Name: "name"}
+	c.Check(spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecurityDBus,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "connected-plug",
+		Origin:      interfaces.RuleOrigin{Interface: "name", Plug: plugRef, Slot: slotRef, Source: interfaces.SourceConnectedPlug},
+	}, {
+		Backend:     interfaces.SecurityDBus,
+		SecurityTag: "snap.snap1.app1",
+		Rule:        "permanent-plug",
+		Origin:      interfaces.RuleOrigin{Interface: "name", Plug: plugRef, Source: interfaces.SourcePermanentPlug},
+	}})
+
+	appSet, err = interfaces.NewSnapAppSet(s.slot.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec = dbus.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedSlot(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(spec.AddPermanentSlot(s.iface, s.slotInfo), IsNil)
+	c.Check(spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecurityDBus,
+		SecurityTag: "snap.snap2.app2",
+		Rule:        "connected-slot",
+		Origin:      interfaces.RuleOrigin{Interface: "test", Plug: plugRef, Slot: slotRef, Source: interfaces.SourceConnectedSlot},
+	}, {
+		Backend:     interfaces.SecurityDBus,
+		SecurityTag: "snap.snap2.app2",
+		Rule:        "permanent-slot",
+		Origin:      interfaces.RuleOrigin{Interface: "test", Slot: slotRef, Source: interfaces.SourcePermanentSlot},
+	}})
+}
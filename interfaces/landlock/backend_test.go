@@ -0,0 +1,187 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/landlock"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type backendSuite struct {
+	ifacetest.BackendSuite
+}
+
+var _ = Suite(&backendSuite{})
+
+var testedConfinementOpts = []interfaces.ConfinementOptions{
+	{},
+	{DevMode: true},
+	{JailMode: true},
+	{Classic: true},
+}
+
+func (s *backendSuite) SetUpTest(c *C) {
+	s.Backend = &landlock.Backend{}
+	s.BackendSuite.SetUpTest(c)
+	c.Assert(s.Repo.AddBackend(s.Backend), IsNil)
+}
+
+func (s *backendSuite) TearDownTest(c *C) {
+	s.BackendSuite.TearDownTest(c)
+}
+
+func (s *backendSuite) TestInitialize(c *C) {
+	err := s.Backend.Initialize(nil)
+	c.Assert(err, IsNil)
+}
+
+// Tests for Setup() and Remove()
+func (s *backendSuite) TestName(c *C) {
+	c.Check(s.Backend.Name(), Equals, interfaces.SecurityLandlock)
+}
+
+func (s *backendSuite) TestInstallingSnapWritesProfiles(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd.landlock")
+	c.Check(profile, testutil.FileContains, "fs rx /snap/samba\n")
+	c.Check(profile, testutil.FileContains, "fs rw /var/snap/samba\n")
+	c.Check(profile, Not(testutil.FileContains), "@")
+}
+
+func (s *backendSuite) TestInstallingSnapWritesHookProfiles(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.HookYaml, 0)
+	profile := filepath.Join(dirs.SnapSeccompDir, "snap.foo.hook.configure.landlock")
+	c.Check(profile, testutil.FilePresent)
+}
+
+func (s *backendSuite) TestInstallingParallelInstanceUsesSnapName(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "samba_foo", ifacetest.SambaYamlV1, 0)
+	profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba_foo.smbd.landlock")
+	c.Check(profile, testutil.FileContains, "fs rx /snap/samba\n")
+}
+
+func (s *backendSuite) TestRemovingSnapRemovesProfiles(c *C) {
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+		s.RemoveSnap(c, snapInfo)
+		profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd.landlock")
+		c.Check(profile, testutil.FileAbsent)
+	}
+}
+
+func (s *backendSuite) TestRemovingSnapLeavesSeccompProfiles(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapSeccompDir, 0755), IsNil)
+	seccompProfile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd.src")
+	c.Assert(os.WriteFile(seccompProfile, nil, 0644), IsNil)
+
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	c.Check(seccompProfile, testutil.FilePresent)
+	s.RemoveSnap(c, snapInfo)
+	c.Check(seccompProfile, testutil.FilePresent)
+}
+
+func (s *backendSuite) TestUpdatingSnapToOneWithFewerApps(c *C) {
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1WithNmbd, 0)
+		snapInfo = s.UpdateSnap(c, snapInfo, opts, ifacetest.SambaYamlV1, 0)
+		profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.nmbd.landlock")
+		c.Check(profile, testutil.FileAbsent)
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestConfinementModes(c *C) {
+	for _, t := range []struct {
+		opts     interfaces.ConfinementOptions
+		expected string
+	}{
+		{interfaces.ConfinementOptions{}, ""},
+		{interfaces.ConfinementOptions{DevMode: true}, "@complain\n"},
+		{interfaces.ConfinementOptions{DevMode: true, JailMode: true}, ""},
+		{interfaces.ConfinementOptions{Classic: true}, "@unrestricted\n"},
+		{interfaces.ConfinementOptions{Classic: true, DevMode: true}, "@unrestricted\n@complain\n"},
+	} {
+		snapInfo := s.InstallSnap(c, t.opts, "", ifacetest.SambaYamlV1, 0)
+		profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd.landlock")
+		data, err := os.ReadFile(profile)
+		c.Assert(err, IsNil)
+		c.Check(string(data), testutil.Contains, t.expected+"\n# Description:")
+		if t.expected == "" {
+			c.Check(string(data), Not(testutil.Contains), "@")
+		}
+		parsed, err := landlock_sandbox.ParseProfile(bytes.NewReader(data))
+		c.Assert(err, IsNil)
+		c.Check(parsed.Complain, Equals, t.opts.DevMode && !t.opts.JailMode)
+		c.Check(parsed.Unrestricted, Equals, t.opts.Classic && !t.opts.JailMode)
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestCombineSnippets(c *C) {
+	s.Iface.LandlockPermanentSlotCallback = func(spec *landlock.Specification, slot *snap.SlotInfo) error {
+		spec.AddSnippet("net bind *")
+		spec.AddSnippet("fs rw /media")
+		return nil
+	}
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	profile := filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd.landlock")
+	c.Check(profile, testutil.FileContains, "fs r /dev/log\nfs rw /media\nnet bind *\n")
+}
+
+func (s *backendSuite) TestInvalidSnippet(c *C) {
+	s.Iface.LandlockPermanentSlotCallback = func(spec *landlock.Specification, slot *snap.SlotInfo) error {
+		spec.AddSnippet("fs rw media")
+		return nil
+	}
+	snapInfo := snaptest.MockInfo(c, ifacetest.SambaYamlV1, nil)
+	appSet, err := interfaces.NewSnapAppSet(snapInfo, nil)
+	c.Assert(err, IsNil)
+	c.Assert(s.Repo.AddAppSet(appSet), IsNil)
+	err = s.Backend.Setup(appSet, interfaces.ConfinementOptions{}, s.Repo, timings.New(nil))
+	c.Check(err, ErrorMatches, `cannot obtain expected security files for snap "samba": invalid landlock snippet for "snap.samba.smbd": cannot parse line 1: path "media" must be absolute and clean`)
+}
+
+func (s *backendSuite) TestSandboxFeatures(c *C) {
+	restore := landlock_sandbox.MockABIVersion(0)
+	defer restore()
+	c.Check(s.Backend.SandboxFeatures(), HasLen, 0)
+
+	restore = landlock_sandbox.MockABIVersion(4)
+	defer restore()
+	c.Check(s.Backend.SandboxFeatures(), DeepEquals, []string{"abi:4", "fs", "fs-refer", "fs-truncate", "net"})
+}
@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock
+
+// defaultTemplate contains the rules common to all strictly confined snaps.
+//
+// The rules are evaluated inside the mount namespace of the snap, where the
+// root file system is provided by the base snap and parallel instances see
+// their own data under the name of the snap. Landlock does not support
+// globbing, so access is granted to whole directory hierarchies, and rules
+// for paths that do not exist are skipped when the profile is applied.
+//
+// Landlock does not mediate connecting to UNIX sockets nor UDP traffic,
+// access to those is left to seccomp and to DAC.
+var defaultTemplate = []byte(`
+# Description: Allows access to the base snap and to the data of the snap
+# itself. This is the landlock counterpart of the default AppArmor template.
+
+# The base snap
+fs rx /bin
+fs rx /lib
+fs rx /lib32
+fs rx /lib64
+fs rx /libx32
+fs rx /sbin
+fs rx /usr
+fs r /etc
+
+# The snap itself
+fs rx /snap/###SNAP_NAME###
+
+# Data of the snap
+fs rw /var/snap/###SNAP_NAME###
+fs rw $SNAP_USER_DATA
+fs rw $SNAP_USER_COMMON
+fs rw $XDG_RUNTIME_DIR
+fs rw /run/snap.###SNAP_NAME###
+
+# Private /tmp and shared memory
+fs rw /tmp
+fs rw /var/tmp
+fs rw /dev/shm
+
+# Kernel interfaces, write access is mediated by DAC
+fs r /proc
+fs r /sys
+
+# Runtime information like the resolver configuration
+fs r /run
+
+# Common device nodes
+fs rw /dev/null
+fs rw /dev/zero
+fs rw /dev/full
+fs r /dev/random
+fs r /dev/urandom
+fs rw /dev/tty
+fs rw /dev/pts
+fs rw /dev/ptmx
+fs r /dev/log
+`)
@@ -46,17 +46,22 @@ type Specification struct {
 	general  []osutil.MountEntry
 	user     []osutil.MountEntry
 	overname []osutil.MountEntry
+
+	// provenance keeps track of the interfaces which contributed entries
+	provenance interfaces.Provenance
 }
 
 // AddMountEntry adds a new mount entry.
 func (spec *Specification) AddMountEntry(e osutil.MountEntry) error {
 	spec.general = append(spec.general, e)
+	spec.provenance.Record(nil, e.String())
 	return nil
 }
 
 // AddUserMountEntry adds a new user mount entry.
 func (spec *Specification) AddUserMountEntry(e osutil.MountEntry) error {
 	spec.user = append(spec.user, e)
+	spec.provenance.Record(nil, e.String())
 	return nil
 }
 
@@ -321,6 +326,12 @@ func unclashMountEntries(entries []osutil.MountEntry) []osutil.MountEntry {
 	return result
 }
 
+// ExplainedRules returns the mount entries contributed by interfaces along
+// with their origin.
+func (spec *Specification) ExplainedRules() []interfaces.ExplainedRule {
+	return spec.provenance.Rules(interfaces.SecurityMount)
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records mount-specific side-effects of having a connected plug.
@@ -329,6 +340,7 @@ func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *in
 		MountConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
 	}
 	if iface, ok := iface.(definer); ok {
+		defer spec.provenance.Track(interfaces.ConnectedPlugOrigin(plug, slot))()
 		return iface.MountConnectedPlug(spec, plug, slot)
 	}
 	return nil
@@ -340,6 +352,7 @@ func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *in
 		MountConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
 	}
 	if iface, ok := iface.(definer); ok {
+		defer spec.provenance.Track(interfaces.ConnectedSlotOrigin(plug, slot))()
 		return iface.MountConnectedSlot(spec, plug, slot)
 	}
 	return nil
@@ -351,6 +364,7 @@ func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *sn
 		MountPermanentPlug(spec *Specification, plug *snap.PlugInfo) error
 	}
 	if iface, ok := iface.(definer); ok {
+		defer spec.provenance.Track(interfaces.PermanentPlugOrigin(plug))()
 		return iface.MountPermanentPlug(spec, plug)
 	}
 	return nil
@@ -362,6 +376,7 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 		MountPermanentSlot(spec *Specification, slot *snap.SlotInfo) error
 	}
 	if iface, ok := iface.(definer); ok {
+		defer spec.provenance.Track(interfaces.PermanentSlotOrigin(slot))()
 		return iface.MountPermanentSlot(spec, slot)
 	}
 	return nil
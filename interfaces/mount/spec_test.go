@@ -160,6 +160,30 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 		{Dir: "dir-d", Name: "permanent-slot"}})
 }
 
+func (s *specSuite) TestExplainedRules(c *C) {
+	c.Assert(s.spec.AddPermanentPlug(s.iface, s.plugInfo), IsNil)
+	// entries not coming from interfaces have no origin
+	s.spec.AddLayout(snaptest.MockInfo(c, snapWithLayout, &snap.SideInfo{Revision: snap.R(42)}))
+	c.Assert(s.spec.AddPermanentSlot(s.iface, s.slotInfo), IsNil)
+	c.Check(s.spec.ExplainedRules(), DeepEquals, []interfaces.ExplainedRule{{
+		Backend: interfaces.SecurityMount,
+		Rule:    "permanent-plug dir-c none defaults 0 0",
+		Origin: interfaces.RuleOrigin{
+			Interface: "name",
+			Plug:      &interfaces.PlugRef{Snap: "snap", Name: "name"},
+			Source:    interfaces.SourcePermanentPlug,
+		},
+	}, {
+		Backend: interfaces.SecurityMount,
+		Rule:    "permanent-slot dir-d none defaults 0 0",
+		Origin: interfaces.RuleOrigin{
+			Interface: "test",
+			Slot:      &interfaces.SlotRef{Snap: "snap", Name: "name"},
+			Source:    interfaces.SourcePermanentSlot,
+		},
+	}})
+}
+
 const snapWithLayout = `
 name: vanguard
 version: 0
@@ -1037,6 +1037,7 @@ func (s *baseDeclSuite) TestPlugInstallation(c *C) {
 		"sd-control":                       true,
 		"shutdown":                         true,
 		"snap-interfaces-requests-control": true,
+		"snap-lifecycle-observe":           true,
 		"snap-refresh-control":             true,
 		"snap-refresh-observe":             true,
 		"snap-themes-control":              true,
@@ -1343,6 +1344,7 @@ func (s *baseDeclSuite) TestValidity(c *C) {
 		"shutdown":                         true,
 		"shared-memory":                    true,
 		"snap-interfaces-requests-control": true,
+		"snap-lifecycle-observe":           true,
 		"snap-refresh-control":             true,
 		"snap-refresh-observe":             true,
 		"snap-themes-control":              true,
@@ -30,6 +30,32 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
+// DeclarationKind identifies the kind of declaration a rule comes from.
+type DeclarationKind string
+
+const (
+	// SnapDeclaration is the snap-declaration of a snap.
+	SnapDeclaration DeclarationKind = "snap-declaration"
+	// BaseDeclaration is the base-declaration of the system.
+	BaseDeclaration DeclarationKind = "base-declaration"
+)
+
+// DecidingRule identifies the declaration rule which decided the outcome of
+// a policy check.
+type DecidingRule struct {
+	Declaration DeclarationKind
+	// Side is either "plug" or "slot".
+	Side      string
+	Interface string
+	// Constraint is the constraint of the rule which decided the outcome,
+	// as in "allow-installation" or "deny-auto-connection".
+	Constraint string
+}
+
+func (r *DecidingRule) String() string {
+	return fmt.Sprintf("%s of %s rule of interface %q in %s", r.Constraint, r.Side, r.Interface, r.Declaration)
+}
+
 // InstallCandidate represents a candidate snap for installation.
 type InstallCandidate struct {
 	Snap            *snap.Info
@@ -48,58 +74,62 @@ func (ic *InstallCandidate) snapID() string {
 	return "" // never a valid snap-id
 }
 
-func (ic *InstallCandidate) checkSlotRule(slot *snap.SlotInfo, rule *asserts.SlotRule, snapRule bool) error {
+func (ic *InstallCandidate) checkSlotRule(slot *snap.SlotInfo, rule *asserts.SlotRule, snapRule bool) (constraint string, err error) {
 	context := ""
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", ic.SnapDeclaration.SnapName())
 	}
 	if checkSlotInstallationAltConstraints(ic, slot, rule.DenyInstallation) == nil {
-		return fmt.Errorf("installation denied by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
+		return "deny-installation", fmt.Errorf("installation denied by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
 	}
 	if checkSlotInstallationAltConstraints(ic, slot, rule.AllowInstallation) != nil {
-		return fmt.Errorf("installation not allowed by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
+		return "allow-installation", fmt.Errorf("installation not allowed by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
 	}
-	return nil
+	return "allow-installation", nil
 }
 
-func (ic *InstallCandidate) checkPlugRule(plug *snap.PlugInfo, rule *asserts.PlugRule, snapRule bool) error {
+func (ic *InstallCandidate) checkPlugRule(plug *snap.PlugInfo, rule *asserts.PlugRule, snapRule bool) (constraint string, err error) {
 	context := ""
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", ic.SnapDeclaration.SnapName())
 	}
 	if checkPlugInstallationAltConstraints(ic, plug, rule.DenyInstallation) == nil {
-		return fmt.Errorf("installation denied by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
+		return "deny-installation", fmt.Errorf("installation denied by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
 	}
 	if checkPlugInstallationAltConstraints(ic, plug, rule.AllowInstallation) != nil {
-		return fmt.Errorf("installation not allowed by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
+		return "allow-installation", fmt.Errorf("installation not allowed by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
 	}
-	return nil
+	return "allow-installation", nil
 }
 
-func (ic *InstallCandidate) checkSlot(slot *snap.SlotInfo) error {
+func (ic *InstallCandidate) checkSlot(slot *snap.SlotInfo) (*DecidingRule, error) {
 	iface := slot.Interface
 	if snapDecl := ic.SnapDeclaration; snapDecl != nil {
 		if rule := snapDecl.SlotRule(iface); rule != nil {
-			return ic.checkSlotRule(slot, rule, true)
+			constraint, err := ic.checkSlotRule(slot, rule, true)
+			return &DecidingRule{SnapDeclaration, "slot", iface, constraint}, err
 		}
 	}
 	if rule := ic.BaseDeclaration.SlotRule(iface); rule != nil {
-		return ic.checkSlotRule(slot, rule, false)
+		constraint, err := ic.checkSlotRule(slot, rule, false)
+		return &DecidingRule{BaseDeclaration, "slot", iface, constraint}, err
 	}
-	return nil
+	return nil, nil
 }
 
-func (ic *InstallCandidate) checkPlug(plug *snap.PlugInfo) error {
+func (ic *InstallCandidate) checkPlug(plug *snap.PlugInfo) (*DecidingRule, error) {
 	iface := plug.Interface
 	if snapDecl := ic.SnapDeclaration; snapDecl != nil {
 		if rule := snapDecl.PlugRule(iface); rule != nil {
-			return ic.checkPlugRule(plug, rule, true)
+			constraint, err := ic.checkPlugRule(plug, rule, true)
+			return &DecidingRule{SnapDeclaration, "plug", iface, constraint}, err
 		}
 	}
 	if rule := ic.BaseDeclaration.PlugRule(iface); rule != nil {
-		return ic.checkPlugRule(plug, rule, false)
+		constraint, err := ic.checkPlugRule(plug, rule, false)
+		return &DecidingRule{BaseDeclaration, "plug", iface, constraint}, err
 	}
-	return nil
+	return nil, nil
 }
 
 // Check checks whether the installation is allowed.
@@ -109,14 +139,14 @@ func (ic *InstallCandidate) Check() error {
 	}
 
 	for _, slot := range ic.Snap.Slots {
-		err := ic.checkSlot(slot)
+		_, err := ic.checkSlot(slot)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, plug := range ic.Snap.Plugs {
-		err := ic.checkPlug(plug)
+		_, err := ic.checkPlug(plug)
 		if err != nil {
 			return err
 		}
@@ -125,6 +155,28 @@ func (ic *InstallCandidate) Check() error {
 	return nil
 }
 
+// ExplainSlot checks whether the installation of the given slot of the
+// snap is allowed, returning also the rule which decided it. The returned
+// rule is nil if no rule applies to the slot, in which case installation
+// is allowed.
+func (ic *InstallCandidate) ExplainSlot(slot *snap.SlotInfo) (*DecidingRule, error) {
+	if ic.BaseDeclaration == nil {
+		return nil, fmt.Errorf("internal error: improperly initialized InstallCandidate")
+	}
+	return ic.checkSlot(slot)
+}
+
+// ExplainPlug checks whether the installation of the given plug of the
+// snap is allowed, returning also the rule which decided it. The returned
+// rule is nil if no rule applies to the plug, in which case installation
+// is allowed.
+func (ic *InstallCandidate) ExplainPlug(plug *snap.PlugInfo) (*DecidingRule, error) {
+	if ic.BaseDeclaration == nil {
+		return nil, fmt.Errorf("internal error: improperly initialized InstallCandidate")
+	}
+	return ic.checkPlug(plug)
+}
+
 // ConnectCandidate represents a candidate connection.
 type ConnectCandidate struct {
 	Plug                *interfaces.ConnectedPlug
@@ -183,7 +235,7 @@ func (connc *ConnectCandidate) SlotPublisherID() string {
 	return "" // never a valid publisher-id
 }
 
-func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule, snapRule bool) (interfaces.SideArity, error) {
+func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule, snapRule bool) (interfaces.SideArity, string, error) {
 	context := ""
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", connc.PlugSnapDeclaration.SnapName())
@@ -195,17 +247,17 @@ func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule
 		allowConst = rule.AllowAutoConnection
 	}
 	if _, err := checkPlugConnectionAltConstraints(connc, denyConst); err == nil {
-		return nil, fmt.Errorf("%s denied by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
+		return nil, "deny-" + kind, fmt.Errorf("%s denied by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
 	allowedConstraints, err := checkPlugConnectionAltConstraints(connc, allowConst)
 	if err != nil {
-		return nil, fmt.Errorf("%s not allowed by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
+		return nil, "allow-" + kind, fmt.Errorf("%s not allowed by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
-	return sideArity{allowedConstraints.SlotsPerPlug}, nil
+	return sideArity{allowedConstraints.SlotsPerPlug}, "allow-" + kind, nil
 }
 
-func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule, snapRule bool) (interfaces.SideArity, error) {
+func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule, snapRule bool) (interfaces.SideArity, string, error) {
 	context := ""
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", connc.SlotSnapDeclaration.SnapName())
@@ -217,65 +269,85 @@ func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule
 		allowConst = rule.AllowAutoConnection
 	}
 	if _, err := checkSlotConnectionAltConstraints(connc, denyConst); err == nil {
-		return nil, fmt.Errorf("%s denied by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
+		return nil, "deny-" + kind, fmt.Errorf("%s denied by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
 	allowedConstraints, err := checkSlotConnectionAltConstraints(connc, allowConst)
 	if err != nil {
-		return nil, fmt.Errorf("%s not allowed by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
+		return nil, "allow-" + kind, fmt.Errorf("%s not allowed by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
-	return sideArity{allowedConstraints.SlotsPerPlug}, nil
+	return sideArity{allowedConstraints.SlotsPerPlug}, "allow-" + kind, nil
 }
 
-func (connc *ConnectCandidate) check(kind string) (interfaces.SideArity, error) {
+func (connc *ConnectCandidate) check(kind string) (interfaces.SideArity, *DecidingRule, error) {
 	baseDecl := connc.BaseDeclaration
 	if baseDecl == nil {
-		return nil, fmt.Errorf("internal error: improperly initialized ConnectCandidate")
+		return nil, nil, fmt.Errorf("internal error: improperly initialized ConnectCandidate")
 	}
 
 	iface := connc.Plug.Interface()
 
 	if connc.Slot.Interface() != iface {
-		return nil, fmt.Errorf("cannot connect mismatched plug interface %q to slot interface %q", iface, connc.Slot.Interface())
+		return nil, nil, fmt.Errorf("cannot connect mismatched plug interface %q to slot interface %q", iface, connc.Slot.Interface())
 	}
 
 	if plugDecl := connc.PlugSnapDeclaration; plugDecl != nil {
 		if rule := plugDecl.PlugRule(iface); rule != nil {
-			return connc.checkPlugRule(kind, rule, true)
+			arity, constraint, err := connc.checkPlugRule(kind, rule, true)
+			return arity, &DecidingRule{SnapDeclaration, "plug", iface, constraint}, err
 		}
 	}
 	if slotDecl := connc.SlotSnapDeclaration; slotDecl != nil {
 		if rule := slotDecl.SlotRule(iface); rule != nil {
-			return connc.checkSlotRule(kind, rule, true)
+			arity, constraint, err := connc.checkSlotRule(kind, rule, true)
+			return arity, &DecidingRule{SnapDeclaration, "slot", iface, constraint}, err
 		}
 	}
 	if rule := baseDecl.PlugRule(iface); rule != nil {
-		return connc.checkPlugRule(kind, rule, false)
+		arity, constraint, err := connc.checkPlugRule(kind, rule, false)
+		return arity, &DecidingRule{BaseDeclaration, "plug", iface, constraint}, err
 	}
 	if rule := baseDecl.SlotRule(iface); rule != nil {
-		return connc.checkSlotRule(kind, rule, false)
+		arity, constraint, err := connc.checkSlotRule(kind, rule, false)
+		return arity, &DecidingRule{BaseDeclaration, "slot", iface, constraint}, err
 	}
-	return nil, nil
+	return nil, nil, nil
 }
 
 // Check checks whether the connection is allowed.
 func (connc *ConnectCandidate) Check() error {
-	_, err := connc.check("connection")
+	_, _, err := connc.check("connection")
 	return err
 }
 
+// ExplainConnect checks whether the connection is allowed, returning also
+// the rule which decided it. The returned rule is nil if no rule applies to
+// the interface, in which case the connection is allowed.
+func (connc *ConnectCandidate) ExplainConnect() (*DecidingRule, error) {
+	_, rule, err := connc.check("connection")
+	return rule, err
+}
+
 // CheckAutoConnect checks whether the connection is allowed to auto-connect.
 func (connc *ConnectCandidate) CheckAutoConnect() (interfaces.SideArity, error) {
-	arity, err := connc.check("auto-connection")
+	arity, _, err := connc.ExplainAutoConnect()
+	return arity, err
+}
+
+// ExplainAutoConnect checks whether the connection is allowed to
+// auto-connect, returning also the rule which decided it. The returned rule
+// is nil if no rule applies to the interface.
+func (connc *ConnectCandidate) ExplainAutoConnect() (interfaces.SideArity, *DecidingRule, error) {
+	arity, rule, err := connc.check("auto-connection")
 	if err != nil {
-		return nil, err
+		return nil, rule, err
 	}
 	if arity == nil {
 		// shouldn't happen but be safe, the callers should be able
 		// to assume arity to be non nil
 		arity = sideArity{asserts.SideArityConstraint{N: 1}}
 	}
-	return arity, nil
+	return arity, rule, nil
 }
 
 // InstallCandidateMinimalCheck represents a candidate snap installed with --dangerous flag that should pass minimum checks
@@ -1146,6 +1146,82 @@ func (s *policySuite) TestSnapDeclAllowDenyAutoConnection(c *C) {
 	}
 }
 
+func (s *policySuite) TestExplainConnect(c *C) {
+	tests := []struct {
+		iface    string
+		rule     string // "" => no rule
+		expected string // "" => no error
+	}{
+		{"random", "", ""},
+		{"base-plug-allow", `allow-connection of plug rule of interface "base-plug-allow" in base-declaration`, ""},
+		{"base-slot-deny", `deny-connection of slot rule of interface "base-slot-deny" in base-declaration`, `connection denied by slot rule.*`},
+		{"snap-plug-deny", `deny-connection of plug rule of interface "snap-plug-deny" in snap-declaration`, `connection denied by plug rule.*`},
+		{"base-deny-snap-slot-allow", `allow-connection of slot rule of interface "base-deny-snap-slot-allow" in snap-declaration`, ""},
+		{"base-allow-snap-slot-not-allow", `allow-connection of slot rule of interface "base-allow-snap-slot-not-allow" in snap-declaration`, `connection not allowed.*`},
+	}
+
+	for _, t := range tests {
+		cand := policy.ConnectCandidate{
+			Plug:                interfaces.NewConnectedPlug(s.plugSnap.Plugs[t.iface], s.plugAppSet, nil, nil),
+			Slot:                interfaces.NewConnectedSlot(s.slotSnap.Slots[t.iface], s.slotAppSet, nil, nil),
+			PlugSnapDeclaration: s.plugDecl,
+			SlotSnapDeclaration: s.slotDecl,
+			BaseDeclaration:     s.baseDecl,
+		}
+
+		rule, err := cand.ExplainConnect()
+		if t.expected == "" {
+			c.Check(err, IsNil, Commentf(t.iface))
+		} else {
+			c.Check(err, ErrorMatches, t.expected, Commentf(t.iface))
+		}
+		if t.rule == "" {
+			c.Check(rule, IsNil, Commentf(t.iface))
+		} else {
+			c.Assert(rule, NotNil, Commentf(t.iface))
+			c.Check(rule.String(), Equals, t.rule)
+		}
+	}
+}
+
+func (s *policySuite) TestExplainAutoConnect(c *C) {
+	tests := []struct {
+		iface    string
+		rule     string // "" => no rule
+		expected string // "" => no error
+	}{
+		{"random", "", ""},
+		{"auto-base-plug-allow", `allow-auto-connection of plug rule of interface "auto-base-plug-allow" in base-declaration`, ""},
+		{"auto-base-slot-not-allow", `allow-auto-connection of slot rule of interface "auto-base-slot-not-allow" in base-declaration`, `auto-connection not allowed by slot rule.*`},
+		{"auto-snap-slot-deny", `deny-auto-connection of slot rule of interface "auto-snap-slot-deny" in snap-declaration`, `auto-connection denied by slot rule.*`},
+	}
+
+	for _, t := range tests {
+		cand := policy.ConnectCandidate{
+			Plug:                interfaces.NewConnectedPlug(s.plugSnap.Plugs[t.iface], s.plugAppSet, nil, nil),
+			Slot:                interfaces.NewConnectedSlot(s.slotSnap.Slots[t.iface], s.slotAppSet, nil, nil),
+			PlugSnapDeclaration: s.plugDecl,
+			SlotSnapDeclaration: s.slotDecl,
+			BaseDeclaration:     s.baseDecl,
+		}
+
+		arity, rule, err := cand.ExplainAutoConnect()
+		if t.expected == "" {
+			c.Check(err, IsNil, Commentf(t.iface))
+			c.Check(arity.SlotsPerPlugAny(), Equals, false)
+		} else {
+			c.Check(err, ErrorMatches, t.expected, Commentf(t.iface))
+			c.Check(arity, IsNil)
+		}
+		if t.rule == "" {
+			c.Check(rule, IsNil, Commentf(t.iface))
+		} else {
+			c.Assert(rule, NotNil, Commentf(t.iface))
+			c.Check(rule.String(), Equals, t.rule)
+		}
+	}
+}
+
 func (s *policySuite) TestSnapTypeCheckConnection(c *C) {
 	gadgetAppSet := ifacetest.MockInfoAndAppSet(c, `
 name: gadget
@@ -1573,6 +1649,45 @@ slots:
 	}
 }
 
+func (s *policySuite) TestExplainInstallation(c *C) {
+	installSnap := snaptest.MockInfo(c, `name: install-snap
+version: 0
+slots:
+  random1:
+  install-slot-coreonly:
+plugs:
+  install-plug-attr-ok:
+    attr: ok
+`, nil)
+
+	cand := policy.InstallCandidate{
+		Snap:            installSnap,
+		BaseDeclaration: s.baseDecl,
+	}
+
+	rule, err := cand.ExplainSlot(installSnap.Slots["random1"])
+	c.Check(err, IsNil)
+	c.Check(rule, IsNil)
+
+	rule, err = cand.ExplainSlot(installSnap.Slots["install-slot-coreonly"])
+	c.Check(err, ErrorMatches, `installation not allowed by "install-slot-coreonly" slot rule of interface "install-slot-coreonly"`)
+	c.Check(rule, DeepEquals, &policy.DecidingRule{
+		Declaration: policy.BaseDeclaration,
+		Side:        "slot",
+		Interface:   "install-slot-coreonly",
+		Constraint:  "allow-installation",
+	})
+
+	rule, err = cand.ExplainPlug(installSnap.Plugs["install-plug-attr-ok"])
+	c.Check(err, IsNil)
+	c.Check(rule, DeepEquals, &policy.DecidingRule{
+		Declaration: policy.BaseDeclaration,
+		Side:        "plug",
+		Interface:   "install-plug-attr-ok",
+		Constraint:  "allow-installation",
+	})
+}
+
 func (s *policySuite) TestSnapDeclAllowDenyInstallation(c *C) {
 
 	tests := []struct {
@@ -0,0 +1,197 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces
+
+import (
+	"strings"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// Sources of the rules added to a specification.
+const (
+	SourcePermanentPlug = "permanent-plug"
+	SourcePermanentSlot = "permanent-slot"
+	SourceConnectedPlug = "connected-plug"
+	SourceConnectedSlot = "connected-slot"
+)
+
+// RuleOrigin describes which interface, plug or slot caused a rule to be
+// added to a specification.
+type RuleOrigin struct {
+	Interface string   `json:"interface"`
+	Plug      *PlugRef `json:"plug,omitempty"`
+	Slot      *SlotRef `json:"slot,omitempty"`
+	// Source is one of the Source* constants and identifies which side of
+	// the interface produced the rule.
+	Source string `json:"source"`
+}
+
+// PermanentPlugOrigin returns the origin of rules added on behalf of a plug.
+func PermanentPlugOrigin(plug *snap.PlugInfo) RuleOrigin {
+	return RuleOrigin{
+		Interface: plug.Interface,
+		Plug:      &PlugRef{Snap: plug.Snap.InstanceName(), Name: plug.Name},
+		Source:    SourcePermanentPlug,
+	}
+}
+
+// PermanentSlotOrigin returns the origin of rules added on behalf of a slot.
+func PermanentSlotOrigin(slot *snap.SlotInfo) RuleOrigin {
+	return RuleOrigin{
+		Interface: slot.Interface,
+		Slot:      &SlotRef{Snap: slot.Snap.InstanceName(), Name: slot.Name},
+		Source:    SourcePermanentSlot,
+	}
+}
+
+// ConnectedPlugOrigin returns the origin of rules added on behalf of the plug
+// side of a connection.
+func ConnectedPlugOrigin(plug *ConnectedPlug, slot *ConnectedSlot) RuleOrigin {
+	origin := connectionOrigin(plug, slot)
+	origin.Source = SourceConnectedPlug
+	if plug != nil {
+		origin.Interface = plug.Interface()
+	}
+	return origin
+}
+
+// ConnectedSlotOrigin returns the origin of rules added on behalf of the slot
+// side of a connection.
+func ConnectedSlotOrigin(plug *ConnectedPlug, slot *ConnectedSlot) RuleOrigin {
+	origin := connectionOrigin(plug, slot)
+	origin.Source = SourceConnectedSlot
+	if slot != nil {
+		origin.Interface = slot.Interface()
+	}
+	return origin
+}
+
+func connectionOrigin(plug *ConnectedPlug, slot *ConnectedSlot) RuleOrigin {
+	var origin RuleOrigin
+	if plug != nil {
+		origin.Plug = plug.Ref()
+	}
+	if slot != nil {
+		origin.Slot = slot.Ref()
+	}
+	return origin
+}
+
+// ExplainedRule is a single rule of a security profile along with its origin.
+type ExplainedRule struct {
+	Backend SecuritySystem `json:"backend"`
+	// SecurityTag is the tag of the application or hook the rule applies
+	// to, it is empty for rules applying to the whole snap.
+	SecurityTag string     `json:"security-tag,omitempty"`
+	Rule        string     `json:"rule"`
+	Origin      RuleOrigin `json:"origin"`
+}
+
+// RuleExplainer is implemented by specifications which keep track of the
+// origin of their rules.
+type RuleExplainer interface {
+	ExplainedRules() []ExplainedRule
+}
+
+// Provenance assists specifications in keeping track of the origin of the
+// rules they collect.
+//
+// Rules are only recorded while an origin is being tracked, that is, while
+// the specification is delegating to an interface. Rules coming from other
+// sources, such as the templates of the backends, are not recorded.
+//
+// The zero value is ready to use.
+type Provenance struct {
+	origin *RuleOrigin
+	rules  []ExplainedRule
+}
+
+// Track sets the origin of the rules recorded until the returned function
+// is called.
+func (p *Provenance) Track(origin RuleOrigin) (done func()) {
+	p.origin = &origin
+	return func() {
+		p.origin = nil
+	}
+}
+
+// snippetRules returns the rules of a snippet. Each non-empty line of the
+// snippet which is not a comment is a separate rule, AppArmor #include
+// directives are not considered comments.
+func snippetRules(snippet string) []string {
+	var rules []string
+	for _, line := range strings.Split(snippet, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include")) {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	return rules
+}
+
+// Record records the rules of a snippet for the given security tags. An
+// empty list of security tags records the rules for the whole snap.
+func (p *Provenance) Record(securityTags []string, snippet string) {
+	if p.origin == nil {
+		return
+	}
+	if len(securityTags) == 0 {
+		securityTags = []string{""}
+	}
+	for _, line := range snippetRules(snippet) {
+		for _, tag := range securityTags {
+			p.rules = append(p.rules, ExplainedRule{
+				SecurityTag: tag,
+				Rule:        line,
+				Origin:      *p.origin,
+			})
+		}
+	}
+}
+
+// Forget drops the rules of a snippet previously recorded for the given
+// security tag, for instance when the snippet was replaced by another one.
+func (p *Provenance) Forget(securityTag string, snippet string) {
+	forgotten := make(map[string]bool)
+	for _, line := range snippetRules(snippet) {
+		forgotten[line] = true
+	}
+	rules := p.rules[:0]
+	for _, rule := range p.rules {
+		if rule.SecurityTag == securityTag && forgotten[rule.Rule] {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	p.rules = rules
+}
+
+// Rules returns the rules recorded so far for the given backend, in the
+// order they were recorded.
+func (p *Provenance) Rules(backend SecuritySystem) []ExplainedRule {
+	rules := make([]ExplainedRule, 0, len(p.rules))
+	for _, rule := range p.rules {
+		rule.Backend = backend
+		rules = append(rules, rule)
+	}
+	return rules
+}
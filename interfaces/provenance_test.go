@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type provenanceSuite struct{}
+
+var _ = Suite(&provenanceSuite{})
+
+func (s *provenanceSuite) TestRecord(c *C) {
+	info := snaptest.MockInfo(c, `name: consumer
+version: 1
+plugs:
+ plug:
+  interface: network
+`, nil)
+	origin := interfaces.PermanentPlugOrigin(info.Plugs["plug"])
+	c.Check(origin, DeepEquals, interfaces.RuleOrigin{
+		Interface: "network",
+		Plug:      &interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		Source:    interfaces.SourcePermanentPlug,
+	})
+
+	var p interfaces.Provenance
+	// nothing is recorded without an origin
+	p.Record([]string{"snap.consumer.app"}, "template")
+
+	done := p.Track(origin)
+	p.Record([]string{"snap.consumer.app", "snap.consumer.hook.install"}, `
+# comment
+#include <abstractions/nameservice>
+  network inet,
+`)
+	p.Record(nil, "snap-wide")
+	done()
+	p.Record([]string{"snap.consumer.app"}, "after")
+
+	c.Check(p.Rules(interfaces.SecurityAppArmor), DeepEquals, []interfaces.ExplainedRule{
+		{Backend: "apparmor", SecurityTag: "snap.consumer.app", Rule: "#include <abstractions/nameservice>", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "snap.consumer.hook.install", Rule: "#include <abstractions/nameservice>", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "snap.consumer.app", Rule: "network inet,", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "snap.consumer.hook.install", Rule: "network inet,", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "", Rule: "snap-wide", Origin: origin},
+	})
+
+	// forgetting a snippet only drops its rules for the given tag
+	p.Forget("snap.consumer.hook.install", "#include <abstractions/nameservice>\nnetwork inet,")
+	c.Check(p.Rules(interfaces.SecurityAppArmor), DeepEquals, []interfaces.ExplainedRule{
+		{Backend: "apparmor", SecurityTag: "snap.consumer.app", Rule: "#include <abstractions/nameservice>", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "snap.consumer.app", Rule: "network inet,", Origin: origin},
+		{Backend: "apparmor", SecurityTag: "", Rule: "snap-wide", Origin: origin},
+	})
+}
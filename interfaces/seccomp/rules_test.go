@@ -0,0 +1,38 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/seccomp"
+)
+
+type rulesSuite struct{}
+
+var _ = Suite(&rulesSuite{})
+
+func (s *rulesSuite) TestSyscallRuleAllows(c *C) {
+	c.Check(seccomp.SyscallRuleAllows("mount", "mount"), Equals, true)
+	c.Check(seccomp.SyscallRuleAllows("socket AF_NETLINK - NETLINK_AUDIT", "socket"), Equals, true)
+	c.Check(seccomp.SyscallRuleAllows("umount2", "mount"), Equals, false)
+	c.Check(seccomp.SyscallRuleAllows("~mount", "mount"), Equals, false)
+	c.Check(seccomp.SyscallRuleAllows("", "mount"), Equals, false)
+}
@@ -33,6 +33,8 @@ type Specification struct {
 	// Snippets are indexed by security tag.
 	snippets     map[string][]string
 	securityTags []string
+	// provenance keeps track of the interfaces which contributed snippets
+	provenance interfaces.Provenance
 }
 
 func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
@@ -56,6 +58,7 @@ func (spec *Specification) AddSnippet(snippet string) {
 	for _, tag := range spec.securityTags {
 		spec.snippets[tag] = append(spec.snippets[tag], snippet)
 	}
+	spec.provenance.Record(spec.securityTags, snippet)
 }
 
 // Snippets returns a deep copy of all the added snippets.
@@ -91,6 +94,12 @@ func (spec *Specification) SecurityTags() []string {
 	return tags
 }
 
+// ExplainedRules returns the rules contributed by interfaces along with their
+// origin.
+func (spec *Specification) ExplainedRules() []interfaces.ExplainedRule {
+	return spec.provenance.Rules(interfaces.SecuritySecComp)
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records seccomp-specific side-effects of having a connected plug.
@@ -106,6 +115,7 @@ func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *in
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.ConnectedPlugOrigin(plug, slot))()
 		return iface.SecCompConnectedPlug(spec, plug, slot)
 	}
 	return nil
@@ -124,6 +134,7 @@ func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *in
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.ConnectedSlotOrigin(plug, slot))()
 		return iface.SecCompConnectedSlot(spec, plug, slot)
 	}
 	return nil
@@ -142,6 +153,7 @@ func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *sn
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.PermanentPlugOrigin(plug))()
 		return iface.SecCompPermanentPlug(spec, plug)
 	}
 	return nil
@@ -160,6 +172,7 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 
 		spec.securityTags = tags
 		defer func() { spec.securityTags = nil }()
+		defer spec.provenance.Track(interfaces.PermanentSlotOrigin(slot))()
 		return iface.SecCompPermanentSlot(spec, slot)
 	}
 	return nil
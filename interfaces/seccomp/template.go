@@ -228,6 +228,14 @@ kill
 # such that the calling process must already be able to ptrace the target
 # processes and so this is safe.
 kcmp - - KCMP_FILE
+
+# Landlock can only further restrict the calling process. It is used by
+# snap-exec on systems without AppArmor and may be used by applications to
+# sandbox themselves.
+landlock_add_rule
+landlock_create_ruleset
+landlock_restrict_self
+
 link
 linkat
 
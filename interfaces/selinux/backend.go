@@ -0,0 +1,206 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package selinux implements integration between snapd and SELinux.
+//
+// On systems using SELinux, such as Fedora and the RHEL family, and with the
+// experimental selinux-confinement feature enabled, snapd generates a policy
+// module for each snap. The module labels the data directories of the snap
+// and declares a domain for each application and hook, named after its
+// security tag, as in snap_foo_app_t for snap.foo.app. The rules of the
+// domain come from a default template and from the interfaces connected to
+// the snap.
+//
+// The modules are written in CIL and stored in /var/lib/snapd/selinux,
+// from where they are installed with semodule.
+package selinux
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/sandbox/selinux"
+	"github.com/snapcore/snapd/timings"
+)
+
+var (
+	selinuxLoadModule     = selinux.LoadModule
+	selinuxRemoveModule   = selinux.RemoveModule
+	selinuxRestoreContext = selinux.RestoreContext
+)
+
+// Backend is responsible for maintaining SELinux policy modules for snaps.
+type Backend struct{}
+
+// Initialize does nothing.
+func (b *Backend) Initialize(*interfaces.SecurityBackendOptions) error {
+	return nil
+}
+
+// Name returns the name of the backend.
+func (b *Backend) Name() interfaces.SecuritySystem {
+	return interfaces.SecuritySELinux
+}
+
+// moduleName returns the name of the policy module of the given snap.
+func moduleName(snapName string) string {
+	return "snap_" + snapName
+}
+
+// domainForTag returns the SELinux domain of the given security tag.
+//
+// snap-confine computes the same name to switch to the domain when executing
+// the application, see sc_selinux_domain_for_security_tag.
+func domainForTag(securityTag string) string {
+	return domainReplacer.Replace(securityTag) + "_t"
+}
+
+// domainReplacer replaces the characters of security tags which cannot be
+// used in type names, namely the separators of snap, component and app names.
+var domainReplacer = strings.NewReplacer(".", "_", "+", "_")
+
+// Setup creates and loads the SELinux policy module of a given snap.
+//
+// Domains of snaps in developer mode are permissive. Snaps using classic
+// confinement keep running unconfined, their module only labels their data.
+//
+// This method should be called after changing plug, slots, connections between
+// them or application present in the snap.
+func (b *Backend) Setup(appSet *interfaces.SnapAppSet, opts interfaces.ConfinementOptions, repo *interfaces.Repository, tm timings.Measurer) error {
+	snapName := appSet.InstanceName()
+	// Get the snippets that apply to this snap
+	spec, err := repo.SnapSpecification(b.Name(), appSet, opts)
+	if err != nil {
+		return fmt.Errorf("cannot obtain SELinux specification for snap %q: %s", snapName, err)
+	}
+
+	name := moduleName(snapName)
+	content := map[string]osutil.FileState{
+		name + ".cil": &osutil.MemoryFileState{
+			Content: generateContent(spec.(*Specification), opts, appSet),
+			Mode:    0644,
+		},
+	}
+
+	dir := dirs.SnapSELinuxDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory for SELinux policy modules %q: %s", dir, err)
+	}
+
+	changed, _, err := osutil.EnsureDirState(dir, name+".cil", content)
+	if err != nil {
+		return fmt.Errorf("cannot synchronize security files for snap %q: %s", snapName, err)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(dir, name+".cil")
+	if err := selinuxLoadModule(path); err != nil {
+		// make sure loading is attempted again on the next setup
+		if err := os.Remove(path); err != nil {
+			logger.Noticef("cannot remove SELinux policy module %q: %v", path, err)
+		}
+		return fmt.Errorf("cannot load SELinux policy module of snap %q: %v", snapName, err)
+	}
+
+	// relabel the existing data of the snap, the data in the home
+	// directories is relabeled by snap run
+	dataDir := filepath.Join(dirs.SnapDataDir, snapName)
+	if osutil.IsDirectory(dataDir) {
+		if err := selinuxRestoreContext(dataDir, selinux.RestoreMode{Recursive: true}); err != nil {
+			logger.Noticef("cannot restore SELinux context of %q: %v", dataDir, err)
+		}
+	}
+	return nil
+}
+
+// Remove removes and unloads the SELinux policy module of a given snap.
+func (b *Backend) Remove(snapName string) error {
+	name := moduleName(snapName)
+	_, removed, err := osutil.EnsureDirState(dirs.SnapSELinuxDir, name+".cil", nil)
+	if err != nil {
+		return fmt.Errorf("cannot synchronize security files for snap %q: %s", snapName, err)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := selinuxRemoveModule(name); err != nil {
+		return fmt.Errorf("cannot remove SELinux policy module of snap %q: %v", snapName, err)
+	}
+	return nil
+}
+
+// generateContent combines the templates and the security snippets collected
+// from all the interfaces affecting a given snap into a policy module.
+func generateContent(spec *Specification, opts interfaces.ConfinementOptions, appSet *interfaces.SnapAppSet) []byte {
+	snapName := appSet.InstanceName()
+	typePrefix := "snap_" + snapName
+	replacer := strings.NewReplacer(
+		"###SNAP_INSTANCE_NAME###", snapName,
+		"###DATA_DIR###", filepath.Join(dirs.StripRootDir(dirs.SnapDataDir), snapName),
+		"###DATA_TYPE###", typePrefix+"_data_t",
+		"###HOME_TYPE###", typePrefix+"_home_t",
+	)
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "; policy module of snap %q generated by snapd, DO NOT EDIT\n", snapName)
+	buffer.WriteString(replacer.Replace(moduleTemplate))
+
+	if opts.Classic && !opts.JailMode {
+		// classic snaps are not confined by snap-confine
+		return buffer.Bytes()
+	}
+
+	for _, r := range appSet.Runnables() {
+		domain := domainForTag(r.SecurityTag)
+		buffer.WriteString("\n; " + r.SecurityTag)
+		buffer.WriteString(strings.Replace(replacer.Replace(domainTemplate), "###DOMAIN###", domain, -1))
+		if opts.DevMode && !opts.JailMode {
+			fmt.Fprintf(&buffer, "(typepermissive %s)\n", domain)
+		}
+		buffer.WriteString(strings.Replace(spec.SnippetForTag(r.SecurityTag), "###DOMAIN###", domain, -1))
+	}
+
+	return buffer.Bytes()
+}
+
+// NewSpecification returns an empty SELinux specification.
+func (b *Backend) NewSpecification(appSet *interfaces.SnapAppSet, opts interfaces.ConfinementOptions) interfaces.Specification {
+	return &Specification{appSet: appSet}
+}
+
+// SandboxFeatures returns the list of SELinux features, that is the mode
+// SELinux is in.
+func (b *Backend) SandboxFeatures() []string {
+	switch selinux.ProbedLevel() {
+	case selinux.Enforcing:
+		return []string{"mode:enforcing"}
+	case selinux.Permissive:
+		return []string{"mode:permissive"}
+	}
+	return nil
+}
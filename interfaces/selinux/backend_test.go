@@ -0,0 +1,341 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/selinux"
+	selinux_sandbox "github.com/snapcore/snapd/sandbox/selinux"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type backendSuite struct {
+	ifacetest.BackendSuite
+
+	loaded   []string
+	removed  []string
+	restored []string
+}
+
+var _ = Suite(&backendSuite{})
+
+var testedConfinementOpts = []interfaces.ConfinementOptions{
+	{},
+	{DevMode: true},
+	{JailMode: true},
+	{Classic: true},
+}
+
+func (s *backendSuite) SetUpTest(c *C) {
+	s.Backend = &selinux.Backend{}
+	s.BackendSuite.SetUpTest(c)
+	c.Assert(s.Repo.AddBackend(s.Backend), IsNil)
+
+	s.loaded = nil
+	s.removed = nil
+	s.restored = nil
+	s.AddCleanup(selinux.MockLoadModule(func(path string) error {
+		s.loaded = append(s.loaded, path)
+		return nil
+	}))
+	s.AddCleanup(selinux.MockRemoveModule(func(name string) error {
+		s.removed = append(s.removed, name)
+		return nil
+	}))
+	s.AddCleanup(selinux.MockRestoreContext(func(path string, mode selinux_sandbox.RestoreMode) error {
+		c.Check(mode.Recursive, Equals, true)
+		s.restored = append(s.restored, path)
+		return nil
+	}))
+}
+
+func (s *backendSuite) TearDownTest(c *C) {
+	s.BackendSuite.TearDownTest(c)
+}
+
+func (s *backendSuite) TestInitialize(c *C) {
+	err := s.Backend.Initialize(nil)
+	c.Assert(err, IsNil)
+}
+
+// Tests for Setup() and Remove()
+func (s *backendSuite) TestName(c *C) {
+	c.Check(s.Backend.Name(), Equals, interfaces.SecuritySELinux)
+}
+
+func (s *backendSuite) TestInstallingSnapWritesAndLoadsModule(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	module := filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil")
+	c.Check(s.loaded, DeepEquals, []string{module})
+	c.Check(module, testutil.FileContains, `(filecon "/var/snap/samba(/.*)?" any (system_u object_r snap_samba_data_t ((s0) (s0))))`)
+	c.Check(module, testutil.FileContains, `(filecon "HOME_DIR/snap/samba(/.*)?" any (system_u object_r snap_samba_home_t ((s0) (s0))))`)
+	c.Check(module, testutil.FileContains, "\n; snap.samba.smbd\n(type snap_samba_smbd_t)\n")
+	c.Check(module, testutil.FileContains, "(allow snap_samba_smbd_t snap_samba_data_t (file (")
+	c.Check(module, Not(testutil.FileContains), "###")
+	c.Check(module, Not(testutil.FileContains), "typepermissive")
+	// no data to relabel yet
+	c.Check(s.restored, HasLen, 0)
+}
+
+func (s *backendSuite) TestInstallingSnapRestoresDataContext(c *C) {
+	dataDir := filepath.Join(dirs.SnapDataDir, "samba")
+	c.Assert(os.MkdirAll(dataDir, 0755), IsNil)
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	c.Check(s.restored, DeepEquals, []string{dataDir})
+}
+
+func (s *backendSuite) TestInstallingSnapWritesHookDomains(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.HookYaml, 0)
+	module := filepath.Join(dirs.SnapSELinuxDir, "snap_foo.cil")
+	c.Check(module, testutil.FileContains, "(type snap_foo_hook_configure_t)\n")
+}
+
+func (s *backendSuite) TestDomainForTag(c *C) {
+	// keep in sync with sc_selinux_domain_for_security_tag in snap-confine
+	for tag, domain := range map[string]string{
+		"snap.foo.app":               "snap_foo_app_t",
+		"snap.foo.hook.configure":    "snap_foo_hook_configure_t",
+		"snap.foo_instance.app":      "snap_foo_instance_app_t",
+		"snap.foo-bar.app-1":         "snap_foo-bar_app-1_t",
+		"snap.foo+comp.hook.install": "snap_foo_comp_hook_install_t",
+	} {
+		c.Check(selinux.DomainForTag(tag), Equals, domain, Commentf(tag))
+	}
+}
+
+func (s *backendSuite) TestInstallingParallelInstance(c *C) {
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "samba_foo", ifacetest.SambaYamlV1, 0)
+	module := filepath.Join(dirs.SnapSELinuxDir, "snap_samba_foo.cil")
+	c.Check(module, testutil.FileContains, `(filecon "/var/snap/samba_foo(/.*)?" any (system_u object_r snap_samba_foo_data_t ((s0) (s0))))`)
+	c.Check(module, testutil.FileContains, "(type snap_samba_foo_smbd_t)\n")
+}
+
+func (s *backendSuite) TestUnchangedModuleIsNotReloaded(c *C) {
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	c.Check(s.loaded, HasLen, 1)
+}
+
+func (s *backendSuite) TestLoadModuleFailure(c *C) {
+	restore := selinux.MockLoadModule(func(path string) error {
+		return errors.New("boom")
+	})
+	defer restore()
+
+	snapInfo := snaptest.MockInfo(c, ifacetest.SambaYamlV1, nil)
+	appSet, err := interfaces.NewSnapAppSet(snapInfo, nil)
+	c.Assert(err, IsNil)
+	c.Assert(s.Repo.AddAppSet(appSet), IsNil)
+	err = s.Backend.Setup(appSet, interfaces.ConfinementOptions{}, s.Repo, timings.New(nil))
+	c.Check(err, ErrorMatches, `cannot load SELinux policy module of snap "samba": boom`)
+	// the module is written again on the next attempt
+	c.Check(filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil"), testutil.FileAbsent)
+}
+
+func (s *backendSuite) TestRemovingSnapRemovesModule(c *C) {
+	for _, opts := range testedConfinementOpts {
+		s.removed = nil
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+		s.RemoveSnap(c, snapInfo)
+		c.Check(filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil"), testutil.FileAbsent)
+		c.Check(s.removed, DeepEquals, []string{"snap_samba"})
+	}
+}
+
+func (s *backendSuite) TestRemovingUnknownSnapDoesNothing(c *C) {
+	c.Assert(s.Backend.Remove("samba"), IsNil)
+	c.Check(s.removed, HasLen, 0)
+}
+
+func (s *backendSuite) TestUpdatingSnapToOneWithFewerApps(c *C) {
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1WithNmbd, 0)
+		snapInfo = s.UpdateSnap(c, snapInfo, opts, ifacetest.SambaYamlV1, 0)
+		module := filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil")
+		c.Check(module, Not(testutil.FileContains), "snap_samba_nmbd_t")
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestConfinementModes(c *C) {
+	for _, t := range []struct {
+		opts       interfaces.ConfinementOptions
+		domain     bool
+		permissive bool
+	}{
+		{interfaces.ConfinementOptions{}, true, false},
+		{interfaces.ConfinementOptions{DevMode: true}, true, true},
+		{interfaces.ConfinementOptions{DevMode: true, JailMode: true}, true, false},
+		{interfaces.ConfinementOptions{Classic: true}, false, false},
+		{interfaces.ConfinementOptions{Classic: true, JailMode: true}, true, false},
+	} {
+		snapInfo := s.InstallSnap(c, t.opts, "", ifacetest.SambaYamlV1, 0)
+		module := filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil")
+		// data is labeled in all modes
+		c.Check(module, testutil.FileContains, "(type snap_samba_data_t)\n")
+		if t.domain {
+			c.Check(module, testutil.FileContains, "(type snap_samba_smbd_t)\n")
+		} else {
+			c.Check(module, Not(testutil.FileContains), "snap_samba_smbd_t")
+		}
+		if t.permissive {
+			c.Check(module, testutil.FileContains, "(typepermissive snap_samba_smbd_t)\n")
+		} else {
+			c.Check(module, Not(testutil.FileContains), "typepermissive")
+		}
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+// allowedAccess returns the accesses granted by the allow rules of a policy
+// module, as "source target class permission" strings.
+func allowedAccess(c *C, module string) map[string]bool {
+	content, err := os.ReadFile(module)
+	c.Assert(err, IsNil)
+	allowRule := regexp.MustCompile(`^\(allow (\S+) (\S+) \((\S+) \(([^()]*)\)\)\)$`)
+	allowed := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		m := allowRule.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		source, target := m[1], m[2]
+		if target == "self" {
+			target = source
+		}
+		for _, perm := range strings.Fields(m[4]) {
+			allowed[fmt.Sprintf("%s %s %s %s", source, target, m[3], perm)] = true
+		}
+	}
+	return allowed
+}
+
+func (s *backendSuite) TestStrictAppCanStart(c *C) {
+	const yaml = `name: foo
+version: 1
+base: core22
+apps:
+ app:
+  command: bin/app
+`
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", yaml, 0)
+	allowed := allowedAccess(c, filepath.Join(dirs.SnapSELinuxDir, "snap_foo.cil"))
+
+	// the accesses made by snap-confine, snap-exec and a dynamically linked
+	// application of the base, started from a terminal, until it runs
+	for _, access := range []string{
+		// snap-confine executes snap-exec in the domain of the app
+		"snappy_confine_t snap_foo_app_t process transition",
+		"snap_foo_app_t snappy_exec_t file entrypoint",
+		"snap_foo_app_t snappy_exec_t file map",
+		"snap_foo_app_t snappy_confine_t fd use",
+		"snap_foo_app_t unconfined_t fd use",
+		"snap_foo_app_t user_devpts_t chr_file read",
+		"snap_foo_app_t user_devpts_t chr_file write",
+		"snap_foo_app_t user_devpts_t chr_file ioctl",
+		"snap_foo_app_t snap_foo_app_t dir search",
+		"snap_foo_app_t snap_foo_app_t file read",
+		"snap_foo_app_t proc_t file read",
+		"snap_foo_app_t sysfs_t file read",
+		// snap-exec reads the snap.yaml and executes the app
+		"snap_foo_app_t snappy_snap_t dir search",
+		"snap_foo_app_t snappy_snap_t file open",
+		"snap_foo_app_t snappy_snap_t file read",
+		"snap_foo_app_t snappy_snap_t lnk_file read",
+		"snap_foo_app_t snappy_snap_t file execute",
+		"snap_foo_app_t snappy_snap_t file execute_no_trans",
+		// the dynamic loader maps the libraries of the base
+		"snap_foo_app_t snappy_snap_t file map",
+		"snap_foo_app_t etc_t dir search",
+		"snap_foo_app_t ld_so_cache_t file open",
+		"snap_foo_app_t ld_so_cache_t file map",
+		// the C library reads its configuration
+		"snap_foo_app_t etc_t file open",
+		"snap_foo_app_t etc_t file read",
+		"snap_foo_app_t locale_t file read",
+		"snap_foo_app_t net_conf_t file read",
+		// common devices
+		"snap_foo_app_t device_t dir search",
+		"snap_foo_app_t null_device_t chr_file open",
+		"snap_foo_app_t null_device_t chr_file write",
+		"snap_foo_app_t urandom_device_t chr_file read",
+		// the private /tmp
+		"snap_foo_app_t tmp_t dir add_name",
+		"snap_foo_app_t tmp_t file create",
+		"snap_foo_app_t tmp_t file write",
+		// the data of the snap
+		"snap_foo_app_t snappy_var_t dir search",
+		"snap_foo_app_t snap_foo_data_t dir search",
+		"snap_foo_app_t snap_foo_data_t file write",
+		"snap_foo_app_t home_root_t dir search",
+		"snap_foo_app_t user_home_dir_t dir search",
+		"snap_foo_app_t snappy_home_t dir search",
+		"snap_foo_app_t snap_foo_home_t dir add_name",
+		"snap_foo_app_t snap_foo_home_t file create",
+	} {
+		c.Check(allowed[access], Equals, true, Commentf("%s is not allowed", access))
+	}
+}
+
+func (s *backendSuite) TestCombineSnippets(c *C) {
+	s.Iface.SELinuxPermanentSlotCallback = func(spec *selinux.Specification, slot *snap.SlotInfo) error {
+		spec.AddSnippet("(allow ###DOMAIN### self (tcp_socket (create)))")
+		spec.AddSnippet("(allow ###DOMAIN### self (udp_socket (create)))")
+		return nil
+	}
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	module := filepath.Join(dirs.SnapSELinuxDir, "snap_samba.cil")
+	c.Check(module, testutil.FileContains, "(allow snap_samba_smbd_t self (tcp_socket (create)))\n(allow snap_samba_smbd_t self (udp_socket (create)))\n")
+}
+
+func (s *backendSuite) TestSandboxFeatures(c *C) {
+	restore := selinux_sandbox.MockIsEnabled(func() (bool, error) { return false, nil })
+	defer restore()
+	c.Check(s.Backend.SandboxFeatures(), HasLen, 0)
+
+	restore = selinux_sandbox.MockIsEnabled(func() (bool, error) { return true, nil })
+	defer restore()
+	restore = selinux_sandbox.MockIsEnforcing(func() (bool, error) { return false, nil })
+	defer restore()
+	c.Check(s.Backend.SandboxFeatures(), DeepEquals, []string{"mode:permissive"})
+
+	restore = selinux_sandbox.MockIsEnforcing(func() (bool, error) { return true, nil })
+	defer restore()
+	c.Check(s.Backend.SandboxFeatures(), DeepEquals, []string{"mode:enforcing"})
+}
@@ -0,0 +1,39 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux
+
+import (
+	"github.com/snapcore/snapd/sandbox/selinux"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var DomainForTag = domainForTag
+
+func MockLoadModule(f func(path string) error) (restore func()) {
+	return testutil.Mock(&selinuxLoadModule, f)
+}
+
+func MockRemoveModule(f func(name string) error) (restore func()) {
+	return testutil.Mock(&selinuxRemoveModule, f)
+}
+
+func MockRestoreContext(f func(path string, mode selinux.RestoreMode) error) (restore func()) {
+	return testutil.Mock(&selinuxRestoreContext, f)
+}
@@ -0,0 +1,229 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Specification keeps the CIL policy statements of the domains of a snap.
+//
+// Interfaces often contribute the same statements more than once, for
+// instance when several slots of a snap are connected to the same plug, while
+// CIL rejects repeated declarations in a module. The specification thus keeps
+// each statement only once per security tag.
+type Specification struct {
+	appSet *interfaces.SnapAppSet
+	// Statements are indexed by security tag.
+	statements   map[string][]string
+	seen         map[string]map[string]bool
+	securityTags []string
+}
+
+func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
+	return &Specification{
+		appSet: appSet,
+	}
+}
+
+func (spec *Specification) SnapAppSet() *interfaces.SnapAppSet {
+	return spec.appSet
+}
+
+// AddSnippet adds the CIL statements of the given snippet to the domains of
+// the applications and hooks affected by the interface being processed.
+//
+// ###DOMAIN### is replaced with the domain of each application or hook when
+// the policy module is generated, e.g. in
+// "(allow ###DOMAIN### self (tcp_socket (create connect)))". Comments and any
+// text outside of statements are dropped.
+func (spec *Specification) AddSnippet(snippet string) {
+	if len(spec.securityTags) == 0 {
+		return
+	}
+	if spec.statements == nil {
+		spec.statements = make(map[string][]string)
+		spec.seen = make(map[string]map[string]bool)
+	}
+	stmts := cilStatements(snippet)
+	for _, tag := range spec.securityTags {
+		if spec.seen[tag] == nil {
+			spec.seen[tag] = make(map[string]bool)
+		}
+		for _, stmt := range stmts {
+			if spec.seen[tag][stmt] {
+				continue
+			}
+			spec.seen[tag][stmt] = true
+			spec.statements[tag] = append(spec.statements[tag], stmt)
+		}
+	}
+}
+
+// cilStatements splits a CIL snippet into its top-level statements. An
+// unterminated statement is kept as is, so that compiling the module reports
+// it.
+func cilStatements(snippet string) []string {
+	var stmts []string
+	depth, start := 0, -1
+	inString, inComment := false, false
+	for i, r := range snippet {
+		switch {
+		case inComment:
+			inComment = r != '\n'
+		case inString:
+			inString = r != '"'
+		case r == '"':
+			inString = true
+		case r == ';' && depth == 0:
+			inComment = true
+		case r == '(':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+			if depth == 0 {
+				stmts = append(stmts, snippet[start:i+1])
+				start = -1
+			}
+		}
+	}
+	if start >= 0 {
+		stmts = append(stmts, strings.TrimSpace(snippet[start:]))
+	}
+	return stmts
+}
+
+// Snippets returns a copy of the statements of each security tag.
+func (spec *Specification) Snippets() map[string][]string {
+	result := make(map[string][]string, len(spec.statements))
+	for k, v := range spec.statements {
+		vCopy := make([]string, 0, len(v))
+		vCopy = append(vCopy, v...)
+		result[k] = vCopy
+	}
+	return result
+}
+
+// SnippetForTag returns the sorted statements of the given security tag, one
+// per line. Empty string is returned for non-existing security tag.
+func (spec *Specification) SnippetForTag(tag string) string {
+	stmts := make([]string, 0, len(spec.statements[tag]))
+	stmts = append(stmts, spec.statements[tag]...)
+	sort.Strings(stmts)
+
+	var buffer bytes.Buffer
+	for _, stmt := range stmts {
+		buffer.WriteString(stmt)
+		buffer.WriteRune('\n')
+	}
+	return buffer.String()
+}
+
+// SecurityTags returns a list of security tags which have a statement.
+func (spec *Specification) SecurityTags() []string {
+	var tags []string
+	for t := range spec.statements {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Implementation of methods required by interfaces.Specification
+
+// AddConnectedPlug records SELinux-specific side-effects of having a connected plug.
+func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	type definer interface {
+		SELinuxConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	}
+	if iface, ok := iface.(definer); ok {
+		tags, err := spec.appSet.SecurityTagsForConnectedPlug(plug)
+		if err != nil {
+			return err
+		}
+
+		spec.securityTags = tags
+		defer func() { spec.securityTags = nil }()
+		return iface.SELinuxConnectedPlug(spec, plug, slot)
+	}
+	return nil
+}
+
+// AddConnectedSlot records SELinux-specific side-effects of having a connected slot.
+func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	type definer interface {
+		SELinuxConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	}
+	if iface, ok := iface.(definer); ok {
+		tags, err := spec.appSet.SecurityTagsForConnectedSlot(slot)
+		if err != nil {
+			return err
+		}
+
+		spec.securityTags = tags
+		defer func() { spec.securityTags = nil }()
+		return iface.SELinuxConnectedSlot(spec, plug, slot)
+	}
+	return nil
+}
+
+// AddPermanentPlug records SELinux-specific side-effects of having a plug.
+func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *snap.PlugInfo) error {
+	type definer interface {
+		SELinuxPermanentPlug(spec *Specification, plug *snap.PlugInfo) error
+	}
+	if iface, ok := iface.(definer); ok {
+		tags, err := spec.appSet.SecurityTagsForPlug(plug)
+		if err != nil {
+			return err
+		}
+
+		spec.securityTags = tags
+		defer func() { spec.securityTags = nil }()
+		return iface.SELinuxPermanentPlug(spec, plug)
+	}
+	return nil
+}
+
+// AddPermanentSlot records SELinux-specific side-effects of having a slot.
+func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *snap.SlotInfo) error {
+	type definer interface {
+		SELinuxPermanentSlot(spec *Specification, slot *snap.SlotInfo) error
+	}
+	if iface, ok := iface.(definer); ok {
+		tags, err := spec.appSet.SecurityTagsForSlot(slot)
+		if err != nil {
+			return err
+		}
+
+		spec.securityTags = tags
+		defer func() { spec.securityTags = nil }()
+		return iface.SELinuxPermanentSlot(spec, slot)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/selinux"
+	"github.com/snapcore/snapd/snap"
+)
+
+type specSuite struct {
+	iface    *ifacetest.TestInterface
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+	slotInfo *snap.SlotInfo
+	slot     *interfaces.ConnectedSlot
+}
+
+const dbusSendSnippet = `
+; Description: talk to the system bus
+(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))
+(allow ###DOMAIN### system_dbusd_t (dbus (send_msg)))
+`
+
+var _ = Suite(&specSuite{
+	iface: &ifacetest.TestInterface{
+		InterfaceName: "test",
+		SELinuxConnectedPlugCallback: func(spec *selinux.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddSnippet(dbusSendSnippet)
+			return nil
+		},
+		SELinuxConnectedSlotCallback: func(spec *selinux.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddSnippet("(allow ###DOMAIN### system_dbusd_t (dbus (acquire_svc)))")
+			return nil
+		},
+		SELinuxPermanentPlugCallback: func(spec *selinux.Specification, plug *snap.PlugInfo) error {
+			spec.AddSnippet(dbusSendSnippet)
+			return nil
+		},
+		SELinuxPermanentSlotCallback: func(spec *selinux.Specification, slot *snap.SlotInfo) error {
+			spec.AddSnippet("(allow ###DOMAIN### self (tcp_socket (create listen)))")
+			return nil
+		},
+	},
+})
+
+func (s *specSuite) SetUpTest(c *C) {
+	const plugYaml = `name: snap1
+version: 1
+apps:
+ app1:
+  plugs: [name]
+ app2:
+hooks:
+ configure:
+  plugs: [name]
+`
+	s.plug, s.plugInfo = ifacetest.MockConnectedPlug(c, plugYaml, nil, "name")
+
+	const slotYaml = `name: snap2
+version: 1
+slots:
+ name:
+  interface: test
+apps:
+ app:
+`
+	s.slot, s.slotInfo = ifacetest.MockConnectedSlot(c, slotYaml, nil, "name")
+}
+
+func (s *specSuite) TestPlugSide(c *C) {
+	spec := selinux.NewSpecification(s.plug.AppSet())
+	var r interfaces.Specification = spec
+	c.Assert(r.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	// the same rules are only kept once
+	c.Assert(r.AddPermanentPlug(s.iface, s.plugInfo), IsNil)
+
+	// only the app and the hook using the plug get the rules, the comments
+	// are dropped
+	stmts := []string{
+		"(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))",
+		"(allow ###DOMAIN### system_dbusd_t (dbus (send_msg)))",
+	}
+	c.Check(spec.Snippets(), DeepEquals, map[string][]string{
+		"snap.snap1.app1":           stmts,
+		"snap.snap1.hook.configure": stmts,
+	})
+	c.Check(spec.SecurityTags(), DeepEquals, []string{"snap.snap1.app1", "snap.snap1.hook.configure"})
+	c.Check(spec.SnippetForTag("snap.snap1.app1"), Equals, `(allow ###DOMAIN### system_dbusd_t (dbus (send_msg)))
+(allow ###DOMAIN### system_dbusd_t (unix_stream_socket (connectto)))
+`)
+	c.Check(spec.SnippetForTag("snap.snap1.app2"), Equals, "")
+}
+
+func (s *specSuite) TestSlotSide(c *C) {
+	spec := selinux.NewSpecification(s.slot.AppSet())
+	var r interfaces.Specification = spec
+	c.Assert(r.AddConnectedSlot(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(r.AddPermanentSlot(s.iface, s.slotInfo), IsNil)
+	c.Check(spec.SecurityTags(), DeepEquals, []string{"snap.snap2.app"})
+	c.Check(spec.SnippetForTag("snap.snap2.app"), Equals, `(allow ###DOMAIN### self (tcp_socket (create listen)))
+(allow ###DOMAIN### system_dbusd_t (dbus (acquire_svc)))
+`)
+}
+
+func (s *specSuite) TestAddSnippetOutsideOfInterface(c *C) {
+	spec := selinux.NewSpecification(s.plug.AppSet())
+	// there is no application to apply the rules to
+	spec.AddSnippet(dbusSendSnippet)
+	c.Check(spec.SecurityTags(), HasLen, 0)
+	c.Check(spec.Snippets(), HasLen, 0)
+}
+
+func (s *specSuite) TestStatementParsing(c *C) {
+	for _, tc := range []struct {
+		snippet string
+		stmts   []string
+	}{
+		{"", nil},
+		{"; only a comment\n", nil},
+		// nested lists and several statements on one line
+		{"(typeattributeset foo (bar baz))(allow ###DOMAIN### foo (file (read)))", []string{
+			"(typeattributeset foo (bar baz))",
+			"(allow ###DOMAIN### foo (file (read)))",
+		}},
+		// parentheses and semicolons in strings do not count
+		{`(filecon "/var/snap/foo;(/.*)?" any ())`, []string{
+			`(filecon "/var/snap/foo;(/.*)?" any ())`,
+		}},
+		// statements spanning several lines are kept as written
+		{"(allow ###DOMAIN### foo\n  (file (read)))", []string{
+			"(allow ###DOMAIN### foo\n  (file (read)))",
+		}},
+		// unterminated statements are kept for the compiler to report
+		{"(allow ###DOMAIN### foo (file (read))\n", []string{
+			"(allow ###DOMAIN### foo (file (read))",
+		}},
+	} {
+		spec := selinux.NewSpecification(s.plug.AppSet())
+		iface := &ifacetest.TestInterface{
+			InterfaceName: "test",
+			SELinuxPermanentPlugCallback: func(spec *selinux.Specification, plug *snap.PlugInfo) error {
+				spec.AddSnippet(tc.snippet)
+				return nil
+			},
+		}
+		c.Assert(spec.AddPermanentPlug(iface, s.plugInfo), IsNil)
+		c.Check(spec.Snippets()["snap.snap1.app1"], DeepEquals, tc.stmts, Commentf("%q", tc.snippet))
+	}
+}
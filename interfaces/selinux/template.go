@@ -0,0 +1,135 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux
+
+// moduleTemplate contains the declarations common to all the applications
+// and hooks of a snap. It declares the types of the data of the snap and
+// labels the data directories with them.
+//
+// HOME_DIR is expanded by libsemanage to the home directory of each user.
+var moduleTemplate = `
+(type ###DATA_TYPE###)
+(roletype object_r ###DATA_TYPE###)
+(typeattributeset file_type (###DATA_TYPE###))
+(filecon "###DATA_DIR###(/.*)?" any (system_u object_r ###DATA_TYPE### ((s0) (s0))))
+
+(type ###HOME_TYPE###)
+(roletype object_r ###HOME_TYPE###)
+(typeattributeset file_type (###HOME_TYPE###))
+(filecon "HOME_DIR/snap/###SNAP_INSTANCE_NAME###(/.*)?" any (system_u object_r ###HOME_TYPE### ((s0) (s0))))
+`
+
+// domainTemplate contains the rules common to all strictly confined
+// applications and hooks. It is the SELinux counterpart of the default
+// AppArmor template, granting access to the snap itself, to its base, to its
+// data and to the parts of the host every application needs to start.
+//
+// snap-confine, running as snappy_confine_t, switches to the domain right
+// before executing snap-exec, which in turn executes the application. The
+// snap and its base, mounted as the root file system, are labeled
+// snappy_snap_t while snap-exec and the rest of the host keep their labels.
+var domainTemplate = `
+(type ###DOMAIN###)
+(roletype system_r ###DOMAIN###)
+(typeattributeset domain (###DOMAIN###))
+(allow snappy_confine_t ###DOMAIN### (process (transition noatsecure rlimitinh siginh)))
+(allow ###DOMAIN### snappy_exec_t (file (entrypoint execute getattr map open read)))
+
+; Processes of the application
+(allow ###DOMAIN### self (process (fork sigchld sigkill sigstop signull signal getsched setsched getpgid setpgid getattr getcap setrlimit)))
+(allow ###DOMAIN### self (fifo_file (getattr ioctl read write)))
+(allow ###DOMAIN### self (unix_stream_socket (create connect getattr getopt setopt read write shutdown)))
+(allow ###DOMAIN### self (unix_dgram_socket (create connect getattr getopt setopt read write)))
+(allow ###DOMAIN### self (dir (getattr open read search)))
+(allow ###DOMAIN### self (file (getattr open read)))
+(allow ###DOMAIN### self (lnk_file (getattr read)))
+
+; Descriptors inherited from the user session or from systemd
+(allow ###DOMAIN### snappy_confine_t (fd (use)))
+(allow ###DOMAIN### unconfined_t (fd (use)))
+(allow ###DOMAIN### init_t (fd (use)))
+(allow ###DOMAIN### snappy_confine_t (fifo_file (append getattr ioctl read write)))
+(allow ###DOMAIN### unconfined_t (fifo_file (append getattr ioctl read write)))
+(allow ###DOMAIN### init_t (fifo_file (append getattr ioctl read write)))
+(allow ###DOMAIN### user_devpts_t (chr_file (append getattr ioctl read write)))
+(allow ###DOMAIN### devpts_t (chr_file (append getattr ioctl read write)))
+
+; The snap itself and its base
+(allow ###DOMAIN### snappy_snap_t (dir (getattr open read search)))
+(allow ###DOMAIN### snappy_snap_t (file (entrypoint execute execute_no_trans getattr ioctl lock map open read)))
+(allow ###DOMAIN### snappy_snap_t (lnk_file (getattr read)))
+
+; Configuration and shared libraries of the host
+(allow ###DOMAIN### etc_t (dir (getattr open read search)))
+(allow ###DOMAIN### etc_t (file (getattr ioctl lock map open read)))
+(allow ###DOMAIN### etc_t (lnk_file (getattr read)))
+(allow ###DOMAIN### ld_so_cache_t (file (getattr map open read)))
+(allow ###DOMAIN### locale_t (dir (getattr open read search)))
+(allow ###DOMAIN### locale_t (file (getattr map open read)))
+(allow ###DOMAIN### locale_t (lnk_file (getattr read)))
+(allow ###DOMAIN### net_conf_t (file (getattr open read)))
+(allow ###DOMAIN### net_conf_t (lnk_file (getattr read)))
+(allow ###DOMAIN### lib_t (dir (getattr open read search)))
+(allow ###DOMAIN### lib_t (file (execute getattr map open read)))
+(allow ###DOMAIN### lib_t (lnk_file (getattr read)))
+(allow ###DOMAIN### ld_so_t (file (execute getattr map open read)))
+(allow ###DOMAIN### textrel_shlib_t (file (execute getattr map open read)))
+
+; Devices
+(allow ###DOMAIN### device_t (dir (getattr open read search)))
+(allow ###DOMAIN### devpts_t (dir (getattr open read search)))
+(allow ###DOMAIN### device_t (lnk_file (getattr read)))
+(allow ###DOMAIN### null_device_t (chr_file (append getattr ioctl map open read write)))
+(allow ###DOMAIN### zero_device_t (chr_file (append getattr ioctl map open read write)))
+(allow ###DOMAIN### random_device_t (chr_file (getattr ioctl open read)))
+(allow ###DOMAIN### urandom_device_t (chr_file (getattr ioctl open read)))
+(allow ###DOMAIN### ptmx_t (chr_file (getattr ioctl open read write)))
+
+; Kernel interfaces
+(allow ###DOMAIN### proc_t (dir (getattr open read search)))
+(allow ###DOMAIN### sysfs_t (dir (getattr open read search)))
+(allow ###DOMAIN### proc_t (file (getattr open read)))
+(allow ###DOMAIN### sysfs_t (file (getattr open read)))
+(allow ###DOMAIN### proc_t (lnk_file (getattr read)))
+(allow ###DOMAIN### sysfs_t (lnk_file (getattr read)))
+
+; Private /tmp and shared memory
+(allow ###DOMAIN### tmp_t (dir (add_name create getattr ioctl lock open read remove_name rename rmdir search setattr write)))
+(allow ###DOMAIN### tmp_t (file (append create getattr ioctl lock map open read rename setattr unlink write)))
+(allow ###DOMAIN### tmp_t (lnk_file (create getattr read rename unlink)))
+(allow ###DOMAIN### tmp_t (sock_file (create getattr setattr unlink write)))
+(allow ###DOMAIN### tmpfs_t (dir (add_name create getattr ioctl lock open read remove_name rename rmdir search setattr write)))
+(allow ###DOMAIN### tmpfs_t (file (append create getattr ioctl lock map open read rename setattr unlink write)))
+
+; Directories leading to the data of the snap
+(allow ###DOMAIN### var_run_t (dir (getattr search)))
+(allow ###DOMAIN### snappy_var_t (dir (getattr search)))
+(allow ###DOMAIN### home_root_t (dir (getattr search)))
+(allow ###DOMAIN### user_home_dir_t (dir (getattr search)))
+(allow ###DOMAIN### snappy_home_t (dir (getattr search)))
+
+; Data of the snap
+(allow ###DOMAIN### ###DATA_TYPE### (dir (add_name create getattr ioctl lock open read remove_name rename rmdir search setattr write)))
+(allow ###DOMAIN### ###DATA_TYPE### (file (append create getattr ioctl lock map open read rename setattr unlink write)))
+(allow ###DOMAIN### ###DATA_TYPE### (lnk_file (create getattr read rename unlink)))
+(allow ###DOMAIN### ###HOME_TYPE### (dir (add_name create getattr ioctl lock open read remove_name rename rmdir search setattr write)))
+(allow ###DOMAIN### ###HOME_TYPE### (file (append create getattr ioctl lock map open read rename setattr unlink write)))
+(allow ###DOMAIN### ###HOME_TYPE### (lnk_file (create getattr read rename unlink)))
+`
@@ -48,6 +48,9 @@ type Specification struct {
 	securityTags             []string
 	udevadmSubsystemTriggers []string
 	controlsDeviceCgroup     bool
+
+	// provenance keeps track of the interfaces which contributed snippets
+	provenance interfaces.Provenance
 }
 
 func NewSpecification(appSet *interfaces.SnapAppSet) *Specification {
@@ -94,6 +97,7 @@ func (spec *Specification) addEntry(snippet, tag string) {
 // AddSnippet adds a new udev snippet.
 func (spec *Specification) AddSnippet(snippet string) {
 	spec.addEntry(snippet, "")
+	spec.provenance.Record(nil, snippet)
 }
 
 // udevTag converts a security tag into a string that can be used as a udev tag.
@@ -114,6 +118,7 @@ func (spec *Specification) TagDevice(snippet string) {
 	for _, securityTag := range spec.securityTags {
 		tag := udevTag(securityTag)
 		spec.addEntry(fmt.Sprintf("# %s\n%s, TAG+=\"%s\"", spec.iface, snippet, tag), tag)
+		spec.provenance.Record([]string{securityTag}, fmt.Sprintf("%s, TAG+=\"%s\"", snippet, tag))
 		// SUBSYSTEM=="module" is for kernel modules not devices.
 		// SUBSYSTEM=="subsystem" is for subsystems (the top directories in /sys/class). Not for devices.
 		// When loaded, they send an ADD event
@@ -155,6 +160,12 @@ func (spec *Specification) Snippets() (result []string) {
 	return result
 }
 
+// ExplainedRules returns the rules contributed by interfaces along with their
+// origin.
+func (spec *Specification) ExplainedRules() []interfaces.ExplainedRule {
+	return spec.provenance.Rules(interfaces.SecurityUDev)
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records udev-specific side-effects of having a connected plug.
@@ -172,6 +183,7 @@ func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *in
 		spec.securityTags = tags
 		spec.iface = ifname
 		defer func() { spec.securityTags = nil; spec.iface = "" }()
+		defer spec.provenance.Track(interfaces.ConnectedPlugOrigin(plug, slot))()
 		return iface.UDevConnectedPlug(spec, plug, slot)
 	}
 	return nil
@@ -192,6 +204,7 @@ func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *in
 		spec.securityTags = tags
 		spec.iface = ifname
 		defer func() { spec.securityTags = nil; spec.iface = "" }()
+		defer spec.provenance.Track(interfaces.ConnectedSlotOrigin(plug, slot))()
 		return iface.UDevConnectedSlot(spec, plug, slot)
 	}
 	return nil
@@ -212,6 +225,7 @@ func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *sn
 		spec.securityTags = tags
 		spec.iface = ifname
 		defer func() { spec.securityTags = nil; spec.iface = "" }()
+		defer spec.provenance.Track(interfaces.PermanentPlugOrigin(plug))()
 		return iface.UDevPermanentPlug(spec, plug)
 	}
 	return nil
@@ -232,6 +246,7 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 		spec.securityTags = tags
 		spec.iface = ifname
 		defer func() { spec.securityTags = nil; spec.iface = "" }()
+		defer spec.provenance.Track(interfaces.PermanentSlotOrigin(slot))()
 		return iface.UDevPermanentSlot(spec, slot)
 	}
 	return nil
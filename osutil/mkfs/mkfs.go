@@ -41,6 +41,9 @@ var (
 		"vfat":    mkfsVfat32,
 		"vfat-32": mkfsVfat32,
 		"ext4":    mkfsExt4,
+		"btrfs":   mkfsBtrfs,
+		"xfs":     mkfsXfs,
+		"f2fs":    mkfsF2fs,
 	}
 )
 
@@ -105,6 +108,12 @@ func mkfsExt4(img, label, contentsRootDir string, deviceSize, sectorSize quantit
 	}
 	mkfsArgs = append(mkfsArgs, img)
 
+	return runAsRoot(mkfsArgs)
+}
+
+// runAsRoot runs the given command, through fakeroot when not already running
+// as root, so that any files created by it are owned by root.
+func runAsRoot(args []string) error {
 	var cmd *exec.Cmd
 	if os.Geteuid() != 0 {
 		// run through fakeroot so that files are owned by root
@@ -118,13 +127,13 @@ func mkfsExt4(img, label, contentsRootDir string, deviceSize, sectorSize quantit
 			}
 			if len(fakerootFlags) > 0 {
 				fakerootArgs := append(flags, "--")
-				mkfsArgs = append(fakerootArgs, mkfsArgs...)
+				args = append(fakerootArgs, args...)
 			}
 		}
-		cmd = exec.Command("fakeroot", mkfsArgs...)
+		cmd = exec.Command("fakeroot", args...)
 	} else {
 		// no need to fake it if we're already root
-		cmd = exec.Command(mkfsArgs[0], mkfsArgs[1:]...)
+		cmd = exec.Command(args[0], args[1:]...)
 	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -133,6 +142,82 @@ func mkfsExt4(img, label, contentsRootDir string, deviceSize, sectorSize quantit
 	return nil
 }
 
+// mkfsBtrfs creates a Btrfs filesystem in given image file, with an optional
+// filesystem label, and populates it with the contents of provided root
+// directory.
+func mkfsBtrfs(img, label, contentsRootDir string, deviceSize, sectorSize quantity.Size) error {
+	// -f is needed as the image may already carry a filesystem signature
+	mkfsArgs := []string{"mkfs.btrfs", "-f"}
+	if contentsRootDir != "" {
+		// mkfs.btrfs can populate the filesystem with contents of given
+		// root directory
+		mkfsArgs = append(mkfsArgs, "--rootdir", contentsRootDir)
+	}
+	if label != "" {
+		mkfsArgs = append(mkfsArgs, "-L", label)
+	}
+	mkfsArgs = append(mkfsArgs, img)
+
+	return runAsRoot(mkfsArgs)
+}
+
+// mkfsXfs creates an XFS filesystem in given image file, with an optional
+// filesystem label. Populating the filesystem with contents is not supported.
+func mkfsXfs(img, label, contentsRootDir string, deviceSize, sectorSize quantity.Size) error {
+	if contentsRootDir != "" {
+		// mkfs.xfs only knows how to populate a filesystem from a
+		// prototype file, which we do not generate
+		return fmt.Errorf("cannot populate xfs filesystem with contents: not supported")
+	}
+	mkfsArgs := []string{"-f"}
+	// the sector size of the filesystem cannot be smaller than the one of
+	// the device, mkfs.xfs defaults to 512 bytes for images
+	if sectorSize > 512 {
+		mkfsArgs = append(mkfsArgs, "-s", "size="+sectorSize.String())
+	}
+	if label != "" {
+		mkfsArgs = append(mkfsArgs, "-L", label)
+	}
+	mkfsArgs = append(mkfsArgs, img)
+
+	cmd := exec.Command("mkfs.xfs", mkfsArgs...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return osutil.OutputErr(out, err)
+	}
+	return nil
+}
+
+// mkfsF2fs creates an F2FS filesystem in given image file, with an optional
+// filesystem label, and populates it with the contents of provided root
+// directory.
+func mkfsF2fs(img, label, contentsRootDir string, deviceSize, sectorSize quantity.Size) error {
+	mkfsArgs := []string{"-f"}
+	if label != "" {
+		mkfsArgs = append(mkfsArgs, "-l", label)
+	}
+	mkfsArgs = append(mkfsArgs, img)
+
+	cmd := exec.Command("mkfs.f2fs", mkfsArgs...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return osutil.OutputErr(out, err)
+	}
+
+	// if there is no content to copy we are done now
+	if contentsRootDir == "" {
+		return nil
+	}
+
+	// mkfs.f2fs does not know how to populate the filesystem with contents,
+	// this is done by sload.f2fs from the same tools instead
+	sloadArgs := []string{"sload.f2fs", "-f", contentsRootDir, img}
+	if err := runAsRoot(sloadArgs); err != nil {
+		return fmt.Errorf("cannot populate f2fs filesystem with contents: %v", err)
+	}
+	return nil
+}
+
 func mkfsVfat16(img, label, contentsRootDir string, deviceSize, sectorSize quantity.Size) error {
 	return mkfsVfat(img, label, contentsRootDir, deviceSize, sectorSize, "16")
 }
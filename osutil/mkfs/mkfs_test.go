@@ -43,7 +43,7 @@ var _ = Suite(&mkfsSuite{})
 func (m *mkfsSuite) SetUpTest(c *C) {
 	m.BaseTest.SetUpTest(c)
 
-	// fakeroot, mkfs.ext4, mkfs.vfat and mcopy are commonly installed in
+	// fakeroot, mkfs.ext4, mkfs.vfat, mcopy and others are commonly installed in
 	// the host system, set up some overrides so that we avoid calling the
 	// host tools
 	cmdFakeroot := testutil.MockCommand(c, "fakeroot", "echo 'override in test' ; exit 1")
@@ -57,6 +57,11 @@ func (m *mkfsSuite) SetUpTest(c *C) {
 
 	cmdMcopy := testutil.MockCommand(c, "mcopy", "echo 'override in test'; exit 1")
 	m.AddCleanup(cmdMcopy.Restore)
+
+	for _, tool := range []string{"mkfs.btrfs", "mkfs.xfs", "mkfs.f2fs", "sload.f2fs"} {
+		cmd := testutil.MockCommand(c, tool, "echo 'override in test'; exit 1")
+		m.AddCleanup(cmd.Restore)
+	}
 }
 
 func (m *mkfsSuite) TestMkfsExt4Happy(c *C) {
@@ -369,6 +374,128 @@ func (m *mkfsSuite) TestMkfsInvalidFs(c *C) {
 	c.Assert(err, ErrorMatches, `cannot create unsupported filesystem "no-fs"`)
 }
 
+// callsAsRoot returns the calls of a command which is run through fakeroot
+// when not running as root.
+func callsAsRoot(cmd, fakeroot *testutil.MockCmd) [][]string {
+	if os.Geteuid() == 0 {
+		return cmd.Calls()
+	}
+	return fakeroot.Calls()
+}
+
+func (m *mkfsSuite) TestMkfsBtrfsHappy(c *C) {
+	cmdFakeroot := testutil.MockCommand(c, "fakeroot", "")
+	defer cmdFakeroot.Restore()
+	cmdMkfs := testutil.MockCommand(c, "mkfs.btrfs", "")
+	defer cmdMkfs.Restore()
+
+	err := mkfs.MakeWithContent("btrfs", "foo.img", "my-label", "contents", 0, 0)
+	c.Assert(err, IsNil)
+	calls := callsAsRoot(cmdMkfs, cmdFakeroot)
+	c.Assert(calls, HasLen, 1)
+	c.Check(calls[0][len(calls[0])-7:], DeepEquals, []string{
+		"mkfs.btrfs", "-f",
+		"--rootdir", "contents",
+		"-L", "my-label",
+		"foo.img",
+	})
+
+	cmdMkfs.ForgetCalls()
+	cmdFakeroot.ForgetCalls()
+
+	// no content and no label
+	err = mkfs.Make("btrfs", "foo.img", "", 0, 0)
+	c.Assert(err, IsNil)
+	calls = callsAsRoot(cmdMkfs, cmdFakeroot)
+	c.Assert(calls, HasLen, 1)
+	c.Check(calls[0][len(calls[0])-3:], DeepEquals, []string{
+		"mkfs.btrfs", "-f", "foo.img",
+	})
+}
+
+func (m *mkfsSuite) TestMkfsBtrfsError(c *C) {
+	cmdFakeroot := testutil.MockCommand(c, "fakeroot", "echo 'command failed'; exit 1")
+	defer cmdFakeroot.Restore()
+	cmdMkfs := testutil.MockCommand(c, "mkfs.btrfs", "echo 'command failed'; exit 1")
+	defer cmdMkfs.Restore()
+
+	err := mkfs.MakeWithContent("btrfs", "foo.img", "my-label", "contents", 0, 0)
+	c.Assert(err, ErrorMatches, "command failed")
+}
+
+func (m *mkfsSuite) TestMkfsXfsHappy(c *C) {
+	cmd := testutil.MockCommand(c, "mkfs.xfs", "")
+	defer cmd.Restore()
+
+	err := mkfs.Make("xfs", "foo.img", "my-label", 0, 0)
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"mkfs.xfs", "-f", "-L", "my-label", "foo.img"},
+	})
+
+	cmd.ForgetCalls()
+
+	// sector size larger than the default
+	err = mkfs.Make("xfs", "foo.img", "", 0, 4096)
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"mkfs.xfs", "-f", "-s", "size=4096", "foo.img"},
+	})
+}
+
+func (m *mkfsSuite) TestMkfsXfsContentsUnsupported(c *C) {
+	cmd := testutil.MockCommand(c, "mkfs.xfs", "")
+	defer cmd.Restore()
+
+	err := mkfs.MakeWithContent("xfs", "foo.img", "my-label", "contents", 0, 0)
+	c.Assert(err, ErrorMatches, "cannot populate xfs filesystem with contents: not supported")
+	c.Check(cmd.Calls(), HasLen, 0)
+}
+
+func (m *mkfsSuite) TestMkfsF2fsHappy(c *C) {
+	cmdMkfs := testutil.MockCommand(c, "mkfs.f2fs", "")
+	defer cmdMkfs.Restore()
+	cmdFakeroot := testutil.MockCommand(c, "fakeroot", "")
+	defer cmdFakeroot.Restore()
+	cmdSload := testutil.MockCommand(c, "sload.f2fs", "")
+	defer cmdSload.Restore()
+
+	err := mkfs.MakeWithContent("f2fs", "foo.img", "my-label", "contents", 0, 0)
+	c.Assert(err, IsNil)
+	c.Check(cmdMkfs.Calls(), DeepEquals, [][]string{
+		{"mkfs.f2fs", "-f", "-l", "my-label", "foo.img"},
+	})
+	calls := callsAsRoot(cmdSload, cmdFakeroot)
+	c.Assert(calls, HasLen, 1)
+	c.Check(calls[0][len(calls[0])-4:], DeepEquals, []string{
+		"sload.f2fs", "-f", "contents", "foo.img",
+	})
+
+	cmdMkfs.ForgetCalls()
+	cmdSload.ForgetCalls()
+	cmdFakeroot.ForgetCalls()
+
+	// no content
+	err = mkfs.Make("f2fs", "foo.img", "", 0, 0)
+	c.Assert(err, IsNil)
+	c.Check(cmdMkfs.Calls(), DeepEquals, [][]string{
+		{"mkfs.f2fs", "-f", "foo.img"},
+	})
+	c.Check(callsAsRoot(cmdSload, cmdFakeroot), HasLen, 0)
+}
+
+func (m *mkfsSuite) TestMkfsF2fsErrorInSload(c *C) {
+	cmdMkfs := testutil.MockCommand(c, "mkfs.f2fs", "")
+	defer cmdMkfs.Restore()
+	cmdFakeroot := testutil.MockCommand(c, "fakeroot", "echo 'failed'; exit 1")
+	defer cmdFakeroot.Restore()
+	cmdSload := testutil.MockCommand(c, "sload.f2fs", "echo 'failed'; exit 1")
+	defer cmdSload.Restore()
+
+	err := mkfs.MakeWithContent("f2fs", "foo.img", "my-label", "contents", 0, 0)
+	c.Assert(err, ErrorMatches, "cannot populate f2fs filesystem with contents: failed")
+}
+
 func makeSizedFile(c *C, path string, size int64, content []byte) {
 	err := os.MkdirAll(filepath.Dir(path), 0755)
 	c.Assert(err, IsNil)
@@ -193,6 +193,11 @@ func sudoersFile(name string) string {
 	return filepath.Join(sudoersDotD, "create-user-"+strings.Replace(name, ".", "%2E", -1))
 }
 
+// HasSudoersDropIn returns whether the user was given sudo rights by AddUser.
+func HasSudoersDropIn(name string) bool {
+	return FileExists(sudoersFile(name))
+}
+
 var hasAddUserExecutable = func() bool {
 	return ExecutableExists("adduser")
 }
@@ -163,6 +163,24 @@ karl.sagan ALL=(ALL) NOPASSWD:ALL
 `)
 }
 
+func (s *createUserSuite) TestHasSudoersDropIn(c *check.C) {
+	r := osutil.MockhasAddUserExecutable(func() bool { return true })
+	defer r()
+	mockSudoers := c.MkDir()
+	restorer := osutil.MockSudoersDotD(mockSudoers)
+	defer restorer()
+
+	c.Check(osutil.HasSudoersDropIn("karl.sagan"), check.Equals, false)
+
+	err := osutil.AddUser("karl.sagan", &osutil.AddUserOptions{Sudoer: true})
+	c.Assert(err, check.IsNil)
+	err = osutil.AddUser("karl.popper", nil)
+	c.Assert(err, check.IsNil)
+
+	c.Check(osutil.HasSudoersDropIn("karl.sagan"), check.Equals, true)
+	c.Check(osutil.HasSudoersDropIn("karl.popper"), check.Equals, false)
+}
+
 func (s *createUserSuite) TestAddUserSSHKeys(c *check.C) {
 	r := osutil.MockhasAddUserExecutable(func() bool { return true })
 	defer r()
@@ -0,0 +1,53 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	coreOptionRecoverySystemsCheckInterval = "recovery-systems.check-interval"
+	coreOptionRecoverySystemsAutoRepair    = "recovery-systems.auto-repair"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core."+coreOptionRecoverySystemsCheckInterval] = true
+	supportedConfigurations["core."+coreOptionRecoverySystemsAutoRepair] = true
+}
+
+func validateRecoverySystemsCheckSettings(tr RunTransaction) error {
+	intervalStr, err := coreCfg(tr, coreOptionRecoverySystemsCheckInterval)
+	if err != nil {
+		return err
+	}
+	if intervalStr != "" && intervalStr != "no" {
+		dur, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return fmt.Errorf("%s cannot be parsed: %v", coreOptionRecoverySystemsCheckInterval, err)
+		}
+		if dur < time.Hour*24 {
+			return fmt.Errorf("%s must be at least 24 hours, or \"no\" to disable", coreOptionRecoverySystemsCheckInterval)
+		}
+	}
+	return validateBoolFlag(tr, coreOptionRecoverySystemsAutoRepair)
+}
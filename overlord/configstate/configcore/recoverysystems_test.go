@@ -0,0 +1,62 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type recoverySystemsSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&recoverySystemsSuite{})
+
+func (s *recoverySystemsSuite) TestConfigureCheckIntervalHappy(c *C) {
+	for _, interval := range []string{"168h", "24h", "no"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"recovery-systems.check-interval": interval,
+				"recovery-systems.auto-repair":    "true",
+			},
+		})
+		c.Check(err, IsNil)
+	}
+}
+
+func (s *recoverySystemsSuite) TestConfigureCheckIntervalErrors(c *C) {
+	for _, tc := range []struct {
+		conf map[string]interface{}
+		err  string
+	}{
+		{map[string]interface{}{"recovery-systems.check-interval": "10m"}, `recovery-systems.check-interval must be at least 24 hours, or "no" to disable`},
+		{map[string]interface{}{"recovery-systems.check-interval": "invalid"}, `recovery-systems.check-interval cannot be parsed:.*`},
+		{map[string]interface{}{"recovery-systems.auto-repair": "yes"}, `recovery-systems.auto-repair can only be set to 'true' or 'false'`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
@@ -128,6 +129,10 @@ type DeviceManager struct {
 	preseedSystemLabel string
 
 	ntpSyncedOrTimedOut bool
+
+	// passphraseChangeMu serializes passphrase changes, which run
+	// without holding the state lock
+	passphraseChangeMu sync.Mutex
 }
 
 // Manager returns a new device manager.
@@ -184,6 +189,9 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	runner.AddHandler("setup-ubuntu-save", m.doSetupUbuntuSave, nil)
 	runner.AddHandler("setup-run-system", m.doSetupRunSystem, nil)
 	runner.AddHandler("factory-reset-run-system", m.doFactoryResetRunSystem, nil)
+	runner.AddHandler("prepare-factory-reset", m.doPrepareFactoryReset, nil)
+	runner.AddHandler("restore-preserved-data", m.doRestorePreservedData, nil)
+	runner.AddHandler("check-recovery-systems", m.doCheckRecoverySystems, nil)
 	runner.AddHandler("restart-system-to-run-mode", m.doRestartSystemToRunMode, nil)
 	runner.AddHandler("prepare-remodeling", m.doPrepareRemodeling, nil)
 	runner.AddCleanup("prepare-remodeling", m.cleanupRemodel)
@@ -196,7 +204,7 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	// deployed boot assets must be backward compatible with reverted kernel
 	// or gadget snaps. There are no further changes to the boot assets,
 	// unless a new gadget update is deployed.
-	runner.AddHandler("update-gadget-assets", m.doUpdateGadgetAssets, nil)
+	runner.AddHandler("update-gadget-assets", m.doUpdateGadgetAssets, m.undoUpdateGadgetAssets)
 	// There is no undo handler for successful boot config update. The
 	// config assets are assumed to be always backwards compatible.
 	runner.AddHandler("update-managed-boot-config", m.doUpdateManagedBootConfig, nil)
@@ -1717,6 +1725,8 @@ func (m *DeviceManager) ensurePostFactoryReset() error {
 		}
 	}
 
+	m.maybeScheduleRestorePreservedData()
+
 	return os.Remove(factoryResetMarker)
 }
 
@@ -1839,6 +1849,10 @@ func (m *DeviceManager) Ensure() error {
 		if err := m.ensureExpiredUsersRemoved(); err != nil {
 			errs = append(errs, err)
 		}
+
+		if err := m.ensureRecoverySystemsChecked(); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if len(errs) > 0 {
@@ -2708,6 +2722,12 @@ func (h fdeSetupHandler) Error(err error) (bool, error) {
 var (
 	secbootEnsureRecoveryKey  = secboot.EnsureRecoveryKey
 	secbootRemoveRecoveryKeys = secboot.RemoveRecoveryKeys
+	secbootChangePassphrase   = secboot.ChangePassphrase
+
+	secbootListKeySlots           = secboot.ListKeySlots
+	secbootAddNamedRecoveryKey    = secboot.AddNamedRecoveryKey
+	secbootRotateNamedRecoveryKey = secboot.RotateNamedRecoveryKey
+	secbootRemoveNamedRecoveryKey = secboot.RemoveNamedRecoveryKey
 )
 
 // EnsureRecoveryKeys makes sure appropriate recovery keys exist and
@@ -2822,6 +2842,178 @@ func (m *DeviceManager) RemoveRecoveryKeys() error {
 	return secbootRemoveRecoveryKeys(recoveryKeyDevices)
 }
 
+// runModeRecoveryKeyDevices returns the encrypted ubuntu-data and ubuntu-save
+// devices of a system in run mode along with how operations on them are
+// authorized.
+func (m *DeviceManager) runModeRecoveryKeyDevices() ([]secboot.RecoveryKeyDevice, error) {
+	mode := m.SystemMode(SysAny)
+	if mode != "run" {
+		return nil, fmt.Errorf("cannot manage recovery keys from system mode %q", mode)
+	}
+	if !device.HasEncryptedMarkerUnder(dirs.SnapFDEDir) {
+		return nil, fmt.Errorf("system does not use disk encryption")
+	}
+	deviceCtx, err := DeviceCtx(m.state, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	model := deviceCtx.Model()
+
+	dataMountPoints, err := boot.HostUbuntuDataForMode(m.SystemMode(SysHasModeenv), model)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine ubuntu-data mount point: %v", err)
+	}
+	if len(dataMountPoints) == 0 {
+		// shouldn't happen as the marker file is under ubuntu-data
+		return nil, fmt.Errorf("cannot manage recovery keys without any ubuntu-data mount points")
+	}
+	authKeyDir := dataMountPoints[0]
+	if !model.Classic() {
+		authKeyDir = filepath.Join(authKeyDir, "system-data")
+	}
+	return []secboot.RecoveryKeyDevice{
+		{
+			Mountpoint: dataMountPoints[0],
+			// authorization from keyring
+		},
+		{
+			Mountpoint:         boot.InitramfsUbuntuSaveDir,
+			AuthorizingKeyFile: device.SaveKeyUnder(dirs.SnapFDEDirUnder(authKeyDir)),
+		},
+	}, nil
+}
+
+// RecoveryKeySlots returns the used key slots of the encrypted ubuntu-data
+// and ubuntu-save volumes.
+func (m *DeviceManager) RecoveryKeySlots() (*client.SystemRecoveryKeySlotsResponse, error) {
+	rkeyDevs, err := m.runModeRecoveryKeyDevices()
+	if err != nil {
+		return nil, err
+	}
+	volumes := []string{"ubuntu-data", "ubuntu-save"}
+	resp := &client.SystemRecoveryKeySlotsResponse{
+		Volumes: make(map[string][]client.KeySlot, len(rkeyDevs)),
+	}
+	for i, rkeyDev := range rkeyDevs {
+		slots, err := secbootListKeySlots(rkeyDev.Mountpoint)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list key slots of %s: %v", volumes[i], err)
+		}
+		keySlots := make([]client.KeySlot, 0, len(slots))
+		for _, slot := range slots {
+			ks := client.KeySlot{
+				ID:   slot.ID,
+				Type: slot.Type,
+				Name: slot.Name,
+			}
+			if !slot.Created.IsZero() {
+				created := slot.Created
+				ks.Created = &created
+			}
+			keySlots = append(keySlots, ks)
+		}
+		resp.Volumes[volumes[i]] = keySlots
+	}
+	return resp, nil
+}
+
+// AddNamedRecoveryKey adds a newly generated recovery key with the given name
+// to the encrypted volumes and returns it. The key is not stored on the
+// device.
+func (m *DeviceManager) AddNamedRecoveryKey(name string) (string, error) {
+	if err := secboot.ValidateRecoveryKeyName(name); err != nil {
+		return "", err
+	}
+	rkeyDevs, err := m.runModeRecoveryKeyDevices()
+	if err != nil {
+		return "", err
+	}
+	rkey, err := secbootAddNamedRecoveryKey(name, rkeyDevs)
+	if err != nil {
+		return "", err
+	}
+	return rkey.String(), nil
+}
+
+// RotateNamedRecoveryKey replaces the recovery key with the given name with a
+// newly generated one on all encrypted volumes and returns it. The old key
+// remains valid should the operation fail.
+func (m *DeviceManager) RotateNamedRecoveryKey(name string) (string, error) {
+	if err := secboot.ValidateRecoveryKeyName(name); err != nil {
+		return "", err
+	}
+	rkeyDevs, err := m.runModeRecoveryKeyDevices()
+	if err != nil {
+		return "", err
+	}
+	rkey, err := secbootRotateNamedRecoveryKey(name, rkeyDevs)
+	if err != nil {
+		return "", err
+	}
+	return rkey.String(), nil
+}
+
+// RemoveNamedRecoveryKey removes the recovery key with the given name from
+// the encrypted volumes.
+func (m *DeviceManager) RemoveNamedRecoveryKey(name string) error {
+	if err := secboot.ValidateRecoveryKeyName(name); err != nil {
+		return err
+	}
+	rkeyDevs, err := m.runModeRecoveryKeyDevices()
+	if err != nil {
+		return err
+	}
+	return secbootRemoveNamedRecoveryKey(name, rkeyDevs)
+}
+
+// ChangeEncryptionPassphrase changes the passphrase used to unlock the
+// encrypted volumes of systems set up for passphrase authentication. It must be
+// called with the state lock held, which is released while the volumes are
+// being updated.
+func (m *DeviceManager) ChangeEncryptionPassphrase(oldPassphrase, newPassphrase string) error {
+	mode := m.SystemMode(SysAny)
+	if mode != "run" {
+		return fmt.Errorf("cannot change passphrase from system mode %q", mode)
+	}
+	if !device.HasEncryptedMarkerUnder(dirs.SnapFDEDir) {
+		return fmt.Errorf("system does not use disk encryption")
+	}
+	method, err := device.SealedKeysMethod(dirs.GlobalRootDir)
+	if err != nil && err != device.ErrNoSealedKeys {
+		return err
+	}
+	if method != device.SealingMethodPassphrase {
+		return fmt.Errorf("system does not use passphrase authentication")
+	}
+	if newPassphrase == "" {
+		return fmt.Errorf("new passphrase cannot be empty")
+	}
+	deviceCtx, err := DeviceCtx(m.state, nil, nil)
+	if err != nil {
+		return err
+	}
+	model := deviceCtx.Model()
+
+	dataMountPoints, err := boot.HostUbuntuDataForMode(m.SystemMode(SysHasModeenv), model)
+	if err != nil {
+		return fmt.Errorf("cannot determine ubuntu-data mount point: %v", err)
+	}
+	if len(dataMountPoints) == 0 {
+		// shouldn't happen as the marker file is under ubuntu-data
+		return fmt.Errorf("cannot change passphrase without any ubuntu-data mount points")
+	}
+	mountPoints := []string{dataMountPoints[0], boot.InitramfsUbuntuSaveDir}
+
+	// updating the key slots with strong KDF settings takes a while, do not
+	// block the state in the meantime
+	m.state.Unlock()
+	defer m.state.Lock()
+	m.passphraseChangeMu.Lock()
+	defer m.passphraseChangeMu.Unlock()
+
+	return secbootChangePassphrase(oldPassphrase, newPassphrase, mountPoints)
+}
+
 // checkEncryption verifies whether encryption should be used based on the
 // model grade and the availability of a TPM device or a fde-setup hook
 // in the kernel.
@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/netutil"
@@ -1939,14 +1940,25 @@ func InstallFinish(st *state.State, label string, onVolumes map[string]*gadget.V
 
 // InstallSetupStorageEncryption creates a change that will setup the
 // storage encryption for the install of the given label and
-// volumes.
-func InstallSetupStorageEncryption(st *state.State, label string, onVolumes map[string]*gadget.Volume) (*state.Change, error) {
+// volumes. The optional volumesAuth sets up additional authentication,
+// e.g. a passphrase, for the encrypted volumes.
+func InstallSetupStorageEncryption(st *state.State, label string, onVolumes map[string]*gadget.Volume, volumesAuth *device.VolumesAuthOptions) (*state.Change, error) {
 	if label == "" {
 		return nil, fmt.Errorf("cannot setup storage encryption with an empty system label")
 	}
 	if onVolumes == nil {
 		return nil, fmt.Errorf("cannot setup storage encryption without volumes data")
 	}
+	if volumesAuth != nil {
+		if err := volumesAuth.Validate(); err != nil {
+			return nil, err
+		}
+		if err := secbootCheckVolumesAuthSupported(volumesAuth); err != nil {
+			return nil, err
+		}
+		// the passphrase must never be persisted in the state
+		st.Cache(volumesAuthOptionsKey{label}, volumesAuth)
+	}
 
 	chg := st.NewChange("install-step-setup-storage-encryption", fmt.Sprintf("Setup storage encryption for installing system %q", label))
 	setupStorageEncryptionTask := st.NewTask("install-setup-storage-encryption", fmt.Sprintf("Setup storage encryption for installing system %q", label))
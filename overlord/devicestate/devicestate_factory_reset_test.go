@@ -0,0 +1,412 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/user"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/restart"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/seed/seedtest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type deviceMgrFactoryResetSuite struct {
+	deviceMgrSystemsBaseSuite
+
+	userHome   string
+	knownUsers map[string]bool
+}
+
+var _ = Suite(&deviceMgrFactoryResetSuite{})
+
+func (s *deviceMgrFactoryResetSuite) SetUpTest(c *C) {
+	s.deviceMgrSystemsBaseSuite.SetUpTest(c)
+
+	s.AddCleanup(release.MockOnClassic(false))
+
+	// the recovery system to reset into
+	restore := seed.MockTrusted(s.storeSigning.Trusted)
+	s.AddCleanup(restore)
+	seed20 := &seedtest.TestingSeed20{
+		SeedSnaps: *s.ss,
+		SeedDir:   dirs.SnapSeedDir,
+	}
+	seed20.MakeAssertedSnap(c, "name: snapd\nversion: 1\ntype: snapd", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: pc\nversion: 1\ntype: gadget\nbase: core20", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: pc-kernel\nversion: 1\ntype: kernel", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: core20\nversion: 1\ntype: base", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seedModel := seed20.MakeSeed(c, "20191119", "my-brand", "my-model", map[string]interface{}{
+		"display-name": "my fancy model",
+		"architecture": "amd64",
+		"base":         "core20",
+		"snaps": []interface{}{
+			map[string]interface{}{
+				"name":            "pc-kernel",
+				"id":              seed20.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]interface{}{
+				"name":            "pc",
+				"id":              seed20.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			}},
+	}, nil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.model = s.makeModelAssertionInState(c, "canonical", "pc-20", map[string]interface{}{
+		"architecture": "amd64",
+		"grade":        "dangerous",
+		"revision":     "1",
+		"base":         "core20",
+		"snaps": []interface{}{
+			map[string]interface{}{
+				"name":            "pc-kernel",
+				"id":              s.ss.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]interface{}{
+				"name":            "pc",
+				"id":              s.ss.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			},
+			map[string]interface{}{
+				"name": "site-config",
+				"id":   s.ss.AssertedSnapID("site-config"),
+			},
+		},
+	})
+	snapstatetest.InstallSnap(c, s.state, "name: site-config\nversion: 1\n", nil, &snap.SideInfo{
+		RealName: "site-config",
+		Revision: snap.R(1),
+	}, snapstatetest.InstallSnapOptions{})
+
+	devicestate.SetSaveAvailable(s.mgr, true)
+	// boot was already marked as successful
+	devicestate.SetBootOkRan(s.mgr, true)
+	s.state.Set("seeded-systems", []devicestate.SeededSystem{{
+		System:  "20191119",
+		Model:   seedModel.Model(),
+		BrandID: seedModel.BrandID(),
+	}})
+
+	nopHandler := func(task *state.Task, _ *tomb.Tomb) error { return nil }
+	s.o.TaskRunner().AddHandler("fake-save-snapshot", nopHandler, nil)
+	s.o.TaskRunner().AddHandler("fake-restore-snapshot", nopHandler, nil)
+
+	s.userHome = c.MkDir()
+	s.knownUsers = map[string]bool{"frank": true}
+	lookup := mkUserLookup(s.userHome)
+	s.AddCleanup(devicestate.MockUserLookup(func(username string) (*user.User, error) {
+		if !s.knownUsers[username] {
+			return nil, fmt.Errorf("unknown user %q", username)
+		}
+		usr, err := lookup(username)
+		if err != nil {
+			return nil, err
+		}
+		usr.Name = "Frank"
+		return usr, nil
+	}))
+	s.AddCleanup(devicestate.MockOsutilHasSudoersDropIn(func(name string) bool {
+		return name == "frank"
+	}))
+}
+
+func (s *deviceMgrFactoryResetSuite) mockPreserveSnapshotSave(c *C) {
+	s.AddCleanup(devicestate.MockSnapshotstateSave(func(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		c.Check(instanceNames, DeepEquals, []string{"site-config"})
+		c.Check(users, IsNil)
+		c.Check(options, IsNil)
+		t := st.NewTask("fake-save-snapshot", "...")
+		return 42, instanceNames, state.NewTaskSet(t), nil
+	}))
+}
+
+func (s *deviceMgrFactoryResetSuite) TestFactoryResetPreserveSnapsHappy(c *C) {
+	s.mockPreserveSnapshotSave(c)
+	exportCalls := 0
+	s.AddCleanup(devicestate.MockExportSnapshotToFile(func(ctx context.Context, st *state.State, setID uint64, target string) error {
+		exportCalls++
+		c.Check(setID, Equals, uint64(42))
+		return os.WriteFile(target, []byte("snapshot"), 0600)
+	}))
+
+	// a system user with a password, ssh keys and sudo rights
+	s.state.Lock()
+	_, err := auth.NewUser(s.state, auth.NewUserParams{
+		Username: "frank",
+		Email:    "frank@example.com",
+	})
+	c.Assert(err, IsNil)
+	s.state.Unlock()
+	c.Assert(os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/var/lib/extrausers"), 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.GlobalRootDir, "/var/lib/extrausers/shadow"),
+		[]byte("locked:!:19000::::::\nfrank:$6$salt$hash:19000:0:99999:7:::\n"), 0600), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(s.userHome, ".ssh"), 0700), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(s.userHome, ".ssh", "authorized_keys"),
+		[]byte("ssh-rsa key1\n\nssh-rsa key2\n"), 0600), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg, err := devicestate.FactoryReset(s.state, "20191119", devicestate.FactoryResetOptions{
+		PreserveSnaps: []string{"site-config", "site-config"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(chg.Kind(), Equals, "factory-reset")
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 2)
+	c.Check(tasks[0].Kind(), Equals, "fake-save-snapshot")
+	c.Check(tasks[1].Kind(), Equals, "prepare-factory-reset")
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{tasks[0]})
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(exportCalls, Equals, 1)
+
+	preservedDir := filepath.Join(dirs.SnapSaveDir, "factory-reset")
+	c.Check(filepath.Join(preservedDir, "snapshot.tar"), testutil.FileEquals, "snapshot")
+	content, err := os.ReadFile(filepath.Join(preservedDir, "preserved.json"))
+	c.Assert(err, IsNil)
+	var preserved map[string]interface{}
+	c.Assert(json.Unmarshal(content, &preserved), IsNil)
+	c.Check(preserved, DeepEquals, map[string]interface{}{
+		"snaps": []interface{}{"site-config"},
+		"users": []interface{}{
+			map[string]interface{}{
+				"username":   "frank",
+				"email":      "frank@example.com",
+				"expiration": "0001-01-01T00:00:00Z",
+				"gecos":      "Frank",
+				"password":   "$6$salt$hash",
+				"ssh-keys":   []interface{}{"ssh-rsa key1", "ssh-rsa key2"},
+				"sudoer":     true,
+			},
+		},
+	})
+
+	// and the device restarts into factory reset mode
+	m, err := s.bootloader.GetBootVars("snapd_recovery_mode", "snapd_recovery_system")
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, map[string]string{
+		"snapd_recovery_system": "20191119",
+		"snapd_recovery_mode":   "factory-reset",
+	})
+	c.Check(s.restartRequests, DeepEquals, []restart.RestartType{restart.RestartSystemNow})
+}
+
+func (s *deviceMgrFactoryResetSuite) TestFactoryResetPreserveSnapsExportError(c *C) {
+	s.mockPreserveSnapshotSave(c)
+	s.AddCleanup(devicestate.MockExportSnapshotToFile(func(ctx context.Context, st *state.State, setID uint64, target string) error {
+		return fmt.Errorf("boom")
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg, err := devicestate.FactoryReset(s.state, "20191119", devicestate.FactoryResetOptions{
+		PreserveSnaps: []string{"site-config"},
+	})
+	c.Assert(err, IsNil)
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Check(chg.Err(), ErrorMatches, `(?s).*cannot save data to preserve across factory reset: boom.*`)
+	c.Check(s.restartRequests, HasLen, 0)
+	c.Check(s.bootloader.BootVars["snapd_recovery_mode"], Equals, "")
+}
+
+func (s *deviceMgrFactoryResetSuite) TestFactoryResetPreserveSnapsErrors(c *C) {
+	s.AddCleanup(devicestate.MockSnapshotstateSave(func(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		c.Fatalf("unexpected call")
+		return 0, nil, nil, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, tc := range []struct {
+		label string
+		snaps []string
+		err   string
+	}{
+		{"20191119", []string{"site-config", "other-snap"}, `cannot preserve data of snap "other-snap": snap is not listed in the model`},
+		{"not-found", []string{"site-config"}, `cannot find recovery system "not-found": .*`},
+	} {
+		_, err := devicestate.FactoryReset(s.state, tc.label, devicestate.FactoryResetOptions{
+			PreserveSnaps: tc.snaps,
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+
+	devicestate.SetSystemMode(s.mgr, "recover")
+	_, err := devicestate.FactoryReset(s.state, "20191119", devicestate.FactoryResetOptions{
+		PreserveSnaps: []string{"site-config"},
+	})
+	c.Check(err, ErrorMatches, `cannot preserve snap data across factory reset from "recover" mode`)
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *deviceMgrFactoryResetSuite) TestFactoryResetPreserveSnapsConflict(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("remodel", "...")
+	chg.AddTask(s.state.NewTask("fake-save-snapshot", "..."))
+
+	_, err := devicestate.FactoryReset(s.state, "20191119", devicestate.FactoryResetOptions{
+		PreserveSnaps: []string{"site-config"},
+	})
+	c.Check(err, FitsTypeOf, &snapstate.ChangeConflictError{})
+}
+
+func (s *deviceMgrFactoryResetSuite) mockFactoryResetDone(c *C) {
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	s.state.Unlock()
+	devicestate.SetPostFactoryResetRan(s.mgr, false)
+
+	// mock the factory reset marker of a system that isn't encrypted
+	c.Assert(os.MkdirAll(dirs.SnapDeviceDir, 0755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapDeviceDir, "factory-reset"), []byte("{}"), 0644), IsNil)
+	s.AddCleanup(devicestate.MockMarkFactoryResetComplete(func(encrypted bool) error {
+		return nil
+	}))
+}
+
+func (s *deviceMgrFactoryResetSuite) TestRestorePreservedDataHappy(c *C) {
+	s.mockFactoryResetDone(c)
+
+	preservedDir := filepath.Join(dirs.SnapSaveDir, "factory-reset")
+	c.Assert(os.MkdirAll(preservedDir, 0700), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(preservedDir, "snapshot.tar"), []byte("snapshot"), 0600), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(preservedDir, "preserved.json"), []byte(`{
+"snaps": ["site-config"],
+"users": [
+  {"username": "frank", "email": "frank@example.com", "expiration": "0001-01-01T00:00:00Z", "sudoer": true},
+  {"username": "ann", "email": "ann@example.com", "gecos": "Ann", "password": "$6$salt$hash", "ssh-keys": ["ssh-rsa key"], "expiration": "2030-01-01T00:00:00Z"}
+]}`), 0600), IsNil)
+
+	var addedUsers []string
+	s.AddCleanup(devicestate.MockOsutilAddUser(func(name string, opts *osutil.AddUserOptions) error {
+		addedUsers = append(addedUsers, name)
+		s.knownUsers[name] = true
+		c.Check(opts, DeepEquals, &osutil.AddUserOptions{
+			Gecos:      "Ann",
+			Password:   "$6$salt$hash",
+			SSHKeys:    []string{"ssh-rsa key"},
+			ExtraUsers: true,
+		})
+		return nil
+	}))
+	s.AddCleanup(devicestate.MockSnapshotstateImport(func(ctx context.Context, st *state.State, r io.Reader) (uint64, []string, error) {
+		content, err := io.ReadAll(r)
+		c.Assert(err, IsNil)
+		c.Check(string(content), Equals, "snapshot")
+		return 7, []string{"site-config"}, nil
+	}))
+	s.AddCleanup(devicestate.MockSnapshotstateRestore(func(st *state.State, setID uint64, snapNames []string, users []string) ([]string, *state.TaskSet, error) {
+		c.Check(setID, Equals, uint64(7))
+		c.Check(snapNames, DeepEquals, []string{"site-config"})
+		c.Check(users, IsNil)
+		return snapNames, state.NewTaskSet(st.NewTask("fake-restore-snapshot", "...")), nil
+	}))
+
+	err := s.mgr.Ensure()
+	c.Assert(err, IsNil)
+	// factory reset marker is gone
+	c.Check(filepath.Join(dirs.SnapDeviceDir, "factory-reset"), testutil.FileAbsent)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var chg *state.Change
+	for _, ch := range s.state.Changes() {
+		if ch.Kind() == "restore-preserved-data" {
+			chg = ch
+		}
+	}
+	c.Assert(chg, NotNil)
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 2)
+	c.Check(tasks[0].Kind(), Equals, "restore-preserved-data")
+	c.Check(tasks[1].Kind(), Equals, "fake-restore-snapshot")
+
+	// frank exists already, only ann was added
+	c.Check(addedUsers, DeepEquals, []string{"ann"})
+	authUser, err := auth.UserByUsername(s.state, "ann")
+	c.Assert(err, IsNil)
+	c.Check(authUser.Email, Equals, "ann@example.com")
+	c.Check(authUser.Expiration.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)), Equals, true)
+
+	c.Check(preservedDir, testutil.FileAbsent)
+}
+
+func (s *deviceMgrFactoryResetSuite) TestRestorePreservedDataNothingPreserved(c *C) {
+	s.mockFactoryResetDone(c)
+
+	err := s.mgr.Ensure()
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	for _, chg := range s.state.Changes() {
+		c.Check(chg.Kind(), Not(Equals), "restore-preserved-data")
+	}
+}
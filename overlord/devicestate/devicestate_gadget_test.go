@@ -319,14 +319,15 @@ func (s *deviceMgrGadgetSuite) testUpdateGadgetSimple(c *C, grade string, encryp
 		m := st.Mode()
 		c.Assert(m.IsDir(), Equals, true)
 		c.Check(m.Perm(), Equals, os.FileMode(0750))
+		// the observer reports partition layout changes
+		_, ok := observer.(gadget.LayoutChangeObserver)
+		c.Check(ok, Equals, true, Commentf("unexpected type: %T", observer))
 		if grade == "" {
 			// non UC20 model
-			c.Check(observer, IsNil)
+			c.Check(devicestate.TrustedAssetsObserver(observer), IsNil)
 		} else {
-			c.Check(observer, NotNil)
 			// expecting a very specific observer
-			trustedUpdateObserver, ok := observer.(*boot.TrustedAssetsUpdateObserver)
-			c.Assert(ok, Equals, true, Commentf("unexpected type: %T", observer))
+			trustedUpdateObserver := devicestate.TrustedAssetsObserver(observer)
 			c.Assert(trustedUpdateObserver, NotNil)
 
 			// check that observer is behaving correctly with
@@ -452,6 +453,107 @@ func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnUC20CoreSimpleSystemRestartImme
 	s.testUpdateGadgetSimple(c, "dangerous", encryption, immediate, uc20gadgetYaml, "", isClassic)
 }
 
+func (s *deviceMgrGadgetSuite) testUpdateGadgetOnCoreUndo(c *C, layoutChanges bool, rollbackErr error) (t *state.Task, rollbackCalls int) {
+	rollbackDir := filepath.Join(dirs.SnapRollbackDir, "foo-gadget_34")
+	restore := devicestate.MockGadgetUpdate(func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, observer gadget.ContentUpdateObserver) error {
+		c.Check(path, Equals, rollbackDir)
+		if !layoutChanges {
+			return nil
+		}
+		layoutObserver, ok := observer.(gadget.LayoutChangeObserver)
+		c.Assert(ok, Equals, true)
+		return layoutObserver.BeforeLayoutChange([]*gadget.LayoutPlan{{
+			Volume: "pc",
+			Device: "/dev/vda",
+			Changes: []gadget.LayoutChange{{
+				Kind:        gadget.LayoutChangeCreate,
+				Name:        "extra",
+				DiskIndex:   4,
+				StartOffset: 1325 * quantity.OffsetMiB,
+				NewSize:     100 * quantity.SizeMiB,
+				Filesystem:  "ext4",
+			}},
+		}})
+	})
+	defer restore()
+	restore = devicestate.MockGadgetRollbackUpdate(func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, observer gadget.ContentUpdateObserver) error {
+		rollbackCalls++
+		// the same update is rolled back
+		c.Check(path, Equals, rollbackDir)
+		c.Check(path, testutil.FilePresent)
+		c.Check(current.RootDir, Equals, filepath.Join(dirs.SnapMountDir, "foo-gadget/33"))
+		c.Check(update.RootDir, Equals, filepath.Join(dirs.SnapMountDir, "foo-gadget/34"))
+		c.Check(policy, IsNil)
+		c.Check(observer, NotNil)
+		return rollbackErr
+	})
+	defer restore()
+
+	isClassic := false
+	chg, t := s.setupGadgetUpdate(c, "", gadgetYaml, "", isClassic)
+	devicestate.SetBootOkRan(s.mgr, true)
+
+	s.state.Lock()
+	s.state.Set("seeded", true)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitFor(t)
+	chg.AddTask(terr)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.mockRestartAndSettle(c, s.state, chg)
+
+	c.Assert(chg.IsReady(), Equals, true)
+	if layoutChanges {
+		// the planned changes were reported before being carried out
+		log := t.Log()
+		c.Assert(len(log) > 0, Equals, true)
+		c.Check(log[0], Matches, `.* Gadget update will create partition #4 \("extra"\) at offset 1389363200 with size 100 MiB on volume pc \(/dev/vda\)`)
+	} else {
+		c.Check(t.Has("gadget-layout-changes"), Equals, false)
+	}
+	return t, rollbackCalls
+}
+
+func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreUndoLayoutChanges(c *C) {
+	t, rollbackCalls := s.testUpdateGadgetOnCoreUndo(c, true, nil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.UndoneStatus)
+	c.Check(rollbackCalls, Equals, 1)
+	c.Check(t.Has("gadget-layout-changes"), Equals, false)
+	// the backups are gone once the update is rolled back
+	c.Check(filepath.Join(dirs.SnapRollbackDir, "foo-gadget_34"), testutil.FileAbsent)
+}
+
+func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreUndoLayoutChangesError(c *C) {
+	t, rollbackCalls := s.testUpdateGadgetOnCoreUndo(c, true, errors.New("boom"))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.ErrorStatus)
+	c.Check(rollbackCalls, Equals, 1)
+	c.Check(t.Change().Err(), ErrorMatches, `(?s).*cannot undo gadget assets update: boom.*`)
+	var plans []*gadget.LayoutPlan
+	c.Assert(t.Get("gadget-layout-changes", &plans), IsNil)
+	c.Check(plans, HasLen, 1)
+	c.Check(filepath.Join(dirs.SnapRollbackDir, "foo-gadget_34"), testutil.FilePresent)
+}
+
+func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreUndoNoLayoutChanges(c *C) {
+	t, rollbackCalls := s.testUpdateGadgetOnCoreUndo(c, false, nil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.UndoneStatus)
+	c.Check(rollbackCalls, Equals, 0)
+	c.Check(filepath.Join(dirs.SnapRollbackDir, "foo-gadget_34"), testutil.FileAbsent)
+}
+
 func (s *deviceMgrGadgetSuite) TestUpdateGadgetOnCoreNoUpdateNeeded(c *C) {
 	var called bool
 	restore := devicestate.MockGadgetUpdate(func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, _ gadget.ContentUpdateObserver) error {
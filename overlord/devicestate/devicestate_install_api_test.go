@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/bootloader"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/gadgettest"
 	"github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/gadget/quantity"
@@ -704,7 +705,7 @@ func (s *deviceMgrInstallAPISuite) TestInstallFinishNoLabel(c *C) {
 - install API finish step \(cannot load assertions for label "classic": no seed assertions\)`)
 }
 
-func (s *deviceMgrInstallAPISuite) testInstallSetupStorageEncryption(c *C, hasTPM bool) {
+func (s *deviceMgrInstallAPISuite) testInstallSetupStorageEncryption(c *C, hasTPM bool, volumesAuth *device.VolumesAuthOptions) {
 	// Mock label
 	label := "classic"
 	isClassic := true
@@ -750,9 +751,22 @@ func (s *deviceMgrInstallAPISuite) testInstallSetupStorageEncryption(c *C, hasTP
 	})
 	s.AddCleanup(restore)
 
+	addVolumesAuthCalls := 0
+	restore = devicestate.MockInstallAddVolumesAuth(func(setupData *install.EncryptionSetupData, va *device.VolumesAuthOptions) error {
+		addVolumesAuthCalls++
+		c.Check(encrytpPartCalls, Equals, 1)
+		c.Check(va, Equals, volumesAuth)
+		return nil
+	})
+	s.AddCleanup(restore)
+
 	s.state.Lock()
 	defer s.state.Unlock()
 
+	if volumesAuth != nil {
+		devicestate.MockVolumesAuthOptionsInCache(s.state, label, volumesAuth)
+	}
+
 	// Create change
 	chg := s.state.NewChange("install-step-setup-storage-encryption",
 		"Setup storage encryption")
@@ -800,14 +814,28 @@ func (s *deviceMgrInstallAPISuite) testInstallSetupStorageEncryption(c *C, hasTP
 	c.Check(ok, Equals, true)
 	// Check that state has been stored in the cache
 	c.Check(devicestate.CheckEncryptionSetupDataFromCache(s.state, label), IsNil)
+	if volumesAuth != nil {
+		c.Check(addVolumesAuthCalls, Equals, 1)
+	} else {
+		c.Check(addVolumesAuthCalls, Equals, 0)
+	}
+	// the authentication options are dropped from the cache once used
+	c.Check(devicestate.VolumesAuthOptionsFromCache(s.state, label), IsNil)
 }
 
 func (s *deviceMgrInstallAPISuite) TestInstallSetupStorageEncryptionHappy(c *C) {
-	s.testInstallSetupStorageEncryption(c, true)
+	s.testInstallSetupStorageEncryption(c, true, nil)
+}
+
+func (s *deviceMgrInstallAPISuite) TestInstallSetupStorageEncryptionPassphraseHappy(c *C) {
+	s.testInstallSetupStorageEncryption(c, true, &device.VolumesAuthOptions{
+		Mode:       device.AuthModePassphrase,
+		Passphrase: "secret",
+	})
 }
 
 func (s *deviceMgrInstallAPISuite) TestInstallSetupStorageEncryptionNoCrypto(c *C) {
-	s.testInstallSetupStorageEncryption(c, false)
+	s.testInstallSetupStorageEncryption(c, false, nil)
 }
 
 func (s *deviceMgrInstallAPISuite) TestInstallSetupStorageEncryptionNoLabel(c *C) {
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -36,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/secboot"
 	"github.com/snapcore/snapd/secboot/keys"
 	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
 )
 
 var _ = Suite(&deviceMgrRecoveryKeysSuite{})
@@ -297,3 +299,247 @@ func (s *deviceMgrRecoveryKeysSuite) TestRemoveRecoveryKeysOtherModes(c *C) {
 		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot remove recovery keys from system mode %q`, mode))
 	}
 }
+
+func (s *deviceMgrRecoveryKeysSuite) TestChangeEncryptionPassphrase(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	called := 0
+	defer devicestate.MockSecbootChangePassphrase(func(oldPassphrase, newPassphrase string, mountpoints []string) error {
+		called++
+		c.Check(oldPassphrase, Equals, "old")
+		c.Check(newPassphrase, Equals, "new")
+		c.Check(mountpoints, DeepEquals, []string{boot.InitramfsDataDir, boot.InitramfsUbuntuSaveDir})
+		// the state is not locked while the volumes are updated
+		s.state.Lock()
+		s.state.Unlock()
+		return nil
+	})()
+
+	err := s.mgr.ChangeEncryptionPassphrase("old", "new")
+	c.Check(err, ErrorMatches, `system does not use disk encryption`)
+
+	mockSnapFDEFile(c, "marker", nil)
+	mockSnapFDEFile(c, "sealed-keys", []byte("tpm"))
+	err = s.mgr.ChangeEncryptionPassphrase("old", "new")
+	c.Check(err, ErrorMatches, `system does not use passphrase authentication`)
+
+	mockSnapFDEFile(c, "sealed-keys", []byte("passphrase"))
+	err = s.mgr.ChangeEncryptionPassphrase("old", "")
+	c.Check(err, ErrorMatches, `new passphrase cannot be empty`)
+	c.Check(called, Equals, 0)
+
+	err = s.mgr.ChangeEncryptionPassphrase("old", "new")
+	c.Assert(err, IsNil)
+	c.Check(called, Equals, 1)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestChangeEncryptionPassphraseError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	defer devicestate.MockSecbootChangePassphrase(func(oldPassphrase, newPassphrase string, mountpoints []string) error {
+		return fmt.Errorf("boom")
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+	mockSnapFDEFile(c, "sealed-keys", []byte("passphrase"))
+
+	err := s.mgr.ChangeEncryptionPassphrase("old", "new")
+	c.Assert(err, ErrorMatches, `boom`)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestChangeEncryptionPassphraseOtherModes(c *C) {
+	for _, mode := range []string{"recover", "install"} {
+		devicestate.SetSystemMode(s.mgr, mode)
+
+		err := s.mgr.ChangeEncryptionPassphrase("old", "new")
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot change passphrase from system mode %q`, mode))
+	}
+}
+
+func expectedRecoveryKeyDevices(classic bool) []secboot.RecoveryKeyDevice {
+	keyFilePath := "var/lib/snapd/device/fde/ubuntu-save.key"
+	if !classic {
+		keyFilePath = filepath.Join("system-data", keyFilePath)
+	}
+	return []secboot.RecoveryKeyDevice{
+		{Mountpoint: boot.InitramfsDataDir},
+		{
+			Mountpoint:         boot.InitramfsUbuntuSaveDir,
+			AuthorizingKeyFile: filepath.Join(boot.InitramfsDataDir, keyFilePath),
+		},
+	}
+}
+
+func (s *deviceMgrRecoveryKeysSuite) testRecoveryKeySlots(c *C, classic bool) {
+	if classic {
+		s.setClassicWithModesModelInState(c)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := s.mgr.RecoveryKeySlots()
+	c.Check(err, ErrorMatches, `system does not use disk encryption`)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var mountpoints []string
+	defer devicestate.MockSecbootListKeySlots(func(mountpoint string) ([]secboot.KeySlot, error) {
+		mountpoints = append(mountpoints, mountpoint)
+		slots := []secboot.KeySlot{{ID: 0, Type: "encryption-key"}}
+		if mountpoint == boot.InitramfsDataDir {
+			slots = append(slots, secboot.KeySlot{ID: 4, Type: "named-recovery-key", Name: "backup", Created: created})
+		}
+		return slots, nil
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+
+	slots, err := s.mgr.RecoveryKeySlots()
+	c.Assert(err, IsNil)
+	c.Check(mountpoints, DeepEquals, []string{boot.InitramfsDataDir, boot.InitramfsUbuntuSaveDir})
+	c.Check(slots, DeepEquals, &client.SystemRecoveryKeySlotsResponse{
+		Volumes: map[string][]client.KeySlot{
+			"ubuntu-data": {
+				{ID: 0, Type: "encryption-key"},
+				{ID: 4, Type: "named-recovery-key", Name: "backup", Created: &created},
+			},
+			"ubuntu-save": {
+				{ID: 0, Type: "encryption-key"},
+			},
+		},
+	})
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestRecoveryKeySlots(c *C) {
+	classic := false
+	s.testRecoveryKeySlots(c, classic)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestRecoveryKeySlotsOnClassic(c *C) {
+	classic := true
+	s.testRecoveryKeySlots(c, classic)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestRecoveryKeySlotsError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	defer devicestate.MockSecbootListKeySlots(func(mountpoint string) ([]secboot.KeySlot, error) {
+		return nil, fmt.Errorf("boom")
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+
+	_, err := s.mgr.RecoveryKeySlots()
+	c.Assert(err, ErrorMatches, "cannot list key slots of ubuntu-data: boom")
+}
+
+func (s *deviceMgrRecoveryKeysSuite) testAddNamedRecoveryKey(c *C, classic bool) {
+	if classic {
+		s.setClassicWithModesModelInState(c)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := s.mgr.AddNamedRecoveryKey("backup")
+	c.Check(err, ErrorMatches, `system does not use disk encryption`)
+
+	called := 0
+	defer devicestate.MockSecbootAddNamedRecoveryKey(func(name string, rkeyDevs []secboot.RecoveryKeyDevice) (keys.RecoveryKey, error) {
+		called++
+		c.Check(name, Equals, "backup")
+		c.Check(rkeyDevs, DeepEquals, expectedRecoveryKeyDevices(classic))
+		return keys.RecoveryKey{'r', 'e', 'c', 'o', 'v', 'e', 'r', 'y', '1', '1', '1', '1', '1', '1', '1', '1'}, nil
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+
+	rkey, err := s.mgr.AddNamedRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	c.Check(rkey, Equals, "25970-28515-25974-31090-12593-12593-12593-12593")
+	c.Check(called, Equals, 1)
+	// the key is not stored on disk
+	c.Check(filepath.Join(dirs.SnapFDEDir, "recovery.key"), testutil.FileAbsent)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestAddNamedRecoveryKey(c *C) {
+	classic := false
+	s.testAddNamedRecoveryKey(c, classic)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestAddNamedRecoveryKeyOnClassic(c *C) {
+	classic := true
+	s.testAddNamedRecoveryKey(c, classic)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestRotateNamedRecoveryKey(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	called := 0
+	defer devicestate.MockSecbootRotateNamedRecoveryKey(func(name string, rkeyDevs []secboot.RecoveryKeyDevice) (keys.RecoveryKey, error) {
+		called++
+		c.Check(name, Equals, "backup")
+		c.Check(rkeyDevs, DeepEquals, expectedRecoveryKeyDevices(false))
+		return keys.RecoveryKey{'r', 'e', 'c', 'o', 'v', 'e', 'r', 'y', '1', '1', '1', '1', '1', '1', '1', '1'}, nil
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+
+	rkey, err := s.mgr.RotateNamedRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	c.Check(rkey, Equals, "25970-28515-25974-31090-12593-12593-12593-12593")
+	c.Check(called, Equals, 1)
+
+	defer devicestate.MockSecbootRotateNamedRecoveryKey(func(name string, rkeyDevs []secboot.RecoveryKeyDevice) (keys.RecoveryKey, error) {
+		return keys.RecoveryKey{}, fmt.Errorf("boom")
+	})()
+	_, err = s.mgr.RotateNamedRecoveryKey("backup")
+	c.Assert(err, ErrorMatches, "boom")
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestRemoveNamedRecoveryKey(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	called := 0
+	defer devicestate.MockSecbootRemoveNamedRecoveryKey(func(name string, rkeyDevs []secboot.RecoveryKeyDevice) error {
+		called++
+		c.Check(name, Equals, "backup")
+		c.Check(rkeyDevs, DeepEquals, expectedRecoveryKeyDevices(false))
+		return nil
+	})()
+	mockSnapFDEFile(c, "marker", nil)
+
+	err := s.mgr.RemoveNamedRecoveryKey("backup")
+	c.Assert(err, IsNil)
+	c.Check(called, Equals, 1)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestNamedRecoveryKeysInvalidName(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	mockSnapFDEFile(c, "marker", nil)
+
+	_, err := s.mgr.AddNamedRecoveryKey("Bad_Name")
+	c.Check(err, ErrorMatches, `invalid recovery key name "Bad_Name"`)
+	_, err = s.mgr.RotateNamedRecoveryKey("")
+	c.Check(err, ErrorMatches, `invalid recovery key name ""`)
+	err = s.mgr.RemoveNamedRecoveryKey("-")
+	c.Check(err, ErrorMatches, `invalid recovery key name "-"`)
+}
+
+func (s *deviceMgrRecoveryKeysSuite) TestNamedRecoveryKeysOtherModes(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, mode := range []string{"recover", "install"} {
+		devicestate.SetSystemMode(s.mgr, mode)
+
+		_, err := s.mgr.RecoveryKeySlots()
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot manage recovery keys from system mode %q`, mode))
+		_, err = s.mgr.AddNamedRecoveryKey("backup")
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot manage recovery keys from system mode %q`, mode))
+		_, err = s.mgr.RotateNamedRecoveryKey("backup")
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot manage recovery keys from system mode %q`, mode))
+		err = s.mgr.RemoveNamedRecoveryKey("backup")
+		c.Check(err, ErrorMatches, fmt.Sprintf(`cannot manage recovery keys from system mode %q`, mode))
+	}
+}
@@ -0,0 +1,324 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type deviceMgrSystemsCheckSuite struct {
+	deviceMgrSystemsBaseSuite
+
+	now time.Time
+	// pcSnap is a pristine copy of the gadget snap in the seed
+	pcSnap string
+}
+
+var _ = Suite(&deviceMgrSystemsCheckSuite{})
+
+func (s *deviceMgrSystemsCheckSuite) SetUpTest(c *C) {
+	s.deviceMgrSystemsBaseSuite.SetUpTest(c)
+
+	s.AddCleanup(release.MockOnClassic(false))
+	// boot was already marked as successful
+	devicestate.SetBootOkRan(s.mgr, true)
+
+	s.makeMinimalSeed20(c, "20191119")
+	s.pcSnap = filepath.Join(c.MkDir(), "pc_1.snap")
+	c.Assert(osutil.CopyFile(filepath.Join(dirs.SnapSeedDir, "snaps", "pc_1.snap"), s.pcSnap, 0), IsNil)
+
+	s.now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.AddCleanup(devicestate.MockTimeNow(func() time.Time { return s.now }))
+
+	// restores the pristine gadget snap into the target directory
+	s.o.TaskRunner().AddHandler("fake-repair-download", func(task *state.Task, _ *tomb.Tomb) error {
+		st := task.State()
+		st.Lock()
+		var dir string
+		err := task.Get("dir", &dir)
+		st.Unlock()
+		if err != nil {
+			return err
+		}
+		return osutil.CopyFile(s.pcSnap, filepath.Join(dir, "pc_1.snap"), 0)
+	}, nil)
+	s.o.TaskRunner().AddHandler("fake-broken-download", func(task *state.Task, _ *tomb.Tomb) error {
+		return nil
+	}, nil)
+}
+
+func (s *deviceMgrSystemsCheckSuite) setAutoRepair(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "recovery-systems.auto-repair", true), IsNil)
+	tr.Commit()
+}
+
+func (s *deviceMgrSystemsCheckSuite) checkRecoverySystems(c *C) *state.Change {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("check-recovery-systems", "...")
+	chg.AddTask(s.state.NewTask("check-recovery-systems", "..."))
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	return chg
+}
+
+func (s *deviceMgrSystemsCheckSuite) healthNotices() []*state.Notice {
+	return s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RecoverySystemHealthNotice}})
+}
+
+func (s *deviceMgrSystemsCheckSuite) checkChanges(c *C) []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == "check-recovery-systems" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestEnsureRecoverySystemsCheckScheduling(c *C) {
+	// the first run only records when to start counting from
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.checkChanges(c), HasLen, 0)
+	s.state.Unlock()
+
+	// not yet due
+	s.now = s.now.Add(6 * 24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.checkChanges(c), HasLen, 0)
+	s.state.Unlock()
+
+	// the default interval of a week has passed
+	s.now = s.now.Add(24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	chgs := s.checkChanges(c)
+	c.Assert(chgs, HasLen, 1)
+	tasks := chgs[0].Tasks()
+	c.Assert(tasks, HasLen, 1)
+	c.Check(tasks[0].Kind(), Equals, "check-recovery-systems")
+	var lastCheck time.Time
+	c.Assert(s.state.Get("last-recovery-systems-check", &lastCheck), IsNil)
+	c.Check(lastCheck.Equal(s.now), Equals, true)
+
+	// the check can be disabled
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "recovery-systems.check-interval", "no"), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	s.now = s.now.Add(30 * 24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.checkChanges(c), HasLen, 1)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestEnsureRecoverySystemsCheckConflict(c *C) {
+	s.state.Lock()
+	s.state.Set("last-recovery-systems-check", s.now)
+	chg := s.state.NewChange("create-recovery-system", "...")
+	chg.AddTask(s.state.NewTask("fake-broken-download", "..."))
+	chg.SetStatus(state.DoingStatus)
+	s.state.Unlock()
+
+	s.now = s.now.Add(8 * 24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.checkChanges(c), HasLen, 0)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestCheckRecoverySystemsHealthy(c *C) {
+	s.checkRecoverySystems(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	health, err := devicestate.RecoverySystemsHealth(s.state)
+	c.Assert(err, IsNil)
+	c.Check(health, DeepEquals, map[string]*devicestate.RecoverySystemHealth{
+		"20191119": {LastChecked: s.now},
+	})
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+	c.Check(s.healthNotices(), HasLen, 0)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestCheckRecoverySystemsBroken(c *C) {
+	c.Assert(os.Truncate(filepath.Join(dirs.SnapSeedDir, "snaps", "pc_1.snap"), 5), IsNil)
+
+	s.AddCleanup(devicestate.MockSnapstateDownload(func(ctx context.Context, st *state.State, name string, blobDirectory string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext) (*state.TaskSet, *snap.Info, error) {
+		c.Fatalf("unexpected download")
+		return nil, nil, nil
+	}))
+
+	chg := s.checkRecoverySystems(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(chg.Tasks(), HasLen, 1)
+	health, err := devicestate.RecoverySystemsHealth(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(health["20191119"], NotNil)
+	c.Check(health["20191119"].Error, Matches, `cannot validate ".*/pc_1.snap" for snap "pc" \(snap-id ".*"\), wrong size`)
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Matches, `recovery system "20191119" is broken: cannot validate .* wrong size`)
+
+	notices := s.healthNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "20191119")
+	c.Check(notices[0].LastData()["status"], Equals, "broken")
+	c.Check(notices[0].LastData()["error"], Equals, health["20191119"].Error)
+
+	// the notice is not repeated while the system stays broken the same way
+	s.state.Unlock()
+	s.checkRecoverySystems(c)
+	s.state.Lock()
+	n, err := s.healthNotices()[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":1`)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestCheckRecoverySystemsRepair(c *C) {
+	s.setAutoRepair(c)
+	seedSnapsDir := filepath.Join(dirs.SnapSeedDir, "snaps")
+	c.Assert(os.Truncate(filepath.Join(seedSnapsDir, "pc_1.snap"), 5), IsNil)
+
+	downloads := 0
+	s.AddCleanup(devicestate.MockSnapstateDownload(func(ctx context.Context, st *state.State, name string, blobDirectory string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext) (*state.TaskSet, *snap.Info, error) {
+		downloads++
+		c.Check(name, Equals, "pc")
+		c.Check(blobDirectory, Equals, filepath.Join(seedSnapsDir, ".repair"))
+		c.Check(blobDirectory, testutil.FilePresent)
+		c.Check(opts, DeepEquals, &snapstate.RevisionOptions{Revision: snap.R(1)})
+		// the broken snap is kept until the download is verified
+		c.Check(filepath.Join(seedSnapsDir, "pc_1.snap"), testutil.FilePresent)
+		t := st.NewTask("fake-repair-download", "...")
+		t.Set("dir", blobDirectory)
+		return state.NewTaskSet(t), &snap.Info{}, nil
+	}))
+
+	chg := s.checkRecoverySystems(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(downloads, Equals, 1)
+	tasks := chg.Tasks()
+	c.Assert(tasks, HasLen, 3)
+	c.Check(tasks[0].Kind(), Equals, "check-recovery-systems")
+	c.Check(tasks[1].Kind(), Equals, "fake-repair-download")
+	c.Check(tasks[2].Kind(), Equals, "check-recovery-systems")
+	c.Check(tasks[2].WaitTasks(), DeepEquals, []*state.Task{tasks[1]})
+
+	// the verified snap was moved into the seed
+	c.Check(filepath.Join(seedSnapsDir, "pc_1.snap"), testutil.FileEquals, testutil.FileContentRef(s.pcSnap))
+	c.Check(filepath.Join(seedSnapsDir, ".repair"), testutil.FileAbsent)
+
+	// the system is healthy again
+	health, err := devicestate.RecoverySystemsHealth(s.state)
+	c.Assert(err, IsNil)
+	c.Check(health, DeepEquals, map[string]*devicestate.RecoverySystemHealth{
+		"20191119": {LastChecked: s.now},
+	})
+	c.Check(s.state.AllWarnings(), HasLen, 1)
+	notices := s.healthNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{"status": "healthy"})
+	n, err := notices[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":2`)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestCheckRecoverySystemsRepairDigestMismatch(c *C) {
+	s.setAutoRepair(c)
+	seedSnapsDir := filepath.Join(dirs.SnapSeedDir, "snaps")
+	c.Assert(os.Truncate(filepath.Join(seedSnapsDir, "pc_1.snap"), 5), IsNil)
+
+	s.o.TaskRunner().AddHandler("fake-bad-download", func(task *state.Task, _ *tomb.Tomb) error {
+		return os.WriteFile(filepath.Join(seedSnapsDir, ".repair", "pc_1.snap"), []byte("bad"), 0644)
+	}, nil)
+	s.AddCleanup(devicestate.MockSnapstateDownload(func(ctx context.Context, st *state.State, name string, blobDirectory string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext) (*state.TaskSet, *snap.Info, error) {
+		return state.NewTaskSet(st.NewTask("fake-bad-download", "...")), &snap.Info{}, nil
+	}))
+
+	s.checkRecoverySystems(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	// the broken snap was not replaced by the bad download, which is gone
+	st, err := os.Stat(filepath.Join(seedSnapsDir, "pc_1.snap"))
+	c.Assert(err, IsNil)
+	c.Check(st.Size(), Equals, int64(5))
+	c.Check(filepath.Join(seedSnapsDir, ".repair"), testutil.FileAbsent)
+
+	health, err := devicestate.RecoverySystemsHealth(s.state)
+	c.Assert(err, IsNil)
+	c.Check(health["20191119"].Error, Matches, `cannot validate .* wrong size`)
+}
+
+func (s *deviceMgrSystemsCheckSuite) TestCheckRecoverySystemsRepairGivesUp(c *C) {
+	s.setAutoRepair(c)
+	c.Assert(os.Remove(filepath.Join(dirs.SnapSeedDir, "snaps", "pc_1.snap")), IsNil)
+
+	downloads := 0
+	s.AddCleanup(devicestate.MockSnapstateDownload(func(ctx context.Context, st *state.State, name string, blobDirectory string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext) (*state.TaskSet, *snap.Info, error) {
+		downloads++
+		return state.NewTaskSet(st.NewTask("fake-broken-download", "...")), &snap.Info{}, nil
+	}))
+
+	chg := s.checkRecoverySystems(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(downloads, Equals, 3)
+	c.Check(chg.Tasks(), HasLen, 7)
+	health, err := devicestate.RecoverySystemsHealth(s.state)
+	c.Assert(err, IsNil)
+	c.Check(health["20191119"].Error, Matches, `cannot stat snap: .*`)
+}
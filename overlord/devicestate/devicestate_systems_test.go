@@ -166,6 +166,38 @@ func (s *deviceMgrSystemsBaseSuite) SetUpTest(c *C) {
 	s.o.TaskRunner().AddHandler("fake-validate", nopHandler, nil)
 }
 
+// makeMinimalSeed20 creates a recovery system with the given label holding
+// only the essential snaps.
+func (s *deviceMgrSystemsBaseSuite) makeMinimalSeed20(c *C, label string) *asserts.Model {
+	s.AddCleanup(seed.MockTrusted(s.storeSigning.Trusted))
+	seed20 := &seedtest.TestingSeed20{
+		SeedSnaps: *s.ss,
+		SeedDir:   dirs.SnapSeedDir,
+	}
+	seed20.MakeAssertedSnap(c, "name: snapd\nversion: 1\ntype: snapd", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: pc\nversion: 1\ntype: gadget\nbase: core20", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: pc-kernel\nversion: 1\ntype: kernel", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	seed20.MakeAssertedSnap(c, "name: core20\nversion: 1\ntype: base", nil, snap.R(1), "canonical", seed20.StoreSigning.Database)
+	return seed20.MakeSeed(c, label, "my-brand", "my-model", map[string]interface{}{
+		"display-name": "my fancy model",
+		"architecture": "amd64",
+		"base":         "core20",
+		"snaps": []interface{}{
+			map[string]interface{}{
+				"name":            "pc-kernel",
+				"id":              seed20.AssertedSnapID("pc-kernel"),
+				"type":            "kernel",
+				"default-channel": "20",
+			},
+			map[string]interface{}{
+				"name":            "pc",
+				"id":              seed20.AssertedSnapID("pc"),
+				"type":            "gadget",
+				"default-channel": "20",
+			}},
+	}, nil)
+}
+
 func (s *deviceMgrSystemsSuite) SetUpTest(c *C) {
 	s.deviceMgrSystemsBaseSuite.SetUpTest(c)
 
@@ -22,12 +22,14 @@ package devicestate
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/device"
 	"github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/kernel/fde"
@@ -343,6 +345,18 @@ func MockGadgetUpdate(mock func(model gadget.Model, current, update gadget.Gadge
 	}
 }
 
+func MockGadgetRollbackUpdate(mock func(model gadget.Model, current, update gadget.GadgetData, path string, policy gadget.UpdatePolicyFunc, observer gadget.ContentUpdateObserver) error) (restore func()) {
+	restore = testutil.Backup(&gadgetRollbackUpdate)
+	gadgetRollbackUpdate = mock
+	return restore
+}
+
+// TrustedAssetsObserver returns the trusted boot assets observer wrapped by
+// the observer passed to gadget.Update.
+func TrustedAssetsObserver(observer gadget.ContentUpdateObserver) *boot.TrustedAssetsUpdateObserver {
+	return observer.(*gadgetUpdateObserver).assets
+}
+
 func MockGadgetIsCompatible(mock func(current, update *gadget.Info) error) (restore func()) {
 	old := gadgetIsCompatible
 	gadgetIsCompatible = mock
@@ -429,6 +443,14 @@ func MockInstallEncryptPartitions(f func(onVolumes map[string]*gadget.Volume, en
 	}
 }
 
+func MockInstallAddVolumesAuth(f func(setupData *install.EncryptionSetupData, volumesAuth *device.VolumesAuthOptions) error) (restore func()) {
+	old := installAddVolumesAuth
+	installAddVolumesAuth = f
+	return func() {
+		installAddVolumesAuth = old
+	}
+}
+
 func MockInstallSaveStorageTraits(f func(model gadget.Model, allLaidOutVols map[string]*gadget.Volume, encryptSetupData *install.EncryptionSetupData) error) (restore func()) {
 	old := installSaveStorageTraits
 	installSaveStorageTraits = f
@@ -516,6 +538,12 @@ func MockSecbootRemoveRecoveryKeys(f func(rkeyDevToKey map[secboot.RecoveryKeyDe
 	return restore
 }
 
+func MockSecbootChangePassphrase(f func(oldPassphrase, newPassphrase string, mountpoints []string) error) (restore func()) {
+	restore = testutil.Backup(&secbootChangePassphrase)
+	secbootChangePassphrase = f
+	return restore
+}
+
 func MockMarkFactoryResetComplete(f func(encrypted bool) error) (restore func()) {
 	restore = testutil.Backup(&bootMarkFactoryResetComplete)
 	bootMarkFactoryResetComplete = f
@@ -592,6 +620,15 @@ func CheckEncryptionSetupDataFromCache(st *state.State, label string) error {
 	return nil
 }
 
+func MockVolumesAuthOptionsInCache(st *state.State, label string, volumesAuth *device.VolumesAuthOptions) {
+	st.Cache(volumesAuthOptionsKey{label}, volumesAuth)
+}
+
+func VolumesAuthOptionsFromCache(st *state.State, label string) *device.VolumesAuthOptions {
+	volumesAuth, _ := st.Cached(volumesAuthOptionsKey{label}).(*device.VolumesAuthOptions)
+	return volumesAuth
+}
+
 func CleanUpEncryptionSetupDataInCache(st *state.State, label string) {
 	st.Lock()
 	defer st.Unlock()
@@ -600,3 +637,57 @@ func CleanUpEncryptionSetupDataInCache(st *state.State, label string) {
 }
 
 type UniqueSnapsInRecoverySystem = uniqueSnapsInRecoverySystem
+
+func MockSecbootListKeySlots(f func(mountpoint string) ([]secboot.KeySlot, error)) (restore func()) {
+	restore = testutil.Backup(&secbootListKeySlots)
+	secbootListKeySlots = f
+	return restore
+}
+
+func MockSecbootAddNamedRecoveryKey(f func(name string, rkeyDevs []secboot.RecoveryKeyDevice) (keys.RecoveryKey, error)) (restore func()) {
+	restore = testutil.Backup(&secbootAddNamedRecoveryKey)
+	secbootAddNamedRecoveryKey = f
+	return restore
+}
+
+func MockSecbootRotateNamedRecoveryKey(f func(name string, rkeyDevs []secboot.RecoveryKeyDevice) (keys.RecoveryKey, error)) (restore func()) {
+	restore = testutil.Backup(&secbootRotateNamedRecoveryKey)
+	secbootRotateNamedRecoveryKey = f
+	return restore
+}
+
+func MockSecbootRemoveNamedRecoveryKey(f func(name string, rkeyDevs []secboot.RecoveryKeyDevice) error) (restore func()) {
+	restore = testutil.Backup(&secbootRemoveNamedRecoveryKey)
+	secbootRemoveNamedRecoveryKey = f
+	return restore
+}
+
+func MockSnapshotstateSave(f func(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error)) (restore func()) {
+	restore = testutil.Backup(&snapshotstateSave)
+	snapshotstateSave = f
+	return restore
+}
+
+func MockSnapshotstateImport(f func(ctx context.Context, st *state.State, r io.Reader) (setID uint64, snapNames []string, err error)) (restore func()) {
+	restore = testutil.Backup(&snapshotstateImport)
+	snapshotstateImport = f
+	return restore
+}
+
+func MockSnapshotstateRestore(f func(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error)) (restore func()) {
+	restore = testutil.Backup(&snapshotstateRestore)
+	snapshotstateRestore = f
+	return restore
+}
+
+func MockExportSnapshotToFile(f func(ctx context.Context, st *state.State, setID uint64, target string) error) (restore func()) {
+	restore = testutil.Backup(&exportSnapshotToFile)
+	exportSnapshotToFile = f
+	return restore
+}
+
+func MockOsutilHasSudoersDropIn(f func(name string) bool) (restore func()) {
+	restore = testutil.Backup(&osutilHasSudoersDropIn)
+	osutilHasSudoersDropIn = f
+	return restore
+}
@@ -0,0 +1,385 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	snapshotstateSave    = snapshotstate.Save
+	snapshotstateImport  = snapshotstate.Import
+	snapshotstateRestore = snapshotstate.Restore
+
+	osutilHasSudoersDropIn = osutil.HasSudoersDropIn
+)
+
+// FactoryResetOptions holds the options for a factory reset requested from
+// run mode.
+type FactoryResetOptions struct {
+	// PreserveSnaps lists the snaps whose data is carried across the
+	// factory reset.
+	PreserveSnaps []string
+}
+
+// preservedData describes the data that was saved to ubuntu-save before a
+// factory reset, to be restored once the device is seeded again.
+type preservedData struct {
+	Snaps []string        `json:"snaps"`
+	Users []preservedUser `json:"users,omitempty"`
+}
+
+// preservedUser describes a system user created by snapd that is recreated
+// after a factory reset.
+type preservedUser struct {
+	Username   string    `json:"username"`
+	Email      string    `json:"email,omitempty"`
+	Expiration time.Time `json:"expiration,omitempty"`
+	Gecos      string    `json:"gecos,omitempty"`
+	// Password is the crypt(3) password hash of the user, if any.
+	Password string   `json:"password,omitempty"`
+	SSHKeys  []string `json:"ssh-keys,omitempty"`
+	Sudoer   bool     `json:"sudoer,omitempty"`
+}
+
+func factoryResetPreservedDir() string {
+	return filepath.Join(dirs.SnapSaveDir, "factory-reset")
+}
+
+func factoryResetPreservedDataFile() string {
+	return filepath.Join(factoryResetPreservedDir(), "preserved.json")
+}
+
+func factoryResetPreservedSnapshotFile() string {
+	return filepath.Join(factoryResetPreservedDir(), "snapshot.tar")
+}
+
+// FactoryReset requests a factory reset of the device into the given
+// recovery system, carrying the data of the snaps listed in the options and
+// the system users across the reset. The data is saved in an automatic
+// snapshot written to ubuntu-save, which is restored once the device is
+// seeded again after the reset. The returned change saves the data and
+// requests the restart into factory reset mode.
+// Note that the state must be locked by the caller.
+func FactoryReset(st *state.State, systemLabel string, opts FactoryResetOptions) (*state.Change, error) {
+	if len(opts.PreserveSnaps) == 0 {
+		return nil, fmt.Errorf("internal error: no snaps to preserve across factory reset")
+	}
+	if systemLabel == "" {
+		return nil, fmt.Errorf("internal error: system label is unset")
+	}
+	if mode := deviceMgr(st).SystemMode(SysAny); mode != "run" {
+		return nil, fmt.Errorf("cannot preserve snap data across factory reset from %q mode", mode)
+	}
+	if _, err := os.Stat(filepath.Join(dirs.SnapSeedDir, "systems", systemLabel)); err != nil {
+		return nil, fmt.Errorf("cannot find recovery system %q: %v", systemLabel, err)
+	}
+	if err := snapstate.CheckChangeConflictRunExclusively(st, "factory-reset"); err != nil {
+		return nil, err
+	}
+
+	model, err := findModel(st)
+	if err != nil {
+		return nil, err
+	}
+	modelSnaps := make(map[string]bool)
+	for _, sn := range model.AllSnaps() {
+		modelSnaps[sn.Name] = true
+	}
+	for _, name := range opts.PreserveSnaps {
+		if !modelSnaps[name] {
+			return nil, fmt.Errorf("cannot preserve data of snap %q: snap is not listed in the model", name)
+		}
+	}
+
+	setID, snaps, ts, err := snapshotstateSave(st, strutil.Deduplicate(opts.PreserveSnaps), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prepare := st.NewTask("prepare-factory-reset",
+		fmt.Sprintf(i18n.G("Prepare factory reset preserving data of snaps %s"), strutil.Quoted(snaps)))
+	prepare.Set("system-label", systemLabel)
+	prepare.Set("snapshot-set-id", setID)
+	prepare.Set("preserve-snaps", snaps)
+	prepare.WaitAll(ts)
+
+	chg := st.NewChange("factory-reset", fmt.Sprintf(i18n.G("Factory reset into system %q"), systemLabel))
+	chg.AddAll(ts)
+	chg.AddTask(prepare)
+	return chg, nil
+}
+
+func (m *DeviceManager) doPrepareFactoryReset(t *state.Task, tomb *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var systemLabel string
+	if err := t.Get("system-label", &systemLabel); err != nil {
+		return err
+	}
+	var setID uint64
+	if err := t.Get("snapshot-set-id", &setID); err != nil {
+		return err
+	}
+	var snaps []string
+	if err := t.Get("preserve-snaps", &snaps); err != nil {
+		return err
+	}
+
+	err := m.withSaveDir(func() error {
+		if err := os.MkdirAll(factoryResetPreservedDir(), 0700); err != nil {
+			return err
+		}
+		if err := exportSnapshotToFile(tomb.Context(nil), st, setID, factoryResetPreservedSnapshotFile()); err != nil {
+			return err
+		}
+		users, err := systemUsersToPreserve(st)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(&preservedData{
+			Snaps: snaps,
+			Users: users,
+		})
+		if err != nil {
+			return err
+		}
+		return osutil.AtomicWriteFile(factoryResetPreservedDataFile(), data, 0600, 0)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot save data to preserve across factory reset: %v", err)
+	}
+
+	// the restart into factory reset mode is requested by
+	// RequestSystemAction which takes the state lock
+	st.Unlock()
+	err = m.RequestSystemAction(systemLabel, SystemAction{Mode: "factory-reset"})
+	st.Lock()
+	if err != nil {
+		os.RemoveAll(factoryResetPreservedDir())
+		return err
+	}
+	return nil
+}
+
+// exportSnapshotToFile writes an export of the given snapshot set to a file.
+// Note that the state must be locked by the caller.
+var exportSnapshotToFile = func(ctx context.Context, st *state.State, setID uint64, target string) error {
+	export, err := snapshotstate.Export(ctx, st, setID)
+	if err != nil {
+		return err
+	}
+	defer snapshotstate.UnsetSnapshotOpInProgress(st, setID)
+	defer export.Close()
+
+	// exporting the snapshot can be slow so drop the lock
+	st.Unlock()
+	defer st.Lock()
+
+	if err := export.Init(); err != nil {
+		return err
+	}
+	f, err := osutil.NewAtomicFile(target, 0600, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return err
+	}
+	// becomes a noop once the file is committed
+	defer f.Cancel()
+	if err := export.StreamTo(f); err != nil {
+		return fmt.Errorf("cannot export snapshot set #%d: %v", setID, err)
+	}
+	return f.Commit()
+}
+
+// systemUsersToPreserve returns the details of the system users created by
+// snapd, such that they can be recreated after a factory reset.
+func systemUsersToPreserve(st *state.State) ([]preservedUser, error) {
+	authUsers, err := auth.Users(st)
+	if err != nil {
+		return nil, err
+	}
+	passwords, err := extraUsersPasswords()
+	if err != nil {
+		return nil, err
+	}
+
+	var users []preservedUser
+	for _, authUser := range authUsers {
+		if authUser.Username == "" {
+			// not a system user
+			continue
+		}
+		usr, err := userLookup(authUser.Username)
+		if err != nil {
+			logger.Noticef("cannot preserve user %q across factory reset: %v", authUser.Username, err)
+			continue
+		}
+		users = append(users, preservedUser{
+			Username:   authUser.Username,
+			Email:      authUser.Email,
+			Expiration: authUser.Expiration,
+			Gecos:      usr.Name,
+			Password:   passwords[authUser.Username],
+			SSHKeys:    authorizedSSHKeys(usr.HomeDir),
+			Sudoer:     osutilHasSudoersDropIn(authUser.Username),
+		})
+	}
+	return users, nil
+}
+
+// extraUsersPasswords returns the password hashes of the users in the
+// extrausers database, users without a usable password are omitted.
+func extraUsersPasswords() (map[string]string, error) {
+	passwords := make(map[string]string)
+	if release.OnClassic {
+		// users are not kept in extrausers on classic
+		return passwords, nil
+	}
+	f, err := os.Open(filepath.Join(dirs.GlobalRootDir, "/var/lib/extrausers/shadow"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return passwords, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[1] == "" || strings.HasPrefix(fields[1], "!") || strings.HasPrefix(fields[1], "*") {
+			// locked or disabled password
+			continue
+		}
+		passwords[fields[0]] = fields[1]
+	}
+	return passwords, scanner.Err()
+}
+
+func authorizedSSHKeys(homeDir string) []string {
+	content, err := os.ReadFile(filepath.Join(homeDir, ".ssh", "authorized_keys"))
+	if err != nil {
+		return nil
+	}
+	var keys []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			keys = append(keys, line)
+		}
+	}
+	return keys
+}
+
+// maybeScheduleRestorePreservedData creates a change restoring the data that
+// was preserved across a factory reset, if there is any.
+func (m *DeviceManager) maybeScheduleRestorePreservedData() {
+	if !osutil.FileExists(factoryResetPreservedDataFile()) {
+		return
+	}
+	restore := m.state.NewTask("restore-preserved-data", i18n.G("Restore data preserved across factory reset"))
+	chg := m.state.NewChange("restore-preserved-data", i18n.G("Restore data preserved across factory reset"))
+	chg.AddTask(restore)
+	m.state.EnsureBefore(0)
+}
+
+func (m *DeviceManager) doRestorePreservedData(t *state.Task, tomb *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	content, err := os.ReadFile(factoryResetPreservedDataFile())
+	if err != nil {
+		return fmt.Errorf("cannot read data preserved across factory reset: %v", err)
+	}
+	var data preservedData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return fmt.Errorf("cannot decode data preserved across factory reset: %v", err)
+	}
+
+	// users are recreated first so that their snap data can be restored
+	for _, usr := range data.Users {
+		if _, err := userLookup(usr.Username); err == nil {
+			// user exists already, e.g. created from a
+			// system-user assertion
+			continue
+		}
+		opts := &osutil.AddUserOptions{
+			Sudoer:   usr.Sudoer,
+			Gecos:    usr.Gecos,
+			SSHKeys:  usr.SSHKeys,
+			Password: usr.Password,
+		}
+		if _, err := addUser(st, usr.Username, usr.Email, usr.Expiration, opts); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(factoryResetPreservedSnapshotFile())
+	if err != nil {
+		return fmt.Errorf("cannot open snapshot preserved across factory reset: %v", err)
+	}
+	defer f.Close()
+	// snapshotstate.Import takes the state lock
+	st.Unlock()
+	setID, _, err := snapshotstateImport(tomb.Context(nil), st, f)
+	st.Lock()
+	if err != nil {
+		return fmt.Errorf("cannot import snapshot preserved across factory reset: %v", err)
+	}
+
+	_, ts, err := snapshotstateRestore(st, setID, data.Snaps, nil)
+	if err != nil {
+		return err
+	}
+	ts.WaitFor(t)
+	t.Change().AddAll(ts)
+
+	// the snapshot set is now kept with the other snapshots
+	if err := os.RemoveAll(factoryResetPreservedDir()); err != nil {
+		logger.Noticef("cannot remove data preserved across factory reset: %v", err)
+	}
+	return nil
+}
@@ -82,7 +82,8 @@ func pendingGadgetData(snapsup *snapstate.SnapSetup, pendingDeviceCtx snapstate.
 }
 
 var (
-	gadgetUpdate = gadget.Update
+	gadgetUpdate         = gadget.Update
+	gadgetRollbackUpdate = gadget.RollbackUpdate
 )
 
 func setGadgetRestartRequired(t *state.Task) {
@@ -90,22 +91,89 @@ func setGadgetRestartRequired(t *state.Task) {
 	chg.Set("gadget-restart-required", true)
 }
 
-func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error {
+// gadgetUpdateObserver observes the gadget update on behalf of the task. The
+// content updates are passed to the trusted boot assets observer if there is
+// one, the partition layout changes are reported in the task before they are
+// carried out.
+type gadgetUpdateObserver struct {
+	task   *state.Task
+	assets *boot.TrustedAssetsUpdateObserver
+}
+
+func (o *gadgetUpdateObserver) Observe(op gadget.ContentOperation, partRole, targetRootDir, relativeTargetPath string, data *gadget.ContentChange) (gadget.ContentChangeAction, error) {
+	if o.assets == nil {
+		return gadget.ChangeApply, nil
+	}
+	return o.assets.Observe(op, partRole, targetRootDir, relativeTargetPath, data)
+}
+
+func (o *gadgetUpdateObserver) BeforeWrite() error {
+	if o.assets == nil {
+		return nil
+	}
+	return o.assets.BeforeWrite()
+}
+
+func (o *gadgetUpdateObserver) Canceled() error {
+	if o.assets == nil {
+		return nil
+	}
+	return o.assets.Canceled()
+}
+
+// newGadgetUpdateObserver returns the observer of the gadget update of the
+// task, done must be called once the update is complete.
+func newGadgetUpdateObserver(t *state.Task, args *gadgetUpdateArgs) (obs *gadgetUpdateObserver, done func(), err error) {
+	obs = &gadgetUpdateObserver{task: t}
+	observeTrustedBootAssets, err := boot.TrustedAssetsUpdateObserverForModel(args.model, args.update.RootDir)
+	if err != nil && err != boot.ErrObserverNotApplicable {
+		return nil, nil, fmt.Errorf("cannot setup asset update observer: %v", err)
+	}
+	if err != nil {
+		return obs, func() {}, nil
+	}
+	obs.assets = observeTrustedBootAssets
+	return obs, observeTrustedBootAssets.Done, nil
+}
+
+// BeforeLayoutChange logs the planned partition layout changes in the task
+// and records them, so that undoing the task reverts them.
+func (o *gadgetUpdateObserver) BeforeLayoutChange(plans []*gadget.LayoutPlan) error {
+	for _, plan := range plans {
+		for i := range plan.Changes {
+			o.task.Logf("Gadget update will %s on volume %s (%s)", plan.Changes[i].String(), plan.Volume, plan.Device)
+		}
+	}
+	o.task.Set("gadget-layout-changes", plans)
+	return nil
+}
+
+// gadgetUpdateArgs are the arguments of the gadget update carried out by a
+// task, they are worked out the same way to undo the update.
+type gadgetUpdateArgs struct {
+	model        *asserts.Model
+	current      *gadget.GadgetData
+	update       *gadget.GadgetData
+	rollbackName string
+	updatePolicy gadget.UpdatePolicyFunc
+}
+
+// gadgetUpdateArgsForTask returns the arguments of the gadget update of the
+// task, nil is returned when there is no current gadget to update.
+func gadgetUpdateArgsForTask(t *state.Task) (*gadgetUpdateArgs, error) {
 	st := t.State()
-	st.Lock()
-	defer st.Unlock()
 
 	snapsup, err := snapstate.TaskSnapSetup(t)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	remodelCtx, err := DeviceCtx(st, t, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if remodelCtx.IsClassicBoot() {
-		return fmt.Errorf("cannot run update gadget assets task on a classic system")
+		return nil, fmt.Errorf("cannot run update gadget assets task on a classic system")
 	}
 	isRemodel := remodelCtx.ForRemodeling()
 	groundDeviceCtx := remodelCtx.GroundContext()
@@ -120,18 +188,18 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 	case snap.TypeGadget:
 		expectedGadgetSnap := model.Gadget()
 		if snapsup.InstanceName() != expectedGadgetSnap {
-			return fmt.Errorf("cannot apply gadget assets update from non-model gadget snap %q, expected %q snap",
+			return nil, fmt.Errorf("cannot apply gadget assets update from non-model gadget snap %q, expected %q snap",
 				snapsup.InstanceName(), expectedGadgetSnap)
 		}
 
 		updateData, err = pendingGadgetData(snapsup, remodelCtx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case snap.TypeKernel:
 		expectedKernelSnap := model.Kernel()
 		if snapsup.InstanceName() != expectedKernelSnap {
-			return fmt.Errorf("cannot apply kernel assets update from non-model kernel snap %q, expected %q snap",
+			return nil, fmt.Errorf("cannot apply kernel assets update from non-model kernel snap %q, expected %q snap",
 				snapsup.InstanceName(), expectedKernelSnap)
 		}
 
@@ -139,24 +207,21 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		// argumented from a different kernel
 		updateData, err = CurrentGadgetData(t.State(), groundDeviceCtx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	default:
-		return fmt.Errorf("internal errror: doUpdateGadgetAssets called with snap type %v", snapsup.Type)
+		return nil, fmt.Errorf("internal errror: doUpdateGadgetAssets called with snap type %v", snapsup.Type)
 	}
 
 	currentData, err := CurrentGadgetData(t.State(), groundDeviceCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if currentData == nil {
 		// no updates during first boot & seeding
-		return nil
+		return nil, nil
 	}
 
-	// Inject fault during the refresh of the gadget assets
-	osutil.MaybeInjectFault("refresh-gadget-assets")
-
 	// add kernel directories
 	currentKernelInfo, err := snapstate.CurrentInfo(st, groundDeviceCtx.Model().Kernel())
 	// XXX: switch to the normal `if err != nil { return err }` pattern
@@ -170,16 +235,11 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 	if snapsup.Type == snap.TypeKernel {
 		updateKernelInfo, err := snap.ReadInfo(snapsup.InstanceName(), snapsup.SideInfo)
 		if err != nil {
-			return fmt.Errorf("cannot read candidate kernel snap details: %v", err)
+			return nil, fmt.Errorf("cannot read candidate kernel snap details: %v", err)
 		}
 		updateData.KernelRootDir = updateKernelInfo.MountDir()
 	}
 
-	snapRollbackDir, err := makeRollbackDir(fmt.Sprintf("%v_%v", snapsup.InstanceName(), snapsup.SideInfo.Revision))
-	if err != nil {
-		return fmt.Errorf("cannot prepare update rollback directory: %v", err)
-	}
-
 	var updatePolicy gadget.UpdatePolicyFunc = nil
 
 	// Even with a remodel a kernel refresh only updates the kernel assets
@@ -191,27 +251,54 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		updatePolicy = gadget.RemodelUpdatePolicy
 	}
 
+	return &gadgetUpdateArgs{
+		model:        model,
+		current:      currentData,
+		update:       updateData,
+		rollbackName: fmt.Sprintf("%v_%v", snapsup.InstanceName(), snapsup.SideInfo.Revision),
+		updatePolicy: updatePolicy,
+	}, nil
+}
+
+func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	args, err := gadgetUpdateArgsForTask(t)
+	if err != nil {
+		return err
+	}
+	if args == nil {
+		// no updates during first boot & seeding
+		return nil
+	}
+
+	// Inject fault during the refresh of the gadget assets
+	osutil.MaybeInjectFault("refresh-gadget-assets")
+
+	snapRollbackDir, err := makeRollbackDir(args.rollbackName)
+	if err != nil {
+		return fmt.Errorf("cannot prepare update rollback directory: %v", err)
+	}
+
 	err = func() error {
-		var updateObserver gadget.ContentUpdateObserver
-		observeTrustedBootAssets, err := boot.TrustedAssetsUpdateObserverForModel(model, updateData.RootDir)
-		if err != nil && err != boot.ErrObserverNotApplicable {
-			return fmt.Errorf("cannot setup asset update observer: %v", err)
-		}
-		if err == nil {
-			updateObserver = observeTrustedBootAssets
-			defer observeTrustedBootAssets.Done()
+		updateObserver, done, err := newGadgetUpdateObserver(t, args)
+		if err != nil {
+			return err
 		}
+		defer done()
 		// do not release the state lock, the update observer may
 		// attempt to modify modeenv inside, which implicitly is
 		// guarded by the state lock; on top of that we do not expect
 		// the update to be moving large amounts of data
-		if err := gadgetUpdate(model, *currentData, *updateData, snapRollbackDir, updatePolicy, updateObserver); err != nil {
+		if err := gadgetUpdate(args.model, *args.current, *args.update, snapRollbackDir, args.updatePolicy, updateObserver); err != nil {
 			return err
 		}
-		if updateObserver == nil {
+		if updateObserver.assets == nil {
 			return nil
 		}
-		return observeTrustedBootAssets.UpdateBootEntry()
+		return updateObserver.assets.UpdateBootEntry()
 	}()
 	if err != nil {
 		if err == gadget.ErrNoUpdate {
@@ -222,8 +309,11 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 		return err
 	}
 
-	if err := os.RemoveAll(snapRollbackDir); err != nil && !os.IsNotExist(err) {
-		logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
+	// the backups are needed to undo the partition layout changes
+	if !t.Has("gadget-layout-changes") {
+		if err := os.RemoveAll(snapRollbackDir); err != nil && !os.IsNotExist(err) {
+			logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
+		}
 	}
 
 	// TODO: consider having the option to do this early via recovery in
@@ -232,6 +322,44 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 	return snapstate.FinishTaskWithRestart(t, state.DoneStatus, restart.RestartSystem, nil)
 }
 
+func (m *DeviceManager) undoUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	// the assets written by an update which left the partition layout
+	// untouched are kept in place
+	if !t.Has("gadget-layout-changes") {
+		return nil
+	}
+
+	args, err := gadgetUpdateArgsForTask(t)
+	if err != nil {
+		return err
+	}
+	if args == nil {
+		return nil
+	}
+	snapRollbackDir := filepath.Join(dirs.SnapRollbackDir, args.rollbackName)
+
+	// restore the assets from their backups, the partition tables are then
+	// restored by the layout rollback
+	updateObserver, done, err := newGadgetUpdateObserver(t, args)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := gadgetRollbackUpdate(args.model, *args.current, *args.update, snapRollbackDir, args.updatePolicy, updateObserver); err != nil {
+		return fmt.Errorf("cannot undo gadget assets update: %v", err)
+	}
+	t.Set("gadget-layout-changes", nil)
+
+	if err := os.RemoveAll(snapRollbackDir); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
+	}
+	return nil
+}
+
 // fromSystemOption tells us if t was created when setting a system
 // option for the kernel command line.
 func fromSystemOption(t *state.Task) bool {
@@ -70,10 +70,12 @@ var (
 	installMountVolumes                  = install.MountVolumes
 	installWriteContent                  = install.WriteContent
 	installEncryptPartitions             = install.EncryptPartitions
+	installAddVolumesAuth                = install.AddVolumesAuth
 	installSaveStorageTraits             = install.SaveStorageTraits
 	installMatchDisksToGadgetVolumes     = install.MatchDisksToGadgetVolumes
 	secbootStageEncryptionKeyChange      = secboot.StageEncryptionKeyChange
 	secbootTransitionEncryptionKeyChange = secboot.TransitionEncryptionKeyChange
+	secbootCheckVolumesAuthSupported     = secboot.CheckVolumesAuthSupported
 
 	installLogicPrepareRunSystemData = installLogic.PrepareRunSystemData
 )
@@ -304,7 +306,7 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 	}
 
 	if useEncryption {
-		if err := installLogic.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, trustedInstallObserver); err != nil {
+		if err := installLogic.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, nil, trustedInstallObserver); err != nil {
 			return err
 		}
 	}
@@ -630,7 +632,7 @@ func (m *DeviceManager) doFactoryResetRunSystem(t *state.Task, _ *tomb.Tomb) err
 		// keep track of the new ubuntu-save encryption key
 		installedSystem.KeyForRole[gadget.SystemSave] = saveEncryptionKey
 
-		if err := installLogic.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, trustedInstallObserver); err != nil {
+		if err := installLogic.PrepareEncryptedSystemData(model, installedSystem.KeyForRole, nil, trustedInstallObserver); err != nil {
 			return err
 		}
 	}
@@ -874,6 +876,10 @@ type encryptionSetupDataKey struct {
 	systemLabel string
 }
 
+type volumesAuthOptionsKey struct {
+	systemLabel string
+}
+
 func mountSeedContainer(filePath, subdir string) (mountpoint string, unmount func() error, err error) {
 	mountpoint = filepath.Join(dirs.SnapRunDir, "snap-content", subdir)
 	if err := os.MkdirAll(mountpoint, 0755); err != nil {
@@ -1168,7 +1174,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 
 	if useEncryption {
 		if trustedInstallObserver != nil {
-			if err := installLogic.PrepareEncryptedSystemData(systemAndSnaps.Model, install.KeysForRole(encryptSetupData), trustedInstallObserver); err != nil {
+			if err := installLogic.PrepareEncryptedSystemData(systemAndSnaps.Model, install.KeysForRole(encryptSetupData), encryptSetupData.VolumesAuth(), trustedInstallObserver); err != nil {
 				return err
 			}
 		}
@@ -1288,6 +1294,12 @@ func (m *DeviceManager) doInstallSetupStorageEncryption(t *state.Task, _ *tomb.T
 	if err != nil {
 		return err
 	}
+	if volumesAuth, ok := st.Cached(volumesAuthOptionsKey{systemLabel}).(*device.VolumesAuthOptions); ok && volumesAuth != nil {
+		if err := installAddVolumesAuth(encryptionSetupData, volumesAuth); err != nil {
+			return err
+		}
+		st.Cache(volumesAuthOptionsKey{systemLabel}, nil)
+	}
 
 	// Store created devices in the change so they can be accessed from the installer
 	apiData := map[string]interface{}{
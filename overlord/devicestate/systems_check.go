@@ -0,0 +1,343 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/seed"
+	"github.com/snapcore/snapd/timings"
+)
+
+const (
+	defaultRecoverySystemsCheckInterval = 7 * 24 * time.Hour
+
+	// maxRecoverySystemsRepairRounds limits how many times broken snaps
+	// are downloaded again before giving up until the next check
+	maxRecoverySystemsRepairRounds = 3
+)
+
+// RecoverySystemHealth holds the outcome of the last integrity check of a
+// recovery system.
+type RecoverySystemHealth struct {
+	LastChecked time.Time `json:"last-checked"`
+	// Error describes why the recovery system is broken, it is empty if
+	// the system passed the check.
+	Error string `json:"error,omitempty"`
+}
+
+// RecoverySystemsHealth returns the outcome of the last integrity check of
+// the recovery systems, indexed by the system label.
+func RecoverySystemsHealth(st *state.State) (map[string]*RecoverySystemHealth, error) {
+	var health map[string]*RecoverySystemHealth
+	if err := st.Get("recovery-systems-health", &health); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return health, nil
+}
+
+// recoverySystemsCheckSettings returns how often recovery systems are checked,
+// with a zero interval meaning that the check is disabled, and whether broken
+// snaps should be downloaded again.
+func recoverySystemsCheckSettings(st *state.State) (interval time.Duration, autoRepair bool, err error) {
+	tr := config.NewTransaction(st)
+	var intervalStr string
+	if err := tr.GetMaybe("core", "recovery-systems.check-interval", &intervalStr); err != nil {
+		return 0, false, err
+	}
+	if err := tr.GetMaybe("core", "recovery-systems.auto-repair", &autoRepair); err != nil {
+		return 0, false, err
+	}
+	switch intervalStr {
+	case "":
+		interval = defaultRecoverySystemsCheckInterval
+	case "no":
+		interval = 0
+	default:
+		interval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			return 0, false, fmt.Errorf("cannot parse recovery systems check interval: %v", err)
+		}
+	}
+	return interval, autoRepair, nil
+}
+
+func (m *DeviceManager) ensureRecoverySystemsChecked() error {
+	if release.OnClassic {
+		return nil
+	}
+	// only UC20+ in run mode have recovery systems to check
+	if m.SystemMode(SysHasModeenv) != "run" {
+		return nil
+	}
+
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	interval, _, err := recoverySystemsCheckSettings(st)
+	if err != nil {
+		return err
+	}
+	if interval == 0 {
+		return nil
+	}
+
+	var lastCheck time.Time
+	if err := st.Get("last-recovery-systems-check", &lastCheck); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	now := timeNow()
+	if lastCheck.IsZero() {
+		// the systems were just seeded or created, start counting
+		// from now
+		st.Set("last-recovery-systems-check", now)
+		return nil
+	}
+	if now.Before(lastCheck.Add(interval)) {
+		return nil
+	}
+
+	for _, chg := range st.Changes() {
+		if chg.Kind() == "check-recovery-systems" && !chg.IsReady() {
+			return nil
+		}
+	}
+	// recovery systems are being modified, try again later
+	if err := snapstate.CheckChangeConflictRunExclusively(st, "check-recovery-systems"); err != nil {
+		return nil
+	}
+
+	st.Set("last-recovery-systems-check", now)
+	check := st.NewTask("check-recovery-systems", i18n.G("Check integrity of recovery systems"))
+	chg := st.NewChange("check-recovery-systems", i18n.G("Check integrity of recovery systems"))
+	chg.AddTask(check)
+	st.EnsureBefore(0)
+	return nil
+}
+
+// recoverySystemSnapRepair is a seed snap downloaded again to repair recovery
+// systems. The download is only moved into the seed once verified, so that
+// the broken snap is kept when the download fails.
+type recoverySystemSnapRepair struct {
+	// Download is where the snap was downloaded to.
+	Download string `json:"download"`
+	// Path is where the snap is expected in the seed.
+	Path string `json:"path"`
+	// SHA3_384 is the digest the snap is expected to have.
+	SHA3_384 string `json:"sha3-384"`
+}
+
+// recoverySystemRepairDir returns where the snaps needed to repair the given
+// seed snaps directory are downloaded to. It is on the same filesystem, so
+// that the verified snaps can be renamed into place.
+func recoverySystemRepairDir(snapsDir string) string {
+	return filepath.Join(snapsDir, ".repair")
+}
+
+// applyRecoverySystemRepairs moves the downloaded snaps into the seed once
+// their digest matches the one expected by the seed. Downloads which do not
+// match are dropped, leaving the broken snaps in place.
+func applyRecoverySystemRepairs(repairs []recoverySystemSnapRepair) {
+	repairDirs := make(map[string]bool)
+	for _, repair := range repairs {
+		repairDirs[filepath.Dir(repair.Download)] = true
+		digest, _, err := asserts.SnapFileSHA3_384(repair.Download)
+		if err == nil && digest != repair.SHA3_384 {
+			err = fmt.Errorf("expected sha3-384 %s, got %s", repair.SHA3_384, digest)
+		}
+		if err == nil {
+			err = os.Rename(repair.Download, repair.Path)
+		}
+		if err != nil {
+			logger.Noticef("cannot repair recovery systems snap %q: %v", repair.Path, err)
+			if err := os.Remove(repair.Download); err != nil && !os.IsNotExist(err) {
+				logger.Noticef("cannot remove downloaded snap: %v", err)
+			}
+		}
+	}
+	for dir := range repairDirs {
+		// only removed once empty
+		os.Remove(dir)
+	}
+}
+
+// checkRecoverySystem verifies the assertions, the metadata and the snaps of
+// the given recovery system.
+func checkRecoverySystem(label string) error {
+	sd, err := seedOpen(dirs.SnapSeedDir, label)
+	if err != nil {
+		return err
+	}
+	if err := sd.LoadAssertions(nil, nil); err != nil {
+		return err
+	}
+	return sd.LoadMeta(seed.AllModes, nil, timings.New(nil))
+}
+
+func (m *DeviceManager) doCheckRecoverySystems(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var round int
+	if err := t.Get("repair-round", &round); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	var repairs []recoverySystemSnapRepair
+	if err := t.Get("repairs", &repairs); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	_, autoRepair, err := recoverySystemsCheckSettings(st)
+	if err != nil {
+		return err
+	}
+	prevHealth, err := RecoverySystemsHealth(st)
+	if err != nil {
+		return err
+	}
+
+	systemDirs, err := filepath.Glob(filepath.Join(dirs.SnapSeedDir, "systems", "*"))
+	if err != nil {
+		return fmt.Errorf("cannot list available systems: %v", err)
+	}
+
+	// verifying the snaps can take a while
+	st.Unlock()
+	applyRecoverySystemRepairs(repairs)
+	checkErrs := make(map[string]error, len(systemDirs))
+	for _, systemDir := range systemDirs {
+		label := filepath.Base(systemDir)
+		checkErrs[label] = checkRecoverySystem(label)
+	}
+	st.Lock()
+
+	// systems which are gone are dropped
+	health := make(map[string]*RecoverySystemHealth, len(checkErrs))
+	now := timeNow()
+	var broken []*seed.SnapIntegrityError
+	brokenPaths := make(map[string]bool)
+	for label, checkErr := range checkErrs {
+		health[label] = &RecoverySystemHealth{LastChecked: now}
+		if checkErr != nil {
+			health[label].Error = checkErr.Error()
+		}
+		if err := addRecoverySystemHealthNotice(st, label, prevHealth[label], health[label]); err != nil {
+			return err
+		}
+		if checkErr == nil {
+			continue
+		}
+		st.Warnf("recovery system %q is broken: %v", label, checkErr)
+
+		var integrityErr *seed.SnapIntegrityError
+		if errors.As(checkErr, &integrityErr) && !brokenPaths[integrityErr.Path] {
+			brokenPaths[integrityErr.Path] = true
+			broken = append(broken, integrityErr)
+		}
+	}
+	st.Set("recovery-systems-health", health)
+
+	if !autoRepair || len(broken) == 0 {
+		return nil
+	}
+	if round >= maxRecoverySystemsRepairRounds {
+		logger.Noticef("cannot repair recovery systems, giving up after %d attempts", round)
+		return nil
+	}
+
+	sort.Slice(broken, func(i, j int) bool { return broken[i].Path < broken[j].Path })
+	chg := t.Change()
+	recheck := st.NewTask("check-recovery-systems", i18n.G("Check integrity of repaired recovery systems"))
+	recheck.Set("repair-round", round+1)
+	repairs = nil
+	for _, integrityErr := range broken {
+		logger.Noticef("downloading snap %q (%s) again to repair recovery systems", integrityErr.SnapName, integrityErr.Revision)
+		// the broken snap is only replaced once the download is verified
+		repairDir := recoverySystemRepairDir(filepath.Dir(integrityErr.Path))
+		if err := os.MkdirAll(repairDir, 0755); err != nil {
+			return fmt.Errorf("cannot create directory to repair recovery systems: %v", err)
+		}
+		const userID = 0
+		ts, _, err := snapstateDownload(context.TODO(), st, integrityErr.SnapName, repairDir, &snapstate.RevisionOptions{
+			Revision: integrityErr.Revision,
+		}, userID, snapstate.Flags{}, nil)
+		if err != nil {
+			return fmt.Errorf("cannot repair recovery systems: %v", err)
+		}
+		ts.WaitFor(t)
+		recheck.WaitAll(ts)
+		chg.AddAll(ts)
+		repairs = append(repairs, recoverySystemSnapRepair{
+			Download: filepath.Join(repairDir, filepath.Base(integrityErr.Path)),
+			Path:     integrityErr.Path,
+			SHA3_384: integrityErr.SHA3_384,
+		})
+	}
+	recheck.Set("repairs", repairs)
+	chg.AddTask(recheck)
+	return nil
+}
+
+// addRecoverySystemHealthNotice records a notice when the recovery system with
+// the given label was found broken, or healthy again after it was broken.
+// Warnings are still recorded for broken systems, for users, while notices let
+// applications monitoring the device follow the health of the systems.
+func addRecoverySystemHealthNotice(st *state.State, label string, prev, cur *RecoverySystemHealth) error {
+	wasBroken := prev != nil && prev.Error != ""
+	data := map[string]string{}
+	switch {
+	case cur.Error != "" && (!wasBroken || prev.Error != cur.Error):
+		data["status"] = "broken"
+		data["error"] = cur.Error
+	case cur.Error == "" && wasBroken:
+		data["status"] = "healthy"
+	default:
+		return nil
+	}
+	_, err := st.AddNotice(nil, state.RecoverySystemHealthNotice, label, &state.AddNoticeOptions{
+		Data: data,
+	})
+	return err
+}
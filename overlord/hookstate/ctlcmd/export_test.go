@@ -25,8 +25,10 @@ import (
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/confdb"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/confdbstate"
 	"github.com/snapcore/snapd/overlord/devicestate"
@@ -35,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -212,3 +215,20 @@ func MockConfdbstateGetStoredTransaction(f func(*state.Task) (*confdbstate.Trans
 		confdbstateGetStoredTransaction = old
 	}
 }
+
+func MockSnapshotstateSaveSnapInitiated(f func(st *state.State, instanceName string, users []string, ignoreChangeID string) (uint64, *state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapshotstateSaveSnapInitiated, f)
+}
+
+func MockSnapshotstateList(f func(ctx context.Context, st *state.State, setID uint64, snapNames []string) ([]client.SnapshotSet, error)) (restore func()) {
+	return testutil.Mock(&snapshotstateList, f)
+}
+
+func MockQuotaGroupUsage(memory func(*quota.Group) (quantity.Size, error), tasks func(*quota.Group) (int, error)) (restore func()) {
+	r1 := testutil.Mock(&quotaGroupMemoryUsage, memory)
+	r2 := testutil.Mock(&quotaGroupTaskUsage, tasks)
+	return func() {
+		r2()
+		r1()
+	}
+}
@@ -0,0 +1,338 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	shortNoticesHelp = i18n.G("List notices about the lifecycle of snaps")
+	longNoticesHelp  = i18n.G(`
+The notices command lists, as JSON, the notices recorded when snaps are
+installed, refreshed or removed ("change-update" notices) and when their
+refreshes are inhibited ("refresh-inhibit" notices). Each notice is completed
+with the snaps it is about, and the current status of the change for
+"change-update" notices.
+
+The command requires the snap-lifecycle-observe interface to be connected.
+
+The --after option only lists the notices which were last repeated after the
+given time, and --wait waits up to the given duration for such notices to
+occur. With --snaps-in=validation-set only the notices about the snaps in the
+enforced validation sets of the calling snap are listed, and with
+--snaps-in=quota-group the ones about the snaps in its quota group.
+`)
+)
+
+// maxNoticesWait is the longest that "snapctl notices" can wait for notices,
+// so that the request of snapctl does not time out.
+const maxNoticesWait = time.Minute
+
+var lifecycleNoticeTypes = []state.NoticeType{state.ChangeUpdateNotice, state.RefreshInhibitNotice}
+
+func init() {
+	addCommand("notices", shortNoticesHelp, longNoticesHelp, func() command { return &noticesCommand{} })
+}
+
+type noticesCommand struct {
+	baseCommand
+	Types   string        `long:"types" description:"Only list notices of the given types (comma-separated)"`
+	After   string        `long:"after" description:"Only list notices last repeated after the given time (RFC3339)"`
+	Wait    time.Duration `long:"wait" description:"Wait up to the given duration for notices to occur"`
+	SnapsIn string        `long:"snaps-in" choice:"validation-set" choice:"quota-group" description:"Only list notices about the snaps in the same validation set or quota group"`
+}
+
+// lifecycleNotice is a notice as listed by "snapctl notices".
+type lifecycleNotice struct {
+	ID           string            `json:"id"`
+	Type         state.NoticeType  `json:"type"`
+	Key          string            `json:"key"`
+	LastRepeated time.Time         `json:"last-repeated"`
+	LastData     map[string]string `json:"last-data,omitempty"`
+	// Status is the current status of the change of "change-update"
+	// notices.
+	Status string   `json:"status,omitempty"`
+	Snaps  []string `json:"snaps"`
+}
+
+// The 'snapctl notices' command is read-only and can run as non-root through
+// snapctl, access is granted by the snap-lifecycle-observe interface instead.
+func (c *noticesCommand) Execute([]string) error {
+	ctx, err := c.ensureContext()
+	if err != nil {
+		return err
+	}
+
+	if c.Wait < 0 || c.Wait > maxNoticesWait {
+		return fmt.Errorf(i18n.G("cannot wait for notices for %s, the maximum is %s"), c.Wait, maxNoticesWait)
+	}
+	filter := &state.NoticeFilter{Types: lifecycleNoticeTypes}
+	if c.Types != "" {
+		filter.Types = nil
+		for _, typ := range strutil.CommaSeparatedList(c.Types) {
+			noticeType := state.NoticeType(typ)
+			if !noticeTypeIn(lifecycleNoticeTypes, noticeType) {
+				return fmt.Errorf(i18n.G("cannot list notices of type %q"), typ)
+			}
+			filter.Types = append(filter.Types, noticeType)
+		}
+	}
+	if c.After != "" {
+		filter.After, err = time.Parse(time.RFC3339, c.After)
+		if err != nil {
+			return fmt.Errorf(i18n.G("invalid --after time: %v"), err)
+		}
+	}
+
+	st := ctx.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := checkLifecycleObserveConnected(st, ctx.InstanceName()); err != nil {
+		return err
+	}
+	var snapsIn []string
+	switch c.SnapsIn {
+	case "validation-set":
+		snapsIn, err = validationSetSnaps(st, ctx.InstanceName())
+	case "quota-group":
+		snapsIn, err = quotaGroupSnaps(st, ctx.InstanceName())
+	}
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), c.Wait)
+	defer cancel()
+
+	var results []*lifecycleNotice
+	for {
+		var notices []*state.Notice
+		if c.Wait == 0 {
+			notices = st.Notices(filter)
+		} else {
+			notices, err = st.WaitNotices(waitCtx, filter)
+			if errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		results, err = lifecycleNotices(st, notices, snapsIn)
+		if err != nil {
+			return err
+		}
+		if len(results) > 0 || len(notices) == 0 || c.Wait == 0 {
+			break
+		}
+		// all the notices were about other snaps, wait for newer ones
+		filter.After = notices[len(notices)-1].LastRepeated()
+	}
+
+	if results == nil {
+		results = []*lifecycleNotice{}
+	}
+	b, err := json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		return err
+	}
+	c.printf("%s\n", b)
+	return nil
+}
+
+func noticeTypeIn(types []state.NoticeType, typ state.NoticeType) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func checkLifecycleObserveConnected(st *state.State, snapName string) error {
+	conns, err := ifacestate.ConnectionStates(st)
+	if err != nil {
+		return fmt.Errorf("internal error: cannot get connections: %s", err)
+	}
+	for connID, connState := range conns {
+		if connState.Interface != "snap-lifecycle-observe" || !connState.Active() {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(connID)
+		if err != nil {
+			return err
+		}
+		if connRef.PlugRef.Snap == snapName {
+			return nil
+		}
+	}
+	return fmt.Errorf(i18n.G("cannot list notices: snap %q does not have the snap-lifecycle-observe interface connected"), snapName)
+}
+
+// validationSetSnaps returns the names of the snaps in the enforced
+// validation sets which contain the given snap.
+func validationSetSnaps(st *state.State, instanceName string) ([]string, error) {
+	vsets, err := snapstate.EnforcedValidationSets(st)
+	if err != nil {
+		return nil, err
+	}
+	snapName := snap.InstanceSnap(instanceName)
+	var names []string
+	for _, vs := range vsets.Sets() {
+		var inSet bool
+		for _, sn := range vs.Snaps() {
+			if sn.SnapName() == snapName {
+				inSet = true
+				break
+			}
+		}
+		if !inSet {
+			continue
+		}
+		for _, sn := range vs.Snaps() {
+			if !strutil.ListContains(names, sn.SnapName()) {
+				names = append(names, sn.SnapName())
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf(i18n.G("snap %q is not in any enforced validation set"), instanceName)
+	}
+	return names, nil
+}
+
+// quotaGroupSnaps returns the names of the snaps in the quota group of the
+// given snap.
+func quotaGroupSnaps(st *state.State, instanceName string) ([]string, error) {
+	grps, err := servicestate.SnapQuotaGroups(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	if len(grps) == 0 {
+		return nil, fmt.Errorf(i18n.G("snap %q is not in a quota group"), instanceName)
+	}
+	return grps[0].Snaps, nil
+}
+
+// lifecycleNotices completes the given notices with the snaps they are
+// about, dropping the ones not about snaps or, if snapsIn is not empty,
+// about none of the snaps in it.
+func lifecycleNotices(st *state.State, notices []*state.Notice, snapsIn []string) ([]*lifecycleNotice, error) {
+	var results []*lifecycleNotice
+	for _, n := range notices {
+		res := &lifecycleNotice{
+			ID:           n.ID(),
+			Type:         n.Type(),
+			Key:          n.Key(),
+			LastRepeated: n.LastRepeated(),
+			LastData:     n.LastData(),
+		}
+		switch n.Type() {
+		case state.ChangeUpdateNotice:
+			chg := st.Change(n.Key())
+			if chg == nil {
+				// pruned already
+				continue
+			}
+			snaps, err := changeSnaps(chg)
+			if err != nil {
+				return nil, err
+			}
+			if len(snaps) == 0 {
+				continue
+			}
+			res.Status = chg.Status().String()
+			res.Snaps = snaps
+		case state.RefreshInhibitNotice:
+			snaps, err := inhibitedSnaps(st)
+			if err != nil {
+				return nil, err
+			}
+			res.Snaps = snaps
+		}
+		if len(snapsIn) > 0 {
+			var snaps []string
+			for _, name := range res.Snaps {
+				if strutil.ListContains(snapsIn, snap.InstanceSnap(name)) {
+					snaps = append(snaps, name)
+				}
+			}
+			if len(snaps) == 0 {
+				continue
+			}
+			res.Snaps = snaps
+		}
+		if res.Snaps == nil {
+			res.Snaps = []string{}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func changeSnaps(chg *state.Change) ([]string, error) {
+	var snaps []string
+	for _, t := range chg.Tasks() {
+		names, err := snapstate.SnapsAffectedByTask(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !strutil.ListContains(snaps, name) {
+				snaps = append(snaps, name)
+			}
+		}
+	}
+	sort.Strings(snaps)
+	return snaps, nil
+}
+
+// inhibitedSnaps returns the snaps whose refresh is currently inhibited.
+func inhibitedSnaps(st *state.State) ([]string, error) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	var snaps []string
+	for name, snapst := range snapStates {
+		if snapst.RefreshInhibitedTime != nil {
+			snaps = append(snaps, name)
+		}
+	}
+	sort.Strings(snaps)
+	return snaps, nil
+}
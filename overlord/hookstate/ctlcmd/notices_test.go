@@ -0,0 +1,282 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type noticesSuite struct {
+	testutil.BaseTest
+	st          *state.State
+	mockContext *hookstate.Context
+}
+
+var _ = Suite(&noticesSuite{})
+
+func (s *noticesSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.st = state.New(nil)
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	mockInstalledSnap(c, s.st, `name: agent
+plugs:
+  snap-lifecycle-observe:
+`, "")
+	s.st.Set("conns", map[string]interface{}{
+		"agent:snap-lifecycle-observe core:snap-lifecycle-observe": map[string]interface{}{
+			"interface": "snap-lifecycle-observe",
+		},
+	})
+
+	task := s.st.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "agent", Revision: snap.R(1), Hook: "test-hook"}
+	ctx, err := hookstate.NewContext(task, s.st, setup, hooktest.NewMockHandler(), "")
+	c.Assert(err, IsNil)
+	s.mockContext = ctx
+}
+
+// addSnapChange adds a change with a task for each of the given snaps.
+func (s *noticesSuite) addSnapChange(kind string, snaps ...string) *state.Change {
+	chg := s.st.NewChange(kind, "...")
+	for _, name := range snaps {
+		t := s.st.NewTask("link-snap", "...")
+		t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: name}})
+		chg.AddTask(t)
+	}
+	return chg
+}
+
+type noticeResult struct {
+	Type     string            `json:"type"`
+	Key      string            `json:"key"`
+	LastData map[string]string `json:"last-data"`
+	Status   string            `json:"status"`
+	Snaps    []string          `json:"snaps"`
+}
+
+func (s *noticesSuite) runNotices(c *C, args ...string) []noticeResult {
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, append([]string{"notices"}, args...), 1000)
+	c.Assert(err, IsNil)
+	c.Check(string(stderr), Equals, "")
+	var res []noticeResult
+	c.Assert(json.Unmarshal(stdout, &res), IsNil)
+	return res
+}
+
+func (s *noticesSuite) TestNotConnected(c *C) {
+	s.st.Lock()
+	s.st.Set("conns", map[string]interface{}{
+		"agent:snap-lifecycle-observe core:snap-lifecycle-observe": map[string]interface{}{
+			"interface": "snap-lifecycle-observe",
+			"undesired": true,
+		},
+	})
+	s.st.Unlock()
+
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"notices"}, 0)
+	c.Check(err, ErrorMatches, `cannot list notices: snap "agent" does not have the snap-lifecycle-observe interface connected`)
+}
+
+func (s *noticesSuite) TestInvalidArguments(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"notices", "--types=warning"}, 0)
+	c.Check(err, ErrorMatches, `cannot list notices of type "warning"`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"notices", "--wait=2m"}, 0)
+	c.Check(err, ErrorMatches, `cannot wait for notices for 2m0s, the maximum is 1m0s`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"notices", "--after=yesterday"}, 0)
+	c.Check(err, ErrorMatches, `invalid --after time: .*`)
+}
+
+func (s *noticesSuite) TestNotices(c *C) {
+	s.st.Lock()
+	mockInstalledSnap(c, s.st, `name: foo`, "")
+	installChg := s.addSnapChange("install-snap", "foo")
+	// changes not about snaps are not listed
+	s.addSnapChange("ensure-quota")
+	// the refresh of foo is inhibited
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.st, "foo", &snapst), IsNil)
+	now := time.Now()
+	snapst.RefreshInhibitedTime = &now
+	snapstate.Set(s.st, "foo", &snapst)
+	_, err := s.st.AddNotice(nil, state.RefreshInhibitNotice, "-", nil)
+	c.Assert(err, IsNil)
+	// warnings are not lifecycle notices
+	s.st.Warnf("danger")
+	s.st.Unlock()
+
+	c.Check(s.runNotices(c), DeepEquals, []noticeResult{{
+		Type:     "change-update",
+		Key:      installChg.ID(),
+		LastData: map[string]string{"kind": "install-snap"},
+		Status:   "Do",
+		Snaps:    []string{"foo"},
+	}, {
+		Type:  "refresh-inhibit",
+		Key:   "-",
+		Snaps: []string{"foo"},
+	}})
+
+	c.Check(s.runNotices(c, "--types=refresh-inhibit"), DeepEquals, []noticeResult{{
+		Type:  "refresh-inhibit",
+		Key:   "-",
+		Snaps: []string{"foo"},
+	}})
+
+	c.Check(s.runNotices(c, "--after="+time.Now().Format(time.RFC3339Nano)), HasLen, 0)
+}
+
+func (s *noticesSuite) TestNoticesSnapsInQuotaGroup(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"notices", "--snaps-in=quota-group"}, 0)
+	c.Check(err, ErrorMatches, `snap "agent" is not in a quota group`)
+
+	s.st.Lock()
+	_, err = servicestatetest.PatchQuotas(s.st, &quota.Group{
+		Name:        "grp",
+		MemoryLimit: quantity.SizeGiB,
+		Snaps:       []string{"agent", "foo"},
+	})
+	c.Assert(err, IsNil)
+	fooChg := s.addSnapChange("install-snap", "foo")
+	s.addSnapChange("install-snap", "bar")
+	multiChg := s.addSnapChange("refresh-snap", "bar", "foo")
+	s.st.Unlock()
+
+	c.Check(s.runNotices(c, "--snaps-in=quota-group"), DeepEquals, []noticeResult{{
+		Type:     "change-update",
+		Key:      fooChg.ID(),
+		LastData: map[string]string{"kind": "install-snap"},
+		Status:   "Do",
+		Snaps:    []string{"foo"},
+	}, {
+		Type:     "change-update",
+		Key:      multiChg.ID(),
+		LastData: map[string]string{"kind": "refresh-snap"},
+		Status:   "Do",
+		// only the snaps in the group are listed
+		Snaps: []string{"foo"},
+	}})
+}
+
+func (s *noticesSuite) TestNoticesSnapsInValidationSet(c *C) {
+	storeSigning := assertstest.NewStoreStack("can0nical", nil)
+	vsSnap := func(name string) interface{} {
+		return map[string]interface{}{
+			"name":     name,
+			"id":       snaptest.AssertedSnapID(name),
+			"presence": "optional",
+		}
+	}
+	vs, err := storeSigning.Sign(asserts.ValidationSetType, map[string]interface{}{
+		"type":         "validation-set",
+		"authority-id": "can0nical",
+		"series":       "16",
+		"account-id":   "can0nical",
+		"name":         "fleet",
+		"sequence":     "1",
+		"snaps":        []interface{}{vsSnap("agent"), vsSnap("foo")},
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	vsets := snapasserts.NewValidationSets()
+	restore := testutil.Mock(&snapstate.EnforcedValidationSets, func(st *state.State, extraVss ...*asserts.ValidationSet) (*snapasserts.ValidationSets, error) {
+		return vsets, nil
+	})
+	defer restore()
+
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"notices", "--snaps-in=validation-set"}, 0)
+	c.Check(err, ErrorMatches, `snap "agent" is not in any enforced validation set`)
+
+	c.Assert(vsets.Add(vs.(*asserts.ValidationSet)), IsNil)
+
+	s.st.Lock()
+	s.addSnapChange("install-snap", "bar")
+	fooChg := s.addSnapChange("remove-snap", "foo")
+	s.st.Unlock()
+
+	c.Check(s.runNotices(c, "--snaps-in=validation-set"), DeepEquals, []noticeResult{{
+		Type:     "change-update",
+		Key:      fooChg.ID(),
+		LastData: map[string]string{"kind": "remove-snap"},
+		Status:   "Do",
+		Snaps:    []string{"foo"},
+	}})
+}
+
+func (s *noticesSuite) TestNoticesWait(c *C) {
+	s.st.Lock()
+	_, err := servicestatetest.PatchQuotas(s.st, &quota.Group{
+		Name:        "grp",
+		MemoryLimit: quantity.SizeGiB,
+		Snaps:       []string{"agent", "foo"},
+	})
+	c.Assert(err, IsNil)
+	s.st.Unlock()
+
+	after := time.Now().Format(time.RFC3339Nano)
+	var fooChgID string
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.st.Lock()
+		// not about a snap of the quota group
+		s.addSnapChange("install-snap", "bar")
+		s.st.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		s.st.Lock()
+		fooChgID = s.addSnapChange("install-snap", "foo").ID()
+		s.st.Unlock()
+	}()
+
+	res := s.runNotices(c, "--wait=10s", "--after="+after, "--snaps-in=quota-group")
+	c.Assert(res, HasLen, 1)
+	s.st.Lock()
+	c.Check(res[0].Key, Equals, fooChgID)
+	s.st.Unlock()
+	c.Check(res[0].Snaps, DeepEquals, []string{"foo"})
+}
+
+func (s *noticesSuite) TestNoticesWaitTimeout(c *C) {
+	after := time.Now().Format(time.RFC3339Nano)
+	c.Check(s.runNotices(c, "--wait=10ms", "--after="+after), HasLen, 0)
+}
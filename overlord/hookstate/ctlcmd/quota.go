@@ -0,0 +1,180 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/strutil"
+	strquantity "github.com/snapcore/snapd/strutil/quantity"
+)
+
+var (
+	shortQuotaHelp = i18n.G("Show the quota of the snap")
+	longQuotaHelp  = i18n.G(`
+The quota command shows the resource limits and the current resource usage of
+the quota group the snap is in, followed by its parent groups. Each limit
+applies to all the snaps and services of the group and its sub-groups, so the
+effective limit of a resource is the lowest one set in any of the groups.
+
+Nothing is shown if the snap is not in a quota group.
+`)
+)
+
+var (
+	quotaGroupMemoryUsage = (*quota.Group).CurrentMemoryUsage
+	quotaGroupTaskUsage   = (*quota.Group).CurrentTaskUsage
+)
+
+func init() {
+	addCommand("quota", shortQuotaHelp, longQuotaHelp, func() command { return &quotaCommand{} })
+}
+
+type quotaCommand struct {
+	baseCommand
+}
+
+func fmtQuotaSize(size quantity.Size) string {
+	return strings.TrimSpace(strquantity.FormatAmount(uint64(size), -1)) + "B"
+}
+
+// The 'snapctl quota' command is read-only and can run as non-root through
+// snapctl.
+func (c *quotaCommand) Execute([]string) error {
+	ctx, err := c.ensureContext()
+	if err != nil {
+		return err
+	}
+
+	st := ctx.State()
+	st.Lock()
+	grps, err := servicestate.SnapQuotaGroups(st, ctx.InstanceName())
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	for i, grp := range grps {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := writeQuotaGroup(w, grp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeQuotaGroup(w *tabwriter.Writer, grp *quota.Group) error {
+	fmt.Fprintf(w, "name:\t%s\n", grp.Name)
+	if grp.ParentGroup != "" {
+		fmt.Fprintf(w, "parent:\t%s\n", grp.ParentGroup)
+	}
+
+	resources := grp.GetQuotaResources()
+	fmt.Fprintf(w, "constraints:\n")
+	if resources.Memory != nil {
+		fmt.Fprintf(w, "  memory:\t%s\n", fmtQuotaSize(resources.Memory.Limit))
+	}
+	if resources.CPU != nil {
+		fmt.Fprintf(w, "  cpu-count:\t%d\n", resources.CPU.Count)
+		fmt.Fprintf(w, "  cpu-percentage:\t%d\n", resources.CPU.Percentage)
+	}
+	if resources.CPUSet != nil && len(resources.CPUSet.CPUs) > 0 {
+		fmt.Fprintf(w, "  cpu-set:\t%s\n", strutil.IntsToCommaSeparated(resources.CPUSet.CPUs))
+	}
+	if resources.Threads != nil {
+		fmt.Fprintf(w, "  threads:\t%d\n", resources.Threads.Limit)
+	}
+	if resources.Journal != nil {
+		if resources.Journal.Size != nil {
+			fmt.Fprintf(w, "  journal-size:\t%s\n", fmtQuotaSize(resources.Journal.Size.Limit))
+		}
+		if resources.Journal.Rate != nil {
+			fmt.Fprintf(w, "  journal-rate:\t%d/%s\n", resources.Journal.Rate.Count, resources.Journal.Rate.Period)
+		}
+	}
+
+	// like for "snap quota", usage is only reported for the resources
+	// which are limited
+	if resources.Memory == nil && resources.Threads == nil {
+		return nil
+	}
+	fmt.Fprintf(w, "current:\n")
+	if resources.Memory != nil {
+		mem, err := quotaGroupMemoryUsage(grp)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  memory:\t%s\n", fmtQuotaSize(mem))
+	}
+	if resources.Threads != nil {
+		threads, err := quotaGroupTaskUsage(grp)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  threads:\t%d\n", threads)
+	}
+	return nil
+}
+
+// quotaSummary summarizes the effective memory and thread limits applying to
+// a service, along with their current usage. The effective limit of a
+// resource is the one of the closest group limiting it.
+func quotaSummary(grps []*quota.Group) (string, error) {
+	var memGrp, threadsGrp *quota.Group
+	for _, grp := range grps {
+		if memGrp == nil && grp.MemoryLimit != 0 {
+			memGrp = grp
+		}
+		if threadsGrp == nil && grp.ThreadLimit != 0 {
+			threadsGrp = grp
+		}
+	}
+
+	var usage []string
+	if memGrp != nil {
+		mem, err := quotaGroupMemoryUsage(memGrp)
+		if err != nil {
+			return "", err
+		}
+		usage = append(usage, fmt.Sprintf("memory=%s/%s", fmtQuotaSize(mem), fmtQuotaSize(memGrp.MemoryLimit)))
+	}
+	if threadsGrp != nil {
+		threads, err := quotaGroupTaskUsage(threadsGrp)
+		if err != nil {
+			return "", err
+		}
+		usage = append(usage, fmt.Sprintf("threads=%d/%d", threads, threadsGrp.ThreadLimit))
+	}
+	if len(usage) == 0 {
+		return grps[0].Name, nil
+	}
+	return fmt.Sprintf("%s(%s)", grps[0].Name, strings.Join(usage, ",")), nil
+}
@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type quotaSuite struct {
+	testutil.BaseTest
+	st          *state.State
+	mockContext *hookstate.Context
+}
+
+var _ = Suite(&quotaSuite{})
+
+func (s *quotaSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.st = state.New(nil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	task := s.st.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "test-hook"}
+	ctx, err := hookstate.NewContext(task, s.st, setup, hooktest.NewMockHandler(), "")
+	c.Assert(err, IsNil)
+	s.mockContext = ctx
+
+	s.AddCleanup(ctlcmd.MockQuotaGroupUsage(func(grp *quota.Group) (quantity.Size, error) {
+		switch grp.Name {
+		case "top":
+			return 300 * quantity.SizeMiB, nil
+		case "grp":
+			return 100 * quantity.SizeMiB, nil
+		}
+		c.Fatalf("unexpected memory usage query for group %q", grp.Name)
+		return 0, nil
+	}, func(grp *quota.Group) (int, error) {
+		c.Check(grp.Name, Equals, "grp")
+		return 4, nil
+	}))
+}
+
+func (s *quotaSuite) TestQuotaNoGroup(c *C) {
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, []string{"quota"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "")
+	c.Check(string(stderr), Equals, "")
+}
+
+func (s *quotaSuite) TestQuota(c *C) {
+	s.st.Lock()
+	_, err := servicestatetest.PatchQuotas(s.st, &quota.Group{
+		Name:        "top",
+		MemoryLimit: quantity.SizeGiB,
+		CPULimit:    &quota.GroupQuotaCPU{Count: 2, Percentage: 50},
+		SubGroups:   []string{"grp", "sibling"},
+	}, &quota.Group{
+		Name:         "grp",
+		MemoryLimit:  512 * quantity.SizeMiB,
+		ThreadLimit:  32,
+		JournalLimit: &quota.GroupQuotaJournal{Size: 64 * quantity.SizeMiB, RateEnabled: true, RateCount: 10, RatePeriod: time.Second},
+		ParentGroup:  "top",
+		Snaps:        []string{"test-snap"},
+	}, &quota.Group{
+		// groups of other snaps are not shown
+		Name:        "sibling",
+		MemoryLimit: 256 * quantity.SizeMiB,
+		ParentGroup: "top",
+		Snaps:       []string{"other-snap"},
+	})
+	s.st.Unlock()
+	c.Assert(err, IsNil)
+
+	// the command is available to non-root users
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, []string{"quota"}, 1000)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, `
+name:    grp
+parent:  top
+constraints:
+  memory:        537MB
+  threads:       32
+  journal-size:  67.1MB
+  journal-rate:  10/1s
+current:
+  memory:   105MB
+  threads:  4
+
+name:  top
+constraints:
+  memory:          1.07GB
+  cpu-count:       2
+  cpu-percentage:  50
+current:
+  memory:  315MB
+`[1:])
+	c.Check(string(stderr), Equals, "")
+}
@@ -26,9 +26,11 @@ import (
 	"sort"
 	"text/tabwriter"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 )
@@ -112,13 +114,59 @@ func (c *servicesCommand) Execute([]string) error {
 		return err
 	}
 
+	quotas, err := serviceQuotas(ctx.State(), services)
+	if err != nil {
+		return err
+	}
+
 	w := tabwriter.NewWriter(c.stdout, 5, 3, 2, ' ', 0)
 	defer w.Flush()
 
-	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
-	for _, svc := range services {
-		fmt.Fprintln(w, clientutil.FmtServiceStatus(&svc, isGlobal))
+	if quotas == nil {
+		fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes"))
+	} else {
+		fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent\tNotes\tQuota"))
+	}
+	for i, svc := range services {
+		if quotas == nil {
+			fmt.Fprintln(w, clientutil.FmtServiceStatus(&svc, isGlobal))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", clientutil.FmtServiceStatus(&svc, isGlobal), quotas[i])
+		}
 	}
 
 	return nil
 }
+
+// serviceQuotas returns a summary of the quota applying to each of the given
+// services, or nil if none of them is subject to a quota.
+func serviceQuotas(st *state.State, services []client.AppInfo) ([]string, error) {
+	st.Lock()
+	defer st.Unlock()
+
+	var anyQuota bool
+	quotas := make([]string, len(services))
+	for i, svc := range services {
+		quotas[i] = "-"
+		// quota groups only apply to system services
+		if svc.DaemonScope == snap.UserDaemon {
+			continue
+		}
+		grps, err := servicestate.ServiceQuotaGroups(st, svc.Snap, svc.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(grps) == 0 {
+			continue
+		}
+		quotas[i], err = quotaSummary(grps)
+		if err != nil {
+			return nil, err
+		}
+		anyQuota = true
+	}
+	if !anyQuota {
+		return nil, nil
+	}
+	return quotas, nil
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -40,10 +41,12 @@ import (
 	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/store/storetest"
@@ -1045,3 +1048,49 @@ func (s *servicectlSuite) TestServicesWithoutContext(c *C) {
 		c.Check(err, ErrorMatches, expectedError)
 	}
 }
+
+func (s *servicectlSuite) TestServicesWithQuota(c *C) {
+	restore := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		c.Assert(args[0], Equals, "show")
+		c.Check(args[2], Equals, "snap.test-snap.test-service.service")
+		return []byte(`Id=snap.test-snap.test-service.service
+Names=snap.test-snap.test-service.service
+Type=simple
+ActiveState=active
+UnitFileState=enabled
+NeedDaemonReload=no
+`), nil
+	})
+	defer restore()
+	restore = ctlcmd.MockQuotaGroupUsage(func(grp *quota.Group) (quantity.Size, error) {
+		c.Check(grp.Name, Equals, "grp")
+		return 100 * quantity.SizeMiB, nil
+	}, func(grp *quota.Group) (int, error) {
+		c.Check(grp.Name, Equals, "svc-grp")
+		return 4, nil
+	})
+	defer restore()
+
+	s.st.Lock()
+	_, err := servicestatetest.PatchQuotas(s.st, &quota.Group{
+		Name:        "grp",
+		MemoryLimit: quantity.SizeGiB,
+		Snaps:       []string{"test-snap"},
+		SubGroups:   []string{"svc-grp"},
+	}, &quota.Group{
+		Name:        "svc-grp",
+		ThreadLimit: 32,
+		ParentGroup: "grp",
+		Services:    []string{"test-snap.test-service"},
+	})
+	s.st.Unlock()
+	c.Assert(err, IsNil)
+
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, []string{"services", "test-snap.test-service"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, `
+Service                 Startup  Current  Notes  Quota
+test-snap.test-service  enabled  active   -      svc-grp(memory=105MB/1.07GB,threads=4/32)
+`[1:])
+	c.Check(string(stderr), Equals, "")
+}
@@ -0,0 +1,169 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/strutil/quantity"
+)
+
+var (
+	shortSnapshotHelp = i18n.G("Save or list snapshots of the snap data")
+	longSnapshotHelp  = i18n.G(`
+The snapshot command saves and lists snapshots of the data of the calling snap.
+
+The save subcommand creates a new snapshot set containing only the calling
+snap, and waits for it to be saved before printing the ID of the set. It can
+be used for instance by the post-refresh hook to protect the data of the snap
+before migrating it. Snap-initiated snapshots are shown with the
+"snap-initiated" note in "snap saved" and are rate limited.
+
+The list subcommand lists the snapshot sets containing the calling snap.
+`)
+
+	snapshotstateSaveSnapInitiated = snapshotstate.SaveSnapInitiated
+	snapshotstateList              = snapshotstate.List
+
+	snapshotSaveTimeout = 10 * time.Minute
+)
+
+func init() {
+	addCommand("snapshot", shortSnapshotHelp, longSnapshotHelp, func() command {
+		cmd := &snapshotCommand{}
+		cmd.SaveCmd.snapshot = cmd
+		cmd.ListCmd.snapshot = cmd
+		return cmd
+	})
+}
+
+type snapshotCommand struct {
+	baseCommand
+	SaveCmd snapshotSaveCmd `command:"save" description:"save a snapshot of the snap data"`
+	ListCmd snapshotListCmd `command:"list" description:"list the snapshots of the snap"`
+}
+
+func (c *snapshotCommand) Execute([]string) error {
+	// This is needed in order to implement the interface, but it's never
+	// called.
+	return nil
+}
+
+type snapshotSaveCmd struct {
+	Users    string `long:"users" description:"Snapshot data of only specific users (comma-separated)"`
+	snapshot *snapshotCommand
+}
+
+func (s *snapshotSaveCmd) Execute([]string) error {
+	ctx, err := s.snapshot.ensureContext()
+	if err != nil {
+		return err
+	}
+	users := strutil.CommaSeparatedList(s.Users)
+
+	st := ctx.State()
+	st.Lock()
+	// do not conflict with the change of the calling hook, e.g. a refresh
+	setID, ts, err := snapshotstateSaveSnapInitiated(st, ctx.InstanceName(), users, changeIDIfNotEphemeral(ctx))
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	// the snapshot is always saved in its own change, as the calling hook
+	// cannot proceed before the data is saved
+	chg := st.NewChange("save-snapshot", fmt.Sprintf("Save snapshot of snap %q requested by the snap", ctx.InstanceName()))
+	chg.AddAll(ts)
+	chg.Set("api-data", map[string]interface{}{
+		"set-id":    setID,
+		"snap-name": ctx.InstanceName(),
+	})
+	st.EnsureBefore(0)
+	st.Unlock()
+
+	select {
+	case <-chg.Ready():
+		st.Lock()
+		err := chg.Err()
+		st.Unlock()
+		if err != nil {
+			return err
+		}
+	case <-time.After(snapshotSaveTimeout):
+		return fmt.Errorf("snapctl snapshot save command is taking too long")
+	}
+
+	s.snapshot.printf("%d\n", setID)
+	return nil
+}
+
+type snapshotListCmd struct {
+	snapshot *snapshotCommand
+}
+
+func (s *snapshotListCmd) Execute([]string) error {
+	ctx, err := s.snapshot.ensureContext()
+	if err != nil {
+		return err
+	}
+
+	st := ctx.State()
+	st.Lock()
+	sets, err := snapshotstateList(context.TODO(), st, 0, []string{ctx.InstanceName()})
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(s.snapshot.stdout, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Set\tTime\tVersion\tRev\tSize\tNotes"))
+	for _, sg := range sets {
+		for _, sh := range sg.Snapshots {
+			var notes []string
+			if sh.Auto {
+				notes = append(notes, "auto")
+			}
+			if sh.SnapInitiated {
+				notes = append(notes, "snap-initiated")
+			}
+			if sh.Broken != "" {
+				notes = append(notes, "broken")
+			}
+			note := "-"
+			if len(notes) > 0 {
+				note = strings.Join(notes, ",")
+			}
+			size := quantity.FormatAmount(uint64(sh.Size), -1) + "B"
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", sg.ID, sh.Time.UTC().Format(time.RFC3339), sh.Version, sh.Revision, size, note)
+		}
+	}
+	return nil
+}
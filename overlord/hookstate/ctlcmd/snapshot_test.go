@@ -0,0 +1,192 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type snapshotSuite struct {
+	testutil.BaseTest
+	st          *state.State
+	mockHandler *hooktest.MockHandler
+}
+
+var _ = Suite(&snapshotSuite{})
+
+func (s *snapshotSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.st = state.New(nil)
+	s.mockHandler = hooktest.NewMockHandler()
+}
+
+func (s *snapshotSuite) hookContext(c *C) *hookstate.Context {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("refresh", "refresh snap")
+	task := s.st.NewTask("run-hook", "run hook")
+	chg.AddTask(task)
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "post-refresh"}
+	ctx, err := hookstate.NewContext(task, s.st, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	return ctx
+}
+
+func (s *snapshotSuite) TestMissingContext(c *C) {
+	_, _, err := ctlcmd.Run(nil, []string{"snapshot", "save"}, 0)
+	c.Check(err, ErrorMatches, `cannot invoke snapctl operation commands \(here "snapshot"\) from outside of a snap`)
+	_, _, err = ctlcmd.Run(nil, []string{"snapshot", "list"}, 0)
+	c.Check(err, ErrorMatches, `cannot invoke snapctl operation commands \(here "snapshot"\) from outside of a snap`)
+}
+
+func (s *snapshotSuite) TestSaveNotRoot(c *C) {
+	_, _, err := ctlcmd.Run(s.hookContext(c), []string{"snapshot", "save"}, 1000)
+	c.Check(err, ErrorMatches, `cannot use "snapshot" with uid 1000, try with sudo`)
+}
+
+func (s *snapshotSuite) TestSave(c *C) {
+	mockContext := s.hookContext(c)
+
+	s.st.Lock()
+	task := s.st.NewTask("save-snapshot", "save snapshot")
+	hookTask, _ := mockContext.Task()
+	hookChgID := hookTask.Change().ID()
+	s.st.Unlock()
+
+	restore := ctlcmd.MockSnapshotstateSaveSnapInitiated(func(st *state.State, instanceName string, users []string, ignoreChangeID string) (uint64, *state.TaskSet, error) {
+		c.Check(instanceName, Equals, "test-snap")
+		c.Check(users, DeepEquals, []string{"alice", "bob"})
+		// the change of the calling hook is not a conflict
+		c.Check(ignoreChangeID, Equals, hookChgID)
+		return 42, state.NewTaskSet(task), nil
+	})
+	defer restore()
+
+	type result struct {
+		stdout []byte
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		stdout, _, err := ctlcmd.Run(mockContext, []string{"snapshot", "save", "--users=alice,bob"}, 0)
+		done <- result{stdout, err}
+	}()
+
+	// wait for the change to be created
+	var chg *state.Change
+	for i := 0; i < 50; i++ {
+		s.st.Lock()
+		chg = task.Change()
+		s.st.Unlock()
+		if chg != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(chg, NotNil)
+
+	s.st.Lock()
+	// the snapshot is saved in its own change
+	c.Check(chg.ID(), Not(Equals), hookChgID)
+	c.Check(chg.Kind(), Equals, "save-snapshot")
+	var apiData map[string]interface{}
+	c.Check(chg.Get("api-data", &apiData), IsNil)
+	c.Check(apiData, DeepEquals, map[string]interface{}{
+		"set-id":    42.,
+		"snap-name": "test-snap",
+	})
+	chg.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+
+	res := <-done
+	c.Assert(res.err, IsNil)
+	c.Check(string(res.stdout), Equals, "42\n")
+}
+
+func (s *snapshotSuite) TestSaveError(c *C) {
+	restore := ctlcmd.MockSnapshotstateSaveSnapInitiated(func(st *state.State, instanceName string, users []string, ignoreChangeID string) (uint64, *state.TaskSet, error) {
+		c.Check(users, HasLen, 0)
+		return 0, nil, errors.New(`cannot save snapshot of snap "test-snap": too many requests, retry after 2026-10-19T10:10:00Z`)
+	})
+	defer restore()
+
+	stdout, _, err := ctlcmd.Run(s.hookContext(c), []string{"snapshot", "save"}, 0)
+	c.Check(err, ErrorMatches, `cannot save snapshot of snap "test-snap": too many requests, retry after .*`)
+	c.Check(stdout, HasLen, 0)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	// only the change of the hook
+	c.Check(s.st.Changes(), HasLen, 1)
+}
+
+func (s *snapshotSuite) TestList(c *C) {
+	t0 := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	restore := ctlcmd.MockSnapshotstateList(func(ctx context.Context, st *state.State, setID uint64, snapNames []string) ([]client.SnapshotSet, error) {
+		c.Check(setID, Equals, uint64(0))
+		c.Check(snapNames, DeepEquals, []string{"test-snap"})
+		return []client.SnapshotSet{
+			{ID: 1, Snapshots: []*client.Snapshot{
+				{SetID: 1, Snap: "test-snap", Revision: snap.R(1), Version: "1.0", Time: t0, Size: 2048, Auto: true},
+			}},
+			{ID: 3, Snapshots: []*client.Snapshot{
+				{SetID: 3, Snap: "test-snap", Revision: snap.R(2), Version: "2.0", Time: t0.Add(time.Hour), Size: 1000, SnapInitiated: true},
+			}},
+			{ID: 4, Snapshots: []*client.Snapshot{
+				{SetID: 4, Snap: "test-snap", Revision: snap.R(2), Version: "2.0", Time: t0.Add(2 * time.Hour), Size: 10},
+			}},
+		}, nil
+	})
+	defer restore()
+
+	stdout, stderr, err := ctlcmd.Run(s.hookContext(c), []string{"snapshot", "list"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, `
+Set  Time                  Version  Rev  Size    Notes
+1    2026-10-19T10:00:00Z  1.0      1     2048B  auto
+3    2026-10-19T11:00:00Z  2.0      2     1000B  snap-initiated
+4    2026-10-19T12:00:00Z  2.0      2       10B  -
+`[1:])
+	c.Check(string(stderr), Equals, "")
+}
+
+func (s *snapshotSuite) TestListEmpty(c *C) {
+	restore := ctlcmd.MockSnapshotstateList(func(ctx context.Context, st *state.State, setID uint64, snapNames []string) ([]client.SnapshotSet, error) {
+		return nil, nil
+	})
+	defer restore()
+
+	stdout, _, err := ctlcmd.Run(s.hookContext(c), []string{"snapshot", "list"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(stdout, HasLen, 0)
+}
@@ -659,16 +659,36 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		policyChecker = policyCheck.check
 	}
 
+	// services of the plug snap may be ordered after services of the slot
+	// snap, refuse connections creating a cycle before anything is changed
+	if err := servicestate.CheckConnectServiceDependencies(st, connRef); err != nil {
+		return err
+	}
+
 	// static attributes of the plug and slot not provided, the ones from snap infos will be used
 	conn, err := m.repo.Connect(connRef, nil, plugDynamicAttrs, nil, slotDynamicAttrs, policyChecker)
 	if err != nil || conn == nil {
 		return err
 	}
+	var slotOpts, plugOpts interfaces.ConfinementOptions
+	securitySetUp := false
 	defer func() {
-		if err != nil {
-			if err := m.repo.Disconnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name); err != nil {
-				logger.Noticef("cannot undo failed connection: %v", err)
-			}
+		if err == nil {
+			return
+		}
+		if err := m.repo.Disconnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name); err != nil {
+			logger.Noticef("cannot undo failed connection: %v", err)
+			return
+		}
+		if !securitySetUp {
+			return
+		}
+		// the security profiles were already set up with the connection
+		if err := m.setupSnapSecurity(task, slotAppSet, slotOpts, perfTimings); err != nil {
+			logger.Noticef("cannot undo security setup of snap %q: %v", slotRef.Snap, err)
+		}
+		if err := m.setupSnapSecurity(task, plugAppSet, plugOpts, perfTimings); err != nil {
+			logger.Noticef("cannot undo security setup of snap %q: %v", plugRef.Snap, err)
 		}
 	}()
 
@@ -677,22 +697,23 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		if err != nil {
 			return err
 		}
-		slotOpts, err := m.buildConfinementOptions(st, slotSnapInfo, slotSnapst.Flags)
+		slotOpts, err = m.buildConfinementOptions(st, slotSnapInfo, slotSnapst.Flags)
 		if err != nil {
 			return err
 		}
-		if err := m.setupSnapSecurity(task, slotAppSet, slotOpts, perfTimings); err != nil {
-			return err
-		}
-
 		plugSnapInfo, err := plugSnapst.CurrentInfo()
 		if err != nil {
 			return err
 		}
-		plugOpts, err := m.buildConfinementOptions(st, plugSnapInfo, plugSnapst.Flags)
+		plugOpts, err = m.buildConfinementOptions(st, plugSnapInfo, plugSnapst.Flags)
 		if err != nil {
 			return err
 		}
+
+		securitySetUp = true
+		if err := m.setupSnapSecurity(task, slotAppSet, slotOpts, perfTimings); err != nil {
+			return err
+		}
 		if err := m.setupSnapSecurity(task, plugAppSet, plugOpts, perfTimings); err != nil {
 			return err
 		}
@@ -705,7 +726,8 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 	// inactive connection already and we should restore its properties
 	// in case of undo. Otherwise we don't have to keep old-conn because undo
 	// can simply delete any trace of the connection.
-	if old, ok := conns[connRef.ID()]; ok && old.Undesired {
+	old, hadOld := conns[connRef.ID()]
+	if hadOld && old.Undesired {
 		task.Set("old-conn", old)
 	}
 
@@ -721,6 +743,18 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 	}
 	setConns(st, conns)
 
+	// regenerate the units of the plug snap services now ordered after
+	// services of the slot snap
+	if err := ensureServiceDependencies(st, plugRef.Snap); err != nil {
+		if hadOld {
+			conns[connRef.ID()] = old
+		} else {
+			delete(conns, connRef.ID())
+		}
+		setConns(st, conns)
+		return err
+	}
+
 	// the dynamic attributes might have been updated by the interface's BeforeConnectPlug/Slot code,
 	// so we need to update the task for connect-plug- and connect-slot- hooks to see new values.
 	setDynamicHookAttributes(task, conn.Plug.DynamicAttrs(), conn.Slot.DynamicAttrs())
@@ -840,7 +874,7 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 	}
 	setConns(st, conns)
 
-	return nil
+	return ensureServiceDependencies(st, cref.PlugRef.Snap)
 }
 
 func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error {
@@ -946,7 +980,7 @@ func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error
 	conns[connRef.ID()] = &oldconn
 	setConns(st, conns)
 
-	return nil
+	return ensureServiceDependencies(st, plugRef.Snap)
 }
 
 func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
@@ -983,6 +1017,10 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	if err := ensureServiceDependencies(st, plugRef.Snap); err != nil {
+		return err
+	}
+
 	var delayedSetupProfiles bool
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
@@ -1056,6 +1094,24 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+// ensureServiceDependencies regenerates the service units of the given snap,
+// as its services may depend on services of the snaps connected to its plugs.
+func ensureServiceDependencies(st *state.State, instanceName string) error {
+	var snapst snapstate.SnapState
+	err := snapstate.Get(st, instanceName, &snapst)
+	if errors.Is(err, state.ErrNoState) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	snapInfo, err := snapst.CurrentInfo()
+	if err != nil {
+		return err
+	}
+	return servicestate.EnsureServiceDependencies(st, snapInfo)
+}
+
 // timeout for shared content retry
 var contentLinkRetryTimeout = 30 * time.Second
 
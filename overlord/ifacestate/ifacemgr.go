@@ -20,12 +20,15 @@
 package ifacestate
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/backends"
+	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
@@ -396,6 +399,176 @@ func (m *InterfaceManager) ConnectionStates() (connStateByRef map[string]Connect
 	return ConnectionStates(m.state)
 }
 
+// ExplainSandbox returns the rules that interfaces contribute to the security
+// profiles of the given snap, along with the interface, plug or slot which
+// caused each of them. Only the backends whose specifications implement
+// interfaces.RuleExplainer contribute rules, and rules coming from the
+// templates of the security backends are not included.
+//
+// The state must be locked by the caller.
+func (m *InterfaceManager) ExplainSandbox(instanceName string) ([]interfaces.ExplainedRule, error) {
+	var snapst snapstate.SnapState
+	err := snapstate.Get(m.state, instanceName, &snapst)
+	if errors.Is(err, state.ErrNoState) {
+		return nil, &snap.NotInstalledError{Snap: instanceName}
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapInfo, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	appSet, err := appSetForSnapRevision(m.state, snapInfo)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := m.buildConfinementOptions(m.state, snapInfo, snapst.Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []interfaces.ExplainedRule{}
+	for _, backend := range m.repo.Backends() {
+		spec, err := m.repo.SnapSpecification(backend.Name(), appSet, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain %s specification for snap %q: %v", backend.Name(), instanceName, err)
+		}
+		if explainer, ok := spec.(interfaces.RuleExplainer); ok {
+			rules = append(rules, explainer.ExplainedRules()...)
+		}
+	}
+	return rules, nil
+}
+
+// InterfaceCandidate describes an interface which the snap could plug, along
+// with the rules that connecting the plug would add to the AppArmor and seccomp
+// profiles of the snap.
+type InterfaceCandidate struct {
+	Interface string
+	// Plug is the name of the plug of the snap using the interface, if the
+	// snap declares one.
+	Plug string
+	// Connected is set when the plug of the snap is connected.
+	Connected bool
+	Rules     []interfaces.ExplainedRule
+}
+
+// InterfaceCandidates returns the interfaces with a slot on the system which
+// the given snap could plug, along with the rules they would grant. Plugs the
+// snap does not declare are assumed to be used by all its applications and
+// hooks, without any attributes. Interfaces whose policy cannot be generated
+// for such a plug are skipped.
+//
+// The state must be locked by the caller.
+func (m *InterfaceManager) InterfaceCandidates(instanceName string) ([]InterfaceCandidate, error) {
+	var snapst snapstate.SnapState
+	err := snapstate.Get(m.state, instanceName, &snapst)
+	if errors.Is(err, state.ErrNoState) {
+		return nil, &snap.NotInstalledError{Snap: instanceName}
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapInfo, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	appSet, err := appSetForSnapRevision(m.state, snapInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// plugs declared by the snap, preferring the connected ones
+	declared := make(map[string]*snap.PlugInfo)
+	connected := make(map[string]bool)
+	for _, plug := range m.repo.Plugs(instanceName) {
+		conns, err := m.repo.Connected(instanceName, plug.Name)
+		if err != nil {
+			return nil, err
+		}
+		if connected[plug.Interface] {
+			continue
+		}
+		if len(conns) > 0 || declared[plug.Interface] == nil {
+			declared[plug.Interface] = plug
+			connected[plug.Interface] = len(conns) > 0
+		}
+	}
+
+	var candidates []InterfaceCandidate
+	for _, iface := range m.repo.AllInterfaces() {
+		slots := m.repo.AllSlots(iface.Name())
+		if len(slots) == 0 {
+			continue
+		}
+		// prefer the implicit slots of the system
+		slotInfo := slots[0]
+		for _, slot := range slots {
+			if typ := slot.Snap.Type(); typ == snap.TypeOS || typ == snap.TypeSnapd {
+				slotInfo = slot
+				break
+			}
+		}
+
+		candidate := InterfaceCandidate{Interface: iface.Name()}
+		plugInfo := declared[iface.Name()]
+		if plugInfo != nil {
+			candidate.Plug = plugInfo.Name
+			candidate.Connected = connected[iface.Name()]
+		} else {
+			plugInfo = &snap.PlugInfo{
+				Snap:      snapInfo,
+				Name:      iface.Name(),
+				Interface: iface.Name(),
+				Apps:      snapInfo.Apps,
+				Unscoped:  true,
+			}
+			if err := interfaces.BeforePreparePlug(iface, plugInfo); err != nil {
+				continue
+			}
+		}
+		slotAppSet, err := interfaces.NewSnapAppSet(slotInfo.Snap, nil)
+		if err != nil {
+			return nil, err
+		}
+		plug := interfaces.NewConnectedPlug(plugInfo, appSet, nil, nil)
+		slot := interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil)
+
+		rules, err := plugSideRules(iface, appSet, plugInfo, plug, slot)
+		if err != nil {
+			logger.Debugf("cannot compute rules of interface %q for snap %q: %v", iface.Name(), instanceName, err)
+			continue
+		}
+		candidate.Rules = rules
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// plugSideRules returns the AppArmor and seccomp rules added to the snap by
+// the plug side of the given interface.
+func plugSideRules(iface interfaces.Interface, appSet *interfaces.SnapAppSet, plugInfo *snap.PlugInfo, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) ([]interfaces.ExplainedRule, error) {
+	specs := []interface {
+		interfaces.Specification
+		interfaces.RuleExplainer
+	}{
+		apparmor.NewSpecification(appSet),
+		seccomp.NewSpecification(appSet),
+	}
+	var rules []interfaces.ExplainedRule
+	for _, spec := range specs {
+		if err := spec.AddPermanentPlug(iface, plugInfo); err != nil {
+			return nil, err
+		}
+		if err := spec.AddConnectedPlug(iface, plug, slot); err != nil {
+			return nil, err
+		}
+		rules = append(rules, spec.ExplainedRules()...)
+	}
+	return rules, nil
+}
+
 // ResolveDisconnect resolves potentially missing plug or slot names and
 // returns a list of fully populated connection references that can be
 // disconnected.
@@ -39,6 +39,8 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
@@ -59,6 +61,7 @@ import (
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/snapdenv"
+	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
 )
@@ -1362,6 +1365,102 @@ func (s *interfaceManagerSuite) TestEnsureProcessesConnectTask(c *C) {
 		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"}}})
 }
 
+const consumerServiceYaml = `
+name: consumer
+version: 1
+plugs:
+ plug:
+  interface: test
+slots:
+ slot:
+  interface: test
+apps:
+ app:
+  daemon: simple
+  service-dependencies:
+   plug:
+    services: [db]
+    wants: true
+`
+
+const producerServiceYaml = `
+name: producer
+version: 1
+slots:
+ slot:
+  interface: test
+plugs:
+ plug:
+  interface: test
+apps:
+ db:
+  daemon: simple
+  service-dependencies:
+   plug: {}
+`
+
+func (s *interfaceManagerSuite) TestConnectUpdatesServiceDependencies(c *C) {
+	s.MockModel(c, nil)
+	var sysctlArgs [][]string
+	restore := systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		sysctlArgs = append(sysctlArgs, args)
+		return nil, nil
+	})
+	defer restore()
+	// unit generation looks up the interfaces of the plugs
+	restore = builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.mockSnap(c, consumerServiceYaml)
+	s.mockSnap(c, producerServiceYaml)
+	_ = s.manager(c)
+
+	s.state.Lock()
+	change := s.state.NewChange("kind", "summary")
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	c.Assert(change.Err(), IsNil)
+	s.state.Unlock()
+
+	unit := filepath.Join(dirs.SnapServicesDir, "snap.consumer.app.service")
+	c.Check(unit, testutil.FileContains, "\nWants=snap.producer.db.service\n")
+	c.Check(unit, testutil.FileContains, " snapd.apparmor.service snap.producer.db.service\n")
+	c.Check(sysctlArgs, DeepEquals, [][]string{{"daemon-reload"}})
+
+	// connecting back creates a cycle
+	s.secBackend.SetupCalls = nil
+	s.state.Lock()
+	change = s.state.NewChange("kind", "summary")
+	ts, err = ifacestate.Connect(s.state, "producer", "plug", "consumer", "slot")
+	c.Assert(err, IsNil)
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(change.Err(), ErrorMatches, `(?s).*cannot order services of snap "producer": dependency cycle: producer.db -> consumer.app -> producer.db.*`)
+
+	var conns map[string]interface{}
+	c.Assert(s.state.Get("conns", &conns), IsNil)
+	c.Check(conns, HasLen, 1)
+	c.Check(conns["consumer:plug producer:slot"], NotNil)
+
+	// the cycle was detected before connecting and setting up security
+	repo := s.manager(c).Repository()
+	c.Check(repo.Interfaces().Connections, DeepEquals, []*interfaces.ConnRef{{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"}}})
+	c.Check(s.secBackend.SetupCalls, HasLen, 0)
+}
+
 func (s *interfaceManagerSuite) TestConnectTaskCheckInterfaceMismatch(c *C) {
 	s.MockModel(c, nil)
 
@@ -9450,6 +9549,129 @@ func (s *interfaceManagerSuite) testConnectionStates(c *C, auto, byGadget, undes
 	c.Check(conns, DeepEquals, expected)
 }
 
+func (s *interfaceManagerSuite) TestExplainSandbox(c *C) {
+	mountBackend := &mount.Backend{}
+	restore := ifacestate.MockSecurityBackends([]interfaces.SecurityBackend{s.secBackend, mountBackend})
+	defer restore()
+
+	s.mockIfaces(&ifacetest.TestInterface{
+		InterfaceName: "test",
+		MountConnectedPlugCallback: func(spec *mount.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			return spec.AddMountEntry(osutil.MountEntry{Name: "/src", Dir: "/dst", Type: "none", Options: []string{"bind"}})
+		},
+	}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test"},
+	})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	rules, err := mgr.ExplainSandbox("consumer")
+	c.Assert(err, IsNil)
+	c.Check(rules, DeepEquals, []interfaces.ExplainedRule{{
+		Backend: interfaces.SecurityMount,
+		Rule:    "/src /dst none bind 0 0",
+		Origin: interfaces.RuleOrigin{
+			Interface: "test",
+			Plug:      &interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+			Slot:      &interfaces.SlotRef{Snap: "producer", Name: "slot"},
+			Source:    interfaces.SourceConnectedPlug,
+		},
+	}})
+
+	// the slot side did not contribute anything
+	rules, err = mgr.ExplainSandbox("producer")
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+
+	_, err = mgr.ExplainSandbox("unknown")
+	c.Check(err, ErrorMatches, `snap "unknown" is not installed`)
+}
+
+func (s *interfaceManagerSuite) TestInterfaceCandidates(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{
+		InterfaceName: "test",
+		SecCompConnectedPlugCallback: func(spec *seccomp.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddSnippet("mount")
+			return nil
+		},
+	}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, ubuntuCoreSnapYaml)
+	s.mockSnap(c, producerYaml)
+	s.mockSnap(c, `name: consumer
+version: 1
+apps:
+ app:
+  plugs: [plug, network]
+plugs:
+ plug:
+  interface: test
+`)
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:network ubuntu-core:network": map[string]interface{}{"interface": "network"},
+	})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	candidates, err := mgr.InterfaceCandidates("consumer")
+	c.Assert(err, IsNil)
+	byIface := make(map[string]ifacestate.InterfaceCandidate)
+	for _, candidate := range candidates {
+		byIface[candidate.Interface] = candidate
+	}
+	// no slot for test2
+	c.Check(byIface, Not(testutil.Contains), "test2")
+
+	test := byIface["test"]
+	c.Check(test.Plug, Equals, "plug")
+	c.Check(test.Connected, Equals, false)
+	c.Check(test.Rules, DeepEquals, []interfaces.ExplainedRule{{
+		Backend:     interfaces.SecuritySecComp,
+		SecurityTag: "snap.consumer.app",
+		Rule:        "mount",
+		Origin: interfaces.RuleOrigin{
+			Interface: "test",
+			Plug:      &interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+			Slot:      &interfaces.SlotRef{Snap: "producer", Name: "slot"},
+			Source:    interfaces.SourceConnectedPlug,
+		},
+	}})
+
+	network := byIface["network"]
+	c.Check(network.Plug, Equals, "network")
+	c.Check(network.Connected, Equals, true)
+	c.Check(network.Rules, Not(HasLen), 0)
+
+	// builtin interfaces not plugged by the snap are considered too
+	home := byIface["home"]
+	c.Check(home.Plug, Equals, "")
+	c.Check(home.Connected, Equals, false)
+	var homeTags []string
+	for _, rule := range home.Rules {
+		c.Check(rule.Backend, Equals, interfaces.SecurityAppArmor)
+		c.Check(rule.Origin.Plug, DeepEquals, &interfaces.PlugRef{Snap: "consumer", Name: "home"})
+		homeTags = append(homeTags, rule.SecurityTag)
+	}
+	c.Check(homeTags, Not(HasLen), 0)
+	c.Check(homeTags, testutil.DeepContains, "snap.consumer.app")
+
+	_, err = mgr.InterfaceCandidates("unknown")
+	c.Check(err, ErrorMatches, `snap "unknown" is not installed`)
+}
+
 func (s *interfaceManagerSuite) TestConnectionStatesAutoManual(c *C) {
 	var isAuto, byGadget, isUndesired, hotplugGone bool = true, false, false, false
 	s.testConnectionStates(c, isAuto, byGadget, isUndesired, hotplugGone, map[string]ifacestate.ConnectionState{
@@ -240,12 +240,12 @@ func BuildInstallObserver(model *asserts.Model, gadgetDir string, useEncryption
 }
 
 // PrepareEncryptedSystemData executes preparations related to encrypted system data:
-// * provides trustedInstallObserver with the chosen keys
+// * provides trustedInstallObserver with the chosen keys and authentication options
 // * uses trustedInstallObserver to track any trusted assets in ubuntu-seed
 // * save keys and markers for ubuntu-data being able to safely open ubuntu-save
 // It is the responsibility of the caller to call
 // ObserveExistingTrustedRecoveryAssets on trustedInstallObserver.
-func PrepareEncryptedSystemData(model *asserts.Model, keyForRole map[string]keys.EncryptionKey, trustedInstallObserver boot.TrustedAssetsInstallObserver) error {
+func PrepareEncryptedSystemData(model *asserts.Model, keyForRole map[string]keys.EncryptionKey, volumesAuth *device.VolumesAuthOptions, trustedInstallObserver boot.TrustedAssetsInstallObserver) error {
 	// validity check
 	if len(keyForRole) == 0 || keyForRole[gadget.SystemData] == nil || keyForRole[gadget.SystemSave] == nil {
 		return fmt.Errorf("internal error: system encryption keys are unset")
@@ -255,6 +255,9 @@ func PrepareEncryptedSystemData(model *asserts.Model, keyForRole map[string]keys
 
 	// make note of the encryption keys
 	trustedInstallObserver.ChosenEncryptionKeys(dataEncryptionKey, saveEncryptionKey)
+	if volumesAuth != nil {
+		trustedInstallObserver.ChosenVolumesAuth(volumesAuth)
+	}
 
 	if err := saveKeys(model, keyForRole); err != nil {
 		return err
@@ -899,7 +899,7 @@ func (s *installSuite) TestPrepareEncryptedSystemData(c *C) {
 		gadget.SystemData: dataEncryptionKey,
 		gadget.SystemSave: saveKey,
 	}
-	err = install.PrepareEncryptedSystemData(mockModel, keyForRole, to)
+	err = install.PrepareEncryptedSystemData(mockModel, keyForRole, nil, to)
 	c.Assert(err, IsNil)
 
 	c.Check(filepath.Join(filepath.Join(dirs.GlobalRootDir, "/run/mnt/ubuntu-data/system-data/var/lib/snapd/device/fde"), "ubuntu-save.key"), testutil.FileEquals, []byte(saveKey))
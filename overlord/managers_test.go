@@ -7596,6 +7596,13 @@ func (s *mgrsSuiteCore) testRemodelUC20WithRecoverySystem(c *C, encrypted bool)
 		return nil
 	})
 	defer restore()
+	restore = boot.MockSecbootBuildPCRProtectionProfile(func(modelParams []*secboot.SealKeyModelParams) (string, error) {
+		if !encrypted {
+			return "", fmt.Errorf("unexpected call")
+		}
+		return "profile", nil
+	})
+	defer restore()
 
 	chg, err := devicestate.Remodel(st, newModel, nil, nil, devicestate.RemodelOptions{})
 	c.Assert(err, IsNil)
@@ -20,11 +20,17 @@
 package servicestate
 
 import (
+	"time"
+
 	tomb "gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+	"github.com/snapcore/snapd/wrappers"
 )
 
 var (
@@ -54,6 +60,14 @@ func (m *ServiceManager) UndoQuotaAddSnap(t *state.Task, to *tomb.Tomb) error {
 	return m.undoQuotaAddSnap(t, to)
 }
 
+func (m *ServiceManager) DoQuotaAutoCreate(t *state.Task, to *tomb.Tomb) error {
+	return m.doQuotaAutoCreate(t, to)
+}
+
+func (m *ServiceManager) UndoQuotaAutoCreate(t *state.Task, to *tomb.Tomb) error {
+	return m.undoQuotaAutoCreate(t, to)
+}
+
 func EnsureSnapServicesForGroupOptions(allGrps map[string]*quota.Group, extraSnaps []string) *ensureSnapServicesForGroupOptions {
 	return &ensureSnapServicesForGroupOptions{
 		allGrps:    allGrps,
@@ -76,3 +90,23 @@ func MockResourcesCheckFeatureRequirements(f func(*quota.Resources) error) (rest
 	resourcesCheckFeatureRequirements = f
 	return r
 }
+
+func MockWrappersStartServices(f func(apps []*snap.AppInfo, disabledSvcs *wrappers.DisabledServices, opts *wrappers.StartServicesOptions, inter wrappers.Interacter, tm timings.Measurer) error) (restore func()) {
+	return testutil.Mock(&wrappersStartServices, f)
+}
+
+func MockWrappersStopServices(f func(apps []*snap.AppInfo, opts *wrappers.StopServicesOptions, reason snap.ServiceStopReason, inter wrappers.Interacter, tm timings.Measurer) error) (restore func()) {
+	return testutil.Mock(&wrappersStopServices, f)
+}
+
+func MockWrappersQueryDisabledServices(f func(info *snap.Info, pb progress.Meter) (*wrappers.DisabledServices, error)) (restore func()) {
+	return testutil.Mock(&wrappersQueryDisabledServices, f)
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&timeNow, f)
+}
+
+func MockServiceRestartThreshold(threshold int) (restore func()) {
+	return testutil.Mock(&serviceRestartThreshold, threshold)
+}
@@ -0,0 +1,353 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/client/clientutil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timings"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+var (
+	wrappersStartServices         = wrappers.StartServices
+	wrappersStopServices          = wrappers.StopServices
+	wrappersQueryDisabledServices = wrappers.QueryDisabledServices
+)
+
+// LingeringUsers returns the names of the users for which lingering was
+// enabled through snapd, so that their snap user services run without them
+// being logged in.
+func LingeringUsers(st *state.State) ([]string, error) {
+	var users []string
+	if err := st.Get("lingering-users", &users); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetLingering creates a task set to enable, or disable, systemd lingering
+// for the given users. Once lingering is enabled for a user, its snap user
+// services are started by snapd through the session agent of the user,
+// including on boot. When lingering is disabled, the snap user services of
+// the user are stopped.
+func SetLingering(st *state.State, users []string, enable bool) (*state.TaskSet, error) {
+	if len(users) == 0 {
+		return nil, fmt.Errorf("cannot change lingering without a list of users")
+	}
+	uids, err := osutil.UsernamesToUids(users)
+	if err != nil {
+		return nil, fmt.Errorf("cannot change lingering: %v", err)
+	}
+	if _, ok := uids[0]; ok {
+		return nil, fmt.Errorf("cannot change lingering of the root user")
+	}
+
+	summary := fmt.Sprintf("Enable lingering for users %s", strutil.Quoted(users))
+	if !enable {
+		summary = fmt.Sprintf("Disable lingering for users %s", strutil.Quoted(users))
+	}
+	t := st.NewTask("set-lingering", summary)
+	t.Set("users", users)
+	t.Set("enable", enable)
+	return state.NewTaskSet(t), nil
+}
+
+func (m *ServiceManager) doSetLingering(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var users []string
+	var enable bool
+	if err := t.Get("users", &users); err != nil {
+		return err
+	}
+	if err := t.Get("enable", &enable); err != nil {
+		return err
+	}
+
+	changed, err := m.setLingering(st, users, enable)
+	// remember the users whose lingering was changed for undo, even on
+	// error as some of them may have been changed already
+	t.Set("changed-users", changed)
+	return err
+}
+
+func (m *ServiceManager) undoSetLingering(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var changed []string
+	var enable bool
+	if err := t.Get("changed-users", &changed); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if err := t.Get("enable", &enable); err != nil {
+		return err
+	}
+
+	_, err := m.setLingering(st, changed, !enable)
+	return err
+}
+
+// setLingering enables, or disables, lingering for the given users and
+// returns the ones for which it was changed. The state must be locked.
+func (m *ServiceManager) setLingering(st *state.State, users []string, enable bool) (changed []string, err error) {
+	for _, username := range users {
+		if enable {
+			if err := systemdSetLinger(username, true); err != nil {
+				return changed, err
+			}
+		} else {
+			if err := stopUserServices(st, username); err != nil {
+				return changed, err
+			}
+			if err := systemdSetLinger(username, false); err != nil {
+				return changed, err
+			}
+		}
+		// the snap user services of a lingering user are started by
+		// Ensure once the session agent of the user is available
+		delete(m.lingeringUsersStarted, username)
+
+		// the state was unlocked while stopping the services
+		lingering, err := LingeringUsers(st)
+		if err != nil {
+			return changed, err
+		}
+		wasLingering := strutil.ListContains(lingering, username)
+		switch {
+		case enable && !wasLingering:
+			lingering = append(lingering, username)
+			changed = append(changed, username)
+		case !enable && wasLingering:
+			lingering = removeUser(lingering, username)
+			changed = append(changed, username)
+		}
+		if len(lingering) == 0 {
+			st.Set("lingering-users", nil)
+		} else {
+			st.Set("lingering-users", lingering)
+		}
+	}
+
+	if enable {
+		st.EnsureBefore(0)
+	}
+	return changed, nil
+}
+
+// systemdSetLinger enables, or disables, lingering for the given user in
+// systemd-logind, which starts, or stops, the systemd user instance of the
+// user accordingly.
+func systemdSetLinger(username string, enable bool) error {
+	action, what := "enable-linger", "enable"
+	if !enable {
+		action, what = "disable-linger", "disable"
+	}
+	out, err := exec.Command("loginctl", action, username).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot %s lingering for user %q: %v", what, username, osutil.OutputErr(out, err))
+	}
+	return nil
+}
+
+// userServices returns the user services of the active snaps, by snap info
+// sorted by instance name. The state must be locked.
+func userServices(st *state.State) ([]*snap.Info, map[*snap.Info][]*snap.AppInfo, error) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	var infos []*snap.Info
+	svcs := make(map[*snap.Info][]*snap.AppInfo)
+	for _, snapst := range snapStates {
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, app := range info.Services() {
+			if app.DaemonScope == snap.UserDaemon {
+				svcs[info] = append(svcs[info], app)
+			}
+		}
+		if len(svcs[info]) > 0 {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].InstanceName() < infos[j].InstanceName()
+	})
+	return infos, svcs, nil
+}
+
+// startUserServices starts the snap user services of the given user through
+// its session agent, leaving out the ones the user disabled. The state must
+// be locked, it is unlocked while starting the services.
+func startUserServices(st *state.State, username string, uid int) error {
+	infos, svcs, err := userServices(st)
+	if err != nil {
+		return err
+	}
+
+	st.Unlock()
+	defer st.Lock()
+	for _, info := range infos {
+		ordered, err := snap.SortServices(svcs[info])
+		if err != nil {
+			return err
+		}
+		disabled, err := wrappersQueryDisabledServices(info, progress.Null)
+		if err != nil {
+			return err
+		}
+		userDisabled := &wrappers.DisabledServices{
+			UserServices: map[int][]string{uid: disabled.UserServices[uid]},
+		}
+		opts := &wrappers.StartServicesOptions{
+			ScopeOptions: wrappers.ScopeOptions{
+				Scope: wrappers.ServiceScopeUser,
+				Users: []string{username},
+			},
+		}
+		if err := wrappersStartServices(ordered, userDisabled, opts, progress.Null, timings.New(nil)); err != nil {
+			return fmt.Errorf("cannot start services of snap %q for user %q: %v", info.InstanceName(), username, err)
+		}
+	}
+	return nil
+}
+
+// stopUserServices stops the snap user services of the given user through
+// its session agent. The state must be locked, it is unlocked while stopping
+// the services.
+func stopUserServices(st *state.State, username string) error {
+	infos, svcs, err := userServices(st)
+	if err != nil {
+		return err
+	}
+
+	st.Unlock()
+	defer st.Lock()
+	for _, info := range infos {
+		opts := &wrappers.StopServicesOptions{
+			ScopeOptions: wrappers.ScopeOptions{
+				Scope: wrappers.ServiceScopeUser,
+				Users: []string{username},
+			},
+		}
+		if err := wrappersStopServices(svcs[info], opts, snap.StopReasonOther, progress.Null, timings.New(nil)); err != nil {
+			return fmt.Errorf("cannot stop services of snap %q for user %q: %v", info.InstanceName(), username, err)
+		}
+	}
+	return nil
+}
+
+// ensureLingeringUserServices starts the snap user services of the lingering
+// users once their session agent is available, which on boot happens when
+// systemd starts their user instance.
+func (m *ServiceManager) ensureLingeringUserServices() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	users, err := LingeringUsers(m.state)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, username := range users {
+		if !m.lingeringUsersStarted[username] {
+			pending = append(pending, username)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	sessions, err := clientutil.AvailableUserSessions()
+	if err != nil {
+		return err
+	}
+	for _, username := range pending {
+		uid, err := usernameToUid(username)
+		if err != nil {
+			logger.Noticef("cannot start snap user services of lingering user %q: %v", username, err)
+			m.lingeringUsersStarted[username] = true
+			continue
+		}
+		if !intListContains(sessions, uid) {
+			// try again on the next ensure
+			continue
+		}
+		if err := startUserServices(m.state, username, uid); err != nil {
+			// do not retry, the user may have broken services
+			logger.Noticef("cannot start snap user services of lingering user %q: %v", username, err)
+		}
+		m.lingeringUsersStarted[username] = true
+	}
+	return nil
+}
+
+func intListContains(list []int, i int) bool {
+	for _, v := range list {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+func usernameToUid(username string) (int, error) {
+	uids, err := osutil.UsernamesToUids([]string{username})
+	if err != nil {
+		return 0, err
+	}
+	for uid := range uids {
+		return uid, nil
+	}
+	return 0, fmt.Errorf("internal error: no uid for user %q", username)
+}
+
+func removeUser(users []string, username string) []string {
+	var res []string
+	for _, u := range users {
+		if u != username {
+			res = append(res, u)
+		}
+	}
+	return res
+}
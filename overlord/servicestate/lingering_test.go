@@ -0,0 +1,273 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/user"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+type lingeringSuite struct {
+	testutil.BaseTest
+	state      *state.State
+	o          *overlord.Overlord
+	serviceMgr *servicestate.ServiceManager
+
+	loginctl *testutil.MockCmd
+	started  []string
+	stopped  []string
+}
+
+var _ = Suite(&lingeringSuite{})
+
+const userServicesSnapYaml = `name: user-snap
+version: 1.0
+apps:
+  svc1:
+    daemon: simple
+    daemon-scope: user
+  svc2:
+    daemon: simple
+    daemon-scope: user
+  sys:
+    daemon: simple
+`
+
+func (s *lingeringSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.o = overlord.Mock()
+	s.state = s.o.State()
+	s.serviceMgr = servicestate.Manager(s.state, s.o.TaskRunner())
+	s.o.AddManager(s.serviceMgr)
+	s.o.AddManager(s.o.TaskRunner())
+	c.Assert(s.o.StartUp(), IsNil)
+
+	s.AddCleanup(osutil.MockUserLookup(func(username string) (*user.User, error) {
+		uids := map[string]string{"root": "0", "alice": "1000", "bob": "1001"}
+		uid, ok := uids[username]
+		if !ok {
+			return nil, fmt.Errorf("unknown user %s", username)
+		}
+		return &user.User{Uid: uid, Gid: uid, Username: username}, nil
+	}))
+
+	s.loginctl = testutil.MockCommand(c, "loginctl", "")
+	s.AddCleanup(s.loginctl.Restore)
+
+	s.started = nil
+	s.stopped = nil
+	s.AddCleanup(servicestate.MockWrappersStartServices(func(apps []*snap.AppInfo, disabledSvcs *wrappers.DisabledServices, opts *wrappers.StartServicesOptions, inter wrappers.Interacter, tm timings.Measurer) error {
+		c.Check(opts.Scope, Equals, wrappers.ServiceScopeUser)
+		c.Assert(opts.Users, HasLen, 1)
+		// only the services disabled by the user are left out
+		c.Check(disabledSvcs, DeepEquals, &wrappers.DisabledServices{
+			UserServices: map[int][]string{1000: {"svc2"}},
+		})
+		for _, app := range apps {
+			s.started = append(s.started, opts.Users[0]+":"+app.Name)
+		}
+		return nil
+	}))
+	s.AddCleanup(servicestate.MockWrappersStopServices(func(apps []*snap.AppInfo, opts *wrappers.StopServicesOptions, reason snap.ServiceStopReason, inter wrappers.Interacter, tm timings.Measurer) error {
+		c.Check(opts.Scope, Equals, wrappers.ServiceScopeUser)
+		c.Assert(opts.Users, HasLen, 1)
+		for _, app := range apps {
+			s.stopped = append(s.stopped, opts.Users[0]+":"+app.Name)
+		}
+		return nil
+	}))
+	s.AddCleanup(servicestate.MockWrappersQueryDisabledServices(func(info *snap.Info, pb progress.Meter) (*wrappers.DisabledServices, error) {
+		return &wrappers.DisabledServices{
+			UserServices: map[int][]string{1000: {"svc2"}, 1001: {"svc1"}},
+		}, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	si := &snap.SideInfo{RealName: "user-snap", Revision: snap.R(1)}
+	snaptest.MockSnap(c, userServicesSnapYaml, si)
+	snapstate.Set(s.state, "user-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+}
+
+func (s *lingeringSuite) mockSessionAgent(c *C, uid int) {
+	c.Assert(os.MkdirAll(filepath.Join(dirs.XdgRuntimeDirBase, fmt.Sprint(uid), "snapd-session-agent.socket"), 0700), IsNil)
+}
+
+// runSetLingering must be called with the state locked.
+func (s *lingeringSuite) runSetLingering(c *C, users []string, enable bool) *state.Change {
+	ts, err := servicestate.SetLingering(s.state, users, enable)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("linger", "...")
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	err = s.o.Settle(5 * time.Second)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	return chg
+}
+
+func (s *lingeringSuite) TestSetLingeringErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := servicestate.SetLingering(s.state, nil, true)
+	c.Check(err, ErrorMatches, `cannot change lingering without a list of users`)
+	_, err = servicestate.SetLingering(s.state, []string{"alice", "mallory"}, true)
+	c.Check(err, ErrorMatches, `cannot change lingering: unknown user mallory`)
+	_, err = servicestate.SetLingering(s.state, []string{"root"}, false)
+	c.Check(err, ErrorMatches, `cannot change lingering of the root user`)
+}
+
+func (s *lingeringSuite) TestEnableLingering(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	chg := s.runSetLingering(c, []string{"alice"}, true)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Tasks()[0].Summary(), Equals, `Enable lingering for users "alice"`)
+
+	c.Check(s.loginctl.Calls(), DeepEquals, [][]string{{"loginctl", "enable-linger", "alice"}})
+	users, err := servicestate.LingeringUsers(s.state)
+	c.Assert(err, IsNil)
+	c.Check(users, DeepEquals, []string{"alice"})
+	// the session agent of alice is not available yet
+	c.Check(s.started, HasLen, 0)
+
+	s.mockSessionAgent(c, 1000)
+	s.state.Unlock()
+	c.Assert(s.serviceMgr.Ensure(), IsNil)
+	s.state.Lock()
+	sort.Strings(s.started)
+	c.Check(s.started, DeepEquals, []string{"alice:svc1", "alice:svc2"})
+
+	// the services are only started once
+	s.started = nil
+	s.state.Unlock()
+	c.Assert(s.serviceMgr.Ensure(), IsNil)
+	s.state.Lock()
+	c.Check(s.started, HasLen, 0)
+}
+
+func (s *lingeringSuite) TestEnsureLingeringOnStartup(c *C) {
+	s.state.Lock()
+	s.state.Set("lingering-users", []string{"alice", "bob"})
+	s.state.Unlock()
+	s.mockSessionAgent(c, 1000)
+
+	c.Assert(s.serviceMgr.Ensure(), IsNil)
+	// only the services of the users with a session agent are started
+	sort.Strings(s.started)
+	c.Check(s.started, DeepEquals, []string{"alice:svc1", "alice:svc2"})
+	c.Check(s.loginctl.Calls(), HasLen, 0)
+}
+
+func (s *lingeringSuite) TestDisableLingering(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("lingering-users", []string{"alice", "bob"})
+
+	chg := s.runSetLingering(c, []string{"bob"}, false)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(chg.Tasks()[0].Summary(), Equals, `Disable lingering for users "bob"`)
+
+	sort.Strings(s.stopped)
+	c.Check(s.stopped, DeepEquals, []string{"bob:svc1", "bob:svc2"})
+	c.Check(s.loginctl.Calls(), DeepEquals, [][]string{{"loginctl", "disable-linger", "bob"}})
+	users, err := servicestate.LingeringUsers(s.state)
+	c.Assert(err, IsNil)
+	c.Check(users, DeepEquals, []string{"alice"})
+}
+
+func (s *lingeringSuite) TestUndoEnableLingering(c *C) {
+	s.state.Lock()
+	s.state.Set("lingering-users", []string{"alice"})
+
+	ts, err := servicestate.SetLingering(s.state, []string{"alice", "bob"}, true)
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("linger", "...")
+	chg.AddAll(ts)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitAll(ts)
+	chg.AddTask(terr)
+	s.o.TaskRunner().AddHandler("error-trigger", func(t *state.Task, _ *tomb.Tomb) error {
+		return fmt.Errorf("boom")
+	}, nil)
+
+	s.state.Unlock()
+	err = s.o.Settle(5 * time.Second)
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	// only the lingering of bob, which was changed, is undone
+	c.Check(s.loginctl.Calls(), DeepEquals, [][]string{
+		{"loginctl", "enable-linger", "alice"},
+		{"loginctl", "enable-linger", "bob"},
+		{"loginctl", "disable-linger", "bob"},
+	})
+	users, err := servicestate.LingeringUsers(s.state)
+	c.Assert(err, IsNil)
+	c.Check(users, DeepEquals, []string{"alice"})
+}
+
+func (s *lingeringSuite) TestEnableLingeringError(c *C) {
+	s.loginctl = testutil.MockCommand(c, "loginctl", `echo "no such user"; exit 1`)
+	s.AddCleanup(s.loginctl.Restore)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	chg := s.runSetLingering(c, []string{"alice"}, true)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*cannot enable lingering for user "alice": no such user.*`)
+	users, err := servicestate.LingeringUsers(s.state)
+	c.Assert(err, IsNil)
+	c.Check(users, HasLen, 0)
+}
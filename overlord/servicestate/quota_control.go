@@ -44,6 +44,7 @@ func checkSystemdVersion() {
 
 func init() {
 	snapstate.AddSnapToQuotaGroup = AddSnapToQuotaGroup
+	snapstate.AutoQuotaGroup = AutoQuotaGroup
 	EnsureQuotaUsability()
 }
 
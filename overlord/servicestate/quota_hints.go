@@ -0,0 +1,251 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// resourcesFromHints returns the resource limits of a quota group holding the
+// given snaps, as the sum of the resources their services declare they expect
+// to use. The memory limit is only derived from the maximum memory of the
+// services, and is left unset unless all of them declare one. It also returns
+// whether any of the services declared resources.
+func resourcesFromHints(infos []*snap.Info) (quota.Resources, bool) {
+	var memory quantity.Size
+	var cpuShare, threads int
+	hinted := false
+	unboundedMemory := false
+	for _, info := range infos {
+		for _, app := range info.Services() {
+			res := app.Resources
+			if res == nil {
+				continue
+			}
+			hinted = true
+			// the expected memory is not a limit, using it would get
+			// the service killed when it needs more than usual
+			if res.MaxMemory == 0 {
+				unboundedMemory = true
+			}
+			memory += res.MaxMemory
+			cpuShare += res.CPUShare
+			threads += res.Threads
+		}
+	}
+
+	rb := quota.NewResourcesBuilder()
+	if memory != 0 && !unboundedMemory {
+		rb.WithMemoryLimit(memory)
+	}
+	if cpuShare != 0 {
+		// spread the share over as few CPUs as possible, as the
+		// percentage is per CPU
+		count := (cpuShare + 99) / 100
+		rb.WithCPUCount(count)
+		rb.WithCPUPercentage((cpuShare + count - 1) / count)
+	}
+	if threads != 0 {
+		rb.WithThreadLimit(threads)
+	}
+	return rb.Build(), hinted
+}
+
+// ResourcesFromSnapHints returns the given resource limits completed with
+// the limits computed from the resources the services of the given snaps
+// declare they expect to use. The limits which are already set are kept.
+func ResourcesFromSnapHints(st *state.State, snaps []string, limits quota.Resources) (quota.Resources, error) {
+	if len(snaps) == 0 {
+		return quota.Resources{}, errors.New("cannot use resource hints without snaps")
+	}
+
+	infos := make([]*snap.Info, 0, len(snaps))
+	for _, name := range snaps {
+		info, err := snapstate.CurrentInfo(st, name)
+		if err != nil {
+			return quota.Resources{}, err
+		}
+		infos = append(infos, info)
+	}
+	hints, hinted := resourcesFromHints(infos)
+	if !hinted {
+		return quota.Resources{}, fmt.Errorf("cannot use resource hints: snaps %s do not declare any resources", strutil.Quoted(snaps))
+	}
+
+	if limits.Memory == nil {
+		limits.Memory = hints.Memory
+	}
+	if limits.CPU == nil {
+		limits.CPU = hints.CPU
+	}
+	if limits.Threads == nil {
+		limits.Threads = hints.Threads
+	}
+	return limits, nil
+}
+
+// AutoQuotaGroup returns a task creating a quota group for the snap from the
+// resources its services declare they expect to use. It is intended to be
+// used when installing the snap, and does nothing if the snap declares no
+// resources or is already in a quota group.
+func AutoQuotaGroup(st *state.State, snapName string) *state.Task {
+	return st.NewTask("quota-auto-create", fmt.Sprintf(i18n.G("Create quota group for snap %q from its resource hints"), snapName))
+}
+
+// autoQuotaGroupName returns the name of the quota group automatically
+// created for the snap.
+func autoQuotaGroupName(instanceName string) string {
+	return strings.Replace(instanceName, "_", "-", -1)
+}
+
+func (m *ServiceManager) doQuotaAutoCreate(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(t)
+	defer perfTimings.Save(st)
+
+	snapsup, err := snapstate.TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	snapName := snapsup.InstanceName()
+
+	info, err := snapstate.CurrentInfo(st, snapName)
+	if err != nil {
+		return err
+	}
+	limits, hinted := resourcesFromHints([]*snap.Info{info})
+	if !hinted {
+		return nil
+	}
+
+	// the quota group is only a convenience, installing the snap must not
+	// fail because of it
+	quotaName := autoQuotaGroupName(snapName)
+	if err := naming.ValidateQuotaGroup(quotaName); err != nil {
+		t.Logf("Cannot create quota group for snap %q: %v", snapName, err)
+		return nil
+	}
+	if err := verifyQuotaRequirements(st, limits); err != nil {
+		t.Logf("Cannot create quota group %q: %v", quotaName, err)
+		return nil
+	}
+	allGrps, err := AllQuotas(st)
+	if err != nil {
+		return err
+	}
+	for _, grp := range allGrps {
+		if strutil.ListContains(grp.Snaps, snapName) {
+			t.Logf("Snap %q is already in quota group %q", snapName, grp.Name)
+			return nil
+		}
+	}
+
+	qc := QuotaControlAction{
+		Action:         "create",
+		QuotaName:      quotaName,
+		ResourceLimits: limits,
+		AddSnaps:       []string{snapName},
+	}
+	grp, allGrps, _, err := quotaCreate(st, qc, allGrps)
+	if err != nil {
+		t.Logf("Cannot create quota group %q: %v", quotaName, err)
+		return nil
+	}
+	t.Set("quota-name", quotaName)
+
+	// ensure service and slices on disk and their states are updated
+	opts := &ensureSnapServicesForGroupOptions{
+		allGrps: allGrps,
+	}
+	_, err = ensureSnapServicesForGroup(st, t, grp, opts)
+	return err
+}
+
+func (m *ServiceManager) undoQuotaAutoCreate(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(t)
+	defer perfTimings.Save(st)
+
+	var quotaName string
+	if err := t.Get("quota-name", &quotaName); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			// no quota group was created
+			return nil
+		}
+		return err
+	}
+
+	snapsup, err := snapstate.TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	if err := EnsureSnapAbsentFromQuota(st, snapsup.InstanceName()); err != nil {
+		return err
+	}
+
+	allGrps, err := AllQuotas(st)
+	if err != nil {
+		return err
+	}
+	if _, ok := allGrps[quotaName]; !ok {
+		return nil
+	}
+	qc := QuotaControlAction{
+		Action:    "remove",
+		QuotaName: quotaName,
+	}
+	grp, allGrps, _, err := quotaRemove(st, qc, allGrps)
+	if err != nil {
+		return err
+	}
+	// the snap is no longer in the group, only its slice is left
+	opts := &ensureSnapServicesForGroupOptions{
+		allGrps: allGrps,
+	}
+	_, err = ensureSnapServicesForGroup(st, t, grp, opts)
+	return err
+}
+
+func affectedQuotasForQuotaAutoCreate(t *state.Task) (quotas []string, err error) {
+	snapsup, err := snapstate.TaskSnapSetup(t)
+	if err != nil {
+		return nil, err
+	}
+	return []string{autoQuotaGroupName(snapsup.InstanceName())}, nil
+}
@@ -0,0 +1,280 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+const testHintsYaml = `name: test-snap
+version: v1
+apps:
+  svc1:
+    command: bin.sh
+    daemon: simple
+    resources:
+      expected-memory: 100MB
+      max-memory: 1GB
+      cpu-share: 150
+      threads: 32
+  svc2:
+    command: bin.sh
+    daemon: simple
+    resources:
+      expected-memory: 100MB
+      max-memory: 500MB
+      cpu-share: 30
+  app:
+    command: bin.sh
+`
+
+func (s *quotaHandlersSuite) TestResourcesFromSnapHints(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testHintsYaml, s.testSnapSideInfo)
+
+	limits, err := servicestate.ResourcesFromSnapHints(st, []string{"test-snap"}, quota.Resources{})
+	c.Assert(err, IsNil)
+	// 180% of a CPU is spread over 2 CPUs
+	c.Check(limits, DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(1500*1000*1000).
+		WithCPUCount(2).
+		WithCPUPercentage(90).
+		WithThreadLimit(32).
+		Build())
+
+	// the limits which are set are kept
+	limits, err = servicestate.ResourcesFromSnapHints(st, []string{"test-snap"},
+		quota.NewResourcesBuilder().WithThreadLimit(64).WithCPUSet([]int{0, 1}).Build())
+	c.Assert(err, IsNil)
+	c.Check(limits, DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(1500*1000*1000).
+		WithCPUCount(2).
+		WithCPUPercentage(90).
+		WithCPUSet([]int{0, 1}).
+		WithThreadLimit(64).
+		Build())
+}
+
+func (s *quotaHandlersSuite) TestResourcesFromSnapHintsExpectedMemoryOnly(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	yaml := strings.Replace(testHintsYaml, "      max-memory: 1GB\n", "", 1)
+	yaml = strings.Replace(yaml, "      max-memory: 500MB\n", "", 1)
+	snaptest.MockSnapCurrent(c, yaml, s.testSnapSideInfo)
+
+	// the expected memory is not used as a limit
+	limits, err := servicestate.ResourcesFromSnapHints(st, []string{"test-snap"}, quota.Resources{})
+	c.Assert(err, IsNil)
+	c.Check(limits, DeepEquals, quota.NewResourcesBuilder().
+		WithCPUCount(2).
+		WithCPUPercentage(90).
+		WithThreadLimit(32).
+		Build())
+}
+
+func (s *quotaHandlersSuite) TestResourcesFromSnapHintsMaxMemoryNotDeclaredByAll(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	// svc1 declares a maximum memory, svc2 only an expected one
+	snaptest.MockSnapCurrent(c, strings.Replace(testHintsYaml, "      max-memory: 500MB\n", "", 1), s.testSnapSideInfo)
+
+	// limiting the memory to the maximum of svc1 would starve svc2
+	limits, err := servicestate.ResourcesFromSnapHints(st, []string{"test-snap"}, quota.Resources{})
+	c.Assert(err, IsNil)
+	c.Check(limits, DeepEquals, quota.NewResourcesBuilder().
+		WithCPUCount(2).
+		WithCPUPercentage(90).
+		WithThreadLimit(32).
+		Build())
+}
+
+func (s *quotaHandlersSuite) TestResourcesFromSnapHintsErrors(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	_, err := servicestate.ResourcesFromSnapHints(st, nil, quota.Resources{})
+	c.Check(err, ErrorMatches, `cannot use resource hints without snaps`)
+	_, err = servicestate.ResourcesFromSnapHints(st, []string{"test-snap"}, quota.Resources{})
+	c.Check(err, ErrorMatches, `cannot use resource hints: snaps "test-snap" do not declare any resources`)
+	_, err = servicestate.ResourcesFromSnapHints(st, []string{"test-snap", "other-snap"}, quota.Resources{})
+	c.Check(err, ErrorMatches, `snap "other-snap" is not installed`)
+}
+
+func (s *quotaHandlersSuite) newAutoQuotaTask(c *C) *state.Task {
+	t := servicestate.AutoQuotaGroup(s.state, "test-snap")
+	c.Check(t.Kind(), Equals, "quota-auto-create")
+	c.Check(t.Summary(), Equals, `Create quota group for snap "test-snap" from its resource hints`)
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "test-snap",
+			Revision: snap.R(42),
+		},
+	})
+	chg := s.state.NewChange("install-snap", "...")
+	chg.AddTask(t)
+	return t
+}
+
+func (s *quotaHandlersSuite) TestDoQuotaAutoCreate(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+		systemctlCallsForSliceStart("test-snap"),
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testHintsYaml, s.testSnapSideInfo)
+
+	t := s.newAutoQuotaTask(c)
+	st.Unlock()
+	err := s.mgr.DoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	// the slice depends on the CPUs of the host, only check the state
+	grp, err := servicestate.GetQuota(st, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(grp.Snaps, DeepEquals, []string{"test-snap"})
+	c.Check(grp.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(1500*1000*1000).
+		WithCPUCount(2).
+		WithCPUPercentage(90).
+		WithThreadLimit(32).
+		Build())
+	var quotaName string
+	c.Assert(t.Get("quota-name", &quotaName), IsNil)
+	c.Check(quotaName, Equals, "test-snap")
+}
+
+func (s *quotaHandlersSuite) TestDoQuotaAutoCreateNoHints(c *C) {
+	r := s.mockSystemctlCalls(c, nil)
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	t := s.newAutoQuotaTask(c)
+	st.Unlock()
+	err := s.mgr.DoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+	checkQuotaState(c, st, nil)
+
+	// undo has nothing to do either
+	st.Unlock()
+	err = s.mgr.UndoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+}
+
+func (s *quotaHandlersSuite) TestDoQuotaAutoCreateAlreadyInGroup(c *C) {
+	r := s.mockSystemctlCalls(c, nil)
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testHintsYaml, s.testSnapSideInfo)
+	grpLimits := quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build()
+	err := servicestatetest.MockQuotaInState(st, "foo", "", []string{"test-snap"}, nil, grpLimits)
+	c.Assert(err, IsNil)
+
+	t := s.newAutoQuotaTask(c)
+	st.Unlock()
+	err = s.mgr.DoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	allGrps, err := servicestate.AllQuotas(st)
+	c.Assert(err, IsNil)
+	c.Assert(allGrps, HasLen, 1)
+	c.Check(allGrps["foo"].Snaps, DeepEquals, []string{"test-snap"})
+	c.Assert(t.Log(), HasLen, 1)
+	c.Check(t.Log()[0], Matches, `.* Snap "test-snap" is already in quota group "foo"`)
+}
+
+func (s *quotaHandlersSuite) TestUndoQuotaAutoCreate(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// doQuotaAutoCreate
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+		systemctlCallsForSliceStart("test-snap"),
+		// undoQuotaAutoCreate, the snap is moved out of the group
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+		systemctlCallsForMultipleServiceRestart("test-snap", []string{"svc1", "svc2"}),
+		// and the group is removed
+		systemctlCallsForSliceStop("test-snap"),
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testHintsYaml, s.testSnapSideInfo)
+
+	t := s.newAutoQuotaTask(c)
+	st.Unlock()
+	err := s.mgr.DoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+
+	st.Unlock()
+	err = s.mgr.UndoQuotaAutoCreate(t, nil)
+	st.Lock()
+	c.Assert(err, IsNil)
+	checkQuotaState(c, st, nil)
+}
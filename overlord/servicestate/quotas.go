@@ -24,7 +24,9 @@ import (
 
 	"github.com/snapcore/snapd/overlord/servicestate/internal"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var ErrQuotaNotFound = errors.New("quota not found")
@@ -49,3 +51,53 @@ func GetQuota(st *state.State, name string) (*quota.Group, error) {
 
 	return group, nil
 }
+
+func snapQuotaGroup(allGrps map[string]*quota.Group, instanceName string) *quota.Group {
+	for _, grp := range allGrps {
+		if strutil.ListContains(grp.Snaps, instanceName) {
+			return grp
+		}
+	}
+	return nil
+}
+
+// quotaGroupWithAncestors returns the given group followed by its parent
+// groups, up to the root group.
+func quotaGroupWithAncestors(allGrps map[string]*quota.Group, grp *quota.Group) []*quota.Group {
+	var grps []*quota.Group
+	for grp != nil {
+		grps = append(grps, grp)
+		grp = allGrps[grp.ParentGroup]
+	}
+	return grps
+}
+
+// SnapQuotaGroups returns the quota group of the given snap followed by its
+// ancestors, or nil if the snap is not in any quota group.
+func SnapQuotaGroups(st *state.State, instanceName string) ([]*quota.Group, error) {
+	allGrps, err := internal.AllQuotas(st)
+	if err != nil {
+		return nil, err
+	}
+
+	return quotaGroupWithAncestors(allGrps, snapQuotaGroup(allGrps, instanceName)), nil
+}
+
+// ServiceQuotaGroups returns the quota group the given service of a snap
+// is run in followed by its ancestors. This is either a service sub-group or
+// the quota group of the snap itself. It returns nil if the service is not
+// subject to any quota.
+func ServiceQuotaGroups(st *state.State, instanceName, appName string) ([]*quota.Group, error) {
+	allGrps, err := internal.AllQuotas(st)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := snap.JoinSnapApp(instanceName, appName)
+	for _, grp := range allGrps {
+		if strutil.ListContains(grp.Services, svc) {
+			return quotaGroupWithAncestors(allGrps, grp), nil
+		}
+	}
+	return quotaGroupWithAncestors(allGrps, snapQuotaGroup(allGrps, instanceName)), nil
+}
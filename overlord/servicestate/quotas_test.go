@@ -113,3 +113,57 @@ func (s *servicestateQuotasSuite) TestQuotas(c *C) {
 	_, err = servicestate.GetQuota(st, "unknown")
 	c.Assert(err, Equals, servicestate.ErrQuotaNotFound)
 }
+
+func (s *servicestateQuotasSuite) TestSnapAndServiceQuotaGroups(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// no quota groups at all
+	grps, err := servicestate.SnapQuotaGroups(st, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(grps, HasLen, 0)
+
+	top := &quota.Group{
+		Name:        "top",
+		MemoryLimit: quantity.SizeGiB,
+		SubGroups:   []string{"snaps"},
+	}
+	snaps := &quota.Group{
+		Name:        "snaps",
+		ThreadLimit: 32,
+		ParentGroup: "top",
+		SubGroups:   []string{"svcs"},
+		Snaps:       []string{"test-snap"},
+	}
+	svcs := &quota.Group{
+		Name:        "svcs",
+		MemoryLimit: 512 * quantity.SizeMiB,
+		ParentGroup: "snaps",
+		Services:    []string{"test-snap.svc1"},
+	}
+	_, err = servicestatetest.PatchQuotas(st, top, snaps, svcs)
+	c.Assert(err, IsNil)
+
+	grps, err = servicestate.SnapQuotaGroups(st, "test-snap")
+	c.Assert(err, IsNil)
+	c.Check(grps, DeepEquals, []*quota.Group{snaps, top})
+
+	grps, err = servicestate.SnapQuotaGroups(st, "other-snap")
+	c.Assert(err, IsNil)
+	c.Check(grps, HasLen, 0)
+
+	// services in a service sub-group
+	grps, err = servicestate.ServiceQuotaGroups(st, "test-snap", "svc1")
+	c.Assert(err, IsNil)
+	c.Check(grps, DeepEquals, []*quota.Group{svcs, snaps, top})
+
+	// other services run in the group of the snap
+	grps, err = servicestate.ServiceQuotaGroups(st, "test-snap", "svc2")
+	c.Assert(err, IsNil)
+	c.Check(grps, DeepEquals, []*quota.Group{snaps, top})
+
+	grps, err = servicestate.ServiceQuotaGroups(st, "other-snap", "svc1")
+	c.Assert(err, IsNil)
+	c.Check(grps, HasLen, 0)
+}
@@ -0,0 +1,360 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/ifacestate/schema"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdenv"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+func hasServiceDependencies(snapInfo *snap.Info) bool {
+	for _, app := range snapInfo.Services() {
+		if len(app.ServiceDependencies) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// connectedSnaps returns the names of the snaps connected to each plug, keyed
+// by "<snap>:<plug>". Connections are read directly from the state, as the
+// interface repository is not available yet when security profiles are
+// regenerated on startup.
+func connectedSnaps(st *state.State) (map[string][]string, error) {
+	var conns map[string]*schema.ConnState
+	if err := st.Get("conns", &conns); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+
+	connected := make(map[string][]string)
+	for id, cstate := range conns {
+		if cstate.Undesired || cstate.HotplugGone {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		key := connRef.PlugRef.String()
+		connected[key] = append(connected[key], connRef.SlotRef.Snap)
+	}
+	for _, snaps := range connected {
+		sort.Strings(snaps)
+	}
+	return connected, nil
+}
+
+type serviceDependency struct {
+	app   *snap.AppInfo
+	wants bool
+}
+
+// serviceDependencyResolver resolves the dependencies of services on the
+// services of the snaps connected to their plugs.
+type serviceDependencyResolver struct {
+	st        *state.State
+	connected map[string][]string
+	infos     map[string]*snap.Info
+}
+
+func newServiceDependencyResolver(st *state.State, snapInfo *snap.Info) (*serviceDependencyResolver, error) {
+	connected, err := connectedSnaps(st)
+	if err != nil {
+		return nil, err
+	}
+	return &serviceDependencyResolver{
+		st:        st,
+		connected: connected,
+		// the snap being worked on is not necessarily the current one
+		infos: map[string]*snap.Info{snapInfo.InstanceName(): snapInfo},
+	}, nil
+}
+
+func (r *serviceDependencyResolver) snapInfo(instanceName string) (*snap.Info, error) {
+	if info, ok := r.infos[instanceName]; ok {
+		return info, nil
+	}
+	info, err := snapstate.CurrentInfo(r.st, instanceName)
+	if err != nil {
+		var notInstalled *snap.NotInstalledError
+		if !errors.As(err, &notInstalled) {
+			return nil, err
+		}
+		info = nil
+	}
+	r.infos[instanceName] = info
+	return info, nil
+}
+
+// dependencies returns the services of other snaps the given service depends
+// on, in a stable order.
+func (r *serviceDependencyResolver) dependencies(app *snap.AppInfo) ([]serviceDependency, error) {
+	plugNames := make([]string, 0, len(app.ServiceDependencies))
+	for plugName := range app.ServiceDependencies {
+		plugNames = append(plugNames, plugName)
+	}
+	sort.Strings(plugNames)
+
+	var deps []serviceDependency
+	for _, plugName := range plugNames {
+		dep := app.ServiceDependencies[plugName]
+		key := fmt.Sprintf("%s:%s", app.Snap.InstanceName(), plugName)
+		for _, slotSnap := range r.connected[key] {
+			// services of the same snap are ordered with before/after
+			if slotSnap == app.Snap.InstanceName() {
+				continue
+			}
+			info, err := r.snapInfo(slotSnap)
+			if err != nil {
+				return nil, err
+			}
+			if info == nil {
+				continue
+			}
+
+			names := dep.Services
+			if len(names) == 0 {
+				for _, other := range info.Services() {
+					if other.DaemonScope == app.DaemonScope {
+						names = append(names, other.Name)
+					}
+				}
+				sort.Strings(names)
+			}
+			for _, name := range names {
+				other := info.Apps[name]
+				if other == nil || !other.IsService() {
+					logger.Noticef("ignoring dependency of %s.%s on %s.%s: not a service", app.Snap.InstanceName(), app.Name, slotSnap, name)
+					continue
+				}
+				if other.DaemonScope != app.DaemonScope {
+					logger.Noticef("ignoring dependency of %s.%s on %s.%s: services have different daemon scopes", app.Snap.InstanceName(), app.Name, slotSnap, name)
+					continue
+				}
+				deps = append(deps, serviceDependency{app: other, wants: dep.Wants})
+			}
+		}
+	}
+	return deps, nil
+}
+
+// after returns all the services the given service is ordered after, either
+// within its snap or across snaps.
+func (r *serviceDependencyResolver) after(app *snap.AppInfo) ([]*snap.AppInfo, error) {
+	var after []*snap.AppInfo
+	for _, name := range app.After {
+		if other := app.Snap.Apps[name]; other != nil {
+			after = append(after, other)
+		}
+	}
+	for _, other := range sortedServices(app.Snap) {
+		for _, name := range other.Before {
+			if name == app.Name {
+				after = append(after, other)
+				break
+			}
+		}
+	}
+	deps, err := r.dependencies(app)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range deps {
+		after = append(after, dep.app)
+	}
+	return after, nil
+}
+
+// checkCycles verifies that the services of the given snap are not part of a
+// cycle of ordering dependencies spanning several snaps.
+func (r *serviceDependencyResolver) checkCycles(snapInfo *snap.Info) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	marks := make(map[*snap.AppInfo]int)
+	var path []*snap.AppInfo
+
+	var visit func(app *snap.AppInfo) error
+	visit = func(app *snap.AppInfo) error {
+		switch marks[app] {
+		case visited:
+			return nil
+		case visiting:
+			var names []string
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == app {
+					for _, p := range path[i:] {
+						names = append(names, fmt.Sprintf("%s.%s", p.Snap.InstanceName(), p.Name))
+					}
+					break
+				}
+			}
+			names = append(names, fmt.Sprintf("%s.%s", app.Snap.InstanceName(), app.Name))
+			return fmt.Errorf("cannot order services of snap %q: dependency cycle: %s", snapInfo.InstanceName(), strings.Join(names, " -> "))
+		}
+
+		marks[app] = visiting
+		path = append(path, app)
+		after, err := r.after(app)
+		if err != nil {
+			return err
+		}
+		for _, other := range after {
+			if err := visit(other); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[app] = visited
+		return nil
+	}
+
+	for _, app := range sortedServices(snapInfo) {
+		if err := visit(app); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedServices(snapInfo *snap.Info) []*snap.AppInfo {
+	services := snapInfo.Services()
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+// serviceDependencies computes the units of other snaps each service of the
+// given snap is ordered after and wants, based on the current connections of
+// the plugs referenced by its service-dependencies.
+func serviceDependencies(st *state.State, snapInfo *snap.Info) (map[string]*wrappers.ServiceDependencies, error) {
+	if !hasServiceDependencies(snapInfo) {
+		return nil, nil
+	}
+
+	r, err := newServiceDependencyResolver(st, snapInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkCycles(snapInfo); err != nil {
+		return nil, err
+	}
+
+	svcDeps := make(map[string]*wrappers.ServiceDependencies)
+	for _, app := range sortedServices(snapInfo) {
+		deps, err := r.dependencies(app)
+		if err != nil {
+			return nil, err
+		}
+		if len(deps) == 0 {
+			continue
+		}
+		seen := make(map[string]bool, len(deps))
+		unitDeps := &wrappers.ServiceDependencies{}
+		for _, dep := range deps {
+			unit := dep.app.ServiceName()
+			if !seen[unit] {
+				seen[unit] = true
+				unitDeps.After = append(unitDeps.After, unit)
+			}
+			if dep.wants && !strutil.ListContains(unitDeps.Wants, unit) {
+				unitDeps.Wants = append(unitDeps.Wants, unit)
+			}
+		}
+		svcDeps[app.Name] = unitDeps
+	}
+	if len(svcDeps) == 0 {
+		return nil, nil
+	}
+	return svcDeps, nil
+}
+
+// CheckConnectServiceDependencies verifies that connecting the given plug and
+// slot would not make the services of the plug snap part of a cycle of
+// ordering dependencies. It is meant to be called before the connection is
+// made, as the check considers it on top of the current connections.
+func CheckConnectServiceDependencies(st *state.State, connRef *interfaces.ConnRef) error {
+	snapInfo, err := snapstate.CurrentInfo(st, connRef.PlugRef.Snap)
+	if err != nil {
+		var notInstalled *snap.NotInstalledError
+		if errors.As(err, &notInstalled) {
+			return nil
+		}
+		return err
+	}
+	if !hasServiceDependencies(snapInfo) {
+		return nil
+	}
+
+	r, err := newServiceDependencyResolver(st, snapInfo)
+	if err != nil {
+		return err
+	}
+	key := connRef.PlugRef.String()
+	if !strutil.ListContains(r.connected[key], connRef.SlotRef.Snap) {
+		r.connected[key] = append(r.connected[key], connRef.SlotRef.Snap)
+		sort.Strings(r.connected[key])
+	}
+	return r.checkCycles(snapInfo)
+}
+
+// EnsureServiceDependencies regenerates the service units of the given snap
+// so that they follow the current connections of the plugs referenced by its
+// service-dependencies. It does nothing for snaps without such dependencies.
+func EnsureServiceDependencies(st *state.State, snapInfo *snap.Info) error {
+	if !hasServiceDependencies(snapInfo) {
+		return nil
+	}
+
+	opts, err := SnapServiceOptions(st, snapInfo, nil)
+	if err != nil {
+		return err
+	}
+
+	ensureOpts := &wrappers.EnsureSnapServicesOptions{
+		Preseeding: snapdenv.Preseeding(),
+	}
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return err
+	}
+	if !deviceCtx.Classic() && deviceCtx.Model().Base() != "" {
+		ensureOpts.RequireMountedSnapdSnap = true
+	}
+
+	return wrappers.EnsureSnapServices(map[*snap.Info]*wrappers.SnapServiceOptions{
+		snapInfo: opts,
+	}, ensureOpts, nil, progress.Null)
+}
@@ -0,0 +1,240 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/wrappers"
+)
+
+type serviceDependenciesSuite struct {
+	testutil.BaseTest
+	state *state.State
+}
+
+var _ = Suite(&serviceDependenciesSuite{})
+
+func (s *serviceDependenciesSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+	s.state = state.New(nil)
+}
+
+const webYaml = `name: web
+version: 1
+plugs:
+  db:
+    interface: content
+    content: db
+    target: $SNAP_DATA/db
+apps:
+  server:
+    daemon: simple
+    service-dependencies:
+      db:
+        services: [postgres, missing]
+        wants: true
+  worker:
+    daemon: simple
+    after: [server]
+    service-dependencies:
+      db: {}
+  cli:
+    command: bin/cli
+`
+
+const dbYaml = `name: db
+version: 1
+slots:
+  db:
+    interface: content
+    content: db
+    read: [$SNAP]
+plugs:
+  web:
+    interface: content
+    content: web
+    target: $SNAP_DATA/web
+apps:
+  postgres:
+    daemon: simple
+  backup:
+    daemon: oneshot
+  user-agent:
+    daemon: simple
+    daemon-scope: user
+  psql:
+    command: bin/psql
+`
+
+func (s *serviceDependenciesSuite) mockSnap(c *C, yaml string, rev int) *snap.Info {
+	si := &snap.SideInfo{RealName: snaptest.MockInfo(c, yaml, nil).SnapName(), Revision: snap.R(rev)}
+	info := snaptest.MockSnap(c, yaml, si)
+	snapstate.Set(s.state, info.InstanceName(), &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+		SnapType: "app",
+	})
+	return info
+}
+
+func (s *serviceDependenciesSuite) TestSnapServiceOptionsServiceDependencies(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	webInfo := s.mockSnap(c, webYaml, 1)
+	s.mockSnap(c, dbYaml, 2)
+
+	// not connected
+	opts, err := servicestate.SnapServiceOptions(st, webInfo, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &wrappers.SnapServiceOptions{})
+
+	st.Set("conns", map[string]interface{}{
+		"web:db db:db": map[string]interface{}{"interface": "content"},
+	})
+
+	opts, err = servicestate.SnapServiceOptions(st, webInfo, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &wrappers.SnapServiceOptions{
+		ServiceDependencies: map[string]*wrappers.ServiceDependencies{
+			"server": {
+				After: []string{"snap.db.postgres.service"},
+				Wants: []string{"snap.db.postgres.service"},
+			},
+			"worker": {
+				// all system services of the connected snap
+				After: []string{"snap.db.backup.service", "snap.db.postgres.service"},
+			},
+		},
+	})
+
+	// undesired connections are ignored
+	st.Set("conns", map[string]interface{}{
+		"web:db db:db": map[string]interface{}{"interface": "content", "undesired": true},
+	})
+	opts, err = servicestate.SnapServiceOptions(st, webInfo, nil)
+	c.Assert(err, IsNil)
+	c.Check(opts.ServiceDependencies, HasLen, 0)
+}
+
+func (s *serviceDependenciesSuite) TestSnapServiceOptionsServiceDependenciesCycle(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	webInfo := s.mockSnap(c, webYaml, 1)
+	dbInfo := s.mockSnap(c, strings.Replace(dbYaml, `
+  postgres:
+    daemon: simple
+`, `
+  postgres:
+    daemon: simple
+    service-dependencies:
+      web:
+        services: [worker]
+`, 1), 2)
+
+	st.Set("conns", map[string]interface{}{
+		"web:db db:db": map[string]interface{}{"interface": "content"},
+	})
+
+	// no cycle as long as db is not connected to web
+	_, err := servicestate.SnapServiceOptions(st, dbInfo, nil)
+	c.Assert(err, IsNil)
+
+	st.Set("conns", map[string]interface{}{
+		"web:db db:db":   map[string]interface{}{"interface": "content"},
+		"db:web web:web": map[string]interface{}{"interface": "content"},
+	})
+
+	_, err = servicestate.SnapServiceOptions(st, webInfo, nil)
+	c.Check(err, ErrorMatches, `cannot order services of snap "web": dependency cycle: web.server -> db.postgres -> web.worker -> web.server`)
+	_, err = servicestate.SnapServiceOptions(st, dbInfo, nil)
+	c.Check(err, ErrorMatches, `cannot order services of snap "db": dependency cycle: db.postgres -> web.worker -> web.server -> db.postgres`)
+}
+
+func (s *serviceDependenciesSuite) TestCheckConnectServiceDependencies(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockSnap(c, webYaml, 1)
+	s.mockSnap(c, strings.Replace(dbYaml, `
+  postgres:
+    daemon: simple
+`, `
+  postgres:
+    daemon: simple
+    service-dependencies:
+      web:
+        services: [worker]
+`, 1), 2)
+
+	st.Set("conns", map[string]interface{}{
+		"web:db db:db": map[string]interface{}{"interface": "content"},
+	})
+
+	webDB := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "web", Name: "db"},
+		SlotRef: interfaces.SlotRef{Snap: "db", Name: "db"},
+	}
+	dbWeb := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "db", Name: "web"},
+		SlotRef: interfaces.SlotRef{Snap: "web", Name: "web"},
+	}
+
+	// already connected
+	c.Check(servicestate.CheckConnectServiceDependencies(st, webDB), IsNil)
+
+	// connecting back would create a cycle
+	err := servicestate.CheckConnectServiceDependencies(st, dbWeb)
+	c.Check(err, ErrorMatches, `cannot order services of snap "db": dependency cycle: db.postgres -> web.worker -> web.server -> db.postgres`)
+
+	// the state was not changed by the check
+	var conns map[string]interface{}
+	c.Assert(st.Get("conns", &conns), IsNil)
+	c.Check(conns, HasLen, 1)
+
+	// without the first connection there is no cycle
+	st.Set("conns", nil)
+	c.Check(servicestate.CheckConnectServiceDependencies(st, dbWeb), IsNil)
+
+	// snaps that are not installed are ignored
+	c.Check(servicestate.CheckConnectServiceDependencies(st, &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "missing", Name: "db"},
+		SlotRef: interfaces.SlotRef{Snap: "db", Name: "db"},
+	}), IsNil)
+}
@@ -0,0 +1,189 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var (
+	timeNow = time.Now
+
+	// how often the snap services are checked for failures
+	serviceFailureCheckInterval = 5 * time.Minute
+	// number of automatic restarts of a service since it was last started
+	// above which it is considered to be crash-looping
+	serviceRestartThreshold = 5
+)
+
+// serviceFailureState is what was last observed of the failures of a service.
+type serviceFailureState struct {
+	Failed   bool `json:"failed,omitempty"`
+	Restarts int  `json:"restarts,omitempty"`
+}
+
+// serviceFailures is what was last observed of the failures of the snap
+// services during the given boot, by <snap>.<app> name. It is kept in the
+// state so that failures are not notified again when snapd restarts. The
+// restart counts tracked by systemd start over whenever a service is started,
+// so the observations of a previous boot do not apply.
+type serviceFailures struct {
+	BootID   string                         `json:"boot-id"`
+	Services map[string]serviceFailureState `json:"services,omitempty"`
+}
+
+// ensureServiceFailureNotices periodically checks the system services of
+// the snaps, and records a notice when one of them entered the failed state
+// or was restarted by systemd more often than the restart threshold since the
+// previous check.
+func (m *ServiceManager) ensureServiceFailureNotices() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	now := timeNow()
+	if now.Sub(m.lastServiceFailureCheck) < serviceFailureCheckInterval {
+		return nil
+	}
+	m.lastServiceFailureCheck = now
+
+	var seeded bool
+	err := m.state.Get("seeded", &seeded)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	allStates, err := snapstate.All(m.state)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	var apps []*snap.AppInfo
+	for name, snapst := range allStates {
+		if !snapst.Active {
+			continue
+		}
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			logger.Noticef("cannot check services of snap %q for failures: %v", name, err)
+			continue
+		}
+		for _, app := range info.Services() {
+			if app.DaemonScope != snap.SystemDaemon {
+				continue
+			}
+			apps = append(apps, app)
+		}
+	}
+	if len(apps) == 0 {
+		return nil
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].ServiceName() < apps[j].ServiceName()
+	})
+	serviceNames := make([]string, 0, len(apps))
+	for _, app := range apps {
+		serviceNames = append(serviceNames, app.ServiceName())
+	}
+
+	bootID, err := osutilBootID()
+	if err != nil {
+		return err
+	}
+
+	// do not hold the state lock while waiting for systemd
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+	m.state.Unlock()
+	allStats, err := sysd.ServiceRestartStats(serviceNames)
+	m.state.Lock()
+	if err != nil {
+		// try again on the next check
+		logger.Noticef("cannot check snap services for failures: %v", err)
+		return nil
+	}
+
+	var prev serviceFailures
+	if err := m.state.Get("service-failures", &prev); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if prev.BootID != bootID {
+		// the failures observed during a previous boot are gone
+		prev.Services = nil
+	}
+
+	observed := serviceFailures{
+		BootID:   bootID,
+		Services: make(map[string]serviceFailureState, len(apps)),
+	}
+	for i, app := range apps {
+		key := snap.JoinSnapApp(app.Snap.InstanceName(), app.Name)
+		stats := allStats[i]
+		observed.Services[key] = serviceFailureState{
+			Failed:   stats.Failed,
+			Restarts: stats.Restarts,
+		}
+		if err := m.recordServiceFailure(key, prev.Services[key], stats); err != nil {
+			return err
+		}
+	}
+	m.state.Set("service-failures", observed)
+	return nil
+}
+
+func (m *ServiceManager) recordServiceFailure(key string, prev serviceFailureState, stats *systemd.ServiceRestartStats) error {
+	var reason string
+	switch {
+	case stats.Failed && !prev.Failed:
+		reason = "failed"
+	case stats.Restarts > serviceRestartThreshold && prev.Restarts <= serviceRestartThreshold:
+		reason = "restart-threshold"
+	default:
+		return nil
+	}
+
+	data := map[string]string{
+		"reason":   reason,
+		"restarts": strconv.Itoa(stats.Restarts),
+	}
+	if stats.ExitSignal != "" {
+		data["exit-signal"] = stats.ExitSignal
+	} else if !stats.ExitTime.IsZero() {
+		data["exit-code"] = strconv.Itoa(stats.ExitCode)
+	}
+	if !stats.ExitTime.IsZero() {
+		data["exit-time"] = stats.ExitTime.Format(time.RFC3339)
+	}
+	_, err := m.state.AddNotice(nil, state.SnapServiceFailureNotice, key, &state.AddNoticeOptions{
+		Data: data,
+	})
+	return err
+}
@@ -0,0 +1,294 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type serviceFailuresSuite struct {
+	testutil.BaseTest
+	state      *state.State
+	serviceMgr *servicestate.ServiceManager
+
+	now   time.Time
+	stats map[string]string
+	calls [][]string
+}
+
+var _ = Suite(&serviceFailuresSuite{})
+
+const failuresSnapYaml = `name: test-snap
+version: 1.0
+apps:
+  svc1:
+    daemon: simple
+  svc2:
+    daemon: simple
+  user-svc:
+    daemon: simple
+    daemon-scope: user
+  app:
+`
+
+func (s *serviceFailuresSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.now = time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(servicestate.MockTimeNow(func() time.Time { return s.now }))
+	s.AddCleanup(servicestate.MockServiceRestartThreshold(3))
+
+	s.AddCleanup(servicestate.MockOsutilBootID("boot-id-1"))
+
+	s.stats = make(map[string]string)
+	s.calls = nil
+	s.AddCleanup(systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		s.calls = append(s.calls, args)
+		if len(args) < 3 || args[0] != "show" || args[1] != "--property=ActiveState,NRestarts,ExecMainCode,ExecMainStatus,ExecMainExitTimestamp" {
+			return nil, fmt.Errorf("unexpected systemctl call: %v", args)
+		}
+		var outs []string
+		for _, unit := range args[2:] {
+			out, ok := s.stats[unit]
+			if !ok {
+				out = "ActiveState=active\nNRestarts=0\nExecMainCode=0\nExecMainStatus=0\nExecMainExitTimestamp=\n"
+			}
+			outs = append(outs, out)
+		}
+		return []byte(strings.Join(outs, "\n")), nil
+	}))
+
+	o := overlord.Mock()
+	s.state = o.State()
+	s.serviceMgr = servicestate.Manager(s.state, o.TaskRunner())
+	s.AddCleanup(servicestate.MockEnsuredSnapServices(s.serviceMgr, true))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+	si := &snap.SideInfo{RealName: "test-snap", Revision: snap.R(1)}
+	snaptest.MockSnapCurrent(c, failuresSnapYaml, si)
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+		SnapType: "app",
+	})
+}
+
+func (s *serviceFailuresSuite) ensureAfter(c *C, d time.Duration) {
+	s.now = s.now.Add(d)
+	c.Assert(s.serviceMgr.Ensure(), IsNil)
+}
+
+func (s *serviceFailuresSuite) failureNotices() []*state.Notice {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapServiceFailureNotice}})
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesInterval(c *C) {
+	// the services are left time to start
+	s.ensureAfter(c, time.Minute)
+	c.Check(s.calls, HasLen, 0)
+
+	// only the system services are checked, at once
+	s.ensureAfter(c, 5*time.Minute)
+	c.Check(s.calls, DeepEquals, [][]string{
+		{"show", "--property=ActiveState,NRestarts,ExecMainCode,ExecMainStatus,ExecMainExitTimestamp", "snap.test-snap.svc1.service", "snap.test-snap.svc2.service"},
+	})
+	c.Check(s.failureNotices(), HasLen, 0)
+
+	// and not again until the next interval
+	s.calls = nil
+	s.ensureAfter(c, time.Minute)
+	c.Check(s.calls, HasLen, 0)
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesNotSeeded(c *C) {
+	s.state.Lock()
+	s.state.Set("seeded", false)
+	s.state.Unlock()
+
+	s.ensureAfter(c, 10*time.Minute)
+	c.Check(s.calls, HasLen, 0)
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesFailed(c *C) {
+	s.stats["snap.test-snap.svc1.service"] = `ActiveState=failed
+NRestarts=1
+ExecMainCode=1
+ExecMainStatus=3
+ExecMainExitTimestamp=Mon 2026-10-19 10:02:00 UTC
+`
+	s.ensureAfter(c, 5*time.Minute)
+
+	notices := s.failureNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "test-snap.svc1")
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"reason":    "failed",
+		"restarts":  "1",
+		"exit-code": "3",
+		"exit-time": "2026-10-19T10:02:00Z",
+	})
+
+	// the notice is not repeated while the service stays failed
+	s.ensureAfter(c, 5*time.Minute)
+	notices = s.failureNotices()
+	c.Assert(notices, HasLen, 1)
+	n, err := notices[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":1`)
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesRestartThreshold(c *C) {
+	crashing := func(restarts int) string {
+		return fmt.Sprintf(`ActiveState=activating
+NRestarts=%d
+ExecMainCode=2
+ExecMainStatus=11
+ExecMainExitTimestamp=Mon 2026-10-19 10:02:00 UTC
+`, restarts)
+	}
+
+	// below the threshold
+	s.stats["snap.test-snap.svc2.service"] = crashing(3)
+	s.ensureAfter(c, 5*time.Minute)
+	c.Check(s.failureNotices(), HasLen, 0)
+
+	// above the threshold
+	s.stats["snap.test-snap.svc2.service"] = crashing(4)
+	s.ensureAfter(c, 5*time.Minute)
+	notices := s.failureNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "test-snap.svc2")
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"reason":      "restart-threshold",
+		"restarts":    "4",
+		"exit-signal": "SIGSEGV",
+		"exit-time":   "2026-10-19T10:02:00Z",
+	})
+
+	// the notice is only recorded when the threshold is crossed
+	s.stats["snap.test-snap.svc2.service"] = crashing(10)
+	s.ensureAfter(c, 5*time.Minute)
+	n, err := s.failureNotices()[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":1`)
+
+	// which happens again once the service was restarted manually
+	s.stats["snap.test-snap.svc2.service"] = crashing(0)
+	s.ensureAfter(c, 5*time.Minute)
+	s.stats["snap.test-snap.svc2.service"] = crashing(5)
+	s.ensureAfter(c, 5*time.Minute)
+	n, err = s.failureNotices()[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":2`)
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesStatsError(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	s.stats["snap.test-snap.svc1.service"] = "garbage\n"
+	s.stats["snap.test-snap.svc2.service"] = "ActiveState=failed\n"
+
+	s.ensureAfter(c, 5*time.Minute)
+	c.Check(s.failureNotices(), HasLen, 0)
+	c.Check(logbuf.String(), testutil.Contains, `cannot check snap services for failures: cannot get restart statistics of service "snap.test-snap.svc1.service": bad line "garbage"`)
+
+	// the services are checked again on the next interval
+	delete(s.stats, "snap.test-snap.svc1.service")
+	s.ensureAfter(c, 5*time.Minute)
+	notices := s.failureNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "test-snap.svc2")
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"reason":   "failed",
+		"restarts": "0",
+	})
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesStateUnlocked(c *C) {
+	s.AddCleanup(systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		// the state is not locked while waiting for systemd
+		s.state.Lock()
+		s.state.Unlock()
+		return []byte("ActiveState=active\n\nActiveState=failed\n"), nil
+	}))
+
+	s.ensureAfter(c, 5*time.Minute)
+	notices := s.failureNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "test-snap.svc2")
+}
+
+func (s *serviceFailuresSuite) TestEnsureServiceFailureNoticesSnapdRestart(c *C) {
+	s.stats["snap.test-snap.svc1.service"] = "ActiveState=failed\nNRestarts=4\n"
+	s.ensureAfter(c, 5*time.Minute)
+	c.Assert(s.failureNotices(), HasLen, 1)
+
+	s.state.Lock()
+	var observed map[string]interface{}
+	c.Assert(s.state.Get("service-failures", &observed), IsNil)
+	s.state.Unlock()
+	c.Check(observed, DeepEquals, map[string]interface{}{
+		"boot-id": "boot-id-1",
+		"services": map[string]interface{}{
+			"test-snap.svc1": map[string]interface{}{"failed": true, "restarts": 4.0},
+			"test-snap.svc2": map[string]interface{}{},
+		},
+	})
+
+	// a new manager, as after a restart of snapd, does not notify again
+	s.serviceMgr = servicestate.Manager(s.state, state.NewTaskRunner(s.state))
+	s.AddCleanup(servicestate.MockEnsuredSnapServices(s.serviceMgr, true))
+	s.ensureAfter(c, 5*time.Minute)
+	n, err := s.failureNotices()[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":1`)
+
+	// while a failure after a reboot is notified again
+	s.AddCleanup(servicestate.MockOsutilBootID("boot-id-2"))
+	s.ensureAfter(c, 5*time.Minute)
+	n, err = s.failureNotices()[0].MarshalJSON()
+	c.Assert(err, IsNil)
+	c.Check(string(n), testutil.Contains, `"occurrences":2`)
+}
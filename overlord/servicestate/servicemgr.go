@@ -48,13 +48,23 @@ type ServiceManager struct {
 	state *state.State
 
 	ensuredSnapSvcs bool
+	// lingeringUsersStarted tracks the lingering users whose snap user
+	// services were started since snapd started.
+	lingeringUsersStarted map[string]bool
+
+	// lastServiceFailureCheck is when the snap services were last checked
+	// for failures.
+	lastServiceFailureCheck time.Time
 }
 
 // Manager returns a new service manager.
 func Manager(st *state.State, runner *state.TaskRunner) *ServiceManager {
 	delayedCrossMgrInit()
 	m := &ServiceManager{
-		state: st,
+		state:                 st,
+		lingeringUsersStarted: make(map[string]bool),
+		// leave the services time to start before checking them
+		lastServiceFailureCheck: timeNow(),
 	}
 	// TODO: undo handler
 	runner.AddHandler("service-control", m.doServiceControl, nil)
@@ -69,7 +79,10 @@ func Manager(st *state.State, runner *state.TaskRunner) *ServiceManager {
 	// with the correct setup. This task also supports proper handling of
 	// failure during install and correctly removes the snap again.
 	runner.AddHandler("quota-add-snap", m.doQuotaAddSnap, m.undoQuotaAddSnap)
+	runner.AddHandler("set-lingering", m.doSetLingering, m.undoSetLingering)
 	RegisterAffectedQuotasByKind("quota-add-snap", affectedQuotasForQuotaAddSnap)
+	runner.AddHandler("quota-auto-create", m.doQuotaAutoCreate, m.undoQuotaAutoCreate)
+	RegisterAffectedQuotasByKind("quota-auto-create", affectedQuotasForQuotaAutoCreate)
 	// quota-add-snap uses snap-setup and because of this retrieving the snap
 	// that is being added is implicitly already supported by snapstate/conflict.go
 
@@ -204,6 +217,12 @@ func (m *ServiceManager) Ensure() error {
 	if err := m.ensureSnapServicesUpdated(); err != nil {
 		return err
 	}
+	if err := m.ensureLingeringUserServices(); err != nil {
+		return err
+	}
+	if err := m.ensureServiceFailureNotices(); err != nil {
+		return err
+	}
 	return nil
 }
 
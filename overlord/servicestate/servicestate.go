@@ -474,7 +474,7 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 	}
 
 	// collect all services for a single call to systemctl
-	extra := len(snapApp.Sockets)
+	extra := len(snapApp.Sockets) + len(snapApp.ActivatesOnPath)
 	if snapApp.Timer != nil {
 		extra++
 	}
@@ -487,6 +487,12 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 		sockSvcFileToName[sockUnit] = sock.Name
 		serviceNames = append(serviceNames, sockUnit)
 	}
+	pathSvcFileToName := make(map[string]string, len(snapApp.ActivatesOnPath))
+	for _, path := range snapApp.ActivatesOnPath {
+		pathUnit := filepath.Base(path.File())
+		pathSvcFileToName[pathUnit] = path.Name
+		serviceNames = append(serviceNames, pathUnit)
+	}
 	if snapApp.Timer != nil {
 		timerUnit := filepath.Base(snapApp.Timer.File())
 		serviceNames = append(serviceNames, timerUnit)
@@ -516,6 +522,13 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 				Active:  st.Active,
 				Type:    "socket",
 			})
+		case ".path":
+			appInfo.Activators = append(appInfo.Activators, client.AppActivator{
+				Name:    pathSvcFileToName[st.Name],
+				Enabled: st.Enabled,
+				Active:  st.Active,
+				Type:    "path",
+			})
 		}
 	}
 	// Decorate with D-Bus names that activate this service
@@ -583,21 +596,38 @@ func SnapServiceOptions(st *state.State, snapInfo *snap.Info, quotaGroups map[st
 		}
 	}
 
+	// and for dependencies on services of connected snaps
+	opts.ServiceDependencies, err = serviceDependencies(st, snapInfo)
+	if err != nil {
+		return nil, err
+	}
+
 	return opts, nil
 }
 
 // LogReader returns an io.ReadCloser which produce logs for the provided
 // snap AppInfo's. It is a convenience wrapper around the systemd.LogReader
-// implementation.
-func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, error) {
-	serviceNames := make([]string, len(appInfos))
-	for i, appInfo := range appInfos {
+// implementation. The logs of user daemons are read from the journals of all
+// users. The optional filter restricts the returned entries.
+func LogReader(appInfos []*snap.AppInfo, n int, follow bool, filter *systemd.LogFilter) (io.ReadCloser, error) {
+	var serviceNames, userServiceNames []string
+	for _, appInfo := range appInfos {
 		if !appInfo.IsService() {
 			return nil, fmt.Errorf("cannot read logs for app %q: not a service", appInfo.Name)
 		}
-		serviceNames[i] = appInfo.ServiceName()
+		if appInfo.DaemonScope == snap.UserDaemon {
+			userServiceNames = append(userServiceNames, appInfo.ServiceName())
+		} else {
+			serviceNames = append(serviceNames, appInfo.ServiceName())
+		}
 	}
 
+	var f systemd.LogFilter
+	if filter != nil {
+		f = *filter
+	}
+	f.UserServices = userServiceNames
+
 	// Include journal namespaces if supported. The --namespace option was
 	// introduced in systemd version 245. If systemd is older than that then
 	// we cannot use journal quotas in any case and don't include them.
@@ -609,5 +639,53 @@ func LogReader(appInfos []*snap.AppInfo, n int, follow bool) (io.ReadCloser, err
 	}
 
 	sysd := systemd.New(systemd.SystemMode, progress.Null)
-	return sysd.LogReader(serviceNames, n, follow, includeNamespaces)
+	return sysd.LogReader(serviceNames, n, follow, includeNamespaces, &f)
+}
+
+// DecorateWithRestartStats adds the restart statistics tracked by systemd to
+// the given client.AppInfos associated with the given snap.AppInfos, systemd is
+// queried once for all of them. Apps of inactive snaps and apps which are not
+// system services are left alone, as the statistics of user services are not
+// tracked by the system instance of systemd.
+func DecorateWithRestartStats(appInfos []client.AppInfo, snapApps []*snap.AppInfo) error {
+	if len(appInfos) != len(snapApps) {
+		return fmt.Errorf("internal error: cannot decorate %d app infos with the restart statistics of %d apps", len(appInfos), len(snapApps))
+	}
+	var decorated []*client.AppInfo
+	var serviceNames []string
+	for i, snapApp := range snapApps {
+		appInfo := &appInfos[i]
+		if appInfo.Snap != snapApp.Snap.InstanceName() || appInfo.Name != snapApp.Name {
+			return fmt.Errorf("internal error: misassociated app info %v and client app info %s.%s", snapApp, appInfo.Snap, appInfo.Name)
+		}
+		if !snapApp.Snap.IsActive() || !snapApp.IsService() || snapApp.DaemonScope != snap.SystemDaemon {
+			continue
+		}
+		decorated = append(decorated, appInfo)
+		serviceNames = append(serviceNames, snapApp.ServiceName())
+	}
+	if len(serviceNames) == 0 {
+		// nothing to do
+		return nil
+	}
+
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+	allStats, err := sysd.ServiceRestartStats(serviceNames)
+	if err != nil {
+		return fmt.Errorf("cannot get restart statistics of services: %v", err)
+	}
+	for i, appInfo := range decorated {
+		stats := allStats[i]
+		appInfo.RestartStats = &client.AppRestartStats{
+			Failed:     stats.Failed,
+			Restarts:   stats.Restarts,
+			ExitCode:   stats.ExitCode,
+			ExitSignal: stats.ExitSignal,
+		}
+		if !stats.ExitTime.IsZero() {
+			exitTime := stats.ExitTime
+			appInfo.RestartStats.ExitTime = &exitTime
+		}
+	}
+	return nil
 }
@@ -28,6 +28,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -103,7 +104,7 @@ func (s *statusDecoratorSuite) TestDecorateWithStatus(c *C) {
 				activeState = "inactive"
 				unitState = "disabled"
 			}
-			if strings.HasSuffix(unit, ".timer") || strings.HasSuffix(unit, ".socket") || strings.HasSuffix(unit, ".target") {
+			if strings.HasSuffix(unit, ".timer") || strings.HasSuffix(unit, ".socket") || strings.HasSuffix(unit, ".target") || strings.HasSuffix(unit, ".path") {
 				// Units using the baseProperties query
 				return []byte(fmt.Sprintf(`Id=%s
 Names=%[1]s
@@ -221,6 +222,35 @@ NeedDaemonReload=no
 			{Name: "socket1", Type: "socket", Active: enabled, Enabled: enabled},
 		})
 
+		// service with path
+		app = &client.AppInfo{
+			Snap:   snp.InstanceName(),
+			Name:   "svc",
+			Daemon: "simple",
+		}
+		snapApp = &snap.AppInfo{
+			Snap:        snp,
+			Name:        "svc",
+			Daemon:      "simple",
+			DaemonScope: snap.SystemDaemon,
+		}
+		snapApp.ActivatesOnPath = map[string]*snap.PathInfo{
+			"spool": {
+				App:       snapApp,
+				Name:      "spool",
+				Condition: snap.DirectoryNotEmpty,
+				Path:      "$SNAP_COMMON/spool",
+			},
+		}
+
+		err = sd.DecorateWithStatus(app, snapApp)
+		c.Assert(err, IsNil)
+		c.Check(app.Active, Equals, enabled)
+		c.Check(app.Enabled, Equals, enabled)
+		c.Check(app.Activators, DeepEquals, []client.AppActivator{
+			{Name: "spool", Type: "path", Active: enabled, Enabled: enabled},
+		})
+
 		// service with slot activation will always be enabled as we cannot
 		// disable/enable slot activation at the moment.
 		app = &client.AppInfo{
@@ -410,6 +440,91 @@ NeedDaemonReload=no
 	}
 }
 
+func (s *statusDecoratorSuite) TestDecorateWithRestartStats(c *C) {
+	snp := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(1),
+		},
+	}
+	c.Assert(os.MkdirAll(snp.MountDir(), 0755), IsNil)
+	inactive := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "bar",
+			Revision: snap.R(1),
+		},
+	}
+
+	var calls [][]string
+	r := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		calls = append(calls, args)
+		return []byte(`ActiveState=failed
+NRestarts=3
+ExecMainCode=2
+ExecMainStatus=9
+ExecMainExitTimestamp=Mon 2026-10-19 10:02:00 UTC
+
+ActiveState=active
+NRestarts=0
+ExecMainCode=0
+ExecMainStatus=0
+ExecMainExitTimestamp=
+`), nil
+	})
+	defer r()
+
+	apps := []client.AppInfo{
+		{Snap: "foo", Name: "app"},
+		{Snap: "foo", Name: "svc", Daemon: "simple"},
+		{Snap: "foo", Name: "user-svc", Daemon: "simple"},
+		{Snap: "foo", Name: "other-svc", Daemon: "simple"},
+		{Snap: "bar", Name: "svc", Daemon: "simple"},
+	}
+	snapApps := []*snap.AppInfo{
+		// not a service
+		{Snap: snp, Name: "app"},
+		{Snap: snp, Name: "svc", Daemon: "simple", DaemonScope: snap.SystemDaemon},
+		// a user service
+		{Snap: snp, Name: "user-svc", Daemon: "simple", DaemonScope: snap.UserDaemon},
+		{Snap: snp, Name: "other-svc", Daemon: "simple", DaemonScope: snap.SystemDaemon},
+		// a system service of an inactive snap
+		{Snap: inactive, Name: "svc", Daemon: "simple", DaemonScope: snap.SystemDaemon},
+	}
+
+	// the snap is inactive, there is nothing to do
+	c.Assert(servicestate.DecorateWithRestartStats(apps, snapApps), IsNil)
+	for _, app := range apps {
+		c.Check(app.RestartStats, IsNil)
+	}
+	c.Check(calls, HasLen, 0)
+
+	// systemd is asked once about the system services of the active snap
+	c.Assert(os.Symlink(snp.Revision.String(), filepath.Join(filepath.Dir(snp.MountDir()), "current")), IsNil)
+	c.Assert(servicestate.DecorateWithRestartStats(apps, snapApps), IsNil)
+	c.Check(calls, DeepEquals, [][]string{
+		{"show", "--property=ActiveState,NRestarts,ExecMainCode,ExecMainStatus,ExecMainExitTimestamp", "snap.foo.svc.service", "snap.foo.other-svc.service"},
+	})
+	exitTime := time.Date(2026, time.October, 19, 10, 2, 0, 0, time.UTC)
+	c.Check(apps[0].RestartStats, IsNil)
+	c.Check(apps[1].RestartStats, DeepEquals, &client.AppRestartStats{
+		Failed:     true,
+		Restarts:   3,
+		ExitSignal: "SIGKILL",
+		ExitTime:   &exitTime,
+	})
+	c.Check(apps[2].RestartStats, IsNil)
+	c.Check(apps[3].RestartStats, DeepEquals, &client.AppRestartStats{})
+	c.Check(apps[4].RestartStats, IsNil)
+
+	// misassociated app info
+	apps[1].Name = "other"
+	err := servicestate.DecorateWithRestartStats(apps, snapApps)
+	c.Check(err, ErrorMatches, `internal error: misassociated app info .*`)
+
+	err = servicestate.DecorateWithRestartStats(apps[:1], snapApps)
+	c.Check(err, ErrorMatches, `internal error: cannot decorate 1 app infos with the restart statistics of 5 apps`)
+}
+
 type instructionSuite struct {
 	rootUser       *user.User
 	defaultUser    *user.User
@@ -934,7 +1049,7 @@ func (s *snapServiceOptionsSuite) TestLogReader(c *C) {
 			Snap:        snp,
 			Name:        "svc1",
 			Daemon:      "simple",
-			DaemonScope: snap.UserDaemon,
+			DaemonScope: snap.SystemDaemon,
 		},
 		{
 			Snap:        snp,
@@ -948,19 +1063,26 @@ func (s *snapServiceOptionsSuite) TestLogReader(c *C) {
 	defer restore()
 
 	var jctlCalls int
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 		jctlCalls++
-		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
+		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service"})
 		c.Check(n, Equals, 100)
 		c.Check(follow, Equals, false)
 		c.Check(namespaces, Equals, false)
+		c.Check(filter, DeepEquals, &systemd.LogFilter{
+			Priority:     "err",
+			UserServices: []string{"snap.foo.svc2.service"},
+		})
 		return io.NopCloser(strings.NewReader("")), nil
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	filter := &systemd.LogFilter{Priority: "err"}
+	_, err := servicestate.LogReader(appInfos, 100, false, filter)
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
+	// the given filter is left untouched
+	c.Check(filter.UserServices, IsNil)
 }
 
 func (s *snapServiceOptionsSuite) TestLogReaderFailsWithNonServices(c *C) {
@@ -990,7 +1112,7 @@ func (s *snapServiceOptionsSuite) TestLogReaderFailsWithNonServices(c *C) {
 		},
 	}
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, 100, false, nil)
 	c.Assert(err.Error(), Equals, `cannot read logs for app "app1": not a service`)
 }
 
@@ -1026,9 +1148,10 @@ func (s *snapServiceOptionsSuite) TestLogReaderNamespaces(c *C) {
 
 	restore := systemd.MockSystemdVersion(245, nil)
 	defer restore()
-	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool) (rc io.ReadCloser, err error) {
+	restore = systemd.MockJournalctl(func(svcs []string, n int, follow, namespaces bool, filter *systemd.LogFilter) (rc io.ReadCloser, err error) {
 		jctlCalls++
-		c.Check(svcs, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
+		c.Check(svcs, IsNil)
+		c.Check(filter.UserServices, DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
 		c.Check(n, Equals, 100)
 		c.Check(follow, Equals, false)
 		c.Check(namespaces, Equals, true)
@@ -1036,7 +1159,7 @@ func (s *snapServiceOptionsSuite) TestLogReaderNamespaces(c *C) {
 	})
 	defer restore()
 
-	_, err := servicestate.LogReader(appInfos, 100, false)
+	_, err := servicestate.LogReader(appInfos, 100, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(jctlCalls, Equals, 1)
 }
@@ -48,6 +48,8 @@ var (
 	SaveExpiration             = saveExpiration
 	ExpiredSnapshotSets        = expiredSnapshotSets
 	RemoveSnapshotState        = removeSnapshotState
+	MarkSnapInitiated          = markSnapInitiated
+	ResetExpiration            = resetExpiration
 
 	SetSnapshotOpInProgress = setSnapshotOpInProgress
 
@@ -215,3 +217,11 @@ func MockGetSnapDirOptions(f func(*state.State, string) (*dirs.SnapDirOptions, e
 		getSnapDirOpts = old
 	}
 }
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
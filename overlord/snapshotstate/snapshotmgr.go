@@ -170,6 +170,8 @@ type snapshotSetup struct {
 	Filename string                `json:"filename,omitempty"`
 	Current  snap.Revision         `json:"current"`
 	Auto     bool                  `json:"auto,omitempty"`
+	// SnapInitiated is set if the snap requested the snapshot itself
+	SnapInitiated bool `json:"snap-initiated,omitempty"`
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -215,6 +217,11 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 			return nil, nil, nil, err
 		}
 	}
+	if snapshot.SnapInitiated {
+		if err := markSnapInitiated(st, snapshot.SetID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	return snapshot, cur, cfg, nil
 }
@@ -466,6 +473,7 @@ func delayedCrossMgrInit() {
 	snapstate.AutomaticSnapshot = AutomaticSnapshot
 	snapstate.AutomaticSnapshotExpiration = AutomaticSnapshotExpiration
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
+	snapstate.PruneSnapInitiatedSnapshots = PruneSnapInitiatedSnapshots
 }
 
 func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions) (*client.Snapshot, error)) (restore func()) {
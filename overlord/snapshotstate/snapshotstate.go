@@ -49,10 +49,16 @@ var (
 
 	// Default expiration time for automatic snapshots, if not set by the user
 	defaultAutomaticSnapshotExpiration = time.Hour * 24 * 31
+
+	// Minimum time between two snapshots requested by the same snap
+	snapInitiatedSnapshotInterval = 10 * time.Minute
+
+	timeNow = time.Now
 )
 
 type snapshotState struct {
-	ExpiryTime time.Time `json:"expiry-time"`
+	ExpiryTime    time.Time `json:"expiry-time"`
+	SnapInitiated bool      `json:"snap-initiated,omitempty"`
 }
 
 func newSnapshotSetID(st *state.State) (uint64, error) {
@@ -151,9 +157,9 @@ func AutomaticSnapshotExpiration(st *state.State) (time.Duration, error) {
 	return defaultAutomaticSnapshotExpiration, nil
 }
 
-// saveExpiration saves expiration date of the given snapshot set, in the state.
-// The state needs to be locked by the caller.
-func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
+// updateSnapshotState updates the record of the given snapshot set in the
+// state. The state needs to be locked by the caller.
+func updateSnapshotState(st *state.State, setID uint64, update func(sst *snapshotState)) error {
 	var snapshots map[uint64]*json.RawMessage
 	err := st.Get("snapshots", &snapshots)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
@@ -162,9 +168,14 @@ func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
 	if snapshots == nil {
 		snapshots = make(map[uint64]*json.RawMessage)
 	}
-	data, err := json.Marshal(&snapshotState{
-		ExpiryTime: expiryTime,
-	})
+	var sst snapshotState
+	if raw := snapshots[setID]; raw != nil {
+		if err := json.Unmarshal(*raw, &sst); err != nil {
+			return err
+		}
+	}
+	update(&sst)
+	data, err := json.Marshal(&sst)
 	if err != nil {
 		return err
 	}
@@ -174,6 +185,39 @@ func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
 	return nil
 }
 
+// saveExpiration saves expiration date of the given snapshot set, in the state.
+// The state needs to be locked by the caller.
+func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
+	return updateSnapshotState(st, setID, func(sst *snapshotState) {
+		sst.ExpiryTime = expiryTime
+	})
+}
+
+// markSnapInitiated marks the given snapshot set as requested by the snap
+// itself, in the state. The state needs to be locked by the caller.
+func markSnapInitiated(st *state.State, setID uint64) error {
+	return updateSnapshotState(st, setID, func(sst *snapshotState) {
+		sst.SnapInitiated = true
+	})
+}
+
+// resetExpiration resets the expiration date of the given snapshot set,
+// removing its record from the state if nothing else is kept for it.
+// The state needs to be locked by the caller.
+func resetExpiration(st *state.State, setID uint64) error {
+	var snapshots map[uint64]*snapshotState
+	err := st.Get("snapshots", &snapshots)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if sst, ok := snapshots[setID]; ok && sst.SnapInitiated {
+		return updateSnapshotState(st, setID, func(sst *snapshotState) {
+			sst.ExpiryTime = time.Time{}
+		})
+	}
+	return removeSnapshotState(st, setID)
+}
+
 // removeSnapshotState removes given set IDs from the state.
 func removeSnapshotState(st *state.State, setIDs ...uint64) error {
 	var snapshots map[uint64]*json.RawMessage
@@ -207,7 +251,7 @@ func expiredSnapshotSets(st *state.State, cutoffTime time.Time) (map[uint64]bool
 
 	expired := make(map[uint64]bool)
 	for setID, snapshotSet := range snapshots {
-		if snapshotSet.ExpiryTime.Before(cutoffTime) {
+		if !snapshotSet.ExpiryTime.IsZero() && snapshotSet.ExpiryTime.Before(cutoffTime) {
 			expired[setID] = true
 		}
 	}
@@ -320,15 +364,20 @@ func List(ctx context.Context, st *state.State, setID uint64, snapNames []string
 		return nil, err
 	}
 
-	// decorate all snapshots with "auto" flag if we have expiry time set for them.
+	// decorate all snapshots with "auto" flag if we have expiry time set for
+	// them, and with "snap-initiated" flag if the snap requested them.
 	for _, sset := range sets {
-		// at the moment we only keep records with expiry time so checking non-zero
-		// expiry-time is not strictly necessary, but it makes it future-proof in case
-		// we add more attributes to these entries.
-		if snapshotState, ok := snapshots[sset.ID]; ok && !snapshotState.ExpiryTime.IsZero() {
-			for _, snapshot := range sset.Snapshots {
+		snapshotState, ok := snapshots[sset.ID]
+		if !ok {
+			continue
+		}
+		for _, snapshot := range sset.Snapshots {
+			if !snapshotState.ExpiryTime.IsZero() {
 				snapshot.Auto = true
 			}
+			if snapshotState.SnapInitiated {
+				snapshot.SnapInitiated = true
+			}
 		}
 	}
 
@@ -365,10 +414,7 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 
 			// trying to import identical snapshot; instead return set ID of
 			// the existing one and reset its expiry time.
-			// XXX: at the moment expiry-time is the only attribute so we can
-			// just remove the record. If we ever add more attributes this needs
-			// to reset expiry-time only.
-			if err := removeSnapshotState(st, dupErr.SetID); err != nil {
+			if err := resetExpiration(st, dupErr.SetID); err != nil {
 				return 0, nil, err
 			}
 			return dupErr.SetID, dupErr.SnapNames, nil
@@ -381,6 +427,76 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 // Save creates a taskset for taking snapshots of snaps' data.
 // Note that the state must be locked by the caller.
 func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	return save(st, instanceNames, users, options, &saveFlags{})
+}
+
+type saveFlags struct {
+	// snapInitiated is set when the snap requested the snapshot itself
+	snapInitiated bool
+	// ignoreChangeID is the change of the hook requesting the snapshot
+	ignoreChangeID string
+}
+
+// SaveSnapInitiated creates a taskset for taking a snapshot of the data of
+// the given snap, as requested by the snap itself through snapctl. The
+// resulting snapshot set is tagged as snap-initiated. Such requests are rate
+// limited per snap. ignoreChangeID is the change of the hook making the
+// request, if any, which does not conflict with the snapshot.
+// Note that the state must be locked by the caller.
+func SaveSnapInitiated(st *state.State, instanceName string, users []string, ignoreChangeID string) (setID uint64, ts *state.TaskSet, err error) {
+	var lastSaves map[string]time.Time
+	if err := st.Get("snap-initiated-snapshots", &lastSaves); err != nil && !errors.Is(err, state.ErrNoState) {
+		return 0, nil, err
+	}
+	now := timeNow()
+	if last, ok := lastSaves[instanceName]; ok {
+		if next := last.Add(snapInitiatedSnapshotInterval); now.Before(next) {
+			return 0, nil, fmt.Errorf("cannot save snapshot of snap %q: too many requests, retry after %s", instanceName, next.Format(time.RFC3339))
+		}
+	}
+
+	flags := &saveFlags{
+		snapInitiated:  true,
+		ignoreChangeID: ignoreChangeID,
+	}
+	setID, _, ts, err = save(st, []string{instanceName}, users, nil, flags)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if lastSaves == nil {
+		lastSaves = make(map[string]time.Time)
+	}
+	lastSaves[instanceName] = now
+	st.Set("snap-initiated-snapshots", lastSaves)
+
+	return setID, ts, nil
+}
+
+// PruneSnapInitiatedSnapshots forgets the last snapshot requested by the given
+// snap, it is called when the snap is removed.
+// Note that the state must be locked by the caller.
+func PruneSnapInitiatedSnapshots(st *state.State, instanceName string) error {
+	var lastSaves map[string]time.Time
+	if err := st.Get("snap-initiated-snapshots", &lastSaves); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := lastSaves[instanceName]; !ok {
+		return nil
+	}
+	delete(lastSaves, instanceName)
+	if len(lastSaves) == 0 {
+		st.Set("snap-initiated-snapshots", nil)
+	} else {
+		st.Set("snap-initiated-snapshots", lastSaves)
+	}
+	return nil
+}
+
+func save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, flags *saveFlags) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
 	if len(instanceNames) == 0 {
 		instanceNames, err = allActiveSnapNames(st)
 		if err != nil {
@@ -400,7 +516,7 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 	}
 
 	// Make sure we do not snapshot if anything like install/remove/refresh is in progress
-	if err := snapstateCheckChangeConflictMany(st, instanceNames, ""); err != nil {
+	if err := snapstateCheckChangeConflictMany(st, instanceNames, flags.ignoreChangeID); err != nil {
 		return 0, nil, nil, err
 	}
 
@@ -416,10 +532,11 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		task := st.NewTask("save-snapshot", desc)
 
 		snapshot := snapshotSetup{
-			SetID:   setID,
-			Snap:    name,
-			Users:   users,
-			Options: options[name],
+			SetID:         setID,
+			Snap:          name,
+			Users:         users,
+			Options:       options[name],
+			SnapInitiated: flags.snapInitiated,
 		}
 
 		task.Set("snapshot-setup", &snapshot)
@@ -597,6 +597,100 @@ func (s snapshotSuite) TestSaveOneSnap(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestSaveSnapInitiated(c *check.C) {
+	var conflictChangeIDs []string
+	defer snapshotstate.MockSnapstateCheckChangeConflictMany(func(_ *state.State, names []string, ignoreChangeID string) error {
+		c.Check(names, check.DeepEquals, []string{"a-snap"})
+		conflictChangeIDs = append(conflictChangeIDs, ignoreChangeID)
+		return nil
+	})()
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	defer snapshotstate.MockTimeNow(func() time.Time { return now })()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "a-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "a-snap", Revision: snap.R(1)},
+		}),
+		Current: snap.R(1),
+	})
+
+	setID, ts, err := snapshotstate.SaveSnapInitiated(st, "a-snap", []string{"a-user"}, "42")
+	c.Assert(err, check.IsNil)
+	c.Check(setID, check.Equals, uint64(1))
+	c.Check(conflictChangeIDs, check.DeepEquals, []string{"42"})
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "save-snapshot")
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":         1.,
+		"snap":           "a-snap",
+		"users":          []interface{}{"a-user"},
+		"current":        "unset",
+		"snap-initiated": true,
+	})
+
+	// too soon
+	now = now.Add(5 * time.Minute)
+	_, _, err = snapshotstate.SaveSnapInitiated(st, "a-snap", nil, "")
+	c.Check(err, check.ErrorMatches, `cannot save snapshot of snap "a-snap": too many requests, retry after 2026-10-19T10:10:00Z`)
+	c.Check(conflictChangeIDs, check.HasLen, 1)
+
+	now = now.Add(5 * time.Minute)
+	setID, _, err = snapshotstate.SaveSnapInitiated(st, "a-snap", nil, "")
+	c.Assert(err, check.IsNil)
+	c.Check(setID, check.Equals, uint64(2))
+	c.Check(conflictChangeIDs, check.DeepEquals, []string{"42", ""})
+}
+
+func (snapshotSuite) TestPruneSnapInitiatedSnapshots(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	// nothing to prune
+	c.Assert(snapshotstate.PruneSnapInitiatedSnapshots(st, "a-snap"), check.IsNil)
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	st.Set("snap-initiated-snapshots", map[string]time.Time{
+		"a-snap":       now,
+		"another-snap": now,
+	})
+
+	c.Assert(snapshotstate.PruneSnapInitiatedSnapshots(st, "a-snap"), check.IsNil)
+	var lastSaves map[string]time.Time
+	c.Assert(st.Get("snap-initiated-snapshots", &lastSaves), check.IsNil)
+	c.Check(lastSaves, check.DeepEquals, map[string]time.Time{"another-snap": now})
+
+	// unknown snaps are ignored
+	c.Assert(snapshotstate.PruneSnapInitiatedSnapshots(st, "unknown-snap"), check.IsNil)
+	c.Assert(st.Get("snap-initiated-snapshots", &lastSaves), check.IsNil)
+	c.Check(lastSaves, check.HasLen, 1)
+
+	// the state entry goes away with the last snap
+	c.Assert(snapshotstate.PruneSnapInitiatedSnapshots(st, "another-snap"), check.IsNil)
+	c.Check(st.Get("snap-initiated-snapshots", &lastSaves), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (snapshotSuite) TestSaveSnapInitiatedNotInstalled(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err := snapshotstate.SaveSnapInitiated(st, "foo", nil, "")
+	c.Assert(err, check.ErrorMatches, `snap "foo" is not installed`)
+
+	// failed requests do not count towards the rate limit
+	var lastSaves map[string]time.Time
+	c.Check(st.Get("snap-initiated-snapshots", &lastSaves), testutil.ErrorIs, state.ErrNoState)
+}
+
 func (snapshotSuite) TestSaveIntegration(c *check.C) {
 	if os.Geteuid() == 0 {
 		c.Skip("this test cannot run as root (runuser will fail)")
@@ -1685,6 +1779,39 @@ func (snapshotSuite) TestExpiredSnapshotSets(c *check.C) {
 	c.Check(expired, check.DeepEquals, map[uint64]bool{13: true})
 }
 
+func (snapshotSuite) TestExpiredSnapshotSetsIgnoresSnapInitiated(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	c.Assert(snapshotstate.MarkSnapInitiated(st, 12), check.IsNil)
+
+	expired, err := snapshotstate.ExpiredSnapshotSets(st, time.Now())
+	c.Assert(err, check.IsNil)
+	c.Check(expired, check.HasLen, 0)
+}
+
+func (snapshotSuite) TestResetExpiration(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	tm, err := time.Parse(time.RFC3339, "2019-03-11T11:24:00Z")
+	c.Assert(err, check.IsNil)
+	c.Assert(snapshotstate.SaveExpiration(st, 12, tm), check.IsNil)
+	c.Assert(snapshotstate.SaveExpiration(st, 13, tm), check.IsNil)
+	c.Assert(snapshotstate.MarkSnapInitiated(st, 13), check.IsNil)
+
+	c.Assert(snapshotstate.ResetExpiration(st, 12), check.IsNil)
+	c.Assert(snapshotstate.ResetExpiration(st, 13), check.IsNil)
+
+	var snapshots map[string]map[string]interface{}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.DeepEquals, map[string]map[string]interface{}{
+		"13": {"expiry-time": "0001-01-01T00:00:00Z", "snap-initiated": true},
+	})
+}
+
 func (snapshotSuite) TestAutomaticSnapshotDisabled(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
@@ -1835,6 +1962,33 @@ func (snapshotSuite) TestListSetsAutoFlag(c *check.C) {
 	}
 }
 
+func (snapshotSuite) TestListSetsSnapInitiatedFlag(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Set("snapshots", map[uint64]interface{}{
+		1: map[string]interface{}{"snap-initiated": true},
+	})
+
+	restore := snapshotstate.MockBackendList(func(ctx context.Context, setID uint64, snapNames []string) ([]client.SnapshotSet, error) {
+		return []client.SnapshotSet{
+			{ID: 1, Snapshots: []*client.Snapshot{{Snap: "foo", SetID: 1}}},
+			{ID: 2, Snapshots: []*client.Snapshot{{Snap: "foo", SetID: 2, Auto: true}}},
+		}, nil
+	})
+	defer restore()
+
+	sets, err := snapshotstate.List(context.TODO(), st, 0, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(sets, check.HasLen, 2)
+	c.Check(sets[0].Snapshots[0].SnapInitiated, check.Equals, true)
+	c.Check(sets[0].Snapshots[0].Auto, check.Equals, false)
+	// the auto flag of old snapshots is kept
+	c.Check(sets[1].Snapshots[0].SnapInitiated, check.Equals, false)
+	c.Check(sets[1].Snapshots[0].Auto, check.Equals, true)
+}
+
 func (snapshotSuite) TestImportSnapshotHappy(c *check.C) {
 	st := state.New(nil)
 
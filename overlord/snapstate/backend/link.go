@@ -263,6 +263,10 @@ func (b Backend) StopServices(apps []*snap.AppInfo, reason snap.ServiceStopReaso
 	return wrappers.StopServices(apps, nil, reason, meter, tm)
 }
 
+func (b Backend) CheckRollingServices(apps []*snap.AppInfo, meter progress.Meter, tm timings.Measurer) error {
+	return wrappers.CheckRollingServices(apps, meter, tm)
+}
+
 func (b Backend) generateWrappers(s *snap.Info, linkCtx LinkContext) (wrappers.SnapdRestart, error) {
 	var err error
 	var cleanupFuncs []func(*snap.Info) error
@@ -1389,6 +1389,19 @@ func (f *fakeSnappyBackend) StopServices(svcs []*snap.AppInfo, reason snap.Servi
 	return f.maybeErrForLastOp()
 }
 
+func (f *fakeSnappyBackend) CheckRollingServices(svcs []*snap.AppInfo, meter progress.Meter, tm timings.Measurer) error {
+	services := make([]string, 0, len(svcs))
+	for _, svc := range svcs {
+		services = append(services, svc.Name)
+	}
+	f.appendOp(&fakeOp{
+		op:       "check-rolling-services",
+		path:     svcSnapMountDir(svcs),
+		services: services,
+	})
+	return f.maybeErrForLastOp()
+}
+
 func (f *fakeSnappyBackend) KillSnapApps(snapName string, reason snap.AppKillReason, tm timings.Measurer) error {
 	testLock, err := snaplock.OpenLock(snapName)
 	if err != nil {
@@ -3199,16 +3199,37 @@ func (m *SnapManager) startSnapServices(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	var checkRolling bool
+	if err := t.Get("check-rolling-services", &checkRolling); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	var rollingSvcs []*snap.AppInfo
+	if checkRolling {
+		for _, app := range startupOrdered {
+			if app.RefreshStrategy == "rolling" {
+				rollingSvcs = append(rollingSvcs, app)
+			}
+		}
+	}
+
 	pb := NewTaskProgressAdapterUnlocked(t)
 
 	st.Unlock()
+	defer st.Lock()
 	err = m.backend.StartServices(startupOrdered, &wrappers.DisabledServices{
 		SystemServices: missingSvcsOverview.FoundSystemServices,
 		UserServices:   missingSvcsOverview.FoundUserServices,
 	}, pb, perfTimings)
-	st.Lock()
+	if err != nil {
+		return err
+	}
 
-	return err
+	if len(rollingSvcs) == 0 {
+		return nil
+	}
+	// the sockets of the services kept listening during the refresh, make
+	// sure they were handed over to the current revision
+	return m.backend.CheckRollingServices(rollingSvcs, pb, perfTimings)
 }
 
 func (m *SnapManager) undoStartSnapServices(t *state.Task, _ *tomb.Tomb) error {
@@ -3756,6 +3777,11 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		if err := pruneSnapsHold(st, snapsup.InstanceName()); err != nil {
 			return err
 		}
+		if PruneSnapInitiatedSnapshots != nil {
+			if err := PruneSnapInitiatedSnapshots(st, snapsup.InstanceName()); err != nil {
+				return err
+			}
+		}
 
 		// Remove configuration associated with this snap.
 		err = config.DeleteSnapConfig(st, snapsup.InstanceName())
@@ -570,6 +570,10 @@ var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.Task
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
+// PruneSnapInitiatedSnapshots allows to hook the snapshot manager's pruning
+// of the snapshot state of a removed snap.
+var PruneSnapInitiatedSnapshots func(st *state.State, instanceName string) error
+
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
 	info, err := snapReadInfo(name, si)
 	if err != nil && flags&errorOnBroken != 0 {
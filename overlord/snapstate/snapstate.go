@@ -236,6 +236,16 @@ func refreshRetain(st *state.State) int {
 	return retain
 }
 
+// autoQuotasEnabled returns whether a quota group is created from the
+// resource hints of snaps when installing them.
+func autoQuotasEnabled(st *state.State) (bool, error) {
+	var auto bool
+	if err := config.NewTransaction(st).GetMaybe("core", "quotas.auto", &auto); err != nil {
+		return false, err
+	}
+	return auto, nil
+}
+
 var excludeFromRefreshAppAwareness = func(t snap.Type) bool {
 	return t == snap.TypeSnapd || t == snap.TypeOS
 }
@@ -702,6 +712,14 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 			return nil, err
 		}
 		addTask(quotaAddSnapTask)
+	} else if !snapst.IsInstalled() && snapsup.Type == snap.TypeApp {
+		autoQuotas, err := autoQuotasEnabled(st)
+		if err != nil {
+			return nil, err
+		}
+		if autoQuotas {
+			addTask(AutoQuotaGroup(st, snapsup.InstanceName()))
+		}
 	}
 
 	// only run default-configure hook if installing the snap for the first time and
@@ -713,6 +731,11 @@ func doInstall(st *state.State, snapst *SnapState, snapsup SnapSetup, compsups [
 
 	// run new services
 	startSnapServices := st.NewTask("start-snap-services", fmt.Sprintf(i18n.G("Start snap %q%s services"), snapsup.InstanceName(), revisionStr))
+	if snapst.IsInstalled() {
+		// the sockets of services with the "rolling" refresh strategy
+		// were kept listening by stop-snap-services
+		startSnapServices.Set("check-rolling-services", true)
+	}
 	addTask(startSnapServices)
 
 	for _, t := range componentsTSS.discardTasks {
@@ -1010,6 +1033,10 @@ var AddSnapToQuotaGroup = func(st *state.State, snapName string, quotaGroup stri
 	panic("internal error: snapstate.AddSnapToQuotaGroup is unset")
 }
 
+var AutoQuotaGroup = func(st *state.State, snapName string) *state.Task {
+	panic("internal error: snapstate.AutoQuotaGroup is unset")
+}
+
 var HasActiveConnection = func(st *state.State, iface string) (bool, error) {
 	panic("internal error: snapstate.HasActiveConnection is unset")
 }
@@ -5152,6 +5152,38 @@ func (s *snapmgrTestSuite) TestInstallQuotaGroup(c *C) {
 	c.Check(quotaWasCalled, Equals, true)
 }
 
+func (s *snapmgrTestSuite) TestInstallAutoQuotaGroup(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var autoQuotaCalled int
+	s.o.TaskRunner().AddHandler("quota-auto-create", func(t *state.Task, _ *tomb.Tomb) error {
+		autoQuotaCalled++
+		return nil
+	}, nil)
+
+	// not enabled by default
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(tasksWithKind(ts, "quota-auto-create"), HasLen, 0)
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "quotas.auto", true), IsNil)
+	tr.Commit()
+
+	chg := s.state.NewChange("install", "")
+	ts, err = snapstate.Install(context.Background(), s.state, "some-snap", nil, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(tasksWithKind(ts, "quota-auto-create"), HasLen, 1)
+	chg.AddAll(ts)
+
+	s.settle(c)
+
+	c.Check(chg.Err(), IsNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(autoQuotaCalled, Equals, 1)
+}
+
 func (s *snapmgrTestSuite) TestInstallUndoQuotaGroup(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1761,6 +1761,49 @@ func (s *snapmgrTestSuite) TestRemovePrunesRefreshGatingDataOnLastRevision(c *C)
 	c.Check(candidates["foo-snap"], NotNil)
 }
 
+func (s *snapmgrTestSuite) TestRemovePrunesSnapInitiatedSnapshotsOnLastRevision(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	var pruned []string
+	old := snapstate.PruneSnapInitiatedSnapshots
+	snapstate.PruneSnapInitiatedSnapshots = func(st *state.State, instanceName string) error {
+		pruned = append(pruned, instanceName)
+		return nil
+	}
+	defer func() { snapstate.PruneSnapInitiatedSnapshots = old }()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(11)},
+			{RealName: "some-snap", Revision: snap.R(12)},
+		}),
+		Current:  snap.R(12),
+		SnapType: "app",
+	})
+
+	// removing a revision which is not the last one keeps the state
+	chg := st.NewChange("remove", "remove a revision")
+	ts, err := snapstate.Remove(st, "some-snap", snap.R(11), nil)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+	c.Check(pruned, HasLen, 0)
+
+	chg = st.NewChange("remove", "remove a snap")
+	ts, err = snapstate.Remove(st, "some-snap", snap.R(0), nil)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+	c.Assert(chg.Err(), IsNil)
+	c.Check(pruned, DeepEquals, []string{"some-snap"})
+}
+
 func (s *snapmgrTestSuite) TestRemoveKeepsGatingDataIfNotLastRevision(c *C) {
 	st := s.state
 	st.Lock()
@@ -7756,6 +7756,139 @@ func (s *snapmgrTestSuite) TestInstallModeDisableUpdateServiceNotDisabled(c *C)
 	c.Check(op.disabledServices, HasLen, 0)
 }
 
+func (s *snapmgrTestSuite) TestRefreshStrategyRollingUpdate(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	oldServicesSnapYaml := servicesSnapYaml
+	servicesSnapYaml += `
+  svcRolling:
+    daemon: simple
+    refresh-strategy: rolling
+    plugs: [network-bind]
+    sockets:
+      sock:
+        listen-stream: $SNAP_COMMON/sock
+`
+	defer func() { servicesSnapYaml = oldServicesSnapYaml }()
+
+	si := &snap.SideInfo{
+		RealName: "services-snap", SnapID: "services-snap-id", Revision: snap.R(7),
+	}
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+		Active:   true,
+	})
+	snaptest.MockSnap(c, string(servicesSnapYaml), si)
+
+	updateChg := s.state.NewChange("refresh", "...")
+	updateTs, err := snapstate.Update(s.state, "services-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	updateChg.AddAll(updateTs)
+
+	s.settle(c)
+
+	c.Assert(updateChg.Err(), IsNil)
+	c.Assert(updateChg.IsReady(), Equals, true)
+
+	// the sockets kept listening are checked once the new revision
+	// services are started
+	ops := s.fakeBackend.ops.Ops()
+	startIdx := -1
+	for i, op := range ops {
+		if op == "start-snap-services" {
+			startIdx = i
+		}
+	}
+	c.Assert(startIdx, Not(Equals), -1)
+	c.Assert(len(ops) > startIdx+1, Equals, true)
+	c.Check(ops[startIdx+1], Equals, "check-rolling-services")
+	op := s.fakeBackend.ops.First("check-rolling-services")
+	c.Check(op.services, DeepEquals, []string{"svcRolling"})
+	c.Check(op.path, Equals, filepath.Join(dirs.SnapMountDir, "services-snap/11"))
+}
+
+func (s *snapmgrTestSuite) TestRefreshStrategyRollingFreshInstall(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	oldServicesSnapYaml := servicesSnapYaml
+	servicesSnapYaml += `
+  svcRolling:
+    daemon: simple
+    refresh-strategy: rolling
+    plugs: [network-bind]
+    sockets:
+      sock:
+        listen-stream: $SNAP_COMMON/sock
+`
+	defer func() { servicesSnapYaml = oldServicesSnapYaml }()
+
+	installChg := s.state.NewChange("install", "...")
+	installTs, err := snapstate.Install(context.Background(), s.state, "services-snap", nil, 0, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	installChg.AddAll(installTs)
+
+	s.settle(c)
+
+	c.Assert(installChg.Err(), IsNil)
+	c.Assert(installChg.IsReady(), Equals, true)
+
+	// nothing was kept listening
+	c.Check(s.fakeBackend.ops.First("start-snap-services"), NotNil)
+	c.Check(s.fakeBackend.ops.First("check-rolling-services"), IsNil)
+}
+
+func (s *snapmgrTestSuite) TestRefreshStrategyRollingUpdateHealthCheckFails(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	oldServicesSnapYaml := servicesSnapYaml
+	servicesSnapYaml += `
+  svcRolling:
+    daemon: simple
+    refresh-strategy: rolling
+    plugs: [network-bind]
+    sockets:
+      sock:
+        listen-stream: $SNAP_COMMON/sock
+`
+	defer func() { servicesSnapYaml = oldServicesSnapYaml }()
+
+	si := &snap.SideInfo{
+		RealName: "services-snap", SnapID: "services-snap-id", Revision: snap.R(7),
+	}
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  si.Revision,
+		Active:   true,
+	})
+	snaptest.MockSnap(c, string(servicesSnapYaml), si)
+
+	s.fakeBackend.maybeInjectErr = func(op *fakeOp) error {
+		if op.op == "check-rolling-services" {
+			return errors.New(`socket "snap.services-snap.svcRolling.sock.socket" is not listening after refresh`)
+		}
+		return nil
+	}
+
+	updateChg := s.state.NewChange("refresh", "...")
+	updateTs, err := snapstate.Update(s.state, "services-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	updateChg.AddAll(updateTs)
+
+	s.settle(c)
+
+	c.Assert(updateChg.Err(), ErrorMatches, `(?s).*socket "snap.services-snap.svcRolling.sock.socket" is not listening after refresh.*`)
+
+	// the refresh was undone
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "services-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Current, Equals, snap.R(7))
+}
+
 func (s *snapmgrTestSuite) TestInstallModeDisableFreshInstallEnabledByHook(c *C) {
 	st := s.state
 	st.Lock()
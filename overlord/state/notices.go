@@ -108,6 +108,34 @@ func (n *Notice) Type() NoticeType {
 	return n.noticeType
 }
 
+// ID returns the unique ID of the notice.
+func (n *Notice) ID() string {
+	return n.id
+}
+
+// Key returns the notice key, which differentiates notices of the same type.
+func (n *Notice) Key() string {
+	return n.key
+}
+
+// LastRepeated returns the time this notice was last repeated.
+func (n *Notice) LastRepeated() time.Time {
+	return n.lastRepeated
+}
+
+// LastData returns a copy of the data captured from the last occurrence of
+// this notice.
+func (n *Notice) LastData() map[string]string {
+	if n.lastData == nil {
+		return nil
+	}
+	data := make(map[string]string, len(n.lastData))
+	for k, v := range n.lastData {
+		data[k] = v
+	}
+	return data
+}
+
 func flattenUserID(userID *uint32) (uid uint32, isSet bool) {
 	if userID == nil {
 		return 0, false
@@ -214,11 +242,22 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever a snap service enters the failed state or is
+	// restarted by systemd more often than the restart threshold. The key
+	// for snap-service-failure notices is the <snap>.<app> name of the
+	// service.
+	SnapServiceFailureNotice NoticeType = "snap-service-failure"
+
+	// Recorded whenever the integrity check of a recovery system finds it
+	// broken, or healthy again after it was broken. The key for
+	// recovery-system-health notices is the label of the recovery system.
+	RecoverySystemHealthNotice NoticeType = "recovery-system-health"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice, SnapServiceFailureNotice, RecoverySystemHealthNotice:
 		return true
 	}
 	return false
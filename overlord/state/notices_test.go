@@ -168,6 +168,32 @@ func (s *noticesSuite) TestType(c *C) {
 	c.Check(notices[0].Type(), Equals, state.WarningNotice)
 }
 
+func (s *noticesSuite) TestAccessors(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	start := time.Now()
+	addNotice(c, st, nil, state.ChangeUpdateNotice, "123", &state.AddNoticeOptions{
+		Data: map[string]string{"kind": "install-snap"},
+	})
+	addNotice(c, st, nil, state.RefreshInhibitNotice, "-", nil)
+
+	notices := st.Notices(nil)
+	c.Assert(notices, HasLen, 2)
+	c.Check(notices[0].ID(), Not(Equals), notices[1].ID())
+	c.Check(notices[0].Key(), Equals, "123")
+	c.Check(notices[0].LastRepeated().Before(start), Equals, false)
+	data := notices[0].LastData()
+	c.Check(data, DeepEquals, map[string]string{"kind": "install-snap"})
+	// the data cannot be modified through the returned map
+	data["kind"] = "remove-snap"
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{"kind": "install-snap"})
+
+	c.Check(notices[1].Key(), Equals, "-")
+	c.Check(notices[1].LastData(), IsNil)
+}
+
 func (s *noticesSuite) TestOccurrences(c *C) {
 	st := state.New(nil)
 	st.Lock()
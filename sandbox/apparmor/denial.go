@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmor
+
+import (
+	"strings"
+
+	"github.com/snapcore/snapd/sandbox/audit"
+)
+
+// Denial describes an access which was denied by AppArmor, either by the
+// kernel or by a trusted helper such as dbus-daemon.
+type Denial struct {
+	// Label is the label of the confined process, that is the name of its
+	// AppArmor profile.
+	Label     string
+	Operation string
+	Class     string
+	// Name is the path of the accessed object for file denials.
+	Name string
+	// RequestedMask are the requested permissions, as in "r" or "wc".
+	RequestedMask string
+	// Capability is the name of the denied capability.
+	Capability string
+	// DBus specific fields.
+	Bus       string
+	Path      string
+	Interface string
+	Member    string
+}
+
+// ParseDenial parses an audit message logged by AppArmor. It returns false if
+// the message does not describe a denial.
+func ParseDenial(msg string) (*Denial, bool) {
+	if !strings.Contains(msg, `apparmor="DENIED"`) {
+		return nil, false
+	}
+	fields := audit.ParseFields(msg, "name", "comm")
+	label := fields["profile"]
+	if label == "" {
+		// messages from dbus-daemon use label rather than profile
+		label = fields["label"]
+	}
+	if label == "" {
+		return nil, false
+	}
+	return &Denial{
+		Label:         label,
+		Operation:     fields["operation"],
+		Class:         fields["class"],
+		Name:          fields["name"],
+		RequestedMask: fields["requested_mask"],
+		Capability:    fields["capname"],
+		Bus:           fields["bus"],
+		Path:          fields["path"],
+		Interface:     fields["interface"],
+		Member:        fields["member"],
+	}, true
+}
+
+// SecurityTag returns the security tag of the snap application or hook
+// confined by the profile, or an empty string if the denial was not caused by
+// a snap. Child profiles, as in snap.foo.app//child, belong to their parent.
+func (d *Denial) SecurityTag() string {
+	tag, _, _ := strings.Cut(d.Label, "//")
+	if !strings.HasPrefix(tag, "snap.") {
+		return ""
+	}
+	return tag
+}
+
+// String returns a short description of the denied access.
+func (d *Denial) String() string {
+	switch {
+	case d.Capability != "":
+		return "capability " + d.Capability
+	case strings.HasPrefix(d.Operation, "dbus_"):
+		return "dbus " + strings.TrimPrefix(d.Operation, "dbus_") + " " + d.Interface + "." + d.Member + " on " + d.Path
+	case d.Name != "":
+		return d.Operation + " " + d.Name + " (" + d.RequestedMask + ")"
+	}
+	return d.Operation
+}
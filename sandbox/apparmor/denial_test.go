@@ -0,0 +1,75 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmor_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/apparmor"
+)
+
+type denialSuite struct{}
+
+var _ = Suite(&denialSuite{})
+
+func (s *denialSuite) TestParseDenialFile(c *C) {
+	d, ok := apparmor.ParseDenial(`audit: type=1400 audit(1700000000.123:42): apparmor="DENIED" operation="open" class="file" profile="snap.foo.app" name="/etc/shadow" pid=1234 comm="cat" requested_mask="r" denied_mask="r" fsuid=1000 ouid=0`)
+	c.Assert(ok, Equals, true)
+	c.Check(d, DeepEquals, &apparmor.Denial{
+		Label:         "snap.foo.app",
+		Operation:     "open",
+		Class:         "file",
+		Name:          "/etc/shadow",
+		RequestedMask: "r",
+	})
+	c.Check(d.SecurityTag(), Equals, "snap.foo.app")
+	c.Check(d.String(), Equals, "open /etc/shadow (r)")
+}
+
+func (s *denialSuite) TestParseDenialCapability(c *C) {
+	d, ok := apparmor.ParseDenial(`audit: type=1400 audit(1700000000.123:43): apparmor="DENIED" operation="capable" class="cap" profile="snap.foo.hook.configure//null-/usr/bin/foo" pid=1234 comm="foo" capability=21  capname="sys_admin"`)
+	c.Assert(ok, Equals, true)
+	c.Check(d.Capability, Equals, "sys_admin")
+	c.Check(d.SecurityTag(), Equals, "snap.foo.hook.configure")
+	c.Check(d.String(), Equals, "capability sys_admin")
+}
+
+func (s *denialSuite) TestParseDenialDBus(c *C) {
+	d, ok := apparmor.ParseDenial(`apparmor="DENIED" operation="dbus_method_call"  bus="system" path="/org/freedesktop/hostname1" interface="org.freedesktop.DBus.Properties" member="GetAll" mask="send" name="org.freedesktop.hostname1" pid=1234 label="snap.foo.app" peer_pid=42 peer_label="unconfined"`)
+	c.Assert(ok, Equals, true)
+	c.Check(d.Label, Equals, "snap.foo.app")
+	c.Check(d.Bus, Equals, "system")
+	c.Check(d.String(), Equals, "dbus method_call org.freedesktop.DBus.Properties.GetAll on /org/freedesktop/hostname1")
+}
+
+func (s *denialSuite) TestParseDenialNotDenial(c *C) {
+	for _, msg := range []string{
+		`audit: type=1400 audit(1700000000.123:44): apparmor="STATUS" operation="profile_load" profile="unconfined" name="snap.foo.app" pid=1`,
+		`audit: type=1326 audit(1700000000.123:45): auid=1000 uid=1000 syscall=165`,
+		`apparmor="DENIED" operation="open" name="/etc/shadow"`,
+	} {
+		_, ok := apparmor.ParseDenial(msg)
+		c.Check(ok, Equals, false, Commentf("%s", msg))
+	}
+
+	d, ok := apparmor.ParseDenial(`apparmor="DENIED" operation="open" profile="/usr/bin/man" name="/etc/shadow" requested_mask="r"`)
+	c.Assert(ok, Equals, true)
+	c.Check(d.SecurityTag(), Equals, "")
+}
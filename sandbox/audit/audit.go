@@ -0,0 +1,62 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package audit implements parsing of the messages logged by the kernel
+// audit subsystem, such as the ones describing security denials.
+package audit
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// ParseFields returns the key=value fields of an audit message.
+//
+// Values of untrusted fields are either enclosed in double quotes or, when
+// they contain spaces, quotes or control characters, hex encoded. Quoted
+// values are returned without the quotes. Hex encoded values are only decoded
+// for the keys listed in hexKeys, as there is no way to tell them apart from
+// other unquoted values.
+func ParseFields(msg string, hexKeys ...string) map[string]string {
+	fields := make(map[string]string)
+	for _, word := range strings.Fields(msg) {
+		key, value, ok := strings.Cut(word, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		} else if isHexKey(key, hexKeys) {
+			if decoded, err := hex.DecodeString(value); err == nil {
+				value = string(decoded)
+			}
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+func isHexKey(key string, hexKeys []string) bool {
+	for _, k := range hexKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package audit_test
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/audit"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type auditSuite struct{}
+
+var _ = Suite(&auditSuite{})
+
+func (s *auditSuite) TestParseFields(c *C) {
+	msg := `audit: type=1400 audit(1700000000.123:42): apparmor="DENIED" operation="open" profile="snap.foo.app" name=2F746D702F6120622E747874 pid=1234 comm="foo" requested_mask="r"`
+	c.Check(audit.ParseFields(msg, "name"), DeepEquals, map[string]string{
+		"type":           "1400",
+		"apparmor":       "DENIED",
+		"operation":      "open",
+		"profile":        "snap.foo.app",
+		"name":           "/tmp/a b.txt",
+		"pid":            "1234",
+		"comm":           "foo",
+		"requested_mask": "r",
+	})
+
+	// hex values are only decoded for the requested keys
+	c.Check(audit.ParseFields(`name=2F746D70 code=0x50000`), DeepEquals, map[string]string{
+		"name": "2F746D70",
+		"code": "0x50000",
+	})
+	// invalid hex is kept as is
+	c.Check(audit.ParseFields(`name=/tmp`, "name"), DeepEquals, map[string]string{
+		"name": "/tmp",
+	})
+}
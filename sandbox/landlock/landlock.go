@@ -0,0 +1,290 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package landlock implements probing of the Landlock LSM as well as parsing
+// of the landlock profiles generated by snapd, which are enforced by
+// snap-confine.
+//
+// Landlock is an unprivileged, stackable LSM that allows a process to restrict
+// its own access to the file system hierarchy and, starting with ABI version
+// 4, to TCP ports. It is used to confine snaps on systems where AppArmor is
+// not available.
+package landlock
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Access rights to the file system as defined by the kernel ABI.
+const (
+	accessFSExecute    uint64 = 1 << 0
+	accessFSWriteFile  uint64 = 1 << 1
+	accessFSReadFile   uint64 = 1 << 2
+	accessFSReadDir    uint64 = 1 << 3
+	accessFSRemoveDir  uint64 = 1 << 4
+	accessFSRemoveFile uint64 = 1 << 5
+	accessFSMakeChar   uint64 = 1 << 6
+	accessFSMakeDir    uint64 = 1 << 7
+	accessFSMakeReg    uint64 = 1 << 8
+	accessFSMakeSock   uint64 = 1 << 9
+	accessFSMakeFifo   uint64 = 1 << 10
+	accessFSMakeBlock  uint64 = 1 << 11
+	accessFSMakeSym    uint64 = 1 << 12
+	// ABI version 2
+	accessFSRefer uint64 = 1 << 13
+	// ABI version 3
+	accessFSTruncate uint64 = 1 << 14
+)
+
+// accessFromPerms converts permission letters used in the profiles ("r",
+// "w" and "x") into the corresponding file system access rights.
+func accessFromPerms(perms string) (uint64, error) {
+	if perms == "" {
+		return 0, fmt.Errorf("empty permissions")
+	}
+	var access uint64
+	for _, p := range perms {
+		switch p {
+		case 'r':
+			access |= accessFSReadFile | accessFSReadDir
+		case 'w':
+			// character and block devices cannot be created by
+			// snaps anyway
+			access |= accessFSWriteFile | accessFSRemoveDir |
+				accessFSRemoveFile | accessFSMakeDir | accessFSMakeReg |
+				accessFSMakeSock | accessFSMakeFifo | accessFSMakeSym |
+				accessFSRefer | accessFSTruncate
+		case 'x':
+			access |= accessFSExecute
+		default:
+			return 0, fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	return access, nil
+}
+
+// PathRule grants access to a file system hierarchy.
+type PathRule struct {
+	// Perms is a combination of "r", "w" and "x".
+	Perms string
+	// Path is the file or directory below which access is granted, it may
+	// refer to the environment variables listed in ExpandableVariables.
+	Path string
+}
+
+// PortRule grants access to a TCP port.
+type PortRule struct {
+	// Access is either "bind" or "connect".
+	Access string
+	// Port is the TCP port number, or -1 when any port is allowed.
+	Port int
+}
+
+// Profile describes the landlock ruleset of a single snap application or
+// hook.
+type Profile struct {
+	// Complain is set for snaps in devmode, landlock has no complain mode
+	// so such profiles are not enforced.
+	Complain bool
+	// Unrestricted is set for snaps using classic confinement.
+	Unrestricted bool
+
+	FS  []PathRule
+	Net []PortRule
+}
+
+// ExpandableVariables are the environment variables which can be referenced
+// from the paths of profile rules. They depend on the user running the
+// application and thus are only known at the time the profile is applied.
+var ExpandableVariables = []string{
+	"SNAP_REAL_HOME",
+	"SNAP_USER_COMMON",
+	"SNAP_USER_DATA",
+	"XDG_RUNTIME_DIR",
+}
+
+func isExpandable(name string) bool {
+	for _, v := range ExpandableVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePath(path string) error {
+	var badVar string
+	expanded := os.Expand(path, func(name string) string {
+		if !isExpandable(name) {
+			badVar = name
+		}
+		return "/"
+	})
+	if badVar != "" {
+		return fmt.Errorf("cannot use variable %q in path %q", badVar, path)
+	}
+	if !filepath.IsAbs(expanded) || filepath.Clean(path) != path {
+		return fmt.Errorf("path %q must be absolute and clean", path)
+	}
+	return nil
+}
+
+// ParseProfile parses a landlock profile.
+//
+// The profile is line oriented, empty lines and lines starting with "#" are
+// ignored. The following directives are understood:
+//
+//	@complain
+//	@unrestricted
+//	fs <perms> <path>
+//	net <bind|connect> <port|*>
+func ParseProfile(r io.Reader) (*Profile, error) {
+	profile := &Profile{}
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "@complain":
+			profile.Complain = true
+			continue
+		case "@unrestricted":
+			profile.Unrestricted = true
+			continue
+		case "fs", "net":
+		default:
+			return nil, fmt.Errorf("cannot parse line %d: unknown directive %q", lineno, fields[0])
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("cannot parse line %d: expected 3 fields, got %d", lineno, len(fields))
+		}
+		switch fields[0] {
+		case "fs":
+			if _, err := accessFromPerms(fields[1]); err != nil {
+				return nil, fmt.Errorf("cannot parse line %d: %v", lineno, err)
+			}
+			if err := validatePath(fields[2]); err != nil {
+				return nil, fmt.Errorf("cannot parse line %d: %v", lineno, err)
+			}
+			profile.FS = append(profile.FS, PathRule{Perms: fields[1], Path: fields[2]})
+		case "net":
+			if fields[1] != "bind" && fields[1] != "connect" {
+				return nil, fmt.Errorf("cannot parse line %d: unknown network access %q", lineno, fields[1])
+			}
+			port := -1
+			if fields[2] != "*" {
+				n, err := strconv.ParseUint(fields[2], 10, 16)
+				if err != nil {
+					return nil, fmt.Errorf("cannot parse line %d: invalid port %q", lineno, fields[2])
+				}
+				port = int(n)
+			}
+			profile.Net = append(profile.Net, PortRule{Access: fields[1], Port: port})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ValidateSnippet checks that the given snippet is a valid fragment of a
+// landlock profile.
+func ValidateSnippet(snippet string) error {
+	_, err := ParseProfile(bytes.NewBufferString(snippet))
+	return err
+}
+
+// ProfilePath returns the path of the landlock profile for the given security
+// tag, in the given directory.
+func ProfilePath(dir, securityTag string) string {
+	return filepath.Join(dir, securityTag+".landlock")
+}
+
+// probing
+
+type landlockProbe struct {
+	abi  int
+	once sync.Once
+}
+
+var landlockProber = &landlockProbe{}
+
+func (lp *landlockProbe) version() int {
+	lp.once.Do(func() {
+		lp.abi = probeABIVersion()
+	})
+	return lp.abi
+}
+
+// ABIVersion returns the landlock ABI version supported by the running
+// kernel, or 0 if landlock is not supported or has been disabled.
+func ABIVersion() int {
+	return landlockProber.version()
+}
+
+// IsSupported returns true if landlock can be used on this system.
+func IsSupported() bool {
+	return ABIVersion() > 0
+}
+
+// Features returns a list of tags describing the landlock features
+// supported by the kernel.
+func Features() []string {
+	abi := ABIVersion()
+	if abi == 0 {
+		return nil
+	}
+	features := []string{fmt.Sprintf("abi:%d", abi), "fs"}
+	if abi >= 2 {
+		features = append(features, "fs-refer")
+	}
+	if abi >= 3 {
+		features = append(features, "fs-truncate")
+	}
+	if abi >= 4 {
+		features = append(features, "net")
+	}
+	return features
+}
+
+// mocking
+
+// MockABIVersion mocks the landlock ABI version supported by the kernel.
+func MockABIVersion(abi int) (restore func()) {
+	old := landlockProber
+	landlockProber = &landlockProbe{abi: abi}
+	landlockProber.once.Do(func() {})
+	return func() {
+		landlockProber = old
+	}
+}
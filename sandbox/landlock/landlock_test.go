@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/landlock"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type landlockSuite struct{}
+
+var _ = Suite(&landlockSuite{})
+
+func (s *landlockSuite) TestFeatures(c *C) {
+	restore := landlock.MockABIVersion(0)
+	defer restore()
+	c.Check(landlock.IsSupported(), Equals, false)
+	c.Check(landlock.Features(), IsNil)
+
+	restore = landlock.MockABIVersion(1)
+	defer restore()
+	c.Check(landlock.IsSupported(), Equals, true)
+	c.Check(landlock.Features(), DeepEquals, []string{"abi:1", "fs"})
+
+	restore = landlock.MockABIVersion(4)
+	defer restore()
+	c.Check(landlock.ABIVersion(), Equals, 4)
+	c.Check(landlock.Features(), DeepEquals, []string{"abi:4", "fs", "fs-refer", "fs-truncate", "net"})
+}
+
+func (s *landlockSuite) TestParseProfile(c *C) {
+	profile, err := landlock.ParseProfile(bytes.NewBufferString(`
+# comment
+@complain
+fs rx /usr
+fs rw $SNAP_USER_DATA
+net bind 8080
+net connect *
+`))
+	c.Assert(err, IsNil)
+	c.Check(profile, DeepEquals, &landlock.Profile{
+		Complain: true,
+		FS: []landlock.PathRule{
+			{Perms: "rx", Path: "/usr"},
+			{Perms: "rw", Path: "$SNAP_USER_DATA"},
+		},
+		Net: []landlock.PortRule{
+			{Access: "bind", Port: 8080},
+			{Access: "connect", Port: -1},
+		},
+	})
+}
+
+func (s *landlockSuite) TestParseProfileErrors(c *C) {
+	for _, t := range []struct {
+		profile string
+		err     string
+	}{
+		{"fs rx", `cannot parse line 1: expected 3 fields, got 2`},
+		{"fs rz /usr", `cannot parse line 1: unknown permission 'z'`},
+		{"fs r usr", `cannot parse line 1: path "usr" must be absolute and clean`},
+		{"fs r /usr/../etc", `cannot parse line 1: path "/usr/../etc" must be absolute and clean`},
+		{"fs r $HOME/foo", `cannot parse line 1: cannot use variable "HOME" in path "\$HOME/foo"`},
+		{"net listen 80", `cannot parse line 1: unknown network access "listen"`},
+		{"net bind 100000", `cannot parse line 1: invalid port "100000"`},
+		{"\nfoo bar baz", `cannot parse line 2: unknown directive "foo"`},
+	} {
+		_, err := landlock.ParseProfile(bytes.NewBufferString(t.profile))
+		c.Check(err, ErrorMatches, t.err, Commentf("%q", t.profile))
+		c.Check(landlock.ValidateSnippet(t.profile), ErrorMatches, t.err)
+	}
+}
+
+func (s *landlockSuite) TestAccessFromPerms(c *C) {
+	access, err := landlock.AccessFromPerms("rx")
+	c.Assert(err, IsNil)
+	c.Check(access, Equals, uint64(0xd))
+}
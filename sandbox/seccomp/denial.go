@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/sandbox/audit"
+)
+
+// auditTypeSeccomp is the type of the audit messages logged for system calls
+// matching a seccomp rule with logging.
+const auditTypeSeccomp = "1326"
+
+// Denial describes a system call which was denied by seccomp.
+type Denial struct {
+	// Label is the security label of the process, which is the name of its
+	// AppArmor profile when AppArmor is enabled.
+	Label string
+	// Exe is the path of the executable of the process.
+	Exe     string
+	Syscall int
+	// Compat is set when the system call was made with the compatibility
+	// (32-bit) ABI of the architecture.
+	Compat bool
+}
+
+// ParseDenial parses an audit message logged by seccomp. It returns false if
+// the message does not describe a denial.
+func ParseDenial(msg string) (*Denial, bool) {
+	fields := audit.ParseFields(msg, "exe")
+	if fields["type"] != auditTypeSeccomp {
+		// the type is not part of the message when reading it from the
+		// audit transport of the journal
+		if !strings.Contains(msg, "syscall=") || !strings.Contains(msg, "code=") {
+			return nil, false
+		}
+	}
+	syscall, err := strconv.Atoi(fields["syscall"])
+	if err != nil {
+		return nil, false
+	}
+	return &Denial{
+		Label:   strings.TrimPrefix(fields["subj"], "="),
+		Exe:     fields["exe"],
+		Syscall: syscall,
+		Compat:  fields["compat"] == "1",
+	}, true
+}
+
+// SecurityTag returns the security tag of the snap application or hook which
+// made the system call, or an empty string if it cannot be determined from the
+// label of the process.
+func (d *Denial) SecurityTag() string {
+	tag, _, _ := strings.Cut(d.Label, "//")
+	if !strings.HasPrefix(tag, "snap.") {
+		return ""
+	}
+	return tag
+}
+
+// SyscallName returns the name of the denied system call, or an empty string
+// if it is not known.
+func (d *Denial) SyscallName() string {
+	if d.Compat {
+		// numbers of compat system calls are different
+		return ""
+	}
+	return syscallNames[d.Syscall]
+}
+
+// String returns a short description of the denied system call.
+func (d *Denial) String() string {
+	if name := d.SyscallName(); name != "" {
+		return "syscall " + name
+	}
+	return fmt.Sprintf("syscall %d", d.Syscall)
+}
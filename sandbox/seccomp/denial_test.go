@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp_test
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/snapcore/snapd/sandbox/seccomp"
+)
+
+type denialSuite struct{}
+
+var _ = Suite(&denialSuite{})
+
+func (s *denialSuite) TestParseDenial(c *C) {
+	d, ok := seccomp.ParseDenial(`audit: type=1326 audit(1700000000.123:42): auid=1000 uid=1000 gid=1000 ses=3 subj=snap.foo.app (enforce) pid=1234 comm="foo" exe="/snap/foo/1/bin/foo" sig=0 arch=c000003e syscall=165 compat=0 ip=0x7f0000000000 code=0x50000`)
+	c.Assert(ok, Equals, true)
+	c.Check(d, DeepEquals, &seccomp.Denial{
+		Label:   "snap.foo.app",
+		Exe:     "/snap/foo/1/bin/foo",
+		Syscall: 165,
+	})
+	c.Check(d.SecurityTag(), Equals, "snap.foo.app")
+
+	// as read from the audit transport
+	d, ok = seccomp.ParseDenial(`auid=1000 uid=1000 gid=1000 ses=3 subj=unconfined pid=1234 comm="foo" exe=2F736E61702F666F6F2F312F62696E2F6120666F6F sig=0 arch=c000003e syscall=165 compat=1 ip=0x7f0000000000 code=0x50000`)
+	c.Assert(ok, Equals, true)
+	c.Check(d.Exe, Equals, "/snap/foo/1/bin/a foo")
+	c.Check(d.Compat, Equals, true)
+	c.Check(d.SecurityTag(), Equals, "")
+}
+
+func (s *denialSuite) TestParseDenialNotDenial(c *C) {
+	for _, msg := range []string{
+		`audit: type=1400 audit(1700000000.123:42): apparmor="DENIED" operation="open" profile="snap.foo.app"`,
+		`audit: type=1326 audit(1700000000.123:42): syscall=foo`,
+		`some random kernel message`,
+	} {
+		_, ok := seccomp.ParseDenial(msg)
+		c.Check(ok, Equals, false, Commentf("%s", msg))
+	}
+}
+
+func (s *denialSuite) TestString(c *C) {
+	d := &seccomp.Denial{Syscall: unix.SYS_MOUNT}
+	c.Check(d.SyscallName(), Equals, "mount")
+	c.Check(d.String(), Equals, "syscall mount")
+
+	d = &seccomp.Denial{Syscall: unix.SYS_MOUNT, Compat: true}
+	c.Check(d.SyscallName(), Equals, "")
+	c.Check(d.String(), Equals, fmt.Sprintf("syscall %d", unix.SYS_MOUNT))
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// syscallNames maps the numbers of the native system calls which are commonly
+// denied to snaps to their names.
+var syscallNames = map[int]string{
+	unix.SYS_ACCT:               "acct",
+	unix.SYS_ADD_KEY:            "add_key",
+	unix.SYS_ADJTIMEX:           "adjtimex",
+	unix.SYS_BIND:               "bind",
+	unix.SYS_BPF:                "bpf",
+	unix.SYS_CHROOT:             "chroot",
+	unix.SYS_CLOCK_SETTIME:      "clock_settime",
+	unix.SYS_DELETE_MODULE:      "delete_module",
+	unix.SYS_FANOTIFY_INIT:      "fanotify_init",
+	unix.SYS_FANOTIFY_MARK:      "fanotify_mark",
+	unix.SYS_FCHOWN:             "fchown",
+	unix.SYS_FCHOWNAT:           "fchownat",
+	unix.SYS_FINIT_MODULE:       "finit_module",
+	unix.SYS_INIT_MODULE:        "init_module",
+	unix.SYS_IOCTL:              "ioctl",
+	unix.SYS_IOPRIO_SET:         "ioprio_set",
+	unix.SYS_KCMP:               "kcmp",
+	unix.SYS_KEXEC_LOAD:         "kexec_load",
+	unix.SYS_KEYCTL:             "keyctl",
+	unix.SYS_MBIND:              "mbind",
+	unix.SYS_MIGRATE_PAGES:      "migrate_pages",
+	unix.SYS_MKNODAT:            "mknodat",
+	unix.SYS_MOUNT:              "mount",
+	unix.SYS_MOVE_PAGES:         "move_pages",
+	unix.SYS_NAME_TO_HANDLE_AT:  "name_to_handle_at",
+	unix.SYS_OPEN_BY_HANDLE_AT:  "open_by_handle_at",
+	unix.SYS_PERF_EVENT_OPEN:    "perf_event_open",
+	unix.SYS_PERSONALITY:        "personality",
+	unix.SYS_PIVOT_ROOT:         "pivot_root",
+	unix.SYS_PROCESS_VM_READV:   "process_vm_readv",
+	unix.SYS_PROCESS_VM_WRITEV:  "process_vm_writev",
+	unix.SYS_PTRACE:             "ptrace",
+	unix.SYS_QUOTACTL:           "quotactl",
+	unix.SYS_REBOOT:             "reboot",
+	unix.SYS_REQUEST_KEY:        "request_key",
+	unix.SYS_SCHED_SETATTR:      "sched_setattr",
+	unix.SYS_SCHED_SETSCHEDULER: "sched_setscheduler",
+	unix.SYS_SETDOMAINNAME:      "setdomainname",
+	unix.SYS_SETGID:             "setgid",
+	unix.SYS_SETGROUPS:          "setgroups",
+	unix.SYS_SETHOSTNAME:        "sethostname",
+	unix.SYS_SETNS:              "setns",
+	unix.SYS_SETPRIORITY:        "setpriority",
+	unix.SYS_SETRESGID:          "setresgid",
+	unix.SYS_SETRESUID:          "setresuid",
+	unix.SYS_SETTIMEOFDAY:       "settimeofday",
+	unix.SYS_SETUID:             "setuid",
+	unix.SYS_SET_MEMPOLICY:      "set_mempolicy",
+	unix.SYS_SOCKET:             "socket",
+	unix.SYS_SWAPOFF:            "swapoff",
+	unix.SYS_SWAPON:             "swapon",
+	unix.SYS_SYSLOG:             "syslog",
+	unix.SYS_UMOUNT2:            "umount2",
+	unix.SYS_UNSHARE:            "unshare",
+	unix.SYS_USERFAULTFD:        "userfaultfd",
+	unix.SYS_VHANGUP:            "vhangup",
+}
@@ -0,0 +1,47 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux
+
+import (
+	"os/exec"
+
+	"github.com/snapcore/snapd/osutil"
+)
+
+// LoadModule installs the policy module at the given path, replacing the
+// module of the same name if it is already installed. The name of the module
+// is the name of the file, without the extension. Both CIL sources and
+// compiled policy packages are accepted.
+func LoadModule(path string) error {
+	output, err := exec.Command("semodule", "-i", path).CombinedOutput()
+	if err != nil {
+		return osutil.OutputErr(output, err)
+	}
+	return nil
+}
+
+// RemoveModule removes the policy module of the given name.
+func RemoveModule(name string) error {
+	output, err := exec.Command("semodule", "-r", name).CombinedOutput()
+	if err != nil {
+		return osutil.OutputErr(output, err)
+	}
+	return nil
+}
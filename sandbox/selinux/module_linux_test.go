@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2024 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package selinux_test
+
+import (
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/selinux"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type moduleSuite struct{}
+
+var _ = check.Suite(&moduleSuite{})
+
+func (s *moduleSuite) TestLoadModule(c *check.C) {
+	cmd := testutil.MockCommand(c, "semodule", "")
+	defer cmd.Restore()
+
+	err := selinux.LoadModule("/path/to/snap_foo.cil")
+	c.Assert(err, check.IsNil)
+	c.Assert(cmd.Calls(), check.DeepEquals, [][]string{
+		{"semodule", "-i", "/path/to/snap_foo.cil"},
+	})
+}
+
+func (s *moduleSuite) TestLoadModuleError(c *check.C) {
+	cmd := testutil.MockCommand(c, "semodule", "echo 'Failed to resolve typeattributeset statement'; exit 1")
+	defer cmd.Restore()
+
+	err := selinux.LoadModule("/path/to/snap_foo.cil")
+	c.Assert(err, check.ErrorMatches, "Failed to resolve typeattributeset statement")
+}
+
+func (s *moduleSuite) TestRemoveModule(c *check.C) {
+	cmd := testutil.MockCommand(c, "semodule", "")
+	defer cmd.Restore()
+
+	err := selinux.RemoveModule("snap_foo")
+	c.Assert(err, check.IsNil)
+	c.Assert(cmd.Calls(), check.DeepEquals, [][]string{
+		{"semodule", "-r", "snap_foo"},
+	})
+}
+
+func (s *moduleSuite) TestRemoveModuleError(c *check.C) {
+	cmd := testutil.MockCommand(c, "semodule", "echo 'No such module'; exit 1")
+	defer cmd.Restore()
+
+	err := selinux.RemoveModule("snap_foo")
+	c.Assert(err, check.ErrorMatches, "No such module")
+}
@@ -19,6 +19,12 @@
 
 package secboot
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
 // EncryptionType specifies what encryption backend should be used (if any)
 type EncryptionType string
 
@@ -41,3 +47,27 @@ type RecoveryKeyDevice struct {
 	// present in the user session keyring
 	AuthorizingKeyFile string
 }
+
+// KeySlot describes a used key slot of an encrypted device.
+type KeySlot struct {
+	ID int
+	// Type describes what the key slot is used for, e.g.
+	// "encryption-key", "recovery-key" or "named-recovery-key".
+	Type string
+	// Name is only set for named recovery keys.
+	Name string
+	// Created is only known for named recovery keys.
+	Created time.Time
+}
+
+var validRecoveryKeyName = regexp.MustCompile(`^[a-z0-9](?:-?[a-z0-9])*$`)
+
+// ValidateRecoveryKeyName checks that the name of a named recovery key
+// consists of lowercase letters, digits and single dashes (not at the start or
+// end) and is at most 40 characters long.
+func ValidateRecoveryKeyName(name string) error {
+	if len(name) > 40 || !validRecoveryKeyName.MatchString(name) {
+		return fmt.Errorf("invalid recovery key name %q", name)
+	}
+	return nil
+}
@@ -32,6 +32,26 @@ func RemoveRecoveryKeys(map[RecoveryKeyDevice]string) error {
 	return errBuildWithoutSecboot
 }
 
+func ListKeySlots(mountpoint string) ([]KeySlot, error) {
+	return nil, errBuildWithoutSecboot
+}
+
+func AddNamedRecoveryKey(string, []RecoveryKeyDevice) (keys.RecoveryKey, error) {
+	return keys.RecoveryKey{}, errBuildWithoutSecboot
+}
+
+func RotateNamedRecoveryKey(string, []RecoveryKeyDevice) (keys.RecoveryKey, error) {
+	return keys.RecoveryKey{}, errBuildWithoutSecboot
+}
+
+func RemoveNamedRecoveryKey(string, []RecoveryKeyDevice) error {
+	return errBuildWithoutSecboot
+}
+
+func ChangePassphrase(oldPassphrase, newPassphrase string, mountpoints []string) error {
+	return errBuildWithoutSecboot
+}
+
 func StageEncryptionKeyChange(node string, key keys.EncryptionKey) error {
 	return errBuildWithoutSecboot
 }
@@ -81,6 +81,13 @@ func AddRecoveryKey(key keys.EncryptionKey, rkey keys.RecoveryKey, node string)
 	return keymgr.AddRecoveryKeyToLUKSDeviceUsingKey(rkey, key, node)
 }
 
+// AddPassphrase adds a passphrase to the existing encrypted volume created
+// with FormatEncryptedDevice on the block device given by node. The existing
+// key to the encrypted volume is provided in the key argument.
+func AddPassphrase(key keys.EncryptionKey, passphrase string, node string) error {
+	return keymgr.AddPassphraseToLUKSDeviceUsingKey(passphrase, key, node)
+}
+
 func runSnapFDEKeymgr(args []string, stdin io.Reader) error {
 	toolPath, err := snapdtool.InternalToolPath("snap-fde-keymgr")
 	if err != nil {
@@ -177,6 +184,99 @@ func RemoveRecoveryKeys(rkeyDevToKey map[RecoveryKeyDevice]string) error {
 	return nil
 }
 
+// ListKeySlots returns the used key slots of the encrypted device
+// corresponding to the given mount point.
+func ListKeySlots(mountpoint string) ([]KeySlot, error) {
+	dev, err := devByPartUUIDFromMount(mountpoint)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find matching device: %v", err)
+	}
+	slots, err := keymgr.ListLUKSDeviceKeySlots(dev)
+	if err != nil {
+		return nil, err
+	}
+	keySlots := make([]KeySlot, 0, len(slots))
+	for _, slot := range slots {
+		keySlots = append(keySlots, KeySlot{
+			ID:      slot.ID,
+			Type:    string(slot.Type),
+			Name:    slot.Name,
+			Created: slot.Created,
+		})
+	}
+	return keySlots, nil
+}
+
+func runNamedRecoveryKeyCommand(command, name string, rkeyDevs []RecoveryKeyDevice, stdin io.Reader) error {
+	args := []string{
+		command,
+		"--name", name,
+	}
+	for _, rkeyDev := range rkeyDevs {
+		dev, err := devByPartUUIDFromMount(rkeyDev.Mountpoint)
+		if err != nil {
+			return fmt.Errorf("cannot find matching device for: %v", err)
+		}
+		authzMethod := "keyring"
+		if rkeyDev.AuthorizingKeyFile != "" {
+			authzMethod = "file:" + rkeyDev.AuthorizingKeyFile
+		}
+		args = append(args, []string{
+			"--devices", dev,
+			"--authorizations", authzMethod,
+		}...)
+	}
+
+	if err := runSnapFDEKeymgr(args, stdin); err != nil {
+		return fmt.Errorf("cannot run keymgr tool: %v", err)
+	}
+	return nil
+}
+
+func runNamedRecoveryKeyCommandWithNewKey(command, name string, rkeyDevs []RecoveryKeyDevice) (keys.RecoveryKey, error) {
+	rkey, err := keys.NewRecoveryKey()
+	if err != nil {
+		return keys.RecoveryKey{}, fmt.Errorf("cannot create recovery key: %v", err)
+	}
+	var buf bytes.Buffer
+	err = json.NewEncoder(&buf).Encode(struct {
+		Key []byte `json:"key"`
+	}{
+		Key: rkey[:],
+	})
+	if err != nil {
+		return keys.RecoveryKey{}, fmt.Errorf("cannot encode recovery key for the FDE key manager tool: %v", err)
+	}
+	if err := runNamedRecoveryKeyCommand(command, name, rkeyDevs, &buf); err != nil {
+		return keys.RecoveryKey{}, err
+	}
+	return rkey, nil
+}
+
+// AddNamedRecoveryKey generates a new recovery key and adds it under the given
+// name to all the encrypted devices. The key is not stored anywhere, it is
+// only returned to the caller.
+func AddNamedRecoveryKey(name string, rkeyDevs []RecoveryKeyDevice) (keys.RecoveryKey, error) {
+	logger.Debugf("adding recovery key %q", name)
+	return runNamedRecoveryKeyCommandWithNewKey("add-named-recovery-key", name, rkeyDevs)
+}
+
+// RotateNamedRecoveryKey replaces the named recovery key of all the encrypted
+// devices with a newly generated one, which is returned. The old key keeps
+// working on all devices until the new one was added to and verified on all of
+// them.
+func RotateNamedRecoveryKey(name string, rkeyDevs []RecoveryKeyDevice) (keys.RecoveryKey, error) {
+	logger.Debugf("rotating recovery key %q", name)
+	return runNamedRecoveryKeyCommandWithNewKey("rotate-named-recovery-key", name, rkeyDevs)
+}
+
+// RemoveNamedRecoveryKey removes the named recovery key from all the encrypted
+// devices.
+func RemoveNamedRecoveryKey(name string, rkeyDevs []RecoveryKeyDevice) error {
+	logger.Debugf("removing recovery key %q", name)
+	return runNamedRecoveryKeyCommand("remove-named-recovery-key", name, rkeyDevs, nil)
+}
+
 // StageEncryptionKeyChange stages a new encryption key for a given encrypted
 // device. The new key is added into a temporary slot. To complete the
 // encryption key change process, a call to TransitionEncryptionKeyChange is
@@ -243,3 +343,37 @@ func TransitionEncryptionKeyChange(mountpoint string, key keys.EncryptionKey) er
 	}
 	return nil
 }
+
+// ChangePassphrase changes the passphrase of the encrypted devices
+// corresponding to the given mount points. The change is authorized using the
+// old passphrase.
+func ChangePassphrase(oldPassphrase, newPassphrase string, mountpoints []string) error {
+	command := []string{
+		"change-passphrase",
+	}
+	for _, mp := range mountpoints {
+		dev, err := devByPartUUIDFromMount(mp)
+		if err != nil {
+			return fmt.Errorf("cannot find matching device: %v", err)
+		}
+		logger.Debugf("changing passphrase on device: %v", dev)
+		command = append(command, "--devices", dev)
+	}
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(struct {
+		Old string `json:"old"`
+		New string `json:"new"`
+	}{
+		Old: oldPassphrase,
+		New: newPassphrase,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode passphrases for the FDE key manager tool: %v", err)
+	}
+
+	if err := runSnapFDEKeymgr(command, &buf); err != nil {
+		return fmt.Errorf("cannot run FDE key manager tool: %v", err)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	sb "github.com/snapcore/secboot"
 	. "gopkg.in/check.v1"
@@ -122,7 +123,8 @@ done
 	s.AddCleanup(s.systemdRunCmd.Restore)
 	s.keymgrCmd = testutil.MockCommand(c, filepath.Join(dirs.DistroLibExecDir, "snap-fde-keymgr"), fmt.Sprintf(`
 set -e
-if [ "$1" = "change-encryption-key" ]; then
+if [ "$1" = "change-encryption-key" ] || [ "$1" = "change-passphrase" ] ||
+   [ "$1" = "add-named-recovery-key" ] || [ "$1" = "rotate-named-recovery-key" ]; then
     cat > %s/input
     exit 0
 fi
@@ -298,6 +300,38 @@ func (s *keymgrSuite) TestTransitionEncryptionKeyHappy(c *C) {
 	c.Check(filepath.Join(s.d, "input"), testutil.FileEquals, b.String())
 }
 
+func (s *keymgrSuite) TestChangePassphraseHappy(c *C) {
+	s.mocksForDeviceMounts(c)
+
+	err := secboot.ChangePassphrase("old", "new", []string{"/foo", "/bar"})
+	c.Assert(err, IsNil)
+	c.Check(s.systemdRunCmd.Calls(), DeepEquals, [][]string{
+		{
+			"systemd-run",
+			"--wait", "--pipe", "--collect", "--service-type=exec", "--quiet",
+			"--property=KeyringMode=inherit", "--",
+			s.keymgrCmd.Exe(), "change-passphrase",
+			"--devices", "/dev/disk/by-partuuid/foo-uuid",
+			"--devices", "/dev/disk/by-partuuid/bar-uuid",
+		},
+	})
+	c.Check(filepath.Join(s.d, "input"), testutil.FileEquals, `{"old":"old","new":"new"}`+"\n")
+}
+
+func (s *keymgrSuite) TestChangePassphraseNoMountDev(c *C) {
+	restore := osutil.MockMountInfo(`
+27 27 600:3 / /foo rw,relatime shared:7 - vfat /dev/mapper/foo rw
+`[1:])
+	s.AddCleanup(restore)
+
+	udevadmCmd := testutil.MockCommand(c, "udevadm", `echo nope; exit 1`)
+	defer udevadmCmd.Restore()
+
+	err := secboot.ChangePassphrase("old", "new", []string{"/foo"})
+	c.Assert(err, ErrorMatches, "cannot find matching device: cannot partition for mount /foo: cannot process udev properties of /dev/mapper/foo: nope")
+	c.Check(s.systemdRunCmd.Calls(), HasLen, 0)
+}
+
 func (s *keymgrSuite) mocksForDeviceMounts(c *C) (udevadmCmd *testutil.MockCmd) {
 	restore := osutil.MockMountInfo(`
 27 27 600:3 / /foo rw,relatime shared:7 - vfat /dev/mapper/foo rw
@@ -446,3 +480,108 @@ func (s *keymgrSuite) TestRemoveRecoveryKey(c *C) {
 	c.Check(s.systemdRunCmd.Calls(), DeepEquals, expectedSystemdRunCalls)
 	c.Check(s.keymgrCmd.Calls(), DeepEquals, expectedKeymgrCalls)
 }
+
+func (s *keymgrSuite) TestListKeySlots(c *C) {
+	s.mocksForDeviceMounts(c)
+	cryptsetupCmd := testutil.MockCommand(c, "cryptsetup", `
+cat <<'EOF'
+{
+  "keyslots": {"0": {"type": "luks2"}, "1": {"type": "luks2"}, "4": {"type": "luks2"}},
+  "tokens": {"0": {"type": "snapd-recovery-key", "keyslots": ["4"], "snapd_name": "backup", "snapd_created": "2026-01-02T03:04:05Z"}}
+}
+EOF
+`)
+	defer cryptsetupCmd.Restore()
+
+	slots, err := secboot.ListKeySlots("/foo")
+	c.Assert(err, IsNil)
+	c.Check(cryptsetupCmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/disk/by-partuuid/foo-uuid"},
+	})
+	c.Check(slots, DeepEquals, []secboot.KeySlot{
+		{ID: 0, Type: "encryption-key"},
+		{ID: 1, Type: "recovery-key"},
+		{ID: 4, Type: "named-recovery-key", Name: "backup", Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+	})
+	// nothing goes through the key manager tool
+	c.Check(s.systemdRunCmd.Calls(), HasLen, 0)
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKey(c *C) {
+	s.mocksForDeviceMounts(c)
+
+	rkey, err := secboot.AddNamedRecoveryKey("backup", []secboot.RecoveryKeyDevice{
+		{Mountpoint: "/foo"},
+		{Mountpoint: "/bar", AuthorizingKeyFile: "/authz/key.file"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.keymgrCmd.Calls(), DeepEquals, [][]string{
+		{
+			"snap-fde-keymgr", "add-named-recovery-key",
+			"--name", "backup",
+			"--devices", "/dev/disk/by-partuuid/foo-uuid", "--authorizations", "keyring",
+			"--devices", "/dev/disk/by-partuuid/bar-uuid", "--authorizations", "file:/authz/key.file",
+		},
+	})
+	// the generated key is passed to the tool
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(struct {
+		Key []byte `json:"key"`
+	}{
+		Key: rkey[:],
+	})
+	c.Check(filepath.Join(s.d, "input"), testutil.FileEquals, b.String())
+	c.Check(rkey, Not(DeepEquals), keys.RecoveryKey{})
+}
+
+func (s *keymgrSuite) TestRotateNamedRecoveryKey(c *C) {
+	s.mocksForDeviceMounts(c)
+
+	rkey, err := secboot.RotateNamedRecoveryKey("backup", []secboot.RecoveryKeyDevice{
+		{Mountpoint: "/foo"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.keymgrCmd.Calls(), DeepEquals, [][]string{
+		{
+			"snap-fde-keymgr", "rotate-named-recovery-key",
+			"--name", "backup",
+			"--devices", "/dev/disk/by-partuuid/foo-uuid", "--authorizations", "keyring",
+		},
+	})
+	var b bytes.Buffer
+	json.NewEncoder(&b).Encode(struct {
+		Key []byte `json:"key"`
+	}{
+		Key: rkey[:],
+	})
+	c.Check(filepath.Join(s.d, "input"), testutil.FileEquals, b.String())
+}
+
+func (s *keymgrSuite) TestRemoveNamedRecoveryKey(c *C) {
+	s.mocksForDeviceMounts(c)
+
+	err := secboot.RemoveNamedRecoveryKey("backup", []secboot.RecoveryKeyDevice{
+		{Mountpoint: "/foo"},
+		{Mountpoint: "/bar", AuthorizingKeyFile: "/authz/key.file"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(s.keymgrCmd.Calls(), DeepEquals, [][]string{
+		{
+			"snap-fde-keymgr", "remove-named-recovery-key",
+			"--name", "backup",
+			"--devices", "/dev/disk/by-partuuid/foo-uuid", "--authorizations", "keyring",
+			"--devices", "/dev/disk/by-partuuid/bar-uuid", "--authorizations", "file:/authz/key.file",
+		},
+	})
+}
+
+func (s *keymgrSuite) TestNamedRecoveryKeyBadKeymgr(c *C) {
+	s.mocksForDeviceMounts(c)
+	keymgrCmd := testutil.MockCommand(c, filepath.Join(dirs.DistroLibExecDir, "snap-fde-keymgr"), `echo keymgr very unhappy; exit 1`)
+	defer keymgrCmd.Restore()
+
+	_, err := secboot.AddNamedRecoveryKey("backup", []secboot.RecoveryKeyDevice{{Mountpoint: "/foo"}})
+	c.Assert(err, ErrorMatches, "cannot run keymgr tool: .*keymgr very unhappy.*")
+	err = secboot.RemoveNamedRecoveryKey("backup", []secboot.RecoveryKeyDevice{{Mountpoint: "/foo"}})
+	c.Assert(err, ErrorMatches, "cannot run keymgr tool: .*keymgr very unhappy.*")
+}
@@ -23,6 +23,9 @@ import (
 	"testing"
 
 	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget/device"
+	"github.com/snapcore/snapd/secboot"
 )
 
 func TestSecboot(t *testing.T) { TestingT(t) }
@@ -36,3 +39,26 @@ var _ = Suite(&encryptSuite{})
 func (s *encryptSuite) SetUpTest(c *C) {
 	s.dir = c.MkDir()
 }
+
+func (s *encryptSuite) TestCheckVolumesAuthSupported(c *C) {
+	c.Check(secboot.CheckVolumesAuthSupported(nil), IsNil)
+	c.Check(secboot.CheckVolumesAuthSupported(&device.VolumesAuthOptions{
+		Mode:       device.AuthModePassphrase,
+		Passphrase: "1234",
+	}), IsNil)
+	c.Check(secboot.CheckVolumesAuthSupported(&device.VolumesAuthOptions{
+		Mode: "pin",
+	}), ErrorMatches, `invalid authentication mode "pin"`)
+	c.Check(secboot.CheckVolumesAuthSupported(&device.VolumesAuthOptions{
+		Mode: "foo",
+	}), ErrorMatches, `invalid authentication mode "foo"`)
+}
+
+func (s *encryptSuite) TestValidateRecoveryKeyName(c *C) {
+	for _, name := range []string{"a", "backup", "key-1", "0", "a-b-c", "aaaaaaaaaabbbbbbbbbbccccccccccdddddddddd"} {
+		c.Check(secboot.ValidateRecoveryKeyName(name), IsNil, Commentf("%q", name))
+	}
+	for _, name := range []string{"", "-a", "a-", "a--b", "Backup", "key_1", "a b", "aaaaaaaaaabbbbbbbbbbccccccccccdddddddddde"} {
+		c.Check(secboot.ValidateRecoveryKeyName(name), ErrorMatches, `invalid recovery key name ".*"`, Commentf("%q", name))
+	}
+}
@@ -236,3 +236,11 @@ func MockSbLockoutAuthSet(f func(tpm *sb_tpm2.Connection) bool) (restore func())
 	lockoutAuthSet = f
 	return restore
 }
+
+func MockSystemdAskPassword(f func(name, device string) (string, error)) (restore func()) {
+	old := systemdAskPassword
+	systemdAskPassword = f
+	return func() {
+		systemdAskPassword = old
+	}
+}
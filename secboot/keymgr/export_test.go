@@ -19,6 +19,8 @@
 package keymgr
 
 import (
+	"time"
+
 	sb "github.com/snapcore/secboot"
 
 	"github.com/snapcore/snapd/testutil"
@@ -31,3 +33,9 @@ func MockGetDiskUnlockKeyFromKernel(f func(prefix, devicePath string, remove boo
 }
 
 var RecoveryKDF = recoveryKDF
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	restore = testutil.Backup(&timeNow)
+	timeNow = f
+	return restore
+}
@@ -36,8 +36,11 @@ const (
 	encryptionKeySlot = 0
 	// key slot used by the recovery key
 	recoveryKeySlot = 1
-	// temporary key slot used when changing the encryption key
+	// temporary key slot used when changing the encryption key or the
+	// passphrase
 	tempKeySlot = recoveryKeySlot + 1
+	// key slot used by the passphrase
+	passphraseKeySlot = tempKeySlot + 1
 )
 
 var (
@@ -160,6 +163,89 @@ func RemoveRecoveryKeyFromLUKSDeviceUsingKey(currKey keys.EncryptionKey, dev str
 	return nil
 }
 
+// AddPassphraseToLUKSDeviceUsingKey adds a passphrase to the existing LUKS
+// encrypted volume on the block device given by dev. The existing key to the
+// encrypted volume is provided in the currKey argument and used to authorize
+// the operation. The passphrase is added to keyslot 3.
+//
+// Unlike for the recovery key, the KDF parameters are benchmarked by
+// cryptsetup as a passphrase may be of low entropy.
+func AddPassphraseToLUKSDeviceUsingKey(passphrase string, currKey keys.EncryptionKey, dev string) error {
+	if passphrase == "" {
+		return fmt.Errorf("cannot use an empty passphrase")
+	}
+	options := luks2.AddKeyOptions{
+		Slot: passphraseKeySlot,
+	}
+	if err := luks2.AddKey(dev, currKey, []byte(passphrase), &options); err != nil {
+		return fmt.Errorf("cannot add passphrase: %v", err)
+	}
+	return nil
+}
+
+// StageLUKSDevicePassphraseChange stages a new passphrase with the goal of
+// replacing the passphrase in keyslot 3 of a LUKS2 device. The operation is
+// authorized using the old passphrase. Once staged, the device can be unlocked
+// with either passphrase.
+func StageLUKSDevicePassphraseChange(oldPassphrase, newPassphrase string, dev string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("cannot use an empty passphrase")
+	}
+	oldKey := []byte(oldPassphrase)
+
+	// free up the temp slot
+	if err := luks2.KillSlot(dev, tempKeySlot, oldKey); err != nil {
+		if !isKeyslotNotActive(err) {
+			return fmt.Errorf("cannot kill the temporary keyslot: %v", err)
+		}
+	}
+	options := luks2.AddKeyOptions{
+		Slot: tempKeySlot,
+	}
+	if err := luks2.AddKey(dev, oldKey, []byte(newPassphrase), &options); err != nil {
+		return fmt.Errorf("cannot add temporary passphrase: %v", err)
+	}
+	return nil
+}
+
+// UnstageLUKSDevicePassphraseChange removes a passphrase staged by
+// StageLUKSDevicePassphraseChange, leaving the old passphrase as the only one
+// to unlock the device. The operation is authorized using the old passphrase.
+func UnstageLUKSDevicePassphraseChange(oldPassphrase string, dev string) error {
+	if err := luks2.KillSlot(dev, tempKeySlot, []byte(oldPassphrase)); err != nil {
+		if !isKeyslotNotActive(err) {
+			return fmt.Errorf("cannot kill the temporary keyslot: %v", err)
+		}
+	}
+	return nil
+}
+
+// TransitionLUKSDevicePassphraseChange completes the change of the passphrase
+// in keyslot 3 to the new passphrase, which must have been staged before with
+// StageLUKSDevicePassphraseChange and thus authorizes the operations.
+func TransitionLUKSDevicePassphraseChange(newPassphrase string, dev string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("cannot use an empty passphrase")
+	}
+	newKey := []byte(newPassphrase)
+
+	if err := luks2.KillSlot(dev, passphraseKeySlot, newKey); err != nil {
+		if !isKeyslotNotActive(err) {
+			return fmt.Errorf("cannot kill the passphrase keyslot: %v", err)
+		}
+	}
+	options := luks2.AddKeyOptions{
+		Slot: passphraseKeySlot,
+	}
+	if err := luks2.AddKey(dev, newKey, newKey, &options); err != nil {
+		return fmt.Errorf("cannot add new passphrase: %v", err)
+	}
+	if err := luks2.KillSlot(dev, tempKeySlot, newKey); err != nil {
+		return fmt.Errorf("cannot kill the temporary keyslot: %v", err)
+	}
+	return nil
+}
+
 // StageLUKSDeviceEncryptionKeyChange stages a new encryption key with the goal
 // of changing the main encryption key referenced in keyslot 0. The operation is
 // authorized using the key that unlocked the device and is stored in the
@@ -42,6 +42,7 @@ type keymgrSuite struct {
 
 	rootDir       string
 	cryptsetupCmd *testutil.MockCmd
+	tokenInput    string
 }
 
 var _ = Suite(&keymgrSuite{})
@@ -859,3 +860,155 @@ CmaTotal:         131072 kB
 		ForceIterations: 4,
 	})
 }
+
+func (s *keymgrSuite) TestAddPassphraseToLUKSDeviceUsingKey(c *C) {
+	cmd := s.mockCryptsetupForAddKey(c)
+	defer cmd.Restore()
+
+	unlockKey := keys.EncryptionKey{1, 2, 3, 4}
+	err := keymgr.AddPassphraseToLUKSDeviceUsingKey("secret passphrase", unlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{
+			"cryptsetup", "luksAddKey", "--type", "luks2",
+			"--key-file", "-", "--keyfile-size", "4",
+			"--batch-mode",
+			"--pbkdf", "argon2i",
+			"--key-slot", "3",
+			"/dev/foobar", "-",
+		},
+	})
+	c.Check(filepath.Join(s.rootDir, "cryptsetup.input"), testutil.FileEquals, append([]byte(unlockKey), "secret passphrase"...))
+}
+
+func (s *keymgrSuite) TestAddPassphraseToLUKSDeviceUsingKeyErrors(c *C) {
+	err := keymgr.AddPassphraseToLUKSDeviceUsingKey("", keys.EncryptionKey{1, 2, 3, 4}, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot use an empty passphrase")
+	c.Check(s.cryptsetupCmd.Calls(), HasLen, 0)
+
+	cmd := testutil.MockCommand(c, "cryptsetup", `echo "Key slot 3 is full, please select another one." >&2; exit 1`)
+	defer cmd.Restore()
+	err = keymgr.AddPassphraseToLUKSDeviceUsingKey("secret", keys.EncryptionKey{1, 2, 3, 4}, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot add passphrase: cryptsetup failed with: Key slot 3 is full, please select another one.")
+}
+
+func (s *keymgrSuite) mockPassphraseCryptsetup(c *C) *testutil.MockCmd {
+	return testutil.MockCommand(c, "cryptsetup", fmt.Sprintf(`
+case "$1" in
+  luksKillSlot)
+    cat >> %[1]s
+    echo >> %[1]s
+    if [ "$6" = "2" ] && [ ! -e %[2]s ]; then
+      touch %[2]s
+      echo "Keyslot 2 is not active." >&2
+      exit 1
+    fi
+    ;;
+  luksAddKey)
+    cat >> %[1]s
+    echo >> %[1]s
+    ;;
+esac
+`, filepath.Join(s.rootDir, "cryptsetup.input"), filepath.Join(s.rootDir, "temp-slot-killed")))
+}
+
+func (s *keymgrSuite) TestStageLUKSDevicePassphraseChange(c *C) {
+	cmd := s.mockPassphraseCryptsetup(c)
+	defer cmd.Restore()
+
+	err := keymgr.StageLUKSDevicePassphraseChange("old", "new", "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "2"},
+		{
+			"cryptsetup", "luksAddKey", "--type", "luks2",
+			"--key-file", "-", "--keyfile-size", "3",
+			"--batch-mode",
+			"--pbkdf", "argon2i",
+			"--key-slot", "2",
+			"/dev/foobar", "-",
+		},
+	})
+	// the old passphrase authorizes adding the new one
+	c.Check(filepath.Join(s.rootDir, "cryptsetup.input"), testutil.FileEquals, "old\noldnew\n")
+}
+
+func (s *keymgrSuite) TestStageLUKSDevicePassphraseChangeErrors(c *C) {
+	err := keymgr.StageLUKSDevicePassphraseChange("old", "", "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot use an empty passphrase")
+	c.Check(s.cryptsetupCmd.Calls(), HasLen, 0)
+
+	cmd := testutil.MockCommand(c, "cryptsetup", `
+if [ "$1" = "luksAddKey" ]; then
+  echo "No key available with this passphrase." >&2
+  exit 2
+fi
+`)
+	defer cmd.Restore()
+	err = keymgr.StageLUKSDevicePassphraseChange("wrong", "new", "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot add temporary passphrase: cryptsetup failed with: No key available with this passphrase.")
+	c.Check(cmd.Calls(), HasLen, 2)
+}
+
+func (s *keymgrSuite) TestUnstageLUKSDevicePassphraseChange(c *C) {
+	cmd := s.mockPassphraseCryptsetup(c)
+	defer cmd.Restore()
+
+	// the first attempt finds the temporary keyslot not active, which is
+	// not an error
+	for i := 0; i < 2; i++ {
+		err := keymgr.UnstageLUKSDevicePassphraseChange("old", "/dev/foobar")
+		c.Assert(err, IsNil)
+	}
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "2"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "2"},
+	})
+	c.Check(filepath.Join(s.rootDir, "cryptsetup.input"), testutil.FileEquals, "old\nold\n")
+
+	cmd = testutil.MockCommand(c, "cryptsetup", `echo "No key available with this passphrase." >&2; exit 2`)
+	defer cmd.Restore()
+	err := keymgr.UnstageLUKSDevicePassphraseChange("wrong", "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot kill the temporary keyslot: cryptsetup failed with: No key available with this passphrase.")
+}
+
+func (s *keymgrSuite) TestTransitionLUKSDevicePassphraseChange(c *C) {
+	cmd := s.mockPassphraseCryptsetup(c)
+	defer cmd.Restore()
+	// the temporary keyslot holds the staged passphrase
+	c.Assert(os.WriteFile(filepath.Join(s.rootDir, "temp-slot-killed"), nil, 0644), IsNil)
+
+	err := keymgr.TransitionLUKSDevicePassphraseChange("new", "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "3"},
+		{
+			"cryptsetup", "luksAddKey", "--type", "luks2",
+			"--key-file", "-", "--keyfile-size", "3",
+			"--batch-mode",
+			"--pbkdf", "argon2i",
+			"--key-slot", "3",
+			"/dev/foobar", "-",
+		},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "2"},
+	})
+	// the staged passphrase authorizes all operations
+	c.Check(filepath.Join(s.rootDir, "cryptsetup.input"), testutil.FileEquals, "new\nnewnew\nnew\n")
+}
+
+func (s *keymgrSuite) TestTransitionLUKSDevicePassphraseChangeErrors(c *C) {
+	err := keymgr.TransitionLUKSDevicePassphraseChange("", "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot use an empty passphrase")
+	c.Check(s.cryptsetupCmd.Calls(), HasLen, 0)
+
+	cmd := testutil.MockCommand(c, "cryptsetup", `
+if [ "$1" = "luksAddKey" ]; then
+  echo "No key available with this passphrase." >&2
+  exit 2
+fi
+`)
+	defer cmd.Restore()
+	err = keymgr.TransitionLUKSDevicePassphraseChange("new", "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot add new passphrase: cryptsetup failed with: No key available with this passphrase.")
+	c.Check(cmd.Calls(), HasLen, 2)
+}
@@ -0,0 +1,349 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package keymgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/secboot/keys"
+	"github.com/snapcore/snapd/secboot/luks2"
+)
+
+const (
+	// first key slot used by named recovery keys
+	namedRecoveryKeyFirstSlot = passphraseKeySlot + 1
+	// number of key slots supported by LUKS2
+	maxKeySlots = 32
+
+	// type of the LUKS2 tokens attaching a name and creation time to
+	// the key slot of a named recovery key
+	namedRecoveryKeyTokenType = "snapd-recovery-key"
+)
+
+var timeNow = time.Now
+
+// KeySlotType describes what a key slot of a LUKS2 device is used for.
+type KeySlotType string
+
+const (
+	KeySlotEncryptionKey    KeySlotType = "encryption-key"
+	KeySlotRecoveryKey      KeySlotType = "recovery-key"
+	KeySlotTemporary        KeySlotType = "temporary"
+	KeySlotPassphrase       KeySlotType = "passphrase"
+	KeySlotNamedRecoveryKey KeySlotType = "named-recovery-key"
+	KeySlotUnknown          KeySlotType = "unknown"
+)
+
+// KeySlot describes a used key slot of a LUKS2 device.
+type KeySlot struct {
+	ID   int
+	Type KeySlotType
+	// Name is only set for named recovery keys.
+	Name string
+	// Created is only known for named recovery keys.
+	Created time.Time
+}
+
+type namedRecoveryKeyToken struct {
+	Type     string    `json:"type"`
+	Keyslots []string  `json:"keyslots"`
+	Name     string    `json:"snapd_name"`
+	Created  time.Time `json:"snapd_created"`
+}
+
+type namedRecoveryKeySlot struct {
+	slot    int
+	tokenID int
+	name    string
+	created time.Time
+}
+
+// namedRecoveryKeySlots returns the key slots of named recovery keys as
+// described by the tokens in the LUKS2 metadata, sorted by slot.
+func namedRecoveryKeySlots(md *luks2.Metadata) ([]namedRecoveryKeySlot, error) {
+	var named []namedRecoveryKeySlot
+	for id, raw := range md.Tokens {
+		var tok namedRecoveryKeyToken
+		if err := json.Unmarshal(raw, &tok); err != nil {
+			return nil, fmt.Errorf("cannot decode token %v: %v", id, err)
+		}
+		if tok.Type != namedRecoveryKeyTokenType {
+			continue
+		}
+		tokenID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token ID %q", id)
+		}
+		if len(tok.Keyslots) != 1 {
+			return nil, fmt.Errorf("invalid recovery key token %v: expected exactly one key slot, got %v", id, len(tok.Keyslots))
+		}
+		slot, err := strconv.Atoi(tok.Keyslots[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid recovery key token %v: invalid key slot %q", id, tok.Keyslots[0])
+		}
+		named = append(named, namedRecoveryKeySlot{
+			slot:    slot,
+			tokenID: tokenID,
+			name:    tok.Name,
+			created: tok.Created,
+		})
+	}
+	sort.Slice(named, func(i, j int) bool { return named[i].slot < named[j].slot })
+	return named, nil
+}
+
+func readNamedRecoveryKeySlots(dev string) (*luks2.Metadata, []namedRecoveryKeySlot, error) {
+	md, err := luks2.ReadMetadata(dev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read LUKS2 metadata: %v", err)
+	}
+	named, err := namedRecoveryKeySlots(md)
+	if err != nil {
+		return nil, nil, err
+	}
+	return md, named, nil
+}
+
+// ListLUKSDeviceKeySlots returns the used key slots of a LUKS2 device along
+// with what they are used for, sorted by slot.
+func ListLUKSDeviceKeySlots(dev string) ([]KeySlot, error) {
+	md, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return nil, err
+	}
+	namedBySlot := make(map[int]namedRecoveryKeySlot, len(named))
+	for _, n := range named {
+		namedBySlot[n.slot] = n
+	}
+
+	slots := make([]KeySlot, 0, len(md.Keyslots))
+	for id := range md.Keyslots {
+		slot, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key slot ID %q", id)
+		}
+		ks := KeySlot{ID: slot}
+		if n, ok := namedBySlot[slot]; ok {
+			ks.Type = KeySlotNamedRecoveryKey
+			ks.Name = n.name
+			ks.Created = n.created
+		} else {
+			switch slot {
+			case encryptionKeySlot:
+				ks.Type = KeySlotEncryptionKey
+			case recoveryKeySlot:
+				ks.Type = KeySlotRecoveryKey
+			case tempKeySlot:
+				ks.Type = KeySlotTemporary
+			case passphraseKeySlot:
+				ks.Type = KeySlotPassphrase
+			default:
+				ks.Type = KeySlotUnknown
+			}
+		}
+		slots = append(slots, ks)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].ID < slots[j].ID })
+	return slots, nil
+}
+
+func freeKeySlot(md *luks2.Metadata) (int, error) {
+	for slot := namedRecoveryKeyFirstSlot; slot < maxKeySlots; slot++ {
+		if _, ok := md.Keyslots[strconv.Itoa(slot)]; !ok {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no free key slot")
+}
+
+func addNamedRecoveryKey(md *luks2.Metadata, name string, recoveryKey keys.RecoveryKey, currKey keys.EncryptionKey, dev string) (int, error) {
+	slot, err := freeKeySlot(md)
+	if err != nil {
+		return 0, err
+	}
+	opts, err := recoveryKDF()
+	if err != nil {
+		return 0, err
+	}
+	options := luks2.AddKeyOptions{
+		KDFOptions: *opts,
+		Slot:       slot,
+	}
+	if err := luks2.AddKey(dev, currKey, recoveryKey[:], &options); err != nil {
+		return 0, fmt.Errorf("cannot add key: %v", err)
+	}
+	token, err := json.Marshal(namedRecoveryKeyToken{
+		Type:     namedRecoveryKeyTokenType,
+		Keyslots: []string{strconv.Itoa(slot)},
+		Name:     name,
+		Created:  timeNow().UTC(),
+	})
+	if err == nil {
+		err = luks2.ImportToken(dev, token)
+	}
+	if err != nil {
+		// without the token the key slot cannot be identified
+		// later, so do not leave it behind
+		if killErr := luks2.KillSlot(dev, slot, currKey); killErr != nil {
+			logger.Noticef("cannot kill key slot %v after failing to add its token: %v", slot, killErr)
+		}
+		return 0, fmt.Errorf("cannot add recovery key token: %v", err)
+	}
+	return slot, nil
+}
+
+func removeNamedRecoveryKeySlots(named []namedRecoveryKeySlot, currKey keys.EncryptionKey, dev string) error {
+	for _, n := range named {
+		if err := luks2.KillSlot(dev, n.slot, currKey); err != nil {
+			if !isKeyslotNotActive(err) {
+				return fmt.Errorf("cannot kill key slot %v: %v", n.slot, err)
+			}
+		}
+		if err := luks2.RemoveToken(dev, n.tokenID); err != nil {
+			return fmt.Errorf("cannot remove recovery key token %v: %v", n.tokenID, err)
+		}
+	}
+	return nil
+}
+
+func filterNamedRecoveryKeySlots(named []namedRecoveryKeySlot, keep func(n namedRecoveryKeySlot) bool) []namedRecoveryKeySlot {
+	var filtered []namedRecoveryKeySlot
+	for _, n := range named {
+		if keep(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+func byName(name string) func(n namedRecoveryKeySlot) bool {
+	return func(n namedRecoveryKeySlot) bool { return n.name == name }
+}
+
+// AddNamedRecoveryKeyToLUKSDeviceUsingKey adds a recovery key under the given
+// name to the first free key slot after the ones with a fixed use of the
+// LUKS2 device dev. The name and the creation time are recorded in a LUKS2
+// token associated with the key slot. The existing key to the encrypted device
+// is provided in the currKey argument and used to authorize the operation.
+func AddNamedRecoveryKeyToLUKSDeviceUsingKey(name string, recoveryKey keys.RecoveryKey, currKey keys.EncryptionKey, dev string) error {
+	if name == "" {
+		return fmt.Errorf("cannot use an empty recovery key name")
+	}
+	md, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return err
+	}
+	if len(filterNamedRecoveryKeySlots(named, byName(name))) != 0 {
+		return fmt.Errorf("recovery key %q already exists", name)
+	}
+	_, err = addNamedRecoveryKey(md, name, recoveryKey, currKey, dev)
+	return err
+}
+
+// RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey removes the recovery key with the
+// given name from the LUKS2 device dev, using currKey to authorize the
+// operation.
+func RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey(name string, currKey keys.EncryptionKey, dev string) error {
+	_, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return err
+	}
+	toRemove := filterNamedRecoveryKeySlots(named, byName(name))
+	if len(toRemove) == 0 {
+		return fmt.Errorf("recovery key %q does not exist", name)
+	}
+	return removeNamedRecoveryKeySlots(toRemove, currKey, dev)
+}
+
+// StageNamedRecoveryKeyRotation adds a new recovery key under the name of an
+// existing named recovery key of the LUKS2 device dev and verifies that it
+// unlocks the device. The old key remains usable until the rotation is
+// completed with TransitionNamedRecoveryKeyRotation, or the staged key can be
+// dropped with AbortNamedRecoveryKeyRotation. The key slot of the new key is
+// returned.
+func StageNamedRecoveryKeyRotation(name string, recoveryKey keys.RecoveryKey, currKey keys.EncryptionKey, dev string) (int, error) {
+	md, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return 0, err
+	}
+	if len(filterNamedRecoveryKeySlots(named, byName(name))) == 0 {
+		return 0, fmt.Errorf("recovery key %q does not exist", name)
+	}
+	slot, err := addNamedRecoveryKey(md, name, recoveryKey, currKey, dev)
+	if err != nil {
+		return 0, err
+	}
+	if err := luks2.TestKey(dev, slot, recoveryKey[:]); err != nil {
+		if err := AbortNamedRecoveryKeyRotation(name, slot, currKey, dev); err != nil {
+			logger.Noticef("cannot remove unverified recovery key %q: %v", name, err)
+		}
+		return 0, fmt.Errorf("cannot verify new recovery key: %v", err)
+	}
+	return slot, nil
+}
+
+// TransitionNamedRecoveryKeyRotation completes the rotation of the named
+// recovery key by removing all key slots of the key other than the staged
+// one in newSlot.
+func TransitionNamedRecoveryKeyRotation(name string, newSlot int, currKey keys.EncryptionKey, dev string) error {
+	_, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return err
+	}
+	staged := false
+	toRemove := filterNamedRecoveryKeySlots(named, func(n namedRecoveryKeySlot) bool {
+		if n.name != name {
+			return false
+		}
+		if n.slot == newSlot {
+			staged = true
+			return false
+		}
+		return true
+	})
+	if !staged {
+		return fmt.Errorf("recovery key %q has no staged key in key slot %v", name, newSlot)
+	}
+	return removeNamedRecoveryKeySlots(toRemove, currKey, dev)
+}
+
+// AbortNamedRecoveryKeyRotation drops the key staged in newSlot for the
+// rotation of the named recovery key, leaving the old key in place.
+func AbortNamedRecoveryKeyRotation(name string, newSlot int, currKey keys.EncryptionKey, dev string) error {
+	_, named, err := readNamedRecoveryKeySlots(dev)
+	if err != nil {
+		return err
+	}
+	toRemove := filterNamedRecoveryKeySlots(named, func(n namedRecoveryKeySlot) bool {
+		return n.name == name && n.slot == newSlot
+	})
+	return removeNamedRecoveryKeySlots(toRemove, currKey, dev)
+}
+
+// EncryptionKeyFromUserKeyring returns the key that unlocked the LUKS2 device
+// dev as found in the user keyring.
+func EncryptionKeyFromUserKeyring(dev string) (keys.EncryptionKey, error) {
+	return getEncryptionKeyFromUserKeyring(dev)
+}
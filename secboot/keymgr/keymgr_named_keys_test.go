@@ -0,0 +1,311 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package keymgr_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/secboot/keymgr"
+	"github.com/snapcore/snapd/secboot/keys"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const mockNamedKeysMetadata = `{
+  "keyslots": {
+    "0": {"type": "luks2", "key_size": 64, "priority": 2},
+    "1": {"type": "luks2", "key_size": 64},
+    "3": {"type": "luks2", "key_size": 64},
+    "4": {"type": "luks2", "key_size": 64},
+    "5": {"type": "luks2", "key_size": 64},
+    "9": {"type": "luks2", "key_size": 64}
+  },
+  "tokens": {
+    "0": {"type": "snapd-recovery-key", "keyslots": ["4"], "snapd_name": "foo", "snapd_created": "2026-01-02T03:04:05Z"},
+    "1": {"type": "snapd-recovery-key", "keyslots": ["5"], "snapd_name": "bar", "snapd_created": "2026-02-03T04:05:06Z"},
+    "2": {"type": "systemd-tpm2", "keyslots": ["9"]}
+  }
+}`
+
+// metadata after a key named foo was staged for rotation in key slot 6
+const mockNamedKeysStagedMetadata = `{
+  "keyslots": {
+    "0": {"type": "luks2", "key_size": 64, "priority": 2},
+    "4": {"type": "luks2", "key_size": 64},
+    "6": {"type": "luks2", "key_size": 64}
+  },
+  "tokens": {
+    "0": {"type": "snapd-recovery-key", "keyslots": ["4"], "snapd_name": "foo", "snapd_created": "2026-01-02T03:04:05Z"},
+    "3": {"type": "snapd-recovery-key", "keyslots": ["6"], "snapd_name": "foo", "snapd_created": "2026-10-19T10:00:00Z"}
+  }
+}`
+
+var mockUnlockKey = keys.EncryptionKey{'u', 'n', 'l', 'o', 'c', 'k'}
+
+// mockCryptsetupForNamedKeys mocks cryptsetup so that luksDump reports the
+// given metadata, which is replaced by metadataAfterImport (if set) once a
+// token is imported. Commands listed in failing exit with an error.
+func (s *keymgrSuite) mockCryptsetupForNamedKeys(c *C, metadata, metadataAfterImport string, failing ...string) *testutil.MockCmd {
+	d := c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(d, "metadata.json"), []byte(metadata), 0644), IsNil)
+	if metadataAfterImport != "" {
+		c.Assert(os.WriteFile(filepath.Join(d, "metadata-after.json"), []byte(metadataAfterImport), 0644), IsNil)
+	}
+	failCases := ""
+	for _, f := range failing {
+		failCases += fmt.Sprintf("  %s) echo \"%s failed\" >&2; exit 1 ;;\n", f, f)
+	}
+	cmd := testutil.MockCommand(c, "cryptsetup", fmt.Sprintf(`
+case "$1" in
+%[2]s  luksDump)
+    cat %[1]s/metadata.json
+    ;;
+  token)
+    if [ "$2" = "import" ]; then
+      cat > %[1]s/token.input
+      if [ -e %[1]s/metadata-after.json ]; then
+        cp %[1]s/metadata-after.json %[1]s/metadata.json
+      fi
+    fi
+    ;;
+  *)
+    cat > /dev/null
+    ;;
+esac
+`, d, failCases))
+	s.AddCleanup(cmd.Restore)
+	s.tokenInput = filepath.Join(d, "token.input")
+	return cmd
+}
+
+func (s *keymgrSuite) mockTimeNow(c *C) {
+	s.AddCleanup(keymgr.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	}))
+}
+
+var addKeySlot6Call = []string{
+	"cryptsetup", "luksAddKey", "--type", "luks2",
+	"--key-file", "-", "--keyfile-size", "6",
+	"--batch-mode",
+	"--pbkdf", "argon2i",
+	"--pbkdf-force-iterations", "4",
+	"--pbkdf-memory", "202834",
+	"--key-slot", "6",
+	"/dev/foobar", "-",
+}
+
+func (s *keymgrSuite) TestListLUKSDeviceKeySlots(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	slots, err := keymgr.ListLUKSDeviceKeySlots("/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+	})
+	c.Check(slots, DeepEquals, []keymgr.KeySlot{
+		{ID: 0, Type: keymgr.KeySlotEncryptionKey},
+		{ID: 1, Type: keymgr.KeySlotRecoveryKey},
+		{ID: 3, Type: keymgr.KeySlotPassphrase},
+		{ID: 4, Type: keymgr.KeySlotNamedRecoveryKey, Name: "foo", Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{ID: 5, Type: keymgr.KeySlotNamedRecoveryKey, Name: "bar", Created: time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)},
+		{ID: 9, Type: keymgr.KeySlotUnknown},
+	})
+}
+
+func (s *keymgrSuite) TestListLUKSDeviceKeySlotsBadToken(c *C) {
+	s.mockCryptsetupForNamedKeys(c, `{"keyslots": {}, "tokens": {"0": {"type": "snapd-recovery-key", "keyslots": ["4", "5"]}}}`, "")
+
+	_, err := keymgr.ListLUKSDeviceKeySlots("/dev/foobar")
+	c.Assert(err, ErrorMatches, "invalid recovery key token 0: expected exactly one key slot, got 2")
+}
+
+func (s *keymgrSuite) TestListLUKSDeviceKeySlotsError(c *C) {
+	s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "", "luksDump")
+
+	_, err := keymgr.ListLUKSDeviceKeySlots("/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot read LUKS2 metadata: cryptsetup failed with: luksDump failed")
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKeyHappy(c *C) {
+	s.mockTimeNow(c)
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	err := keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey("baz", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	// slots 4 and 5 are used, 6 is the first free one
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		addKeySlot6Call,
+		{"cryptsetup", "token", "import", "--json-file", "-", "/dev/foobar"},
+	})
+	c.Check(s.tokenInput, testutil.FileEquals,
+		`{"type":"snapd-recovery-key","keyslots":["6"],"snapd_name":"baz","snapd_created":"2026-10-19T10:00:00Z"}`)
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKeyAlreadyExists(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	err := keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey("foo", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, `recovery key "foo" already exists`)
+	c.Check(cmd.Calls(), HasLen, 1)
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKeyEmptyName(c *C) {
+	err := keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey("", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot use an empty recovery key name")
+	c.Check(s.cryptsetupCmd.Calls(), HasLen, 0)
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKeyTokenError(c *C) {
+	s.mockTimeNow(c)
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "", "token")
+
+	err := keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey("baz", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot add recovery key token: cryptsetup failed with: token failed")
+	// the key slot is not left behind
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		addKeySlot6Call,
+		{"cryptsetup", "token", "import", "--json-file", "-", "/dev/foobar"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "6"},
+	})
+}
+
+func (s *keymgrSuite) TestAddNamedRecoveryKeyNoFreeSlot(c *C) {
+	md := `{"keyslots": {`
+	for i := 0; i < 32; i++ {
+		if i > 0 {
+			md += ","
+		}
+		md += fmt.Sprintf(`"%d": {"type": "luks2"}`, i)
+	}
+	md += `}, "tokens": {}}`
+	s.mockCryptsetupForNamedKeys(c, md, "")
+
+	err := keymgr.AddNamedRecoveryKeyToLUKSDeviceUsingKey("baz", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "no free key slot")
+}
+
+func (s *keymgrSuite) TestRemoveNamedRecoveryKeyHappy(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	err := keymgr.RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey("bar", mockUnlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "5"},
+		{"cryptsetup", "token", "remove", "--token-id", "1", "/dev/foobar"},
+	})
+}
+
+func (s *keymgrSuite) TestRemoveNamedRecoveryKeyMissing(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	err := keymgr.RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey("baz", mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, `recovery key "baz" does not exist`)
+	c.Check(cmd.Calls(), HasLen, 1)
+}
+
+func (s *keymgrSuite) TestRemoveNamedRecoveryKeyKillError(c *C) {
+	s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "", "luksKillSlot")
+
+	err := keymgr.RemoveNamedRecoveryKeyFromLUKSDeviceUsingKey("foo", mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot kill key slot 4: cryptsetup failed with: luksKillSlot failed")
+}
+
+func (s *keymgrSuite) TestStageNamedRecoveryKeyRotationHappy(c *C) {
+	s.mockTimeNow(c)
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	slot, err := keymgr.StageNamedRecoveryKeyRotation("foo", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(slot, Equals, 6)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		addKeySlot6Call,
+		{"cryptsetup", "token", "import", "--json-file", "-", "/dev/foobar"},
+		{"cryptsetup", "open", "--test-passphrase", "--type", "luks2", "--key-file", "-", "--key-slot", "6", "/dev/foobar"},
+	})
+	c.Check(s.tokenInput, testutil.FileEquals,
+		`{"type":"snapd-recovery-key","keyslots":["6"],"snapd_name":"foo","snapd_created":"2026-10-19T10:00:00Z"}`)
+}
+
+func (s *keymgrSuite) TestStageNamedRecoveryKeyRotationMissing(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	_, err := keymgr.StageNamedRecoveryKeyRotation("baz", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, `recovery key "baz" does not exist`)
+	c.Check(cmd.Calls(), HasLen, 1)
+}
+
+func (s *keymgrSuite) TestStageNamedRecoveryKeyRotationVerifyError(c *C) {
+	s.mockTimeNow(c)
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, mockNamedKeysStagedMetadata, "open")
+
+	_, err := keymgr.StageNamedRecoveryKeyRotation("foo", mockRecoveryKey, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, "cannot verify new recovery key: cryptsetup failed with: open failed")
+	// the unverified key is removed again
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		addKeySlot6Call,
+		{"cryptsetup", "token", "import", "--json-file", "-", "/dev/foobar"},
+		{"cryptsetup", "open", "--test-passphrase", "--type", "luks2", "--key-file", "-", "--key-slot", "6", "/dev/foobar"},
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "6"},
+		{"cryptsetup", "token", "remove", "--token-id", "3", "/dev/foobar"},
+	})
+}
+
+func (s *keymgrSuite) TestTransitionNamedRecoveryKeyRotationHappy(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysStagedMetadata, "")
+
+	err := keymgr.TransitionNamedRecoveryKeyRotation("foo", 6, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "4"},
+		{"cryptsetup", "token", "remove", "--token-id", "0", "/dev/foobar"},
+	})
+}
+
+func (s *keymgrSuite) TestTransitionNamedRecoveryKeyRotationNotStaged(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysMetadata, "")
+
+	err := keymgr.TransitionNamedRecoveryKeyRotation("foo", 6, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, ErrorMatches, `recovery key "foo" has no staged key in key slot 6`)
+	c.Check(cmd.Calls(), HasLen, 1)
+}
+
+func (s *keymgrSuite) TestAbortNamedRecoveryKeyRotation(c *C) {
+	cmd := s.mockCryptsetupForNamedKeys(c, mockNamedKeysStagedMetadata, "")
+
+	err := keymgr.AbortNamedRecoveryKeyRotation("foo", 6, mockUnlockKey, "/dev/foobar")
+	c.Assert(err, IsNil)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/dev/foobar"},
+		{"cryptsetup", "luksKillSlot", "--type", "luks2", "--key-file", "-", "/dev/foobar", "6"},
+		{"cryptsetup", "token", "remove", "--token-id", "3", "/dev/foobar"},
+	})
+}
@@ -169,3 +169,21 @@ func KillSlot(devicePath string, slot int, key []byte) error {
 func SetSlotPriority(devicePath string, slot int, priority SlotPriority) error {
 	return cryptsetupCmd(nil, "config", "--priority", priority.String(), "--key-slot", strconv.Itoa(slot), devicePath)
 }
+
+// TestKey checks that the supplied key unlocks the keyslot with the supplied
+// slot number of the specified LUKS2 container, without activating it.
+func TestKey(devicePath string, slot int, key []byte) error {
+	return cryptsetupCmd(bytes.NewReader(key), "open", "--test-passphrase", "--type", "luks2", "--key-file", "-", "--key-slot", strconv.Itoa(slot), devicePath)
+}
+
+// ImportToken imports the supplied JSON encoded token into the specified LUKS2
+// container. The token is assigned the first free token ID.
+func ImportToken(devicePath string, token []byte) error {
+	return cryptsetupCmd(bytes.NewReader(token), "token", "import", "--json-file", "-", devicePath)
+}
+
+// RemoveToken removes the token with the supplied ID from the specified LUKS2
+// container.
+func RemoveToken(devicePath string, tokenID int) error {
+	return cryptsetupCmd(nil, "token", "remove", "--token-id", strconv.Itoa(tokenID), devicePath)
+}
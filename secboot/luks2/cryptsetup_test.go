@@ -94,3 +94,37 @@ func (s *luks2Suite) TestAddKeyBadCryptsetup(c *C) {
 	err = luks2.AddKey("/my/device", []byte("old-key"), []byte("new-key"), nil)
 	c.Check(err, ErrorMatches, "cryptsetup failed with: some-error")
 }
+
+func (s *luks2Suite) TestTestKey(c *C) {
+	err := luks2.TestKey("/my/device", 4, []byte("some-key"))
+	c.Check(err, IsNil)
+	c.Check(s.mockCryptsetup.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "open", "--test-passphrase", "--type", "luks2", "--key-file", "-", "--key-slot", "4", "/my/device"},
+	})
+	c.Check(filepath.Join(s.tmpdir, "stdout"), testutil.FileEquals, "some-key")
+}
+
+func (s *luks2Suite) TestTestKeyWrongKey(c *C) {
+	mockCryptsetup := testutil.MockCommand(c, "cryptsetup", "echo No key available with this passphrase.; exit 2")
+	defer mockCryptsetup.Restore()
+
+	err := luks2.TestKey("/my/device", 4, []byte("some-key"))
+	c.Check(err, ErrorMatches, "cryptsetup failed with: No key available with this passphrase.")
+}
+
+func (s *luks2Suite) TestImportToken(c *C) {
+	err := luks2.ImportToken("/my/device", []byte(`{"type":"foo","keyslots":["4"]}`))
+	c.Check(err, IsNil)
+	c.Check(s.mockCryptsetup.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "token", "import", "--json-file", "-", "/my/device"},
+	})
+	c.Check(filepath.Join(s.tmpdir, "stdout"), testutil.FileEquals, `{"type":"foo","keyslots":["4"]}`)
+}
+
+func (s *luks2Suite) TestRemoveToken(c *C) {
+	err := luks2.RemoveToken("/my/device", 2)
+	c.Check(err, IsNil)
+	c.Check(s.mockCryptsetup.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "token", "remove", "--token-id", "2", "/my/device"},
+	})
+}
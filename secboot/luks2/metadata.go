@@ -0,0 +1,59 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package luks2
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/snapcore/snapd/osutil"
+)
+
+// Keyslot describes a keyslot of a LUKS2 container.
+type Keyslot struct {
+	Type     string `json:"type"`
+	KeySize  int    `json:"key_size"`
+	Priority *int   `json:"priority,omitempty"`
+}
+
+// Metadata is the subset of the JSON metadata of a LUKS2 container that is
+// of interest to snapd. Keyslots and tokens are indexed by their IDs.
+type Metadata struct {
+	Keyslots map[string]Keyslot `json:"keyslots"`
+	// Tokens are kept in their raw form as their content depends on
+	// their type.
+	Tokens map[string]json.RawMessage `json:"tokens"`
+}
+
+// ReadMetadata reads the JSON metadata from the header of the specified LUKS2
+// container.
+func ReadMetadata(devicePath string) (*Metadata, error) {
+	cmd := exec.Command("cryptsetup", "luksDump", "--dump-json-metadata", devicePath)
+	output, stderr, err := osutil.RunCmd(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("cryptsetup failed with: %v", osutil.OutputErr(stderr, err))
+	}
+	var md Metadata
+	if err := json.Unmarshal(output, &md); err != nil {
+		return nil, fmt.Errorf("cannot decode LUKS2 metadata: %v", err)
+	}
+	return &md, nil
+}
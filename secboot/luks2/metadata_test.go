@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package luks2_test
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/secboot/luks2"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const mockMetadata = `{
+  "keyslots": {
+    "0": {"type": "luks2", "key_size": 64, "priority": 2},
+    "1": {"type": "luks2", "key_size": 64},
+    "4": {"type": "luks2", "key_size": 64}
+  },
+  "tokens": {
+    "0": {"type": "snapd-recovery-key", "keyslots": ["4"], "snapd_name": "foo"}
+  },
+  "segments": {},
+  "digests": {},
+  "config": {"json_size": "12288", "keyslots_size": "16744448"}
+}`
+
+func (s *luks2Suite) TestReadMetadata(c *C) {
+	mockCryptsetup := testutil.MockCommand(c, "cryptsetup", "cat <<'EOF'\n"+mockMetadata+"\nEOF\n")
+	defer mockCryptsetup.Restore()
+
+	md, err := luks2.ReadMetadata("/my/device")
+	c.Assert(err, IsNil)
+	c.Check(mockCryptsetup.Calls(), DeepEquals, [][]string{
+		{"cryptsetup", "luksDump", "--dump-json-metadata", "/my/device"},
+	})
+	high := 2
+	c.Check(md.Keyslots, DeepEquals, map[string]luks2.Keyslot{
+		"0": {Type: "luks2", KeySize: 64, Priority: &high},
+		"1": {Type: "luks2", KeySize: 64},
+		"4": {Type: "luks2", KeySize: 64},
+	})
+	c.Assert(md.Tokens, HasLen, 1)
+	var tok map[string]interface{}
+	c.Assert(json.Unmarshal(md.Tokens["0"], &tok), IsNil)
+	c.Check(tok, DeepEquals, map[string]interface{}{
+		"type":       "snapd-recovery-key",
+		"keyslots":   []interface{}{"4"},
+		"snapd_name": "foo",
+	})
+}
+
+func (s *luks2Suite) TestReadMetadataError(c *C) {
+	mockCryptsetup := testutil.MockCommand(c, "cryptsetup", "echo 'Device /my/device is not a valid LUKS device.' >&2; exit 1")
+	defer mockCryptsetup.Restore()
+
+	_, err := luks2.ReadMetadata("/my/device")
+	c.Check(err, ErrorMatches, "cryptsetup failed with: Device /my/device is not a valid LUKS device.")
+}
+
+func (s *luks2Suite) TestReadMetadataBadJSON(c *C) {
+	mockCryptsetup := testutil.MockCommand(c, "cryptsetup", "echo 'not json'")
+	defer mockCryptsetup.Restore()
+
+	_, err := luks2.ReadMetadata("/my/device")
+	c.Check(err, ErrorMatches, "cannot decode LUKS2 metadata: .*")
+}
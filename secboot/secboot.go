@@ -26,6 +26,7 @@ package secboot
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/bootloader"
@@ -54,6 +55,10 @@ type LoadChain struct {
 	// Next is a list of alternative chains that can be loaded
 	// following the boot file.
 	Next []*LoadChain
+	// LoadedByFirmware is set when the boot file is loaded and
+	// verified using the firmware services rather than through shim,
+	// as is the case for images started by systemd-boot.
+	LoadedByFirmware bool
 }
 
 // NewLoadChain returns a LoadChain corresponding to loading the given
@@ -153,6 +158,10 @@ type UnlockVolumeUsingSealedKeyOptions struct {
 	// WhichModel if invoked should return the device model
 	// assertion for which the disk is being unlocked.
 	WhichModel func() (*asserts.Model, error)
+	// PassphraseAuth when true indicates that the volume is protected
+	// by a user passphrase rather than a sealed key, the user is
+	// prompted for the passphrase instead.
+	PassphraseAuth bool
 }
 
 // UnlockMethod is the method that was used to unlock a volume.
@@ -173,6 +182,9 @@ const (
 	UnlockedWithKey
 	// UnlockStatusUnknown indicates that the unlock status of the device is not clear.
 	UnlockStatusUnknown
+	// UnlockedWithPassphrase indicates that the device was unlocked by the
+	// user providing the passphrase at the prompt.
+	UnlockedWithPassphrase
 )
 
 // UnlockResult is the result of trying to unlock a volume.
@@ -192,6 +204,7 @@ type UnlockResult struct {
 	// - UnlockedWithRecoveryKey
 	// - UnlockedWithSealedKey
 	// - UnlockedWithKey
+	// - UnlockedWithPassphrase
 	UnlockMethod UnlockMethod
 }
 
@@ -201,6 +214,20 @@ func EncryptedPartitionName(name string) string {
 	return name + "-enc"
 }
 
+// CheckVolumesAuthSupported checks whether the given authentication
+// options for encrypted volumes are supported.
+func CheckVolumesAuthSupported(volumesAuth *device.VolumesAuthOptions) error {
+	if volumesAuth == nil {
+		return nil
+	}
+	switch volumesAuth.Mode {
+	case device.AuthModePassphrase:
+		return nil
+	default:
+		return fmt.Errorf("invalid authentication mode %q", volumesAuth.Mode)
+	}
+}
+
 // MarkSuccessful marks the secure boot parts of the boot as
 // successful.
 //
@@ -44,6 +44,10 @@ func ResealKeys(params *ResealKeysParams) error {
 	return errBuildWithoutSecboot
 }
 
+func BuildPCRProtectionProfile(modelParams []*SealKeyModelParams) (string, error) {
+	return "", errBuildWithoutSecboot
+}
+
 func ProvisionTPM(mode TPMProvisionMode, lockoutAuthFile string) error {
 	return errBuildWithoutSecboot
 }
@@ -21,14 +21,18 @@
 package secboot
 
 import (
+	"bytes"
 	"fmt"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	sb "github.com/snapcore/secboot"
 	"golang.org/x/xerrors"
 
 	"github.com/snapcore/snapd/kernel/fde"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/disks"
 )
 
@@ -113,6 +117,10 @@ func UnlockVolumeUsingSealedKeyIfEncrypted(disk disks.Disk, name string, sealedE
 	sourceDevice := partDevice
 	targetDevice := filepath.Join("/dev/mapper", mapperName)
 
+	if opts != nil && opts.PassphraseAuth {
+		return unlockVolumeUsingPassphrase(name, sourceDevice, targetDevice, mapperName, opts)
+	}
+
 	if fdeHasRevealKey() {
 		return unlockVolumeUsingSealedKeyFDERevealKey(sealedEncryptionKeyFile, sourceDevice, targetDevice, mapperName, opts)
 	} else {
@@ -120,6 +128,60 @@ func UnlockVolumeUsingSealedKeyIfEncrypted(disk disks.Disk, name string, sealedE
 	}
 }
 
+// passphraseTries is the number of times the user is prompted for the
+// passphrase before giving up.
+const passphraseTries = 3
+
+var systemdAskPassword = systemdAskPasswordImpl
+
+func systemdAskPasswordImpl(name, device string) (string, error) {
+	prompt := fmt.Sprintf("Please enter the passphrase for %s (%s):", name, device)
+	cmd := exec.Command("systemd-ask-password", "--icon", "drive-harddisk", "--id", "snapd:"+name, prompt)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain passphrase: %v", osutil.OutputErr(stderr.Bytes(), err))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func unlockVolumeUsingPassphrase(name, sourceDevice, targetDevice, mapperName string, opts *UnlockVolumeUsingSealedKeyOptions) (UnlockResult, error) {
+	res := UnlockResult{IsEncrypted: true, PartDevice: sourceDevice}
+	options := sb.ActivateVolumeOptions{
+		KeyringPrefix: keyringPrefix,
+	}
+
+	var err error
+	for i := 0; i < passphraseTries; i++ {
+		var passphrase string
+		passphrase, err = systemdAskPassword(name, sourceDevice)
+		if err != nil {
+			break
+		}
+		err = sbActivateVolumeWithKey(mapperName, sourceDevice, []byte(passphrase), &options)
+		if err == nil {
+			logger.Noticef("successfully activated encrypted device %q using a passphrase", sourceDevice)
+			res.FsDevice = targetDevice
+			res.UnlockMethod = UnlockedWithPassphrase
+			return res, nil
+		}
+		logger.Noticef("cannot activate encrypted device %q using a passphrase: %v", sourceDevice, err)
+	}
+
+	if opts.AllowRecoveryKey {
+		rkErr := UnlockEncryptedVolumeWithRecoveryKey(mapperName, sourceDevice)
+		if rkErr == nil {
+			res.FsDevice = targetDevice
+			res.UnlockMethod = UnlockedWithRecoveryKey
+			return res, nil
+		}
+		return res, fmt.Errorf("cannot unlock encrypted device %q with passphrase: %v, and %v", sourceDevice, err, rkErr)
+	}
+
+	return res, fmt.Errorf("cannot unlock encrypted device %q with passphrase: %v", sourceDevice, err)
+}
+
 // UnlockEncryptedVolumeUsingKey unlocks an existing volume using the provided key.
 func UnlockEncryptedVolumeUsingKey(disk disks.Disk, name string, key []byte) (UnlockResult, error) {
 	unlockRes := UnlockResult{
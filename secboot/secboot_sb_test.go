@@ -1254,6 +1254,62 @@ func (s *secbootSuite) TestResealKey(c *C) {
 	}
 }
 
+func (s *secbootSuite) TestBuildPCRProtectionProfile(c *C) {
+	mockEFI := bootloader.NewBootFile("", filepath.Join(c.MkDir(), "file.efi"), bootloader.RoleRecovery)
+	err := os.WriteFile(mockEFI.Path, nil, 0644)
+	c.Assert(err, IsNil)
+
+	modelParams := []*secboot.SealKeyModelParams{
+		{
+			EFILoadChains:  []*secboot.LoadChain{secboot.NewLoadChain(mockEFI)},
+			KernelCmdlines: []string{"cmdline"},
+			Model:          &asserts.Model{},
+		},
+	}
+
+	var calls []string
+	restore := secboot.MockSbEfiAddSecureBootPolicyProfile(func(profile *sb_tpm2.PCRProtectionProfile, params *sb_efi.SecureBootPolicyProfileParams) error {
+		calls = append(calls, "secure-boot")
+		return nil
+	})
+	defer restore()
+	restore = secboot.MockSbEfiAddBootManagerProfile(func(profile *sb_tpm2.PCRProtectionProfile, params *sb_efi.BootManagerProfileParams) error {
+		calls = append(calls, "boot-manager")
+		return nil
+	})
+	defer restore()
+	restore = secboot.MockSbEfiAddSystemdStubProfile(func(profile *sb_tpm2.PCRProtectionProfile, params *sb_efi.SystemdStubProfileParams) error {
+		calls = append(calls, "systemd-stub")
+		c.Check(params.KernelCmdlines, DeepEquals, []string{"cmdline"})
+		return nil
+	})
+	defer restore()
+	addSnapModelErr := error(nil)
+	restore = secboot.MockSbAddSnapModelProfile(func(profile *sb_tpm2.PCRProtectionProfile, params *sb_tpm2.SnapModelProfileParams) error {
+		calls = append(calls, "snap-model")
+		return addSnapModelErr
+	})
+	defer restore()
+	// the TPM is never accessed
+	restore = secboot.MockSbConnectToDefaultTPM(func() (*sb_tpm2.Connection, error) {
+		c.Fatalf("unexpected TPM connection")
+		return nil, nil
+	})
+	defer restore()
+
+	profile, err := secboot.BuildPCRProtectionProfile(modelParams)
+	c.Assert(err, IsNil)
+	c.Check(profile, Not(Equals), "")
+	c.Check(calls, DeepEquals, []string{"secure-boot", "boot-manager", "systemd-stub", "snap-model"})
+
+	addSnapModelErr = errors.New("some error")
+	_, err = secboot.BuildPCRProtectionProfile(modelParams)
+	c.Assert(err, ErrorMatches, "cannot add snap model profile: some error")
+
+	_, err = secboot.BuildPCRProtectionProfile(nil)
+	c.Assert(err, ErrorMatches, "at least one set of model-specific parameters is required")
+}
+
 func (s *secbootSuite) TestSealKeyNoModelParams(c *C) {
 	myKeys := []secboot.SealKeyRequest{
 		{
@@ -1388,6 +1444,147 @@ func (s *secbootSuite) TestUnlockEncryptedVolumeUsingKeyErr(c *C) {
 	})
 }
 
+func (s *secbootSuite) TestUnlockVolumeUsingSealedKeyIfEncryptedPassphrase(c *C) {
+	disk := &disks.MockDiskMapping{
+		Structure: []disks.Partition{
+			{
+				FilesystemLabel: "ubuntu-data-enc",
+				PartitionUUID:   "123-123-123",
+			},
+		},
+	}
+	restore := secboot.MockRandomKernelUUID(func() (string, error) {
+		return "random-uuid-123-123", nil
+	})
+	defer restore()
+	asked := 0
+	restore = secboot.MockSystemdAskPassword(func(name, device string) (string, error) {
+		c.Check(name, Equals, "ubuntu-data")
+		c.Check(device, Equals, "/dev/disk/by-partuuid/123-123-123")
+		asked++
+		if asked == 1 {
+			return "wrong", nil
+		}
+		return "passphrase", nil
+	})
+	defer restore()
+	restore = secboot.MockSbActivateVolumeWithKey(func(volumeName, sourceDevicePath string, key []byte,
+		options *sb.ActivateVolumeOptions) error {
+		c.Check(options, DeepEquals, &sb.ActivateVolumeOptions{KeyringPrefix: "ubuntu-fde"})
+		c.Check(volumeName, Equals, "ubuntu-data-random-uuid-123-123")
+		c.Check(sourceDevicePath, Equals, "/dev/disk/by-partuuid/123-123-123")
+		if string(key) != "passphrase" {
+			return fmt.Errorf("invalid key")
+		}
+		return nil
+	})
+	defer restore()
+	restore = secboot.MockSbActivateVolumeWithKeyData(func(volumeName, sourceDevicePath string, key *sb.KeyData, options *sb.ActivateVolumeOptions) (sb.SnapModelChecker, error) {
+		c.Fatalf("unexpected call")
+		return nil, nil
+	})
+	defer restore()
+
+	opts := &secboot.UnlockVolumeUsingSealedKeyOptions{PassphraseAuth: true}
+	unlockRes, err := secboot.UnlockVolumeUsingSealedKeyIfEncrypted(disk, "ubuntu-data", "", opts)
+	c.Assert(err, IsNil)
+	c.Check(asked, Equals, 2)
+	c.Check(unlockRes, DeepEquals, secboot.UnlockResult{
+		PartDevice:   "/dev/disk/by-partuuid/123-123-123",
+		FsDevice:     "/dev/mapper/ubuntu-data-random-uuid-123-123",
+		IsEncrypted:  true,
+		UnlockMethod: secboot.UnlockedWithPassphrase,
+	})
+}
+
+func (s *secbootSuite) TestUnlockVolumeUsingSealedKeyIfEncryptedPassphraseFallbackRecoveryKey(c *C) {
+	disk := &disks.MockDiskMapping{
+		Structure: []disks.Partition{
+			{
+				FilesystemLabel: "ubuntu-data-enc",
+				PartitionUUID:   "123-123-123",
+			},
+		},
+	}
+	restore := secboot.MockRandomKernelUUID(func() (string, error) {
+		return "random-uuid-123-123", nil
+	})
+	defer restore()
+	asked := 0
+	restore = secboot.MockSystemdAskPassword(func(name, device string) (string, error) {
+		asked++
+		return "wrong", nil
+	})
+	defer restore()
+	restore = secboot.MockSbActivateVolumeWithKey(func(volumeName, sourceDevicePath string, key []byte,
+		options *sb.ActivateVolumeOptions) error {
+		return fmt.Errorf("invalid key")
+	})
+	defer restore()
+	rkErr := fmt.Errorf("invalid recovery key")
+	restore = secboot.MockSbActivateVolumeWithRecoveryKey(func(volumeName, sourceDevicePath string,
+		keyReader io.Reader, options *sb.ActivateVolumeOptions) error {
+		c.Check(volumeName, Equals, "ubuntu-data-random-uuid-123-123")
+		c.Check(options, DeepEquals, &sb.ActivateVolumeOptions{
+			RecoveryKeyTries: 3,
+			KeyringPrefix:    "ubuntu-fde",
+		})
+		return rkErr
+	})
+	defer restore()
+
+	opts := &secboot.UnlockVolumeUsingSealedKeyOptions{
+		PassphraseAuth:   true,
+		AllowRecoveryKey: true,
+	}
+	_, err := secboot.UnlockVolumeUsingSealedKeyIfEncrypted(disk, "ubuntu-data", "", opts)
+	c.Assert(err, ErrorMatches, `cannot unlock encrypted device "/dev/disk/by-partuuid/123-123-123" with passphrase: invalid key, and cannot unlock encrypted device .*: invalid recovery key`)
+	c.Check(asked, Equals, 3)
+
+	rkErr = nil
+	unlockRes, err := secboot.UnlockVolumeUsingSealedKeyIfEncrypted(disk, "ubuntu-data", "", opts)
+	c.Assert(err, IsNil)
+	c.Check(unlockRes, DeepEquals, secboot.UnlockResult{
+		PartDevice:   "/dev/disk/by-partuuid/123-123-123",
+		FsDevice:     "/dev/mapper/ubuntu-data-random-uuid-123-123",
+		IsEncrypted:  true,
+		UnlockMethod: secboot.UnlockedWithRecoveryKey,
+	})
+}
+
+func (s *secbootSuite) TestUnlockVolumeUsingSealedKeyIfEncryptedPassphrasePromptError(c *C) {
+	disk := &disks.MockDiskMapping{
+		Structure: []disks.Partition{
+			{
+				FilesystemLabel: "ubuntu-data-enc",
+				PartitionUUID:   "123-123-123",
+			},
+		},
+	}
+	restore := secboot.MockRandomKernelUUID(func() (string, error) {
+		return "random-uuid-123-123", nil
+	})
+	defer restore()
+	restore = secboot.MockSystemdAskPassword(func(name, device string) (string, error) {
+		return "", fmt.Errorf("cannot obtain passphrase: timeout")
+	})
+	defer restore()
+	restore = secboot.MockSbActivateVolumeWithKey(func(volumeName, sourceDevicePath string, key []byte,
+		options *sb.ActivateVolumeOptions) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer restore()
+
+	opts := &secboot.UnlockVolumeUsingSealedKeyOptions{PassphraseAuth: true}
+	unlockRes, err := secboot.UnlockVolumeUsingSealedKeyIfEncrypted(disk, "ubuntu-data", "", opts)
+	c.Assert(err, ErrorMatches, `cannot unlock encrypted device "/dev/disk/by-partuuid/123-123-123" with passphrase: cannot obtain passphrase: timeout`)
+	c.Check(unlockRes, DeepEquals, secboot.UnlockResult{
+		PartDevice:  "/dev/disk/by-partuuid/123-123-123",
+		IsEncrypted: true,
+	})
+}
+
 func (s *secbootSuite) TestUnlockVolumeUsingSealedKeyIfEncryptedFdeRevealKeyErr(c *C) {
 	restore := fde.MockRunFDERevealKey(func(req *fde.RevealKeyRequest) ([]byte, error) {
 		return nil, fmt.Errorf(`cannot run ["fde-reveal-key"]: helper error`)
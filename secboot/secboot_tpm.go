@@ -470,6 +470,21 @@ func ResealKeys(params *ResealKeysParams) error {
 	return sbSealedKeyObjectRevokeOldPCRProtectionPolicies(sealedKeyObjects[0], tpm, authKey)
 }
 
+// BuildPCRProtectionProfile computes the PCR protection profile for the given
+// model parameters without accessing the TPM, and returns its textual
+// representation. It can be used to check that a set of boot chains can be
+// sealed against before committing to them.
+func BuildPCRProtectionProfile(modelParams []*SealKeyModelParams) (string, error) {
+	if len(modelParams) < 1 {
+		return "", fmt.Errorf("at least one set of model-specific parameters is required")
+	}
+	pcrProfile, err := buildPCRProtectionProfile(modelParams)
+	if err != nil {
+		return "", err
+	}
+	return pcrProfile.String(), nil
+}
+
 func buildPCRProtectionProfile(modelParams []*SealKeyModelParams) (*sb_tpm2.PCRProtectionProfile, error) {
 	numModels := len(modelParams)
 	modelPCRProfiles := make([]*sb_tpm2.PCRProtectionProfile, 0, numModels)
@@ -606,8 +621,13 @@ func buildLoadSequences(chains []*LoadChain) (loadseqs []*sb_efi.ImageLoadEvent,
 func (lc *LoadChain) loadEvent(source sb_efi.ImageLoadEventSource) (*sb_efi.ImageLoadEvent, error) {
 	var next []*sb_efi.ImageLoadEvent
 	for _, nextChain := range lc.Next {
-		// everything that is not the root has source shim
-		ev, err := nextChain.loadEvent(sb_efi.Shim)
+		// everything that is not the root has source shim, unless
+		// it was loaded directly through the firmware
+		source := sb_efi.Shim
+		if nextChain.LoadedByFirmware {
+			source = sb_efi.Firmware
+		}
+		ev, err := nextChain.loadEvent(source)
 		if err != nil {
 			return nil, err
 		}
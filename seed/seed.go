@@ -39,6 +39,28 @@ var (
 	open = Open
 )
 
+// SnapIntegrityError is returned by LoadMeta when an asserted seed snap is
+// missing or does not match its snap-revision assertion.
+type SnapIntegrityError struct {
+	// Path is where the snap is expected in the seed.
+	Path     string
+	SnapName string
+	SnapID   string
+	Revision snap.Revision
+	// SHA3_384 is the digest the snap is expected to have.
+	SHA3_384 string
+
+	Err error
+}
+
+func (e *SnapIntegrityError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SnapIntegrityError) Unwrap() error {
+	return e.Err
+}
+
 // Component holds the details of a component in a seed.
 type Component struct {
 	Path         string
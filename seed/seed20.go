@@ -954,13 +954,24 @@ func (s *seed20) lookupVerifiedRevision(snapRef naming.SnapRef, handler Containe
 	snapName := snapDecl.SnapName()
 	snapPath = filepath.Join(snapsDir, fmt.Sprintf("%s_%d.snap", snapName, snapRev.SnapRevision()))
 
+	integrityErr := func(err error) error {
+		return &SnapIntegrityError{
+			Path:     snapPath,
+			SnapName: snapName,
+			SnapID:   snapID,
+			Revision: snap.R(snapRev.SnapRevision()),
+			SHA3_384: snapRev.SnapSHA3_384(),
+			Err:      err,
+		}
+	}
+
 	fi, err := os.Stat(snapPath)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("cannot stat snap: %v", err)
+		return "", nil, nil, integrityErr(fmt.Errorf("cannot stat snap: %v", err))
 	}
 
 	if fi.Size() != int64(snapRev.SnapSize()) {
-		return "", nil, nil, fmt.Errorf("cannot validate %q for snap %q (snap-id %q), wrong size", snapPath, snapName, snapID)
+		return "", nil, nil, integrityErr(fmt.Errorf("cannot validate %q for snap %q (snap-id %q), wrong size", snapPath, snapName, snapID))
 	}
 
 	cpi := snap.MinimalSnapContainerPlaceInfo(snapName, snap.R(snapRev.SnapRevision()))
@@ -970,7 +981,7 @@ func (s *seed20) lookupVerifiedRevision(snapRef naming.SnapRef, handler Containe
 	}
 
 	if snapSHA3_384 != snapRev.SnapSHA3_384() {
-		return "", nil, nil, fmt.Errorf("cannot validate %q for snap %q (snap-id %q), hash mismatch with snap-revision", snapPath, snapName, snapID)
+		return "", nil, nil, integrityErr(fmt.Errorf("cannot validate %q for snap %q (snap-id %q), hash mismatch with snap-revision", snapPath, snapName, snapID))
 	}
 
 	if newPath != "" {
@@ -566,6 +566,12 @@ func (s *seed20Suite) TestLoadMetaMissingSnap(c *C) {
 
 	err = seed20.LoadMeta(seed.AllModes, nil, s.perfTimings)
 	c.Check(err, ErrorMatches, `cannot stat snap:.*pc_1\.snap.*`)
+	integrityErr, ok := err.(*seed.SnapIntegrityError)
+	c.Assert(ok, Equals, true)
+	c.Check(integrityErr.Path, Equals, filepath.Join(s.SeedDir, "snaps", "pc_1.snap"))
+	c.Check(integrityErr.SnapName, Equals, "pc")
+	c.Check(integrityErr.SnapID, Equals, s.AssertedSnapID("pc"))
+	c.Check(integrityErr.Revision, Equals, snap.R(1))
 }
 
 func (s *seed20Suite) TestLoadMetaWrongSizeSnap(c *C) {
@@ -583,6 +589,8 @@ func (s *seed20Suite) TestLoadMetaWrongSizeSnap(c *C) {
 
 	err = seed20.LoadMeta(seed.AllModes, nil, s.perfTimings)
 	c.Check(err, ErrorMatches, `cannot validate ".*pc_1\.snap" for snap "pc" \(snap-id "pc.*"\), wrong size`)
+	c.Assert(err, FitsTypeOf, &seed.SnapIntegrityError{})
+	c.Check(err.(*seed.SnapIntegrityError).SHA3_384, Equals, s.AssertedSnapRevision("pc").SnapSHA3_384())
 }
 
 func (s *seed20Suite) TestLoadMetaWrongHashSnap(c *C) {
@@ -615,6 +623,7 @@ func (s *seed20Suite) TestLoadMetaWrongHashSnap(c *C) {
 
 	err = seed20.LoadMeta(seed.AllModes, nil, s.perfTimings)
 	c.Check(err, ErrorMatches, `cannot validate ".*pc_1\.snap" for snap "pc" \(snap-id "pc.*"\), hash mismatch with snap-revision`)
+	c.Check(err, FitsTypeOf, &seed.SnapIntegrityError{})
 }
 
 func (s *seed20Suite) TestLoadMetaWrongGadgetBase(c *C) {
@@ -33,6 +33,7 @@ import (
 
 	"github.com/snapcore/snapd/desktop/desktopentry"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/metautil"
 	"github.com/snapcore/snapd/osutil"
@@ -1251,6 +1252,61 @@ type TimerInfo struct {
 	Timer string
 }
 
+// PathCondition is the condition on a path which activates an app.
+type PathCondition string
+
+const (
+	// PathExists activates the app when the path exists.
+	PathExists PathCondition = "path-exists"
+	// PathChanged activates the app when the file at the path is closed
+	// after having been written to.
+	PathChanged PathCondition = "path-changed"
+	// DirectoryNotEmpty activates the app when the directory at the path
+	// contains at least one file.
+	DirectoryNotEmpty PathCondition = "directory-not-empty"
+)
+
+// PathInfo provides information on application path activation.
+type PathInfo struct {
+	App *AppInfo
+
+	Name      string
+	Condition PathCondition
+	Path      string
+}
+
+// ServiceDependencyInfo provides information on the dependency of a service
+// on the services of the snap connected to one of its plugs.
+type ServiceDependencyInfo struct {
+	App  *AppInfo
+	Plug *PlugInfo
+
+	// Services lists the services of the connected snap that the app is
+	// started after. If empty, all the services of the connected snap with
+	// the same daemon scope are used.
+	Services []string
+	// Wants is whether starting the app also starts those services.
+	Wants bool
+}
+
+// ResourceHints provides the resources a service declares it expects to
+// use. They are only hints, which can be used to size a quota group for the
+// snap.
+type ResourceHints struct {
+	App *AppInfo
+
+	// ExpectedMemory is the memory the service is expected to use during
+	// normal operation.
+	ExpectedMemory quantity.Size
+	// MaxMemory is the maximum memory the service should ever use.
+	MaxMemory quantity.Size
+	// CPUShare is the percentage of a single CPU the service is expected to
+	// use, it can go over 100 for services using several CPUs.
+	CPUShare int
+	// Threads is the maximum number of threads the service should use.
+	Threads int
+}
+
 // StopModeType is the type for the "stop-mode:" of a snap app
 type StopModeType string
 
@@ -1316,12 +1372,42 @@ type AppInfo struct {
 	StopMode        StopModeType
 	InstallMode     string
 
+	// RefreshStrategy is how the service is handed over to the new
+	// revision when its snap is refreshed, as set with "refresh-strategy"
+	// in snap.yaml:
+	//  - "restart", the default, stops the service before the refresh
+	//    and starts it again afterwards.
+	//  - "rolling" is only supported by socket activated services. The
+	//    service is stopped before the refresh while its sockets keep
+	//    listening, connections received in the meantime are queued and
+	//    activate the new revision once the refresh is done.
+	//  - "drain" sends SIGUSR1 to the main process of the service and
+	//    gives it until DrainTimeout to finish its work and exit on its
+	//    own before stopping it. The default action of SIGUSR1 is to
+	//    terminate the process, services which do not handle the signal
+	//    are thus killed right away, without running their stop-command,
+	//    and are reported as failed until started again.
+	RefreshStrategy string
+	// DrainTimeout is how long a service with the "drain" refresh
+	// strategy is given to exit on its own, 30 seconds if unset.
+	DrainTimeout timeout.Timeout
+
 	// TODO: this should go away once we have more plumbing and can change
 	// things vs refactor
 	// https://github.com/snapcore/snapd/pull/794#discussion_r58688496
 	BusName     string
 	ActivatesOn []*SlotInfo
 
+	ActivatesOnPath map[string]*PathInfo
+
+	// ServiceDependencies are the dependencies on services of other snaps,
+	// keyed by the name of the plug connecting to them.
+	ServiceDependencies map[string]*ServiceDependencyInfo
+
+	// Resources are the resources the service declares it expects to use,
+	// if any.
+	Resources *ResourceHints
+
 	Plugs   map[string]*PlugInfo
 	Slots   map[string]*SlotInfo
 	Sockets map[string]*SocketInfo
@@ -1430,6 +1516,11 @@ func (socket *SocketInfo) File() string {
 	return filepath.Join(socket.App.serviceDir(), socket.App.SecurityTag()+"."+socket.Name+".socket")
 }
 
+// File returns the path to the *.path file
+func (path *PathInfo) File() string {
+	return filepath.Join(path.App.serviceDir(), path.App.SecurityTag()+"."+path.Name+".path")
+}
+
 // File returns the path to the *.timer file
 func (timer *TimerInfo) File() string {
 	return filepath.Join(timer.App.serviceDir(), timer.App.SecurityTag()+".timer")
@@ -28,6 +28,7 @@ import (
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/metautil"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timeout"
@@ -86,6 +87,8 @@ type appYaml struct {
 	WatchdogTimeout timeout.Timeout `yaml:"watchdog-timeout,omitempty"`
 	Completer       string          `yaml:"completer,omitempty"`
 	RefreshMode     string          `yaml:"refresh-mode,omitempty"`
+	RefreshStrategy string          `yaml:"refresh-strategy,omitempty"`
+	DrainTimeout    timeout.Timeout `yaml:"drain-timeout,omitempty"`
 	StopMode        StopModeType    `yaml:"stop-mode,omitempty"`
 	InstallMode     string          `yaml:"install-mode,omitempty"`
 
@@ -94,9 +97,14 @@ type appYaml struct {
 	SlotNames    []string         `yaml:"slots,omitempty"`
 	PlugNames    []string         `yaml:"plugs,omitempty"`
 
-	BusName     string   `yaml:"bus-name,omitempty"`
-	ActivatesOn []string `yaml:"activates-on,omitempty"`
-	CommonID    string   `yaml:"common-id,omitempty"`
+	BusName         string              `yaml:"bus-name,omitempty"`
+	ActivatesOn     []string            `yaml:"activates-on,omitempty"`
+	ActivatesOnPath map[string]pathYaml `yaml:"activates-on-path,omitempty"`
+	CommonID        string              `yaml:"common-id,omitempty"`
+
+	ServiceDependencies map[string]serviceDependencyYaml `yaml:"service-dependencies,omitempty"`
+
+	Resources *resourcesYaml `yaml:"resources,omitempty"`
 
 	Environment strutil.OrderedMap `yaml:"environment,omitempty"`
 
@@ -139,6 +147,77 @@ type socketsYaml struct {
 	SocketMode   os.FileMode `yaml:"socket-mode,omitempty"`
 }
 
+type pathYaml struct {
+	PathExists        string `yaml:"path-exists,omitempty"`
+	PathChanged       string `yaml:"path-changed,omitempty"`
+	DirectoryNotEmpty string `yaml:"directory-not-empty,omitempty"`
+}
+
+type serviceDependencyYaml struct {
+	Services []string `yaml:"services,omitempty"`
+	Wants    bool     `yaml:"wants,omitempty"`
+}
+
+type resourcesYaml struct {
+	ExpectedMemory string `yaml:"expected-memory,omitempty"`
+	MaxMemory      string `yaml:"max-memory,omitempty"`
+	CPUShare       int    `yaml:"cpu-share,omitempty"`
+	Threads        int    `yaml:"threads,omitempty"`
+}
+
+// hints returns the resource hints described by the stanza.
+func (r *resourcesYaml) hints(app *AppInfo) (*ResourceHints, error) {
+	hints := &ResourceHints{
+		App:      app,
+		CPUShare: r.CPUShare,
+		Threads:  r.Threads,
+	}
+	for _, m := range []struct {
+		field string
+		value string
+		size  *quantity.Size
+	}{
+		{"expected-memory", r.ExpectedMemory, &hints.ExpectedMemory},
+		{"max-memory", r.MaxMemory, &hints.MaxMemory},
+	} {
+		if m.value == "" {
+			continue
+		}
+		size, err := strutil.ParseByteSize(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", m.field, err)
+		}
+		*m.size = quantity.Size(size)
+	}
+	return hints, nil
+}
+
+// condition returns the only condition set for the path.
+func (p pathYaml) condition() (PathCondition, string, error) {
+	var cond PathCondition
+	var path string
+	for _, c := range []struct {
+		cond PathCondition
+		path string
+	}{
+		{PathExists, p.PathExists},
+		{PathChanged, p.PathChanged},
+		{DirectoryNotEmpty, p.DirectoryNotEmpty},
+	} {
+		if c.path == "" {
+			continue
+		}
+		if cond != "" {
+			return "", "", fmt.Errorf("only one of %s, %s or %s can be set", PathExists, PathChanged, DirectoryNotEmpty)
+		}
+		cond, path = c.cond, c.path
+	}
+	if cond == "" {
+		return "", "", fmt.Errorf("one of %s, %s or %s must be set", PathExists, PathChanged, DirectoryNotEmpty)
+	}
+	return cond, path, nil
+}
+
 // InfoFromSnapYaml creates a new info based on the given snap.yaml data
 func InfoFromSnapYaml(yamlData []byte) (*Info, error) {
 	return infoFromSnapYaml(yamlData, new(scopedTracker))
@@ -449,13 +528,15 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 			Completer:       yApp.Completer,
 			StopMode:        yApp.StopMode,
 			RefreshMode:     yApp.RefreshMode,
+			RefreshStrategy: yApp.RefreshStrategy,
+			DrainTimeout:    yApp.DrainTimeout,
 			InstallMode:     yApp.InstallMode,
 			Before:          yApp.Before,
 			After:           yApp.After,
 			Autostart:       yApp.Autostart,
 			WatchdogTimeout: yApp.WatchdogTimeout,
 		}
-		if len(y.Plugs) > 0 || len(yApp.PlugNames) > 0 {
+		if len(y.Plugs) > 0 || len(yApp.PlugNames) > 0 || len(yApp.ServiceDependencies) > 0 {
 			app.Plugs = make(map[string]*PlugInfo)
 		}
 		if len(y.Slots) > 0 || len(yApp.SlotNames) > 0 {
@@ -467,6 +548,12 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 		if len(yApp.ActivatesOn) > 0 {
 			app.ActivatesOn = make([]*SlotInfo, 0, len(yApp.ActivatesOn))
 		}
+		if len(yApp.ActivatesOnPath) > 0 {
+			app.ActivatesOnPath = make(map[string]*PathInfo, len(yApp.ActivatesOnPath))
+		}
+		if len(yApp.ServiceDependencies) > 0 {
+			app.ServiceDependencies = make(map[string]*ServiceDependencyInfo, len(yApp.ServiceDependencies))
+		}
 		// Daemons default to being system daemons
 		if app.Daemon != "" && app.DaemonScope == "" {
 			app.DaemonScope = SystemDaemon
@@ -532,6 +619,41 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 				SocketMode:   data.SocketMode,
 			}
 		}
+		for name, data := range yApp.ActivatesOnPath {
+			cond, path, err := data.condition()
+			if err != nil {
+				return fmt.Errorf("invalid activates-on-path value %q on app %q: %v", name, appName, err)
+			}
+			app.ActivatesOnPath[name] = &PathInfo{
+				App:       app,
+				Name:      name,
+				Condition: cond,
+				Path:      path,
+			}
+		}
+		for plugName, data := range yApp.ServiceDependencies {
+			plug, ok := snap.Plugs[plugName]
+			if !ok {
+				return fmt.Errorf("invalid service-dependencies value %q on app %q: plug not found", plugName, appName)
+			}
+			app.ServiceDependencies[plugName] = &ServiceDependencyInfo{
+				App:      app,
+				Plug:     plug,
+				Services: data.Services,
+				Wants:    data.Wants,
+			}
+			// Implicitly add the plug to the app
+			strk.markPlug(plug)
+			app.Plugs[plugName] = plug
+			plug.Apps[appName] = app
+		}
+		if yApp.Resources != nil {
+			hints, err := yApp.Resources.hints(app)
+			if err != nil {
+				return fmt.Errorf("invalid resources value on app %q: %v", appName, err)
+			}
+			app.Resources = hints
+		}
 		if yApp.Timer != "" {
 			app.Timer = &TimerInfo{
 				App:   app,
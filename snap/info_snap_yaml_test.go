@@ -1434,6 +1434,194 @@ apps:
 	c.Check(err, ErrorMatches, `invalid activates-on value "test-slot" on app "daemon": slot not found`)
 }
 
+func (s *YamlSuite) TestUnmarshalActivatesOnPath(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        daemon: simple
+        activates-on-path:
+            spool:
+                directory-not-empty: $SNAP_COMMON/spool
+            config:
+                path-changed: $SNAP_DATA/config.yaml
+            flag:
+                path-exists: $SNAP_DATA/go
+    foo:
+`))
+	c.Assert(err, IsNil)
+	app := info.Apps["daemon"]
+	c.Assert(app, NotNil)
+	c.Check(app.ActivatesOnPath, DeepEquals, map[string]*snap.PathInfo{
+		"spool": {
+			App:       app,
+			Name:      "spool",
+			Condition: snap.DirectoryNotEmpty,
+			Path:      "$SNAP_COMMON/spool",
+		},
+		"config": {
+			App:       app,
+			Name:      "config",
+			Condition: snap.PathChanged,
+			Path:      "$SNAP_DATA/config.yaml",
+		},
+		"flag": {
+			App:       app,
+			Name:      "flag",
+			Condition: snap.PathExists,
+			Path:      "$SNAP_DATA/go",
+		},
+	})
+	c.Check(info.Apps["foo"].ActivatesOnPath, IsNil)
+}
+
+func (s *YamlSuite) TestUnmarshalActivatesOnPathErrors(c *C) {
+	_, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        activates-on-path:
+            spool:
+                directory-not-empty: $SNAP_COMMON/spool
+                path-exists: $SNAP_COMMON/spool
+`))
+	c.Check(err, ErrorMatches, `invalid activates-on-path value "spool" on app "daemon": only one of path-exists, path-changed or directory-not-empty can be set`)
+
+	_, err = snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        activates-on-path:
+            spool: {}
+`))
+	c.Check(err, ErrorMatches, `invalid activates-on-path value "spool" on app "daemon": one of path-exists, path-changed or directory-not-empty must be set`)
+}
+
+func (s *YamlSuite) TestUnmarshalServiceDependencies(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+plugs:
+    db:
+        interface: content
+apps:
+    daemon:
+        daemon: simple
+        service-dependencies:
+            db:
+                services: [postgres]
+                wants: true
+    foo:
+`))
+	c.Assert(err, IsNil)
+	app := info.Apps["daemon"]
+	c.Assert(app, NotNil)
+	plug := info.Plugs["db"]
+	c.Assert(plug, NotNil)
+	c.Check(app.ServiceDependencies, DeepEquals, map[string]*snap.ServiceDependencyInfo{
+		"db": {
+			App:      app,
+			Plug:     plug,
+			Services: []string{"postgres"},
+			Wants:    true,
+		},
+	})
+	c.Check(app.Plugs["db"], Equals, plug)
+	c.Check(plug.Apps["daemon"], Equals, app)
+	c.Check(info.Apps["foo"].ServiceDependencies, IsNil)
+}
+
+func (s *YamlSuite) TestUnmarshalServiceDependenciesPlugNotFound(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        daemon: simple
+        service-dependencies:
+            db:
+                services: [postgres]
+`))
+	c.Check(info, IsNil)
+	c.Check(err, ErrorMatches, `invalid service-dependencies value "db" on app "daemon": plug not found`)
+}
+
+func (s *YamlSuite) TestUnmarshalRefreshStrategy(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    web:
+        daemon: simple
+        refresh-strategy: rolling
+    worker:
+        daemon: simple
+        refresh-strategy: drain
+        drain-timeout: 30s
+    foo:
+`))
+	c.Assert(err, IsNil)
+	c.Check(info.Apps["web"].RefreshStrategy, Equals, "rolling")
+	c.Check(info.Apps["web"].DrainTimeout, Equals, timeout.Timeout(0))
+	c.Check(info.Apps["worker"].RefreshStrategy, Equals, "drain")
+	c.Check(info.Apps["worker"].DrainTimeout, Equals, timeout.Timeout(30*time.Second))
+	c.Check(info.Apps["foo"].RefreshStrategy, Equals, "")
+}
+
+func (s *YamlSuite) TestUnmarshalResources(c *C) {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        daemon: simple
+        resources:
+            expected-memory: 100MB
+            max-memory: 1GB
+            cpu-share: 50
+            threads: 32
+    other:
+        daemon: simple
+        resources:
+            threads: 4
+    foo:
+`))
+	c.Assert(err, IsNil)
+	app := info.Apps["daemon"]
+	c.Assert(app, NotNil)
+	c.Check(app.Resources, DeepEquals, &snap.ResourceHints{
+		App:            app,
+		ExpectedMemory: 100 * 1000 * 1000,
+		MaxMemory:      1000 * 1000 * 1000,
+		CPUShare:       50,
+		Threads:        32,
+	})
+	other := info.Apps["other"]
+	c.Check(other.Resources, DeepEquals, &snap.ResourceHints{
+		App:     other,
+		Threads: 4,
+	})
+	c.Check(info.Apps["foo"].Resources, IsNil)
+}
+
+func (s *YamlSuite) TestUnmarshalResourcesErrors(c *C) {
+	_, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        daemon: simple
+        resources:
+            expected-memory: lots
+`))
+	c.Check(err, ErrorMatches, `invalid resources value on app "daemon": invalid expected-memory: cannot parse "lots": .*`)
+
+	_, err = snap.InfoFromSnapYaml([]byte(`
+name: snap
+apps:
+    daemon:
+        daemon: simple
+        resources:
+            max-memory: 1024
+`))
+	c.Check(err, ErrorMatches, `invalid resources value on app "daemon": invalid max-memory: cannot parse "1024": need a number with a unit as input`)
+}
+
 // type and architectures
 
 func (s *YamlSuite) TestSnapYamlTypeDefault(c *C) {
@@ -163,6 +163,15 @@ func ValidateSocket(name string) error {
 	return nil
 }
 
+// ValidatePathActivator checks if a string can be used as a name for a path
+// activating an app.
+func ValidatePathActivator(name string) error {
+	if !isValidName(name) {
+		return fmt.Errorf("invalid path activator name: %q", name)
+	}
+	return nil
+}
+
 // ValidateIfaceTag can be used to check valid tags in interfaces.
 // These tags are used to match plugs with slots, and although they
 // could be arbitrary strings it is nice to keep naming consistent
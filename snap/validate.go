@@ -657,6 +657,7 @@ func validateAppTimeouts(app *AppInfo) error {
 		{"start-timeout", app.StartTimeout},
 		{"stop-timeout", app.StopTimeout},
 		{"watchdog-timeout", app.WatchdogTimeout},
+		{"drain-timeout", app.DrainTimeout},
 	} {
 		if t.timeout == 0 {
 			continue
@@ -671,6 +672,45 @@ func validateAppTimeouts(app *AppInfo) error {
 	return nil
 }
 
+func validateAppRefreshStrategy(app *AppInfo) error {
+	switch app.RefreshStrategy {
+	case "", "restart", "rolling", "drain":
+		// valid
+	default:
+		return fmt.Errorf(`"refresh-strategy" field contains invalid value %q`, app.RefreshStrategy)
+	}
+	if app.RefreshStrategy != "" {
+		if app.Daemon == "" {
+			return fmt.Errorf(`"refresh-strategy" cannot be used for %q, only for services`, app.Name)
+		}
+		if app.RefreshMode == "endure" {
+			return fmt.Errorf(`"refresh-strategy" cannot be used for %q with "refresh-mode" set to "endure"`, app.Name)
+		}
+	}
+	// the services running in user sessions are stopped and started
+	// through the session agents, which only know how to restart them
+	if app.RefreshStrategy != "" && app.RefreshStrategy != "restart" && app.DaemonScope == UserDaemon {
+		return fmt.Errorf(`"refresh-strategy" cannot be set to %q for user daemon %q`, app.RefreshStrategy, app.Name)
+	}
+	if app.RefreshStrategy == "rolling" {
+		// the service hands over its listening sockets to the new
+		// revision, which requires them to outlive the service process
+		// and to be independent of the revision
+		if len(app.Sockets) == 0 {
+			return fmt.Errorf(`"refresh-strategy" cannot be set to "rolling" for %q, only for socket activated services`, app.Name)
+		}
+		for _, sock := range app.Sockets {
+			if strings.HasPrefix(sock.ListenStream, "$SNAP_DATA/") {
+				return fmt.Errorf(`"refresh-strategy" cannot be set to "rolling" for %q, socket %q listens in the revision specific $SNAP_DATA`, app.Name, sock.Name)
+			}
+		}
+	}
+	if app.DrainTimeout != 0 && app.RefreshStrategy != "drain" {
+		return fmt.Errorf(`"drain-timeout" can only be used for %q with "refresh-strategy" set to "drain"`, app.Name)
+	}
+	return nil
+}
+
 func validateAppTimer(app *AppInfo) error {
 	if app.Timer == nil {
 		return nil
@@ -749,6 +789,95 @@ func validateAppActivatesOn(app *AppInfo) error {
 	return nil
 }
 
+func validateAppPath(path *PathInfo) error {
+	if err := naming.ValidatePathActivator(path.Name); err != nil {
+		return err
+	}
+
+	fieldName := string(path.Condition)
+	if clean := filepath.Clean(path.Path); clean != path.Path {
+		return fmt.Errorf("invalid %q: %q should be written as %q", fieldName, path.Path, clean)
+	}
+	if err := validateField(fieldName, path.Path, commandChainContentWhitelist); err != nil {
+		return err
+	}
+
+	// only the writable areas of the snap can be watched
+	switch path.App.DaemonScope {
+	case SystemDaemon:
+		if !(strings.HasPrefix(path.Path, "$SNAP_DATA/") || strings.HasPrefix(path.Path, "$SNAP_COMMON/")) {
+			return fmt.Errorf("invalid %q: system daemon paths must have a prefix of $SNAP_DATA or $SNAP_COMMON", fieldName)
+		}
+	case UserDaemon:
+		if !(strings.HasPrefix(path.Path, "$SNAP_USER_DATA/") || strings.HasPrefix(path.Path, "$SNAP_USER_COMMON/")) {
+			return fmt.Errorf("invalid %q: user daemon paths must have a prefix of $SNAP_USER_DATA or $SNAP_USER_COMMON", fieldName)
+		}
+	default:
+		return fmt.Errorf("invalid %q: cannot validate paths for daemon-scope %q", fieldName, path.App.DaemonScope)
+	}
+
+	return nil
+}
+
+func validateAppActivatesOnPath(app *AppInfo) error {
+	if len(app.ActivatesOnPath) == 0 {
+		return nil
+	}
+
+	if !app.IsService() {
+		return errors.New("activates-on-path is only applicable to services")
+	}
+
+	for _, path := range app.ActivatesOnPath {
+		if err := validateAppPath(path); err != nil {
+			return fmt.Errorf("invalid definition of activates-on-path %q: %v", path.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateAppServiceDependencies(app *AppInfo) error {
+	if len(app.ServiceDependencies) == 0 {
+		return nil
+	}
+
+	if !app.IsService() {
+		return errors.New("service-dependencies is only applicable to services")
+	}
+
+	for _, dep := range app.ServiceDependencies {
+		for _, name := range dep.Services {
+			if !ValidAppName(name) {
+				return fmt.Errorf("invalid definition of service-dependencies %q: invalid service name %q", dep.Plug.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateAppResources(app *AppInfo) error {
+	res := app.Resources
+	if res == nil {
+		return nil
+	}
+
+	if !app.IsService() {
+		return errors.New("resources is only applicable to services")
+	}
+
+	if res.MaxMemory != 0 && res.MaxMemory < res.ExpectedMemory {
+		return fmt.Errorf("invalid resources: max-memory %s is lower than expected-memory %s",
+			res.MaxMemory.IECString(), res.ExpectedMemory.IECString())
+	}
+	if res.CPUShare < 0 {
+		return fmt.Errorf("invalid resources: cpu-share cannot be negative")
+	}
+	if res.Threads < 0 {
+		return fmt.Errorf("invalid resources: threads cannot be negative")
+	}
+	return nil
+}
+
 // appContentWhitelist is the whitelist of legal chars in the "apps"
 // section of snap.yaml. Do not allow any of [',",`] here or snap-exec
 // will get confused. chainContentWhitelist is the same, but for the
@@ -827,6 +956,18 @@ func ValidateApp(app *AppInfo) error {
 		return err
 	}
 
+	if err := validateAppActivatesOnPath(app); err != nil {
+		return err
+	}
+
+	if err := validateAppServiceDependencies(app); err != nil {
+		return err
+	}
+
+	if err := validateAppResources(app); err != nil {
+		return err
+	}
+
 	if err := validateAppRestart(app); err != nil {
 		return err
 	}
@@ -872,6 +1013,9 @@ func ValidateApp(app *AppInfo) error {
 	if app.InstallMode != "" && app.Daemon == "" {
 		return fmt.Errorf(`"install-mode" cannot be used for %q, only for services`, app.Name)
 	}
+	if err := validateAppRefreshStrategy(app); err != nil {
+		return err
+	}
 
 	return validateAppTimer(app)
 }
@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	. "gopkg.in/check.v1"
@@ -34,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	. "github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeout"
 )
 
 type ValidateSuite struct {
@@ -572,6 +574,57 @@ func (s *ValidateSuite) TestAppRefreshMode(c *C) {
 	}
 }
 
+func (s *ValidateSuite) TestAppRefreshStrategy(c *C) {
+	commonSockets := map[string]*SocketInfo{
+		"sock": {Name: "sock", ListenStream: "$SNAP_COMMON/sock"},
+	}
+	netSockets := map[string]*SocketInfo{
+		"sock": {Name: "sock", ListenStream: "8080"},
+	}
+	dataSockets := map[string]*SocketInfo{
+		"sock": {Name: "sock", ListenStream: "$SNAP_DATA/sock"},
+	}
+	for _, t := range []struct {
+		app    AppInfo
+		errMsg string
+	}{
+		// good
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "restart"}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling", Sockets: commonSockets}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling", Sockets: netSockets}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "drain"}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "drain", DrainTimeout: timeout.Timeout(10 * time.Second)}, ""},
+		{AppInfo{Daemon: "simple", DaemonScope: UserDaemon, RefreshStrategy: "restart"}, ""},
+		// bad
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "invalid-thing"}, `"refresh-strategy" field contains invalid value "invalid-thing"`},
+		{AppInfo{RefreshStrategy: "rolling"}, `"refresh-strategy" cannot be used for "foo", only for services`},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling", RefreshMode: "endure"}, `"refresh-strategy" cannot be used for "foo" with "refresh-mode" set to "endure"`},
+		{AppInfo{Daemon: "simple", DaemonScope: UserDaemon, RefreshStrategy: "rolling"}, `"refresh-strategy" cannot be set to "rolling" for user daemon "foo"`},
+		{AppInfo{Daemon: "simple", DaemonScope: UserDaemon, RefreshStrategy: "drain"}, `"refresh-strategy" cannot be set to "drain" for user daemon "foo"`},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling"}, `"refresh-strategy" cannot be set to "rolling" for "foo", only for socket activated services`},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling", Sockets: dataSockets}, `"refresh-strategy" cannot be set to "rolling" for "foo", socket "sock" listens in the revision specific \$SNAP_DATA`},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "rolling", Sockets: commonSockets, DrainTimeout: timeout.Timeout(10 * time.Second)}, `"drain-timeout" can only be used for "foo" with "refresh-strategy" set to "drain"`},
+		{AppInfo{Daemon: "simple", DaemonScope: SystemDaemon, RefreshStrategy: "drain", DrainTimeout: timeout.Timeout(-10 * time.Second)}, `drain-timeout cannot be negative`},
+		{AppInfo{DrainTimeout: timeout.Timeout(10 * time.Second)}, `drain-timeout is only applicable to services`},
+	} {
+		app := t.app
+		app.Name = "foo"
+		if len(app.Sockets) > 0 {
+			app.Plugs = map[string]*PlugInfo{"network-bind": {Name: "network-bind", Interface: "network-bind"}}
+			for _, sock := range app.Sockets {
+				sock.App = &app
+			}
+		}
+		err := ValidateApp(&app)
+		if t.errMsg == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.errMsg)
+		}
+	}
+}
+
 func (s *ValidateSuite) TestAppWhitelistError(c *C) {
 	err := ValidateApp(&AppInfo{Name: "foo", Command: "x\n"})
 	c.Assert(err, NotNil)
@@ -595,6 +648,148 @@ apps:
 	c.Check(ValidateApp(app), IsNil)
 }
 
+func (s *ValidateSuite) TestAppActivatesOnPath(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+apps:
+  ingest:
+    daemon: simple
+    activates-on-path:
+      spool:
+        directory-not-empty: $SNAP_COMMON/spool
+  user-ingest:
+    daemon: simple
+    daemon-scope: user
+    activates-on-path:
+      spool:
+        path-changed: $SNAP_USER_DATA/spool/new
+`))
+	c.Assert(err, IsNil)
+	c.Check(ValidateApp(info.Apps["ingest"]), IsNil)
+	c.Check(ValidateApp(info.Apps["user-ingest"]), IsNil)
+}
+
+func (s *ValidateSuite) TestAppActivatesOnPathErrors(c *C) {
+	for _, t := range []struct {
+		app string
+		err string
+	}{{`
+    command: foo
+    activates-on-path:
+      spool:
+        directory-not-empty: $SNAP_COMMON/spool`,
+		`activates-on-path is only applicable to services`,
+	}, {`
+    daemon: simple
+    activates-on-path:
+      spool:
+        directory-not-empty: /var/spool`,
+		`invalid definition of activates-on-path "spool": invalid "directory-not-empty": system daemon paths must have a prefix of \$SNAP_DATA or \$SNAP_COMMON`,
+	}, {`
+    daemon: simple
+    activates-on-path:
+      spool:
+        path-exists: $SNAP_DATA/../../etc/passwd`,
+		`invalid definition of activates-on-path "spool": invalid "path-exists": "\$SNAP_DATA/../../etc/passwd" should be written as "../etc/passwd"`,
+	}, {`
+    daemon: simple
+    activates-on-path:
+      spool:
+        path-exists: $SNAP_DATA/a file`,
+		`invalid definition of activates-on-path "spool": app description field 'path-exists' contains illegal .*`,
+	}, {`
+    daemon: simple
+    daemon-scope: user
+    activates-on-path:
+      spool:
+        path-changed: $SNAP_COMMON/spool`,
+		`invalid definition of activates-on-path "spool": invalid "path-changed": user daemon paths must have a prefix of \$SNAP_USER_DATA or \$SNAP_USER_COMMON`,
+	}, {`
+    daemon: simple
+    activates-on-path:
+      bad_name:
+        path-changed: $SNAP_COMMON/spool`,
+		`invalid definition of activates-on-path "bad_name": invalid path activator name: "bad_name"`,
+	}} {
+		info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+apps:
+  ingest:` + t.app + "\n"))
+		c.Assert(err, IsNil)
+		c.Check(ValidateApp(info.Apps["ingest"]), ErrorMatches, t.err, Commentf(t.app))
+	}
+}
+
+func (s *ValidateSuite) TestAppServiceDependencies(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+plugs:
+  db:
+    interface: content
+apps:
+  server:
+    daemon: simple
+    service-dependencies:
+      db:
+        services: [postgres]
+  cmd:
+    command: foo
+    service-dependencies:
+      db: {}
+  other:
+    daemon: simple
+    service-dependencies:
+      db:
+        services: [bad_name]
+`))
+	c.Assert(err, IsNil)
+	c.Check(ValidateApp(info.Apps["server"]), IsNil)
+	c.Check(ValidateApp(info.Apps["cmd"]), ErrorMatches, `service-dependencies is only applicable to services`)
+	c.Check(ValidateApp(info.Apps["other"]), ErrorMatches, `invalid definition of service-dependencies "db": invalid service name "bad_name"`)
+}
+
+func (s *ValidateSuite) TestAppResources(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+apps:
+  server:
+    daemon: simple
+    resources:
+      expected-memory: 100MB
+      max-memory: 1GB
+      cpu-share: 150
+      threads: 32
+  expected-only:
+    daemon: simple
+    resources:
+      expected-memory: 100MB
+  cmd:
+    command: foo
+    resources:
+      threads: 2
+  over:
+    daemon: simple
+    resources:
+      expected-memory: 1GB
+      max-memory: 100MB
+  negative-cpu:
+    daemon: simple
+    resources:
+      cpu-share: -1
+  negative-threads:
+    daemon: simple
+    resources:
+      threads: -1
+`))
+	c.Assert(err, IsNil)
+	c.Check(ValidateApp(info.Apps["server"]), IsNil)
+	c.Check(ValidateApp(info.Apps["expected-only"]), IsNil)
+	c.Check(ValidateApp(info.Apps["cmd"]), ErrorMatches, `resources is only applicable to services`)
+	c.Check(ValidateApp(info.Apps["over"]), ErrorMatches, `invalid resources: max-memory 95.37 MiB is lower than expected-memory 953.67 MiB`)
+	c.Check(ValidateApp(info.Apps["negative-cpu"]), ErrorMatches, `invalid resources: cpu-share cannot be negative`)
+	c.Check(ValidateApp(info.Apps["negative-threads"]), ErrorMatches, `invalid resources: threads cannot be negative`)
+}
+
 func (s *ValidateSuite) TestAppActivatesOnNotDaemon(c *C) {
 	info, err := InfoFromSnapYaml([]byte(`name: foo
 version: 1.0
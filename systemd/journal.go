@@ -22,9 +22,11 @@ package systemd
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log/syslog"
 	"net"
 	"os"
+	"strconv"
 )
 
 var journalStdoutPath = "/run/systemd/journal/stdout"
@@ -70,3 +72,20 @@ func NewJournalStreamFile(identifier string, priority syslog.Priority, levelPref
 
 	return conn.File()
 }
+
+// AuditLogReader returns a reader of the kernel and audit messages stored in
+// the journal, as a stream of JSON encoded Log entries. Only the last n
+// entries are returned, or all of them if n is negative.
+//
+// Security denials of AppArmor and seccomp are logged as such messages.
+func AuditLogReader(n int) (io.ReadCloser, error) {
+	args := []string{"-o", "json", "--no-pager"}
+	if n < 0 {
+		args = append(args, "--no-tail")
+	} else {
+		args = append(args, "-n", strconv.Itoa(n))
+	}
+	// matches for the same field are combined with a logical OR
+	args = append(args, "_TRANSPORT=kernel", "_TRANSPORT=audit")
+	return osutilStreamCommand("journalctl", args...)
+}
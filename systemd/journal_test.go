@@ -20,9 +20,11 @@
 package systemd_test
 
 import (
+	"io"
 	"log/syslog"
 	"net"
 	"path"
+	"strings"
 
 	. "gopkg.in/check.v1"
 
@@ -87,3 +89,21 @@ func (j *journalTestSuite) TestStreamFileHeader(c *C) {
 
 	<-doneCh
 }
+
+func (j *journalTestSuite) TestAuditLogReader(c *C) {
+	var calls [][]string
+	restore := MockOsutilStreamCommand(func(name string, args ...string) (io.ReadCloser, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return io.NopCloser(strings.NewReader("")), nil
+	})
+	defer restore()
+
+	_, err := AuditLogReader(10)
+	c.Assert(err, IsNil)
+	_, err = AuditLogReader(-1)
+	c.Assert(err, IsNil)
+	c.Check(calls, DeepEquals, [][]string{
+		{"journalctl", "-o", "json", "--no-pager", "-n", "10", "_TRANSPORT=kernel", "_TRANSPORT=audit"},
+		{"journalctl", "-o", "json", "--no-pager", "--no-tail", "_TRANSPORT=kernel", "_TRANSPORT=audit"},
+	})
+}
@@ -34,9 +34,12 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"text/template"
 	"time"
 
+	"golang.org/x/sys/unix"
+
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/logger"
@@ -275,12 +278,98 @@ func EnsureAtLeast(requiredVersion int) error {
 
 var osutilStreamCommand = osutil.StreamCommand
 
+// LogFilter holds the optional restrictions on the journal entries returned by
+// LogReader.
+type LogFilter struct {
+	// Since and Until restrict the entries to the given time range, when
+	// set.
+	Since time.Time
+	Until time.Time
+	// Priority is the minimum priority of the entries, as understood by
+	// journalctl -p, e.g. "err" or "3".
+	Priority string
+	// Grep is a regular expression the messages of the entries must match,
+	// as understood by journalctl --grep.
+	Grep string
+	// Boot selects the entries of a given boot, as understood by journalctl
+	// -b, e.g. "0", "-1" or a boot ID.
+	Boot string
+	// UserServices are user session services whose entries are returned in
+	// addition to the ones of the system services.
+	UserServices []string
+}
+
+func (f *LogFilter) args() []string {
+	var args []string
+	if !f.Since.IsZero() {
+		args = append(args, fmt.Sprintf("--since=@%d", f.Since.Unix()))
+	}
+	if !f.Until.IsZero() {
+		args = append(args, fmt.Sprintf("--until=@%d", f.Until.Unix()))
+	}
+	if f.Priority != "" {
+		args = append(args, "--priority="+f.Priority)
+	}
+	if f.Grep != "" {
+		args = append(args, "--grep="+f.Grep)
+	}
+	if f.Boot != "" {
+		args = append(args, "--boot="+f.Boot)
+	}
+	return args
+}
+
+// systemUnitMatches returns the journal matches used by journalctl -u to find
+// the messages about the given system units, followed by a "+" to OR them
+// with further matches. Matches on the same field are ORed, matches on
+// different fields are ANDed and "+" ORs groups of matches.
+func systemUnitMatches(units []string) []string {
+	if len(units) == 0 {
+		return nil
+	}
+	groups := []struct {
+		field string
+		extra []string
+	}{
+		// messages of the units themselves
+		{field: "_SYSTEMD_UNIT"},
+		// core dumps of the units
+		{field: "COREDUMP_UNIT", extra: []string{"MESSAGE_ID=fc2e22bc6ee647b6b90729ab34a250b1", "_UID=0"}},
+		// messages of systemd about the units
+		{field: "UNIT", extra: []string{"_PID=1"}},
+		// messages of authorized daemons about the units
+		{field: "OBJECT_SYSTEMD_UNIT", extra: []string{"_UID=0"}},
+	}
+	var matches []string
+	for _, group := range groups {
+		matches = append(matches, group.extra...)
+		for _, unit := range units {
+			matches = append(matches, group.field+"="+unit)
+		}
+		matches = append(matches, "+")
+	}
+	return matches
+}
+
 // jctl calls journalctl to get the JSON logs of the given services.
-var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
-	// args will need two entries per service, plus a fixed number (give or take
-	// one) for the initial options.
-	args := make([]string, 0, 2*len(svcs)+7)        // We have at most 7 extra arguments
-	args = append(args, "-o", "json", "--no-pager") //   3...
+var jctl = func(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
+	if filter == nil {
+		filter = &LogFilter{}
+	}
+	filterArgs := filter.args()
+
+	// args will need two entries per service (or, with user services, the
+	// unit matches of the services and one per user service), plus the
+	// filter arguments and a fixed number (give or take one) for the
+	// initial options.
+	unitArgs := 2 * len(svcs)
+	var unitMatches []string
+	if len(filter.UserServices) > 0 {
+		unitMatches = systemUnitMatches(svcs)
+		unitArgs = len(unitMatches) + len(filter.UserServices)
+	}
+	args := make([]string, 0, unitArgs+len(filterArgs)+7) // We have at most 7 extra arguments
+	args = append(args, "-o", "json", "--no-pager")       //   3...
 	if n < 0 {
 		args = append(args, "--no-tail") // < 2
 	} else {
@@ -292,15 +381,28 @@ var jctl = func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, e
 	if namespaces {
 		args = append(args, "--namespace=*") // ... + 1 == 7
 	}
+	args = append(args, filterArgs...)
+
+	if len(filter.UserServices) == 0 {
+		for i := range svcs {
+			args = append(args, "-u", svcs[i])
+		}
+		return osutilStreamCommand("journalctl", args...)
+	}
 
-	for i := range svcs {
-		args = append(args, "-u", svcs[i]) // this is why 2×
+	// -u only knows about system services, and --user-unit about the
+	// services of the calling user, so match on the unit fields directly;
+	// the system services are matched like -u does, so that the messages
+	// of systemd about them and their core dumps are kept
+	args = append(args, unitMatches...)
+	for _, svc := range filter.UserServices {
+		args = append(args, "_SYSTEMD_USER_UNIT="+svc)
 	}
 
 	return osutilStreamCommand("journalctl", args...)
 }
 
-func MockJournalctl(f func(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error)) func() {
+func MockJournalctl(f func(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error)) func() {
 	oldJctl := jctl
 	jctl = f
 	return func() {
@@ -413,6 +515,10 @@ type Systemd interface {
 	// unit's transition to inactive.
 	// TODO: incorporate this result into Status instead?
 	InactiveEnterTimestamp(unit string) (time.Time, error)
+	// ServiceRestartStats returns what systemd tracked about the exits and
+	// automatic restarts of the given services since they were last
+	// started, in the order of the services.
+	ServiceRestartStats(services []string) ([]*ServiceRestartStats, error)
 	// IsEnabled checks whether the given service is enabled.
 	IsEnabled(service string) (bool, error)
 	// IsActive checks whether the given service is Active
@@ -422,7 +528,8 @@ type Systemd interface {
 	// as it grows.
 	// If namespaces is set to true, the log reader will include journal namespace
 	// logs, and is required to get logs for services which are in journal namespaces.
-	LogReader(services []string, n int, follow, namespaces bool) (io.ReadCloser, error)
+	// The entries can be further restricted with the optional filter.
+	LogReader(services []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error)
 	// EnsureMountUnitFile adds/enables/starts a mount unit.
 	EnsureMountUnitFile(description, what, where, fstype string, flags EnsureMountUnitFlags) (string, error)
 	// EnsureMountUnitFileWithOptions adds/enables/starts a mount unit with options.
@@ -503,6 +610,9 @@ const (
 	// the default target for systemd timer units that we generate
 	TimersTarget = "timers.target"
 
+	// the default target for systemd path units that we generate
+	PathsTarget = "paths.target"
+
 	// the target for systemd user session units that we generate
 	UserServicesTarget = "default.target"
 )
@@ -682,8 +792,8 @@ func (s *systemd) StartNoBlock(serviceNames []string) error {
 	return err
 }
 
-func (*systemd) LogReader(serviceNames []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
-	return jctl(serviceNames, n, follow, namespaces)
+func (*systemd) LogReader(serviceNames []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
+	return jctl(serviceNames, n, follow, namespaces, filter)
 }
 
 var statusregex = regexp.MustCompile(`(?m)^(?:(.+?)=(.*)|(.*))?$`)
@@ -716,6 +826,7 @@ var unitProperties = map[string][]string{
 	".timer":  baseProperties,
 	".socket": baseProperties,
 	".target": baseProperties,
+	".path":   baseProperties,
 	// in service units, Type is the daemon type
 	".service": extendedProperties,
 	// in mount units, Type is the fs type
@@ -950,6 +1061,108 @@ func (s *systemd) InactiveEnterTimestamp(unit string) (time.Time, error) {
 	return inactiveEnterTime, nil
 }
 
+// ServiceRestartStats holds what systemd tracked about the exits and
+// automatic restarts of a service since it was last started.
+type ServiceRestartStats struct {
+	// Failed is true when the service is in the failed state.
+	Failed bool
+	// Restarts is the number of times systemd restarted the service
+	// according to its restart condition.
+	Restarts int
+	// ExitCode is the exit code of the last main process of the service,
+	// if it exited on its own.
+	ExitCode int
+	// ExitSignal is the name of the signal which terminated the last main
+	// process of the service, if any, e.g. "SIGSEGV".
+	ExitSignal string
+	// ExitTime is when the last main process of the service exited, it is
+	// the zero time if it did not exit since the service was last started.
+	ExitTime time.Time
+}
+
+var restartStatsProperties = []string{"ActiveState", "NRestarts", "ExecMainCode", "ExecMainStatus", "ExecMainExitTimestamp"}
+
+// values of ExecMainCode, see waitid(2)
+const (
+	cldExited = 1
+	cldKilled = 2
+	cldDumped = 3
+)
+
+func (s *systemd) ServiceRestartStats(services []string) ([]*ServiceRestartStats, error) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+	args := make([]string, 0, len(services)+2)
+	args = append(args, "show", "--property="+strings.Join(restartStatsProperties, ","))
+	args = append(args, services...)
+	out, err := s.systemctl(args...)
+	if err != nil {
+		return nil, osutil.OutputErr(out, err)
+	}
+
+	// systemctl separates the properties of the units by an empty line
+	blocks := strings.Split(strings.TrimSpace(string(out)), "\n\n")
+	if len(blocks) != len(services) {
+		return nil, fmt.Errorf("cannot get restart statistics of services: expected %d results, got %d", len(services), len(blocks))
+	}
+	allStats := make([]*ServiceRestartStats, 0, len(services))
+	for i, block := range blocks {
+		stats, err := parseServiceRestartStats(services[i], block)
+		if err != nil {
+			return nil, err
+		}
+		allStats = append(allStats, stats)
+	}
+	return allStats, nil
+}
+
+func parseServiceRestartStats(service, out string) (*ServiceRestartStats, error) {
+	var mainCode, mainStatus int
+	var err error
+	stats := &ServiceRestartStats{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("cannot get restart statistics of service %q: bad line %q in ‘systemctl show’ output", service, line)
+		}
+		k, v := kv[0], kv[1]
+		// properties unknown to older systemd versions, or not
+		// applicable yet, are reported without a value
+		if v == "" || v == "[not set]" {
+			continue
+		}
+		switch k {
+		case "ActiveState":
+			stats.Failed = v == "failed"
+		case "NRestarts":
+			stats.Restarts, err = strconv.Atoi(v)
+		case "ExecMainCode":
+			mainCode, err = strconv.Atoi(v)
+		case "ExecMainStatus":
+			mainStatus, err = strconv.Atoi(v)
+		case "ExecMainExitTimestamp":
+			stats.ExitTime, err = time.Parse("Mon 2006-01-02 15:04:05 MST", v)
+		default:
+			return nil, fmt.Errorf("cannot get restart statistics of service %q: unexpected field %q in ‘systemctl show’ output", service, k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot get restart statistics of service %q: invalid value %q for %s", service, v, k)
+		}
+	}
+
+	switch mainCode {
+	case cldExited:
+		stats.ExitCode = mainStatus
+	case cldKilled, cldDumped:
+		stats.ExitSignal = unix.SignalName(syscall.Signal(mainStatus))
+		if stats.ExitSignal == "" {
+			stats.ExitSignal = strconv.Itoa(mainStatus)
+		}
+	}
+	return stats, nil
+}
+
 func (s *systemd) Status(unitNames []string) ([]*UnitStatus, error) {
 	if s.mode == GlobalUserMode {
 		return s.getGlobalUserStatus(unitNames...)
@@ -962,7 +1175,7 @@ func (s *systemd) Status(unitNames []string) ([]*UnitStatus, error) {
 	for _, name := range unitNames {
 		// Group units with the same query string together to
 		// optimize the number of 'systemctl' invocations.
-		if strings.HasSuffix(name, ".timer") || strings.HasSuffix(name, ".socket") || strings.HasSuffix(name, ".target") {
+		if strings.HasSuffix(name, ".timer") || strings.HasSuffix(name, ".socket") || strings.HasSuffix(name, ".target") || strings.HasSuffix(name, ".path") {
 			// Units using the baseProperties query
 			limitedUnits = append(limitedUnits, name)
 		} else {
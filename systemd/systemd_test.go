@@ -164,7 +164,7 @@ func (s *SystemdTestSuite) myRun(args ...string) (out []byte, delay time.Duratio
 	return out, delayReq, err
 }
 
-func (s *SystemdTestSuite) myJctl(svcs []string, n int, follow, namespaces bool) (io.ReadCloser, error) {
+func (s *SystemdTestSuite) myJctl(svcs []string, n int, follow, namespaces bool, filter *LogFilter) (io.ReadCloser, error) {
 	var err error
 	var out []byte
 
@@ -938,7 +938,7 @@ func (s *SystemdTestSuite) TestKill(c *C) {
 func (s *SystemdTestSuite) TestLogErrJctl(c *C) {
 	s.jerrs = []error{errors.New("mock journalctl error")}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false, nil)
 	c.Check(err, NotNil)
 	c.Check(reader, IsNil)
 	c.Check(s.jns, DeepEquals, []string{"24"})
@@ -954,7 +954,7 @@ func (s *SystemdTestSuite) TestLogs(c *C) {
 `
 	s.jouts = [][]byte{[]byte(expected)}
 
-	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false)
+	reader, err := New(SystemMode, s.rep).LogReader([]string{"foo"}, 24, false, false, nil)
 	c.Check(err, IsNil)
 	logs, err := io.ReadAll(reader)
 	c.Assert(err, IsNil)
@@ -1727,20 +1727,59 @@ func (s *SystemdTestSuite) TestJctl(c *C) {
 		return nil, nil
 	})
 
-	_, err = Jctl([]string{"foo", "bar"}, 10, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, 10, false, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar", "baz"}, 99, true, false)
+	_, err = Jctl([]string{"foo", "bar", "baz"}, 99, true, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "99", "-f", "-u", "foo", "-u", "bar", "-u", "baz"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, false)
+	_, err = Jctl([]string{"foo", "bar"}, -1, false, false, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "-u", "foo", "-u", "bar"})
-	_, err = Jctl([]string{"foo", "bar"}, -1, false, true)
+	_, err = Jctl([]string{"foo", "bar"}, -1, false, true, nil)
 	c.Assert(err, IsNil)
 	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "--namespace=*", "-u", "foo", "-u", "bar"})
 }
 
+func (s *SystemdTestSuite) TestJctlFilter(c *C) {
+	var args []string
+	var err error
+	MockOsutilStreamCommand(func(name string, myargs ...string) (io.ReadCloser, error) {
+		c.Check(cap(myargs) <= len(myargs)+3, Equals, true, Commentf("cap:%d, len:%d", cap(myargs), len(myargs)))
+		args = myargs
+		return nil, nil
+	})
+
+	filter := &LogFilter{
+		Since:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Priority: "warning",
+		Grep:     "fail(ed|ure)",
+		Boot:     "-1",
+	}
+	_, err = Jctl([]string{"foo", "bar"}, 10, false, true, filter)
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10", "--namespace=*",
+		"--since=@1767225600", "--until=@1767312000", "--priority=warning", "--grep=fail(ed|ure)", "--boot=-1",
+		"-u", "foo", "-u", "bar"})
+
+	// user services are matched on the unit fields, system services are
+	// matched like -u does
+	_, err = Jctl([]string{"foo", "bar"}, 10, false, false, &LogFilter{UserServices: []string{"baz"}})
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "-n", "10",
+		"_SYSTEMD_UNIT=foo", "_SYSTEMD_UNIT=bar", "+",
+		"MESSAGE_ID=fc2e22bc6ee647b6b90729ab34a250b1", "_UID=0", "COREDUMP_UNIT=foo", "COREDUMP_UNIT=bar", "+",
+		"_PID=1", "UNIT=foo", "UNIT=bar", "+",
+		"_UID=0", "OBJECT_SYSTEMD_UNIT=foo", "OBJECT_SYSTEMD_UNIT=bar", "+",
+		"_SYSTEMD_USER_UNIT=baz"})
+
+	_, err = Jctl(nil, -1, true, false, &LogFilter{UserServices: []string{"baz", "quux"}})
+	c.Assert(err, IsNil)
+	c.Check(args, DeepEquals, []string{"-o", "json", "--no-pager", "--no-tail", "-f",
+		"_SYSTEMD_USER_UNIT=baz", "_SYSTEMD_USER_UNIT=quux"})
+}
+
 func (s *SystemdTestSuite) TestIsActiveUnderRoot(c *C) {
 	sysErr := &Error{}
 	// manpage states that systemctl returns exit code 3 for inactive
@@ -2539,6 +2578,124 @@ func (s *SystemdTestSuite) TestInactiveEnterTimestampFailure(c *C) {
 	c.Check(stamp.IsZero(), Equals, true)
 }
 
+func (s *SystemdTestSuite) TestServiceRestartStatsExited(c *C) {
+	s.outs = [][]byte{
+		[]byte(`ActiveState=failed
+NRestarts=7
+ExecMainCode=1
+ExecMainStatus=3
+ExecMainExitTimestamp=Fri 2021-04-16 15:32:21 UTC
+`),
+	}
+	stats, err := New(SystemMode, s.rep).ServiceRestartStats([]string{"foo.service"})
+	c.Assert(err, IsNil)
+	c.Check(s.argses, DeepEquals, [][]string{
+		{"show", "--property=ActiveState,NRestarts,ExecMainCode,ExecMainStatus,ExecMainExitTimestamp", "foo.service"},
+	})
+	c.Check(stats, DeepEquals, []*ServiceRestartStats{{
+		Failed:   true,
+		Restarts: 7,
+		ExitCode: 3,
+		ExitTime: time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC),
+	}})
+}
+
+func (s *SystemdTestSuite) TestServiceRestartStatsMany(c *C) {
+	s.outs = [][]byte{
+		[]byte(`ActiveState=failed
+NRestarts=7
+ExecMainCode=1
+ExecMainStatus=3
+ExecMainExitTimestamp=Fri 2021-04-16 15:32:21 UTC
+
+ActiveState=active
+NRestarts=0
+ExecMainCode=0
+ExecMainStatus=0
+ExecMainExitTimestamp=
+`),
+	}
+	stats, err := New(SystemMode, s.rep).ServiceRestartStats([]string{"foo.service", "bar.service"})
+	c.Assert(err, IsNil)
+	c.Check(s.argses, DeepEquals, [][]string{
+		{"show", "--property=ActiveState,NRestarts,ExecMainCode,ExecMainStatus,ExecMainExitTimestamp", "foo.service", "bar.service"},
+	})
+	c.Check(stats, DeepEquals, []*ServiceRestartStats{{
+		Failed:   true,
+		Restarts: 7,
+		ExitCode: 3,
+		ExitTime: time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC),
+	}, {}})
+
+	// nothing to do without services
+	s.argses = nil
+	stats, err = New(SystemMode, s.rep).ServiceRestartStats(nil)
+	c.Assert(err, IsNil)
+	c.Check(stats, HasLen, 0)
+	c.Check(s.argses, HasLen, 0)
+}
+
+func (s *SystemdTestSuite) TestServiceRestartStatsKilled(c *C) {
+	// killed, and killed with a core dump
+	for _, code := range []string{"2", "3"} {
+		s.outs = append(s.outs, []byte(fmt.Sprintf(`ActiveState=activating
+NRestarts=1
+ExecMainCode=%s
+ExecMainStatus=11
+ExecMainExitTimestamp=Fri 2021-04-16 15:32:21 UTC
+`, code)))
+	}
+	sysd := New(SystemMode, s.rep)
+	for i := 0; i < len(s.outs); i++ {
+		stats, err := sysd.ServiceRestartStats([]string{"foo.service"})
+		c.Assert(err, IsNil)
+		c.Check(stats, DeepEquals, []*ServiceRestartStats{{
+			Restarts:   1,
+			ExitSignal: "SIGSEGV",
+			ExitTime:   time.Date(2021, time.April, 16, 15, 32, 21, 0, time.UTC),
+		}})
+	}
+}
+
+func (s *SystemdTestSuite) TestServiceRestartStatsNeverExited(c *C) {
+	// NRestarts is not known to older versions of systemd
+	s.outs = [][]byte{
+		[]byte(`ActiveState=active
+NRestarts=
+ExecMainCode=0
+ExecMainStatus=0
+ExecMainExitTimestamp=
+`),
+	}
+	stats, err := New(SystemMode, s.rep).ServiceRestartStats([]string{"foo.service"})
+	c.Assert(err, IsNil)
+	c.Check(stats, DeepEquals, []*ServiceRestartStats{{}})
+}
+
+func (s *SystemdTestSuite) TestServiceRestartStatsErrors(c *C) {
+	s.outs = [][]byte{
+		[]byte("garbage"),
+		[]byte("NRestarts=many"),
+		[]byte("ExecMainExitTimestamp=yesterday"),
+		[]byte("Foo=bar"),
+		[]byte("ActiveState=active\n\nActiveState=active"),
+		[]byte("mocked failure"),
+	}
+	s.errors = []error{nil, nil, nil, nil, nil, fmt.Errorf("mocked failure")}
+	sysd := New(SystemMode, s.rep)
+	for _, errMsg := range []string{
+		`cannot get restart statistics of service "foo.service": bad line "garbage" in ‘systemctl show’ output`,
+		`cannot get restart statistics of service "foo.service": invalid value "many" for NRestarts`,
+		`cannot get restart statistics of service "foo.service": invalid value "yesterday" for ExecMainExitTimestamp`,
+		`cannot get restart statistics of service "foo.service": unexpected field "Foo" in ‘systemctl show’ output`,
+		`cannot get restart statistics of services: expected 1 results, got 2`,
+		"mocked failure",
+	} {
+		_, err := sysd.ServiceRestartStats([]string{"foo.service"})
+		c.Check(err, ErrorMatches, errMsg)
+	}
+}
+
 func (s *SystemdTestSuite) TestInactiveEnterTimestampMalformed(c *C) {
 	s.outs = [][]byte{
 		[]byte(`InactiveEnterTimestamp`),
@@ -53,6 +53,14 @@ func MockKillWait(wait time.Duration) (restore func()) {
 	}
 }
 
+func MockDrainPollInterval(interval time.Duration) (restore func()) {
+	oldDrainPollInterval := drainPollInterval
+	drainPollInterval = interval
+	return func() {
+		drainPollInterval = oldDrainPollInterval
+	}
+}
+
 func MockEnsureDirState(f func(dir string, glob string, content map[string]osutil.FileState) (changed, removed []string, err error)) (restore func()) {
 	oldEnsureDirState := ensureDirState
 	ensureDirState = f
@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package internal
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/systemd"
+)
+
+// pathDirectives maps the path conditions to the systemd.path(5) directives.
+var pathDirectives = map[snap.PathCondition]string{
+	snap.PathExists:        "PathExists",
+	snap.PathChanged:       "PathChanged",
+	snap.DirectoryNotEmpty: "DirectoryNotEmpty",
+}
+
+func renderPath(path *snap.PathInfo) string {
+	s := path.App.Snap
+	p := path.Path
+	switch path.App.DaemonScope {
+	case snap.SystemDaemon:
+		p = strings.Replace(p, "$SNAP_DATA", s.DataDir(), -1)
+		p = strings.Replace(p, "$SNAP_COMMON", s.CommonDataDir(), -1)
+	case snap.UserDaemon:
+		// TODO: use SnapDirOpts here, like for sockets
+		p = strings.Replace(p, "$SNAP_USER_DATA", s.UserDataDir("%h", nil), -1)
+		p = strings.Replace(p, "$SNAP_USER_COMMON", s.UserCommonDataDir("%h", nil), -1)
+	default:
+		panic("unknown snap.DaemonScope")
+	}
+	return p
+}
+
+func generateSnapServicePathUnitFile(appInfo *snap.AppInfo, pathName string) []byte {
+	pathTemplate := `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path {{.PathName}} for snap application {{.App.Snap.InstanceName}}.{{.App.Name}}
+{{- if .MountUnit}}
+Requires={{.MountUnit}}
+After={{.MountUnit}}
+{{- end}}
+X-Snappy=yes
+
+[Path]
+Unit={{.ServiceFileName}}
+{{.Directive}}={{.Path}}
+{{- if .MakeDirectory}}
+MakeDirectory=yes
+{{- end}}
+
+[Install]
+WantedBy={{.PathsTarget}}
+`
+	var templateOut bytes.Buffer
+	t := template.Must(template.New("path-wrapper").Parse(pathTemplate))
+
+	path := appInfo.ActivatesOnPath[pathName]
+	wrapperData := struct {
+		App             *snap.AppInfo
+		ServiceFileName string
+		PathsTarget     string
+		MountUnit       string
+		PathName        string
+		Directive       string
+		Path            string
+		MakeDirectory   bool
+	}{
+		App:             appInfo,
+		ServiceFileName: filepath.Base(appInfo.ServiceFile()),
+		PathsTarget:     systemd.PathsTarget,
+		PathName:        pathName,
+		Directive:       pathDirectives[path.Condition],
+		Path:            renderPath(path),
+		// the watched directory may not exist yet on a fresh install
+		MakeDirectory: path.Condition == snap.DirectoryNotEmpty,
+	}
+	switch appInfo.DaemonScope {
+	case snap.SystemDaemon:
+		wrapperData.MountUnit = filepath.Base(systemd.MountUnitPath(appInfo.Snap.MountDir()))
+	case snap.UserDaemon:
+		// nothing
+	default:
+		panic("unknown snap.DaemonScope")
+	}
+
+	if err := t.Execute(&templateOut, wrapperData); err != nil {
+		// this can never happen, except we forget a variable
+		logger.Panicf("Unable to execute template: %v", err)
+	}
+
+	return templateOut.Bytes()
+}
+
+// GenerateSnapPathUnitFiles returns the content of the .path units activating
+// the given app, by path name.
+func GenerateSnapPathUnitFiles(app *snap.AppInfo) (map[string][]byte, error) {
+	if err := snap.ValidateApp(app); err != nil {
+		return nil, err
+	}
+
+	pathFiles := make(map[string][]byte)
+	for name := range app.ActivatesOnPath {
+		pathFiles[name] = generateSnapServicePathUnitFile(app, name)
+	}
+	return pathFiles, nil
+}
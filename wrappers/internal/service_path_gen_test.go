@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package internal_test
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/wrappers/internal"
+)
+
+type servicePathUnitGenSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&servicePathUnitGenSuite{})
+
+func (s *servicePathUnitGenSuite) TestGenerateSnapServiceWithPaths(c *C) {
+	const spoolExpectedFmt = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path spool for snap application some-snap.app
+Requires=%s-some\x2dsnap-44.mount
+After=%s-some\x2dsnap-44.mount
+X-Snappy=yes
+
+[Path]
+Unit=snap.some-snap.app.service
+DirectoryNotEmpty=%s/spool
+MakeDirectory=yes
+
+[Install]
+WantedBy=paths.target
+`
+	const flagExpectedFmt = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path flag for snap application some-snap.app
+Requires=%s-some\x2dsnap-44.mount
+After=%s-some\x2dsnap-44.mount
+X-Snappy=yes
+
+[Path]
+Unit=snap.some-snap.app.service
+PathExists=%s/flag
+
+[Install]
+WantedBy=paths.target
+`
+
+	si := &snap.Info{
+		SuggestedName: "some-snap",
+		Version:       "1.0",
+		SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+	}
+	service := &snap.AppInfo{
+		Snap:        si,
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.SystemDaemon,
+		ActivatesOnPath: map[string]*snap.PathInfo{
+			"spool": {
+				Name:      "spool",
+				Condition: snap.DirectoryNotEmpty,
+				Path:      "$SNAP_COMMON/spool",
+			},
+			"flag": {
+				Name:      "flag",
+				Condition: snap.PathExists,
+				Path:      "$SNAP_DATA/flag",
+			},
+		},
+	}
+	service.ActivatesOnPath["spool"].App = service
+	service.ActivatesOnPath["flag"].App = service
+
+	generatedPaths, err := internal.GenerateSnapPathUnitFiles(service)
+	c.Assert(err, IsNil)
+	c.Check(generatedPaths, DeepEquals, map[string][]byte{
+		"spool": []byte(fmt.Sprintf(spoolExpectedFmt, mountUnitPrefix, mountUnitPrefix, si.CommonDataDir())),
+		"flag":  []byte(fmt.Sprintf(flagExpectedFmt, mountUnitPrefix, mountUnitPrefix, si.DataDir())),
+	})
+}
+
+func (s *servicePathUnitGenSuite) TestGenerateSnapUserServiceWithPaths(c *C) {
+	si := &snap.Info{
+		SuggestedName: "some-snap",
+		Version:       "1.0",
+		SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+	}
+	service := &snap.AppInfo{
+		Snap:        si,
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.UserDaemon,
+		ActivatesOnPath: map[string]*snap.PathInfo{
+			"config": {
+				Name:      "config",
+				Condition: snap.PathChanged,
+				Path:      "$SNAP_USER_COMMON/config",
+			},
+		},
+	}
+	service.ActivatesOnPath["config"].App = service
+
+	generatedPaths, err := internal.GenerateSnapPathUnitFiles(service)
+	c.Assert(err, IsNil)
+	c.Check(string(generatedPaths["config"]), Equals, `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path config for snap application some-snap.app
+X-Snappy=yes
+
+[Path]
+Unit=snap.some-snap.app.service
+PathChanged=%h/snap/some-snap/common/config
+
+[Install]
+WantedBy=paths.target
+`)
+}
+
+func (s *servicePathUnitGenSuite) TestGenerateSnapPathUnitFilesInvalid(c *C) {
+	service := &snap.AppInfo{
+		Snap:        &snap.Info{SuggestedName: "some-snap"},
+		Name:        "app",
+		Daemon:      "simple",
+		DaemonScope: snap.SystemDaemon,
+		ActivatesOnPath: map[string]*snap.PathInfo{
+			"etc": {
+				Name:      "etc",
+				Condition: snap.PathChanged,
+				Path:      "/etc/passwd",
+			},
+		},
+	}
+	service.ActivatesOnPath["etc"].App = service
+
+	_, err := internal.GenerateSnapPathUnitFiles(service)
+	c.Assert(err, ErrorMatches, `invalid definition of activates-on-path "etc": .*`)
+}
@@ -251,6 +251,14 @@ func SnapServiceUnits(app *snap.AppInfo) (service string, activators []string) {
 	// Sort the results from sockets for consistency
 	sort.Strings(activators)
 
+	// Add application paths, sorted as well
+	paths := make([]string, 0, len(app.ActivatesOnPath))
+	for _, path := range app.ActivatesOnPath {
+		paths = append(paths, filepath.Base(path.File()))
+	}
+	sort.Strings(paths)
+	activators = append(activators, paths...)
+
 	// Add application timer
 	if app.Timer != nil {
 		activators = append(activators, filepath.Base(app.Timer.File()))
@@ -583,6 +583,11 @@ apps:
        listen-stream: $SNAP_DATA/sock1.socket
       sock2:
        listen-stream: $SNAP_DATA/sock2.socket
+    activates-on-path:
+      spool:
+        directory-not-empty: $SNAP_USER_COMMON/spool
+      config:
+        path-changed: $SNAP_USER_DATA/config
 `
 	info := snaptest.MockSnap(c, surviveYaml, &snap.SideInfo{Revision: snap.R(1)})
 
@@ -591,10 +596,13 @@ apps:
 
 	// The activators must appear the in following order:
 	// Sockets, sorted
+	// Paths, sorted
 	// Timer unit
 	c.Check(activators, DeepEquals, []string{
 		"snap.test-snap.foo.sock1.socket",
 		"snap.test-snap.foo.sock2.socket",
+		"snap.test-snap.foo.config.path",
+		"snap.test-snap.foo.spool.path",
 		"snap.test-snap.foo.timer",
 	})
 }
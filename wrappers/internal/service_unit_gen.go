@@ -49,6 +49,13 @@ type SnapServicesUnitOptions struct {
 	// CoreMountedSnapdSnapDep is whether the generated unit should depend on
 	// the provided snapd snapd being mounted
 	CoreMountedSnapdSnapDep string
+
+	// ExternalAfter lists units of other snaps the service is ordered after.
+	ExternalAfter []string
+
+	// ExternalWants lists units of other snaps started along with the
+	// service.
+	ExternalWants []string
 }
 
 func serviceStopTimeout(app *snap.AppInfo) time.Duration {
@@ -120,6 +127,9 @@ Requires={{.MountUnit}}
 {{- if .PrerequisiteTarget}}
 Wants={{.PrerequisiteTarget}}
 {{- end}}
+{{- if .ExternalWants}}
+Wants={{ stringsJoin .ExternalWants " " }}
+{{- end}}
 {{- if .After}}
 After={{ stringsJoin .After " " }}
 {{- end}}
@@ -184,7 +194,7 @@ Slice={{.SliceUnit}}
 {{- if .LogNamespace}}
 LogNamespace={{.LogNamespace}}
 {{- end}}
-{{- if not (or .App.Sockets .App.Timer .App.ActivatesOn) }}
+{{- if not (or .App.Sockets .App.Timer .App.ActivatesOn .App.ActivatesOnPath) }}
 
 [Install]
 WantedBy={{.ServicesTarget}}
@@ -254,6 +264,7 @@ WantedBy={{.ServicesTarget}}
 		BusName                  string
 		Before                   []string
 		After                    []string
+		ExternalWants            []string
 		InterfaceServiceSnippets string
 		SliceUnit                string
 		LogNamespace             string
@@ -276,8 +287,9 @@ WantedBy={{.ServicesTarget}}
 		OOMAdjustScore: oomAdjustScore,
 		BusName:        busName,
 
-		Before: generateServiceNames(appInfo.Snap, appInfo.Before),
-		After:  generateServiceNames(appInfo.Snap, appInfo.After),
+		Before:        generateServiceNames(appInfo.Snap, appInfo.Before),
+		After:         generateServiceNames(appInfo.Snap, appInfo.After),
+		ExternalWants: opts.ExternalWants,
 
 		// systemd runs as PID 1 so %h will not work.
 		Home: "/root",
@@ -306,6 +318,8 @@ WantedBy={{.ServicesTarget}}
 		}
 	}
 
+	wrapperData.After = append(wrapperData.After, opts.ExternalAfter...)
+
 	// Add extra "After" targets
 	if wrapperData.PrerequisiteTarget != "" {
 		wrapperData.After = append([]string{wrapperData.PrerequisiteTarget}, wrapperData.After...)
@@ -573,6 +573,48 @@ WantedBy=multi-user.target
 	}
 }
 
+func (s *serviceUnitGenSuite) TestServiceExternalDependencies(c *C) {
+	service := &snap.AppInfo{
+		Snap: &snap.Info{
+			SuggestedName: "snap",
+			Version:       "0.3.4",
+			SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+		},
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.SystemDaemon,
+	}
+
+	generatedWrapper, err := internal.GenerateSnapServiceUnitFile(service, &internal.SnapServicesUnitOptions{
+		ExternalAfter: []string{"snap.db.postgres.service", "snap.db.pgbouncer.service"},
+		ExternalWants: []string{"snap.db.postgres.service"},
+	})
+	c.Assert(err, IsNil)
+
+	c.Check(string(generatedWrapper), Equals, fmt.Sprintf(`[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Service for snap application snap.app
+Requires=%s-snap-44.mount
+Wants=network.target
+Wants=snap.db.postgres.service
+After=%s-snap-44.mount network.target snapd.apparmor.service snap.db.postgres.service snap.db.pgbouncer.service
+X-Snappy=yes
+
+[Service]
+EnvironmentFile=-/etc/environment
+ExecStart=/usr/bin/snap run snap.app
+SyslogIdentifier=snap.app
+Restart=on-failure
+WorkingDirectory=/var/snap/snap/44
+TimeoutStopSec=30
+Type=simple
+
+[Install]
+WantedBy=multi-user.target
+`, mountUnitPrefix, mountUnitPrefix))
+}
+
 func (s *serviceUnitGenSuite) TestKillModeSig(c *C) {
 	for _, rm := range []string{"sigterm", "sighup", "sigusr1", "sigusr2", "sigint"} {
 		service := &snap.AppInfo{
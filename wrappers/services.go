@@ -73,6 +73,19 @@ type Interacter interface {
 // wait this time between TERM and KILL
 var killWait = 5 * time.Second
 
+// drainNotifySignal is the signal notifying the services with the "drain"
+// refresh strategy that they are about to be stopped for a refresh. Its
+// default action terminates the services which do not handle it.
+const drainNotifySignal = "USR1"
+
+var (
+	// time given to the services with the "drain" refresh strategy to
+	// exit on their own when they do not declare a drain-timeout
+	defaultDrainTimeout = 30 * time.Second
+	// how often to check whether a draining service has exited
+	drainPollInterval = 500 * time.Millisecond
+)
+
 // ScopeOptions provides ways to limit the effects of service operations
 // to a certain scope, including which users and service type.
 type ScopeOptions struct {
@@ -178,7 +191,7 @@ func reloadOrRestartServices(sysd systemd.Systemd, cli *userServiceClient, reloa
 }
 
 func serviceIsActivated(app *snap.AppInfo) bool {
-	return len(app.Sockets) > 0 || app.Timer != nil || len(app.ActivatesOn) > 0
+	return len(app.Sockets) > 0 || app.Timer != nil || len(app.ActivatesOn) > 0 || len(app.ActivatesOnPath) > 0
 }
 
 func serviceIsSlotActivated(app *snap.AppInfo) bool {
@@ -445,6 +458,19 @@ type SnapServiceOptions struct {
 
 	// QuotaGroup is the quota group for the specified snap.
 	QuotaGroup *quota.Group
+
+	// ServiceDependencies are the dependencies of the services of the
+	// specified snap on units of other snaps, keyed by app name.
+	ServiceDependencies map[string]*ServiceDependencies
+}
+
+// ServiceDependencies are the dependencies of a service on units of other
+// snaps.
+type ServiceDependencies struct {
+	// After lists the units the service is ordered after.
+	After []string
+	// Wants lists the units started along with the service.
+	Wants []string
 }
 
 // ObserveChangeCallback can be invoked by EnsureSnapServices to observe
@@ -598,6 +624,11 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 		svcQuotaMap = opts.QuotaGroup.ServiceMap()
 	}
 
+	var svcDeps map[string]*ServiceDependencies
+	if snapSvcOpts := es.snaps[snapInfo]; snapSvcOpts != nil {
+		svcDeps = snapSvcOpts.ServiceDependencies
+	}
+
 	// note that the Preseeding option is not used here at all
 	for _, svc := range services {
 		// if an inclusion list is provided, then we want to make sure this service
@@ -624,11 +655,16 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 
 		// Generate new service file state, make an app-specific generateSnapServicesOptions
 		// to avoid modifying the original copy, if we were to override the quota group.
-		content, err := internal.GenerateSnapServiceUnitFile(svc, &internal.SnapServicesUnitOptions{
+		genOpts := &internal.SnapServicesUnitOptions{
 			QuotaGroup:              quotaGrp,
 			VitalityRank:            opts.VitalityRank,
 			CoreMountedSnapdSnapDep: opts.CoreMountedSnapdSnapDep,
-		})
+		}
+		if deps := svcDeps[svc.Name]; deps != nil {
+			genOpts.ExternalAfter = deps.After
+			genOpts.ExternalWants = deps.Wants
+		}
+		content, err := internal.GenerateSnapServiceUnitFile(svc, genOpts)
 		if err != nil {
 			return err
 		}
@@ -650,6 +686,18 @@ func (es *ensureSnapServicesContext) ensureSnapServiceSystemdUnits(snapInfo *sna
 			}
 		}
 
+		// Generate systemd .path files if needed
+		pathFiles, err := internal.GenerateSnapPathUnitFiles(svc)
+		if err != nil {
+			return err
+		}
+		for name, content := range pathFiles {
+			path := svc.ActivatesOnPath[name].File()
+			if err := handleFileModification(svc, "path", name, path, content); err != nil {
+				return err
+			}
+		}
+
 		if svc.Timer != nil {
 			content, err := internal.GenerateSnapServiceTimerUnitFile(svc)
 			if err != nil {
@@ -967,6 +1015,42 @@ func filterAppsForStop(apps []*snap.AppInfo, reason snap.ServiceStopReason, opts
 	return sys, usr
 }
 
+// drainService notifies the service that it is about to be stopped for a
+// refresh and gives it until its drain timeout to finish the work in progress
+// and exit on its own. Whatever happens, the service is stopped afterwards so
+// failures are only logged.
+func drainService(sysd systemd.Systemd, app *snap.AppInfo) {
+	svc := app.ServiceName()
+	isActive := func() bool {
+		sts, err := sysd.Status([]string{svc})
+		if err != nil || len(sts) != 1 {
+			logger.Noticef("cannot get status of service %q while draining it: %v", svc, err)
+			return false
+		}
+		return sts[0].Active
+	}
+
+	if !isActive() {
+		return
+	}
+	if err := sysd.Kill(svc, drainNotifySignal, "main"); err != nil {
+		logger.Noticef("cannot notify service %q that it is about to be stopped: %v", svc, err)
+		return
+	}
+
+	drainTimeout := time.Duration(app.DrainTimeout)
+	if drainTimeout == 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	for deadline := time.Now().Add(drainTimeout); time.Now().Before(deadline); {
+		time.Sleep(drainPollInterval)
+		if !isActive() {
+			return
+		}
+	}
+	logger.Noticef("service %q did not exit within its drain timeout of %v", svc, drainTimeout)
+}
+
 // StopServicesOptions carries additional parameters for StopServices.
 type StopServicesOptions struct {
 	Disable bool
@@ -1002,6 +1086,25 @@ func StopServices(apps []*snap.AppInfo, opts *StopServicesOptions, reason snap.S
 	systemServices := serviceUnitsFromApps(sysApps, includeActivatedServices)
 	userServices := serviceUnitsFromApps(userApps, includeActivatedServices)
 
+	// services which want to finish their work before being stopped for a
+	// refresh, by service unit name
+	drainApps := make(map[string]*snap.AppInfo)
+	// sockets of services handing them over to the new revision, which keep
+	// listening through the refresh
+	keepListening := make(map[string]bool)
+	if reason == snap.StopReasonRefresh {
+		for _, app := range sysApps {
+			switch app.RefreshStrategy {
+			case "drain":
+				drainApps[app.ServiceName()] = app
+			case "rolling":
+				for _, sock := range app.Sockets {
+					keepListening[filepath.Base(sock.File())] = true
+				}
+			}
+		}
+	}
+
 	// Save any potentionally expensive calls if there is no need
 	if len(userServices) != 0 {
 		timings.Run(tm, "stop-user-services", "stop user services", func(nested timings.Measurer) {
@@ -1014,6 +1117,14 @@ func StopServices(apps []*snap.AppInfo, opts *StopServicesOptions, reason snap.S
 
 	timings.Run(tm, "stop-services", "stop services", func(nestedTm timings.Measurer) {
 		for _, srv := range systemServices {
+			if keepListening[srv] {
+				continue
+			}
+			if app, ok := drainApps[srv]; ok {
+				timings.Run(nestedTm, "drain-service", fmt.Sprintf("drain service %q", srv), func(_ timings.Measurer) {
+					drainService(sysd, app)
+				})
+			}
 			timings.Run(nestedTm, "stop-service", fmt.Sprintf("stop service %q", srv), func(_ timings.Measurer) {
 				err = sysd.Stop([]string{srv})
 			})
@@ -1135,6 +1246,19 @@ func RemoveSnapServices(s *snap.Info, inter Interacter) error {
 			systemUnitFiles = append(systemUnitFiles, path)
 		}
 
+		for _, path := range app.ActivatesOnPath {
+			pathFile := path.File()
+			pathUnitName := filepath.Base(pathFile)
+			logger.Noticef("RemoveSnapServices - path %s", pathUnitName)
+			switch app.DaemonScope {
+			case snap.SystemDaemon:
+				systemUnits = append(systemUnits, pathUnitName)
+			case snap.UserDaemon:
+				userUnits = append(userUnits, pathUnitName)
+			}
+			systemUnitFiles = append(systemUnitFiles, pathFile)
+		}
+
 		if app.Timer != nil {
 			path := app.Timer.File()
 
@@ -1332,6 +1456,44 @@ func RestartServices(apps []*snap.AppInfo, explicitServices []string,
 	return nil
 }
 
+// CheckRollingServices checks that the sockets of the services with the
+// "rolling" refresh strategy are still listening once their snap was
+// refreshed. The sockets are kept open while the services are stopped, the
+// connections received in the meantime are queued and served by the current
+// revision of the services, which it is activated for.
+func CheckRollingServices(apps []*snap.AppInfo, inter Interacter, tm timings.Measurer) error {
+	sysd := systemd.New(systemd.SystemMode, inter)
+
+	var sockets []string
+	for _, app := range apps {
+		if !app.IsService() || app.RefreshStrategy != "rolling" || app.DaemonScope != snap.SystemDaemon {
+			continue
+		}
+		for _, sock := range app.Sockets {
+			sockets = append(sockets, filepath.Base(sock.File()))
+		}
+	}
+	if len(sockets) == 0 {
+		return nil
+	}
+	sort.Strings(sockets)
+
+	var sts []*systemd.UnitStatus
+	var err error
+	timings.Run(tm, "check-rolling-services", "check sockets of rolling services", func(_ timings.Measurer) {
+		sts, err = sysd.Status(sockets)
+	})
+	if err != nil {
+		return err
+	}
+	for _, st := range sts {
+		if !st.Active {
+			return fmt.Errorf("socket %q is not listening after refresh", st.Name)
+		}
+	}
+	return nil
+}
+
 // DisabledServices represents an overview of which services in a snap
 // are currently disabled, both of system services and user services.
 // User services are indexed by the system uid as user services may run
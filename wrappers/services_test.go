@@ -37,6 +37,7 @@ import (
 
 	// imported to ensure actual interfaces are defined (in production this is guaranteed by ifacestate)
 	_ "github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/progress"
@@ -2054,6 +2055,33 @@ WantedBy=multi-user.target
 	})
 }
 
+func (s *servicesTestSuite) TestEnsureSnapServicesWithServiceDependencies(c *C) {
+	info := snaptest.MockSnap(c, packageHello+` svc2:
+  command: bin/hello
+  daemon: simple
+`, &snap.SideInfo{Revision: snap.R(12)})
+	svc1File := filepath.Join(s.tempdir, "/etc/systemd/system/snap.hello-snap.svc1.service")
+	svc2File := filepath.Join(s.tempdir, "/etc/systemd/system/snap.hello-snap.svc2.service")
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {
+			ServiceDependencies: map[string]*wrappers.ServiceDependencies{
+				"svc1": {
+					After: []string{"snap.db.postgres.service"},
+					Wants: []string{"snap.db.postgres.service"},
+				},
+			},
+		},
+	}
+
+	err := wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+
+	c.Check(svc1File, testutil.FileContains, "\nWants=snap.db.postgres.service\n")
+	c.Check(svc1File, testutil.FileContains, " snapd.apparmor.service snap.db.postgres.service\n")
+	c.Check(svc2File, Not(testutil.FileContains), "snap.db.postgres.service")
+}
+
 func (s *servicesTestSuite) TestEnsureSnapServicesAddsNewSvc(c *C) {
 	// map unit -> new
 	seen := make(map[string]bool)
@@ -2784,6 +2812,30 @@ func (s *servicesTestSuite) TestRemoveSnapWithSocketsRemovesSocketsService(c *C)
 	}
 }
 
+func (s *servicesTestSuite) TestRemoveSnapWithPathsRemovesPathUnits(c *C) {
+	info := snaptest.MockSnap(c, packageHelloNoSrv+`
+ svc1:
+  daemon: simple
+  activates-on-path:
+    spool:
+      directory-not-empty: $SNAP_COMMON/spool
+`, &snap.SideInfo{Revision: snap.R(12)})
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+	pathFile := info.Apps["svc1"].ActivatesOnPath["spool"].File()
+	c.Check(pathFile, testutil.FilePresent)
+
+	s.sysdLog = nil
+	err = wrappers.RemoveSnapServices(info, &progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(pathFile, testutil.FileAbsent)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"--no-reload", "disable", "snap.hello-snap.svc1.spool.path", "snap.hello-snap.svc1.service"},
+		{"daemon-reload"},
+	})
+}
+
 func (s *servicesTestSuite) TestRemoveSnapPackageFallbackToKill(c *C) {
 	restore := wrappers.MockKillWait(time.Millisecond)
 	defer restore()
@@ -3920,6 +3972,43 @@ ListenStream=%s
 	c.Check(sock3File, testutil.FileContains, expected)
 }
 
+func (s *servicesTestSuite) TestAddSnapPathFiles(c *C) {
+	info := snaptest.MockSnap(c, packageHelloNoSrv+`
+ svc1:
+  daemon: simple
+  activates-on-path:
+    spool:
+      directory-not-empty: $SNAP_COMMON/spool
+    config:
+      path-changed: $SNAP_DATA/config.yaml
+`, &snap.SideInfo{Revision: snap.R(12)})
+
+	spoolFile := filepath.Join(dirs.GlobalRootDir, "/etc/systemd/system/snap.hello-snap.svc1.spool.path")
+	configFile := filepath.Join(dirs.GlobalRootDir, "/etc/systemd/system/snap.hello-snap.svc1.config.path")
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	c.Check(spoolFile, testutil.FileContains, fmt.Sprintf(`[Path]
+Unit=snap.hello-snap.svc1.service
+DirectoryNotEmpty=%s
+MakeDirectory=yes
+
+[Install]
+WantedBy=paths.target
+`, filepath.Join(dirs.GlobalRootDir, "/var/snap/hello-snap/common/spool")))
+	c.Check(configFile, testutil.FileContains, fmt.Sprintf(`[Path]
+Unit=snap.hello-snap.svc1.service
+PathChanged=%s
+
+[Install]
+WantedBy=paths.target
+`, filepath.Join(dirs.GlobalRootDir, "/var/snap/hello-snap/12/config.yaml")))
+
+	// the service itself is only started by the path units
+	c.Check(info.Apps["svc1"].ServiceFile(), Not(testutil.FileContains), "[Install]")
+}
+
 func (s *servicesTestSuite) TestAddSnapUserSocketFiles(c *C) {
 	info := snaptest.MockSnap(c, packageHelloNoSrv+`
  svc1:
@@ -4394,6 +4483,283 @@ apps:
 	})
 }
 
+func (s *servicesTestSuite) TestStopServiceRollingRefresh(c *C) {
+	const rollingYaml = `name: rolling-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: rolling
+  daemon: simple
+  plugs: [network-bind]
+  sockets:
+    sock:
+      listen-stream: $SNAP_COMMON/sock
+`
+	info := snaptest.MockSnap(c, rollingYaml, &snap.SideInfo{Revision: snap.R(1)})
+	srvFile := "snap.rolling-snap.srv.service"
+	sockFile := "snap.rolling-snap.srv.sock.socket"
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	// the service is stopped but its socket keeps listening through the
+	// refresh
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRefresh, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRemove, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"stop", sockFile},
+		{"show", "--property=ActiveState", sockFile},
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+}
+
+func (s *servicesTestSuite) TestStopServiceDrain(c *C) {
+	r := wrappers.MockDrainPollInterval(time.Millisecond)
+	defer r()
+
+	const drainYaml = `name: drain-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: drain
+  drain-timeout: 10s
+  daemon: simple
+`
+	info := snaptest.MockSnap(c, drainYaml, &snap.SideInfo{Revision: snap.R(1)})
+	srvFile := "snap.drain-snap.srv.service"
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	// the service exits on its own once notified
+	notified := false
+	r = systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		if cmd[0] == "kill" {
+			notified = true
+		}
+		states := map[string]systemdtest.ServiceState{}
+		if notified {
+			states[srvFile] = systemdtest.ServiceState{ActiveState: "inactive", UnitFileState: "enabled"}
+		}
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, states); out != nil {
+			return out, nil
+		}
+		return []byte("ActiveState=inactive\n"), nil
+	})
+	defer r()
+
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRefresh, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"show", "--property=Id,ActiveState,UnitFileState,Type,Names,NeedDaemonReload", srvFile},
+		{"kill", srvFile, "-s", "USR1", "--kill-who=main"},
+		{"show", "--property=Id,ActiveState,UnitFileState,Type,Names,NeedDaemonReload", srvFile},
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+
+	// the service is only notified when it is stopped for a refresh
+	notified = false
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRemove, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+}
+
+func (s *servicesTestSuite) TestStopServiceDrainNoSignalHandler(c *C) {
+	r := wrappers.MockDrainPollInterval(time.Millisecond)
+	defer r()
+
+	const drainYaml = `name: drain-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: drain
+  drain-timeout: 10s
+  daemon: simple
+`
+	info := snaptest.MockSnap(c, drainYaml, &snap.SideInfo{Revision: snap.R(1)})
+	srvFile := "snap.drain-snap.srv.service"
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	// the service does not handle the notification signal, whose default
+	// action terminates it, and systemd considers it failed
+	killed := false
+	r = systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		if cmd[0] == "kill" {
+			killed = true
+		}
+		states := map[string]systemdtest.ServiceState{}
+		if killed {
+			states[srvFile] = systemdtest.ServiceState{ActiveState: "failed", UnitFileState: "enabled"}
+		}
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, states); out != nil {
+			return out, nil
+		}
+		return []byte("ActiveState=failed\n"), nil
+	})
+	defer r()
+
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRefresh, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	// there is no waiting for the drain timeout and the stop goes ahead
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"show", "--property=Id,ActiveState,UnitFileState,Type,Names,NeedDaemonReload", srvFile},
+		{"kill", srvFile, "-s", "USR1", "--kill-who=main"},
+		{"show", "--property=Id,ActiveState,UnitFileState,Type,Names,NeedDaemonReload", srvFile},
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+}
+
+func (s *servicesTestSuite) TestStopServiceDrainTimeout(c *C) {
+	r := wrappers.MockDrainPollInterval(time.Millisecond)
+	defer r()
+	logbuf, r := logger.MockLogger()
+	defer r()
+
+	const drainYaml = `name: drain-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: drain
+  drain-timeout: 5ms
+  daemon: simple
+`
+	info := snaptest.MockSnap(c, drainYaml, &snap.SideInfo{Revision: snap.R(1)})
+	srvFile := "snap.drain-snap.srv.service"
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	// the service never exits on its own
+	r = systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, nil); out != nil {
+			return out, nil
+		}
+		return []byte("ActiveState=inactive\n"), nil
+	})
+	defer r()
+
+	s.sysdLog = nil
+	err = wrappers.StopServices(info.Services(), nil, snap.StopReasonRefresh, progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Assert(len(s.sysdLog) >= 5, Equals, true)
+	c.Check(s.sysdLog[1], DeepEquals, []string{"kill", srvFile, "-s", "USR1", "--kill-who=main"})
+	// and is stopped once the drain timeout has expired
+	c.Check(s.sysdLog[len(s.sysdLog)-2:], DeepEquals, [][]string{
+		{"stop", srvFile},
+		{"show", "--property=ActiveState", srvFile},
+	})
+	c.Check(logbuf.String(), testutil.Contains, `service "snap.drain-snap.srv.service" did not exit within its drain timeout of 5ms`)
+}
+
+func (s *servicesTestSuite) TestCheckRollingServices(c *C) {
+	const rollingYaml = `name: rolling-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: rolling
+  daemon: simple
+  plugs: [network-bind]
+  sockets:
+    sock:
+      listen-stream: $SNAP_COMMON/sock
+    web:
+      listen-stream: 8080
+ other:
+  command: bin/other
+  daemon: simple
+  plugs: [network-bind]
+  sockets:
+    sock:
+      listen-stream: $SNAP_COMMON/other
+`
+	info := snaptest.MockSnap(c, rollingYaml, &snap.SideInfo{Revision: snap.R(1)})
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	r := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, nil); out != nil {
+			return out, nil
+		}
+		return nil, nil
+	})
+	defer r()
+
+	s.sysdLog = nil
+	err = wrappers.CheckRollingServices(info.Services(), progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	// only the sockets of services with the rolling refresh strategy
+	// are checked, nothing is restarted
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"show", "--property=Id,ActiveState,UnitFileState,Names", "snap.rolling-snap.srv.sock.socket", "snap.rolling-snap.srv.web.socket"},
+	})
+}
+
+func (s *servicesTestSuite) TestCheckRollingServicesNotListening(c *C) {
+	const rollingYaml = `name: rolling-snap
+version: 1.0
+apps:
+ srv:
+  command: bin/srv
+  refresh-strategy: rolling
+  daemon: simple
+  plugs: [network-bind]
+  sockets:
+    sock:
+      listen-stream: $SNAP_COMMON/sock
+`
+	info := snaptest.MockSnap(c, rollingYaml, &snap.SideInfo{Revision: snap.R(1)})
+	sockFile := "snap.rolling-snap.srv.sock.socket"
+
+	err := s.addSnapServices(info, false)
+	c.Assert(err, IsNil)
+
+	// the socket failed while the service was being refreshed
+	r := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		states := map[string]systemdtest.ServiceState{
+			sockFile: {ActiveState: "failed", UnitFileState: "enabled"},
+		}
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, states); out != nil {
+			return out, nil
+		}
+		return nil, nil
+	})
+	defer r()
+
+	err = wrappers.CheckRollingServices(info.Services(), progress.Null, s.perfTimings)
+	c.Assert(err, ErrorMatches, `socket "snap.rolling-snap.srv.sock.socket" is not listening after refresh`)
+}
+
 func (s *servicesTestSuite) TestStopServiceSigs(c *C) {
 	r := wrappers.MockKillWait(1 * time.Millisecond)
 	defer r()